@@ -13,16 +13,23 @@ import (
 	"time"
 
 	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/api_keys"
+	"postgresus-backend/internal/features/approvals"
 	"postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups"
+	backups_binlog "postgresus-backend/internal/features/backups/binlog"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/disk"
+	"postgresus-backend/internal/features/encryption/rotation"
 	"postgresus-backend/internal/features/encryption/secrets"
 	healthcheck_attempt "postgresus-backend/internal/features/healthcheck/attempt"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
 	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/reports"
 	"postgresus-backend/internal/features/restores"
+	restores_verifications "postgresus-backend/internal/features/restores/verifications"
 	"postgresus-backend/internal/features/servers"
 	"postgresus-backend/internal/features/storages"
 	system_healthcheck "postgresus-backend/internal/features/system/healthcheck"
@@ -30,6 +37,7 @@ import (
 	users_middleware "postgresus-backend/internal/features/users/middleware"
 	users_services "postgresus-backend/internal/features/users/services"
 	workspaces_controllers "postgresus-backend/internal/features/workspaces/controllers"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	env_utils "postgresus-backend/internal/util/env"
 	files_utils "postgresus-backend/internal/util/files"
 	"postgresus-backend/internal/util/logger"
@@ -67,9 +75,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	err = secrets.GetSecretKeyService().MigrateKeyFromDbToFileIfExist()
+	err = secrets.GetSecretKeyService().MigrateKeyFromDbIfExist()
 	if err != nil {
-		log.Error("Failed to migrate secret key from database to file", "error", err)
+		log.Error("Failed to migrate secret key from database", "error", err)
+		os.Exit(1)
+	}
+
+	// the key is loaded on start, so misconfigured key provider is found
+	// before the first backup
+	if _, err := secrets.GetSecretKeyService().GetSecretKey(); err != nil {
+		log.Error("Failed to load secret key", "error", err)
 		os.Exit(1)
 	}
 
@@ -79,7 +94,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	handlePasswordReset(log)
+	handleCommands(log)
 
 	go generateSwaggerDocs(log)
 
@@ -104,26 +119,54 @@ func main() {
 	startServerWithGracefulShutdown(log, ginApp)
 }
 
-func handlePasswordReset(log *slog.Logger) {
+func handleCommands(log *slog.Logger) {
 	audit_logs.SetupDependencies()
 
 	newPassword := flag.String("new-password", "", "Set a new password for the user")
 	email := flag.String("email", "", "Email of the user to reset password")
+	isRotateMasterKey := flag.Bool(
+		"rotate-master-key",
+		false,
+		"Generate new master key and re-encrypt stored credentials",
+	)
 
 	flag.Parse()
 
-	if *newPassword == "" {
+	if *isRotateMasterKey {
+		rotateMasterKey(log)
+	}
+
+	handlePasswordReset(*newPassword, *email, log)
+}
+
+func handlePasswordReset(newPassword string, email string, log *slog.Logger) {
+	if newPassword == "" {
 		return
 	}
 
 	log.Info("Found reset password command - reseting password...")
 
-	if *email == "" {
+	if email == "" {
 		log.Info("No email provided, please provide an email via --email=\"some@email.com\" flag")
 		os.Exit(1)
 	}
 
-	resetPassword(*email, *newPassword, log)
+	resetPassword(email, newPassword, log)
+}
+
+// rotateMasterKey is run while the server is running as well: the server
+// reloads the keyring and removes retired keys when nothing uses them
+func rotateMasterKey(log *slog.Logger) {
+	log.Info("Rotating master key...")
+
+	keyVersion, err := rotation.GetMasterKeyRotationService().RotateMasterKey()
+	if err != nil {
+		log.Error("Failed to rotate master key", "error", err)
+		os.Exit(1)
+	}
+
+	log.Info("Master key rotated successfully", "version", keyVersion)
+	os.Exit(0)
 }
 
 func resetPassword(email string, newPassword string, log *slog.Logger) {
@@ -257,6 +300,7 @@ func setUpRoutes(r *gin.Engine) {
 	userController.RegisterProtectedRoutes(protected)
 	workspaces_controllers.GetWorkspaceController().RegisterRoutes(protected)
 	workspaces_controllers.GetMembershipController().RegisterRoutes(protected)
+	workspaces_controllers.GetCustomRoleController().RegisterRoutes(protected)
 	disk.GetDiskController().RegisterRoutes(protected)
 	notifiers.GetNotifierController().RegisterRoutes(protected)
 	storages.GetStorageController().RegisterRoutes(protected)
@@ -264,10 +308,14 @@ func setUpRoutes(r *gin.Engine) {
 	databases.GetDatabaseController().RegisterRoutes(protected)
 	backups.GetBackupController().RegisterRoutes(protected)
 	restores.GetRestoreController().RegisterRoutes(protected)
+	restores_verifications.GetVerificationController().RegisterRoutes(protected)
 	healthcheck_config.GetHealthcheckConfigController().RegisterRoutes(protected)
 	healthcheck_attempt.GetHealthcheckAttemptController().RegisterRoutes(protected)
 	backups_config.GetBackupConfigController().RegisterRoutes(protected)
 	audit_logs.GetAuditLogController().RegisterRoutes(protected)
+	api_keys.GetAPIKeyController().RegisterRoutes(protected)
+	approvals.GetApprovalController().RegisterRoutes(protected)
+	reports.GetReportController().RegisterRoutes(protected)
 	users_controllers.GetManagementController().RegisterRoutes(protected)
 	users_controllers.GetSettingsController().RegisterRoutes(protected)
 }
@@ -275,9 +323,14 @@ func setUpRoutes(r *gin.Engine) {
 func setUpDependencies() {
 	databases.SetupDependencies()
 	backups.SetupDependencies()
+	backups_wal.SetupDependencies()
+	backups_binlog.SetupDependencies()
 	restores.SetupDependencies()
+	restores_verifications.SetupDependencies()
 	healthcheck_config.SetupDependencies()
 	audit_logs.SetupDependencies()
+	api_keys.SetupDependencies()
+	workspaces_services.SetupDependencies()
 	notifiers.SetupDependencies()
 	storages.SetupDependencies()
 }
@@ -294,13 +347,49 @@ func runBackgroundTasks(log *slog.Logger) {
 		backups.GetBackupBackgroundService().Run()
 	})
 
+	go runWithPanicLogging(log, "WAL archiving service", func() {
+		backups_wal.GetWalArchivingService().Run()
+	})
+
+	go runWithPanicLogging(log, "binlog archiving service", func() {
+		backups_binlog.GetBinlogArchivingService().Run()
+	})
+
 	go runWithPanicLogging(log, "restore background service", func() {
 		restores.GetRestoreBackgroundService().Run()
 	})
 
+	go runWithPanicLogging(log, "backup verification background service", func() {
+		restores_verifications.GetVerificationBackgroundService().Run()
+	})
+
 	go runWithPanicLogging(log, "healthcheck attempt background service", func() {
 		healthcheck_attempt.GetHealthcheckAttemptBackgroundService().Run()
 	})
+
+	go runWithPanicLogging(log, "LDAP sync background service", func() {
+		users_services.GetLDAPSyncBackgroundService().Run()
+	})
+
+	go runWithPanicLogging(log, "approval background service", func() {
+		approvals.GetApprovalBackgroundService().Run()
+	})
+
+	go runWithPanicLogging(log, "audit log forwarder", func() {
+		audit_logs.GetAuditLogForwarder().Run()
+	})
+
+	go runWithPanicLogging(log, "notification delivery background service", func() {
+		notifiers.GetNotificationDeliveryBackgroundService().Run()
+	})
+
+	go runWithPanicLogging(log, "master key rotation background service", func() {
+		rotation.GetMasterKeyRotationBackgroundService().Run()
+	})
+
+	go runWithPanicLogging(log, "workspace report background service", func() {
+		reports.GetReportBackgroundService().Run()
+	})
 }
 
 func runWithPanicLogging(log *slog.Logger, serviceName string, fn func()) {
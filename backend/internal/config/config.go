@@ -38,6 +38,31 @@ type EnvVariables struct {
 	DataFolder    string
 	TempFolder    string
 	SecretKeyPath string
+	// master key encrypted by Vault or KMS, used instead of SecretKeyPath
+	WrappedSecretKeyPath string
+
+	// where the master key is kept: "file" (default), "env", "vault" or "http"
+	SecretKeyProvider string `env:"SECRET_KEY_PROVIDER"`
+	// master key for "env" provider, at least 32 characters
+	SecretKey string `env:"SECRET_KEY"`
+	// HashiCorp Vault Transit, the master key is encrypted by the Vault key
+	VaultAddress        string `env:"VAULT_ADDR"`
+	VaultToken          string `env:"VAULT_TOKEN"`
+	VaultNamespace      string `env:"VAULT_NAMESPACE"`
+	VaultTransitMount   string `env:"VAULT_TRANSIT_MOUNT"`
+	VaultTransitKeyName string `env:"VAULT_TRANSIT_KEY"`
+	// envelope encryption endpoint with /wrap and /unwrap methods, e.g.
+	// KMIP gateway or proxy to cloud KMS
+	KeyManagementURL   string `env:"KMS_URL"`
+	KeyManagementToken string `env:"KMS_TOKEN"`
+	KeyManagementKeyID string `env:"KMS_KEY_ID"`
+	// re-encrypt backups made with retired master keys in background, so
+	// the keys are removed without waiting for backups to expire
+	IsReencryptBackupsOnKeyRotation bool `env:"SECRET_KEY_REENCRYPT_BACKUPS"`
+
+	// binlogs are archived after the server closes them, so the binlog being
+	// written is flushed when it is older than the interval. 15 by default
+	BinlogFlushIntervalMinutes int `env:"BINLOG_FLUSH_INTERVAL_MINUTES"`
 
 	TestGoogleDriveClientID     string `env:"TEST_GOOGLE_DRIVE_CLIENT_ID"`
 	TestGoogleDriveClientSecret string `env:"TEST_GOOGLE_DRIVE_CLIENT_SECRET"`
@@ -91,6 +116,56 @@ type EnvVariables struct {
 	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
 	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET"`
 
+	// OpenID Connect single sign-on (Keycloak, Authentik, Azure AD, etc.)
+	OIDCIssuerURL    string `env:"OIDC_ISSUER_URL"`
+	OIDCClientID     string `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	// shown on the sign in button, "SSO" by default
+	OIDCProviderName string `env:"OIDC_PROVIDER_NAME"`
+	// space separated, "openid email profile" by default
+	OIDCScopes string `env:"OIDC_SCOPES"`
+	// claim with the list of groups, "groups" by default
+	OIDCGroupsClaim string `env:"OIDC_GROUPS_CLAIM"`
+	// comma separated groups which members get ADMIN role, when empty the
+	// role is not changed on sign in
+	OIDCAdminGroups string `env:"OIDC_ADMIN_GROUPS"`
+	// semicolon separated "group=workspaceID:WORKSPACE_ROLE" entries
+	OIDCWorkspaceGroups string `env:"OIDC_WORKSPACE_GROUPS"`
+	// create users signed in via OIDC even when external registrations
+	// are disabled
+	OIDCIsAutoProvisioning bool `env:"OIDC_AUTO_PROVISIONING"`
+
+	// LDAP / Active Directory sign in, e.g. "ldaps://dc.example.com:636"
+	LDAPURL string `env:"LDAP_URL"`
+	// service account used to search users, anonymous search when empty
+	LDAPBindDN       string `env:"LDAP_BIND_DN"`
+	LDAPBindPassword string `env:"LDAP_BIND_PASSWORD"`
+	LDAPSearchBase   string `env:"LDAP_SEARCH_BASE"`
+	// filter with {username} placeholder, matches uid, mail, sAMAccountName
+	// and userPrincipalName by default
+	LDAPUserFilter     string `env:"LDAP_USER_FILTER"`
+	LDAPEmailAttribute string `env:"LDAP_EMAIL_ATTRIBUTE"`
+	LDAPNameAttribute  string `env:"LDAP_NAME_ATTRIBUTE"`
+	LDAPGroupAttribute string `env:"LDAP_GROUP_ATTRIBUTE"`
+	LDAPIsStartTLS     bool   `env:"LDAP_START_TLS"`
+	LDAPSkipTLSVerify  bool   `env:"LDAP_SKIP_TLS_VERIFY"`
+	// same format as OIDC_ADMIN_GROUPS and OIDC_WORKSPACE_GROUPS, groups
+	// are matched by DN or CN
+	LDAPAdminGroups         string `env:"LDAP_ADMIN_GROUPS"`
+	LDAPWorkspaceGroups     string `env:"LDAP_WORKSPACE_GROUPS"`
+	LDAPIsAutoProvisioning  bool   `env:"LDAP_AUTO_PROVISIONING"`
+	LDAPSyncIntervalMinutes int    `env:"LDAP_SYNC_INTERVAL_MINUTES"`
+	// link LDAP accounts to existing accounts with the same email. Anyone
+	// who can set mail attribute in the directory can take over the account,
+	// so it is enabled only when the directory is trusted
+	LDAPIsLinkExistingByEmail bool `env:"LDAP_LINK_EXISTING_BY_EMAIL"`
+
+	// forwarding of audit logs to SIEM, e.g. "udp://siem.example.com:514"
+	AuditLogSyslogAddress string `env:"AUDIT_LOG_SYSLOG_ADDRESS"`
+	// audit logs are sent as JSON by POST, the token is sent as Bearer
+	AuditLogWebhookURL   string `env:"AUDIT_LOG_WEBHOOK_URL"`
+	AuditLogWebhookToken string `env:"AUDIT_LOG_WEBHOOK_TOKEN"`
+
 	// testing Telegram
 	TestTelegramBotToken string `env:"TEST_TELEGRAM_BOT_TOKEN"`
 	TestTelegramChatID   string `env:"TEST_TELEGRAM_CHAT_ID"`
@@ -101,6 +176,9 @@ type EnvVariables struct {
 	TestSupabaseUsername string `env:"TEST_SUPABASE_USERNAME"`
 	TestSupabasePassword string `env:"TEST_SUPABASE_PASSWORD"`
 	TestSupabaseDatabase string `env:"TEST_SUPABASE_DATABASE"`
+
+	// testing Vault Transit, dev server with "testtoken" root token
+	TestVaultPort string `env:"TEST_VAULT_PORT"`
 }
 
 var (
@@ -200,6 +278,11 @@ func loadEnvVariables() {
 	env.DataFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "backups")
 	env.TempFolder = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "temp")
 	env.SecretKeyPath = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "secret.key")
+	env.WrappedSecretKeyPath = filepath.Join(
+		filepath.Dir(backendRoot),
+		"postgresus-data",
+		"secret.key.wrapped",
+	)
 	env.CertsDir = filepath.Join(filepath.Dir(backendRoot), "postgresus-data", "certs")
 
 	if env.IsTesting {
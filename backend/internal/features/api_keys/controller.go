@@ -0,0 +1,133 @@
+package api_keys
+
+import (
+	"net/http"
+
+	users_middleware "postgresus-backend/internal/features/users/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyController struct {
+	apiKeyService *APIKeyService
+}
+
+func (c *APIKeyController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/api-keys", c.CreateAPIKey)
+	router.GET("/api-keys", c.GetAPIKeys)
+	router.POST("/api-keys/:id/revoke", c.RevokeAPIKey)
+}
+
+// CreateAPIKey
+// @Summary Create an API key
+// @Description Create a workspace API key with the given scopes. The key is returned only once
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param request body CreateAPIKeyRequestDTO true "API key data"
+// @Success 200 {object} CreateAPIKeyResponseDTO
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /api-keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request CreateAPIKeyRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.apiKeyService.CreateAPIKey(user, request.WorkspaceID, &request)
+	if err != nil {
+		if err.Error() == "insufficient permissions to manage API keys in this workspace" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetAPIKeys
+// @Summary Get API keys
+// @Description Get all API keys of a workspace, including revoked and expired ones
+// @Tags api-keys
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param workspace_id query string true "Workspace ID"
+// @Success 200 {array} APIKey
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /api-keys [get]
+func (c *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Query("workspace_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace_id"})
+		return
+	}
+
+	apiKeys, err := c.apiKeyService.GetAPIKeys(user, workspaceID)
+	if err != nil {
+		if err.Error() == "insufficient permissions to manage API keys in this workspace" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiKeys)
+}
+
+// RevokeAPIKey
+// @Summary Revoke an API key
+// @Description Revoke the API key, requests made with it are rejected afterwards
+// @Tags api-keys
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /api-keys/{id}/revoke [post]
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return
+	}
+
+	if err := c.apiKeyService.RevokeAPIKey(user, id); err != nil {
+		if err.Error() == "insufficient permissions to manage API keys in this workspace" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,32 @@
+package api_keys
+
+import (
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/logger"
+)
+
+var apiKeyRepository = &APIKeyRepository{}
+var apiKeyService = &APIKeyService{
+	apiKeyRepository,
+	users_services.GetUserService(),
+	workspaces_services.GetAuthorizationService(),
+	audit_logs.GetAuditLogService(),
+	logger.GetLogger(),
+}
+var apiKeyController = &APIKeyController{
+	apiKeyService,
+}
+
+func GetAPIKeyService() *APIKeyService {
+	return apiKeyService
+}
+
+func GetAPIKeyController() *APIKeyController {
+	return apiKeyController
+}
+
+func SetupDependencies() {
+	users_services.GetUserService().SetAPIKeyAuthenticator(apiKeyService)
+}
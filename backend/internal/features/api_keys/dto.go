@@ -0,0 +1,21 @@
+package api_keys
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateAPIKeyRequestDTO struct {
+	WorkspaceID uuid.UUID     `json:"workspaceId" binding:"required"`
+	Name        string        `json:"name"        binding:"required"`
+	Scopes      []APIKeyScope `json:"scopes"      binding:"required"`
+	ExpiresAt   *time.Time    `json:"expiresAt"`
+}
+
+// CreateAPIKeyResponseDTO contains the key itself, it is returned only once
+// on creation and cannot be restored later
+type CreateAPIKeyResponseDTO struct {
+	APIKey *APIKey `json:"apiKey"`
+	Key    string  `json:"key"`
+}
@@ -0,0 +1,39 @@
+package api_keys
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeBackupsRead   APIKeyScope = "backups:read"
+	APIKeyScopeBackupsCreate APIKeyScope = "backups:create"
+	APIKeyScopeRestoresRead  APIKeyScope = "restores:read"
+	APIKeyScopeRestoresRun   APIKeyScope = "restores:run"
+	APIKeyScopeDatabasesRead APIKeyScope = "databases:read"
+)
+
+// scopeRoutes lists routes granted by each scope. Routes which are not
+// listed cannot be called with API keys at all
+var scopeRoutes = map[APIKeyScope][]string{
+	APIKeyScopeBackupsRead: {
+		"GET /api/v1/backups",
+		"GET /api/v1/backups/:id/file",
+	},
+	APIKeyScopeBackupsCreate: {
+		"POST /api/v1/backups",
+		"POST /api/v1/backups/:id/cancel",
+	},
+	APIKeyScopeRestoresRead: {
+		"GET /api/v1/restores/:backupId",
+	},
+	APIKeyScopeRestoresRun: {
+		"POST /api/v1/restores/:backupId/restore",
+	},
+	APIKeyScopeDatabasesRead: {
+		"GET /api/v1/databases",
+		"GET /api/v1/databases/:id",
+	},
+}
+
+func (s APIKeyScope) IsValid() bool {
+	_, isFound := scopeRoutes[s]
+	return isFound
+}
@@ -0,0 +1,104 @@
+package api_keys
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKey struct {
+	ID          uuid.UUID `json:"id"          gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	WorkspaceID uuid.UUID `json:"workspaceId" gorm:"column:workspace_id;type:uuid;not null"`
+	Name        string    `json:"name"        gorm:"column:name;type:text;not null"`
+
+	// only hash of the key is stored, the prefix allows to recognize the key
+	// in the list without revealing it
+	KeyPrefix string `json:"keyPrefix" gorm:"column:key_prefix;type:text;not null"`
+	HashedKey string `json:"-"         gorm:"column:hashed_key;type:text;not null"`
+
+	Scopes       []APIKeyScope `json:"scopes" gorm:"-"`
+	ScopesString string        `json:"-"      gorm:"column:scopes;type:text;not null"`
+
+	ExpiresAt  *time.Time `json:"expiresAt"  gorm:"column:expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt"  gorm:"column:revoked_at"`
+
+	CreatedByUserID uuid.UUID `json:"createdByUserId" gorm:"column:created_by_user_id;type:uuid;not null"`
+	CreatedAt       time.Time `json:"createdAt"       gorm:"column:created_at;not null"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) BeforeSave(tx *gorm.DB) error {
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	k.ScopesString = strings.Join(scopes, ",")
+
+	return nil
+}
+
+func (k *APIKey) AfterFind(tx *gorm.DB) error {
+	k.Scopes = []APIKeyScope{}
+
+	if k.ScopesString == "" {
+		return nil
+	}
+
+	for _, scope := range strings.Split(k.ScopesString, ",") {
+		k.Scopes = append(k.Scopes, APIKeyScope(scope))
+	}
+
+	return nil
+}
+
+func (k *APIKey) Validate() error {
+	if strings.TrimSpace(k.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	if len(k.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+
+	for _, scope := range k.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now().UTC()) {
+		return errors.New("expiration time must be in the future")
+	}
+
+	return nil
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+
+	return k.ExpiresAt == nil || k.ExpiresAt.After(now)
+}
+
+func (k *APIKey) IsRouteAllowed(method string, route string) bool {
+	methodRoute := method + " " + route
+
+	for _, scope := range k.Scopes {
+		if slices.Contains(scopeRoutes[scope], methodRoute) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package api_keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsRouteAllowed_OnlyRoutesOfGrantedScopesAllowed(t *testing.T) {
+	apiKey := &APIKey{
+		Scopes: []APIKeyScope{APIKeyScopeBackupsCreate, APIKeyScopeBackupsRead},
+	}
+
+	assert.True(t, apiKey.IsRouteAllowed("POST", "/api/v1/backups"))
+	assert.True(t, apiKey.IsRouteAllowed("GET", "/api/v1/backups/:id/file"))
+	assert.False(t, apiKey.IsRouteAllowed("DELETE", "/api/v1/backups/:id"))
+	assert.False(t, apiKey.IsRouteAllowed("POST", "/api/v1/restores/:backupId/restore"))
+	assert.False(t, apiKey.IsRouteAllowed("POST", "/api/v1/api-keys"))
+}
+
+func Test_IsActive_RevokedOrExpiredKeyInactive(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.True(t, (&APIKey{}).IsActive(now))
+	assert.True(t, (&APIKey{ExpiresAt: &future}).IsActive(now))
+	assert.False(t, (&APIKey{ExpiresAt: &past}).IsActive(now))
+	assert.False(t, (&APIKey{RevokedAt: &past}).IsActive(now))
+}
+
+func Test_Validate_UnknownScopeRejected(t *testing.T) {
+	apiKey := &APIKey{
+		Name:   "CI",
+		Scopes: []APIKeyScope{APIKeyScopeBackupsCreate, "users:manage"},
+	}
+	assert.EqualError(t, apiKey.Validate(), "unknown scope: users:manage")
+
+	apiKey.Scopes = []APIKeyScope{}
+	assert.EqualError(t, apiKey.Validate(), "at least one scope is required")
+
+	apiKey.Scopes = []APIKeyScope{APIKeyScopeBackupsCreate}
+	assert.NoError(t, apiKey.Validate())
+}
@@ -0,0 +1,70 @@
+package api_keys
+
+import (
+	"errors"
+	"time"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct{}
+
+func (r *APIKeyRepository) Save(apiKey *APIKey) error {
+	if apiKey.ID == uuid.Nil {
+		apiKey.ID = uuid.New()
+		return storage.GetDb().Create(apiKey).Error
+	}
+
+	return storage.GetDb().Save(apiKey).Error
+}
+
+func (r *APIKeyRepository) FindByID(id uuid.UUID) (*APIKey, error) {
+	var apiKey APIKey
+
+	if err := storage.GetDb().Where("id = ?", id).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepository) FindByHashedKey(hashedKey string) (*APIKey, error) {
+	var apiKey APIKey
+
+	if err := storage.GetDb().Where("hashed_key = ?", hashedKey).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepository) FindByWorkspaceID(workspaceID uuid.UUID) ([]*APIKey, error) {
+	var apiKeys []*APIKey
+
+	if err := storage.GetDb().
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at DESC").
+		Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *APIKeyRepository) UpdateLastUsedAt(id uuid.UUID, lastUsedAt time.Time) error {
+	return storage.GetDb().
+		Model(&APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", lastUsedAt).Error
+}
@@ -0,0 +1,236 @@
+package api_keys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyTokenPrefix      = "psk_"
+	apiKeyRandomBytes      = 32
+	apiKeyVisiblePrefix    = 12
+	lastUsedUpdateInterval = 1 * time.Minute
+)
+
+type APIKeyService struct {
+	apiKeyRepository     *APIKeyRepository
+	userService          *users_services.UserService
+	authorizationService *workspaces_services.AuthorizationService
+	auditLogService      *audit_logs.AuditLogService
+	logger               *slog.Logger
+}
+
+func (s *APIKeyService) CreateAPIKey(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	request *CreateAPIKeyRequestDTO,
+) (*CreateAPIKeyResponseDTO, error) {
+	if err := s.checkCanManageAPIKeys(user, workspaceID); err != nil {
+		return nil, err
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	apiKey := &APIKey{
+		WorkspaceID:     workspaceID,
+		Name:            strings.TrimSpace(request.Name),
+		KeyPrefix:       key[:apiKeyVisiblePrefix],
+		HashedKey:       hashAPIKey(key),
+		Scopes:          request.Scopes,
+		ExpiresAt:       request.ExpiresAt,
+		CreatedByUserID: user.ID,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	if err := apiKey.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepository.Save(apiKey); err != nil {
+		return nil, err
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCreate,
+		ResourceType: audit_logs_events.AuditResourceAPIKey,
+		ResourceID:   &apiKey.ID,
+		Message:      fmt.Sprintf("API key created: %s (ID: %s)", apiKey.Name, apiKey.ID),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
+
+	return &CreateAPIKeyResponseDTO{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) ([]*APIKey, error) {
+	if err := s.checkCanManageAPIKeys(user, workspaceID); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepository.FindByWorkspaceID(workspaceID)
+}
+
+// RevokeAPIKey disables the key. The key is kept, so audit logs still
+// reference it
+func (s *APIKeyService) RevokeAPIKey(user *users_models.User, apiKeyID uuid.UUID) error {
+	apiKey, err := s.apiKeyRepository.FindByID(apiKeyID)
+	if err != nil {
+		return err
+	}
+
+	if apiKey == nil {
+		return errors.New("API key not found")
+	}
+
+	if err := s.checkCanManageAPIKeys(user, apiKey.WorkspaceID); err != nil {
+		return err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return errors.New("API key is already revoked")
+	}
+
+	revokedAt := time.Now().UTC()
+	apiKey.RevokedAt = &revokedAt
+
+	if err := s.apiKeyRepository.Save(apiKey); err != nil {
+		return err
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRevoke,
+		ResourceType: audit_logs_events.AuditResourceAPIKey,
+		ResourceID:   &apiKey.ID,
+		Message:      fmt.Sprintf("API key revoked: %s (ID: %s)", apiKey.Name, apiKey.ID),
+		User:         user,
+		WorkspaceID:  &apiKey.WorkspaceID,
+	})
+
+	return nil
+}
+
+func (s *APIKeyService) IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyTokenPrefix)
+}
+
+// AuthenticateAPIKey returns the creator of the key restricted to the
+// workspace of the key. The creator must still be active, so keys stop
+// working together with the account
+func (s *APIKeyService) AuthenticateAPIKey(
+	token string,
+	method string,
+	route string,
+) (*users_models.User, bool, error) {
+	apiKey, err := s.apiKeyRepository.FindByHashedKey(hashAPIKey(token))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if apiKey == nil {
+		return nil, false, errors.New("API key not found")
+	}
+
+	now := time.Now().UTC()
+	if !apiKey.IsActive(now) {
+		return nil, false, errors.New("API key is revoked or expired")
+	}
+
+	user, err := s.userService.GetUserByID(apiKey.CreatedByUserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !user.IsActiveUser() {
+		return nil, false, errors.New("user account is deactivated")
+	}
+
+	user.APIKeyID = &apiKey.ID
+	user.APIKeyWorkspaceID = &apiKey.WorkspaceID
+
+	// usage time is updated at most once per interval to not write to DB
+	// on each request
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedUpdateInterval {
+		if err := s.apiKeyRepository.UpdateLastUsedAt(apiKey.ID, now); err != nil {
+			s.logger.Error("Failed to update API key usage time", "error", err)
+		}
+	}
+
+	return user, apiKey.IsRouteAllowed(method, route), nil
+}
+
+func (s *APIKeyService) WriteAPIKeyRequestAuditLog(
+	user *users_models.User,
+	method string,
+	path string,
+	statusCode int,
+) {
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionAPIRequest,
+		ResourceType: audit_logs_events.AuditResourceAPIKey,
+		ResourceID:   user.APIKeyID,
+		Message:      fmt.Sprintf("API key request: %s %s (status %d)", method, path, statusCode),
+		User:         user,
+		WorkspaceID:  user.APIKeyWorkspaceID,
+	})
+}
+
+func (s *APIKeyService) checkCanManageAPIKeys(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) error {
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageWorkspace,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !canManage {
+		return errors.New("insufficient permissions to manage API keys in this workspace")
+	}
+
+	return nil
+}
+
+func generateAPIKey() (string, error) {
+	randomBytes := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// hashAPIKey uses plain SHA-256 instead of bcrypt: keys are random with
+// 256 bits of entropy and are looked up by the hash on every request
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,30 @@
+package approvals
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const expirationCheckInterval = time.Minute
+
+type ApprovalBackgroundService struct {
+	approvalService *ApprovalService
+	logger          *slog.Logger
+}
+
+func (s *ApprovalBackgroundService) Run() {
+	ticker := time.NewTicker(expirationCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.IsShouldShutdown() {
+			break
+		}
+
+		if err := s.approvalService.ExpireRequests(); err != nil {
+			s.logger.Error("failed to expire approval requests", "error", err)
+		}
+	}
+}
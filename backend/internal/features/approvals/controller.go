@@ -0,0 +1,233 @@
+package approvals
+
+import (
+	"net/http"
+	"strings"
+
+	users_middleware "postgresus-backend/internal/features/users/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ApprovalController struct {
+	approvalService *ApprovalService
+}
+
+func (c *ApprovalController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/approvals", c.GetApprovalRequests)
+	router.GET("/approvals/:id", c.GetApprovalRequest)
+	router.POST("/approvals/:id/approve", c.ApproveRequest)
+	router.POST("/approvals/:id/reject", c.RejectRequest)
+	router.POST("/approvals/:id/cancel", c.CancelRequest)
+}
+
+// GetApprovalRequests
+// @Summary Get approval requests
+// @Description Get restores and backup deletions of the workspace which require or required approval
+// @Tags approvals
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param workspace_id query string true "Workspace ID"
+// @Param status query string false "Status filter"
+// @Success 200 {array} ApprovalRequest
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /approvals [get]
+func (c *ApprovalController) GetApprovalRequests(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Query("workspace_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace_id"})
+		return
+	}
+
+	var status *ApprovalStatus
+	if statusStr := ctx.Query("status"); statusStr != "" {
+		parsedStatus := ApprovalStatus(statusStr)
+		if !parsedStatus.IsValid() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+
+		status = &parsedStatus
+	}
+
+	requests, err := c.approvalService.GetRequests(user, workspaceID, status)
+	if err != nil {
+		respondWithApprovalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, requests)
+}
+
+// GetApprovalRequest
+// @Summary Get approval request
+// @Description Get a single approval request
+// @Tags approvals
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Approval request ID"
+// @Success 200 {object} ApprovalRequest
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /approvals/{id} [get]
+func (c *ApprovalController) GetApprovalRequest(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval request ID"})
+		return
+	}
+
+	request, err := c.approvalService.GetRequest(user, id)
+	if err != nil {
+		respondWithApprovalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+// ApproveRequest
+// @Summary Approve request
+// @Description Approve the pending request of another user, the action starts immediately on behalf of the requester
+// @Tags approvals
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Approval request ID"
+// @Param request body DecideApprovalRequestDTO false "Decision comment"
+// @Success 200 {object} ApprovalRequest
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /approvals/{id}/approve [post]
+func (c *ApprovalController) ApproveRequest(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval request ID"})
+		return
+	}
+
+	var requestDTO DecideApprovalRequestDTO
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestDTO); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	request, err := c.approvalService.Approve(user, id, requestDTO.Comment)
+	if err != nil {
+		respondWithApprovalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+// RejectRequest
+// @Summary Reject request
+// @Description Reject the pending request of another user
+// @Tags approvals
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Approval request ID"
+// @Param request body DecideApprovalRequestDTO false "Decision comment"
+// @Success 200 {object} ApprovalRequest
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /approvals/{id}/reject [post]
+func (c *ApprovalController) RejectRequest(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval request ID"})
+		return
+	}
+
+	var requestDTO DecideApprovalRequestDTO
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestDTO); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	request, err := c.approvalService.Reject(user, id, requestDTO.Comment)
+	if err != nil {
+		respondWithApprovalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+// CancelRequest
+// @Summary Cancel request
+// @Description Withdraw own pending request
+// @Tags approvals
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Approval request ID"
+// @Success 200 {object} ApprovalRequest
+// @Failure 400
+// @Failure 401
+// @Router /approvals/{id}/cancel [post]
+func (c *ApprovalController) CancelRequest(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval request ID"})
+		return
+	}
+
+	request, err := c.approvalService.Cancel(user, id)
+	if err != nil {
+		respondWithApprovalError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func respondWithApprovalError(ctx *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "insufficient permissions") {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
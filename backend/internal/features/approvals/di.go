@@ -0,0 +1,44 @@
+package approvals
+
+import (
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+)
+
+var approvalRepository = &ApprovalRepository{}
+var approvalService = &ApprovalService{
+	approvalRepository,
+	databases.GetDatabaseService(),
+	notifiers.GetNotifierService(),
+	users_services.GetUserService(),
+	workspaces_services.GetAuthorizationService(),
+	audit_logs.GetAuditLogService(),
+	encryption.GetFieldEncryptor(),
+	logger.GetLogger(),
+	map[ApprovalAction]ApprovalActionExecutor{},
+}
+var approvalController = &ApprovalController{
+	approvalService,
+}
+
+var approvalBackgroundService = &ApprovalBackgroundService{
+	approvalService,
+	logger.GetLogger(),
+}
+
+func GetApprovalService() *ApprovalService {
+	return approvalService
+}
+
+func GetApprovalController() *ApprovalController {
+	return approvalController
+}
+
+func GetApprovalBackgroundService() *ApprovalBackgroundService {
+	return approvalBackgroundService
+}
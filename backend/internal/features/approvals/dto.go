@@ -0,0 +1,5 @@
+package approvals
+
+type DecideApprovalRequestDTO struct {
+	Comment *string `json:"comment"`
+}
@@ -0,0 +1,33 @@
+package approvals
+
+type ApprovalAction string
+
+const (
+	ApprovalActionRestore      ApprovalAction = "RESTORE"
+	ApprovalActionDeleteBackup ApprovalAction = "DELETE_BACKUP"
+)
+
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending   ApprovalStatus = "PENDING"
+	ApprovalStatusApproved  ApprovalStatus = "APPROVED"
+	ApprovalStatusRejected  ApprovalStatus = "REJECTED"
+	ApprovalStatusCancelled ApprovalStatus = "CANCELLED"
+	ApprovalStatusExpired   ApprovalStatus = "EXPIRED"
+	ApprovalStatusFailed    ApprovalStatus = "FAILED"
+)
+
+func (s ApprovalStatus) IsValid() bool {
+	switch s {
+	case ApprovalStatusPending,
+		ApprovalStatusApproved,
+		ApprovalStatusRejected,
+		ApprovalStatusCancelled,
+		ApprovalStatusExpired,
+		ApprovalStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,16 @@
+package approvals
+
+import (
+	users_models "postgresus-backend/internal/features/users/models"
+)
+
+// ApprovalActionExecutor runs the action once it is approved. Permissions of
+// the requester are checked again, because they may change while the request
+// is pending
+type ApprovalActionExecutor interface {
+	ExecuteApprovedAction(
+		request *ApprovalRequest,
+		requestedBy *users_models.User,
+		payload string,
+	) error
+}
@@ -0,0 +1,47 @@
+package approvals
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalRequest holds the destructive action until another workspace admin
+// approves it. Parameters of the action are stored encrypted, because
+// restore requests contain database credentials
+type ApprovalRequest struct {
+	ID          uuid.UUID      `json:"id"          gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	WorkspaceID uuid.UUID      `json:"workspaceId" gorm:"column:workspace_id;type:uuid;not null"`
+	DatabaseID  uuid.UUID      `json:"databaseId"  gorm:"column:database_id;type:uuid;not null"`
+	BackupID    uuid.UUID      `json:"backupId"    gorm:"column:backup_id;type:uuid;not null"`
+	Action      ApprovalAction `json:"action"      gorm:"column:action;type:text;not null"`
+	Status      ApprovalStatus `json:"status"      gorm:"column:status;type:text;not null"`
+
+	// Summary describes the action for approvers without revealing
+	// credentials of the payload
+	Summary string `json:"summary" gorm:"column:summary;type:text;not null"`
+	Payload string `json:"-"       gorm:"column:payload;type:text;not null"`
+
+	RequestedByUserID uuid.UUID  `json:"requestedByUserId" gorm:"column:requested_by_user_id;type:uuid;not null"`
+	DecidedByUserID   *uuid.UUID `json:"decidedByUserId"   gorm:"column:decided_by_user_id;type:uuid"`
+	DecisionComment   *string    `json:"decisionComment"   gorm:"column:decision_comment;type:text"`
+	FailMessage       *string    `json:"failMessage"       gorm:"column:fail_message;type:text"`
+
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"column:expires_at;not null"`
+	DecidedAt *time.Time `json:"decidedAt" gorm:"column:decided_at"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"column:created_at;not null"`
+}
+
+func (ApprovalRequest) TableName() string {
+	return "approval_requests"
+}
+
+func (r *ApprovalRequest) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// IsDecidable returns false for requests which were already decided or
+// expired, even when the expiration job did not mark them yet
+func (r *ApprovalRequest) IsDecidable(now time.Time) bool {
+	return r.Status == ApprovalStatusPending && !r.IsExpired(now)
+}
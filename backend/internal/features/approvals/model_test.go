@@ -0,0 +1,20 @@
+package approvals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsDecidable_OnlyPendingNotExpiredRequestDecidable(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.True(t, (&ApprovalRequest{Status: ApprovalStatusPending, ExpiresAt: future}).IsDecidable(now))
+	assert.False(t, (&ApprovalRequest{Status: ApprovalStatusPending, ExpiresAt: past}).IsDecidable(now))
+	assert.False(t, (&ApprovalRequest{Status: ApprovalStatusPending, ExpiresAt: now}).IsDecidable(now))
+	assert.False(t, (&ApprovalRequest{Status: ApprovalStatusApproved, ExpiresAt: future}).IsDecidable(now))
+	assert.False(t, (&ApprovalRequest{Status: ApprovalStatusCancelled, ExpiresAt: future}).IsDecidable(now))
+}
@@ -0,0 +1,107 @@
+package approvals
+
+import (
+	"errors"
+	"time"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ApprovalRepository struct{}
+
+func (r *ApprovalRepository) Save(request *ApprovalRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+
+	return storage.GetDb().Save(request).Error
+}
+
+func (r *ApprovalRepository) FindByID(id uuid.UUID) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+
+	if err := storage.GetDb().Where("id = ?", id).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (r *ApprovalRepository) FindByWorkspaceID(
+	workspaceID uuid.UUID,
+	status *ApprovalStatus,
+) ([]*ApprovalRequest, error) {
+	var requests []*ApprovalRequest
+
+	query := storage.GetDb().Where("workspace_id = ?", workspaceID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// FindPendingByBackup returns nil when there is no pending request for the
+// action on the backup
+func (r *ApprovalRepository) FindPendingByBackup(
+	backupID uuid.UUID,
+	action ApprovalAction,
+	now time.Time,
+) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+
+	if err := storage.GetDb().
+		Where("backup_id = ? AND action = ? AND status = ? AND expires_at > ?",
+			backupID, action, ApprovalStatusPending, now).
+		First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (r *ApprovalRepository) FindExpiredPending(now time.Time) ([]*ApprovalRequest, error) {
+	var requests []*ApprovalRequest
+
+	if err := storage.GetDb().
+		Where("status = ? AND expires_at <= ?", ApprovalStatusPending, now).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// UpdateStatusIfPending changes status only when the request is still
+// pending, so two admins cannot decide the same request at once
+func (r *ApprovalRepository) UpdateStatusIfPending(request *ApprovalRequest) (bool, error) {
+	result := storage.GetDb().
+		Model(&ApprovalRequest{}).
+		Where("id = ? AND status = ?", request.ID, ApprovalStatusPending).
+		Updates(map[string]any{
+			"status":             request.Status,
+			"decided_by_user_id": request.DecidedByUserID,
+			"decision_comment":   request.DecisionComment,
+			"decided_at":         request.DecidedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
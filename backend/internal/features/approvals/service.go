@@ -0,0 +1,456 @@
+package approvals
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/encryption"
+
+	"github.com/google/uuid"
+)
+
+const approvalRequestTTL = 24 * time.Hour
+
+type ApprovalService struct {
+	approvalRepository   *ApprovalRepository
+	databaseService      *databases.DatabaseService
+	notifierService      *notifiers.NotifierService
+	userService          *users_services.UserService
+	authorizationService *workspaces_services.AuthorizationService
+	auditLogService      *audit_logs.AuditLogService
+	fieldEncryptor       encryption.FieldEncryptor
+	logger               *slog.Logger
+
+	executors map[ApprovalAction]ApprovalActionExecutor
+}
+
+func (s *ApprovalService) SetActionExecutor(
+	action ApprovalAction,
+	executor ApprovalActionExecutor,
+) {
+	s.executors[action] = executor
+}
+
+// CreateRequest stores the action with its parameters and notifies approvers
+// through notifiers of the database
+func (s *ApprovalService) CreateRequest(
+	user *users_models.User,
+	database *databases.Database,
+	backupID uuid.UUID,
+	action ApprovalAction,
+	summary string,
+	payload any,
+) (*ApprovalRequest, error) {
+	if database.WorkspaceID == nil {
+		return nil, errors.New("cannot request approval for database without workspace")
+	}
+
+	now := time.Now().UTC()
+
+	pendingRequest, err := s.approvalRepository.FindPendingByBackup(backupID, action, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending requests: %w", err)
+	}
+	if pendingRequest != nil {
+		return nil, errors.New("approval request for this backup is already pending")
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	request := &ApprovalRequest{
+		ID:                uuid.New(),
+		WorkspaceID:       *database.WorkspaceID,
+		DatabaseID:        database.ID,
+		BackupID:          backupID,
+		Action:            action,
+		Status:            ApprovalStatusPending,
+		Summary:           summary,
+		RequestedByUserID: user.ID,
+		ExpiresAt:         now.Add(approvalRequestTTL),
+		CreatedAt:         now,
+	}
+
+	request.Payload, err = s.fieldEncryptor.Encrypt(request.ID, string(payloadJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt request: %w", err)
+	}
+
+	if err := s.approvalRepository.Save(request); err != nil {
+		return nil, fmt.Errorf("failed to save approval request: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRequestApproval,
+		ResourceType: audit_logs_events.AuditResourceApprovalRequest,
+		ResourceID:   &request.ID,
+		Message: fmt.Sprintf(
+			"Approval requested for %s of database %s: %s (request ID: %s)",
+			getActionName(action),
+			database.Name,
+			summary,
+			request.ID,
+		),
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
+
+	s.sendNotification(
+		database,
+		notifiers_events.NotificationEventApprovalRequested,
+		fmt.Sprintf("🔐 Approval required: %s of %s", getActionName(action), database.Name),
+		fmt.Sprintf(
+			"%s requested %s: %s. Another workspace admin should approve it before %s (request ID: %s).",
+			user.Email,
+			getActionName(action),
+			summary,
+			request.ExpiresAt.Format(time.RFC3339),
+			request.ID,
+		),
+	)
+
+	return request, nil
+}
+
+func (s *ApprovalService) GetRequests(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	status *ApprovalStatus,
+) ([]*ApprovalRequest, error) {
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, errors.New("insufficient permissions to view approval requests")
+	}
+
+	return s.approvalRepository.FindByWorkspaceID(workspaceID, status)
+}
+
+func (s *ApprovalService) GetRequest(
+	user *users_models.User,
+	id uuid.UUID,
+) (*ApprovalRequest, error) {
+	request, err := s.getRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	canView, err := s.authorizationService.HasPermission(
+		request.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, errors.New("insufficient permissions to view approval requests")
+	}
+
+	return request, nil
+}
+
+// Approve runs the action on behalf of the requester. The approver must be
+// another person with admin permissions in the workspace
+func (s *ApprovalService) Approve(
+	user *users_models.User,
+	id uuid.UUID,
+	comment *string,
+) (*ApprovalRequest, error) {
+	request, database, err := s.decide(user, id, ApprovalStatusApproved, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.execute(request); err != nil {
+		failMessage := err.Error()
+		request.Status = ApprovalStatusFailed
+		request.FailMessage = &failMessage
+
+		if err := s.approvalRepository.Save(request); err != nil {
+			return nil, fmt.Errorf("failed to save approval request: %w", err)
+		}
+
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionApprovedFailed,
+			ResourceType: audit_logs_events.AuditResourceApprovalRequest,
+			ResourceID:   &request.ID,
+			Message: fmt.Sprintf(
+				"Approved %s of database %s failed: %s (request ID: %s)",
+				getActionName(request.Action),
+				database.Name,
+				failMessage,
+				request.ID,
+			),
+			User:        user,
+			WorkspaceID: &request.WorkspaceID,
+		})
+
+		return request, nil
+	}
+
+	return request, nil
+}
+
+func (s *ApprovalService) Reject(
+	user *users_models.User,
+	id uuid.UUID,
+	comment *string,
+) (*ApprovalRequest, error) {
+	request, _, err := s.decide(user, id, ApprovalStatusRejected, comment)
+	return request, err
+}
+
+// Cancel allows the requester to withdraw the request before it is decided
+func (s *ApprovalService) Cancel(
+	user *users_models.User,
+	id uuid.UUID,
+) (*ApprovalRequest, error) {
+	request, err := s.getRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RequestedByUserID != user.ID {
+		return nil, errors.New("only requester can cancel approval request")
+	}
+
+	if !request.IsDecidable(time.Now().UTC()) {
+		return nil, errors.New("approval request is not pending")
+	}
+
+	now := time.Now().UTC()
+	request.Status = ApprovalStatusCancelled
+	request.DecidedByUserID = &user.ID
+	request.DecidedAt = &now
+
+	isUpdated, err := s.approvalRepository.UpdateStatusIfPending(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval request: %w", err)
+	}
+	if !isUpdated {
+		return nil, errors.New("approval request is not pending")
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCancelApproval,
+		ResourceType: audit_logs_events.AuditResourceApprovalRequest,
+		ResourceID:   &request.ID,
+		Message: fmt.Sprintf(
+			"Approval request cancelled for %s: %s (request ID: %s)",
+			getActionName(request.Action),
+			request.Summary,
+			request.ID,
+		),
+		User:        user,
+		WorkspaceID: &request.WorkspaceID,
+	})
+
+	return request, nil
+}
+
+// ExpireRequests marks pending requests which were not decided in time
+func (s *ApprovalService) ExpireRequests() error {
+	requests, err := s.approvalRepository.FindExpiredPending(time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to get expired requests: %w", err)
+	}
+
+	for _, request := range requests {
+		now := time.Now().UTC()
+		request.Status = ApprovalStatusExpired
+		request.DecidedAt = &now
+
+		isUpdated, err := s.approvalRepository.UpdateStatusIfPending(request)
+		if err != nil {
+			return fmt.Errorf("failed to expire approval request: %w", err)
+		}
+		if !isUpdated {
+			continue
+		}
+
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionExpireApproval,
+			ResourceType: audit_logs_events.AuditResourceApprovalRequest,
+			ResourceID:   &request.ID,
+			Message: fmt.Sprintf(
+				"Approval request expired for %s: %s (request ID: %s)",
+				getActionName(request.Action),
+				request.Summary,
+				request.ID,
+			),
+			WorkspaceID: &request.WorkspaceID,
+		})
+	}
+
+	return nil
+}
+
+func (s *ApprovalService) decide(
+	user *users_models.User,
+	id uuid.UUID,
+	status ApprovalStatus,
+	comment *string,
+) (*ApprovalRequest, *databases.Database, error) {
+	request, err := s.getRequest(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	canApprove, err := s.authorizationService.HasPermission(
+		request.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageWorkspace,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !canApprove {
+		return nil, nil, errors.New("insufficient permissions to decide approval requests")
+	}
+
+	if request.RequestedByUserID == user.ID {
+		return nil, nil, errors.New("approval request should be decided by another admin")
+	}
+
+	now := time.Now().UTC()
+	if !request.IsDecidable(now) {
+		return nil, nil, errors.New("approval request is not pending")
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(request.DatabaseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get database: %w", err)
+	}
+
+	request.Status = status
+	request.DecidedByUserID = &user.ID
+	request.DecisionComment = comment
+	request.DecidedAt = &now
+
+	isUpdated, err := s.approvalRepository.UpdateStatusIfPending(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update approval request: %w", err)
+	}
+	if !isUpdated {
+		return nil, nil, errors.New("approval request is not pending")
+	}
+
+	decision := "approved"
+	auditAction := audit_logs_events.AuditActionApprove
+	if status == ApprovalStatusRejected {
+		decision = "rejected"
+		auditAction = audit_logs_events.AuditActionReject
+	}
+
+	message := fmt.Sprintf(
+		"Approval request %s for %s of database %s: %s (request ID: %s)",
+		decision,
+		getActionName(request.Action),
+		database.Name,
+		request.Summary,
+		request.ID,
+	)
+	if comment != nil && *comment != "" {
+		message += fmt.Sprintf(", comment: %s", *comment)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       auditAction,
+		ResourceType: audit_logs_events.AuditResourceApprovalRequest,
+		ResourceID:   &request.ID,
+		Message:      message,
+		User:         user,
+		WorkspaceID:  &request.WorkspaceID,
+	})
+
+	s.sendNotification(
+		database,
+		notifiers_events.NotificationEventApprovalDecided,
+		fmt.Sprintf("Approval %s: %s of %s", decision, getActionName(request.Action), database.Name),
+		fmt.Sprintf("%s %s the request: %s.", user.Email, decision, request.Summary),
+	)
+
+	return request, database, nil
+}
+
+func (s *ApprovalService) execute(request *ApprovalRequest) error {
+	executor, ok := s.executors[request.Action]
+	if !ok {
+		return fmt.Errorf("no executor for action %s", request.Action)
+	}
+
+	requestedBy, err := s.userService.GetUserByID(request.RequestedByUserID)
+	if err != nil {
+		return fmt.Errorf("failed to get requester: %w", err)
+	}
+
+	if !requestedBy.IsActiveUser() {
+		return errors.New("requester is not active anymore")
+	}
+
+	payload, err := s.fieldEncryptor.Decrypt(request.ID, request.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt request: %w", err)
+	}
+
+	return executor.ExecuteApprovedAction(request, requestedBy, payload)
+}
+
+func (s *ApprovalService) getRequest(id uuid.UUID) (*ApprovalRequest, error) {
+	request, err := s.approvalRepository.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval request: %w", err)
+	}
+
+	if request == nil {
+		return nil, errors.New("approval request not found")
+	}
+
+	return request, nil
+}
+
+func (s *ApprovalService) sendNotification(
+	database *databases.Database,
+	eventType notifiers_events.NotificationEventType,
+	title string,
+	message string,
+) {
+	event := notifiers_events.NewNotificationEvent(eventType, title, message)
+	event.Database = database.ToNotificationEventDatabase()
+
+	for _, notifier := range database.Notifiers {
+		s.notifierService.SendNotification(&notifier, event)
+	}
+}
+
+func getActionName(action ApprovalAction) string {
+	switch action {
+	case ApprovalActionRestore:
+		return "restore"
+	case ApprovalActionDeleteBackup:
+		return "backup deletion"
+	default:
+		return string(action)
+	}
+}
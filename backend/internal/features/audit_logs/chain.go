@@ -0,0 +1,47 @@
+package audit_logs
+
+import (
+	"fmt"
+)
+
+// AuditLogChainVerifier checks logs one by one in the order of sequence.
+// Removed logs are detected by gap in sequence or by previous hash, edited
+// logs are detected by hash
+type AuditLogChainVerifier struct {
+	CheckedCount int64
+	LastSequence int64
+	LastHash     string
+
+	isChainStarted bool
+}
+
+func (v *AuditLogChainVerifier) Verify(auditLog *AuditLog) error {
+	// logs written before hashing was introduced
+	if auditLog.Hash == "" && !v.isChainStarted {
+		v.LastSequence = auditLog.Sequence
+		return nil
+	}
+
+	if v.LastSequence != 0 && auditLog.Sequence != v.LastSequence+1 {
+		return fmt.Errorf(
+			"logs between sequence %d and %d are missing",
+			v.LastSequence,
+			auditLog.Sequence,
+		)
+	}
+
+	if auditLog.PrevHash != v.LastHash {
+		return fmt.Errorf("previous hash of log %d does not match", auditLog.Sequence)
+	}
+
+	if auditLog.Hash != auditLog.CalculateHash() {
+		return fmt.Errorf("hash of log %d does not match its content", auditLog.Sequence)
+	}
+
+	v.isChainStarted = true
+	v.CheckedCount++
+	v.LastSequence = auditLog.Sequence
+	v.LastHash = auditLog.Hash
+
+	return nil
+}
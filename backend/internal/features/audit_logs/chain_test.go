@@ -0,0 +1,139 @@
+package audit_logs
+
+import (
+	"testing"
+	"time"
+
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AuditLogChainVerifier_WhenChainIsIntact_VerifiesAllLogs(t *testing.T) {
+	chain := createAuditLogChain(3)
+
+	verifier := &AuditLogChainVerifier{}
+	for _, auditLog := range chain {
+		assert.NoError(t, verifier.Verify(auditLog))
+	}
+
+	assert.Equal(t, int64(3), verifier.CheckedCount)
+	assert.Equal(t, chain[2].Hash, verifier.LastHash)
+}
+
+func Test_AuditLogChainVerifier_WhenLogIsEdited_ReturnsError(t *testing.T) {
+	chain := createAuditLogChain(3)
+	chain[1].Message = "Edited message"
+
+	verifier := &AuditLogChainVerifier{}
+	assert.NoError(t, verifier.Verify(chain[0]))
+
+	err := verifier.Verify(chain[1])
+	assert.EqualError(t, err, "hash of log 2 does not match its content")
+}
+
+func Test_AuditLogChainVerifier_WhenLogIsRemoved_ReturnsError(t *testing.T) {
+	chain := createAuditLogChain(3)
+
+	verifier := &AuditLogChainVerifier{}
+	assert.NoError(t, verifier.Verify(chain[0]))
+
+	err := verifier.Verify(chain[2])
+	assert.EqualError(t, err, "logs between sequence 1 and 3 are missing")
+}
+
+func Test_AuditLogChainVerifier_WhenLogIsReplacedWithRehashedOne_ReturnsError(t *testing.T) {
+	chain := createAuditLogChain(3)
+	chain[1].Message = "Edited message"
+	chain[1].Hash = chain[1].CalculateHash()
+
+	verifier := &AuditLogChainVerifier{}
+	assert.NoError(t, verifier.Verify(chain[0]))
+	assert.NoError(t, verifier.Verify(chain[1]))
+
+	err := verifier.Verify(chain[2])
+	assert.EqualError(t, err, "previous hash of log 3 does not match")
+}
+
+func Test_AuditLogChainVerifier_WithLogsBeforeHashing_SkipsThem(t *testing.T) {
+	legacyLog := &AuditLog{ID: uuid.New(), Sequence: 1, Message: "Legacy log"}
+	chain := createAuditLogChain(2)
+	for _, auditLog := range chain {
+		auditLog.Sequence++
+	}
+	chain[0].Hash = chain[0].CalculateHash()
+	chain[1].PrevHash = chain[0].Hash
+	chain[1].Hash = chain[1].CalculateHash()
+
+	verifier := &AuditLogChainVerifier{}
+	assert.NoError(t, verifier.Verify(legacyLog))
+	assert.NoError(t, verifier.Verify(chain[0]))
+	assert.NoError(t, verifier.Verify(chain[1]))
+	assert.Equal(t, int64(2), verifier.CheckedCount)
+}
+
+func Test_GetChanges_WithNestedAndSecretFields_ReturnsMaskedChanges(t *testing.T) {
+	type connection struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+	}
+	type config struct {
+		Name       string     `json:"name"`
+		Connection connection `json:"connection"`
+		Headers    []string   `json:"headers"`
+	}
+
+	before := config{
+		Name:       "Production",
+		Connection: connection{Host: "db-1", Password: "old-password"},
+		Headers:    []string{"X-Token: old"},
+	}
+	after := config{
+		Name:       "Production",
+		Connection: connection{Host: "db-2", Password: "new-password"},
+		Headers:    []string{"X-Token: new"},
+	}
+
+	changes := GetChanges(before, after)
+
+	assert.Equal(t, []AuditFieldChange{
+		{Field: "connection.host", Before: "db-1", After: "db-2"},
+		{Field: "connection.password", Before: maskedValue, After: maskedValue},
+		{Field: "headers", Before: maskedValue, After: maskedValue},
+	}, changes)
+}
+
+func Test_GetChanges_WhenNothingChanged_ReturnsNil(t *testing.T) {
+	value := map[string]any{"name": "Production", "port": 5432}
+
+	assert.Nil(t, GetChanges(value, Snapshot(value)))
+}
+
+func createAuditLogChain(count int) []*AuditLog {
+	workspaceID := uuid.New()
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	chain := make([]*AuditLog, 0, count)
+	prevHash := ""
+
+	for i := range count {
+		auditLog := &AuditLog{
+			ID:           uuid.New(),
+			WorkspaceID:  &workspaceID,
+			Action:       audit_logs_events.AuditActionUpdate,
+			ResourceType: audit_logs_events.AuditResourceWorkspace,
+			ResourceID:   &workspaceID,
+			Message:      "Workspace updated",
+			CreatedAt:    createdAt.Add(time.Duration(i) * time.Second),
+			Sequence:     int64(i + 1),
+			PrevHash:     prevHash,
+		}
+		auditLog.Hash = auditLog.CalculateHash()
+		prevHash = auditLog.Hash
+
+		chain = append(chain, auditLog)
+	}
+
+	return chain
+}
@@ -0,0 +1,176 @@
+package audit_logs
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const maskedValue = "******"
+
+// fields with these words in the name are never written to logs, only the
+// fact of the change is
+var sensitiveFieldWords = []string{
+	"password",
+	"secret",
+	"token",
+	"key",
+	"credential",
+	"payload",
+	"connectionstring",
+	"configcontent",
+	"webhookurl",
+	"powerautomateurl",
+	"headers",
+}
+
+type AuditFieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// GetChanges compares JSON representations of the config before and after
+// the change. Nested objects are compared by fields, the name of the field
+// is the path like "postgresql.host". Returns nil when nothing changed
+func GetChanges(before any, after any) []AuditFieldChange {
+	beforeValue, err := toJSONValue(before)
+	if err != nil {
+		return nil
+	}
+
+	afterValue, err := toJSONValue(after)
+	if err != nil {
+		return nil
+	}
+
+	changes := make([]AuditFieldChange, 0)
+	collectChanges("", beforeValue, afterValue, &changes)
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return changes
+}
+
+func collectChanges(path string, before any, after any, changes *[]AuditFieldChange) {
+	beforeMap, isBeforeMap := before.(map[string]any)
+	afterMap, isAfterMap := after.(map[string]any)
+
+	if isBeforeMap && isAfterMap {
+		keys := make(map[string]struct{}, len(beforeMap)+len(afterMap))
+		for key := range beforeMap {
+			keys[key] = struct{}{}
+		}
+		for key := range afterMap {
+			keys[key] = struct{}{}
+		}
+
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+
+			collectChanges(fieldPath, beforeMap[key], afterMap[key], changes)
+		}
+
+		return
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	if isSensitiveField(path) {
+		*changes = append(*changes, AuditFieldChange{
+			Field:  path,
+			Before: maskedValue,
+			After:  maskedValue,
+		})
+		return
+	}
+
+	*changes = append(*changes, AuditFieldChange{
+		Field:  path,
+		Before: maskSensitiveFields(before),
+		After:  maskSensitiveFields(after),
+	})
+}
+
+// maskSensitiveFields hides secrets inside changed lists and objects, e.g.
+// tokens of notifiers in the list of database notifiers
+func maskSensitiveFields(value any) any {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		masked := make(map[string]any, len(typedValue))
+		for key, fieldValue := range typedValue {
+			if isSensitiveField(key) {
+				masked[key] = maskedValue
+				continue
+			}
+
+			masked[key] = maskSensitiveFields(fieldValue)
+		}
+
+		return masked
+	case []any:
+		masked := make([]any, len(typedValue))
+		for i, item := range typedValue {
+			masked[i] = maskSensitiveFields(item)
+		}
+
+		return masked
+	default:
+		return value
+	}
+}
+
+// Snapshot copies the state of the config before it is changed in place,
+// nested objects of the config are not shared with the snapshot
+func Snapshot(value any) any {
+	snapshot, err := toJSONValue(value)
+	if err != nil {
+		return nil
+	}
+
+	return snapshot
+}
+
+func isSensitiveField(path string) bool {
+	lowerPath := strings.ToLower(path)
+
+	for _, word := range sensitiveFieldWords {
+		if strings.Contains(lowerPath, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toJSONValue(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
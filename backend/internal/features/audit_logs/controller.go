@@ -1,7 +1,10 @@
 package audit_logs
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	user_models "postgresus-backend/internal/features/users/models"
 
@@ -18,6 +21,8 @@ func (c *AuditLogController) RegisterRoutes(router *gin.RouterGroup) {
 	auditRoutes := router.Group("/audit-logs")
 
 	auditRoutes.GET("/global", c.GetGlobalAuditLogs)
+	auditRoutes.GET("/global/export", c.ExportGlobalAuditLogs)
+	auditRoutes.GET("/global/verify", c.VerifyAuditLogsChain)
 	auditRoutes.GET("/users/:userId", c.GetUserAuditLogs)
 }
 
@@ -31,6 +36,9 @@ func (c *AuditLogController) RegisterRoutes(router *gin.RouterGroup) {
 // @Param limit query int false "Limit number of results" default(100)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Param beforeDate query string false "Filter logs created before this date (RFC3339 format)" format(date-time)
+// @Param action query string false "Filter by action, e.g. UPDATE"
+// @Param resourceType query string false "Filter by resource type, e.g. DATABASE"
+// @Param resourceId query string false "Filter by resource ID"
 // @Success 200 {object} GetAuditLogsResponse
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -54,6 +62,10 @@ func (c *AuditLogController) GetGlobalAuditLogs(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if isAuditLogFilterError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
 		return
 	}
@@ -72,6 +84,9 @@ func (c *AuditLogController) GetGlobalAuditLogs(ctx *gin.Context) {
 // @Param limit query int false "Limit number of results" default(100)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Param beforeDate query string false "Filter logs created before this date (RFC3339 format)" format(date-time)
+// @Param action query string false "Filter by action, e.g. UPDATE"
+// @Param resourceType query string false "Filter by resource type, e.g. DATABASE"
+// @Param resourceId query string false "Filter by resource ID"
 // @Success 200 {object} GetAuditLogsResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -103,9 +118,125 @@ func (c *AuditLogController) GetUserAuditLogs(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if isAuditLogFilterError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// ExportGlobalAuditLogs
+// @Summary Export global audit logs (ADMIN only)
+// @Description Download audit logs across the system as CSV or JSON, filters are the same as for listing
+// @Tags audit-logs
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "csv (default) or json"
+// @Param limit query int false "Limit number of results" default(100000)
+// @Param beforeDate query string false "Filter logs created before this date (RFC3339 format)" format(date-time)
+// @Param action query string false "Filter by action, e.g. UPDATE"
+// @Param resourceType query string false "Filter by resource type, e.g. DATABASE"
+// @Param resourceId query string false "Filter by resource ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /audit-logs/global/export [get]
+func (c *AuditLogController) ExportGlobalAuditLogs(ctx *gin.Context) {
+	user, isOk := ctx.MustGet("user").(*user_models.User)
+	if !isOk {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user type in context"})
+		return
+	}
+
+	format, err := ParseAuditLogExportFormat(ctx.Query("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := &GetAuditLogsRequest{}
+	if err := ctx.ShouldBindQuery(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	auditLogs, err := c.auditLogService.GetGlobalAuditLogsForExport(user, request)
+	if err != nil {
+		if err.Error() == "only administrators can view global audit logs" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if isAuditLogFilterError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
+		return
+	}
+
+	WriteAuditLogsExportResponse(ctx, "audit-logs", format, auditLogs)
+}
+
+// VerifyAuditLogsChain
+// @Summary Verify audit logs chain (ADMIN only)
+// @Description Recalculate hashes of all audit logs to detect removed or edited logs
+// @Tags audit-logs
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} AuditLogChainVerificationDTO
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /audit-logs/global/verify [get]
+func (c *AuditLogController) VerifyAuditLogsChain(ctx *gin.Context) {
+	user, isOk := ctx.MustGet("user").(*user_models.User)
+	if !isOk {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user type in context"})
+		return
+	}
+
+	result, err := c.auditLogService.VerifyChain(user)
+	if err != nil {
+		if err.Error() == "only administrators can verify audit logs" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit logs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// WriteAuditLogsExportResponse writes logs as downloadable file, it is
+// shared with workspace audit logs export
+func WriteAuditLogsExportResponse(
+	ctx *gin.Context,
+	filePrefix string,
+	format AuditLogExportFormat,
+	auditLogs []*AuditLogDTO,
+) {
+	filename := fmt.Sprintf(
+		"%s-%s.%s",
+		filePrefix,
+		time.Now().UTC().Format("20060102-150405"),
+		format,
+	)
+
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	ctx.Status(http.StatusOK)
+
+	if err := WriteAuditLogsExport(ctx.Writer, format, auditLogs); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+func isAuditLogFilterError(err error) bool {
+	return strings.HasPrefix(err.Error(), "invalid")
+}
@@ -1,11 +1,15 @@
 package audit_logs
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	user_enums "postgresus-backend/internal/features/users/enums"
 	users_middleware "postgresus-backend/internal/features/users/middleware"
 	users_services "postgresus-backend/internal/features/users/services"
@@ -141,6 +145,164 @@ func Test_GetGlobalAuditLogs_WithBeforeDateFilter_ReturnsFilteredLogs(t *testing
 	}
 }
 
+func Test_GetGlobalAuditLogs_WithActionAndResourceFilter_ReturnsMatchingLogs(t *testing.T) {
+	adminUser := users_testing.CreateTestUser(user_enums.UserRoleAdmin)
+	router := createRouter()
+	service := GetAuditLogService()
+	resourceID := uuid.New()
+	testID := uuid.New().String()
+
+	updateMessage := fmt.Sprintf("Test update log %s", testID)
+	deleteMessage := fmt.Sprintf("Test delete log %s", testID)
+
+	service.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceStorage,
+		ResourceID:   &resourceID,
+		Message:      updateMessage,
+		UserID:       &adminUser.UserID,
+		Before:       map[string]any{"name": "Old", "secretKey": "old-secret"},
+		After:        map[string]any{"name": "New", "secretKey": "new-secret"},
+	})
+	service.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceStorage,
+		ResourceID:   &resourceID,
+		Message:      deleteMessage,
+		UserID:       &adminUser.UserID,
+	})
+
+	var response GetAuditLogsResponse
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf(
+			"/api/v1/audit-logs/global?action=UPDATE&resourceType=STORAGE&resourceId=%s",
+			resourceID,
+		),
+		"Bearer "+adminUser.Token,
+		http.StatusOK,
+		&response,
+	)
+
+	assert.Equal(t, 1, len(response.AuditLogs))
+	assert.Equal(t, updateMessage, response.AuditLogs[0].Message)
+	assert.Equal(t, audit_logs_events.AuditActionUpdate, response.AuditLogs[0].Action)
+	assert.Equal(t, &resourceID, response.AuditLogs[0].ResourceID)
+	assert.NotEmpty(t, response.AuditLogs[0].Hash)
+
+	var changes []AuditFieldChange
+	assert.NoError(t, json.Unmarshal(response.AuditLogs[0].Changes, &changes))
+	assert.Equal(t, []AuditFieldChange{
+		{Field: "name", Before: "Old", After: "New"},
+		{Field: "secretKey", Before: maskedValue, After: maskedValue},
+	}, changes)
+
+	resp := test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/audit-logs/global?action=UNKNOWN",
+		"Bearer "+adminUser.Token,
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(resp.Body), "invalid action")
+}
+
+func Test_ExportGlobalAuditLogs_InCsvAndJson_ReturnsFilteredLogs(t *testing.T) {
+	adminUser := users_testing.CreateTestUser(user_enums.UserRoleAdmin)
+	memberUser := users_testing.CreateTestUser(user_enums.UserRoleMember)
+	router := createRouter()
+	service := GetAuditLogService()
+	resourceID := uuid.New()
+	message := fmt.Sprintf("Test exported log %s", uuid.New().String())
+
+	service.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCreate,
+		ResourceType: audit_logs_events.AuditResourceNotifier,
+		ResourceID:   &resourceID,
+		Message:      message,
+		UserID:       &adminUser.UserID,
+	})
+
+	exportURL := fmt.Sprintf(
+		"/api/v1/audit-logs/global/export?resourceType=NOTIFIER&resourceId=%s",
+		resourceID,
+	)
+
+	csvResp := test_utils.MakeGetRequest(
+		t,
+		router,
+		exportURL,
+		"Bearer "+adminUser.Token,
+		http.StatusOK,
+	)
+	records, err := csv.NewReader(bytes.NewReader(csvResp.Body)).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, auditLogCSVHeader, records[0])
+	assert.Contains(t, records[1], message)
+	assert.Contains(t, records[1], resourceID.String())
+
+	var exportedLogs []*AuditLogDTO
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		exportURL+"&format=json",
+		"Bearer "+adminUser.Token,
+		http.StatusOK,
+		&exportedLogs,
+	)
+	assert.Equal(t, 1, len(exportedLogs))
+	assert.Equal(t, message, exportedLogs[0].Message)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		exportURL+"&format=xml",
+		"Bearer "+adminUser.Token,
+		http.StatusBadRequest,
+	)
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		exportURL,
+		"Bearer "+memberUser.Token,
+		http.StatusForbidden,
+	)
+}
+
+func Test_VerifyAuditLogsChain_WhenLogsAreWritten_ChainIsValid(t *testing.T) {
+	adminUser := users_testing.CreateTestUser(user_enums.UserRoleAdmin)
+	memberUser := users_testing.CreateTestUser(user_enums.UserRoleMember)
+	router := createRouter()
+	service := GetAuditLogService()
+
+	createAuditLog(service, "Test chained log first", &adminUser.UserID, nil)
+	createAuditLog(service, "Test chained log second", &adminUser.UserID, nil)
+
+	var result AuditLogChainVerificationDTO
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/audit-logs/global/verify",
+		"Bearer "+adminUser.Token,
+		http.StatusOK,
+		&result,
+	)
+
+	assert.True(t, result.IsValid)
+	assert.GreaterOrEqual(t, result.CheckedCount, int64(2))
+	assert.Nil(t, result.BrokenSequence)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/audit-logs/global/verify",
+		"Bearer "+memberUser.Token,
+		http.StatusForbidden,
+	)
+}
+
 func createRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -1,13 +1,22 @@
 package audit_logs
 
 import (
+	"net/http"
+
 	users_services "postgresus-backend/internal/features/users/services"
 	"postgresus-backend/internal/util/logger"
 )
 
 var auditLogRepository = &AuditLogRepository{}
+var auditLogForwarder = &AuditLogForwarder{
+	&http.Client{Timeout: webhookRequestTimeout},
+	logger.GetLogger(),
+	make(chan *AuditLog, forwardQueueSize),
+	nil,
+}
 var auditLogService = &AuditLogService{
 	auditLogRepository: auditLogRepository,
+	auditLogForwarder:  auditLogForwarder,
 	logger:             logger.GetLogger(),
 }
 var auditLogController = &AuditLogController{
@@ -22,6 +31,10 @@ func GetAuditLogController() *AuditLogController {
 	return auditLogController
 }
 
+func GetAuditLogForwarder() *AuditLogForwarder {
+	return auditLogForwarder
+}
+
 func SetupDependencies() {
 	users_services.GetUserService().SetAuditLogWriter(auditLogService)
 	users_services.GetSettingsService().SetAuditLogWriter(auditLogService)
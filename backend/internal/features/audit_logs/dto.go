@@ -1,15 +1,28 @@
 package audit_logs
 
 import (
+	"encoding/json"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+
 	"github.com/google/uuid"
 )
 
+type AuditLogExportFormat string
+
+const (
+	AuditLogExportFormatCSV  AuditLogExportFormat = "csv"
+	AuditLogExportFormatJSON AuditLogExportFormat = "json"
+)
+
 type GetAuditLogsRequest struct {
-	Limit      int        `form:"limit"      json:"limit"`
-	Offset     int        `form:"offset"     json:"offset"`
-	BeforeDate *time.Time `form:"beforeDate" json:"beforeDate"`
+	Limit        int                                 `form:"limit"        json:"limit"`
+	Offset       int                                 `form:"offset"       json:"offset"`
+	BeforeDate   *time.Time                          `form:"beforeDate"   json:"beforeDate"`
+	Action       audit_logs_events.AuditAction       `form:"action"       json:"action"`
+	ResourceType audit_logs_events.AuditResourceType `form:"resourceType" json:"resourceType"`
+	ResourceID   string                              `form:"resourceId"   json:"resourceId"`
 }
 
 type GetAuditLogsResponse struct {
@@ -20,12 +33,34 @@ type GetAuditLogsResponse struct {
 }
 
 type AuditLogDTO struct {
-	ID            uuid.UUID  `json:"id"            gorm:"column:id"`
-	UserID        *uuid.UUID `json:"userId"        gorm:"column:user_id"`
-	WorkspaceID   *uuid.UUID `json:"workspaceId"   gorm:"column:workspace_id"`
-	Message       string     `json:"message"       gorm:"column:message"`
-	CreatedAt     time.Time  `json:"createdAt"     gorm:"column:created_at"`
-	UserEmail     *string    `json:"userEmail"     gorm:"column:user_email"`
-	UserName      *string    `json:"userName"      gorm:"column:user_name"`
-	WorkspaceName *string    `json:"workspaceName" gorm:"column:workspace_name"`
+	ID            uuid.UUID                           `json:"id"            gorm:"column:id"`
+	UserID        *uuid.UUID                          `json:"userId"        gorm:"column:user_id"`
+	WorkspaceID   *uuid.UUID                          `json:"workspaceId"   gorm:"column:workspace_id"`
+	APIKeyID      *uuid.UUID                          `json:"apiKeyId"      gorm:"column:api_key_id"`
+	Action        audit_logs_events.AuditAction       `json:"action"        gorm:"column:action"`
+	ResourceType  audit_logs_events.AuditResourceType `json:"resourceType"  gorm:"column:resource_type"`
+	ResourceID    *uuid.UUID                          `json:"resourceId"    gorm:"column:resource_id"`
+	SourceIP      string                              `json:"sourceIp"      gorm:"column:source_ip"`
+	UserAgent     string                              `json:"userAgent"     gorm:"column:user_agent"`
+	Message       string                              `json:"message"       gorm:"column:message"`
+	Changes       json.RawMessage                     `json:"changes"       gorm:"column:changes"`
+	CreatedAt     time.Time                           `json:"createdAt"     gorm:"column:created_at"`
+	Sequence      int64                               `json:"sequence"      gorm:"column:sequence"`
+	Hash          string                              `json:"hash"          gorm:"column:hash"`
+	UserEmail     *string                             `json:"userEmail"     gorm:"column:user_email"`
+	UserName      *string                             `json:"userName"      gorm:"column:user_name"`
+	WorkspaceName *string                             `json:"workspaceName" gorm:"column:workspace_name"`
+	APIKeyName    *string                             `json:"apiKeyName"    gorm:"column:api_key_name"`
+}
+
+// AuditLogChainVerificationDTO is the result of the hash chain check. Last
+// sequence and hash can be compared with logs forwarded to SIEM to detect
+// removed logs in the end of the chain
+type AuditLogChainVerificationDTO struct {
+	IsValid        bool    `json:"isValid"`
+	CheckedCount   int64   `json:"checkedCount"`
+	BrokenSequence *int64  `json:"brokenSequence"`
+	Error          *string `json:"error"`
+	LastSequence   int64   `json:"lastSequence"`
+	LastHash       string  `json:"lastHash"`
 }
@@ -0,0 +1,161 @@
+package audit_logs_events
+
+import (
+	users_models "postgresus-backend/internal/features/users/models"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate            AuditAction = "CREATE"
+	AuditActionUpdate            AuditAction = "UPDATE"
+	AuditActionDelete            AuditAction = "DELETE"
+	AuditActionCopy              AuditAction = "COPY"
+	AuditActionSignUp            AuditAction = "SIGN_UP"
+	AuditActionSignIn            AuditAction = "SIGN_IN"
+	AuditActionSignInFailed      AuditAction = "SIGN_IN_FAILED"
+	AuditActionSignOut           AuditAction = "SIGN_OUT"
+	AuditActionLinkAccount       AuditAction = "LINK_ACCOUNT"
+	AuditActionRevokeSession     AuditAction = "REVOKE_SESSION"
+	AuditActionChangePassword    AuditAction = "CHANGE_PASSWORD"
+	AuditActionEnableTwoFactor   AuditAction = "ENABLE_TWO_FACTOR"
+	AuditActionDisableTwoFactor  AuditAction = "DISABLE_TWO_FACTOR"
+	AuditActionRegenerateCodes   AuditAction = "REGENERATE_RECOVERY_CODES"
+	AuditActionActivate          AuditAction = "ACTIVATE"
+	AuditActionDeactivate        AuditAction = "DEACTIVATE"
+	AuditActionChangeRole        AuditAction = "CHANGE_ROLE"
+	AuditActionInvite            AuditAction = "INVITE"
+	AuditActionAddMember         AuditAction = "ADD_MEMBER"
+	AuditActionRemoveMember      AuditAction = "REMOVE_MEMBER"
+	AuditActionTransferOwnership AuditAction = "TRANSFER_OWNERSHIP"
+	AuditActionStartBackup       AuditAction = "START_BACKUP"
+	AuditActionCancelBackup      AuditAction = "CANCEL_BACKUP"
+	AuditActionDownloadBackup    AuditAction = "DOWNLOAD_BACKUP"
+	AuditActionStartVerification AuditAction = "START_VERIFICATION"
+	AuditActionStartRestore      AuditAction = "START_RESTORE"
+	AuditActionRequestApproval   AuditAction = "REQUEST_APPROVAL"
+	AuditActionApprove           AuditAction = "APPROVE"
+	AuditActionReject            AuditAction = "REJECT"
+	AuditActionCancelApproval    AuditAction = "CANCEL_APPROVAL"
+	AuditActionExpireApproval    AuditAction = "EXPIRE_APPROVAL"
+	AuditActionApprovedFailed    AuditAction = "APPROVED_ACTION_FAILED"
+	AuditActionRevoke            AuditAction = "REVOKE"
+	AuditActionAPIRequest        AuditAction = "API_REQUEST"
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditActionCreate,
+		AuditActionUpdate,
+		AuditActionDelete,
+		AuditActionCopy,
+		AuditActionSignUp,
+		AuditActionSignIn,
+		AuditActionSignInFailed,
+		AuditActionSignOut,
+		AuditActionLinkAccount,
+		AuditActionRevokeSession,
+		AuditActionChangePassword,
+		AuditActionEnableTwoFactor,
+		AuditActionDisableTwoFactor,
+		AuditActionRegenerateCodes,
+		AuditActionActivate,
+		AuditActionDeactivate,
+		AuditActionChangeRole,
+		AuditActionInvite,
+		AuditActionAddMember,
+		AuditActionRemoveMember,
+		AuditActionTransferOwnership,
+		AuditActionStartBackup,
+		AuditActionCancelBackup,
+		AuditActionDownloadBackup,
+		AuditActionStartVerification,
+		AuditActionStartRestore,
+		AuditActionRequestApproval,
+		AuditActionApprove,
+		AuditActionReject,
+		AuditActionCancelApproval,
+		AuditActionExpireApproval,
+		AuditActionApprovedFailed,
+		AuditActionRevoke,
+		AuditActionAPIRequest:
+		return true
+	default:
+		return false
+	}
+}
+
+type AuditResourceType string
+
+const (
+	AuditResourceUser               AuditResourceType = "USER"
+	AuditResourceSession            AuditResourceType = "SESSION"
+	AuditResourceUsersSettings      AuditResourceType = "USERS_SETTINGS"
+	AuditResourceWorkspace          AuditResourceType = "WORKSPACE"
+	AuditResourceMembership         AuditResourceType = "MEMBERSHIP"
+	AuditResourceCustomRole         AuditResourceType = "CUSTOM_ROLE"
+	AuditResourceDatabaseRole       AuditResourceType = "DATABASE_ROLE"
+	AuditResourceDatabase           AuditResourceType = "DATABASE"
+	AuditResourceBackup             AuditResourceType = "BACKUP"
+	AuditResourceBackupConfig       AuditResourceType = "BACKUP_CONFIG"
+	AuditResourceHealthcheckConfig  AuditResourceType = "HEALTHCHECK_CONFIG"
+	AuditResourceVerificationConfig AuditResourceType = "VERIFICATION_CONFIG"
+	AuditResourceStorage            AuditResourceType = "STORAGE"
+	AuditResourceNotifier           AuditResourceType = "NOTIFIER"
+	AuditResourceAPIKey             AuditResourceType = "API_KEY"
+	AuditResourceApprovalRequest    AuditResourceType = "APPROVAL_REQUEST"
+	AuditResourceReportConfig       AuditResourceType = "REPORT_CONFIG"
+)
+
+func (t AuditResourceType) IsValid() bool {
+	switch t {
+	case AuditResourceUser,
+		AuditResourceSession,
+		AuditResourceUsersSettings,
+		AuditResourceWorkspace,
+		AuditResourceMembership,
+		AuditResourceCustomRole,
+		AuditResourceDatabaseRole,
+		AuditResourceDatabase,
+		AuditResourceBackup,
+		AuditResourceBackupConfig,
+		AuditResourceHealthcheckConfig,
+		AuditResourceVerificationConfig,
+		AuditResourceStorage,
+		AuditResourceNotifier,
+		AuditResourceAPIKey,
+		AuditResourceApprovalRequest,
+		AuditResourceReportConfig:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuditEvent describes the action to be written to audit logs. The event
+// lives in a separate package, so users services can write events without
+// importing audit logs
+type AuditEvent struct {
+	Action       AuditAction
+	ResourceType AuditResourceType
+	ResourceID   *uuid.UUID
+	Message      string
+
+	// actor of the event, source IP, user agent and API key are taken from
+	// the request of the user. Nil for events made by the system
+	User *users_models.User
+	// used when only ID of the actor is known
+	UserID      *uuid.UUID
+	WorkspaceID *uuid.UUID
+
+	// client of requests made before authentication, e.g. sign in
+	SourceIP  string
+	UserAgent string
+
+	// state of the config before and after the change, only changed fields
+	// are written and secrets are masked
+	Before any
+	After  any
+}
@@ -0,0 +1,111 @@
+package audit_logs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var auditLogCSVHeader = []string{
+	"sequence",
+	"id",
+	"created_at",
+	"action",
+	"resource_type",
+	"resource_id",
+	"workspace_id",
+	"workspace_name",
+	"user_id",
+	"user_email",
+	"api_key_id",
+	"api_key_name",
+	"source_ip",
+	"user_agent",
+	"message",
+	"changes",
+	"hash",
+}
+
+func ParseAuditLogExportFormat(format string) (AuditLogExportFormat, error) {
+	switch AuditLogExportFormat(format) {
+	case "", AuditLogExportFormatCSV:
+		return AuditLogExportFormatCSV, nil
+	case AuditLogExportFormatJSON:
+		return AuditLogExportFormatJSON, nil
+	default:
+		return "", errors.New("unsupported export format, use csv or json")
+	}
+}
+
+func (f AuditLogExportFormat) ContentType() string {
+	if f == AuditLogExportFormatJSON {
+		return "application/json"
+	}
+
+	return "text/csv"
+}
+
+func WriteAuditLogsExport(
+	writer io.Writer,
+	format AuditLogExportFormat,
+	auditLogs []*AuditLogDTO,
+) error {
+	if format == AuditLogExportFormatJSON {
+		return json.NewEncoder(writer).Encode(auditLogs)
+	}
+
+	csvWriter := csv.NewWriter(writer)
+
+	if err := csvWriter.Write(auditLogCSVHeader); err != nil {
+		return err
+	}
+
+	for _, auditLog := range auditLogs {
+		if err := csvWriter.Write([]string{
+			strconv.FormatInt(auditLog.Sequence, 10),
+			auditLog.ID.String(),
+			auditLog.CreatedAt.UTC().Format(time.RFC3339Nano),
+			string(auditLog.Action),
+			string(auditLog.ResourceType),
+			uuidToString(auditLog.ResourceID),
+			uuidToString(auditLog.WorkspaceID),
+			stringOrEmpty(auditLog.WorkspaceName),
+			uuidToString(auditLog.UserID),
+			stringOrEmpty(auditLog.UserEmail),
+			uuidToString(auditLog.APIKeyID),
+			stringOrEmpty(auditLog.APIKeyName),
+			auditLog.SourceIP,
+			auditLog.UserAgent,
+			auditLog.Message,
+			string(auditLog.Changes),
+			auditLog.Hash,
+		}); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
+func uuidToString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
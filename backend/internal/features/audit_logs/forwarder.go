@@ -0,0 +1,189 @@
+package audit_logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const (
+	forwardQueueSize       = 10_000
+	forwardShutdownCheck   = 5 * time.Second
+	webhookRequestTimeout  = 10 * time.Second
+	webhookAttemptsCount   = 3
+	webhookRetryBaseDelay  = time.Second
+	auditLogSyslogTag      = "postgresus-audit"
+	auditLogSyslogPriority = syslog.LOG_INFO | syslog.LOG_AUTH
+)
+
+// AuditLogForwarder sends written logs to syslog and webhook of SIEM. Logs
+// are sent in background, so slow SIEM does not slow down requests. When
+// the queue is full, logs are dropped from forwarding but stay in DB
+type AuditLogForwarder struct {
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	queue        chan *AuditLog
+	syslogWriter *syslog.Writer
+}
+
+func (f *AuditLogForwarder) IsEnabled() bool {
+	env := config.GetEnv()
+	return env.AuditLogSyslogAddress != "" || env.AuditLogWebhookURL != ""
+}
+
+func (f *AuditLogForwarder) Forward(auditLog *AuditLog) {
+	if !f.IsEnabled() {
+		return
+	}
+
+	select {
+	case f.queue <- auditLog:
+	default:
+		f.logger.Error(
+			"audit log forwarding queue is full, log is not forwarded",
+			"auditLogId", auditLog.ID,
+		)
+	}
+}
+
+func (f *AuditLogForwarder) Run() {
+	if !f.IsEnabled() {
+		return
+	}
+
+	ticker := time.NewTicker(forwardShutdownCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case auditLog := <-f.queue:
+			f.send(auditLog)
+		case <-ticker.C:
+			if config.IsShouldShutdown() {
+				return
+			}
+		}
+	}
+}
+
+func (f *AuditLogForwarder) send(auditLog *AuditLog) {
+	payload, err := json.Marshal(auditLog)
+	if err != nil {
+		f.logger.Error("failed to serialize audit log", "error", err)
+		return
+	}
+
+	env := config.GetEnv()
+
+	if env.AuditLogSyslogAddress != "" {
+		if err := f.sendToSyslog(env.AuditLogSyslogAddress, payload); err != nil {
+			f.logger.Error("failed to forward audit log to syslog", "error", err)
+		}
+	}
+
+	if env.AuditLogWebhookURL != "" {
+		err := f.sendToWebhook(env.AuditLogWebhookURL, env.AuditLogWebhookToken, payload)
+		if err != nil {
+			f.logger.Error("failed to forward audit log to webhook", "error", err)
+		}
+	}
+}
+
+func (f *AuditLogForwarder) sendToSyslog(syslogAddress string, payload []byte) error {
+	if f.syslogWriter == nil {
+		network, address, err := parseSyslogAddress(syslogAddress)
+		if err != nil {
+			return err
+		}
+
+		writer, err := syslog.Dial(network, address, auditLogSyslogPriority, auditLogSyslogTag)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+
+		f.syslogWriter = writer
+	}
+
+	if err := f.syslogWriter.Info(string(payload)); err != nil {
+		// reconnect on the next log, TCP connection may be closed by server
+		_ = f.syslogWriter.Close()
+		f.syslogWriter = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (f *AuditLogForwarder) sendToWebhook(
+	webhookURL string,
+	webhookToken string,
+	payload []byte,
+) error {
+	var lastErr error
+
+	for attempt := range webhookAttemptsCount {
+		if attempt > 0 {
+			time.Sleep(webhookRetryBaseDelay * time.Duration(1<<(attempt-1)))
+		}
+
+		lastErr = f.postToWebhook(webhookURL, webhookToken, payload)
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	return lastErr
+}
+
+func (f *AuditLogForwarder) postToWebhook(
+	webhookURL string,
+	webhookToken string,
+	payload []byte,
+) error {
+	request, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if webhookToken != "" {
+		request.Header.Set("Authorization", "Bearer "+webhookToken)
+	}
+
+	response, err := f.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// parseSyslogAddress accepts "udp://host:514", "tcp://host:514" or
+// "host:514" which is sent by UDP
+func parseSyslogAddress(address string) (string, string, error) {
+	parsedURL, err := url.Parse(address)
+	if err != nil || parsedURL.Host == "" {
+		return "udp", address, nil
+	}
+
+	switch parsedURL.Scheme {
+	case "udp", "tcp":
+		return parsedURL.Scheme, parsedURL.Host, nil
+	default:
+		return "", "", fmt.Errorf("unsupported syslog protocol: %s", parsedURL.Scheme)
+	}
+}
@@ -1,19 +1,81 @@
 package audit_logs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+
 	"github.com/google/uuid"
 )
 
 type AuditLog struct {
-	ID          uuid.UUID  `json:"id"          gorm:"column:id"`
-	UserID      *uuid.UUID `json:"userId"      gorm:"column:user_id"`
-	WorkspaceID *uuid.UUID `json:"workspaceId" gorm:"column:workspace_id"`
-	Message     string     `json:"message"     gorm:"column:message"`
-	CreatedAt   time.Time  `json:"createdAt"   gorm:"column:created_at"`
+	ID           uuid.UUID                           `json:"id"           gorm:"column:id"`
+	UserID       *uuid.UUID                          `json:"userId"       gorm:"column:user_id"`
+	WorkspaceID  *uuid.UUID                          `json:"workspaceId"  gorm:"column:workspace_id"`
+	APIKeyID     *uuid.UUID                          `json:"apiKeyId"     gorm:"column:api_key_id"`
+	Action       audit_logs_events.AuditAction       `json:"action"       gorm:"column:action"`
+	ResourceType audit_logs_events.AuditResourceType `json:"resourceType" gorm:"column:resource_type"`
+	ResourceID   *uuid.UUID                          `json:"resourceId"   gorm:"column:resource_id"`
+	SourceIP     string                              `json:"sourceIp"     gorm:"column:source_ip"`
+	UserAgent    string                              `json:"userAgent"    gorm:"column:user_agent"`
+	Message      string                              `json:"message"      gorm:"column:message"`
+	// JSON array of AuditFieldChange, set for config changes only
+	Changes   *string   `json:"changes"   gorm:"column:changes"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+
+	// logs are chained by hashes in the order of sequence, so removed or
+	// edited logs break the chain
+	Sequence int64  `json:"sequence" gorm:"column:sequence"`
+	PrevHash string `json:"prevHash" gorm:"column:prev_hash"`
+	Hash     string `json:"hash"     gorm:"column:hash"`
 }
 
 func (AuditLog) TableName() string {
 	return "audit_logs"
 }
+
+// CalculateHash returns SHA-256 of all fields of the log and hash of the
+// previous log. Created at is expected to be truncated to microseconds,
+// otherwise the value read from DB differs from the hashed one
+func (l *AuditLog) CalculateHash() string {
+	hashedFields := struct {
+		Sequence     int64                               `json:"sequence"`
+		PrevHash     string                              `json:"prevHash"`
+		ID           uuid.UUID                           `json:"id"`
+		UserID       *uuid.UUID                          `json:"userId"`
+		WorkspaceID  *uuid.UUID                          `json:"workspaceId"`
+		APIKeyID     *uuid.UUID                          `json:"apiKeyId"`
+		Action       audit_logs_events.AuditAction       `json:"action"`
+		ResourceType audit_logs_events.AuditResourceType `json:"resourceType"`
+		ResourceID   *uuid.UUID                          `json:"resourceId"`
+		SourceIP     string                              `json:"sourceIp"`
+		UserAgent    string                              `json:"userAgent"`
+		Message      string                              `json:"message"`
+		Changes      *string                             `json:"changes"`
+		CreatedAt    string                              `json:"createdAt"`
+	}{
+		Sequence:     l.Sequence,
+		PrevHash:     l.PrevHash,
+		ID:           l.ID,
+		UserID:       l.UserID,
+		WorkspaceID:  l.WorkspaceID,
+		APIKeyID:     l.APIKeyID,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		SourceIP:     l.SourceIP,
+		UserAgent:    l.UserAgent,
+		Message:      l.Message,
+		Changes:      l.Changes,
+		CreatedAt:    l.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	// struct fields are always serialized in the same order
+	payload, _ := json.Marshal(hashedFields)
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -2,120 +2,148 @@ package audit_logs
 
 import (
 	"postgresus-backend/internal/storage"
+	"strings"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-type AuditLogRepository struct{}
+// any constant unique for the app, the lock serializes writers of the chain
+const auditLogChainLockID = 7_311_420_518
 
-func (r *AuditLogRepository) Create(auditLog *AuditLog) error {
-	if auditLog.ID == uuid.Nil {
-		auditLog.ID = uuid.New()
-	}
-
-	return storage.GetDb().Create(auditLog).Error
-}
-
-func (r *AuditLogRepository) GetGlobal(
-	limit, offset int,
-	beforeDate *time.Time,
-) ([]*AuditLogDTO, error) {
-	var auditLogs = make([]*AuditLogDTO, 0)
-
-	sql := `
-		SELECT 
+const auditLogSelectSQL = `
+		SELECT
 			al.id,
 			al.user_id,
 			al.workspace_id,
+			al.api_key_id,
+			al.action,
+			al.resource_type,
+			al.resource_id,
+			al.source_ip,
+			al.user_agent,
 			al.message,
+			al.changes,
 			al.created_at,
+			al.sequence,
+			al.hash,
 			u.email as user_email,
 			u.name as user_name,
-			w.name as workspace_name
+			w.name as workspace_name,
+			ak.name as api_key_name
 		FROM audit_logs al
 		LEFT JOIN users u ON al.user_id = u.id
-		LEFT JOIN workspaces w ON al.workspace_id = w.id`
+		LEFT JOIN workspaces w ON al.workspace_id = w.id
+		LEFT JOIN api_keys ak ON al.api_key_id = ak.id`
 
-	args := []interface{}{}
+type AuditLogFilter struct {
+	BeforeDate   *time.Time
+	Action       audit_logs_events.AuditAction
+	ResourceType audit_logs_events.AuditResourceType
+	ResourceID   *uuid.UUID
+}
 
-	if beforeDate != nil {
-		sql += " WHERE al.created_at < ?"
-		args = append(args, *beforeDate)
-	}
+type AuditLogRepository struct{}
 
-	sql += " ORDER BY al.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+// Create appends the log to the end of the hash chain
+func (r *AuditLogRepository) Create(auditLog *AuditLog) error {
+	if auditLog.ID == uuid.Nil {
+		auditLog.ID = uuid.New()
+	}
 
-	err := storage.GetDb().Raw(sql, args...).Scan(&auditLogs).Error
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLogChainLockID).Error
+		if err != nil {
+			return err
+		}
+
+		var lastLogs []*AuditLog
+		if err := tx.
+			Select("sequence", "hash").
+			Order("sequence DESC").
+			Limit(1).
+			Find(&lastLogs).Error; err != nil {
+			return err
+		}
+
+		auditLog.Sequence = 1
+		auditLog.PrevHash = ""
+		if len(lastLogs) > 0 {
+			auditLog.Sequence = lastLogs[0].Sequence + 1
+			auditLog.PrevHash = lastLogs[0].Hash
+		}
+
+		auditLog.Hash = auditLog.CalculateHash()
+
+		return tx.Create(auditLog).Error
+	})
+}
 
-	return auditLogs, err
+func (r *AuditLogRepository) GetGlobal(
+	limit, offset int,
+	filter *AuditLogFilter,
+) ([]*AuditLogDTO, error) {
+	return r.find(nil, nil, limit, offset, filter)
 }
 
 func (r *AuditLogRepository) GetByUser(
 	userID uuid.UUID,
 	limit, offset int,
-	beforeDate *time.Time,
+	filter *AuditLogFilter,
 ) ([]*AuditLogDTO, error) {
-	var auditLogs = make([]*AuditLogDTO, 0)
+	return r.find(&userID, nil, limit, offset, filter)
+}
 
-	sql := `
-		SELECT 
-			al.id,
-			al.user_id,
-			al.workspace_id,
-			al.message,
-			al.created_at,
-			u.email as user_email,
-			u.name as user_name,
-			w.name as workspace_name
-		FROM audit_logs al
-		LEFT JOIN users u ON al.user_id = u.id
-		LEFT JOIN workspaces w ON al.workspace_id = w.id
-		WHERE al.user_id = ?`
+func (r *AuditLogRepository) GetByWorkspace(
+	workspaceID uuid.UUID,
+	limit, offset int,
+	filter *AuditLogFilter,
+) ([]*AuditLogDTO, error) {
+	return r.find(nil, &workspaceID, limit, offset, filter)
+}
 
-	args := []interface{}{userID}
+func (r *AuditLogRepository) CountGlobal(filter *AuditLogFilter) (int64, error) {
+	var count int64
+	query := storage.GetDb().Model(&AuditLog{})
 
-	if beforeDate != nil {
-		sql += " AND al.created_at < ?"
-		args = append(args, *beforeDate)
+	conditions, args := buildAuditLogConditions("", nil, nil, filter)
+	if len(conditions) > 0 {
+		query = query.Where(strings.Join(conditions, " AND "), args...)
 	}
 
-	sql += " ORDER BY al.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	err := query.Count(&count).Error
+	return count, err
+}
 
-	err := storage.GetDb().Raw(sql, args...).Scan(&auditLogs).Error
+// GetChainAfter returns logs in the order of the chain, used by verification
+func (r *AuditLogRepository) GetChainAfter(sequence int64, limit int) ([]*AuditLog, error) {
+	var auditLogs []*AuditLog
+
+	err := storage.GetDb().
+		Where("sequence > ?", sequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&auditLogs).Error
 
 	return auditLogs, err
 }
 
-func (r *AuditLogRepository) GetByWorkspace(
-	workspaceID uuid.UUID,
+func (r *AuditLogRepository) find(
+	userID *uuid.UUID,
+	workspaceID *uuid.UUID,
 	limit, offset int,
-	beforeDate *time.Time,
+	filter *AuditLogFilter,
 ) ([]*AuditLogDTO, error) {
 	var auditLogs = make([]*AuditLogDTO, 0)
 
-	sql := `
-		SELECT 
-			al.id,
-			al.user_id,
-			al.workspace_id,
-			al.message,
-			al.created_at,
-			u.email as user_email,
-			u.name as user_name,
-			w.name as workspace_name
-		FROM audit_logs al
-		LEFT JOIN users u ON al.user_id = u.id
-		LEFT JOIN workspaces w ON al.workspace_id = w.id
-		WHERE al.workspace_id = ?`
-
-	args := []interface{}{workspaceID}
+	sql := auditLogSelectSQL
 
-	if beforeDate != nil {
-		sql += " AND al.created_at < ?"
-		args = append(args, *beforeDate)
+	conditions, args := buildAuditLogConditions("al.", userID, workspaceID, filter)
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	sql += " ORDER BY al.created_at DESC LIMIT ? OFFSET ?"
@@ -126,14 +154,48 @@ func (r *AuditLogRepository) GetByWorkspace(
 	return auditLogs, err
 }
 
-func (r *AuditLogRepository) CountGlobal(beforeDate *time.Time) (int64, error) {
-	var count int64
-	query := storage.GetDb().Model(&AuditLog{})
+func buildAuditLogConditions(
+	prefix string,
+	userID *uuid.UUID,
+	workspaceID *uuid.UUID,
+	filter *AuditLogFilter,
+) ([]string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	if userID != nil {
+		conditions = append(conditions, prefix+"user_id = ?")
+		args = append(args, *userID)
+	}
 
-	if beforeDate != nil {
-		query = query.Where("created_at < ?", *beforeDate)
+	if workspaceID != nil {
+		conditions = append(conditions, prefix+"workspace_id = ?")
+		args = append(args, *workspaceID)
 	}
 
-	err := query.Count(&count).Error
-	return count, err
+	if filter == nil {
+		return conditions, args
+	}
+
+	if filter.BeforeDate != nil {
+		conditions = append(conditions, prefix+"created_at < ?")
+		args = append(args, *filter.BeforeDate)
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, prefix+"action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.ResourceType != "" {
+		conditions = append(conditions, prefix+"resource_type = ?")
+		args = append(args, filter.ResourceType)
+	}
+
+	if filter.ResourceID != nil {
+		conditions = append(conditions, prefix+"resource_id = ?")
+		args = append(args, *filter.ResourceID)
+	}
+
+	return conditions, args
 }
@@ -1,31 +1,65 @@
 package audit_logs
 
 import (
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	user_enums "postgresus-backend/internal/features/users/enums"
 	user_models "postgresus-backend/internal/features/users/models"
 
 	"github.com/google/uuid"
 )
 
+const (
+	defaultAuditLogsPageSize = 100
+	maxAuditLogsPageSize     = 1000
+	maxExportedAuditLogs     = 100_000
+	chainVerificationBatch   = 1000
+)
+
 type AuditLogService struct {
 	auditLogRepository *AuditLogRepository
+	auditLogForwarder  *AuditLogForwarder
 	logger             *slog.Logger
 }
 
-func (s *AuditLogService) WriteAuditLog(
-	message string,
-	userID *uuid.UUID,
-	workspaceID *uuid.UUID,
-) {
+// WriteAuditEvent never fails the action, errors of writing are only logged
+func (s *AuditLogService) WriteAuditEvent(event *audit_logs_events.AuditEvent) {
 	auditLog := &AuditLog{
-		UserID:      userID,
-		WorkspaceID: workspaceID,
-		Message:     message,
-		CreatedAt:   time.Now().UTC(),
+		UserID:       event.UserID,
+		WorkspaceID:  event.WorkspaceID,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		ResourceID:   event.ResourceID,
+		SourceIP:     event.SourceIP,
+		UserAgent:    event.UserAgent,
+		Message:      event.Message,
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if event.User != nil {
+		auditLog.UserID = &event.User.ID
+		auditLog.APIKeyID = event.User.APIKeyID
+
+		if event.User.RequestIPAddress != "" {
+			auditLog.SourceIP = event.User.RequestIPAddress
+			auditLog.UserAgent = event.User.RequestUserAgent
+		}
+	}
+
+	if event.Before != nil && event.After != nil {
+		if changes := GetChanges(event.Before, event.After); changes != nil {
+			changesJSON, err := json.Marshal(changes)
+			if err != nil {
+				s.logger.Error("failed to serialize audit log changes", "error", err)
+			} else {
+				changesStr := string(changesJSON)
+				auditLog.Changes = &changesStr
+			}
+		}
 	}
 
 	err := s.auditLogRepository.Create(auditLog)
@@ -33,10 +67,8 @@ func (s *AuditLogService) WriteAuditLog(
 		s.logger.Error("failed to create audit log", "error", err)
 		return
 	}
-}
 
-func (s *AuditLogService) CreateAuditLog(auditLog *AuditLog) error {
-	return s.auditLogRepository.Create(auditLog)
+	s.auditLogForwarder.Forward(auditLog)
 }
 
 func (s *AuditLogService) GetGlobalAuditLogs(
@@ -47,19 +79,20 @@ func (s *AuditLogService) GetGlobalAuditLogs(
 		return nil, errors.New("only administrators can view global audit logs")
 	}
 
-	limit := request.Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+	filter, err := toAuditLogFilter(request)
+	if err != nil {
+		return nil, err
 	}
 
+	limit := getAuditLogsLimit(request.Limit, defaultAuditLogsPageSize, maxAuditLogsPageSize)
 	offset := max(request.Offset, 0)
 
-	auditLogs, err := s.auditLogRepository.GetGlobal(limit, offset, request.BeforeDate)
+	auditLogs, err := s.auditLogRepository.GetGlobal(limit, offset, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := s.auditLogRepository.CountGlobal(request.BeforeDate)
+	total, err := s.auditLogRepository.CountGlobal(filter)
 	if err != nil {
 		return nil, err
 	}
@@ -82,18 +115,19 @@ func (s *AuditLogService) GetUserAuditLogs(
 		return nil, errors.New("insufficient permissions to view user audit logs")
 	}
 
-	limit := request.Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+	filter, err := toAuditLogFilter(request)
+	if err != nil {
+		return nil, err
 	}
 
+	limit := getAuditLogsLimit(request.Limit, defaultAuditLogsPageSize, maxAuditLogsPageSize)
 	offset := max(request.Offset, 0)
 
 	auditLogs, err := s.auditLogRepository.GetByUser(
 		targetUserID,
 		limit,
 		offset,
-		request.BeforeDate,
+		filter,
 	)
 	if err != nil {
 		return nil, err
@@ -107,22 +141,25 @@ func (s *AuditLogService) GetUserAuditLogs(
 	}, nil
 }
 
+// GetWorkspaceAuditLogs does not check permissions, it is done by workspace
+// service before the call
 func (s *AuditLogService) GetWorkspaceAuditLogs(
 	workspaceID uuid.UUID,
 	request *GetAuditLogsRequest,
 ) (*GetAuditLogsResponse, error) {
-	limit := request.Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+	filter, err := toAuditLogFilter(request)
+	if err != nil {
+		return nil, err
 	}
 
+	limit := getAuditLogsLimit(request.Limit, defaultAuditLogsPageSize, maxAuditLogsPageSize)
 	offset := max(request.Offset, 0)
 
 	auditLogs, err := s.auditLogRepository.GetByWorkspace(
 		workspaceID,
 		limit,
 		offset,
-		request.BeforeDate,
+		filter,
 	)
 	if err != nil {
 		return nil, err
@@ -135,3 +172,122 @@ func (s *AuditLogService) GetWorkspaceAuditLogs(
 		Offset:    offset,
 	}, nil
 }
+
+func (s *AuditLogService) GetGlobalAuditLogsForExport(
+	user *user_models.User,
+	request *GetAuditLogsRequest,
+) ([]*AuditLogDTO, error) {
+	if user.Role != user_enums.UserRoleAdmin {
+		return nil, errors.New("only administrators can view global audit logs")
+	}
+
+	filter, err := toAuditLogFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auditLogRepository.GetGlobal(
+		getAuditLogsLimit(request.Limit, maxExportedAuditLogs, maxExportedAuditLogs),
+		max(request.Offset, 0),
+		filter,
+	)
+}
+
+// GetWorkspaceAuditLogsForExport does not check permissions, it is done by
+// workspace service before the call
+func (s *AuditLogService) GetWorkspaceAuditLogsForExport(
+	workspaceID uuid.UUID,
+	request *GetAuditLogsRequest,
+) ([]*AuditLogDTO, error) {
+	filter, err := toAuditLogFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auditLogRepository.GetByWorkspace(
+		workspaceID,
+		getAuditLogsLimit(request.Limit, maxExportedAuditLogs, maxExportedAuditLogs),
+		max(request.Offset, 0),
+		filter,
+	)
+}
+
+// VerifyChain recalculates hashes of all logs in the order of the chain.
+// Logs written before the chain was introduced have no hash and are skipped
+func (s *AuditLogService) VerifyChain(
+	user *user_models.User,
+) (*AuditLogChainVerificationDTO, error) {
+	if user.Role != user_enums.UserRoleAdmin {
+		return nil, errors.New("only administrators can verify audit logs")
+	}
+
+	result := &AuditLogChainVerificationDTO{IsValid: true}
+	verifier := &AuditLogChainVerifier{}
+
+	lastSequence := int64(0)
+	for {
+		auditLogs, err := s.auditLogRepository.GetChainAfter(lastSequence, chainVerificationBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(auditLogs) == 0 {
+			break
+		}
+
+		for _, auditLog := range auditLogs {
+			if err := verifier.Verify(auditLog); err != nil {
+				errorMessage := err.Error()
+				result.IsValid = false
+				result.BrokenSequence = &auditLog.Sequence
+				result.Error = &errorMessage
+				result.CheckedCount = verifier.CheckedCount
+
+				return result, nil
+			}
+		}
+
+		lastSequence = auditLogs[len(auditLogs)-1].Sequence
+	}
+
+	result.CheckedCount = verifier.CheckedCount
+	result.LastSequence = verifier.LastSequence
+	result.LastHash = verifier.LastHash
+
+	return result, nil
+}
+
+func toAuditLogFilter(request *GetAuditLogsRequest) (*AuditLogFilter, error) {
+	filter := &AuditLogFilter{
+		BeforeDate:   request.BeforeDate,
+		Action:       request.Action,
+		ResourceType: request.ResourceType,
+	}
+
+	if request.Action != "" && !request.Action.IsValid() {
+		return nil, errors.New("invalid action")
+	}
+
+	if request.ResourceType != "" && !request.ResourceType.IsValid() {
+		return nil, errors.New("invalid resource type")
+	}
+
+	if request.ResourceID != "" {
+		resourceID, err := uuid.Parse(request.ResourceID)
+		if err != nil {
+			return nil, errors.New("invalid resource ID")
+		}
+
+		filter.ResourceID = &resourceID
+	}
+
+	return filter, nil
+}
+
+func getAuditLogsLimit(limit int, defaultLimit int, maxLimit int) int {
+	if limit <= 0 || limit > maxLimit {
+		return defaultLimit
+	}
+
+	return limit
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	user_enums "postgresus-backend/internal/features/users/enums"
 	users_testing "postgresus-backend/internal/features/users/testing"
 
@@ -71,7 +72,14 @@ func Test_AuditLogs_WorkspaceSpecificLogs(t *testing.T) {
 }
 
 func createAuditLog(service *AuditLogService, message string, userID, workspaceID *uuid.UUID) {
-	service.WriteAuditLog(message, userID, workspaceID)
+	service.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceWorkspace,
+		ResourceID:   workspaceID,
+		Message:      message,
+		UserID:       userID,
+		WorkspaceID:  workspaceID,
+	})
 }
 
 func extractMessages(logs []*AuditLogDTO) []string {
@@ -6,10 +6,16 @@ import (
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/util/encryption"
-	"postgresus-backend/internal/util/period"
 	"time"
 )
 
+const (
+	// every backup file is re-read once in this period to detect silent
+	// corruption in storages
+	integrityCheckInterval  = 7 * 24 * time.Hour
+	integrityCheckBatchSize = 10
+)
+
 type BackupBackgroundService struct {
 	backupService       *BackupService
 	backupRepository    *BackupRepository
@@ -32,6 +38,10 @@ func (s *BackupBackgroundService) Run() {
 		return
 	}
 
+	// files are read slowly, so integrity checks must not delay scheduled
+	// backups
+	go s.runIntegrityChecks()
+
 	for {
 		if config.IsShouldShutdown() {
 			return
@@ -83,6 +93,8 @@ func (s *BackupBackgroundService) failBackupsInProgress() error {
 		if err := s.backupRepository.Save(backup); err != nil {
 			return err
 		}
+
+		s.backupService.failStorageCopies(backup, failMessage)
 	}
 
 	return nil
@@ -95,22 +107,10 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 	}
 
 	for _, backupConfig := range enabledBackupConfigs {
-		backupStorePeriod := backupConfig.StorePeriod
-
-		if backupStorePeriod == period.PeriodForever {
-			continue
-		}
-
-		storeDuration := backupStorePeriod.ToDuration()
-		dateBeforeBackupsShouldBeDeleted := time.Now().UTC().Add(-storeDuration)
-
-		oldBackups, err := s.backupRepository.FindBackupsBeforeDate(
-			backupConfig.DatabaseID,
-			dateBeforeBackupsShouldBeDeleted,
-		)
+		databaseBackups, err := s.backupRepository.FindByDatabaseID(backupConfig.DatabaseID)
 		if err != nil {
 			s.logger.Error(
-				"Failed to find old backups for database",
+				"Failed to find backups for database",
 				"databaseId",
 				backupConfig.DatabaseID,
 				"error",
@@ -119,23 +119,35 @@ func (s *BackupBackgroundService) cleanOldBackups() error {
 			continue
 		}
 
-		for _, backup := range oldBackups {
-			storage, err := s.storageService.GetStorageByID(backup.StorageID)
-			if err != nil {
-				s.logger.Error(
-					"Failed to get storage by ID",
-					"storageId",
-					backup.StorageID,
-					"error",
-					err,
-				)
-				continue
-			}
+		oldBackups := GetBackupsToDelete(backupConfig, databaseBackups, time.Now().UTC())
 
-			encryptor := encryption.GetFieldEncryptor()
-			err = storage.DeleteFile(encryptor, backup.ID)
-			if err != nil {
-				s.logger.Error("Failed to delete backup file", "backupId", backup.ID, "error", err)
+		for _, backup := range oldBackups {
+			for _, storageID := range backup.GetCopiesStorageIDs() {
+				storage, err := s.storageService.GetStorageByID(storageID)
+				if err != nil {
+					s.logger.Error(
+						"Failed to get storage by ID",
+						"storageId",
+						storageID,
+						"error",
+						err,
+					)
+					continue
+				}
+
+				encryptor := encryption.GetFieldEncryptor()
+				err = storage.DeleteFile(encryptor, backup.GetFileID())
+				if err != nil {
+					s.logger.Error(
+						"Failed to delete backup file",
+						"backupId",
+						backup.ID,
+						"storageId",
+						storageID,
+						"error",
+						err,
+					)
+				}
 			}
 
 			if err := s.backupRepository.DeleteByID(backup.ID); err != nil {
@@ -208,6 +220,53 @@ func (s *BackupBackgroundService) runPendingBackups() error {
 	return nil
 }
 
+func (s *BackupBackgroundService) runIntegrityChecks() {
+	for {
+		if config.IsShouldShutdown() {
+			return
+		}
+
+		if err := s.checkBackupsIntegrity(); err != nil {
+			s.logger.Error("Failed to check backups integrity", "error", err)
+		}
+
+		time.Sleep(1 * time.Hour)
+	}
+}
+
+func (s *BackupBackgroundService) checkBackupsIntegrity() error {
+	backupsToCheck, err := s.backupRepository.FindForIntegrityCheck(
+		time.Now().UTC().Add(-integrityCheckInterval),
+		integrityCheckBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range backupsToCheck {
+		if config.IsShouldShutdown() {
+			return nil
+		}
+
+		if err := s.backupService.VerifyBackupFile(backup); err != nil {
+			s.logger.Error(
+				"Failed to verify backup file",
+				"backupId",
+				backup.ID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		if backup.IntegrityStatus == BackupIntegrityStatusCorrupted {
+			s.logger.Warn("Backup file is corrupted", "backupId", backup.ID)
+		}
+	}
+
+	return nil
+}
+
 // GetRemainedBackupTryCount returns the number of remaining backup tries for a given backup.
 // If the backup is not failed or the backup config does not allow retries, it returns 0.
 // If the backup is failed and the backup config allows retries, it returns the number of remaining tries.
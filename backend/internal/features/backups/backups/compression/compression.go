@@ -0,0 +1,128 @@
+package compression
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// NewCompressionWriter compresses data written to the returned writer into
+// the base writer. Close must be called to flush the compressed stream, it
+// does not close the base writer
+func NewCompressionWriter(
+	baseWriter io.Writer,
+	algorithm backups_config.BackupCompression,
+	level int,
+) (io.WriteCloser, error) {
+	switch algorithm {
+	case backups_config.BackupCompressionNone:
+		return nopWriteCloser{baseWriter}, nil
+	case backups_config.BackupCompressionGzip:
+		gzipWriter, err := gzip.NewWriterLevel(baseWriter, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+		}
+
+		return gzipWriter, nil
+	case backups_config.BackupCompressionZstd:
+		zstdWriter, err := zstd.NewWriter(
+			baseWriter,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+
+		return zstdWriter, nil
+	case backups_config.BackupCompressionLz4:
+		lz4Writer := lz4.NewWriter(baseWriter)
+
+		err := lz4Writer.Apply(lz4.CompressionLevelOption(getLz4Level(level)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lz4 writer: %w", err)
+		}
+
+		return lz4Writer, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", algorithm)
+	}
+}
+
+// NewDecompressionReader decompresses data read from the base reader. Close
+// releases decoder resources and does not close the base reader
+func NewDecompressionReader(
+	baseReader io.Reader,
+	algorithm backups_config.BackupCompression,
+) (io.ReadCloser, error) {
+	switch algorithm {
+	case backups_config.BackupCompressionNone:
+		return io.NopCloser(baseReader), nil
+	case backups_config.BackupCompressionGzip:
+		gzipReader, err := gzip.NewReader(baseReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+
+		return gzipReader, nil
+	case backups_config.BackupCompressionZstd:
+		zstdReader, err := zstd.NewReader(baseReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+
+		return zstdReader.IOReadCloser(), nil
+	case backups_config.BackupCompressionLz4:
+		return io.NopCloser(lz4.NewReader(baseReader)), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", algorithm)
+	}
+}
+
+// GetFileExtension returns extension of files compressed with the algorithm
+func GetFileExtension(algorithm backups_config.BackupCompression) string {
+	switch algorithm {
+	case backups_config.BackupCompressionGzip:
+		return ".gz"
+	case backups_config.BackupCompressionZstd:
+		return ".zst"
+	case backups_config.BackupCompressionLz4:
+		return ".lz4"
+	default:
+		return ""
+	}
+}
+
+// getLz4Level maps level 1-9 used by lz4 CLI and pg_dump to the encoder
+// level, 1 is the fast mode
+func getLz4Level(level int) lz4.CompressionLevel {
+	levels := []lz4.CompressionLevel{
+		lz4.Level1,
+		lz4.Level2,
+		lz4.Level3,
+		lz4.Level4,
+		lz4.Level5,
+		lz4.Level6,
+		lz4.Level7,
+		lz4.Level8,
+		lz4.Level9,
+	}
+
+	if level <= 1 {
+		return lz4.Fast
+	}
+
+	return levels[min(level, len(levels))-1]
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,56 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CompressionWriter_DecompressionReader_RoundTripForEveryAlgorithm(t *testing.T) {
+	content := []byte(strings.Repeat("INSERT INTO users VALUES (1, 'user@example.com');\n", 10000))
+
+	algorithms := []backups_config.BackupCompression{
+		backups_config.BackupCompressionNone,
+		backups_config.BackupCompressionGzip,
+		backups_config.BackupCompressionZstd,
+		backups_config.BackupCompressionLz4,
+	}
+
+	for _, algorithm := range algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			var compressed bytes.Buffer
+
+			writer, err := NewCompressionWriter(&compressed, algorithm, 5)
+			require.NoError(t, err)
+
+			_, err = writer.Write(content)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			if algorithm != backups_config.BackupCompressionNone {
+				assert.Less(t, compressed.Len(), len(content)/10)
+			}
+
+			reader, err := NewDecompressionReader(&compressed, algorithm)
+			require.NoError(t, err)
+			defer func() {
+				_ = reader.Close()
+			}()
+
+			decompressed, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, content, decompressed)
+		})
+	}
+}
+
+func Test_NewCompressionWriter_UnsupportedAlgorithm_ReturnsError(t *testing.T) {
+	_, err := NewCompressionWriter(io.Discard, "BROTLI", 5)
+	assert.EqualError(t, err, "unsupported compression: BROTLI")
+}
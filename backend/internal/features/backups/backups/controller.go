@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"postgresus-backend/internal/features/databases"
 	users_middleware "postgresus-backend/internal/features/users/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -18,9 +17,11 @@ type BackupController struct {
 func (c *BackupController) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/backups", c.GetBackups)
 	router.POST("/backups", c.MakeBackup)
+	router.GET("/backups/retention-preview", c.GetRetentionPreview)
 	router.GET("/backups/:id/file", c.GetFile)
 	router.DELETE("/backups/:id", c.DeleteBackup)
 	router.POST("/backups/:id/cancel", c.CancelBackup)
+	router.POST("/backups/:id/verify-file", c.VerifyBackupFile)
 }
 
 // GetBackups
@@ -97,12 +98,51 @@ func (c *BackupController) MakeBackup(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "backup started successfully"})
 }
 
+// GetRetentionPreview
+// @Summary Preview retention cleanup
+// @Description Get backups which will be deleted by the next retention cleanup of the database
+// @Tags backups
+// @Produce json
+// @Param database_id query string true "Database ID"
+// @Success 200 {object} GetRetentionPreviewResponse
+// @Failure 400
+// @Failure 401
+// @Router /backups/retention-preview [get]
+func (c *BackupController) GetRetentionPreview(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request GetRetentionPreviewRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	databaseID, err := uuid.Parse(request.DatabaseID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid database_id"})
+		return
+	}
+
+	response, err := c.backupService.GetRetentionPreview(user, databaseID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
 // DeleteBackup
 // @Summary Delete a backup
-// @Description Delete an existing backup
+// @Description Delete an existing backup. When deletions of the database backups require approval, the approval request is created instead
 // @Tags backups
 // @Param id path string true "Backup ID"
 // @Success 204
+// @Success 202 {object} map[string]string
 // @Failure 400
 // @Failure 401
 // @Failure 500
@@ -120,11 +160,20 @@ func (c *BackupController) DeleteBackup(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.backupService.DeleteBackup(user, id); err != nil {
+	approvalRequest, err := c.backupService.DeleteBackup(user, id)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if approvalRequest != nil {
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"message":           "backup deletion is waiting for approval",
+			"approvalRequestId": approvalRequest.ID,
+		})
+		return
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -159,6 +208,37 @@ func (c *BackupController) CancelBackup(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// VerifyBackupFile
+// @Summary Verify integrity of a backup file
+// @Description Re-read every copy of the backup and compare its SHA-256 with the recorded one. The check runs in background, result is saved on the backup
+// @Tags backups
+// @Param id path string true "Backup ID"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Router /backups/{id}/verify-file [post]
+func (c *BackupController) VerifyBackupFile(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid backup ID"})
+		return
+	}
+
+	if err := c.backupService.VerifyBackupFileWithAuth(user, id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 // GetFile
 // @Summary Download a backup file
 // @Description Download the backup file for the specified backup
@@ -182,7 +262,7 @@ func (c *BackupController) GetFile(ctx *gin.Context) {
 		return
 	}
 
-	fileReader, dbType, err := c.backupService.GetBackupFile(user, id)
+	fileReader, extension, err := c.backupService.GetBackupFile(user, id)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -193,11 +273,6 @@ func (c *BackupController) GetFile(ctx *gin.Context) {
 		}
 	}()
 
-	extension := ".dump.zst"
-	if dbType == databases.DatabaseTypeMysql {
-		extension = ".sql.zst"
-	}
-
 	ctx.Header("Content-Type", "application/octet-stream")
 	ctx.Header(
 		"Content-Disposition",
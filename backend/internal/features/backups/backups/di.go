@@ -1,8 +1,10 @@
 package backups
 
 import (
+	"sync"
 	"time"
 
+	"postgresus-backend/internal/features/approvals"
 	audit_logs "postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups/usecases"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -32,8 +34,11 @@ var backupService = &BackupService{
 	logger.GetLogger(),
 	[]BackupRemoveListener{},
 	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 	audit_logs.GetAuditLogService(),
+	approvals.GetApprovalService(),
 	backupContextManager,
+	&sync.Map{},
 }
 
 var backupBackgroundService = &BackupBackgroundService{
@@ -56,6 +61,11 @@ func SetupDependencies() {
 
 	databases.GetDatabaseService().AddDbRemoveListener(backupService)
 	databases.GetDatabaseService().AddDbCopyListener(backups_config.GetBackupConfigService())
+
+	approvals.GetApprovalService().SetActionExecutor(
+		approvals.ApprovalActionDeleteBackup,
+		backupService,
+	)
 }
 
 func GetBackupService() *BackupService {
@@ -18,6 +18,14 @@ type GetBackupsResponse struct {
 	Offset  int       `json:"offset"`
 }
 
+type GetRetentionPreviewRequest struct {
+	DatabaseID string `form:"database_id" binding:"required"`
+}
+
+type GetRetentionPreviewResponse struct {
+	BackupsToDelete []*Backup `json:"backupsToDelete"`
+}
+
 type decryptionReaderCloser struct {
 	*encryption.DecryptionReader
 	baseReader io.ReadCloser
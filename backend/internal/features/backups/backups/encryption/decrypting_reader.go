@@ -117,17 +117,23 @@ func (r *DecryptionReader) readAndValidateHeader(expectedSalt, expectedNonce []b
 func (r *DecryptionReader) readAndDecryptChunk() error {
 	lengthBuf := make([]byte, 4)
 	if _, err := io.ReadFull(r.baseReader, lengthBuf); err != nil {
-		return err
+		// clean EOF means there are no more chunks, while partially read
+		// length means the file is truncated
+		if err == io.EOF {
+			return err
+		}
+
+		return fmt.Errorf("failed to read length of chunk %d: %w", r.chunkIndex, err)
 	}
 
 	chunkLen := binary.BigEndian.Uint32(lengthBuf)
 	if chunkLen == 0 || chunkLen > ChunkSize+16 {
-		return fmt.Errorf("invalid chunk length: %d", chunkLen)
+		return fmt.Errorf("invalid length of chunk %d: %d", r.chunkIndex, chunkLen)
 	}
 
 	encrypted := make([]byte, chunkLen)
 	if _, err := io.ReadFull(r.baseReader, encrypted); err != nil {
-		return fmt.Errorf("failed to read encrypted chunk: %w", err)
+		return fmt.Errorf("failed to read encrypted chunk %d: %w", r.chunkIndex, err)
 	}
 
 	chunkNonce := r.generateChunkNonce()
@@ -135,7 +141,8 @@ func (r *DecryptionReader) readAndDecryptChunk() error {
 	decrypted, err := r.cipher.Open(nil, chunkNonce, encrypted, nil)
 	if err != nil {
 		return fmt.Errorf(
-			"failed to decrypt chunk (authentication failed - file may be corrupted or tampered): %w",
+			"failed to decrypt chunk %d (authentication failed - file may be corrupted or tampered): %w",
+			r.chunkIndex,
 			err,
 		)
 	}
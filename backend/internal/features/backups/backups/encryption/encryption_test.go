@@ -385,3 +385,43 @@ func Test_DecryptionReader_SmallReads_WorksCorrectly(t *testing.T) {
 
 	assert.Equal(t, originalData, decrypted)
 }
+
+func Test_DecryptionReader_TruncatedChunk_ReturnsErrorWithChunkIndex(t *testing.T) {
+	masterKey := uuid.New().String() + uuid.New().String()
+	backupID := uuid.New()
+	salt, err := GenerateSalt()
+	require.NoError(t, err)
+	nonce, err := GenerateNonce()
+	require.NoError(t, err)
+
+	originalData := make([]byte, ChunkSize*2+100)
+	_, err = rand.Read(originalData)
+	require.NoError(t, err)
+
+	var encrypted bytes.Buffer
+	writer, err := NewEncryptionWriter(&encrypted, masterKey, backupID, salt, nonce)
+	require.NoError(t, err)
+
+	_, err = writer.Write(originalData)
+	require.NoError(t, err)
+
+	err = writer.Close()
+	require.NoError(t, err)
+
+	// cut the file in the middle of the second chunk
+	encryptedBytes := encrypted.Bytes()
+	truncatedBytes := encryptedBytes[:HeaderLen+4+ChunkSize+16+100]
+
+	reader, err := NewDecryptionReader(
+		bytes.NewReader(truncatedBytes),
+		masterKey,
+		backupID,
+		salt,
+		nonce,
+	)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(reader)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "chunk 1")
+}
@@ -8,3 +8,29 @@ const (
 	BackupStatusFailed     BackupStatus = "FAILED"
 	BackupStatusCanceled   BackupStatus = "CANCELED"
 )
+
+type BackupStorageCopyStatus string
+
+const (
+	BackupStorageCopyStatusInProgress BackupStorageCopyStatus = "IN_PROGRESS"
+	BackupStorageCopyStatusCompleted  BackupStorageCopyStatus = "COMPLETED"
+	BackupStorageCopyStatusFailed     BackupStorageCopyStatus = "FAILED"
+	BackupStorageCopyStatusCorrupted  BackupStorageCopyStatus = "CORRUPTED"
+)
+
+type BackupIntegrityStatus string
+
+const (
+	BackupIntegrityStatusNotChecked  BackupIntegrityStatus = "NOT_CHECKED"
+	BackupIntegrityStatusValid       BackupIntegrityStatus = "VALID"
+	BackupIntegrityStatusCorrupted   BackupIntegrityStatus = "CORRUPTED"
+	BackupIntegrityStatusCheckFailed BackupIntegrityStatus = "CHECK_FAILED"
+)
+
+type VerificationStatus string
+
+const (
+	VerificationStatusInProgress VerificationStatus = "IN_PROGRESS"
+	VerificationStatusSuccess    VerificationStatus = "SUCCESS"
+	VerificationStatusFailed     VerificationStatus = "FAILED"
+)
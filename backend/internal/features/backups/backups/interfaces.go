@@ -7,6 +7,7 @@ import (
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/features/storages"
 
 	"github.com/google/uuid"
@@ -15,8 +16,12 @@ import (
 type NotificationSender interface {
 	SendNotification(
 		notifier *notifiers.Notifier,
-		title string,
-		message string,
+		event *notifiers_events.NotificationEvent,
+	)
+
+	ResetFailureState(
+		notifier *notifiers.Notifier,
+		event *notifiers_events.NotificationEvent,
 	)
 }
 
@@ -26,7 +31,7 @@ type CreateBackupUsecase interface {
 		backupID uuid.UUID,
 		backupConfig *backups_config.BackupConfig,
 		database *databases.Database,
-		storage *storages.Storage,
+		backupStorages []*storages.Storage,
 		backupProgressListener func(completedMBs float64),
 	) (*usecases_common.BackupMetadata, error)
 }
@@ -2,6 +2,7 @@ package backups
 
 import (
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,8 +13,14 @@ type MockNotificationSender struct {
 
 func (m *MockNotificationSender) SendNotification(
 	notifier *notifiers.Notifier,
-	title string,
-	message string,
+	event *notifiers_events.NotificationEvent,
 ) {
-	m.Called(notifier, title, message)
+	m.Called(notifier, event)
+}
+
+func (m *MockNotificationSender) ResetFailureState(
+	notifier *notifiers.Notifier,
+	event *notifiers_events.NotificationEvent,
+) {
+	m.Called(notifier, event)
 }
@@ -1,21 +1,29 @@
 package backups
 
 import (
+	"encoding/json"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Backup struct {
 	ID uuid.UUID `json:"id" gorm:"column:id;type:uuid;primaryKey"`
 
 	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;not null"`
-	StorageID  uuid.UUID `json:"storageId"  gorm:"column:storage_id;type:uuid;not null"`
+
+	// StorageID is the primary storage of the backup config, or the first
+	// storage with a completed copy when the primary one failed
+	StorageID uuid.UUID `json:"storageId" gorm:"column:storage_id;type:uuid;not null"`
 
 	Status      BackupStatus `json:"status"      gorm:"column:status;not null"`
 	FailMessage *string      `json:"failMessage" gorm:"column:fail_message"`
 
+	BackupType backups_config.BackupType `json:"backupType" gorm:"column:backup_type;type:text;not null;default:'LOGICAL'"`
+
 	BackupSizeMb float64 `json:"backupSizeMb" gorm:"column:backup_size_mb;default:0"`
 
 	BackupDurationMs int64 `json:"backupDurationMs" gorm:"column:backup_duration_ms;default:0"`
@@ -24,5 +32,181 @@ type Backup struct {
 	EncryptionIV   *string                         `json:"-"          gorm:"column:encryption_iv"`
 	Encryption     backups_config.BackupEncryption `json:"encryption" gorm:"column:encryption;type:text;not null;default:'NONE'"`
 
+	// EncryptionKeyVersion is the master key version, empty for files
+	// encrypted before master keys were versioned
+	EncryptionKeyVersion *int `json:"-" gorm:"column:encryption_key_version"`
+
+	// FileID is the name of the file in storages. Re-encryption uploads the
+	// file under a new name, so the old file stays readable until the new
+	// encryption metadata is saved. Empty for files named by the backup ID
+	FileID *uuid.UUID `json:"-" gorm:"column:file_id;type:uuid"`
+
+	// Compression of the file in storage. PostgreSQL custom format dumps are
+	// compressed inside the format, so pg_restore reads them as is
+	Compression backups_config.BackupCompression `json:"compression" gorm:"column:compression;type:text;not null;default:'ZSTD'"`
+
+	// Sha256 is computed over the file content before encryption, so the same
+	// hash is expected from every copy. Backups made before checksums were
+	// introduced have no hash
+	Sha256               *string               `json:"sha256"               gorm:"column:sha256"`
+	IntegrityStatus      BackupIntegrityStatus `json:"integrityStatus"      gorm:"column:integrity_status;type:text;not null;default:'NOT_CHECKED'"`
+	IntegrityFailMessage *string               `json:"integrityFailMessage" gorm:"column:integrity_fail_message"`
+	IntegrityCheckedAt   *time.Time            `json:"integrityCheckedAt"   gorm:"column:integrity_checked_at"`
+
+	// BinlogFile and BinlogPosition are the start of binary logs chained to
+	// this MySQL or MariaDB dump. Empty when binlog archiving is disabled
+	BinlogFile     *string `json:"binlogFile"     gorm:"column:binlog_file"`
+	BinlogPosition *int64  `json:"binlogPosition" gorm:"column:binlog_position"`
+
+	// IsOplogIncluded is set for MongoDB dumps containing oplog entries
+	// written during the dump, they can be restored to a point in time
+	IsOplogIncluded bool `json:"isOplogIncluded" gorm:"column:is_oplog_included;not null;default:false"`
+
+	// every storage the backup was replicated to has its own copy with
+	// status and size
+	StorageCopies []*BackupStorageCopy `json:"storageCopies" gorm:"foreignKey:BackupID"`
+
+	// results of restore drills made from this backup, newest first
+	VerificationResults []*VerificationResult `json:"verificationResults" gorm:"foreignKey:BackupID"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+// GetFileID returns the name of the backup file in storages
+func (b *Backup) GetFileID() uuid.UUID {
+	if b.FileID != nil {
+		return *b.FileID
+	}
+
+	return b.ID
+}
+
+// GetCopiesStorageIDs returns storages of all copies, including failed ones
+func (b *Backup) GetCopiesStorageIDs() []uuid.UUID {
+	if len(b.StorageCopies) == 0 {
+		return []uuid.UUID{b.StorageID}
+	}
+
+	storageIDs := make([]uuid.UUID, 0, len(b.StorageCopies))
+	for _, storageCopy := range b.StorageCopies {
+		storageIDs = append(storageIDs, storageCopy.StorageID)
+	}
+
+	return storageIDs
+}
+
+// GetCompletedCopiesStorageIDs returns storages with completed copies. The
+// storage of the backup goes first, others follow in order of creation
+func (b *Backup) GetCompletedCopiesStorageIDs() []uuid.UUID {
+	if len(b.StorageCopies) == 0 {
+		return []uuid.UUID{b.StorageID}
+	}
+
+	storageIDs := make([]uuid.UUID, 0, len(b.StorageCopies))
+
+	for _, storageCopy := range b.StorageCopies {
+		if storageCopy.Status == BackupStorageCopyStatusCompleted &&
+			storageCopy.StorageID == b.StorageID {
+			storageIDs = append(storageIDs, storageCopy.StorageID)
+		}
+	}
+
+	for _, storageCopy := range b.StorageCopies {
+		if storageCopy.Status == BackupStorageCopyStatusCompleted &&
+			storageCopy.StorageID != b.StorageID {
+			storageIDs = append(storageIDs, storageCopy.StorageID)
+		}
+	}
+
+	return storageIDs
+}
+
+func (b *Backup) ToNotificationEventBackup() *notifiers_events.EventBackup {
+	return &notifiers_events.EventBackup{
+		ID:         b.ID,
+		SizeMb:     b.BackupSizeMb,
+		DurationMs: b.BackupDurationMs,
+		CreatedAt:  b.CreatedAt,
+	}
+}
+
+func (b *Backup) getStorageCopy(storageID uuid.UUID) *BackupStorageCopy {
+	for _, storageCopy := range b.StorageCopies {
+		if storageCopy.StorageID == storageID {
+			return storageCopy
+		}
+	}
+
+	return nil
+}
+
+type BackupStorageCopy struct {
+	ID        uuid.UUID `json:"id"        gorm:"column:id;type:uuid;primaryKey"`
+	BackupID  uuid.UUID `json:"backupId"  gorm:"column:backup_id;type:uuid;not null"`
+	StorageID uuid.UUID `json:"storageId" gorm:"column:storage_id;type:uuid;not null"`
+
+	Status      BackupStorageCopyStatus `json:"status"      gorm:"column:status;not null"`
+	FailMessage *string                 `json:"failMessage" gorm:"column:fail_message"`
+
+	BackupSizeMb float64 `json:"backupSizeMb" gorm:"column:backup_size_mb;default:0"`
+
 	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
 }
+
+func (BackupStorageCopy) TableName() string {
+	return "backup_storage_copies"
+}
+
+// VerificationResult is the outcome of restoring the backup into a scratch
+// database and running sanity checks against it
+type VerificationResult struct {
+	ID         uuid.UUID `json:"id"         gorm:"column:id;type:uuid;primaryKey"`
+	BackupID   uuid.UUID `json:"backupId"   gorm:"column:backup_id;type:uuid;not null"`
+	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;not null"`
+
+	Status      VerificationStatus `json:"status"      gorm:"column:status;not null"`
+	FailMessage *string            `json:"failMessage" gorm:"column:fail_message"`
+
+	CheckResults       []*VerificationCheckResult `json:"checkResults" gorm:"-"`
+	CheckResultsString string                     `json:"-"            gorm:"column:check_results;type:text;not null;default:'[]'"`
+
+	DurationMs int64     `json:"durationMs" gorm:"column:duration_ms;default:0"`
+	CreatedAt  time.Time `json:"createdAt"  gorm:"column:created_at"`
+}
+
+type VerificationCheckResult struct {
+	Name          string  `json:"name"`
+	Value         *string `json:"value"`
+	ExpectedValue *string `json:"expectedValue"`
+	IsPassed      bool    `json:"isPassed"`
+	Error         *string `json:"error"`
+}
+
+func (VerificationResult) TableName() string {
+	return "backup_verification_results"
+}
+
+func (v *VerificationResult) BeforeSave(_ *gorm.DB) error {
+	if v.CheckResults == nil {
+		v.CheckResults = []*VerificationCheckResult{}
+	}
+
+	checkResults, err := json.Marshal(v.CheckResults)
+	if err != nil {
+		return err
+	}
+
+	v.CheckResultsString = string(checkResults)
+
+	return nil
+}
+
+func (v *VerificationResult) AfterFind(_ *gorm.DB) error {
+	v.CheckResults = []*VerificationCheckResult{}
+
+	if v.CheckResultsString == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(v.CheckResultsString), &v.CheckResults)
+}
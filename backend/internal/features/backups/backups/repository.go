@@ -2,6 +2,7 @@ package backups
 
 import (
 	"errors"
+	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/storage"
 
 	"time"
@@ -22,11 +23,31 @@ func (r *BackupRepository) Save(backup *Backup) error {
 	isNew := backup.ID == uuid.Nil
 	if isNew {
 		backup.ID = uuid.New()
-		return db.Create(backup).
+		return db.Omit("StorageCopies", "VerificationResults").
+			Create(backup).
 			Error
 	}
 
-	return db.Save(backup).
+	return db.Omit("StorageCopies", "VerificationResults").
+		Save(backup).
+		Error
+}
+
+func (r *BackupRepository) SaveStorageCopy(storageCopy *BackupStorageCopy) error {
+	if storageCopy.BackupID == uuid.Nil || storageCopy.StorageID == uuid.Nil {
+		return errors.New("backup ID and storage ID are required")
+	}
+
+	db := storage.GetDb()
+
+	isNew := storageCopy.ID == uuid.Nil
+	if isNew {
+		storageCopy.ID = uuid.New()
+		return db.Create(storageCopy).
+			Error
+	}
+
+	return db.Save(storageCopy).
 		Error
 }
 
@@ -35,6 +56,7 @@ func (r *BackupRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*Backup, er
 
 	if err := storage.
 		GetDb().
+		Preload("StorageCopies").
 		Where("database_id = ?", databaseID).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -103,6 +125,8 @@ func (r *BackupRepository) FindByID(id uuid.UUID) (*Backup, error) {
 
 	if err := storage.
 		GetDb().
+		Preload("StorageCopies").
+		Preload("VerificationResults", orderByCreatedAtDesc).
 		Where("id = ?", id).
 		First(&backup).Error; err != nil {
 		return nil, err
@@ -116,6 +140,7 @@ func (r *BackupRepository) FindByStatus(status BackupStatus) ([]*Backup, error)
 
 	if err := storage.
 		GetDb().
+		Preload("StorageCopies").
 		Where("status = ?", status).
 		Order("created_at DESC").
 		Find(&backups).Error; err != nil {
@@ -159,6 +184,188 @@ func (r *BackupRepository) FindByDatabaseIdAndStatus(
 	return backups, nil
 }
 
+func (r *BackupRepository) FindOldestByDatabaseIdAndStatusAndType(
+	databaseID uuid.UUID,
+	status BackupStatus,
+	backupType backups_config.BackupType,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Where(
+			"database_id = ? AND status = ? AND backup_type = ?",
+			databaseID,
+			status,
+			backupType,
+		).
+		Order("created_at ASC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+func (r *BackupRepository) FindOldestWithBinlogPositionByDatabaseIdAndStatus(
+	databaseID uuid.UUID,
+	status BackupStatus,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Where(
+			"database_id = ? AND status = ? AND binlog_file IS NOT NULL",
+			databaseID,
+			status,
+		).
+		Order("created_at ASC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+func (r *BackupRepository) FindLastByDatabaseIdAndStatusAndType(
+	databaseID uuid.UUID,
+	status BackupStatus,
+	backupType backups_config.BackupType,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Preload("StorageCopies").
+		Where(
+			"database_id = ? AND status = ? AND backup_type = ?",
+			databaseID,
+			status,
+			backupType,
+		).
+		Order("created_at DESC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+// FindForIntegrityCheck returns completed backups which were never checked or
+// checked before the date. Never checked backups go first
+func (r *BackupRepository) FindForIntegrityCheck(
+	checkedBefore time.Time,
+	limit int,
+) ([]*Backup, error) {
+	var backups []*Backup
+
+	if err := storage.
+		GetDb().
+		Preload("StorageCopies").
+		Where("status = ?", BackupStatusCompleted).
+		Where("integrity_checked_at IS NULL OR integrity_checked_at < ?", checkedBefore).
+		Order("integrity_checked_at ASC NULLS FIRST").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&backups).Error; err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+// FindEncryptedWithRetiredKey returns completed encrypted backups made with
+// other master key than the current one. Empty version is the legacy key
+func (r *BackupRepository) FindEncryptedWithRetiredKey(
+	currentKeyVersion int,
+	limit int,
+) ([]*Backup, error) {
+	var backups []*Backup
+
+	if err := storage.
+		GetDb().
+		Preload("StorageCopies").
+		Where("status = ?", BackupStatusCompleted).
+		Where("encryption = ?", backups_config.BackupEncryptionEncrypted).
+		Where(
+			"encryption_key_version IS NULL OR encryption_key_version <> ?",
+			currentKeyVersion,
+		).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&backups).Error; err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+func (r *BackupRepository) SaveVerificationResult(result *VerificationResult) error {
+	if result.BackupID == uuid.Nil || result.DatabaseID == uuid.Nil {
+		return errors.New("backup ID and database ID are required")
+	}
+
+	db := storage.GetDb()
+
+	isNew := result.ID == uuid.Nil
+	if isNew {
+		result.ID = uuid.New()
+		return db.Create(result).
+			Error
+	}
+
+	return db.Save(result).
+		Error
+}
+
+func (r *BackupRepository) FindLastVerificationResultByDatabaseID(
+	databaseID uuid.UUID,
+) (*VerificationResult, error) {
+	var result VerificationResult
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ?", databaseID).
+		Order("created_at DESC").
+		First(&result).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (r *BackupRepository) FindVerificationResultsByStatus(
+	status VerificationStatus,
+) ([]*VerificationResult, error) {
+	var results []*VerificationResult
+
+	if err := storage.
+		GetDb().
+		Where("status = ?", status).
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
 func (r *BackupRepository) DeleteByID(id uuid.UUID) error {
 	return storage.GetDb().Delete(&Backup{}, "id = ?", id).Error
 }
@@ -188,6 +395,8 @@ func (r *BackupRepository) FindByDatabaseIDWithPagination(
 
 	if err := storage.
 		GetDb().
+		Preload("StorageCopies").
+		Preload("VerificationResults", orderByCreatedAtDesc).
 		Where("database_id = ?", databaseID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -212,3 +421,87 @@ func (r *BackupRepository) CountByDatabaseID(databaseID uuid.UUID) (int64, error
 
 	return count, nil
 }
+
+func (r *BackupRepository) FindByDatabaseIdCreatedBetween(
+	databaseID uuid.UUID,
+	from, to time.Time,
+) ([]*Backup, error) {
+	var backups []*Backup
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND created_at >= ? AND created_at < ?", databaseID, from, to).
+		Order("created_at DESC").
+		Find(&backups).Error; err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+func (r *BackupRepository) FindLastByDatabaseIdAndStatusBefore(
+	databaseID uuid.UUID,
+	status BackupStatus,
+	before time.Time,
+) (*Backup, error) {
+	var backup Backup
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND status = ? AND created_at < ?", databaseID, status, before).
+		Order("created_at DESC").
+		First(&backup).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &backup, nil
+}
+
+// SumCompletedSizeMbByStorageIDs returns the size of completed copies kept
+// by storages. Backups made before copies were introduced have no copies,
+// their size is counted for the storage of the backup
+func (r *BackupRepository) SumCompletedSizeMbByStorageIDs(
+	storageIDs []uuid.UUID,
+) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		StorageID uuid.UUID
+		UsedMb    float64
+	}
+
+	if err := storage.GetDb().Raw(`
+		SELECT storage_id, SUM(backup_size_mb) AS used_mb
+		FROM (
+			SELECT storage_id, backup_size_mb
+			FROM backup_storage_copies
+			WHERE status = ?
+			UNION ALL
+			SELECT storage_id, backup_size_mb
+			FROM backups
+			WHERE status = ? AND NOT EXISTS (
+				SELECT 1 FROM backup_storage_copies WHERE backup_id = backups.id
+			)
+		) AS stored
+		WHERE storage_id IN ?
+		GROUP BY storage_id`,
+		BackupStorageCopyStatusCompleted,
+		BackupStatusCompleted,
+		storageIDs,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	usedMbByStorageID := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		usedMbByStorageID[row.StorageID] = row.UsedMb
+	}
+
+	return usedMbByStorageID, nil
+}
+
+func orderByCreatedAtDesc(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at DESC")
+}
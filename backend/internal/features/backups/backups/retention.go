@@ -0,0 +1,144 @@
+package backups
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/util/period"
+)
+
+// GetBackupsToDelete returns backups which should be removed by retention policy
+// of the backup config. Backups in progress are never returned
+func GetBackupsToDelete(
+	backupConfig *backups_config.BackupConfig,
+	backups []*Backup,
+	now time.Time,
+) []*Backup {
+	if backupConfig.RetentionPolicyType == backups_config.RetentionPolicyTypeGFS {
+		return getBackupsToDeleteByGfs(backupConfig, backups)
+	}
+
+	return getBackupsToDeleteByTimePeriod(backupConfig.StorePeriod, backups, now)
+}
+
+func getBackupsToDeleteByTimePeriod(
+	storePeriod period.Period,
+	backups []*Backup,
+	now time.Time,
+) []*Backup {
+	backupsToDelete := make([]*Backup, 0)
+
+	if storePeriod == period.PeriodForever || storePeriod == "" {
+		return backupsToDelete
+	}
+
+	dateBeforeBackupsShouldBeDeleted := now.Add(-storePeriod.ToDuration())
+
+	for _, backup := range backups {
+		if backup.Status == BackupStatusInProgress {
+			continue
+		}
+
+		if backup.CreatedAt.Before(dateBeforeBackupsShouldBeDeleted) {
+			backupsToDelete = append(backupsToDelete, backup)
+		}
+	}
+
+	return backupsToDelete
+}
+
+// getBackupsToDeleteByGfs keeps the newest completed backup of each of the
+// latest N hours, days, weeks, months and years. A single backup may cover
+// several tiers at once. Failed and canceled backups do not occupy slots and
+// are kept only while they are newer than the oldest kept backup, so the
+// recent failures history stays visible
+func getBackupsToDeleteByGfs(
+	backupConfig *backups_config.BackupConfig,
+	backups []*Backup,
+) []*Backup {
+	completedBackups := make([]*Backup, 0, len(backups))
+	for _, backup := range backups {
+		if backup.Status == BackupStatusCompleted {
+			completedBackups = append(completedBackups, backup)
+		}
+	}
+
+	slices.SortFunc(completedBackups, func(a, b *Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	tiers := []struct {
+		count     int
+		bucketKey func(t time.Time) string
+	}{
+		{backupConfig.GfsHourlyBackupsCount, func(t time.Time) string {
+			return t.Format("2006-01-02T15")
+		}},
+		{backupConfig.GfsDailyBackupsCount, func(t time.Time) string {
+			return t.Format("2006-01-02")
+		}},
+		{backupConfig.GfsWeeklyBackupsCount, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{backupConfig.GfsMonthlyBackupsCount, func(t time.Time) string {
+			return t.Format("2006-01")
+		}},
+		{backupConfig.GfsYearlyBackupsCount, func(t time.Time) string {
+			return t.Format("2006")
+		}},
+	}
+
+	keptBackups := make(map[*Backup]bool)
+
+	for _, tier := range tiers {
+		if tier.count <= 0 {
+			continue
+		}
+
+		seenBuckets := make(map[string]bool)
+
+		for _, backup := range completedBackups {
+			if len(seenBuckets) >= tier.count {
+				break
+			}
+
+			bucket := tier.bucketKey(backup.CreatedAt.UTC())
+			if seenBuckets[bucket] {
+				continue
+			}
+
+			seenBuckets[bucket] = true
+			keptBackups[backup] = true
+		}
+	}
+
+	var oldestKeptBackupTime *time.Time
+	for backup := range keptBackups {
+		if oldestKeptBackupTime == nil || backup.CreatedAt.Before(*oldestKeptBackupTime) {
+			createdAt := backup.CreatedAt
+			oldestKeptBackupTime = &createdAt
+		}
+	}
+
+	backupsToDelete := make([]*Backup, 0)
+
+	for _, backup := range backups {
+		switch backup.Status {
+		case BackupStatusInProgress:
+			continue
+		case BackupStatusCompleted:
+			if !keptBackups[backup] {
+				backupsToDelete = append(backupsToDelete, backup)
+			}
+		default:
+			if oldestKeptBackupTime != nil && backup.CreatedAt.Before(*oldestKeptBackupTime) {
+				backupsToDelete = append(backupsToDelete, backup)
+			}
+		}
+	}
+
+	return backupsToDelete
+}
@@ -0,0 +1,138 @@
+package backups
+
+import (
+	"testing"
+	"time"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/util/period"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetBackupsToDelete_TimePeriod_DeletesBackupsOlderThanStorePeriod(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType: backups_config.RetentionPolicyTypeTimePeriod,
+		StorePeriod:         period.PeriodWeek,
+	}
+
+	freshBackup := createRetentionTestBackup(now.Add(-24*time.Hour), BackupStatusCompleted)
+	oldBackup := createRetentionTestBackup(now.Add(-8*24*time.Hour), BackupStatusCompleted)
+	oldFailedBackup := createRetentionTestBackup(now.Add(-9*24*time.Hour), BackupStatusFailed)
+
+	backupsToDelete := GetBackupsToDelete(
+		backupConfig,
+		[]*Backup{freshBackup, oldBackup, oldFailedBackup},
+		now,
+	)
+
+	assert.ElementsMatch(t, []*Backup{oldBackup, oldFailedBackup}, backupsToDelete)
+
+	t.Run("Forever store period: Nothing deleted", func(t *testing.T) {
+		backupConfig.StorePeriod = period.PeriodForever
+
+		backupsToDelete := GetBackupsToDelete(
+			backupConfig,
+			[]*Backup{freshBackup, oldBackup, oldFailedBackup},
+			now,
+		)
+
+		assert.Empty(t, backupsToDelete)
+	})
+}
+
+func Test_GetBackupsToDelete_Gfs_KeepsLatestBackupOfEachPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 30, 0, 0, time.UTC)
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType:    backups_config.RetentionPolicyTypeGFS,
+		GfsDailyBackupsCount:   3,
+		GfsMonthlyBackupsCount: 2,
+	}
+
+	// hourly backups for the last 5 days
+	allBackups := make([]*Backup, 0)
+	for i := range 5 * 24 {
+		allBackups = append(
+			allBackups,
+			createRetentionTestBackup(now.Add(-time.Duration(i)*time.Hour), BackupStatusCompleted),
+		)
+	}
+
+	previousMonthBackup := createRetentionTestBackup(
+		time.Date(2024, 5, 20, 4, 0, 0, 0, time.UTC),
+		BackupStatusCompleted,
+	)
+	twoMonthsAgoBackup := createRetentionTestBackup(
+		time.Date(2024, 4, 20, 4, 0, 0, 0, time.UTC),
+		BackupStatusCompleted,
+	)
+	allBackups = append(allBackups, previousMonthBackup, twoMonthsAgoBackup)
+
+	backupsToDelete := GetBackupsToDelete(backupConfig, allBackups, now)
+
+	keptBackups := make([]*Backup, 0)
+	for _, backup := range allBackups {
+		if !containsBackup(backupsToDelete, backup) {
+			keptBackups = append(keptBackups, backup)
+		}
+	}
+
+	// 15 Jun 12:30 (also current month), 14 Jun 23:30, 13 Jun 23:30 and May
+	assert.Len(t, keptBackups, 4)
+	assert.Equal(t, allBackups[0], keptBackups[0])
+	assert.Equal(t, time.Date(2024, 6, 14, 23, 30, 0, 0, time.UTC), keptBackups[1].CreatedAt)
+	assert.Equal(t, time.Date(2024, 6, 13, 23, 30, 0, 0, time.UTC), keptBackups[2].CreatedAt)
+	assert.Equal(t, previousMonthBackup, keptBackups[3])
+	assert.True(t, containsBackup(backupsToDelete, twoMonthsAgoBackup))
+}
+
+func Test_GetBackupsToDelete_Gfs_FailedAndInProgressBackupsHandled(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	backupConfig := &backups_config.BackupConfig{
+		RetentionPolicyType:  backups_config.RetentionPolicyTypeGFS,
+		GfsDailyBackupsCount: 1,
+	}
+
+	inProgressBackup := createRetentionTestBackup(now, BackupStatusInProgress)
+	recentFailedBackup := createRetentionTestBackup(now.Add(-time.Hour), BackupStatusFailed)
+	completedBackup := createRetentionTestBackup(now.Add(-2*time.Hour), BackupStatusCompleted)
+	oldFailedBackup := createRetentionTestBackup(now.Add(-48*time.Hour), BackupStatusFailed)
+	oldCompletedBackup := createRetentionTestBackup(now.Add(-72*time.Hour), BackupStatusCompleted)
+
+	backupsToDelete := GetBackupsToDelete(
+		backupConfig,
+		[]*Backup{
+			inProgressBackup,
+			recentFailedBackup,
+			completedBackup,
+			oldFailedBackup,
+			oldCompletedBackup,
+		},
+		now,
+	)
+
+	assert.ElementsMatch(t, []*Backup{oldFailedBackup, oldCompletedBackup}, backupsToDelete)
+}
+
+func createRetentionTestBackup(createdAt time.Time, status BackupStatus) *Backup {
+	return &Backup{
+		ID:        uuid.New(),
+		Status:    status,
+		CreatedAt: createdAt,
+	}
+}
+
+func containsBackup(backups []*Backup, backup *Backup) bool {
+	for _, b := range backups {
+		if b.ID == backup.ID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,22 +2,33 @@ package backups
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/approvals"
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	"postgresus-backend/internal/features/backups/backups/encryption"
+	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/features/storages"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	util_encryption "postgresus-backend/internal/util/encryption"
@@ -42,8 +53,13 @@ type BackupService struct {
 	backupRemoveListeners []BackupRemoveListener
 
 	workspaceService     *workspaces_services.WorkspaceService
+	authorizationService *workspaces_services.AuthorizationService
 	auditLogService      *audit_logs.AuditLogService
+	approvalService      *approvals.ApprovalService
 	backupContextManager *BackupContextManager
+
+	// IDs of backups which files are being verified right now
+	integrityChecksInProgress *sync.Map
 }
 
 func (s *BackupService) AddBackupRemoveListener(listener BackupRemoveListener) {
@@ -81,7 +97,12 @@ func (s *BackupService) MakeBackupWithAuth(
 		return errors.New("cannot create backup for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionBackup,
+	)
 	if err != nil {
 		return err
 	}
@@ -91,11 +112,14 @@ func (s *BackupService) MakeBackupWithAuth(
 
 	go s.MakeBackup(databaseID, true)
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Backup manually initiated for database: %s", database.Name),
-		&user.ID,
-		database.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionStartBackup,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &databaseID,
+		Message:      fmt.Sprintf("Backup manually initiated for database: %s", database.Name),
+		User:         user,
+		WorkspaceID:  database.WorkspaceID,
+	})
 
 	return nil
 }
@@ -114,7 +138,12 @@ func (s *BackupService) GetBackups(
 		return nil, errors.New("cannot get backups for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -147,47 +176,139 @@ func (s *BackupService) GetBackups(
 	}, nil
 }
 
+// GetRetentionPreview returns backups which will be removed by the next
+// retention cleanup with the current backup config of the database
+func (s *BackupService) GetRetentionPreview(
+	user *users_models.User,
+	databaseID uuid.UUID,
+) (*GetRetentionPreviewResponse, error) {
+	database, err := s.databaseService.GetDatabaseByID(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if database.WorkspaceID == nil {
+		return nil, errors.New("cannot get retention preview for database without workspace")
+	}
+
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccess {
+		return nil, errors.New("insufficient permissions to access backups for this database")
+	}
+
+	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := s.backupRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetRetentionPreviewResponse{
+		BackupsToDelete: GetBackupsToDelete(backupConfig, backups, time.Now().UTC()),
+	}, nil
+}
+
+// DeleteBackup returns the approval request instead of deleting the backup
+// when deletions of the database backups require approval
 func (s *BackupService) DeleteBackup(
 	user *users_models.User,
 	backupID uuid.UUID,
+) (*approvals.ApprovalRequest, error) {
+	return s.deleteBackupWithAuth(user, backupID, false)
+}
+
+// ExecuteApprovedAction deletes the backup on behalf of the requester
+func (s *BackupService) ExecuteApprovedAction(
+	request *approvals.ApprovalRequest,
+	requestedBy *users_models.User,
+	_ string,
 ) error {
+	_, err := s.deleteBackupWithAuth(requestedBy, request.BackupID, true)
+	return err
+}
+
+func (s *BackupService) deleteBackupWithAuth(
+	user *users_models.User,
+	backupID uuid.UUID,
+	isApproved bool,
+) (*approvals.ApprovalRequest, error) {
 	backup, err := s.backupRepository.FindByID(backupID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if database.WorkspaceID == nil {
-		return errors.New("cannot delete backup for database without workspace")
+		return nil, errors.New("cannot delete backup for database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*database.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !canManage {
-		return errors.New("insufficient permissions to delete backup for this database")
+		return nil, errors.New("insufficient permissions to delete backup for this database")
 	}
 
 	if backup.Status == BackupStatusInProgress {
-		return errors.New("backup is in progress")
+		return nil, errors.New("backup is in progress")
+	}
+
+	if !isApproved {
+		backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(database.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if backupConfig.IsBackupDeletionApprovalRequired {
+			return s.approvalService.CreateRequest(
+				user,
+				database,
+				backup.ID,
+				approvals.ApprovalActionDeleteBackup,
+				fmt.Sprintf(
+					"delete backup from %s",
+					backup.CreatedAt.Format("2006-01-02 15:04:05 MST"),
+				),
+				nil,
+			)
+		}
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf(
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceBackup,
+		ResourceID:   &backupID,
+		Message: fmt.Sprintf(
 			"Backup deleted for database: %s (ID: %s)",
 			database.Name,
 			backupID.String(),
 		),
-		&user.ID,
-		database.WorkspaceID,
-	)
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
 
-	return s.deleteBackup(backup)
+	return nil, s.deleteBackup(backup)
 }
 
 func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
@@ -224,28 +345,55 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 		return
 	}
 
-	storage, err := s.storageService.GetStorageByID(*backupConfig.StorageID)
-	if err != nil {
-		s.logger.Error("Failed to get storage by ID", "error", err)
-		return
+	backupStorages := make([]*storages.Storage, 0)
+	for _, storageID := range backupConfig.GetStorageIDs() {
+		storage, err := s.storageService.GetStorageByID(storageID)
+		if err != nil {
+			s.logger.Error("Failed to get storage by ID", "storageId", storageID, "error", err)
+			return
+		}
+
+		backupStorages = append(backupStorages, storage)
 	}
 
 	backup := &Backup{
 		DatabaseID: databaseID,
-		StorageID:  storage.ID,
+		StorageID:  backupStorages[0].ID,
 
 		Status: BackupStatusInProgress,
 
+		BackupType: backups_config.BackupTypeLogical,
+
 		BackupSizeMb: 0,
 
 		CreatedAt: time.Now().UTC(),
 	}
 
+	if backupConfig.BackupType == backups_config.BackupTypePhysical {
+		backup.BackupType = backups_config.BackupTypePhysical
+	}
+
 	if err := s.backupRepository.Save(backup); err != nil {
 		s.logger.Error("Failed to save backup", "error", err)
 		return
 	}
 
+	for _, storage := range backupStorages {
+		storageCopy := &BackupStorageCopy{
+			BackupID:  backup.ID,
+			StorageID: storage.ID,
+			Status:    BackupStorageCopyStatusInProgress,
+			CreatedAt: backup.CreatedAt,
+		}
+
+		if err := s.backupRepository.SaveStorageCopy(storageCopy); err != nil {
+			s.logger.Error("Failed to save backup storage copy", "error", err)
+			return
+		}
+
+		backup.StorageCopies = append(backup.StorageCopies, storageCopy)
+	}
+
 	start := time.Now().UTC()
 
 	backupProgressListener := func(
@@ -268,7 +416,7 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 		backup.ID,
 		backupConfig,
 		database,
-		storage,
+		backupStorages,
 		backupProgressListener,
 	)
 	if err != nil {
@@ -289,14 +437,18 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 				s.logger.Error("Failed to save cancelled backup", "error", err)
 			}
 
-			// Delete partial backup from storage
-			storage, storageErr := s.storageService.GetStorageByID(backup.StorageID)
-			if storageErr == nil {
-				if deleteErr := storage.DeleteFile(s.fieldEncryptor, backup.ID); deleteErr != nil {
+			s.failStorageCopies(backup, errMsg)
+
+			// Delete partial backup from storages
+			for _, storage := range backupStorages {
+				deleteErr := storage.DeleteFile(s.fieldEncryptor, backup.GetFileID())
+				if deleteErr != nil {
 					s.logger.Error(
 						"Failed to delete partial backup file",
 						"backupId",
 						backup.ID,
+						"storageId",
+						storage.ID,
 						"error",
 						deleteErr,
 					)
@@ -325,6 +477,8 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 			s.logger.Error("Failed to save backup", "error", err)
 		}
 
+		s.failStorageCopies(backup, errMsg)
+
 		s.SendBackupNotification(
 			backupConfig,
 			backup,
@@ -343,6 +497,14 @@ func (s *BackupService) MakeBackup(databaseID uuid.UUID, isLastTry bool) {
 		backup.EncryptionSalt = backupMetadata.EncryptionSalt
 		backup.EncryptionIV = backupMetadata.EncryptionIV
 		backup.Encryption = backupMetadata.Encryption
+		backup.EncryptionKeyVersion = backupMetadata.EncryptionKeyVersion
+		backup.Compression = backupMetadata.Compression
+		backup.Sha256 = backupMetadata.Sha256
+		backup.BinlogFile = backupMetadata.BinlogFile
+		backup.BinlogPosition = backupMetadata.BinlogPosition
+		backup.IsOplogIncluded = backupMetadata.IsOplogIncluded
+
+		s.updateStorageCopies(backup, backupMetadata.StorageSaveResults)
 	}
 
 	if err := s.backupRepository.Save(backup); err != nil {
@@ -390,68 +552,170 @@ func (s *BackupService) SendBackupNotification(
 		return
 	}
 
-	for _, notifier := range database.Notifiers {
-		if !slices.Contains(
-			backupConfig.SendNotificationsOn,
-			notificationType,
-		) {
-			continue
+	if len(database.Notifiers) == 0 {
+		return
+	}
+
+	var event *notifiers_events.NotificationEvent
+	switch notificationType {
+	case backups_config.NotificationBackupFailed:
+		message := ""
+		if errorMessage != nil {
+			message = *errorMessage
 		}
 
-		title := ""
-		switch notificationType {
-		case backups_config.NotificationBackupFailed:
-			title = fmt.Sprintf(
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventBackupFailed,
+			fmt.Sprintf(
 				"❌ Backup failed for database \"%s\" (workspace \"%s\")",
 				database.Name,
 				workspace.Name,
-			)
-		case backups_config.NotificationBackupSuccess:
-			title = fmt.Sprintf(
+			),
+			message,
+		)
+		event.Error = errorMessage
+	case backups_config.NotificationBackupSuccess:
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventBackupCompleted,
+			fmt.Sprintf(
 				"✅ Backup completed for database \"%s\" (workspace \"%s\")",
 				database.Name,
 				workspace.Name,
-			)
-		}
+			),
+			fmt.Sprintf(
+				"Backup completed successfully in %s.\nCompressed backup size: %s",
+				notifiers_events.FormatDuration(backup.BackupDurationMs),
+				notifiers_events.FormatSize(backup.BackupSizeMb),
+			),
+		)
+	default:
+		return
+	}
 
-		message := ""
-		if errorMessage != nil {
-			message = *errorMessage
-		} else {
-			// Format size conditionally
-			var sizeStr string
-			if backup.BackupSizeMb < 1024 {
-				sizeStr = fmt.Sprintf("%.2f MB", backup.BackupSizeMb)
-			} else {
-				sizeGB := backup.BackupSizeMb / 1024
-				sizeStr = fmt.Sprintf("%.2f GB", sizeGB)
-			}
+	event.Workspace = &notifiers_events.EventWorkspace{ID: workspace.ID, Name: workspace.Name}
+	event.Database = database.ToNotificationEventDatabase()
+	event.Backup = backup.ToNotificationEventBackup()
 
-			// Format duration as "0m 0s 0ms"
-			totalMs := backup.BackupDurationMs
-			minutes := totalMs / (1000 * 60)
-			seconds := (totalMs % (1000 * 60)) / 1000
-			durationStr := fmt.Sprintf("%dm %ds", minutes, seconds)
+	if storage, err := s.storageService.GetStorageByID(backup.StorageID); err == nil {
+		event.Storage = &notifiers_events.EventStorage{
+			ID:   storage.ID,
+			Name: storage.Name,
+			Type: string(storage.Type),
+		}
+	}
 
-			message = fmt.Sprintf(
-				"Backup completed successfully in %s.\nCompressed backup size: %s",
-				durationStr,
-				sizeStr,
-			)
+	for _, notifier := range database.Notifiers {
+		if isBackupNotificationEnabled(backupConfig, notificationType, &notifier) {
+			s.notificationSender.SendNotification(&notifier, event)
+		} else if notificationType == backups_config.NotificationBackupSuccess {
+			// the success is not sent, but it ends the failure, so the next
+			// failure is not suppressed as repeated
+			s.notificationSender.ResetFailureState(&notifier, event)
 		}
+	}
+}
 
-		s.notificationSender.SendNotification(
-			&notifier,
-			title,
-			message,
-		)
+// isBackupNotificationEnabled checks the notification types of the config.
+// Incident notifier gets the success when only failures are enabled, as the
+// success resolves the incident opened by the failure
+func isBackupNotificationEnabled(
+	backupConfig *backups_config.BackupConfig,
+	notificationType backups_config.BackupNotificationType,
+	notifier *notifiers.Notifier,
+) bool {
+	if slices.Contains(backupConfig.SendNotificationsOn, notificationType) {
+		return true
 	}
+
+	return notificationType == backups_config.NotificationBackupSuccess &&
+		notifier.NotifierType == notifiers.NotifierTypeIncident &&
+		slices.Contains(backupConfig.SendNotificationsOn, backups_config.NotificationBackupFailed)
 }
 
 func (s *BackupService) GetBackup(backupID uuid.UUID) (*Backup, error) {
 	return s.backupRepository.FindByID(backupID)
 }
 
+// GetOldestPhysicalBackup returns the oldest completed physical backup of the
+// database. WAL older than this backup cannot be replayed, so it is not needed
+func (s *BackupService) GetOldestPhysicalBackup(databaseID uuid.UUID) (*Backup, error) {
+	return s.backupRepository.FindOldestByDatabaseIdAndStatusAndType(
+		databaseID,
+		BackupStatusCompleted,
+		backups_config.BackupTypePhysical,
+	)
+}
+
+// GetOldestBinlogBackup returns the oldest completed MySQL or MariaDB dump
+// with binlog position. Binlogs before its position cannot be replayed
+func (s *BackupService) GetOldestBinlogBackup(databaseID uuid.UUID) (*Backup, error) {
+	return s.backupRepository.FindOldestWithBinlogPositionByDatabaseIdAndStatus(
+		databaseID,
+		BackupStatusCompleted,
+	)
+}
+
+// GetLastVerifiableBackup returns the newest completed logical backup. Physical
+// backups are restored into a data directory, so they cannot be checked by
+// running queries
+func (s *BackupService) GetLastVerifiableBackup(databaseID uuid.UUID) (*Backup, error) {
+	return s.backupRepository.FindLastByDatabaseIdAndStatusAndType(
+		databaseID,
+		BackupStatusCompleted,
+		backups_config.BackupTypeLogical,
+	)
+}
+
+// GetBackupsCreatedBetween returns backups of the database created in the
+// period, newest first
+func (s *BackupService) GetBackupsCreatedBetween(
+	databaseID uuid.UUID,
+	from, to time.Time,
+) ([]*Backup, error) {
+	return s.backupRepository.FindByDatabaseIdCreatedBetween(databaseID, from, to)
+}
+
+// GetLastCompletedBackupBefore returns the newest completed backup created
+// before the date or nil when there is no such backup
+func (s *BackupService) GetLastCompletedBackupBefore(
+	databaseID uuid.UUID,
+	before time.Time,
+) (*Backup, error) {
+	return s.backupRepository.FindLastByDatabaseIdAndStatusBefore(
+		databaseID,
+		BackupStatusCompleted,
+		before,
+	)
+}
+
+// GetStoragesUsedMb returns the size of backups kept by storages, storages
+// without backups are missing in the result
+func (s *BackupService) GetStoragesUsedMb(
+	storageIDs []uuid.UUID,
+) (map[uuid.UUID]float64, error) {
+	if len(storageIDs) == 0 {
+		return map[uuid.UUID]float64{}, nil
+	}
+
+	return s.backupRepository.SumCompletedSizeMbByStorageIDs(storageIDs)
+}
+
+func (s *BackupService) SaveVerificationResult(result *VerificationResult) error {
+	return s.backupRepository.SaveVerificationResult(result)
+}
+
+func (s *BackupService) GetLastVerificationResult(
+	databaseID uuid.UUID,
+) (*VerificationResult, error) {
+	return s.backupRepository.FindLastVerificationResultByDatabaseID(databaseID)
+}
+
+func (s *BackupService) GetVerificationResultsByStatus(
+	status VerificationStatus,
+) ([]*VerificationResult, error) {
+	return s.backupRepository.FindVerificationResultsByStatus(status)
+}
+
 func (s *BackupService) CancelBackup(
 	user *users_models.User,
 	backupID uuid.UUID,
@@ -470,7 +734,12 @@ func (s *BackupService) CancelBackup(
 		return errors.New("cannot cancel backup for database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*database.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return err
 	}
@@ -486,15 +755,18 @@ func (s *BackupService) CancelBackup(
 		return err
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf(
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCancelBackup,
+		ResourceType: audit_logs_events.AuditResourceBackup,
+		ResourceID:   &backupID,
+		Message: fmt.Sprintf(
 			"Backup cancelled for database: %s (ID: %s)",
 			database.Name,
 			backupID.String(),
 		),
-		&user.ID,
-		database.WorkspaceID,
-	)
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
 
 	return nil
 }
@@ -502,7 +774,7 @@ func (s *BackupService) CancelBackup(
 func (s *BackupService) GetBackupFile(
 	user *users_models.User,
 	backupID uuid.UUID,
-) (io.ReadCloser, databases.DatabaseType, error) {
+) (io.ReadCloser, string, error) {
 	backup, err := s.backupRepository.FindByID(backupID)
 	if err != nil {
 		return nil, "", err
@@ -517,9 +789,11 @@ func (s *BackupService) GetBackupFile(
 		return nil, "", errors.New("cannot download backup for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(
+	canAccess, err := s.authorizationService.HasDatabasePermission(
 		*database.WorkspaceID,
+		database.ID,
 		user,
+		users_enums.WorkspacePermissionDownload,
 	)
 	if err != nil {
 		return nil, "", err
@@ -528,42 +802,240 @@ func (s *BackupService) GetBackupFile(
 		return nil, "", errors.New("insufficient permissions to download backup for this database")
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf(
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDownloadBackup,
+		ResourceType: audit_logs_events.AuditResourceBackup,
+		ResourceID:   &backupID,
+		Message: fmt.Sprintf(
 			"Backup file downloaded for database: %s (ID: %s)",
 			database.Name,
 			backupID.String(),
 		),
-		&user.ID,
-		database.WorkspaceID,
-	)
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
 
 	reader, err := s.getBackupReader(backupID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	return reader, database.Type, nil
+	return reader, getBackupFileExtension(backup, database.Type), nil
 }
 
-func (s *BackupService) deleteBackup(backup *Backup) error {
-	for _, listener := range s.backupRemoveListeners {
-		if err := listener.OnBeforeBackupRemove(backup); err != nil {
-			return err
+// getBackupFileExtension returns extension of the downloaded file, it
+// depends on the dump format of the engine and the compression applied
+func getBackupFileExtension(backup *Backup, databaseType databases.DatabaseType) string {
+	compressionExtension := compression.GetFileExtension(backup.Compression)
+
+	switch databaseType {
+	case databases.DatabaseTypeMysql, databases.DatabaseTypeMariadb:
+		return ".sql" + compressionExtension
+	case databases.DatabaseTypeMongodb:
+		// gzip is applied by mongodump inside the archive
+		if backup.Compression == backups_config.BackupCompressionGzip {
+			return ".archive"
+		}
+
+		return ".archive" + compressionExtension
+	default:
+		// custom format of pg_dump is compressed internally
+		if backup.BackupType == backups_config.BackupTypePhysical {
+			return ".tar" + compressionExtension
 		}
+
+		return ".dump"
+	}
+}
+
+// GetAvailableBackupStorage returns the first storage from which the backup
+// file can be read. Copies are checked in order, so the primary storage is
+// preferred and secondary ones are used as fallback
+func (s *BackupService) GetAvailableBackupStorage(backup *Backup) (*storages.Storage, error) {
+	storage, fileReader, err := s.openBackupFile(backup)
+	if err != nil {
+		return nil, err
 	}
 
-	storage, err := s.storageService.GetStorageByID(backup.StorageID)
+	if err := fileReader.Close(); err != nil {
+		s.logger.Error("Failed to close file reader", "error", err)
+	}
+
+	return storage, nil
+}
+
+func (s *BackupService) VerifyBackupFileWithAuth(
+	user *users_models.User,
+	backupID uuid.UUID,
+) error {
+	backup, err := s.backupRepository.FindByID(backupID)
 	if err != nil {
 		return err
 	}
 
-	err = storage.DeleteFile(s.fieldEncryptor, backup.ID)
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
 	if err != nil {
-		// we do not return error here, because sometimes clean up performed
-		// before unavailable storage removal or change - therefore we should
-		// proceed even in case of error
-		s.logger.Error("Failed to delete backup file", "error", err)
+		return err
+	}
+
+	if database.WorkspaceID == nil {
+		return errors.New("cannot verify backup for database without workspace")
+	}
+
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionBackup,
+	)
+	if err != nil {
+		return err
+	}
+	if !canAccess {
+		return errors.New("insufficient permissions to verify backup for this database")
+	}
+
+	if backup.Status != BackupStatusCompleted {
+		return errors.New("only completed backups can be verified")
+	}
+
+	if _, isInProgress := s.integrityChecksInProgress.Load(backup.ID); isInProgress {
+		return errors.New("backup file verification is already in progress")
+	}
+
+	go func() {
+		if err := s.VerifyBackupFile(backup); err != nil {
+			s.logger.Error("Failed to verify backup file", "backupId", backup.ID, "error", err)
+		}
+	}()
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionStartVerification,
+		ResourceType: audit_logs_events.AuditResourceBackup,
+		ResourceID:   &backupID,
+		Message: fmt.Sprintf(
+			"Backup file verification initiated for database: %s (ID: %s)",
+			database.Name,
+			backupID.String(),
+		),
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
+
+	return nil
+}
+
+// VerifyBackupFile re-reads every copy of the backup and compares SHA-256 of
+// the content with the hash recorded when the backup was made. Encrypted
+// files are read through DecryptionReader, so every chunk is authenticated
+// as well. Corrupted copies are excluded from restores until a later check
+// passes
+func (s *BackupService) VerifyBackupFile(backup *Backup) error {
+	if backup.Status != BackupStatusCompleted {
+		return errors.New("only completed backups can be verified")
+	}
+
+	if _, isInProgress := s.integrityChecksInProgress.LoadOrStore(backup.ID, true); isInProgress {
+		return errors.New("backup file verification is already in progress")
+	}
+	defer s.integrityChecksInProgress.Delete(backup.ID)
+
+	storageIDs := backup.GetCompletedCopiesStorageIDs()
+	for _, storageCopy := range backup.StorageCopies {
+		if storageCopy.Status == BackupStorageCopyStatusCorrupted {
+			storageIDs = append(storageIDs, storageCopy.StorageID)
+		}
+	}
+
+	corruptedMessages := []string{}
+	checkFailedMessages := []string{}
+
+	if len(storageIDs) == 0 {
+		checkFailedMessages = append(checkFailedMessages, "backup has no copies to verify")
+	}
+
+	for _, storageID := range storageIDs {
+		isCorrupted, err := s.checkBackupCopy(backup, storageID)
+
+		storageCopy := backup.getStorageCopy(storageID)
+
+		switch {
+		case isCorrupted:
+			corruptedMessages = append(
+				corruptedMessages,
+				fmt.Sprintf("storage %s: %s", storageID, err.Error()),
+			)
+
+			if storageCopy != nil {
+				failMessage := err.Error()
+				storageCopy.Status = BackupStorageCopyStatusCorrupted
+				storageCopy.FailMessage = &failMessage
+			}
+		case err != nil:
+			checkFailedMessages = append(
+				checkFailedMessages,
+				fmt.Sprintf("storage %s: %s", storageID, err.Error()),
+			)
+		case storageCopy != nil && storageCopy.Status == BackupStorageCopyStatusCorrupted:
+			storageCopy.Status = BackupStorageCopyStatusCompleted
+			storageCopy.FailMessage = nil
+		}
+
+		if storageCopy != nil {
+			if err := s.backupRepository.SaveStorageCopy(storageCopy); err != nil {
+				s.logger.Error("Failed to save backup storage copy", "error", err)
+			}
+		}
+	}
+
+	checkedAt := time.Now().UTC()
+	backup.IntegrityCheckedAt = &checkedAt
+	backup.IntegrityFailMessage = nil
+
+	switch {
+	case len(corruptedMessages) > 0:
+		failMessage := strings.Join(append(corruptedMessages, checkFailedMessages...), "; ")
+		backup.IntegrityStatus = BackupIntegrityStatusCorrupted
+		backup.IntegrityFailMessage = &failMessage
+	case len(checkFailedMessages) > 0:
+		failMessage := strings.Join(checkFailedMessages, "; ")
+		backup.IntegrityStatus = BackupIntegrityStatusCheckFailed
+		backup.IntegrityFailMessage = &failMessage
+	default:
+		backup.IntegrityStatus = BackupIntegrityStatusValid
+	}
+
+	if err := s.backupRepository.Save(backup); err != nil {
+		return err
+	}
+
+	if backup.IntegrityStatus == BackupIntegrityStatusCorrupted {
+		s.sendBackupCorruptedNotification(backup)
+	}
+
+	return nil
+}
+
+func (s *BackupService) deleteBackup(backup *Backup) error {
+	for _, listener := range s.backupRemoveListeners {
+		if err := listener.OnBeforeBackupRemove(backup); err != nil {
+			return err
+		}
+	}
+
+	for _, storageID := range backup.GetCopiesStorageIDs() {
+		storage, err := s.storageService.GetStorageByID(storageID)
+		if err != nil {
+			return err
+		}
+
+		err = storage.DeleteFile(s.fieldEncryptor, backup.GetFileID())
+		if err != nil {
+			// we do not return error here, because sometimes clean up performed
+			// before unavailable storage removal or change - therefore we should
+			// proceed even in case of error
+			s.logger.Error("Failed to delete backup file", "storageId", storageID, "error", err)
+		}
 	}
 
 	return s.backupRepository.DeleteByID(backup.ID)
@@ -607,19 +1079,24 @@ func (s *BackupService) getBackupReader(backupID uuid.UUID) (io.ReadCloser, erro
 		return nil, fmt.Errorf("failed to find backup: %w", err)
 	}
 
-	storage, err := s.storageService.GetStorageByID(backup.StorageID)
+	_, fileReader, err := s.openBackupFile(backup)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get storage: %w", err)
+		return nil, err
 	}
 
-	fileReader, err := storage.GetFile(s.fieldEncryptor, backup.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get backup file: %w", err)
-	}
+	return s.wrapWithDecryption(backup, fileReader)
+}
 
+// wrapWithDecryption returns the file reader as is for not encrypted backups
+// and wraps it with DecryptionReader otherwise. The file reader is closed on
+// error
+func (s *BackupService) wrapWithDecryption(
+	backup *Backup,
+	fileReader io.ReadCloser,
+) (io.ReadCloser, error) {
 	// If not encrypted, return raw reader
 	if backup.Encryption == backups_config.BackupEncryptionNone {
-		s.logger.Info("Returning non-encrypted backup", "backupId", backupID)
+		s.logger.Info("Returning non-encrypted backup", "backupId", backup.ID)
 		return fileReader, nil
 	}
 
@@ -638,8 +1115,8 @@ func (s *BackupService) getBackupReader(backupID uuid.UUID) (io.ReadCloser, erro
 		return nil, fmt.Errorf("backup marked as encrypted but missing encryption metadata")
 	}
 
-	// Get master key
-	masterKey, err := s.secretKeyService.GetSecretKey()
+	// Get master key which encrypted the backup
+	masterKey, err := s.secretKeyService.GetKeyByVersion(backup.EncryptionKeyVersion)
 	if err != nil {
 		if closeErr := fileReader.Close(); closeErr != nil {
 			s.logger.Error("Failed to close file reader", "error", closeErr)
@@ -679,10 +1156,394 @@ func (s *BackupService) getBackupReader(backupID uuid.UUID) (io.ReadCloser, erro
 		return nil, fmt.Errorf("failed to create decrypting reader: %w", err)
 	}
 
-	s.logger.Info("Returning encrypted backup with decryption", "backupId", backupID)
+	s.logger.Info("Returning encrypted backup with decryption", "backupId", backup.ID)
 
 	return &decryptionReaderCloser{
 		decryptionReader,
 		fileReader,
 	}, nil
 }
+
+func (s *BackupService) openBackupFile(
+	backup *Backup,
+) (*storages.Storage, io.ReadCloser, error) {
+	var lastErr error
+
+	for _, storageID := range backup.GetCompletedCopiesStorageIDs() {
+		storage, err := s.storageService.GetStorageByID(storageID)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to get storage: %w", err)
+			continue
+		}
+
+		fileReader, err := storage.GetFile(s.fieldEncryptor, backup.GetFileID())
+		if err != nil {
+			s.logger.Warn(
+				"Failed to get backup file from storage, trying next copy",
+				"backupId",
+				backup.ID,
+				"storageId",
+				storageID,
+				"error",
+				err,
+			)
+
+			lastErr = fmt.Errorf("failed to get backup file: %w", err)
+			continue
+		}
+
+		return storage, fileReader, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("backup has no completed copies")
+	}
+
+	return nil, nil, lastErr
+}
+
+func (s *BackupService) updateStorageCopies(
+	backup *Backup,
+	storageSaveResults []*usecases_common.StorageSaveResult,
+) {
+	var firstSavedStorageID *uuid.UUID
+
+	for _, storageCopy := range backup.StorageCopies {
+		storageCopy.Status = BackupStorageCopyStatusCompleted
+		storageCopy.BackupSizeMb = backup.BackupSizeMb
+
+		for _, result := range storageSaveResults {
+			if result.StorageID != storageCopy.StorageID {
+				continue
+			}
+
+			storageCopy.BackupSizeMb = float64(result.BytesWritten) / (1024 * 1024)
+
+			if result.Error != nil {
+				failMessage := result.Error.Error()
+				storageCopy.Status = BackupStorageCopyStatusFailed
+				storageCopy.FailMessage = &failMessage
+			}
+		}
+
+		if storageCopy.Status == BackupStorageCopyStatusCompleted && firstSavedStorageID == nil {
+			firstSavedStorageID = &storageCopy.StorageID
+		}
+
+		if err := s.backupRepository.SaveStorageCopy(storageCopy); err != nil {
+			s.logger.Error("Failed to save backup storage copy", "error", err)
+		}
+	}
+
+	// primary storage may fail while secondary ones succeed
+	if firstSavedStorageID != nil {
+		backup.StorageID = *firstSavedStorageID
+	}
+}
+
+func (s *BackupService) failStorageCopies(backup *Backup, failMessage string) {
+	for _, storageCopy := range backup.StorageCopies {
+		storageCopy.Status = BackupStorageCopyStatusFailed
+		storageCopy.FailMessage = &failMessage
+		storageCopy.BackupSizeMb = 0
+
+		if err := s.backupRepository.SaveStorageCopy(storageCopy); err != nil {
+			s.logger.Error("Failed to save backup storage copy", "error", err)
+		}
+	}
+}
+
+// checkBackupCopy reads the copy till the end and compares its hash. Errors
+// of reading the content mean the copy is corrupted, while errors of opening
+// the file are reported as failed check: the storage may be unavailable only
+// for a while
+func (s *BackupService) checkBackupCopy(
+	backup *Backup,
+	storageID uuid.UUID,
+) (bool, error) {
+	storage, err := s.storageService.GetStorageByID(storageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get storage: %w", err)
+	}
+
+	fileReader, err := storage.GetFile(s.fieldEncryptor, backup.GetFileID())
+	if err != nil {
+		return false, fmt.Errorf("failed to get backup file: %w", err)
+	}
+
+	contentReader, err := s.wrapWithDecryption(backup, fileReader)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := contentReader.Close(); err != nil {
+			s.logger.Error("Failed to close file reader", "error", err)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, contentReader); err != nil {
+		return true, fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	// backups made before checksums were introduced are only checked to be
+	// readable and, when encrypted, to have valid chunks
+	if backup.Sha256 == nil {
+		return false, nil
+	}
+
+	actualSha256 := hex.EncodeToString(hash.Sum(nil))
+	if actualSha256 != *backup.Sha256 {
+		return true, fmt.Errorf(
+			"checksum mismatch: expected %s, got %s",
+			*backup.Sha256,
+			actualSha256,
+		)
+	}
+
+	return false, nil
+}
+
+func (s *BackupService) GetBackupsWithRetiredKey(limit int) ([]*Backup, error) {
+	currentKeyVersion, _, err := s.secretKeyService.GetCurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	return s.backupRepository.FindEncryptedWithRetiredKey(currentKeyVersion, limit)
+}
+
+// ReencryptBackup encrypts the backup by the current master key, so the
+// retired key can be removed before the backup expires. The new file is
+// uploaded under a new name and the old files are deleted only after the new
+// encryption metadata is saved, so an interrupted run leaves the backup
+// readable
+func (s *BackupService) ReencryptBackup(backup *Backup) error {
+	if _, isInProgress := s.integrityChecksInProgress.LoadOrStore(backup.ID, true); isInProgress {
+		return errors.New("backup file verification is in progress")
+	}
+	defer s.integrityChecksInProgress.Delete(backup.ID)
+
+	keyVersion, masterKey, err := s.secretKeyService.GetCurrentKey()
+	if err != nil {
+		return fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(config.GetEnv().TempFolder, "reencrypt-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	salt, nonce, err := s.writeReencryptedBackup(backup, masterKey, tempFile)
+	if err != nil {
+		return err
+	}
+
+	oldFileID := backup.GetFileID()
+	newFileID := uuid.New()
+
+	var savedStorageCopies []*BackupStorageCopy
+	var failedStorageCopies []*BackupStorageCopy
+	var lastErr error
+
+	for _, storageCopy := range backup.StorageCopies {
+		if storageCopy.Status != BackupStorageCopyStatusCompleted {
+			continue
+		}
+
+		err := s.uploadReencryptedBackup(storageCopy, newFileID, tempFile)
+		if err != nil {
+			s.logger.Error(
+				"Failed to upload re-encrypted backup",
+				"backupId",
+				backup.ID,
+				"storageId",
+				storageCopy.StorageID,
+				"error",
+				err,
+			)
+
+			failMessage := fmt.Sprintf("failed to upload re-encrypted backup: %s", err.Error())
+			storageCopy.FailMessage = &failMessage
+			failedStorageCopies = append(failedStorageCopies, storageCopy)
+			lastErr = err
+			continue
+		}
+
+		savedStorageCopies = append(savedStorageCopies, storageCopy)
+	}
+
+	// when nothing is uploaded the old files are untouched and stay readable
+	// with the old encryption metadata
+	if len(savedStorageCopies) == 0 {
+		s.deleteBackupFiles(backup, failedStorageCopies, newFileID)
+
+		if lastErr == nil {
+			lastErr = errors.New("backup has no completed copies")
+		}
+
+		return lastErr
+	}
+
+	saltBase64 := base64.StdEncoding.EncodeToString(salt)
+	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
+	backup.EncryptionSalt = &saltBase64
+	backup.EncryptionIV = &nonceBase64
+	backup.EncryptionKeyVersion = &keyVersion
+	backup.FileID = &newFileID
+
+	if err := s.backupRepository.Save(backup); err != nil {
+		s.deleteBackupFiles(backup, savedStorageCopies, newFileID)
+		return err
+	}
+
+	// copies without the new file cannot be read with the new encryption
+	// metadata
+	for _, storageCopy := range failedStorageCopies {
+		storageCopy.Status = BackupStorageCopyStatusFailed
+		if err := s.backupRepository.SaveStorageCopy(storageCopy); err != nil {
+			s.logger.Error("Failed to save backup storage copy", "error", err)
+		}
+	}
+
+	s.deleteBackupFiles(backup, backup.StorageCopies, oldFileID)
+
+	return nil
+}
+
+// deleteBackupFiles removes files which are not referenced by the backup,
+// errors are only logged: a left file takes space but does not break reading
+func (s *BackupService) deleteBackupFiles(
+	backup *Backup,
+	storageCopies []*BackupStorageCopy,
+	fileID uuid.UUID,
+) {
+	for _, storageCopy := range storageCopies {
+		storage, err := s.storageService.GetStorageByID(storageCopy.StorageID)
+		if err == nil {
+			err = storage.DeleteFile(s.fieldEncryptor, fileID)
+		}
+
+		if err != nil {
+			s.logger.Error(
+				"Failed to delete backup file",
+				"backupId",
+				backup.ID,
+				"storageId",
+				storageCopy.StorageID,
+				"error",
+				err,
+			)
+		}
+	}
+}
+
+// writeReencryptedBackup decrypts the backup with the key which encrypted it
+// and encrypts by the current key with new salt and nonce. The content hash
+// is checked, so a damaged file does not replace good copies
+func (s *BackupService) writeReencryptedBackup(
+	backup *Backup,
+	masterKey string,
+	file io.Writer,
+) ([]byte, []byte, error) {
+	_, fileReader, err := s.openBackupFile(backup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contentReader, err := s.wrapWithDecryption(backup, fileReader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := contentReader.Close(); err != nil {
+			s.logger.Error("Failed to close file reader", "error", err)
+		}
+	}()
+
+	salt, err := encryption.GenerateSalt()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	nonce, err := encryption.GenerateNonce()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	encryptionWriter, err := encryption.NewEncryptionWriter(file, masterKey, backup.ID, salt, nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create encrypting writer: %w", err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(encryptionWriter, hash), contentReader); err != nil {
+		return nil, nil, fmt.Errorf("failed to re-encrypt backup: %w", err)
+	}
+
+	if err := encryptionWriter.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to re-encrypt backup: %w", err)
+	}
+
+	if backup.Sha256 != nil && hex.EncodeToString(hash.Sum(nil)) != *backup.Sha256 {
+		return nil, nil, errors.New("checksum mismatch, backup is not re-encrypted")
+	}
+
+	return salt, nonce, nil
+}
+
+func (s *BackupService) uploadReencryptedBackup(
+	storageCopy *BackupStorageCopy,
+	fileID uuid.UUID,
+	file *os.File,
+) error {
+	storage, err := s.storageService.GetStorageByID(storageCopy.StorageID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read temp file: %w", err)
+	}
+
+	return storage.SaveFile(
+		context.Background(),
+		s.fieldEncryptor,
+		s.logger,
+		fileID,
+		file,
+	)
+}
+
+func (s *BackupService) sendBackupCorruptedNotification(backup *Backup) {
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+	if err != nil {
+		s.logger.Error("Failed to get database for notification", "error", err)
+		return
+	}
+
+	message := fmt.Sprintf(
+		"Backup from %s failed integrity check",
+		notifiers_events.FormatTime(backup.CreatedAt),
+	)
+	if backup.IntegrityFailMessage != nil {
+		message += ": " + *backup.IntegrityFailMessage
+	}
+
+	event := notifiers_events.NewNotificationEvent(
+		notifiers_events.NotificationEventBackupCorrupted,
+		fmt.Sprintf("❌ Backup file is corrupted: %s", database.Name),
+		message,
+	)
+	event.Database = database.ToNotificationEventDatabase()
+	event.Backup = backup.ToNotificationEventBackup()
+	event.Error = backup.IntegrityFailMessage
+
+	for _, notifier := range database.Notifiers {
+		s.notificationSender.SendNotification(&notifier, event)
+	}
+}
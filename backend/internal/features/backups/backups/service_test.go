@@ -3,7 +3,9 @@ package backups
 import (
 	"context"
 	"errors"
+	"io"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"postgresus-backend/internal/features/databases"
 	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/features/storages"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_testing "postgresus-backend/internal/features/users/testing"
@@ -51,30 +54,30 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 	t.Run("BackupFailed_FailNotificationSent", func(t *testing.T) {
 		mockNotificationSender := &MockNotificationSender{}
 		backupService := &BackupService{
-			databases.GetDatabaseService(),
-			storages.GetStorageService(),
-			backupRepository,
-			notifiers.GetNotifierService(),
-			mockNotificationSender,
-			backups_config.GetBackupConfigService(),
-			encryption_secrets.GetSecretKeyService(),
-			encryption.GetFieldEncryptor(),
-			&CreateFailedBackupUsecase{},
-			logger.GetLogger(),
-			[]BackupRemoveListener{},
-			workspaces_services.GetWorkspaceService(),
-			nil,
-			NewBackupContextManager(),
+			databaseService:           databases.GetDatabaseService(),
+			storageService:            storages.GetStorageService(),
+			backupRepository:          backupRepository,
+			notifierService:           notifiers.GetNotifierService(),
+			notificationSender:        mockNotificationSender,
+			backupConfigService:       backups_config.GetBackupConfigService(),
+			secretKeyService:          encryption_secrets.GetSecretKeyService(),
+			fieldEncryptor:            encryption.GetFieldEncryptor(),
+			createBackupUseCase:       &CreateFailedBackupUsecase{},
+			logger:                    logger.GetLogger(),
+			backupRemoveListeners:     []BackupRemoveListener{},
+			workspaceService:          workspaces_services.GetWorkspaceService(),
+			authorizationService:      workspaces_services.GetAuthorizationService(),
+			backupContextManager:      NewBackupContextManager(),
+			integrityChecksInProgress: &sync.Map{},
 		}
 
 		// Set up expectations
 		mockNotificationSender.On("SendNotification",
 			mock.Anything,
-			mock.MatchedBy(func(title string) bool {
-				return strings.Contains(title, "❌ Backup failed")
-			}),
-			mock.MatchedBy(func(message string) bool {
-				return strings.Contains(message, "backup failed")
+			mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+				return event.Type == notifiers_events.NotificationEventBackupFailed &&
+					strings.Contains(event.Title, "❌ Backup failed") &&
+					strings.Contains(event.Message, "backup failed")
 			}),
 		).Once()
 
@@ -90,29 +93,29 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 		// Set up expectations
 		mockNotificationSender.On("SendNotification",
 			mock.Anything,
-			mock.MatchedBy(func(title string) bool {
-				return strings.Contains(title, "✅ Backup completed")
-			}),
-			mock.MatchedBy(func(message string) bool {
-				return strings.Contains(message, "Backup completed successfully")
+			mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+				return event.Type == notifiers_events.NotificationEventBackupCompleted &&
+					strings.Contains(event.Title, "✅ Backup completed") &&
+					strings.Contains(event.Message, "Backup completed successfully")
 			}),
 		).Once()
 
 		backupService := &BackupService{
-			databases.GetDatabaseService(),
-			storages.GetStorageService(),
-			backupRepository,
-			notifiers.GetNotifierService(),
-			mockNotificationSender,
-			backups_config.GetBackupConfigService(),
-			encryption_secrets.GetSecretKeyService(),
-			encryption.GetFieldEncryptor(),
-			&CreateSuccessBackupUsecase{},
-			logger.GetLogger(),
-			[]BackupRemoveListener{},
-			workspaces_services.GetWorkspaceService(),
-			nil,
-			NewBackupContextManager(),
+			databaseService:           databases.GetDatabaseService(),
+			storageService:            storages.GetStorageService(),
+			backupRepository:          backupRepository,
+			notifierService:           notifiers.GetNotifierService(),
+			notificationSender:        mockNotificationSender,
+			backupConfigService:       backups_config.GetBackupConfigService(),
+			secretKeyService:          encryption_secrets.GetSecretKeyService(),
+			fieldEncryptor:            encryption.GetFieldEncryptor(),
+			createBackupUseCase:       &CreateSuccessBackupUsecase{},
+			logger:                    logger.GetLogger(),
+			backupRemoveListeners:     []BackupRemoveListener{},
+			workspaceService:          workspaces_services.GetWorkspaceService(),
+			authorizationService:      workspaces_services.GetAuthorizationService(),
+			backupContextManager:      NewBackupContextManager(),
+			integrityChecksInProgress: &sync.Map{},
 		}
 
 		backupService.MakeBackup(database.ID, true)
@@ -124,35 +127,33 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 	t.Run("BackupSuccess_VerifyNotificationContent", func(t *testing.T) {
 		mockNotificationSender := &MockNotificationSender{}
 		backupService := &BackupService{
-			databases.GetDatabaseService(),
-			storages.GetStorageService(),
-			backupRepository,
-			notifiers.GetNotifierService(),
-			mockNotificationSender,
-			backups_config.GetBackupConfigService(),
-			encryption_secrets.GetSecretKeyService(),
-			encryption.GetFieldEncryptor(),
-			&CreateSuccessBackupUsecase{},
-			logger.GetLogger(),
-			[]BackupRemoveListener{},
-			workspaces_services.GetWorkspaceService(),
-			nil,
-			NewBackupContextManager(),
+			databaseService:           databases.GetDatabaseService(),
+			storageService:            storages.GetStorageService(),
+			backupRepository:          backupRepository,
+			notifierService:           notifiers.GetNotifierService(),
+			notificationSender:        mockNotificationSender,
+			backupConfigService:       backups_config.GetBackupConfigService(),
+			secretKeyService:          encryption_secrets.GetSecretKeyService(),
+			fieldEncryptor:            encryption.GetFieldEncryptor(),
+			createBackupUseCase:       &CreateSuccessBackupUsecase{},
+			logger:                    logger.GetLogger(),
+			backupRemoveListeners:     []BackupRemoveListener{},
+			workspaceService:          workspaces_services.GetWorkspaceService(),
+			authorizationService:      workspaces_services.GetAuthorizationService(),
+			backupContextManager:      NewBackupContextManager(),
+			integrityChecksInProgress: &sync.Map{},
 		}
 
 		// capture arguments
 		var capturedNotifier *notifiers.Notifier
-		var capturedTitle string
-		var capturedMessage string
+		var capturedEvent *notifiers_events.NotificationEvent
 
 		mockNotificationSender.On("SendNotification",
 			mock.Anything,
-			mock.AnythingOfType("string"),
-			mock.AnythingOfType("string"),
+			mock.Anything,
 		).Run(func(args mock.Arguments) {
 			capturedNotifier = args.Get(0).(*notifiers.Notifier)
-			capturedTitle = args.Get(1).(string)
-			capturedMessage = args.Get(2).(string)
+			capturedEvent = args.Get(1).(*notifiers_events.NotificationEvent)
 		}).Once()
 
 		backupService.MakeBackup(database.ID, true)
@@ -161,11 +162,14 @@ func Test_BackupExecuted_NotificationSent(t *testing.T) {
 		mockNotificationSender.AssertExpectations(t)
 
 		// Additional detailed assertions
-		assert.Contains(t, capturedTitle, "✅ Backup completed")
-		assert.Contains(t, capturedTitle, database.Name)
-		assert.Contains(t, capturedMessage, "Backup completed successfully")
-		assert.Contains(t, capturedMessage, "10.00 MB")
+		assert.Contains(t, capturedEvent.Title, "✅ Backup completed")
+		assert.Contains(t, capturedEvent.Title, database.Name)
+		assert.Contains(t, capturedEvent.Message, "Backup completed successfully")
+		assert.Contains(t, capturedEvent.Message, "10.00 MB")
 		assert.Equal(t, notifier.ID, capturedNotifier.ID)
+		assert.Equal(t, database.ID, capturedEvent.Database.ID)
+		assert.Equal(t, workspace.ID, capturedEvent.Workspace.ID)
+		assert.Equal(t, 10.0, capturedEvent.Backup.SizeMb)
 	})
 }
 
@@ -177,7 +181,7 @@ func (uc *CreateFailedBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*common.BackupMetadata, error) {
 	backupProgressListener(10)
@@ -191,7 +195,7 @@ func (uc *CreateSuccessBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*common.BackupMetadata, error) {
 	backupProgressListener(10)
@@ -201,3 +205,120 @@ func (uc *CreateSuccessBackupUsecase) Execute(
 		Encryption:     backups_config.BackupEncryptionNone,
 	}, nil
 }
+
+func Test_IsBackupNotificationEnabled_IncidentNotifierGetsSuccessWithFailures(t *testing.T) {
+	failuresOnlyConfig := &backups_config.BackupConfig{
+		SendNotificationsOn: []backups_config.BackupNotificationType{
+			backups_config.NotificationBackupFailed,
+		},
+	}
+	disabledConfig := &backups_config.BackupConfig{
+		SendNotificationsOn: []backups_config.BackupNotificationType{},
+	}
+
+	incidentNotifier := &notifiers.Notifier{NotifierType: notifiers.NotifierTypeIncident}
+	slackNotifier := &notifiers.Notifier{NotifierType: notifiers.NotifierTypeSlack}
+
+	assert.True(t, isBackupNotificationEnabled(
+		failuresOnlyConfig,
+		backups_config.NotificationBackupSuccess,
+		incidentNotifier,
+	))
+	assert.False(t, isBackupNotificationEnabled(
+		failuresOnlyConfig,
+		backups_config.NotificationBackupSuccess,
+		slackNotifier,
+	))
+	assert.True(t, isBackupNotificationEnabled(
+		failuresOnlyConfig,
+		backups_config.NotificationBackupFailed,
+		slackNotifier,
+	))
+	assert.False(t, isBackupNotificationEnabled(
+		disabledConfig,
+		backups_config.NotificationBackupSuccess,
+		incidentNotifier,
+	))
+}
+
+func Test_OpenBackupFile_WhenCopiesUnavailable_NextCopyOpened(t *testing.T) {
+	user := users_testing.CreateTestUser(users_enums.UserRoleAdmin)
+	router := CreateTestRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", user, router)
+	emptyStorage := storages.CreateTestStorage(workspace.ID)
+	savedStorage := storages.CreateTestStorage(workspace.ID)
+	removedStorageID := uuid.New()
+
+	backupID := uuid.New()
+	err := savedStorage.SaveFile(
+		context.Background(),
+		encryption.GetFieldEncryptor(),
+		logger.GetLogger(),
+		backupID,
+		strings.NewReader("backup data"),
+	)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = savedStorage.DeleteFile(encryption.GetFieldEncryptor(), backupID)
+		storages.RemoveTestStorage(emptyStorage.ID)
+		storages.RemoveTestStorage(savedStorage.ID)
+		workspaces_testing.RemoveTestWorkspace(workspace, router)
+	}()
+
+	backupService := &BackupService{
+		storageService: storages.GetStorageService(),
+		fieldEncryptor: encryption.GetFieldEncryptor(),
+		logger:         logger.GetLogger(),
+	}
+
+	testCases := []struct {
+		name              string
+		storageIDs        []uuid.UUID
+		expectedStorageID *uuid.UUID
+	}{
+		{
+			name:              "first copy has no file",
+			storageIDs:        []uuid.UUID{emptyStorage.ID, savedStorage.ID},
+			expectedStorageID: &savedStorage.ID,
+		},
+		{
+			name:              "first copy storage removed",
+			storageIDs:        []uuid.UUID{removedStorageID, emptyStorage.ID, savedStorage.ID},
+			expectedStorageID: &savedStorage.ID,
+		},
+		{
+			name:       "all copies unavailable",
+			storageIDs: []uuid.UUID{removedStorageID, emptyStorage.ID},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			backup := &Backup{ID: backupID, StorageID: testCase.storageIDs[0]}
+			for _, storageID := range testCase.storageIDs {
+				backup.StorageCopies = append(backup.StorageCopies, &BackupStorageCopy{
+					ID:        uuid.New(),
+					BackupID:  backupID,
+					StorageID: storageID,
+					Status:    BackupStorageCopyStatusCompleted,
+				})
+			}
+
+			storage, fileReader, err := backupService.openBackupFile(backup)
+
+			if testCase.expectedStorageID == nil {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, *testCase.expectedStorageID, storage.ID)
+
+			data, err := io.ReadAll(fileReader)
+			assert.NoError(t, err)
+			assert.Equal(t, "backup data", string(data))
+			assert.NoError(t, fileReader.Close())
+		})
+	}
+}
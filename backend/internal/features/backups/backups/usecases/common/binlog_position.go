@@ -0,0 +1,61 @@
+package common
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// binlogPositionHeadLimit is the size of the dump head searched for the
+// position, it is written before any data
+const binlogPositionHeadLimit = 1024 * 1024
+
+// binlogPositionRegexp matches comment written by --master-data=2 and
+// --source-data=2, e.g. "-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000003',
+// MASTER_LOG_POS=157;"
+var binlogPositionRegexp = regexp.MustCompile(
+	`(?:MASTER|SOURCE)_LOG_FILE='([^']+)',\s*(?:MASTER|SOURCE)_LOG_POS=(\d+);`,
+)
+
+// BinlogPositionReader passes the dump through and finds the binary log
+// position the dump is consistent with. Archived binlogs are replayed from
+// this position on restore
+type BinlogPositionReader struct {
+	Reader io.Reader
+
+	head     []byte
+	file     string
+	position int64
+	isFound  bool
+}
+
+func (br *BinlogPositionReader) Read(p []byte) (n int, err error) {
+	n, err = br.Reader.Read(p)
+
+	if !br.isFound && len(br.head) < binlogPositionHeadLimit && n > 0 {
+		br.head = append(br.head, p[:n]...)
+
+		if match := binlogPositionRegexp.FindSubmatch(br.head); match != nil {
+			position, parseErr := strconv.ParseInt(string(match[2]), 10, 64)
+			if parseErr == nil {
+				br.file = string(match[1])
+				br.position = position
+				br.isFound = true
+			}
+
+			br.head = nil
+		}
+	}
+
+	return n, err
+}
+
+// GetBinlogPosition returns file and position, isFound is false when the
+// dump was made without --master-data or --source-data
+func (br *BinlogPositionReader) GetBinlogPosition() (file string, position int64, isFound bool) {
+	return br.file, br.position, br.isFound
+}
+
+func NewBinlogPositionReader(reader io.Reader) *BinlogPositionReader {
+	return &BinlogPositionReader{Reader: reader}
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BinlogPositionReader_MysqldumpHeader_PositionFound(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+	}{
+		{
+			name:   "MySQL 5.7 and MariaDB",
+			header: "--\n-- CHANGE MASTER TO MASTER_LOG_FILE='mysql-bin.000042', MASTER_LOG_POS=1337;\n",
+		},
+		{
+			name:   "MySQL 8.4",
+			header: "--\n-- CHANGE REPLICATION SOURCE TO SOURCE_LOG_FILE='binlog.000042', SOURCE_LOG_POS=1337;\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			dump := "-- MySQL dump 10.13\n" + testCase.header + "CREATE TABLE users (id int);\n"
+
+			// one byte reads check the position split between reads is found
+			reader := NewBinlogPositionReader(iotest.OneByteReader(strings.NewReader(dump)))
+
+			output, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, dump, string(output))
+
+			file, position, isFound := reader.GetBinlogPosition()
+			assert.True(t, isFound)
+			assert.Contains(t, file, ".000042")
+			assert.Equal(t, int64(1337), position)
+		})
+	}
+}
+
+func Test_BinlogPositionReader_DumpWithoutPosition_NotFound(t *testing.T) {
+	reader := NewBinlogPositionReader(strings.NewReader("-- MySQL dump 10.13\nCREATE TABLE users (id int);\n"))
+
+	_, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+
+	_, _, isFound := reader.GetBinlogPosition()
+	assert.False(t, isFound)
+}
@@ -6,4 +6,23 @@ type BackupMetadata struct {
 	EncryptionSalt *string
 	EncryptionIV   *string
 	Encryption     backups_config.BackupEncryption
+
+	EncryptionKeyVersion *int
+
+	// Compression is the algorithm actually used, it may differ from the
+	// configured one when the dump tool does not support it
+	Compression backups_config.BackupCompression
+
+	// SHA-256 of the file content before encryption, hex encoded
+	Sha256 *string
+
+	// binary log position the dump is consistent with, set for MySQL and
+	// MariaDB dumps made with binlog archiving enabled
+	BinlogFile     *string
+	BinlogPosition *int64
+
+	// IsOplogIncluded is set for MongoDB dumps made with --oplog
+	IsOplogIncluded bool
+
+	StorageSaveResults []*StorageSaveResult
 }
@@ -1,6 +1,11 @@
 package common
 
-import "io"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
 
 type CountingWriter struct {
 	Writer       io.Writer
@@ -20,3 +25,25 @@ func (cw *CountingWriter) GetBytesWritten() int64 {
 func NewCountingWriter(writer io.Writer) *CountingWriter {
 	return &CountingWriter{Writer: writer}
 }
+
+// HashingWriter computes SHA-256 of the written bytes. It wraps the writer
+// before encryption, so the hash can be recomputed from the decrypted file
+// regardless of the storage and encryption key
+type HashingWriter struct {
+	Writer io.Writer
+	hash   hash.Hash
+}
+
+func (hw *HashingWriter) Write(p []byte) (n int, err error) {
+	n, err = hw.Writer.Write(p)
+	hw.hash.Write(p[:n])
+	return n, err
+}
+
+func (hw *HashingWriter) GetSha256() string {
+	return hex.EncodeToString(hw.hash.Sum(nil))
+}
+
+func NewHashingWriter(writer io.Writer) *HashingWriter {
+	return &HashingWriter{Writer: writer, hash: sha256.New()}
+}
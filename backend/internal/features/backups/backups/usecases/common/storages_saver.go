@@ -0,0 +1,328 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+
+	"github.com/google/uuid"
+)
+
+const (
+	storagesSaverBufferSize = 1024 * 1024
+	// number of chunks queued for each storage, so a short hiccup of one
+	// storage does not slow down the others
+	storagesSaverQueueSize = 8
+)
+
+// storageWriteTimeout is how long a storage may not accept a chunk before it
+// is dropped from the stream
+var storageWriteTimeout = 5 * time.Minute
+
+type StorageSaveResult struct {
+	StorageID    uuid.UUID
+	BytesWritten int64
+	Error        error
+}
+
+type fileSaver interface {
+	SaveFile(
+		ctx context.Context,
+		encryptor encryption.FieldEncryptor,
+		logger *slog.Logger,
+		fileID uuid.UUID,
+		file io.Reader,
+	) error
+}
+
+type storageTarget struct {
+	storageID uuid.UUID
+	saver     fileSaver
+}
+
+type storageDestination struct {
+	storageID  uuid.UUID
+	pipeWriter *io.PipeWriter
+	cancel     context.CancelFunc
+
+	// chunks are written to the pipe by the writer goroutine of the storage
+	chunks chan []byte
+	// closeErr is set before chunks are closed, the writer closes the pipe with it
+	closeErr error
+	// writeFailed is closed by the writer when the storage stopped reading
+	writeFailed chan struct{}
+	writerDone  chan struct{}
+
+	// owned by the writer goroutine until writerDone is closed
+	bytesWritten int64
+	writeErr     error
+
+	// owned by the distributing goroutine
+	isDropped bool
+	isStalled bool
+	dropErr   error
+
+	saveErrCh chan error
+}
+
+// SaveToStorages streams the reader to all storages at once. Each storage is
+// fed by its own goroutine, so a storage that fails or stalls is dropped from
+// the stream and the remaining copies still complete. The error is returned
+// only when no storage saved the file
+func SaveToStorages(
+	ctx context.Context,
+	encryptor encryption.FieldEncryptor,
+	logger *slog.Logger,
+	fileID uuid.UUID,
+	backupStorages []*storages.Storage,
+	reader io.Reader,
+) ([]*StorageSaveResult, error) {
+	targets := make([]storageTarget, 0, len(backupStorages))
+	for _, storage := range backupStorages {
+		targets = append(targets, storageTarget{storageID: storage.ID, saver: storage})
+	}
+
+	return saveToStorages(ctx, encryptor, logger, fileID, targets, reader)
+}
+
+func saveToStorages(
+	ctx context.Context,
+	encryptor encryption.FieldEncryptor,
+	logger *slog.Logger,
+	fileID uuid.UUID,
+	targets []storageTarget,
+	reader io.Reader,
+) ([]*StorageSaveResult, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("at least one storage is required")
+	}
+
+	destinations := make([]*storageDestination, 0, len(targets))
+
+	for _, target := range targets {
+		pipeReader, pipeWriter := io.Pipe()
+		saveCtx, cancel := context.WithCancel(ctx)
+
+		destination := &storageDestination{
+			storageID:   target.storageID,
+			pipeWriter:  pipeWriter,
+			cancel:      cancel,
+			chunks:      make(chan []byte, storagesSaverQueueSize),
+			writeFailed: make(chan struct{}),
+			writerDone:  make(chan struct{}),
+			saveErrCh:   make(chan error, 1),
+		}
+
+		go func() {
+			saveErr := target.saver.SaveFile(saveCtx, encryptor, logger, fileID, pipeReader)
+			if saveErr != nil {
+				// unblock writer, so failed storage does not stop other copies
+				_ = pipeReader.CloseWithError(saveErr)
+			} else {
+				_ = pipeReader.Close()
+			}
+
+			destination.saveErrCh <- saveErr
+		}()
+
+		go destination.writeChunks()
+
+		destinations = append(destinations, destination)
+	}
+
+	readErr := copyToDestinations(reader, destinations, logger)
+
+	for _, destination := range destinations {
+		if destination.isDropped {
+			continue
+		}
+
+		destination.closeErr = readErr
+		close(destination.chunks)
+	}
+
+	var wg sync.WaitGroup
+	for _, destination := range destinations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer destination.cancel()
+
+			<-destination.writerDone
+
+			if destination.isStalled {
+				// the storage is not reading, so its result is not awaited
+				destination.writeErr = destination.dropErr
+				return
+			}
+
+			saveErr := <-destination.saveErrCh
+			if destination.writeErr == nil {
+				destination.writeErr = saveErr
+			}
+		}()
+	}
+	wg.Wait()
+
+	results := make([]*StorageSaveResult, 0, len(destinations))
+	isAnySaved := false
+
+	for _, destination := range destinations {
+		result := &StorageSaveResult{
+			StorageID:    destination.storageID,
+			BytesWritten: destination.bytesWritten,
+			Error:        destination.writeErr,
+		}
+
+		if readErr != nil && result.Error == nil {
+			result.Error = readErr
+		}
+
+		if result.Error == nil {
+			isAnySaved = true
+		} else {
+			logger.Error(
+				"Failed to save file to storage",
+				"fileId",
+				fileID,
+				"storageId",
+				destination.storageID,
+				"error",
+				result.Error,
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	if readErr != nil {
+		return results, readErr
+	}
+
+	if !isAnySaved {
+		return results, fmt.Errorf("failed to save file to any storage: %w", results[0].Error)
+	}
+
+	return results, nil
+}
+
+func (d *storageDestination) writeChunks() {
+	defer close(d.writerDone)
+
+	for chunk := range d.chunks {
+		written, err := d.pipeWriter.Write(chunk)
+		d.bytesWritten += int64(written)
+
+		if err != nil {
+			d.writeErr = err
+			close(d.writeFailed)
+			return
+		}
+	}
+
+	if d.closeErr != nil {
+		_ = d.pipeWriter.CloseWithError(d.closeErr)
+	} else {
+		_ = d.pipeWriter.Close()
+	}
+}
+
+func copyToDestinations(
+	reader io.Reader,
+	destinations []*storageDestination,
+	logger *slog.Logger,
+) error {
+	for {
+		// chunks are shared by the writers, so each read gets its own buffer
+		buf := make([]byte, storagesSaverBufferSize)
+		n, readErr := reader.Read(buf)
+
+		if n > 0 {
+			isAnyActive := false
+
+			for _, destination := range destinations {
+				if destination.isDropped {
+					continue
+				}
+
+				if err := sendChunk(destination, buf[:n]); err != nil {
+					destination.drop(err)
+					logger.Warn(
+						"Storage dropped from backup stream",
+						"storageId",
+						destination.storageID,
+						"error",
+						err,
+					)
+					continue
+				}
+
+				isAnyActive = true
+			}
+
+			if !isAnyActive {
+				err := errors.New("all storages failed")
+				if closer, ok := reader.(interface{ CloseWithError(error) error }); ok {
+					_ = closer.CloseWithError(err)
+				}
+
+				return nil
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func sendChunk(destination *storageDestination, chunk []byte) error {
+	select {
+	case destination.chunks <- chunk:
+		return nil
+	case <-destination.writeFailed:
+		return errors.New("storage stopped reading the file")
+	default:
+	}
+
+	timer := time.NewTimer(storageWriteTimeout)
+	defer timer.Stop()
+
+	select {
+	case destination.chunks <- chunk:
+		return nil
+	case <-destination.writeFailed:
+		return errors.New("storage stopped reading the file")
+	case <-timer.C:
+		return fmt.Errorf("storage did not accept data for %s", storageWriteTimeout)
+	}
+}
+
+// drop removes the storage from the stream. A stalled storage is unblocked by
+// closing its pipe and canceling its context
+func (d *storageDestination) drop(err error) {
+	d.isDropped = true
+	close(d.chunks)
+
+	select {
+	case <-d.writeFailed:
+		return
+	default:
+	}
+
+	d.isStalled = true
+	d.dropErr = err
+	_ = d.pipeWriter.CloseWithError(err)
+	d.cancel()
+}
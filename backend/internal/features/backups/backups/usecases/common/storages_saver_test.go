@@ -0,0 +1,139 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SaveToStorages_WhenStoragesFailedOrStalled_OnlyThoseStoragesDropped(t *testing.T) {
+	originalTimeout := storageWriteTimeout
+	storageWriteTimeout = 100 * time.Millisecond
+	defer func() { storageWriteTimeout = originalTimeout }()
+
+	// larger than the queue of a storage, so a stalled storage blocks its writer
+	dataSize := (storagesSaverQueueSize + 4) * storagesSaverBufferSize
+	data := bytes.Repeat([]byte("0123456789abcdef"), dataSize/16)
+
+	testCases := []struct {
+		name          string
+		savers        []fileSaver
+		isSaved       []bool
+		expectedError string
+	}{
+		{
+			name:    "one storage failing",
+			savers:  []fileSaver{&fakeSaver{}, &fakeSaver{failAfterBytes: 1024}},
+			isSaved: []bool{true, false},
+		},
+		{
+			name:    "one storage stalling",
+			savers:  []fileSaver{&fakeSaver{isStalling: true}, &fakeSaver{}},
+			isSaved: []bool{false, true},
+		},
+		{
+			name: "all storages failing",
+			savers: []fileSaver{
+				&fakeSaver{failAfterBytes: 1024},
+				&fakeSaver{isStalling: true},
+			},
+			isSaved:       []bool{false, false},
+			expectedError: "failed to save file to any storage",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			targets := make([]storageTarget, 0, len(testCase.savers))
+			for _, saver := range testCase.savers {
+				targets = append(targets, storageTarget{storageID: uuid.New(), saver: saver})
+			}
+
+			done := make(chan struct{})
+			var results []*StorageSaveResult
+			var err error
+
+			go func() {
+				defer close(done)
+
+				results, err = saveToStorages(
+					context.Background(),
+					nil,
+					logger.GetLogger(),
+					uuid.New(),
+					targets,
+					bytes.NewReader(data),
+				)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("saving was blocked by a storage")
+			}
+
+			if testCase.expectedError != "" {
+				assert.ErrorContains(t, err, testCase.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, results, len(testCase.savers))
+
+			for i, saver := range testCase.savers {
+				fake := saver.(*fakeSaver)
+
+				assert.Equal(t, targets[i].storageID, results[i].StorageID)
+
+				if testCase.isSaved[i] {
+					assert.NoError(t, results[i].Error)
+					assert.Equal(t, int64(len(data)), results[i].BytesWritten)
+					assert.Equal(t, data, fake.saved.Bytes())
+				} else {
+					assert.Error(t, results[i].Error)
+				}
+			}
+		})
+	}
+}
+
+type fakeSaver struct {
+	failAfterBytes int
+	isStalling     bool
+
+	saved bytes.Buffer
+}
+
+func (f *fakeSaver) SaveFile(
+	ctx context.Context,
+	encryptor encryption.FieldEncryptor,
+	logger *slog.Logger,
+	fileID uuid.UUID,
+	file io.Reader,
+) error {
+	if f.isStalling {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	if f.failAfterBytes > 0 {
+		if _, err := io.CopyN(&f.saved, file, int64(f.failAfterBytes)); err != nil {
+			return err
+		}
+
+		return errors.New("storage is unavailable")
+	}
+
+	_, err := io.Copy(&f.saved, file)
+	return err
+}
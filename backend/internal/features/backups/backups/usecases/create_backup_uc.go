@@ -28,7 +28,7 @@ func (uc *CreateBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	database *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
 	switch database.Type {
@@ -38,7 +38,7 @@ func (uc *CreateBackupUsecase) Execute(
 			backupID,
 			backupConfig,
 			database,
-			storage,
+			backupStorages,
 			backupProgressListener,
 		)
 
@@ -48,7 +48,7 @@ func (uc *CreateBackupUsecase) Execute(
 			backupID,
 			backupConfig,
 			database,
-			storage,
+			backupStorages,
 			backupProgressListener,
 		)
 
@@ -58,7 +58,7 @@ func (uc *CreateBackupUsecase) Execute(
 			backupID,
 			backupConfig,
 			database,
-			storage,
+			backupStorages,
 			backupProgressListener,
 		)
 
@@ -68,7 +68,7 @@ func (uc *CreateBackupUsecase) Execute(
 			backupID,
 			backupConfig,
 			database,
-			storage,
+			backupStorages,
 			backupProgressListener,
 		)
 
@@ -15,9 +15,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/klauspost/compress/zstd"
 
 	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	backup_encryption "postgresus-backend/internal/features/backups/backups/encryption"
 	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -30,13 +30,12 @@ import (
 )
 
 const (
-	backupTimeout               = 23 * time.Hour
-	shutdownCheckInterval       = 1 * time.Second
-	copyBufferSize              = 8 * 1024 * 1024
-	progressReportIntervalMB    = 1.0
-	zstdStorageCompressionLevel = 5
-	exitCodeGenericError        = 1
-	exitCodeConnectionError     = 2
+	backupTimeout            = 23 * time.Hour
+	shutdownCheckInterval    = 1 * time.Second
+	copyBufferSize           = 8 * 1024 * 1024
+	progressReportIntervalMB = 1.0
+	exitCodeGenericError     = 1
+	exitCodeConnectionError  = 2
 )
 
 type CreateMariadbBackupUsecase struct {
@@ -55,13 +54,13 @@ func (uc *CreateMariadbBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	db *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
 	uc.logger.Info(
 		"Creating MariaDB backup via mariadb-dump",
 		"databaseId", db.ID,
-		"storageId", storage.ID,
+		"storagesCount", len(backupStorages),
 	)
 
 	if !backupConfig.IsBackupsEnabled {
@@ -82,7 +81,7 @@ func (uc *CreateMariadbBackupUsecase) Execute(
 		return nil, fmt.Errorf("failed to decrypt database password: %w", err)
 	}
 
-	args := uc.buildMariadbDumpArgs(mdb)
+	args := uc.buildMariadbDumpArgs(mdb, backupConfig.IsBinlogArchivingEnabled)
 
 	return uc.streamToStorage(
 		ctx,
@@ -96,7 +95,7 @@ func (uc *CreateMariadbBackupUsecase) Execute(
 		),
 		args,
 		decryptedPassword,
-		storage,
+		backupStorages,
 		backupProgressListener,
 		mdb,
 	)
@@ -104,6 +103,7 @@ func (uc *CreateMariadbBackupUsecase) Execute(
 
 func (uc *CreateMariadbBackupUsecase) buildMariadbDumpArgs(
 	mdb *mariadbtypes.MariadbDatabase,
+	isBinlogArchivingEnabled bool,
 ) []string {
 	args := []string{
 		"--host=" + mdb.Host,
@@ -119,6 +119,12 @@ func (uc *CreateMariadbBackupUsecase) buildMariadbDumpArgs(
 
 	args = append(args, "--compress")
 
+	// position of the dump is written as a comment, archived binlogs are
+	// replayed from it on restore
+	if isBinlogArchivingEnabled {
+		args = append(args, "--master-data=2")
+	}
+
 	if mdb.IsHttps {
 		args = append(args, "--ssl")
 	}
@@ -137,7 +143,7 @@ func (uc *CreateMariadbBackupUsecase) streamToStorage(
 	mariadbBin string,
 	args []string,
 	password string,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 	mdbConfig *mariadbtypes.MariadbDatabase,
 ) (*usecases_common.BackupMetadata, error) {
@@ -191,16 +197,31 @@ func (uc *CreateMariadbBackupUsecase) streamToStorage(
 		return nil, err
 	}
 
-	zstdWriter, err := zstd.NewWriter(finalWriter,
-		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(zstdStorageCompressionLevel)))
+	hashingWriter := usecases_common.NewHashingWriter(finalWriter)
+
+	compressionWriter, err := compression.NewCompressionWriter(
+		hashingWriter,
+		backupConfig.Compression,
+		backupConfig.CompressionLevel,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		return nil, fmt.Errorf("failed to create compression writer: %w", err)
 	}
-	countingWriter := usecases_common.NewCountingWriter(zstdWriter)
+	countingWriter := usecases_common.NewCountingWriter(compressionWriter)
+	binlogPositionReader := usecases_common.NewBinlogPositionReader(pgStdout)
 
 	saveErrCh := make(chan error, 1)
+	var storageSaveResults []*usecases_common.StorageSaveResult
 	go func() {
-		saveErr := storage.SaveFile(ctx, uc.fieldEncryptor, uc.logger, backupID, storageReader)
+		results, saveErr := usecases_common.SaveToStorages(
+			ctx,
+			uc.fieldEncryptor,
+			uc.logger,
+			backupID,
+			backupStorages,
+			storageReader,
+		)
+		storageSaveResults = results
 		saveErrCh <- saveErr
 	}()
 
@@ -214,7 +235,7 @@ func (uc *CreateMariadbBackupUsecase) streamToStorage(
 		bytesWritten, err := uc.copyWithShutdownCheck(
 			ctx,
 			countingWriter,
-			pgStdout,
+			binlogPositionReader,
 			backupProgressListener,
 		)
 		bytesWrittenCh <- bytesWritten
@@ -227,13 +248,13 @@ func (uc *CreateMariadbBackupUsecase) streamToStorage(
 
 	select {
 	case <-ctx.Done():
-		uc.cleanupOnCancellation(zstdWriter, encryptionWriter, storageWriter, saveErrCh)
+		uc.cleanupOnCancellation(compressionWriter, encryptionWriter, storageWriter, saveErrCh)
 		return nil, uc.checkCancellationReason()
 	default:
 	}
 
-	if err := zstdWriter.Close(); err != nil {
-		uc.logger.Error("Failed to close zstd writer", "error", err)
+	if err := compressionWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close compression writer", "error", err)
 	}
 	if err := uc.closeWriters(encryptionWriter, storageWriter); err != nil {
 		<-saveErrCh
@@ -257,6 +278,21 @@ func (uc *CreateMariadbBackupUsecase) streamToStorage(
 		return nil, fmt.Errorf("save to storage: %w", saveErr)
 	}
 
+	sha256 := hashingWriter.GetSha256()
+	backupMetadata.Sha256 = &sha256
+	backupMetadata.Compression = backupConfig.Compression
+
+	if binlogFile, binlogPosition, isFound := binlogPositionReader.GetBinlogPosition(); isFound {
+		backupMetadata.BinlogFile = &binlogFile
+		backupMetadata.BinlogPosition = &binlogPosition
+	} else if backupConfig.IsBinlogArchivingEnabled {
+		uc.logger.Warn(
+			"Binlog position not found in dump, binlogs cannot be replayed on top of it",
+			"backupId", backupID,
+		)
+	}
+	backupMetadata.StorageSaveResults = storageSaveResults
+
 	return &backupMetadata, nil
 }
 
@@ -420,7 +456,7 @@ func (uc *CreateMariadbBackupUsecase) setupBackupEncryption(
 		return nil, nil, metadata, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	keyVersion, masterKey, err := uc.secretKeyService.GetCurrentKey()
 	if err != nil {
 		return nil, nil, metadata, fmt.Errorf("failed to get master key: %w", err)
 	}
@@ -440,6 +476,7 @@ func (uc *CreateMariadbBackupUsecase) setupBackupEncryption(
 	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
 	metadata.EncryptionSalt = &saltBase64
 	metadata.EncryptionIV = &nonceBase64
+	metadata.EncryptionKeyVersion = &keyVersion
 	metadata.Encryption = backups_config.BackupEncryptionEncrypted
 
 	uc.logger.Info("Encryption enabled for backup", "backupId", backupID)
@@ -447,16 +484,16 @@ func (uc *CreateMariadbBackupUsecase) setupBackupEncryption(
 }
 
 func (uc *CreateMariadbBackupUsecase) cleanupOnCancellation(
-	zstdWriter *zstd.Encoder,
+	compressionWriter io.WriteCloser,
 	encryptionWriter *backup_encryption.EncryptionWriter,
 	storageWriter io.WriteCloser,
 	saveErrCh chan error,
 ) {
-	if zstdWriter != nil {
+	if compressionWriter != nil {
 		go func() {
-			if closeErr := zstdWriter.Close(); closeErr != nil {
+			if closeErr := compressionWriter.Close(); closeErr != nil {
 				uc.logger.Error(
-					"Failed to close zstd writer during cancellation",
+					"Failed to close compression writer during cancellation",
 					"error",
 					closeErr,
 				)
@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 
 	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	backup_encryption "postgresus-backend/internal/features/backups/backups/encryption"
 	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -49,13 +50,13 @@ func (uc *CreateMongodbBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	db *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
 	uc.logger.Info(
 		"Creating MongoDB backup via mongodump",
 		"databaseId", db.ID,
-		"storageId", storage.ID,
+		"storagesCount", len(backupStorages),
 	)
 
 	if !backupConfig.IsBackupsEnabled {
@@ -67,7 +68,7 @@ func (uc *CreateMongodbBackupUsecase) Execute(
 		return nil, fmt.Errorf("mongodb database configuration is required")
 	}
 
-	if mdb.Database == "" {
+	if mdb.Database == "" && !mdb.IsAllDatabases {
 		return nil, fmt.Errorf("database name is required for mongodump backups")
 	}
 
@@ -76,9 +77,9 @@ func (uc *CreateMongodbBackupUsecase) Execute(
 		return nil, fmt.Errorf("failed to decrypt database password: %w", err)
 	}
 
-	args := uc.buildMongodumpArgs(mdb, decryptedPassword)
+	args := uc.buildMongodumpArgs(mdb, decryptedPassword, backupConfig.Compression)
 
-	return uc.streamToStorage(
+	backupMetadata, err := uc.streamToStorage(
 		ctx,
 		backupID,
 		backupConfig,
@@ -88,22 +89,44 @@ func (uc *CreateMongodbBackupUsecase) Execute(
 			config.GetEnv().MongodbInstallDir,
 		),
 		args,
-		storage,
+		backupStorages,
 		backupProgressListener,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	backupMetadata.IsOplogIncluded = mdb.IsAllDatabases && mdb.IsOplogEnabled
+	backupMetadata.Compression = backupConfig.Compression
+
+	return backupMetadata, nil
 }
 
 func (uc *CreateMongodbBackupUsecase) buildMongodumpArgs(
 	mdb *mongodbtypes.MongodbDatabase,
 	password string,
+	backupCompression backups_config.BackupCompression,
 ) []string {
 	uri := mdb.BuildMongodumpURI(password)
 
 	args := []string{
 		"--uri=" + uri,
-		"--db=" + mdb.Database,
 		"--archive",
-		"--gzip",
+	}
+
+	// mongodump compresses collections inside the archive with gzip of
+	// default level, other algorithms are applied to the whole archive
+	if backupCompression == backups_config.BackupCompressionGzip {
+		args = append(args, "--gzip")
+	}
+
+	// mongodump supports --oplog only for dumps of the whole instance
+	if mdb.IsAllDatabases {
+		if mdb.IsOplogEnabled {
+			args = append(args, "--oplog")
+		}
+	} else {
+		args = append(args, "--db="+mdb.Database)
 	}
 
 	return args
@@ -115,7 +138,7 @@ func (uc *CreateMongodbBackupUsecase) streamToStorage(
 	backupConfig *backups_config.BackupConfig,
 	mongodumpBin string,
 	args []string,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
 	uc.logger.Info("Streaming MongoDB backup to storage", "mongodumpBin", mongodumpBin)
@@ -168,11 +191,30 @@ func (uc *CreateMongodbBackupUsecase) streamToStorage(
 		return nil, err
 	}
 
-	countingWriter := usecases_common.NewCountingWriter(finalWriter)
+	hashingWriter := usecases_common.NewHashingWriter(finalWriter)
+
+	compressionWriter, err := compression.NewCompressionWriter(
+		hashingWriter,
+		uc.getArchiveCompression(backupConfig.Compression),
+		backupConfig.CompressionLevel,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compression writer: %w", err)
+	}
+	countingWriter := usecases_common.NewCountingWriter(compressionWriter)
 
 	saveErrCh := make(chan error, 1)
+	var storageSaveResults []*usecases_common.StorageSaveResult
 	go func() {
-		saveErr := storage.SaveFile(ctx, uc.fieldEncryptor, uc.logger, backupID, storageReader)
+		results, saveErr := usecases_common.SaveToStorages(
+			ctx,
+			uc.fieldEncryptor,
+			uc.logger,
+			backupID,
+			backupStorages,
+			storageReader,
+		)
+		storageSaveResults = results
 		saveErrCh <- saveErr
 	}()
 
@@ -199,11 +241,14 @@ func (uc *CreateMongodbBackupUsecase) streamToStorage(
 
 	select {
 	case <-ctx.Done():
-		uc.cleanupOnCancellation(encryptionWriter, storageWriter, saveErrCh)
+		uc.cleanupOnCancellation(compressionWriter, encryptionWriter, storageWriter, saveErrCh)
 		return nil, uc.checkCancellationReason()
 	default:
 	}
 
+	if err := compressionWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close compression writer", "error", err)
+	}
 	if err := uc.closeWriters(encryptionWriter, storageWriter); err != nil {
 		<-saveErrCh
 		return nil, err
@@ -226,6 +271,10 @@ func (uc *CreateMongodbBackupUsecase) streamToStorage(
 		return nil, fmt.Errorf("save to storage: %w", saveErr)
 	}
 
+	sha256 := hashingWriter.GetSha256()
+	backupMetadata.Sha256 = &sha256
+	backupMetadata.StorageSaveResults = storageSaveResults
+
 	return &backupMetadata, nil
 }
 
@@ -276,7 +325,7 @@ func (uc *CreateMongodbBackupUsecase) setupBackupEncryption(
 		return nil, nil, backupMetadata, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	keyVersion, masterKey, err := uc.secretKeyService.GetCurrentKey()
 	if err != nil {
 		return nil, nil, backupMetadata, fmt.Errorf("failed to get master key: %w", err)
 	}
@@ -298,6 +347,7 @@ func (uc *CreateMongodbBackupUsecase) setupBackupEncryption(
 	backupMetadata.Encryption = backups_config.BackupEncryptionEncrypted
 	backupMetadata.EncryptionSalt = &saltBase64
 	backupMetadata.EncryptionIV = &nonceBase64
+	backupMetadata.EncryptionKeyVersion = &keyVersion
 
 	return encryptionWriter, encryptionWriter, backupMetadata, nil
 }
@@ -374,11 +424,25 @@ func (uc *CreateMongodbBackupUsecase) copyWithShutdownCheck(
 	}
 }
 
+// getArchiveCompression returns the algorithm applied to mongodump output,
+// gzip is already applied by mongodump itself
+func (uc *CreateMongodbBackupUsecase) getArchiveCompression(
+	backupCompression backups_config.BackupCompression,
+) backups_config.BackupCompression {
+	if backupCompression == backups_config.BackupCompressionGzip {
+		return backups_config.BackupCompressionNone
+	}
+
+	return backupCompression
+}
+
 func (uc *CreateMongodbBackupUsecase) cleanupOnCancellation(
+	compressionWriter io.WriteCloser,
 	encryptionWriter *backup_encryption.EncryptionWriter,
 	storageWriter *io.PipeWriter,
 	saveErrCh chan error,
 ) {
+	_ = compressionWriter.Close()
 	if encryptionWriter != nil {
 		_ = encryptionWriter.Close()
 	}
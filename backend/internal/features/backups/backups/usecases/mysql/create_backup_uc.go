@@ -15,9 +15,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/klauspost/compress/zstd"
 
 	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	backup_encryption "postgresus-backend/internal/features/backups/backups/encryption"
 	usecases_common "postgresus-backend/internal/features/backups/backups/usecases/common"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -30,13 +30,12 @@ import (
 )
 
 const (
-	backupTimeout               = 23 * time.Hour
-	shutdownCheckInterval       = 1 * time.Second
-	copyBufferSize              = 8 * 1024 * 1024
-	progressReportIntervalMB    = 1.0
-	zstdStorageCompressionLevel = 5
-	exitCodeGenericError        = 1
-	exitCodeConnectionError     = 2
+	backupTimeout            = 23 * time.Hour
+	shutdownCheckInterval    = 1 * time.Second
+	copyBufferSize           = 8 * 1024 * 1024
+	progressReportIntervalMB = 1.0
+	exitCodeGenericError     = 1
+	exitCodeConnectionError  = 2
 )
 
 type CreateMysqlBackupUsecase struct {
@@ -55,13 +54,13 @@ func (uc *CreateMysqlBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	db *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
 	uc.logger.Info(
 		"Creating MySQL backup via mysqldump",
 		"databaseId", db.ID,
-		"storageId", storage.ID,
+		"storagesCount", len(backupStorages),
 	)
 
 	if !backupConfig.IsBackupsEnabled {
@@ -82,7 +81,7 @@ func (uc *CreateMysqlBackupUsecase) Execute(
 		return nil, fmt.Errorf("failed to decrypt database password: %w", err)
 	}
 
-	args := uc.buildMysqldumpArgs(my)
+	args := uc.buildMysqldumpArgs(my, backupConfig.IsBinlogArchivingEnabled)
 
 	return uc.streamToStorage(
 		ctx,
@@ -96,13 +95,16 @@ func (uc *CreateMysqlBackupUsecase) Execute(
 		),
 		args,
 		decryptedPassword,
-		storage,
+		backupStorages,
 		backupProgressListener,
 		my,
 	)
 }
 
-func (uc *CreateMysqlBackupUsecase) buildMysqldumpArgs(my *mysqltypes.MysqlDatabase) []string {
+func (uc *CreateMysqlBackupUsecase) buildMysqldumpArgs(
+	my *mysqltypes.MysqlDatabase,
+	isBinlogArchivingEnabled bool,
+) []string {
 	args := []string{
 		"--host=" + my.Host,
 		"--port=" + strconv.Itoa(my.Port),
@@ -118,6 +120,12 @@ func (uc *CreateMysqlBackupUsecase) buildMysqldumpArgs(my *mysqltypes.MysqlDatab
 
 	args = append(args, uc.getNetworkCompressionArgs(my.Version)...)
 
+	// position of the dump is written as a comment, archived binlogs are
+	// replayed from it on restore
+	if isBinlogArchivingEnabled {
+		args = append(args, uc.getBinlogPositionArgs(my.Version)...)
+	}
+
 	if my.IsHttps {
 		args = append(args, "--ssl-mode=REQUIRED")
 	}
@@ -145,6 +153,17 @@ func (uc *CreateMysqlBackupUsecase) getNetworkCompressionArgs(version tools.Mysq
 	}
 }
 
+// getBinlogPositionArgs returns option writing the binlog position, it was
+// renamed to --source-data in 8.0.26 and the old name is removed in 8.4
+func (uc *CreateMysqlBackupUsecase) getBinlogPositionArgs(version tools.MysqlVersion) []string {
+	switch version {
+	case tools.MysqlVersion84, tools.MysqlVersion9:
+		return []string{"--source-data=2"}
+	default:
+		return []string{"--master-data=2"}
+	}
+}
+
 func (uc *CreateMysqlBackupUsecase) streamToStorage(
 	parentCtx context.Context,
 	backupID uuid.UUID,
@@ -152,7 +171,7 @@ func (uc *CreateMysqlBackupUsecase) streamToStorage(
 	mysqlBin string,
 	args []string,
 	password string,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(completedMBs float64),
 	myConfig *mysqltypes.MysqlDatabase,
 ) (*usecases_common.BackupMetadata, error) {
@@ -206,16 +225,31 @@ func (uc *CreateMysqlBackupUsecase) streamToStorage(
 		return nil, err
 	}
 
-	zstdWriter, err := zstd.NewWriter(finalWriter,
-		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(zstdStorageCompressionLevel)))
+	hashingWriter := usecases_common.NewHashingWriter(finalWriter)
+
+	compressionWriter, err := compression.NewCompressionWriter(
+		hashingWriter,
+		backupConfig.Compression,
+		backupConfig.CompressionLevel,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		return nil, fmt.Errorf("failed to create compression writer: %w", err)
 	}
-	countingWriter := usecases_common.NewCountingWriter(zstdWriter)
+	countingWriter := usecases_common.NewCountingWriter(compressionWriter)
+	binlogPositionReader := usecases_common.NewBinlogPositionReader(pgStdout)
 
 	saveErrCh := make(chan error, 1)
+	var storageSaveResults []*usecases_common.StorageSaveResult
 	go func() {
-		saveErr := storage.SaveFile(ctx, uc.fieldEncryptor, uc.logger, backupID, storageReader)
+		results, saveErr := usecases_common.SaveToStorages(
+			ctx,
+			uc.fieldEncryptor,
+			uc.logger,
+			backupID,
+			backupStorages,
+			storageReader,
+		)
+		storageSaveResults = results
 		saveErrCh <- saveErr
 	}()
 
@@ -229,7 +263,7 @@ func (uc *CreateMysqlBackupUsecase) streamToStorage(
 		bytesWritten, err := uc.copyWithShutdownCheck(
 			ctx,
 			countingWriter,
-			pgStdout,
+			binlogPositionReader,
 			backupProgressListener,
 		)
 		bytesWrittenCh <- bytesWritten
@@ -242,13 +276,13 @@ func (uc *CreateMysqlBackupUsecase) streamToStorage(
 
 	select {
 	case <-ctx.Done():
-		uc.cleanupOnCancellation(zstdWriter, encryptionWriter, storageWriter, saveErrCh)
+		uc.cleanupOnCancellation(compressionWriter, encryptionWriter, storageWriter, saveErrCh)
 		return nil, uc.checkCancellationReason()
 	default:
 	}
 
-	if err := zstdWriter.Close(); err != nil {
-		uc.logger.Error("Failed to close zstd writer", "error", err)
+	if err := compressionWriter.Close(); err != nil {
+		uc.logger.Error("Failed to close compression writer", "error", err)
 	}
 	if err := uc.closeWriters(encryptionWriter, storageWriter); err != nil {
 		<-saveErrCh
@@ -272,6 +306,21 @@ func (uc *CreateMysqlBackupUsecase) streamToStorage(
 		return nil, fmt.Errorf("save to storage: %w", saveErr)
 	}
 
+	sha256 := hashingWriter.GetSha256()
+	backupMetadata.Sha256 = &sha256
+	backupMetadata.Compression = backupConfig.Compression
+
+	if binlogFile, binlogPosition, isFound := binlogPositionReader.GetBinlogPosition(); isFound {
+		backupMetadata.BinlogFile = &binlogFile
+		backupMetadata.BinlogPosition = &binlogPosition
+	} else if backupConfig.IsBinlogArchivingEnabled {
+		uc.logger.Warn(
+			"Binlog position not found in dump, binlogs cannot be replayed on top of it",
+			"backupId", backupID,
+		)
+	}
+	backupMetadata.StorageSaveResults = storageSaveResults
+
 	return &backupMetadata, nil
 }
 
@@ -433,7 +482,7 @@ func (uc *CreateMysqlBackupUsecase) setupBackupEncryption(
 		return nil, nil, metadata, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	keyVersion, masterKey, err := uc.secretKeyService.GetCurrentKey()
 	if err != nil {
 		return nil, nil, metadata, fmt.Errorf("failed to get master key: %w", err)
 	}
@@ -453,6 +502,7 @@ func (uc *CreateMysqlBackupUsecase) setupBackupEncryption(
 	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
 	metadata.EncryptionSalt = &saltBase64
 	metadata.EncryptionIV = &nonceBase64
+	metadata.EncryptionKeyVersion = &keyVersion
 	metadata.Encryption = backups_config.BackupEncryptionEncrypted
 
 	uc.logger.Info("Encryption enabled for backup", "backupId", backupID)
@@ -460,16 +510,16 @@ func (uc *CreateMysqlBackupUsecase) setupBackupEncryption(
 }
 
 func (uc *CreateMysqlBackupUsecase) cleanupOnCancellation(
-	zstdWriter *zstd.Encoder,
+	compressionWriter io.WriteCloser,
 	encryptionWriter *backup_encryption.EncryptionWriter,
 	storageWriter io.WriteCloser,
 	saveErrCh chan error,
 ) {
-	if zstdWriter != nil {
+	if compressionWriter != nil {
 		go func() {
-			if closeErr := zstdWriter.Close(); closeErr != nil {
+			if closeErr := compressionWriter.Close(); closeErr != nil {
 				uc.logger.Error(
-					"Failed to close zstd writer during cancellation",
+					"Failed to close compression writer during cancellation",
 					"error",
 					closeErr,
 				)
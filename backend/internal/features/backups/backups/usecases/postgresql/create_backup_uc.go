@@ -34,7 +34,7 @@ const (
 	copyBufferSize           = 8 * 1024 * 1024
 	progressReportIntervalMB = 1.0
 	pgConnectTimeout         = 30
-	compressionLevel         = 5
+	maxGzipCompressionLevel  = 9
 	exitCodeAccessViolation  = -1073741819
 	exitCodeGenericError     = 1
 	exitCodeConnectionError  = 2
@@ -56,7 +56,7 @@ func (uc *CreatePostgresqlBackupUsecase) Execute(
 	backupID uuid.UUID,
 	backupConfig *backups_config.BackupConfig,
 	db *databases.Database,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	backupProgressListener func(
 		completedMBs float64,
 	),
@@ -65,8 +65,8 @@ func (uc *CreatePostgresqlBackupUsecase) Execute(
 		"Creating PostgreSQL backup via pg_dump custom format",
 		"databaseId",
 		db.ID,
-		"storageId",
-		storage.ID,
+		"storagesCount",
+		len(backupStorages),
 	)
 
 	if !backupConfig.IsBackupsEnabled {
@@ -83,29 +83,51 @@ func (uc *CreatePostgresqlBackupUsecase) Execute(
 		return nil, fmt.Errorf("database name is required for pg_dump backups")
 	}
 
-	args := uc.buildPgDumpArgs(pg)
+	compression, compressionLevel := uc.getSupportedCompression(
+		pg.Version,
+		backupConfig.BackupType,
+		backupConfig.Compression,
+		backupConfig.CompressionLevel,
+	)
+
+	executable := tools.PostgresqlExecutablePgDump
+	args := uc.buildPgDumpArgs(pg, compression, compressionLevel)
+
+	if backupConfig.BackupType == backups_config.BackupTypePhysical {
+		uc.logger.Info("Using physical backup via pg_basebackup", "databaseId", db.ID)
+
+		executable = tools.PostgresqlExecutablePgBasebackup
+		args = uc.buildPgBasebackupArgs(pg, compression, compressionLevel)
+	}
 
 	decryptedPassword, err := uc.fieldEncryptor.Decrypt(db.ID, pg.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt database password: %w", err)
 	}
 
-	return uc.streamToStorage(
+	backupMetadata, err := uc.streamToStorage(
 		ctx,
 		backupID,
 		backupConfig,
 		tools.GetPostgresqlExecutable(
 			pg.Version,
-			"pg_dump",
+			executable,
 			config.GetEnv().EnvMode,
 			config.GetEnv().PostgresesInstallDir,
 		),
 		args,
 		decryptedPassword,
-		storage,
+		backupStorages,
 		db,
 		backupProgressListener,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	backupMetadata.Compression = compression
+
+	return backupMetadata, nil
 }
 
 // streamToStorage streams pg_dump output directly to storage
@@ -116,7 +138,7 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 	pgBin string,
 	args []string,
 	password string,
-	storage *storages.Storage,
+	backupStorages []*storages.Storage,
 	db *databases.Database,
 	backupProgressListener func(completedMBs float64),
 ) (*usecases_common.BackupMetadata, error) {
@@ -171,14 +193,24 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 		return nil, err
 	}
 
-	countingWriter := usecases_common.NewCountingWriter(finalWriter)
+	hashingWriter := usecases_common.NewHashingWriter(finalWriter)
+	countingWriter := usecases_common.NewCountingWriter(hashingWriter)
 
 	// The backup ID becomes the object key / filename in storage
 
 	// Start streaming into storage in its own goroutine
 	saveErrCh := make(chan error, 1)
+	var storageSaveResults []*usecases_common.StorageSaveResult
 	go func() {
-		saveErr := storage.SaveFile(ctx, uc.fieldEncryptor, uc.logger, backupID, storageReader)
+		results, saveErr := usecases_common.SaveToStorages(
+			ctx,
+			uc.fieldEncryptor,
+			uc.logger,
+			backupID,
+			backupStorages,
+			storageReader,
+		)
+		storageSaveResults = results
 		saveErrCh <- saveErr
 	}()
 
@@ -244,6 +276,10 @@ func (uc *CreatePostgresqlBackupUsecase) streamToStorage(
 		return nil, fmt.Errorf("save to storage: %w", saveErr)
 	}
 
+	sha256 := hashingWriter.GetSha256()
+	backupMetadata.Sha256 = &sha256
+	backupMetadata.StorageSaveResults = storageSaveResults
+
 	return &backupMetadata, nil
 }
 
@@ -324,7 +360,11 @@ func (uc *CreatePostgresqlBackupUsecase) copyWithShutdownCheck(
 	return totalBytesWritten, nil
 }
 
-func (uc *CreatePostgresqlBackupUsecase) buildPgDumpArgs(pg *pgtypes.PostgresqlDatabase) []string {
+func (uc *CreatePostgresqlBackupUsecase) buildPgDumpArgs(
+	pg *pgtypes.PostgresqlDatabase,
+	compression backups_config.BackupCompression,
+	compressionLevel int,
+) []string {
 	args := []string{
 		"-Fc",
 		"--no-password",
@@ -339,20 +379,100 @@ func (uc *CreatePostgresqlBackupUsecase) buildPgDumpArgs(pg *pgtypes.PostgresqlD
 		args = append(args, "-n", schema)
 	}
 
-	compressionArgs := uc.getCompressionArgs(pg.Version)
-	return append(args, compressionArgs...)
+	uc.logger.Info(
+		"Using compression",
+		"compression", compression,
+		"level", compressionLevel,
+		"version", pg.Version,
+	)
+
+	switch {
+	case compression == backups_config.BackupCompressionNone:
+		return append(args, "-Z", "0")
+	case uc.isOlderPostgresVersion(pg.Version):
+		return append(args, "-Z", strconv.Itoa(compressionLevel))
+	default:
+		return append(
+			args,
+			fmt.Sprintf("--compress=%s:%d", strings.ToLower(string(compression)), compressionLevel),
+		)
+	}
 }
 
-func (uc *CreatePostgresqlBackupUsecase) getCompressionArgs(
-	version tools.PostgresqlVersion,
+// buildPgBasebackupArgs builds args to write the whole cluster as a single
+// tar archive to stdout. WAL needed to make the copy consistent is fetched
+// into the archive, so the backup can be restored without WAL archive
+func (uc *CreatePostgresqlBackupUsecase) buildPgBasebackupArgs(
+	pg *pgtypes.PostgresqlDatabase,
+	compression backups_config.BackupCompression,
+	compressionLevel int,
 ) []string {
-	if uc.isOlderPostgresVersion(version) {
-		uc.logger.Info("Using gzip compression level 5 (zstd not available)", "version", version)
-		return []string{"-Z", strconv.Itoa(compressionLevel)}
+	args := []string{
+		"-D", "-",
+		"-Ft",
+		"-X", "fetch",
+		"--checkpoint=fast",
+		"--no-password",
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+		"--verbose",
 	}
 
-	uc.logger.Info("Using zstd compression level 5", "version", version)
-	return []string{fmt.Sprintf("--compress=zstd:%d", compressionLevel)}
+	uc.logger.Info(
+		"Using compression",
+		"compression", compression,
+		"level", compressionLevel,
+		"version", pg.Version,
+	)
+
+	switch {
+	case compression == backups_config.BackupCompressionNone:
+		return args
+	case uc.isOlderPgBasebackupVersion(pg.Version):
+		return append(args, "-z", "-Z", strconv.Itoa(compressionLevel))
+	default:
+		return append(
+			args,
+			fmt.Sprintf(
+				"--compress=client-%s:%d",
+				strings.ToLower(string(compression)),
+				compressionLevel,
+			),
+		)
+	}
+}
+
+// getSupportedCompression falls back to gzip when the tool of the version
+// cannot use the configured algorithm: pg_dump supports zstd and lz4 since
+// PostgreSQL 16, pg_basebackup since PostgreSQL 15
+func (uc *CreatePostgresqlBackupUsecase) getSupportedCompression(
+	version tools.PostgresqlVersion,
+	backupType backups_config.BackupType,
+	compression backups_config.BackupCompression,
+	compressionLevel int,
+) (backups_config.BackupCompression, int) {
+	if compression == backups_config.BackupCompressionNone ||
+		compression == backups_config.BackupCompressionGzip {
+		return compression, compressionLevel
+	}
+
+	isOlderVersion := uc.isOlderPostgresVersion(version)
+	if backupType == backups_config.BackupTypePhysical {
+		isOlderVersion = uc.isOlderPgBasebackupVersion(version)
+	}
+
+	if !isOlderVersion {
+		return compression, compressionLevel
+	}
+
+	uc.logger.Info(
+		"Falling back to gzip compression, configured algorithm is not supported",
+		"compression", compression,
+		"version", version,
+	)
+
+	return backups_config.BackupCompressionGzip, min(compressionLevel, maxGzipCompressionLevel)
 }
 
 func (uc *CreatePostgresqlBackupUsecase) isOlderPostgresVersion(
@@ -364,6 +484,12 @@ func (uc *CreatePostgresqlBackupUsecase) isOlderPostgresVersion(
 		version == tools.PostgresqlVersion15
 }
 
+func (uc *CreatePostgresqlBackupUsecase) isOlderPgBasebackupVersion(
+	version tools.PostgresqlVersion,
+) bool {
+	return uc.isOlderPostgresVersion(version) && version != tools.PostgresqlVersion15
+}
+
 func (uc *CreatePostgresqlBackupUsecase) createBackupContext(
 	parentCtx context.Context,
 ) (context.Context, context.CancelFunc) {
@@ -490,7 +616,7 @@ func (uc *CreatePostgresqlBackupUsecase) setupBackupEncryption(
 		return nil, nil, metadata, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	keyVersion, masterKey, err := uc.secretKeyService.GetCurrentKey()
 	if err != nil {
 		return nil, nil, metadata, fmt.Errorf("failed to get master key: %w", err)
 	}
@@ -510,6 +636,7 @@ func (uc *CreatePostgresqlBackupUsecase) setupBackupEncryption(
 	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
 	metadata.EncryptionSalt = &saltBase64
 	metadata.EncryptionIV = &nonceBase64
+	metadata.EncryptionKeyVersion = &keyVersion
 	metadata.Encryption = backups_config.BackupEncryptionEncrypted
 
 	uc.logger.Info("Encryption enabled for backup", "backupId", backupID)
@@ -0,0 +1,52 @@
+package backups_binlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseBinlogName splits name like "mysql-bin.000042" into base name and
+// sequence number
+func parseBinlogName(name string) (string, int, error) {
+	dotIndex := strings.LastIndex(name, ".")
+	if dotIndex <= 0 || dotIndex == len(name)-1 {
+		return "", 0, fmt.Errorf("invalid binlog name: %s", name)
+	}
+
+	sequence, err := strconv.Atoi(name[dotIndex+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid binlog name: %s", name)
+	}
+
+	return name[:dotIndex], sequence, nil
+}
+
+// compareBinlogNames orders binlogs by sequence number. Logs with another
+// base name were written before log-bin option was changed, so they are
+// compared as text
+func compareBinlogNames(a, b string) int {
+	baseA, sequenceA, errA := parseBinlogName(a)
+	baseB, sequenceB, errB := parseBinlogName(b)
+
+	if errA != nil || errB != nil || baseA != baseB {
+		return strings.Compare(a, b)
+	}
+
+	return sequenceA - sequenceB
+}
+
+// isNextBinlogName checks the server has not rotated any log between the two
+func isNextBinlogName(previous, next string) bool {
+	basePrevious, sequencePrevious, err := parseBinlogName(previous)
+	if err != nil {
+		return false
+	}
+
+	baseNext, sequenceNext, err := parseBinlogName(next)
+	if err != nil {
+		return false
+	}
+
+	return basePrevious == baseNext && sequenceNext == sequencePrevious+1
+}
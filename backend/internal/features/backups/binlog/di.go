@@ -0,0 +1,35 @@
+package backups_binlog
+
+import (
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/google/uuid"
+)
+
+var binlogFileRepository = &BinlogFileRepository{}
+
+var binlogArchivingService = &BinlogArchivingService{
+	binlogFileRepository,
+	backups_config.GetBackupConfigService(),
+	backups.GetBackupService(),
+	databases.GetDatabaseService(),
+	storages.GetStorageService(),
+	encryption_secrets.GetSecretKeyService(),
+	encryption.GetFieldEncryptor(),
+	logger.GetLogger(),
+	map[uuid.UUID]*currentBinlog{},
+}
+
+func SetupDependencies() {
+	databases.GetDatabaseService().AddDbRemoveListener(binlogArchivingService)
+}
+
+func GetBinlogArchivingService() *BinlogArchivingService {
+	return binlogArchivingService
+}
@@ -0,0 +1,121 @@
+package backups_binlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"postgresus-backend/internal/util/tools"
+)
+
+// binlogConnection is a connection of mysqlbinlog or mariadb-binlog to the
+// server the binlogs are fetched from
+type binlogConnection struct {
+	binlogBin string
+	host      string
+	port      int
+	username  string
+	password  string
+	isHttps   bool
+	isMariadb bool
+}
+
+// fetchBinlog downloads closed binlog from the server into the directory
+// as is. Raw files are replayed by the same tool on restore
+func fetchBinlog(
+	ctx context.Context,
+	connection *binlogConnection,
+	binlogName string,
+	binlogDir string,
+) (string, error) {
+	if err := os.MkdirAll(binlogDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create binlog directory: %w", err)
+	}
+
+	myCnfDir, err := createTempMyCnfDir(connection)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(myCnfDir)
+	}()
+
+	// with --raw the result file is a prefix of the written file names
+	args := []string{
+		"--defaults-file=" + filepath.Join(myCnfDir, ".my.cnf"),
+		"--read-from-remote-server",
+		"--raw",
+		"--result-file=" + binlogDir + string(os.PathSeparator),
+		binlogName,
+	}
+
+	cmd := exec.CommandContext(ctx, connection.binlogBin, args...)
+	cmd.Env = append(os.Environ(), "MYSQL_PWD=", "LC_ALL=C.UTF-8", "LANG=C.UTF-8")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: %w: %s",
+			filepath.Base(connection.binlogBin),
+			err,
+			tailOfOutput(string(output)),
+		)
+	}
+
+	binlogPath := filepath.Join(binlogDir, binlogName)
+	if _, err := os.Stat(binlogPath); err != nil {
+		return "", fmt.Errorf("fetched binlog %s not found: %w", binlogName, err)
+	}
+
+	return binlogPath, nil
+}
+
+func createTempMyCnfDir(connection *binlogConnection) (string, error) {
+	tempDir, err := os.MkdirTemp("", "mycnf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	escapedPassword := tools.EscapeMysqlPassword(connection.password)
+	if connection.isMariadb {
+		escapedPassword = tools.EscapeMariadbPassword(connection.password)
+	}
+
+	content := fmt.Sprintf(`[client]
+user=%s
+password="%s"
+host=%s
+port=%d
+`, connection.username, escapedPassword, connection.host, connection.port)
+
+	switch {
+	case connection.isMariadb && connection.isHttps:
+		content += "ssl=true\n"
+	case connection.isMariadb:
+		content += "ssl=false\n"
+	case connection.isHttps:
+		content += "ssl-mode=REQUIRED\n"
+	}
+
+	err = os.WriteFile(filepath.Join(tempDir, ".my.cnf"), []byte(content), 0600)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to write .my.cnf: %w", err)
+	}
+
+	return tempDir, nil
+}
+
+func tailOfOutput(output string) string {
+	const maxLength = 2000
+
+	output = strings.TrimSpace(output)
+	if len(output) <= maxLength {
+		return output
+	}
+
+	return output[len(output)-maxLength:]
+}
@@ -0,0 +1,35 @@
+package backups_binlog
+
+import (
+	"time"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+
+	"github.com/google/uuid"
+)
+
+// BinlogFile is a single MySQL or MariaDB binary log archived to a single
+// storage. The same binlog saved to several storages produces several rows,
+// each row has its own ID which is used as the file ID in the storage
+type BinlogFile struct {
+	ID         uuid.UUID `json:"id"         gorm:"column:id;type:uuid;primaryKey"`
+	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;not null"`
+	StorageID  uuid.UUID `json:"storageId"  gorm:"column:storage_id;type:uuid;not null"`
+
+	FileName  string `json:"fileName"  gorm:"column:file_name;type:text;not null"`
+	SizeBytes int64  `json:"sizeBytes" gorm:"column:size_bytes;not null;default:0"`
+
+	Encryption     backups_config.BackupEncryption `json:"encryption"     gorm:"column:encryption;type:text;not null;default:'NONE'"`
+	EncryptionSalt *string                         `json:"-"              gorm:"column:encryption_salt"`
+	EncryptionIV   *string                         `json:"-"              gorm:"column:encryption_iv"`
+
+	// EncryptionKeyVersion is the master key version, empty for files
+	// encrypted before master keys were versioned
+	EncryptionKeyVersion *int `json:"-" gorm:"column:encryption_key_version"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (BinlogFile) TableName() string {
+	return "binlog_files"
+}
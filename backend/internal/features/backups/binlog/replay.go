@@ -0,0 +1,61 @@
+package backups_binlog
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReplayOptions describe events of archived binlogs applied on top of the
+// restored dump
+type ReplayOptions struct {
+	// StartPosition is the position of the dump in the first binlog
+	StartPosition int64
+
+	// StopTime and StopPosition are exclusive. StopPosition is the position in
+	// the last binlog
+	StopTime     *time.Time
+	StopPosition *int64
+
+	SourceDatabase string
+	TargetDatabase string
+
+	// IsSkipGtids removes GTIDs of MySQL events. Otherwise the server skips
+	// events with GTIDs it has already executed, e.g. when restoring to the
+	// same server
+	IsSkipGtids bool
+}
+
+// BuildReplayArgs returns mysqlbinlog arguments printing events of the
+// backed up database. mysqlbinlog reads stop time in the local time zone, so
+// it must be run with TZ=UTC
+func BuildReplayArgs(options ReplayOptions, binlogPaths []string) []string {
+	args := []string{
+		fmt.Sprintf("--start-position=%d", options.StartPosition),
+	}
+
+	if options.StopTime != nil {
+		args = append(args, "--stop-datetime="+options.StopTime.UTC().Format(time.DateTime))
+	}
+
+	if options.StopPosition != nil {
+		args = append(args, fmt.Sprintf("--stop-position=%d", *options.StopPosition))
+	}
+
+	// database filter is applied after rewriting, so it uses the new name
+	databaseFilter := options.SourceDatabase
+	if options.TargetDatabase != "" && options.TargetDatabase != options.SourceDatabase {
+		args = append(
+			args,
+			fmt.Sprintf("--rewrite-db=%s->%s", options.SourceDatabase, options.TargetDatabase),
+		)
+		databaseFilter = options.TargetDatabase
+	}
+
+	args = append(args, "--database="+databaseFilter)
+
+	if options.IsSkipGtids {
+		args = append(args, "--skip-gtids")
+	}
+
+	return append(args, binlogPaths...)
+}
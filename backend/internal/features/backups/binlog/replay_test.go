@@ -0,0 +1,72 @@
+package backups_binlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildReplayArgs_WhenRestoredToAnotherDatabase_DatabaseRewritten(t *testing.T) {
+	stopTime := time.Date(2026, 2, 23, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	args := BuildReplayArgs(
+		ReplayOptions{
+			StartPosition:  157,
+			StopTime:       &stopTime,
+			SourceDatabase: "shop",
+			TargetDatabase: "shop_restored",
+			IsSkipGtids:    true,
+		},
+		[]string{"/tmp/binlog.000001", "/tmp/binlog.000002"},
+	)
+
+	assert.Equal(
+		t,
+		[]string{
+			"--start-position=157",
+			"--stop-datetime=2026-02-23 14:04:05",
+			"--rewrite-db=shop->shop_restored",
+			"--database=shop_restored",
+			"--skip-gtids",
+			"/tmp/binlog.000001",
+			"/tmp/binlog.000002",
+		},
+		args,
+	)
+}
+
+func Test_BuildReplayArgs_WhenStopPositionSet_PositionPassed(t *testing.T) {
+	stopPosition := int64(4096)
+
+	args := BuildReplayArgs(
+		ReplayOptions{
+			StartPosition:  4,
+			StopPosition:   &stopPosition,
+			SourceDatabase: "shop",
+			TargetDatabase: "shop",
+		},
+		[]string{"/tmp/binlog.000001"},
+	)
+
+	assert.Equal(
+		t,
+		[]string{
+			"--start-position=4",
+			"--stop-position=4096",
+			"--database=shop",
+			"/tmp/binlog.000001",
+		},
+		args,
+	)
+}
+
+func Test_CompareBinlogNames_SequenceWithMoreDigits_OrderedBySequence(t *testing.T) {
+	assert.Negative(t, compareBinlogNames("binlog.999999", "binlog.1000000"))
+	assert.Positive(t, compareBinlogNames("binlog.000010", "binlog.000009"))
+	assert.Zero(t, compareBinlogNames("binlog.000010", "binlog.000010"))
+
+	assert.True(t, isNextBinlogName("binlog.000009", "binlog.000010"))
+	assert.False(t, isNextBinlogName("binlog.000009", "binlog.000011"))
+	assert.False(t, isNextBinlogName("binlog.000009", "mysql-bin.000010"))
+}
@@ -0,0 +1,47 @@
+package backups_binlog
+
+import (
+	"errors"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+type BinlogFileRepository struct{}
+
+func (r *BinlogFileRepository) Save(binlogFile *BinlogFile) error {
+	if binlogFile.DatabaseID == uuid.Nil || binlogFile.StorageID == uuid.Nil {
+		return errors.New("database ID and storage ID are required")
+	}
+
+	db := storage.GetDb()
+
+	isNew := binlogFile.ID == uuid.Nil
+	if isNew {
+		binlogFile.ID = uuid.New()
+		return db.Create(binlogFile).
+			Error
+	}
+
+	return db.Save(binlogFile).
+		Error
+}
+
+func (r *BinlogFileRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*BinlogFile, error) {
+	var binlogFiles []*BinlogFile
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ?", databaseID).
+		Order("created_at ASC").
+		Find(&binlogFiles).Error; err != nil {
+		return nil, err
+	}
+
+	return binlogFiles, nil
+}
+
+func (r *BinlogFileRepository) DeleteByID(id uuid.UUID) error {
+	return storage.GetDb().Delete(&BinlogFile{}, "id = ?", id).Error
+}
@@ -0,0 +1,767 @@
+package backups_binlog
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backup_encryption "postgresus-backend/internal/features/backups/backups/encryption"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+)
+
+const (
+	binlogFetchTimeout         = 30 * time.Minute
+	defaultBinlogFlushInterval = 15 * time.Minute
+)
+
+type BinlogArchivingService struct {
+	binlogFileRepository *BinlogFileRepository
+	backupConfigService  *backups_config.BackupConfigService
+	backupService        *backups.BackupService
+	databaseService      *databases.DatabaseService
+	storageService       *storages.StorageService
+	secretKeyService     *encryption_secrets.SecretKeyService
+	fieldEncryptor       encryption.FieldEncryptor
+	logger               *slog.Logger
+
+	// binlog being written on each server and when it was first seen, used
+	// only by the archiving loop
+	currentBinlogs map[uuid.UUID]*currentBinlog
+}
+
+type currentBinlog struct {
+	name      string
+	firstSeen time.Time
+}
+
+// Run periodically copies binlogs closed by the server to the backup
+// storages. The binlog being written is archived after the server rotates it
+func (s *BinlogArchivingService) Run() {
+	for {
+		if config.IsShouldShutdown() {
+			return
+		}
+
+		if err := s.archiveBinlogs(); err != nil {
+			s.logger.Error("Failed to archive binlogs", "error", err)
+		}
+
+		time.Sleep(1 * time.Minute)
+	}
+}
+
+func (s *BinlogArchivingService) OnBeforeDatabaseRemove(databaseID uuid.UUID) error {
+	binlogFiles, err := s.binlogFileRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, binlogFile := range binlogFiles {
+		s.deleteBinlogFile(binlogFile)
+	}
+
+	return os.RemoveAll(getBinlogDirectory(databaseID))
+}
+
+// DownloadBinlogsForRestore downloads binlogs required to roll the dump
+// forward: from the binlog of the dump up to the stop binlog, or up to the
+// first binlog archived after the stop time. Without stop, all archived
+// binlogs are downloaded. Returns paths of the files in replay order
+func (s *BinlogArchivingService) DownloadBinlogsForRestore(
+	backup *backups.Backup,
+	stopFileName *string,
+	stopTime *time.Time,
+	targetDir string,
+) ([]string, error) {
+	if backup.BinlogFile == nil || backup.BinlogPosition == nil {
+		return nil, errors.New("backup was made without binlog position")
+	}
+
+	binlogFiles, err := s.binlogFileRepository.FindByDatabaseID(backup.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	copiesByName := make(map[string][]*BinlogFile)
+	for _, binlogFile := range binlogFiles {
+		copiesByName[binlogFile.FileName] = append(copiesByName[binlogFile.FileName], binlogFile)
+	}
+
+	var names []string
+	for name := range copiesByName {
+		if compareBinlogNames(name, *backup.BinlogFile) < 0 {
+			continue
+		}
+
+		if stopFileName != nil && compareBinlogNames(name, *stopFileName) > 0 {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, compareBinlogNames)
+
+	if len(names) == 0 || names[0] != *backup.BinlogFile {
+		return nil, fmt.Errorf("binlog %s of the backup is not archived yet", *backup.BinlogFile)
+	}
+
+	// the chain must have no gaps, otherwise replayed data would be inconsistent
+	for i := 1; i < len(names); i++ {
+		if !isNextBinlogName(names[i-1], names[i]) {
+			return nil, fmt.Errorf("binlogs after %s are missing in the archive", names[i-1])
+		}
+	}
+
+	if stopFileName != nil && names[len(names)-1] != *stopFileName {
+		return nil, fmt.Errorf("binlog %s is not archived yet", *stopFileName)
+	}
+
+	if stopTime != nil {
+		names, err = cutNamesByStopTime(names, copiesByName, *stopTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	binlogPaths := make([]string, 0, len(names))
+	for _, name := range names {
+		binlogPath := filepath.Join(targetDir, name)
+
+		if err := s.downloadAnyCopy(copiesByName[name], binlogPath); err != nil {
+			return nil, err
+		}
+
+		binlogPaths = append(binlogPaths, binlogPath)
+	}
+
+	return binlogPaths, nil
+}
+
+// DownloadBinlogFile writes decrypted binlog content to the writer
+func (s *BinlogArchivingService) DownloadBinlogFile(binlogFile *BinlogFile, writer io.Writer) error {
+	storage, err := s.storageService.GetStorageByID(binlogFile.StorageID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage: %w", err)
+	}
+
+	rawReader, err := storage.GetFile(s.fieldEncryptor, binlogFile.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get binlog from storage: %w", err)
+	}
+	defer func() {
+		_ = rawReader.Close()
+	}()
+
+	var reader io.Reader = rawReader
+	if binlogFile.Encryption == backups_config.BackupEncryptionEncrypted {
+		if binlogFile.EncryptionSalt == nil || binlogFile.EncryptionIV == nil {
+			return errors.New("binlog is encrypted but missing encryption metadata")
+		}
+
+		masterKey, err := s.secretKeyService.GetKeyByVersion(binlogFile.EncryptionKeyVersion)
+		if err != nil {
+			return fmt.Errorf("failed to get master key for decryption: %w", err)
+		}
+
+		salt, err := base64.StdEncoding.DecodeString(*binlogFile.EncryptionSalt)
+		if err != nil {
+			return fmt.Errorf("failed to decode encryption salt: %w", err)
+		}
+
+		iv, err := base64.StdEncoding.DecodeString(*binlogFile.EncryptionIV)
+		if err != nil {
+			return fmt.Errorf("failed to decode encryption IV: %w", err)
+		}
+
+		decryptReader, err := backup_encryption.NewDecryptionReader(
+			rawReader,
+			masterKey,
+			binlogFile.ID,
+			salt,
+			iv,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create decryption reader: %w", err)
+		}
+
+		reader = decryptReader
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		return fmt.Errorf("failed to download binlog: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BinlogArchivingService) archiveBinlogs() error {
+	enabledBackupConfigs, err := s.backupConfigService.GetBackupConfigsWithEnabledBackups()
+	if err != nil {
+		return err
+	}
+
+	for _, backupConfig := range enabledBackupConfigs {
+		if !backupConfig.IsBinlogArchivingEnabled {
+			continue
+		}
+
+		if err := s.archiveDatabaseBinlogs(backupConfig); err != nil {
+			s.logger.Error(
+				"Failed to archive binlogs",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
+
+		if err := s.cleanOldBinlogs(backupConfig.DatabaseID); err != nil {
+			s.logger.Error(
+				"Failed to clean old binlogs",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// archiveDatabaseBinlogs archives binlogs starting from the oldest dump with
+// binlog position. Before the first such dump there is nothing to chain
+// binlogs to, so nothing is archived
+func (s *BinlogArchivingService) archiveDatabaseBinlogs(
+	backupConfig *backups_config.BackupConfig,
+) error {
+	oldestBackup, err := s.backupService.GetOldestBinlogBackup(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	if oldestBackup == nil {
+		return nil
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	connection, err := s.getBinlogConnection(database)
+	if err != nil {
+		return err
+	}
+
+	serverBinlogNames, err := s.listServerBinlogs(database)
+	if err != nil {
+		return err
+	}
+
+	if s.flushStaleBinlog(database, serverBinlogNames) {
+		serverBinlogNames, err = s.listServerBinlogs(database)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the last binlog is still being written
+	if len(serverBinlogNames) < 2 {
+		return nil
+	}
+	closedBinlogNames := serverBinlogNames[:len(serverBinlogNames)-1]
+
+	archivedBinlogFiles, err := s.binlogFileRepository.FindByDatabaseID(backupConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	archivedStorageIDsByName := make(map[string][]uuid.UUID)
+	for _, binlogFile := range archivedBinlogFiles {
+		archivedStorageIDsByName[binlogFile.FileName] = append(
+			archivedStorageIDsByName[binlogFile.FileName],
+			binlogFile.StorageID,
+		)
+	}
+
+	s.checkPurgedBinlogs(
+		backupConfig.DatabaseID,
+		*oldestBackup.BinlogFile,
+		closedBinlogNames,
+		archivedStorageIDsByName,
+	)
+
+	binlogDir := getBinlogDirectory(backupConfig.DatabaseID)
+
+	for _, binlogName := range closedBinlogNames {
+		if compareBinlogNames(binlogName, *oldestBackup.BinlogFile) < 0 {
+			continue
+		}
+
+		var missingStorageIDs []uuid.UUID
+		for _, storageID := range backupConfig.GetStorageIDs() {
+			if !slices.Contains(archivedStorageIDsByName[binlogName], storageID) {
+				missingStorageIDs = append(missingStorageIDs, storageID)
+			}
+		}
+
+		if len(missingStorageIDs) == 0 {
+			continue
+		}
+
+		if config.IsShouldShutdown() {
+			return nil
+		}
+
+		if err := s.archiveBinlog(
+			backupConfig,
+			connection,
+			binlogName,
+			binlogDir,
+			missingStorageIDs,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveBinlog fetches the binlog once and saves it to every storage which
+// does not have it. Local copy is removed anyway, failed storages are
+// retried on the next run
+func (s *BinlogArchivingService) archiveBinlog(
+	backupConfig *backups_config.BackupConfig,
+	connection *binlogConnection,
+	binlogName string,
+	binlogDir string,
+	storageIDs []uuid.UUID,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), binlogFetchTimeout)
+	defer cancel()
+
+	binlogPath, err := fetchBinlog(ctx, connection, binlogName, binlogDir)
+	if err != nil {
+		return fmt.Errorf("failed to fetch binlog %s: %w", binlogName, err)
+	}
+	defer func() {
+		if err := os.Remove(binlogPath); err != nil {
+			s.logger.Error("Failed to remove fetched binlog", "error", err)
+		}
+	}()
+
+	for _, storageID := range storageIDs {
+		if err := s.uploadBinlog(backupConfig, storageID, binlogPath, binlogName); err != nil {
+			s.logger.Error(
+				"Failed to upload binlog",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"storageId",
+				storageID,
+				"fileName",
+				binlogName,
+				"error",
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *BinlogArchivingService) uploadBinlog(
+	backupConfig *backups_config.BackupConfig,
+	storageID uuid.UUID,
+	binlogPath string,
+	binlogName string,
+) error {
+	storage, err := s.storageService.GetStorageByID(storageID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(binlogPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	binlogFile := &BinlogFile{
+		ID:         uuid.New(),
+		DatabaseID: backupConfig.DatabaseID,
+		StorageID:  storageID,
+		FileName:   binlogName,
+		SizeBytes:  fileInfo.Size(),
+		Encryption: backups_config.BackupEncryptionNone,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var reader io.Reader = file
+	var encryptedReader *io.PipeReader
+	if backupConfig.Encryption == backups_config.BackupEncryptionEncrypted {
+		encryptedReader, err = s.encryptBinlog(binlogFile, file)
+		if err != nil {
+			return err
+		}
+
+		reader = encryptedReader
+	}
+
+	err = storage.SaveFile(context.Background(), s.fieldEncryptor, s.logger, binlogFile.ID, reader)
+
+	// storage may stop reading on error, closing the pipe unblocks the
+	// encrypting goroutine
+	if encryptedReader != nil {
+		_ = encryptedReader.CloseWithError(err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.binlogFileRepository.Save(binlogFile)
+}
+
+func (s *BinlogArchivingService) encryptBinlog(
+	binlogFile *BinlogFile,
+	file io.Reader,
+) (*io.PipeReader, error) {
+	salt, err := backup_encryption.GenerateSalt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	nonce, err := backup_encryption.GenerateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	keyVersion, masterKey, err := s.secretKeyService.GetCurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+
+	encWriter, err := backup_encryption.NewEncryptionWriter(
+		pipeWriter,
+		masterKey,
+		binlogFile.ID,
+		salt,
+		nonce,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encrypting writer: %w", err)
+	}
+
+	go func() {
+		_, copyErr := io.Copy(encWriter, file)
+		if copyErr == nil {
+			copyErr = encWriter.Close()
+		}
+
+		_ = pipeWriter.CloseWithError(copyErr)
+	}()
+
+	saltBase64 := base64.StdEncoding.EncodeToString(salt)
+	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
+	binlogFile.Encryption = backups_config.BackupEncryptionEncrypted
+	binlogFile.EncryptionSalt = &saltBase64
+	binlogFile.EncryptionIV = &nonceBase64
+	binlogFile.EncryptionKeyVersion = &keyVersion
+
+	return pipeReader, nil
+}
+
+// checkPurgedBinlogs reports binlogs removed by the server before they were
+// archived. Dumps made before the gap can be rolled forward only up to it
+func (s *BinlogArchivingService) checkPurgedBinlogs(
+	databaseID uuid.UUID,
+	startBinlogName string,
+	closedBinlogNames []string,
+	archivedStorageIDsByName map[string][]uuid.UUID,
+) {
+	expectedBinlogName := startBinlogName
+
+	var archivedNames []string
+	for name := range archivedStorageIDsByName {
+		if compareBinlogNames(name, startBinlogName) >= 0 {
+			archivedNames = append(archivedNames, name)
+		}
+	}
+
+	if len(archivedNames) > 0 {
+		lastArchivedName := slices.MaxFunc(archivedNames, compareBinlogNames)
+		if slices.Contains(closedBinlogNames, lastArchivedName) {
+			return
+		}
+
+		expectedBinlogName = lastArchivedName
+	}
+
+	for _, name := range closedBinlogNames {
+		if compareBinlogNames(name, expectedBinlogName) < 0 {
+			continue
+		}
+
+		if name != expectedBinlogName && !isNextBinlogName(expectedBinlogName, name) {
+			s.logger.Error(
+				"Binlogs were purged from the server before archiving, point-in-time restore is not possible across the gap",
+				"databaseId",
+				databaseID,
+				"lastArchivedOrRequired",
+				expectedBinlogName,
+				"firstAvailable",
+				name,
+			)
+		}
+
+		return
+	}
+}
+
+// cleanOldBinlogs removes binlogs written before the oldest dump, they
+// cannot be applied to any remaining backup
+func (s *BinlogArchivingService) cleanOldBinlogs(databaseID uuid.UUID) error {
+	oldestBackup, err := s.backupService.GetOldestBinlogBackup(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if oldestBackup == nil {
+		return nil
+	}
+
+	binlogFiles, err := s.binlogFileRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, binlogFile := range binlogFiles {
+		if compareBinlogNames(binlogFile.FileName, *oldestBackup.BinlogFile) < 0 {
+			s.deleteBinlogFile(binlogFile)
+		}
+	}
+
+	return nil
+}
+
+func (s *BinlogArchivingService) downloadAnyCopy(copies []*BinlogFile, binlogPath string) error {
+	var lastErr error
+
+	for _, binlogCopy := range copies {
+		file, err := os.Create(binlogPath)
+		if err != nil {
+			return err
+		}
+
+		lastErr = s.DownloadBinlogFile(binlogCopy, file)
+		closeErr := file.Close()
+
+		if lastErr == nil && closeErr == nil {
+			return nil
+		}
+
+		if lastErr == nil {
+			lastErr = closeErr
+		}
+
+		s.logger.Warn(
+			"Failed to download binlog copy, trying another storage",
+			"fileName",
+			binlogCopy.FileName,
+			"storageId",
+			binlogCopy.StorageID,
+			"error",
+			lastErr,
+		)
+	}
+
+	return fmt.Errorf("failed to download binlog %s: %w", filepath.Base(binlogPath), lastErr)
+}
+
+func (s *BinlogArchivingService) deleteBinlogFile(binlogFile *BinlogFile) {
+	storage, err := s.storageService.GetStorageByID(binlogFile.StorageID)
+	if err != nil {
+		s.logger.Error("Failed to get storage by ID", "storageId", binlogFile.StorageID, "error", err)
+	} else if err := storage.DeleteFile(s.fieldEncryptor, binlogFile.ID); err != nil {
+		s.logger.Error("Failed to delete binlog file", "binlogFileId", binlogFile.ID, "error", err)
+	}
+
+	if err := s.binlogFileRepository.DeleteByID(binlogFile.ID); err != nil {
+		s.logger.Error("Failed to delete binlog file", "binlogFileId", binlogFile.ID, "error", err)
+	}
+}
+
+// flushStaleBinlog rotates the binlog being written when it is older than
+// the flush interval, so quiet servers do not keep changes unarchived until
+// the binlog is full. Returns true when the binlog is rotated
+func (s *BinlogArchivingService) flushStaleBinlog(
+	database *databases.Database,
+	serverBinlogNames []string,
+) bool {
+	if len(serverBinlogNames) == 0 {
+		return false
+	}
+
+	name := serverBinlogNames[len(serverBinlogNames)-1]
+	now := time.Now().UTC()
+
+	current := s.currentBinlogs[database.ID]
+	if current == nil || current.name != name {
+		s.currentBinlogs[database.ID] = &currentBinlog{name: name, firstSeen: now}
+		return false
+	}
+
+	if now.Sub(current.firstSeen) < getBinlogFlushInterval() {
+		return false
+	}
+
+	var err error
+	switch {
+	case database.Mysql != nil:
+		err = database.Mysql.FlushBinaryLogs(s.logger, s.fieldEncryptor, database.ID)
+	case database.Mariadb != nil:
+		err = database.Mariadb.FlushBinaryLogs(s.logger, s.fieldEncryptor, database.ID)
+	default:
+		return false
+	}
+
+	if err != nil {
+		// without RELOAD privilege binlogs are archived after the server
+		// rotates them by size, so archiving goes on
+		s.logger.Warn(
+			"Failed to flush binary logs",
+			"databaseId",
+			database.ID,
+			"error",
+			err,
+		)
+
+		current.firstSeen = now
+		return false
+	}
+
+	delete(s.currentBinlogs, database.ID)
+	return true
+}
+
+func getBinlogFlushInterval() time.Duration {
+	if minutes := config.GetEnv().BinlogFlushIntervalMinutes; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	return defaultBinlogFlushInterval
+}
+
+func (s *BinlogArchivingService) listServerBinlogs(database *databases.Database) ([]string, error) {
+	switch {
+	case database.Mysql != nil:
+		return database.Mysql.ListBinaryLogs(s.logger, s.fieldEncryptor, database.ID)
+	case database.Mariadb != nil:
+		return database.Mariadb.ListBinaryLogs(s.logger, s.fieldEncryptor, database.ID)
+	default:
+		return nil, errors.New("binlog archiving is supported only for MySQL and MariaDB")
+	}
+}
+
+func (s *BinlogArchivingService) getBinlogConnection(
+	database *databases.Database,
+) (*binlogConnection, error) {
+	switch {
+	case database.Mysql != nil:
+		password, err := s.fieldEncryptor.Decrypt(database.ID, database.Mysql.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt database password: %w", err)
+		}
+
+		return &binlogConnection{
+			binlogBin: tools.GetMysqlExecutable(
+				database.Mysql.Version,
+				tools.MysqlExecutableMysqlbinlog,
+				config.GetEnv().EnvMode,
+				config.GetEnv().MysqlInstallDir,
+			),
+			host:     database.Mysql.Host,
+			port:     database.Mysql.Port,
+			username: database.Mysql.Username,
+			password: password,
+			isHttps:  database.Mysql.IsHttps,
+		}, nil
+	case database.Mariadb != nil:
+		password, err := s.fieldEncryptor.Decrypt(database.ID, database.Mariadb.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt database password: %w", err)
+		}
+
+		return &binlogConnection{
+			binlogBin: tools.GetMariadbExecutable(
+				tools.MariadbExecutableMariadbBinlog,
+				database.Mariadb.Version,
+				config.GetEnv().EnvMode,
+				config.GetEnv().MariadbInstallDir,
+			),
+			host:      database.Mariadb.Host,
+			port:      database.Mariadb.Port,
+			username:  database.Mariadb.Username,
+			password:  password,
+			isHttps:   database.Mariadb.IsHttps,
+			isMariadb: true,
+		}, nil
+	default:
+		return nil, errors.New("binlog archiving is supported only for MySQL and MariaDB")
+	}
+}
+
+// cutNamesByStopTime keeps binlogs up to the first one archived after the
+// stop time. Binlog is archived after it is closed, so it contains all
+// events before its archive time
+func cutNamesByStopTime(
+	names []string,
+	copiesByName map[string][]*BinlogFile,
+	stopTime time.Time,
+) ([]string, error) {
+	for i, name := range names {
+		for _, binlogCopy := range copiesByName[name] {
+			if !binlogCopy.CreatedAt.Before(stopTime) {
+				return names[:i+1], nil
+			}
+		}
+	}
+
+	lastArchivedAt := copiesByName[names[len(names)-1]][0].CreatedAt
+
+	return nil, fmt.Errorf(
+		"binlogs are archived only up to %s, choose earlier recovery target time",
+		lastArchivedAt.Format(time.RFC3339),
+	)
+}
+
+func getBinlogDirectory(databaseID uuid.UUID) string {
+	return filepath.Join(config.GetEnv().DataFolder, "binlog", databaseID.String())
+}
@@ -448,6 +448,82 @@ func Test_SaveBackupConfig_WithEncryptionEncrypted_ConfigSaved(t *testing.T) {
 	assert.Equal(t, BackupEncryptionEncrypted, response.Encryption)
 }
 
+func Test_SaveBackupConfig_WithSecondaryStorages_ConfigSaved(t *testing.T) {
+	router := createTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	database := createTestDatabaseViaAPI("Test Database", workspace.ID, owner.Token, router)
+	primaryStorage := storages.CreateTestStorage(workspace.ID)
+	secondaryStorage := storages.CreateTestStorage(workspace.ID)
+
+	timeOfDay := "04:00"
+	request := BackupConfig{
+		DatabaseID:       database.ID,
+		IsBackupsEnabled: true,
+		StorePeriod:      period.PeriodWeek,
+		BackupInterval: &intervals.Interval{
+			Interval:  intervals.IntervalDaily,
+			TimeOfDay: &timeOfDay,
+		},
+		StorageID:         &primaryStorage.ID,
+		Storage:           primaryStorage,
+		SecondaryStorages: []storages.Storage{*secondaryStorage},
+		SendNotificationsOn: []BackupNotificationType{
+			NotificationBackupFailed,
+		},
+		CpuCount:   2,
+		Encryption: BackupEncryptionNone,
+	}
+
+	var response BackupConfig
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/backup-configs/save",
+		"Bearer "+owner.Token,
+		request,
+		http.StatusOK,
+		&response,
+	)
+
+	savedConfig, err := GetBackupConfigService().GetBackupConfigByDbId(database.ID)
+	assert.NoError(t, err)
+	assert.Len(t, savedConfig.SecondaryStorages, 1)
+	assert.Equal(t, secondaryStorage.ID, savedConfig.SecondaryStorages[0].ID)
+	assert.Equal(
+		t,
+		[]uuid.UUID{primaryStorage.ID, secondaryStorage.ID},
+		savedConfig.GetStorageIDs(),
+	)
+
+	isUsing, err := backupConfigRepository.IsStorageUsing(secondaryStorage.ID)
+	assert.NoError(t, err)
+	assert.True(t, isUsing)
+
+	// primary storage cannot be duplicated as secondary
+	request.SecondaryStorages = []storages.Storage{*primaryStorage}
+	testResp := test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/backup-configs/save",
+		"Bearer "+owner.Token,
+		request,
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(testResp.Body), "primary storage cannot be used as secondary storage")
+
+	// Cleanup
+	savedConfig.SecondaryStorages = []storages.Storage{}
+	_, err = backupConfigRepository.Save(savedConfig)
+	assert.NoError(t, err)
+
+	databases.RemoveTestDatabase(database)
+	storages.RemoveTestStorage(secondaryStorage.ID)
+	storages.RemoveTestStorage(primaryStorage.ID)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
 func createTestDatabaseViaAPI(
 	name string,
 	workspaceID uuid.UUID,
@@ -1,6 +1,7 @@
 package backups_config
 
 import (
+	audit_logs "postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/storages"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
@@ -11,7 +12,8 @@ var backupConfigService = &BackupConfigService{
 	backupConfigRepository,
 	databases.GetDatabaseService(),
 	storages.GetStorageService(),
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
+	audit_logs.GetAuditLogService(),
 	nil,
 }
 var backupConfigController = &BackupConfigController{
@@ -13,3 +13,28 @@ const (
 	BackupEncryptionNone      BackupEncryption = "NONE"
 	BackupEncryptionEncrypted BackupEncryption = "ENCRYPTED"
 )
+
+type RetentionPolicyType string
+
+const (
+	RetentionPolicyTypeTimePeriod RetentionPolicyType = "TIME_PERIOD"
+	RetentionPolicyTypeGFS        RetentionPolicyType = "GFS"
+)
+
+type BackupType string
+
+const (
+	// BackupTypeLogical is a dump of database objects (pg_dump, mysqldump, mongodump)
+	BackupTypeLogical BackupType = "LOGICAL"
+	// BackupTypePhysical is a copy of the whole PostgreSQL cluster made by pg_basebackup
+	BackupTypePhysical BackupType = "PHYSICAL"
+)
+
+type BackupCompression string
+
+const (
+	BackupCompressionNone BackupCompression = "NONE"
+	BackupCompressionGzip BackupCompression = "GZIP"
+	BackupCompressionZstd BackupCompression = "ZSTD"
+	BackupCompressionLz4  BackupCompression = "LZ4"
+)
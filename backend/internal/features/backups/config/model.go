@@ -2,6 +2,7 @@ package backups_config
 
 import (
 	"errors"
+	"fmt"
 	"postgresus-backend/internal/features/intervals"
 	"postgresus-backend/internal/features/storages"
 	"postgresus-backend/internal/util/period"
@@ -11,12 +12,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// defaultCompressionLevel keeps dumps fast while making them several times
+// smaller, for every supported algorithm
+const defaultCompressionLevel = 5
+
 type BackupConfig struct {
 	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;primaryKey;not null"`
 
 	IsBackupsEnabled bool `json:"isBackupsEnabled" gorm:"column:is_backups_enabled;type:boolean;not null"`
 
-	StorePeriod period.Period `json:"storePeriod" gorm:"column:store_period;type:text;not null"`
+	RetentionPolicyType RetentionPolicyType `json:"retentionPolicyType" gorm:"column:retention_policy_type;type:text;not null;default:'TIME_PERIOD'"`
+	StorePeriod         period.Period       `json:"storePeriod"         gorm:"column:store_period;type:text;not null"`
+
+	// GFS (grandfather-father-son) retention: how many of the latest hourly,
+	// daily, weekly, monthly and yearly backups to keep. Zero disables the tier
+	GfsHourlyBackupsCount  int `json:"gfsHourlyBackupsCount"  gorm:"column:gfs_hourly_backups_count;type:int;not null;default:0"`
+	GfsDailyBackupsCount   int `json:"gfsDailyBackupsCount"   gorm:"column:gfs_daily_backups_count;type:int;not null;default:0"`
+	GfsWeeklyBackupsCount  int `json:"gfsWeeklyBackupsCount"  gorm:"column:gfs_weekly_backups_count;type:int;not null;default:0"`
+	GfsMonthlyBackupsCount int `json:"gfsMonthlyBackupsCount" gorm:"column:gfs_monthly_backups_count;type:int;not null;default:0"`
+	GfsYearlyBackupsCount  int `json:"gfsYearlyBackupsCount"  gorm:"column:gfs_yearly_backups_count;type:int;not null;default:0"`
 
 	BackupIntervalID uuid.UUID           `json:"backupIntervalId"         gorm:"column:backup_interval_id;type:uuid;not null"`
 	BackupInterval   *intervals.Interval `json:"backupInterval,omitempty" gorm:"foreignKey:BackupIntervalID"`
@@ -24,6 +38,10 @@ type BackupConfig struct {
 	Storage   *storages.Storage `json:"storage"   gorm:"foreignKey:StorageID"`
 	StorageID *uuid.UUID        `json:"storageId" gorm:"column:storage_id;type:uuid;"`
 
+	// each backup is streamed to the primary storage and to all secondary
+	// storages at once, so a single storage outage does not lose every copy
+	SecondaryStorages []storages.Storage `json:"secondaryStorages" gorm:"many2many:backup_config_secondary_storages;joinForeignKey:BackupConfigDatabaseID;joinReferences:StorageID"`
+
 	SendNotificationsOn       []BackupNotificationType `json:"sendNotificationsOn" gorm:"-"`
 	SendNotificationsOnString string                   `json:"-"                   gorm:"column:send_notifications_on;type:text;not null"`
 
@@ -32,7 +50,26 @@ type BackupConfig struct {
 
 	CpuCount int `json:"cpuCount" gorm:"type:int;not null"`
 
+	// PostgreSQL only. Physical backups with continuous WAL archiving allow
+	// point-in-time recovery between backups
+	BackupType            BackupType `json:"backupType"            gorm:"column:backup_type;type:text;not null;default:'LOGICAL'"`
+	IsWalArchivingEnabled bool       `json:"isWalArchivingEnabled" gorm:"column:is_wal_archiving_enabled;type:boolean;not null;default:false"`
+
+	// MySQL and MariaDB only. Binary logs are archived between full dumps, so
+	// the dump can be rolled forward to a point in time
+	IsBinlogArchivingEnabled bool `json:"isBinlogArchivingEnabled" gorm:"column:is_binlog_archiving_enabled;type:boolean;not null;default:false"`
+
 	Encryption BackupEncryption `json:"encryption" gorm:"column:encryption;type:text;not null;default:'NONE'"`
+
+	// Compression is applied by the dump tool when it supports the algorithm,
+	// otherwise the dump is compressed while streamed to storages
+	Compression      BackupCompression `json:"compression"      gorm:"column:compression;type:text;not null;default:'ZSTD'"`
+	CompressionLevel int               `json:"compressionLevel" gorm:"column:compression_level;type:int;not null;default:5"`
+
+	// Restores and backup deletions wait for approval of another workspace
+	// admin. Only workspace admins can change the policy
+	IsRestoreApprovalRequired        bool `json:"isRestoreApprovalRequired"        gorm:"column:is_restore_approval_required;type:boolean;not null;default:false"`
+	IsBackupDeletionApprovalRequired bool `json:"isBackupDeletionApprovalRequired" gorm:"column:is_backup_deletion_approval_required;type:boolean;not null;default:false"`
 }
 
 func (h *BackupConfig) TableName() string {
@@ -40,6 +77,19 @@ func (h *BackupConfig) TableName() string {
 }
 
 func (b *BackupConfig) BeforeSave(tx *gorm.DB) error {
+	if b.RetentionPolicyType == "" {
+		b.RetentionPolicyType = RetentionPolicyTypeTimePeriod
+	}
+
+	if b.BackupType == "" {
+		b.BackupType = BackupTypeLogical
+	}
+
+	if b.Compression == "" {
+		b.Compression = BackupCompressionZstd
+		b.CompressionLevel = defaultCompressionLevel
+	}
+
 	// Convert SendNotificationsOn array to string
 	if len(b.SendNotificationsOn) > 0 {
 		notificationTypes := make([]string, len(b.SendNotificationsOn))
@@ -78,8 +128,37 @@ func (b *BackupConfig) Validate() error {
 		return errors.New("backup interval is required")
 	}
 
-	if b.StorePeriod == "" {
-		return errors.New("store period is required")
+	switch b.RetentionPolicyType {
+	case "", RetentionPolicyTypeTimePeriod:
+		if b.StorePeriod == "" {
+			return errors.New("store period is required")
+		}
+	case RetentionPolicyTypeGFS:
+		if b.GfsHourlyBackupsCount < 0 || b.GfsDailyBackupsCount < 0 ||
+			b.GfsWeeklyBackupsCount < 0 || b.GfsMonthlyBackupsCount < 0 ||
+			b.GfsYearlyBackupsCount < 0 {
+			return errors.New("GFS backups counts must not be negative")
+		}
+
+		if b.GfsHourlyBackupsCount == 0 && b.GfsDailyBackupsCount == 0 &&
+			b.GfsWeeklyBackupsCount == 0 && b.GfsMonthlyBackupsCount == 0 &&
+			b.GfsYearlyBackupsCount == 0 {
+			return errors.New("at least one GFS backups count must be greater than 0")
+		}
+	default:
+		return errors.New("retention policy type must be TIME_PERIOD or GFS")
+	}
+
+	for i, secondaryStorage := range b.SecondaryStorages {
+		if b.StorageID != nil && secondaryStorage.ID == *b.StorageID {
+			return errors.New("primary storage cannot be used as secondary storage")
+		}
+
+		for _, otherStorage := range b.SecondaryStorages[i+1:] {
+			if otherStorage.ID == secondaryStorage.ID {
+				return errors.New("secondary storages must be unique")
+			}
+		}
 	}
 
 	if b.CpuCount == 0 {
@@ -90,26 +169,97 @@ func (b *BackupConfig) Validate() error {
 		return errors.New("max failed tries count must be greater than 0")
 	}
 
+	if b.BackupType != "" && b.BackupType != BackupTypeLogical &&
+		b.BackupType != BackupTypePhysical {
+		return errors.New("backup type must be LOGICAL or PHYSICAL")
+	}
+
+	if b.IsWalArchivingEnabled && b.BackupType != BackupTypePhysical {
+		return errors.New("WAL archiving requires physical backups")
+	}
+
+	if b.IsBinlogArchivingEnabled && b.BackupType == BackupTypePhysical {
+		return errors.New("binlog archiving requires logical backups")
+	}
+
 	if b.Encryption != "" && b.Encryption != BackupEncryptionNone &&
 		b.Encryption != BackupEncryptionEncrypted {
 		return errors.New("encryption must be NONE or ENCRYPTED")
 	}
 
+	if err := b.validateCompression(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (b *BackupConfig) Copy(newDatabaseID uuid.UUID) *BackupConfig {
 	return &BackupConfig{
-		DatabaseID:          newDatabaseID,
-		IsBackupsEnabled:    b.IsBackupsEnabled,
-		StorePeriod:         b.StorePeriod,
-		BackupIntervalID:    uuid.Nil,
-		BackupInterval:      b.BackupInterval.Copy(),
-		StorageID:           b.StorageID,
-		SendNotificationsOn: b.SendNotificationsOn,
-		IsRetryIfFailed:     b.IsRetryIfFailed,
-		MaxFailedTriesCount: b.MaxFailedTriesCount,
-		CpuCount:            b.CpuCount,
-		Encryption:          b.Encryption,
+		DatabaseID:               newDatabaseID,
+		IsBackupsEnabled:         b.IsBackupsEnabled,
+		RetentionPolicyType:      b.RetentionPolicyType,
+		StorePeriod:              b.StorePeriod,
+		GfsHourlyBackupsCount:    b.GfsHourlyBackupsCount,
+		GfsDailyBackupsCount:     b.GfsDailyBackupsCount,
+		GfsWeeklyBackupsCount:    b.GfsWeeklyBackupsCount,
+		GfsMonthlyBackupsCount:   b.GfsMonthlyBackupsCount,
+		GfsYearlyBackupsCount:    b.GfsYearlyBackupsCount,
+		BackupIntervalID:         uuid.Nil,
+		BackupInterval:           b.BackupInterval.Copy(),
+		StorageID:                b.StorageID,
+		SecondaryStorages:        b.SecondaryStorages,
+		SendNotificationsOn:      b.SendNotificationsOn,
+		IsRetryIfFailed:          b.IsRetryIfFailed,
+		MaxFailedTriesCount:      b.MaxFailedTriesCount,
+		CpuCount:                 b.CpuCount,
+		BackupType:               b.BackupType,
+		IsWalArchivingEnabled:    b.IsWalArchivingEnabled,
+		IsBinlogArchivingEnabled: b.IsBinlogArchivingEnabled,
+		Encryption:               b.Encryption,
+		Compression:              b.Compression,
+		CompressionLevel:         b.CompressionLevel,
+	}
+}
+
+// GetStorageIDs returns IDs of the primary storage followed by secondary ones
+func (b *BackupConfig) GetStorageIDs() []uuid.UUID {
+	storageIDs := make([]uuid.UUID, 0, len(b.SecondaryStorages)+1)
+
+	if b.StorageID != nil {
+		storageIDs = append(storageIDs, *b.StorageID)
 	}
+
+	for _, secondaryStorage := range b.SecondaryStorages {
+		storageIDs = append(storageIDs, secondaryStorage.ID)
+	}
+
+	return storageIDs
+}
+
+// validateCompression checks the level is supported by every tool which may
+// apply the algorithm: pg_dump, pg_basebackup and the Go encoders
+func (b *BackupConfig) validateCompression() error {
+	maxLevel := 0
+
+	switch b.Compression {
+	case "", BackupCompressionNone:
+		return nil
+	case BackupCompressionGzip, BackupCompressionLz4:
+		maxLevel = 9
+	case BackupCompressionZstd:
+		maxLevel = 22
+	default:
+		return errors.New("compression must be NONE, GZIP, ZSTD or LZ4")
+	}
+
+	if b.CompressionLevel < 1 || b.CompressionLevel > maxLevel {
+		return fmt.Errorf(
+			"compression level for %s must be between 1 and %d",
+			b.Compression,
+			maxLevel,
+		)
+	}
+
+	return nil
 }
@@ -39,12 +39,19 @@ func (r *BackupConfigRepository) Save(
 		}
 
 		// Use Save which handles both create and update based on primary key
-		if err := tx.Save(backupConfig).
-			Omit("BackupInterval", "Storage").
+		if err := tx.Omit("BackupInterval", "Storage", "SecondaryStorages").
+			Save(backupConfig).
 			Error; err != nil {
 			return err
 		}
 
+		if err := tx.
+			Model(backupConfig).
+			Association("SecondaryStorages").
+			Replace(backupConfig.SecondaryStorages); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
@@ -62,6 +69,7 @@ func (r *BackupConfigRepository) FindByDatabaseID(databaseID uuid.UUID) (*Backup
 		GetDb().
 		Preload("BackupInterval").
 		Preload("Storage").
+		Preload("SecondaryStorages").
 		Where("database_id = ?", databaseID).
 		First(&backupConfig).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -81,6 +89,7 @@ func (r *BackupConfigRepository) GetWithEnabledBackups() ([]*BackupConfig, error
 		GetDb().
 		Preload("BackupInterval").
 		Preload("Storage").
+		Preload("SecondaryStorages").
 		Where("is_backups_enabled = ?", true).
 		Find(&backupConfigs).Error; err != nil {
 		return nil, err
@@ -100,5 +109,17 @@ func (r *BackupConfigRepository) IsStorageUsing(storageID uuid.UUID) (bool, erro
 		return false, err
 	}
 
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := storage.
+		GetDb().
+		Table("backup_config_secondary_storages").
+		Where("storage_id = ?", storageID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
 	return count > 0, nil
 }
@@ -2,10 +2,14 @@ package backups_config
 
 import (
 	"errors"
+	"fmt"
 
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/intervals"
 	"postgresus-backend/internal/features/storages"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/period"
@@ -17,7 +21,8 @@ type BackupConfigService struct {
 	backupConfigRepository *BackupConfigRepository
 	databaseService        *databases.DatabaseService
 	storageService         *storages.StorageService
-	workspaceService       *workspaces_services.WorkspaceService
+	authorizationService   *workspaces_services.AuthorizationService
+	auditLogService        *audit_logs.AuditLogService
 
 	dbStorageChangeListener BackupConfigStorageChangeListener
 }
@@ -45,7 +50,12 @@ func (s *BackupConfigService) SaveBackupConfigWithAuth(
 		return nil, errors.New("cannot save backup config for database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*database.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +63,55 @@ func (s *BackupConfigService) SaveBackupConfigWithAuth(
 		return nil, errors.New("insufficient permissions to modify backup configuration")
 	}
 
-	return s.SaveBackupConfig(backupConfig)
+	if err := s.validateApprovalPolicyChange(user, database, backupConfig); err != nil {
+		return nil, err
+	}
+
+	if backupConfig.BackupType == BackupTypePhysical &&
+		database.Type != databases.DatabaseTypePostgres {
+		return nil, errors.New("physical backups are supported only for PostgreSQL")
+	}
+
+	if backupConfig.IsBinlogArchivingEnabled &&
+		database.Type != databases.DatabaseTypeMysql &&
+		database.Type != databases.DatabaseTypeMariadb {
+		return nil, errors.New("binlog archiving is supported only for MySQL and MariaDB")
+	}
+
+	for _, secondaryStorage := range backupConfig.SecondaryStorages {
+		storage, err := s.storageService.GetStorageByID(secondaryStorage.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if storage.WorkspaceID != *database.WorkspaceID {
+			return nil, errors.New("secondary storage does not belong to the database workspace")
+		}
+	}
+
+	existingConfig, err := s.backupConfigRepository.FindByDatabaseID(database.ID)
+	if err != nil {
+		return nil, err
+	}
+	configBefore := audit_logs.Snapshot(existingConfig)
+
+	savedConfig, err := s.SaveBackupConfig(backupConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceBackupConfig,
+		ResourceID:   &database.ID,
+		Message:      fmt.Sprintf("Backup config updated for database '%s'", database.Name),
+		User:         user,
+		WorkspaceID:  database.WorkspaceID,
+		Before:       configBefore,
+		After:        savedConfig,
+	})
+
+	return savedConfig, nil
 }
 
 func (s *BackupConfigService) SaveBackupConfig(
@@ -157,9 +215,10 @@ func (s *BackupConfigService) initializeDefaultConfig(
 	timeOfDay := "04:00"
 
 	_, err := s.backupConfigRepository.Save(&BackupConfig{
-		DatabaseID:       databaseID,
-		IsBackupsEnabled: false,
-		StorePeriod:      period.PeriodWeek,
+		DatabaseID:          databaseID,
+		IsBackupsEnabled:    false,
+		RetentionPolicyType: RetentionPolicyTypeTimePeriod,
+		StorePeriod:         period.PeriodWeek,
 		BackupInterval: &intervals.Interval{
 			Interval:  intervals.IntervalDaily,
 			TimeOfDay: &timeOfDay,
@@ -169,14 +228,56 @@ func (s *BackupConfigService) initializeDefaultConfig(
 			NotificationBackupSuccess,
 		},
 		CpuCount:            1,
+		BackupType:          BackupTypeLogical,
 		IsRetryIfFailed:     true,
 		MaxFailedTriesCount: 3,
 		Encryption:          BackupEncryptionNone,
+		Compression:         BackupCompressionZstd,
+		CompressionLevel:    defaultCompressionLevel,
 	})
 
 	return err
 }
 
+// validateApprovalPolicyChange prevents members from disabling approvals
+// which should protect the database from them
+func (s *BackupConfigService) validateApprovalPolicyChange(
+	user *users_models.User,
+	database *databases.Database,
+	backupConfig *BackupConfig,
+) error {
+	existingConfig, err := s.backupConfigRepository.FindByDatabaseID(database.ID)
+	if err != nil {
+		return err
+	}
+
+	isRestoreApprovalRequired := false
+	isBackupDeletionApprovalRequired := false
+	if existingConfig != nil {
+		isRestoreApprovalRequired = existingConfig.IsRestoreApprovalRequired
+		isBackupDeletionApprovalRequired = existingConfig.IsBackupDeletionApprovalRequired
+	}
+
+	if backupConfig.IsRestoreApprovalRequired == isRestoreApprovalRequired &&
+		backupConfig.IsBackupDeletionApprovalRequired == isBackupDeletionApprovalRequired {
+		return nil
+	}
+
+	canManageWorkspace, err := s.authorizationService.HasPermission(
+		*database.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageWorkspace,
+	)
+	if err != nil {
+		return err
+	}
+	if !canManageWorkspace {
+		return errors.New("insufficient permissions to change approval policy")
+	}
+
+	return nil
+}
+
 func storageIDsEqual(id1, id2 *uuid.UUID) bool {
 	if id1 == nil && id2 == nil {
 		return true
@@ -0,0 +1,40 @@
+package backups_wal
+
+import (
+	"sync"
+
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/google/uuid"
+)
+
+var walSegmentRepository = &WalSegmentRepository{}
+var replicationSlotRepository = &ReplicationSlotRepository{}
+
+var walArchivingService = &WalArchivingService{
+	walSegmentRepository,
+	replicationSlotRepository,
+	backups_config.GetBackupConfigService(),
+	backups.GetBackupService(),
+	databases.GetDatabaseService(),
+	storages.GetStorageService(),
+	encryption_secrets.GetSecretKeyService(),
+	encryption.GetFieldEncryptor(),
+	logger.GetLogger(),
+	map[uuid.UUID]*walReceiver{},
+	sync.Mutex{},
+}
+
+func SetupDependencies() {
+	databases.GetDatabaseService().AddDbRemoveListener(walArchivingService)
+}
+
+func GetWalArchivingService() *WalArchivingService {
+	return walArchivingService
+}
@@ -0,0 +1,67 @@
+package backups_wal
+
+import (
+	"time"
+
+	backups_config "postgresus-backend/internal/features/backups/config"
+	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+)
+
+// WalSegment is a single WAL file archived to a single storage. The same
+// segment saved to several storages produces several rows, each row has its
+// own ID which is used as the file ID in the storage
+type WalSegment struct {
+	ID         uuid.UUID `json:"id"         gorm:"column:id;type:uuid;primaryKey"`
+	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;not null"`
+	StorageID  uuid.UUID `json:"storageId"  gorm:"column:storage_id;type:uuid;not null"`
+
+	FileName  string `json:"fileName"  gorm:"column:file_name;type:text;not null"`
+	SizeBytes int64  `json:"sizeBytes" gorm:"column:size_bytes;not null;default:0"`
+
+	Encryption     backups_config.BackupEncryption `json:"encryption"     gorm:"column:encryption;type:text;not null;default:'NONE'"`
+	EncryptionSalt *string                         `json:"-"              gorm:"column:encryption_salt"`
+	EncryptionIV   *string                         `json:"-"              gorm:"column:encryption_iv"`
+
+	// EncryptionKeyVersion is the master key version, empty for files
+	// encrypted before master keys were versioned
+	EncryptionKeyVersion *int `json:"-" gorm:"column:encryption_key_version"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (WalSegment) TableName() string {
+	return "wal_segments"
+}
+
+// ReplicationSlot is a slot created by pg_receivewal on the source server.
+// The server keeps WAL until the slot is dropped, so the connection is
+// copied here to drop the slot after restart or after the database is
+// removed from Postgresus
+type ReplicationSlot struct {
+	DatabaseID uuid.UUID               `json:"databaseId" gorm:"column:database_id;type:uuid;primaryKey"`
+	Version    tools.PostgresqlVersion `json:"version"    gorm:"column:version;type:text;not null"`
+	Host       string                  `json:"host"       gorm:"column:host;type:text;not null"`
+	Port       int                     `json:"port"       gorm:"column:port;type:int;not null"`
+	Username   string                  `json:"username"   gorm:"column:username;type:text;not null"`
+	// Password is encrypted the same way as the password of the database
+	Password  string    `json:"-"         gorm:"column:password;type:text;not null"`
+	IsHttps   bool      `json:"isHttps"   gorm:"column:is_https;type:boolean;default:false"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (ReplicationSlot) TableName() string {
+	return "wal_replication_slots"
+}
+
+func (s *ReplicationSlot) toPostgresql() *pgtypes.PostgresqlDatabase {
+	return &pgtypes.PostgresqlDatabase{
+		Version:  s.Version,
+		Host:     s.Host,
+		Port:     s.Port,
+		Username: s.Username,
+		IsHttps:  s.IsHttps,
+	}
+}
@@ -0,0 +1,267 @@
+package backups_wal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+)
+
+const (
+	pgConnectTimeout = 30
+	maxOutputLength  = 2000
+)
+
+// walReceiver is a running pg_receivewal process streaming WAL of a single
+// database into the local directory
+type walReceiver struct {
+	databaseID uuid.UUID
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+func (r *walReceiver) isRunning() bool {
+	select {
+	case <-r.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *walReceiver) stop() {
+	r.cancel()
+	<-r.done
+}
+
+func startWalReceiver(
+	logger *slog.Logger,
+	databaseID uuid.UUID,
+	pgBin string,
+	pg *pgtypes.PostgresqlDatabase,
+	password string,
+	walDir string,
+) (*walReceiver, error) {
+	if err := os.MkdirAll(walDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	slotName := getReplicationSlotName(databaseID)
+
+	// slot makes the server keep WAL until it is received, so nothing is lost
+	// while Postgresus is restarted
+	_, err := runPgReceivewal(
+		context.Background(),
+		pgBin,
+		pg,
+		password,
+		"--create-slot",
+		"--if-not-exists",
+		"--slot="+slotName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replication slot: %w", err)
+	}
+
+	pgpassDir, err := createTempPgpassDir(pg, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	args := append(
+		buildConnectionArgs(pg),
+		"-D", walDir,
+		"--slot="+slotName,
+		"--verbose",
+	)
+
+	cmd := exec.CommandContext(ctx, pgBin, args...)
+	cmd.Env = buildPgEnv(pg, pgpassDir)
+
+	// the receiver runs for months with --verbose, so only the tail of
+	// stderr is kept to explain the exit
+	stderr := &tailBuffer{limit: maxOutputLength}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		_ = os.RemoveAll(pgpassDir)
+		return nil, fmt.Errorf("start %s: %w", filepath.Base(pgBin), err)
+	}
+
+	receiver := &walReceiver{
+		databaseID: databaseID,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		defer close(receiver.done)
+		defer func() {
+			_ = os.RemoveAll(pgpassDir)
+		}()
+
+		waitErr := cmd.Wait()
+		if ctx.Err() != nil {
+			logger.Info("WAL receiver stopped", "databaseId", databaseID)
+			return
+		}
+
+		logger.Error(
+			"WAL receiver exited",
+			"databaseId",
+			databaseID,
+			"error",
+			waitErr,
+			"stderr",
+			stderr.String(),
+		)
+	}()
+
+	return receiver, nil
+}
+
+func dropReplicationSlot(
+	pgBin string,
+	pg *pgtypes.PostgresqlDatabase,
+	password string,
+	databaseID uuid.UUID,
+) error {
+	output, err := runPgReceivewal(
+		context.Background(),
+		pgBin,
+		pg,
+		password,
+		"--drop-slot",
+		"--slot="+getReplicationSlotName(databaseID),
+	)
+
+	// slot may be never created if the receiver failed to start, there is
+	// nothing to retry then
+	if err != nil && strings.Contains(string(output), "does not exist") {
+		return nil
+	}
+
+	return err
+}
+
+func runPgReceivewal(
+	ctx context.Context,
+	pgBin string,
+	pg *pgtypes.PostgresqlDatabase,
+	password string,
+	extraArgs ...string,
+) ([]byte, error) {
+	pgpassDir, err := createTempPgpassDir(pg, password)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(pgpassDir)
+	}()
+
+	cmd := exec.CommandContext(ctx, pgBin, append(buildConnectionArgs(pg), extraArgs...)...)
+	cmd.Env = buildPgEnv(pg, pgpassDir)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return output, fmt.Errorf("%s: %w: %s", filepath.Base(pgBin), err, tailOfOutput(string(output)))
+	}
+
+	return output, nil
+}
+
+func buildConnectionArgs(pg *pgtypes.PostgresqlDatabase) []string {
+	return []string{
+		"--no-password",
+		"-h", pg.Host,
+		"-p", strconv.Itoa(pg.Port),
+		"-U", pg.Username,
+	}
+}
+
+func buildPgEnv(pg *pgtypes.PostgresqlDatabase, pgpassDir string) []string {
+	env := os.Environ()
+	env = append(env,
+		"PGPASSFILE="+filepath.Join(pgpassDir, ".pgpass"),
+		"PGCONNECT_TIMEOUT="+strconv.Itoa(pgConnectTimeout),
+		"LC_ALL=C.UTF-8",
+		"LANG=C.UTF-8",
+	)
+
+	if pg.IsHttps {
+		env = append(env, "PGSSLMODE=require")
+	} else {
+		env = append(env, "PGSSLMODE=prefer")
+	}
+
+	return env
+}
+
+func createTempPgpassDir(pg *pgtypes.PostgresqlDatabase, password string) (string, error) {
+	tempDir, err := os.MkdirTemp("", "pgpass")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	// replication connections match "replication" database, so wildcard is used
+	pgpassContent := fmt.Sprintf("%s:%d:*:%s:%s",
+		tools.EscapePgpassField(pg.Host),
+		pg.Port,
+		tools.EscapePgpassField(pg.Username),
+		tools.EscapePgpassField(password),
+	)
+
+	err = os.WriteFile(filepath.Join(tempDir, ".pgpass"), []byte(pgpassContent), 0600)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to write temporary .pgpass file: %w", err)
+	}
+
+	return tempDir, nil
+}
+
+// getReplicationSlotName returns slot name unique for the database. Slot
+// names may contain only lower case letters, numbers and underscores
+func getReplicationSlotName(databaseID uuid.UUID) string {
+	return "postgresus_" + strings.ReplaceAll(databaseID.String(), "-", "_")
+}
+
+func tailOfOutput(output string) string {
+	if len(output) <= maxOutputLength {
+		return output
+	}
+
+	return output[len(output)-maxOutputLength:]
+}
+
+// tailBuffer is a writer keeping only the last limit bytes
+type tailBuffer struct {
+	data  []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+
+	if len(b.data) > b.limit {
+		b.data = append(b.data[:0], b.data[len(b.data)-b.limit:]...)
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.data)
+}
@@ -0,0 +1,44 @@
+package backups_wal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetReplicationSlotName_ValidAndUniquePerDatabase(t *testing.T) {
+	databaseID := uuid.MustParse("0b6e4c2a-9f3d-4e8b-a1c5-7d2f6e9b3a10")
+
+	slotName := getReplicationSlotName(databaseID)
+
+	assert.Equal(t, "postgresus_0b6e4c2a_9f3d_4e8b_a1c5_7d2f6e9b3a10", slotName)
+	assert.Regexp(t, regexp.MustCompile(`^[a-z0-9_]+$`), slotName)
+	// PostgreSQL truncates identifiers longer than NAMEDATALEN - 1
+	assert.LessOrEqual(t, len(slotName), 63)
+	assert.Equal(t, slotName, getReplicationSlotName(databaseID))
+	assert.NotEqual(t, slotName, getReplicationSlotName(uuid.New()))
+}
+
+func Test_TailBuffer_KeepsOnlyLastBytes(t *testing.T) {
+	buffer := &tailBuffer{limit: 10}
+
+	written, err := buffer.Write([]byte("12345"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, written)
+	assert.Equal(t, "12345", buffer.String())
+
+	written, err = buffer.Write([]byte("6789abcdef"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, written)
+	assert.Equal(t, "6789abcdef", buffer.String())
+
+	for range 1000 {
+		_, _ = buffer.Write([]byte(strings.Repeat("x", 7)))
+	}
+	_, _ = buffer.Write([]byte("end"))
+
+	assert.Equal(t, "xxxxxxxend", buffer.String())
+}
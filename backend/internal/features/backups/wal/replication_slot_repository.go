@@ -0,0 +1,53 @@
+package backups_wal
+
+import (
+	"errors"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReplicationSlotRepository struct{}
+
+func (r *ReplicationSlotRepository) Save(slot *ReplicationSlot) error {
+	if slot.DatabaseID == uuid.Nil {
+		return errors.New("database ID is required")
+	}
+
+	return storage.GetDb().Save(slot).Error
+}
+
+func (r *ReplicationSlotRepository) FindByDatabaseID(
+	databaseID uuid.UUID,
+) (*ReplicationSlot, error) {
+	var slot ReplicationSlot
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ?", databaseID).
+		First(&slot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &slot, nil
+}
+
+func (r *ReplicationSlotRepository) FindAll() ([]*ReplicationSlot, error) {
+	var slots []*ReplicationSlot
+
+	if err := storage.GetDb().Find(&slots).Error; err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+func (r *ReplicationSlotRepository) DeleteByDatabaseID(databaseID uuid.UUID) error {
+	return storage.GetDb().Delete(&ReplicationSlot{}, "database_id = ?", databaseID).Error
+}
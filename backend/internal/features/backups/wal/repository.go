@@ -0,0 +1,107 @@
+package backups_wal
+
+import (
+	"errors"
+	"time"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WalSegmentRepository struct{}
+
+func (r *WalSegmentRepository) Save(segment *WalSegment) error {
+	if segment.DatabaseID == uuid.Nil || segment.StorageID == uuid.Nil {
+		return errors.New("database ID and storage ID are required")
+	}
+
+	db := storage.GetDb()
+
+	isNew := segment.ID == uuid.Nil
+	if isNew {
+		segment.ID = uuid.New()
+		return db.Create(segment).
+			Error
+	}
+
+	return db.Save(segment).
+		Error
+}
+
+func (r *WalSegmentRepository) FindByDatabaseIDAndStorageIDAndFileName(
+	databaseID uuid.UUID,
+	storageID uuid.UUID,
+	fileName string,
+) (*WalSegment, error) {
+	var segment WalSegment
+
+	if err := storage.
+		GetDb().
+		Where(
+			"database_id = ? AND storage_id = ? AND file_name = ?",
+			databaseID,
+			storageID,
+			fileName,
+		).
+		First(&segment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *WalSegmentRepository) FindByDatabaseIDCreatedAfter(
+	databaseID uuid.UUID,
+	date time.Time,
+) ([]*WalSegment, error) {
+	var segments []*WalSegment
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND created_at >= ?", databaseID, date).
+		Order("file_name ASC, created_at ASC").
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func (r *WalSegmentRepository) FindByDatabaseIDCreatedBefore(
+	databaseID uuid.UUID,
+	date time.Time,
+) ([]*WalSegment, error) {
+	var segments []*WalSegment
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ? AND created_at < ?", databaseID, date).
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func (r *WalSegmentRepository) FindByDatabaseID(databaseID uuid.UUID) ([]*WalSegment, error) {
+	var segments []*WalSegment
+
+	if err := storage.
+		GetDb().
+		Where("database_id = ?", databaseID).
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func (r *WalSegmentRepository) DeleteByID(id uuid.UUID) error {
+	return storage.GetDb().Delete(&WalSegment{}, "id = ?", id).Error
+}
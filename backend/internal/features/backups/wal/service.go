@@ -0,0 +1,604 @@
+package backups_wal
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backup_encryption "postgresus-backend/internal/features/backups/backups/encryption"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/features/storages"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+)
+
+const partialWalSuffix = ".partial"
+
+type WalArchivingService struct {
+	walSegmentRepository      *WalSegmentRepository
+	replicationSlotRepository *ReplicationSlotRepository
+	backupConfigService       *backups_config.BackupConfigService
+	backupService             *backups.BackupService
+	databaseService           *databases.DatabaseService
+	storageService            *storages.StorageService
+	secretKeyService          *encryption_secrets.SecretKeyService
+	fieldEncryptor            encryption.FieldEncryptor
+	logger                    *slog.Logger
+
+	receivers   map[uuid.UUID]*walReceiver
+	receiversMu sync.Mutex
+}
+
+// Run keeps pg_receivewal running for every database with WAL archiving
+// enabled and moves completed WAL segments from the local directory to
+// the backup storages
+func (s *WalArchivingService) Run() {
+	for {
+		if config.IsShouldShutdown() {
+			s.stopAllReceivers()
+			return
+		}
+
+		if err := s.archiveWal(); err != nil {
+			s.logger.Error("Failed to archive WAL", "error", err)
+		}
+
+		time.Sleep(1 * time.Minute)
+	}
+}
+
+func (s *WalArchivingService) OnBeforeDatabaseRemove(databaseID uuid.UUID) error {
+	s.stopReceiver(databaseID)
+
+	// the slot record outlives the database, so a failed drop is retried
+	// on the next tick
+	slot, err := s.replicationSlotRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if slot != nil {
+		s.dropSlot(slot)
+	}
+
+	segments, err := s.walSegmentRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		s.deleteSegment(segment)
+	}
+
+	return os.RemoveAll(getWalDirectory(databaseID))
+}
+
+// GetSegmentsForRestore returns archived segments required to replay WAL
+// on top of the physical backup. Segments closed before the backup started
+// contain only older WAL, so they are skipped. When a segment is saved to
+// several storages, all copies are returned one after another
+func (s *WalArchivingService) GetSegmentsForRestore(
+	backup *backups.Backup,
+) ([]*WalSegment, error) {
+	return s.walSegmentRepository.FindByDatabaseIDCreatedAfter(backup.DatabaseID, backup.CreatedAt)
+}
+
+// DownloadSegment writes decrypted segment content to the writer
+func (s *WalArchivingService) DownloadSegment(segment *WalSegment, writer io.Writer) error {
+	storage, err := s.storageService.GetStorageByID(segment.StorageID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage: %w", err)
+	}
+
+	rawReader, err := storage.GetFile(s.fieldEncryptor, segment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get WAL segment from storage: %w", err)
+	}
+	defer func() {
+		_ = rawReader.Close()
+	}()
+
+	var reader io.Reader = rawReader
+	if segment.Encryption == backups_config.BackupEncryptionEncrypted {
+		if segment.EncryptionSalt == nil || segment.EncryptionIV == nil {
+			return errors.New("WAL segment is encrypted but missing encryption metadata")
+		}
+
+		masterKey, err := s.secretKeyService.GetKeyByVersion(segment.EncryptionKeyVersion)
+		if err != nil {
+			return fmt.Errorf("failed to get master key for decryption: %w", err)
+		}
+
+		salt, err := base64.StdEncoding.DecodeString(*segment.EncryptionSalt)
+		if err != nil {
+			return fmt.Errorf("failed to decode encryption salt: %w", err)
+		}
+
+		iv, err := base64.StdEncoding.DecodeString(*segment.EncryptionIV)
+		if err != nil {
+			return fmt.Errorf("failed to decode encryption IV: %w", err)
+		}
+
+		decryptReader, err := backup_encryption.NewDecryptionReader(
+			rawReader,
+			masterKey,
+			segment.ID,
+			salt,
+			iv,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create decryption reader: %w", err)
+		}
+
+		reader = decryptReader
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		return fmt.Errorf("failed to download WAL segment: %w", err)
+	}
+
+	return nil
+}
+
+func (s *WalArchivingService) archiveWal() error {
+	enabledBackupConfigs, err := s.backupConfigService.GetBackupConfigsWithEnabledBackups()
+	if err != nil {
+		return err
+	}
+
+	archivingDatabaseIDs := make(map[uuid.UUID]bool)
+
+	for _, backupConfig := range enabledBackupConfigs {
+		if !backupConfig.IsWalArchivingEnabled ||
+			backupConfig.BackupType != backups_config.BackupTypePhysical {
+			continue
+		}
+
+		archivingDatabaseIDs[backupConfig.DatabaseID] = true
+
+		if err := s.ensureReceiverRunning(backupConfig.DatabaseID); err != nil {
+			s.logger.Error(
+				"Failed to start WAL receiver",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
+
+		if err := s.uploadCompletedSegments(backupConfig); err != nil {
+			s.logger.Error(
+				"Failed to upload WAL segments",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
+
+		if err := s.cleanOldSegments(backupConfig.DatabaseID); err != nil {
+			s.logger.Error(
+				"Failed to clean old WAL segments",
+				"databaseId",
+				backupConfig.DatabaseID,
+				"error",
+				err,
+			)
+		}
+	}
+
+	s.receiversMu.Lock()
+	stoppedDatabaseIDs := make([]uuid.UUID, 0)
+	for databaseID := range s.receivers {
+		if !archivingDatabaseIDs[databaseID] {
+			stoppedDatabaseIDs = append(stoppedDatabaseIDs, databaseID)
+		}
+	}
+	s.receiversMu.Unlock()
+
+	for _, databaseID := range stoppedDatabaseIDs {
+		s.stopReceiver(databaseID)
+	}
+
+	s.dropUnusedSlots(archivingDatabaseIDs)
+
+	return nil
+}
+
+func (s *WalArchivingService) ensureReceiverRunning(databaseID uuid.UUID) error {
+	s.receiversMu.Lock()
+	defer s.receiversMu.Unlock()
+
+	if receiver, ok := s.receivers[databaseID]; ok && receiver.isRunning() {
+		return nil
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	pgBin, password, err := s.getPgReceivewalConnection(database)
+	if err != nil {
+		return err
+	}
+
+	// slot is recorded before pg_receivewal creates it, so it is never left
+	// on the server untracked
+	if err := s.replicationSlotRepository.Save(&ReplicationSlot{
+		DatabaseID: databaseID,
+		Version:    database.Postgresql.Version,
+		Host:       database.Postgresql.Host,
+		Port:       database.Postgresql.Port,
+		Username:   database.Postgresql.Username,
+		Password:   database.Postgresql.Password,
+		IsHttps:    database.Postgresql.IsHttps,
+		CreatedAt:  time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("failed to save replication slot: %w", err)
+	}
+
+	receiver, err := startWalReceiver(
+		s.logger,
+		databaseID,
+		pgBin,
+		database.Postgresql,
+		password,
+		getWalDirectory(databaseID),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.receivers[databaseID] = receiver
+	s.logger.Info("WAL receiver started", "databaseId", databaseID)
+
+	return nil
+}
+
+func (s *WalArchivingService) stopReceiver(databaseID uuid.UUID) {
+	s.receiversMu.Lock()
+	receiver, ok := s.receivers[databaseID]
+	delete(s.receivers, databaseID)
+	s.receiversMu.Unlock()
+
+	if ok {
+		receiver.stop()
+	}
+}
+
+// dropUnusedSlots drops slots of databases with disabled archiving or
+// removed databases, otherwise the server keeps WAL for them forever and
+// runs out of disk. Slots are persisted, so drops that failed because the
+// database was unreachable are retried after restart as well
+func (s *WalArchivingService) dropUnusedSlots(archivingDatabaseIDs map[uuid.UUID]bool) {
+	slots, err := s.replicationSlotRepository.FindAll()
+	if err != nil {
+		s.logger.Error("Failed to get replication slots", "error", err)
+		return
+	}
+
+	for _, slot := range slots {
+		if archivingDatabaseIDs[slot.DatabaseID] {
+			continue
+		}
+
+		s.dropSlot(slot)
+	}
+}
+
+func (s *WalArchivingService) dropSlot(slot *ReplicationSlot) {
+	if err := s.dropSlotOnServer(slot); err != nil {
+		s.logger.Error(
+			"Failed to drop replication slot, retrying later",
+			"databaseId",
+			slot.DatabaseID,
+			"error",
+			err,
+		)
+		return
+	}
+
+	if err := s.replicationSlotRepository.DeleteByDatabaseID(slot.DatabaseID); err != nil {
+		s.logger.Error(
+			"Failed to delete dropped replication slot",
+			"databaseId",
+			slot.DatabaseID,
+			"error",
+			err,
+		)
+		return
+	}
+
+	s.logger.Info("Replication slot dropped", "databaseId", slot.DatabaseID)
+}
+
+func (s *WalArchivingService) dropSlotOnServer(slot *ReplicationSlot) error {
+	password, err := s.fieldEncryptor.Decrypt(slot.DatabaseID, slot.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt database password: %w", err)
+	}
+
+	pgBin := tools.GetPostgresqlExecutable(
+		slot.Version,
+		tools.PostgresqlExecutablePgReceivewal,
+		config.GetEnv().EnvMode,
+		config.GetEnv().PostgresesInstallDir,
+	)
+
+	return dropReplicationSlot(pgBin, slot.toPostgresql(), password, slot.DatabaseID)
+}
+
+// stopAllReceivers keeps slots, so after restart streaming continues from
+// the last received position
+func (s *WalArchivingService) stopAllReceivers() {
+	s.receiversMu.Lock()
+	databaseIDs := make([]uuid.UUID, 0, len(s.receivers))
+	for databaseID := range s.receivers {
+		databaseIDs = append(databaseIDs, databaseID)
+	}
+	s.receiversMu.Unlock()
+
+	for _, databaseID := range databaseIDs {
+		s.stopReceiver(databaseID)
+	}
+}
+
+func (s *WalArchivingService) uploadCompletedSegments(
+	backupConfig *backups_config.BackupConfig,
+) error {
+	walDir := getWalDirectory(backupConfig.DatabaseID)
+
+	entries, err := os.ReadDir(walDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		// pg_receivewal renames segment only after it is fully written
+		if entry.IsDir() ||
+			strings.HasSuffix(fileName, partialWalSuffix) ||
+			strings.HasPrefix(fileName, ".") {
+			continue
+		}
+
+		isSavedEverywhere := true
+
+		for _, storageID := range backupConfig.GetStorageIDs() {
+			if err := s.uploadSegment(backupConfig, storageID, walDir, fileName); err != nil {
+				isSavedEverywhere = false
+				s.logger.Error(
+					"Failed to upload WAL segment",
+					"databaseId",
+					backupConfig.DatabaseID,
+					"storageId",
+					storageID,
+					"fileName",
+					fileName,
+					"error",
+					err,
+				)
+			}
+		}
+
+		// keep the file locally until every storage has it, so it is retried
+		if isSavedEverywhere {
+			if err := os.Remove(filepath.Join(walDir, fileName)); err != nil {
+				s.logger.Error("Failed to remove uploaded WAL segment", "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *WalArchivingService) uploadSegment(
+	backupConfig *backups_config.BackupConfig,
+	storageID uuid.UUID,
+	walDir string,
+	fileName string,
+) error {
+	existingSegment, err := s.walSegmentRepository.FindByDatabaseIDAndStorageIDAndFileName(
+		backupConfig.DatabaseID,
+		storageID,
+		fileName,
+	)
+	if err != nil {
+		return err
+	}
+
+	if existingSegment != nil {
+		return nil
+	}
+
+	storage, err := s.storageService.GetStorageByID(storageID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filepath.Join(walDir, fileName))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	segment := &WalSegment{
+		ID:         uuid.New(),
+		DatabaseID: backupConfig.DatabaseID,
+		StorageID:  storageID,
+		FileName:   fileName,
+		SizeBytes:  fileInfo.Size(),
+		Encryption: backups_config.BackupEncryptionNone,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var reader io.Reader = file
+	var encryptedReader *io.PipeReader
+	if backupConfig.Encryption == backups_config.BackupEncryptionEncrypted {
+		encryptedReader, err = s.encryptSegment(segment, file)
+		if err != nil {
+			return err
+		}
+
+		reader = encryptedReader
+	}
+
+	err = storage.SaveFile(context.Background(), s.fieldEncryptor, s.logger, segment.ID, reader)
+
+	// storage may stop reading on error, closing the pipe unblocks the
+	// encrypting goroutine
+	if encryptedReader != nil {
+		_ = encryptedReader.CloseWithError(err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.walSegmentRepository.Save(segment)
+}
+
+func (s *WalArchivingService) encryptSegment(
+	segment *WalSegment,
+	file io.Reader,
+) (*io.PipeReader, error) {
+	salt, err := backup_encryption.GenerateSalt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	nonce, err := backup_encryption.GenerateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	keyVersion, masterKey, err := s.secretKeyService.GetCurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+
+	encWriter, err := backup_encryption.NewEncryptionWriter(
+		pipeWriter,
+		masterKey,
+		segment.ID,
+		salt,
+		nonce,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encrypting writer: %w", err)
+	}
+
+	go func() {
+		_, copyErr := io.Copy(encWriter, file)
+		if copyErr == nil {
+			copyErr = encWriter.Close()
+		}
+
+		_ = pipeWriter.CloseWithError(copyErr)
+	}()
+
+	saltBase64 := base64.StdEncoding.EncodeToString(salt)
+	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
+	segment.Encryption = backups_config.BackupEncryptionEncrypted
+	segment.EncryptionSalt = &saltBase64
+	segment.EncryptionIV = &nonceBase64
+	segment.EncryptionKeyVersion = &keyVersion
+
+	return pipeReader, nil
+}
+
+// cleanOldSegments removes WAL which cannot be applied to any remaining
+// physical backup. Without physical backups nothing is removed, because
+// the first backup has not been made yet
+func (s *WalArchivingService) cleanOldSegments(databaseID uuid.UUID) error {
+	oldestBackup, err := s.backupService.GetOldestPhysicalBackup(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if oldestBackup == nil {
+		return nil
+	}
+
+	segments, err := s.walSegmentRepository.FindByDatabaseIDCreatedBefore(
+		databaseID,
+		oldestBackup.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		s.deleteSegment(segment)
+	}
+
+	return nil
+}
+
+func (s *WalArchivingService) deleteSegment(segment *WalSegment) {
+	storage, err := s.storageService.GetStorageByID(segment.StorageID)
+	if err != nil {
+		s.logger.Error("Failed to get storage by ID", "storageId", segment.StorageID, "error", err)
+	} else if err := storage.DeleteFile(s.fieldEncryptor, segment.ID); err != nil {
+		s.logger.Error("Failed to delete WAL segment file", "segmentId", segment.ID, "error", err)
+	}
+
+	if err := s.walSegmentRepository.DeleteByID(segment.ID); err != nil {
+		s.logger.Error("Failed to delete WAL segment", "segmentId", segment.ID, "error", err)
+	}
+}
+
+func (s *WalArchivingService) getPgReceivewalConnection(
+	database *databases.Database,
+) (string, string, error) {
+	if database.Type != databases.DatabaseTypePostgres || database.Postgresql == nil {
+		return "", "", errors.New("WAL archiving is supported only for PostgreSQL")
+	}
+
+	password, err := s.fieldEncryptor.Decrypt(database.ID, database.Postgresql.Password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt database password: %w", err)
+	}
+
+	pgBin := tools.GetPostgresqlExecutable(
+		database.Postgresql.Version,
+		tools.PostgresqlExecutablePgReceivewal,
+		config.GetEnv().EnvMode,
+		config.GetEnv().PostgresesInstallDir,
+	)
+
+	return pgBin, password, nil
+}
+
+func getWalDirectory(databaseID uuid.UUID) string {
+	return filepath.Join(config.GetEnv().DataFolder, "wal", databaseID.String())
+}
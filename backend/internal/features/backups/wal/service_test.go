@@ -0,0 +1,224 @@
+package backups_wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_testing "postgresus-backend/internal/features/users/testing"
+	workspaces_testing "postgresus-backend/internal/features/workspaces/testing"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/tools"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetSegmentsForRestore_SegmentsClosedBeforeBackupSkipped(t *testing.T) {
+	database, storage, cleanup := createTestDatabase(t)
+	defer cleanup()
+
+	secondStorage := storages.CreateTestStorage(*database.WorkspaceID)
+	defer storages.RemoveTestStorage(secondStorage.ID)
+
+	backupCreatedAt := time.Now().UTC().Add(-1 * time.Hour)
+
+	oldSegment := createTestSegment(
+		database.ID,
+		storage.ID,
+		"000000010000000000000001",
+		backupCreatedAt.Add(-1*time.Minute),
+	)
+	secondSegmentCopy := createTestSegment(
+		database.ID,
+		secondStorage.ID,
+		"000000010000000000000003",
+		backupCreatedAt.Add(3*time.Minute),
+	)
+	firstSegment := createTestSegment(
+		database.ID,
+		storage.ID,
+		"000000010000000000000002",
+		backupCreatedAt.Add(1*time.Minute),
+	)
+	secondSegment := createTestSegment(
+		database.ID,
+		storage.ID,
+		"000000010000000000000003",
+		backupCreatedAt.Add(2*time.Minute),
+	)
+
+	otherNotifier := notifiers.CreateTestNotifier(*database.WorkspaceID)
+	otherDatabase := databases.CreateTestDatabase(*database.WorkspaceID, storage, otherNotifier)
+	defer func() {
+		databases.RemoveTestDatabase(otherDatabase)
+		time.Sleep(50 * time.Millisecond) // Wait for cascading deletes
+		notifiers.RemoveTestNotifier(otherNotifier)
+	}()
+
+	otherDatabaseSegment := createTestSegment(
+		otherDatabase.ID,
+		storage.ID,
+		"000000010000000000000002",
+		backupCreatedAt.Add(1*time.Minute),
+	)
+
+	defer func() {
+		for _, segment := range []*WalSegment{
+			oldSegment,
+			firstSegment,
+			secondSegment,
+			secondSegmentCopy,
+			otherDatabaseSegment,
+		} {
+			_ = walSegmentRepository.DeleteByID(segment.ID)
+		}
+	}()
+
+	segments, err := walArchivingService.GetSegmentsForRestore(&backups.Backup{
+		DatabaseID: database.ID,
+		CreatedAt:  backupCreatedAt,
+	})
+	assert.NoError(t, err)
+
+	// segments go in WAL order, copies of the same segment one after another
+	segmentIDs := make([]uuid.UUID, 0, len(segments))
+	for _, segment := range segments {
+		segmentIDs = append(segmentIDs, segment.ID)
+	}
+
+	assert.Equal(
+		t,
+		[]uuid.UUID{firstSegment.ID, secondSegment.ID, secondSegmentCopy.ID},
+		segmentIDs,
+	)
+}
+
+func Test_UploadCompletedSegments_PartialAndHiddenFilesSkipped(t *testing.T) {
+	database, storage, cleanup := createTestDatabase(t)
+	defer cleanup()
+
+	backupConfig := backups_config.EnableBackupsForTestDatabase(database.ID, storage)
+
+	walDir := getWalDirectory(database.ID)
+	assert.NoError(t, os.MkdirAll(filepath.Join(walDir, "archive_status"), 0700))
+	defer func() {
+		_ = os.RemoveAll(walDir)
+	}()
+
+	completedFileName := "000000010000000000000001"
+	partialFileName := "000000010000000000000002" + partialWalSuffix
+	hiddenFileName := ".000000010000000000000002.tmp"
+
+	for _, fileName := range []string{completedFileName, partialFileName, hiddenFileName} {
+		err := os.WriteFile(filepath.Join(walDir, fileName), []byte("wal "+fileName), 0600)
+		assert.NoError(t, err)
+	}
+
+	err := walArchivingService.uploadCompletedSegments(backupConfig)
+	assert.NoError(t, err)
+
+	segments, err := walSegmentRepository.FindByDatabaseID(database.ID)
+	assert.NoError(t, err)
+	defer func() {
+		for _, segment := range segments {
+			walArchivingService.deleteSegment(segment)
+		}
+	}()
+
+	assert.Len(t, segments, 1)
+	if len(segments) == 1 {
+		assert.Equal(t, completedFileName, segments[0].FileName)
+		assert.Equal(t, storage.ID, segments[0].StorageID)
+	}
+
+	// uploaded segment is removed locally, files still being written are kept
+	assert.NoFileExists(t, filepath.Join(walDir, completedFileName))
+	assert.FileExists(t, filepath.Join(walDir, partialFileName))
+	assert.FileExists(t, filepath.Join(walDir, hiddenFileName))
+	assert.DirExists(t, filepath.Join(walDir, "archive_status"))
+}
+
+func Test_DropUnusedSlots_WhenDropFailed_SlotKeptForNextTick(t *testing.T) {
+	archivingDatabaseID := uuid.New()
+	removedDatabaseID := uuid.New()
+
+	for _, databaseID := range []uuid.UUID{archivingDatabaseID, removedDatabaseID} {
+		password, err := encryption.GetFieldEncryptor().Encrypt(databaseID, "postgres")
+		assert.NoError(t, err)
+
+		// nothing listens on the port, so the drop fails
+		err = replicationSlotRepository.Save(&ReplicationSlot{
+			DatabaseID: databaseID,
+			Version:    tools.PostgresqlVersion16,
+			Host:       "localhost",
+			Port:       1,
+			Username:   "postgres",
+			Password:   password,
+			CreatedAt:  time.Now().UTC(),
+		})
+		assert.NoError(t, err)
+	}
+	defer func() {
+		_ = replicationSlotRepository.DeleteByDatabaseID(archivingDatabaseID)
+		_ = replicationSlotRepository.DeleteByDatabaseID(removedDatabaseID)
+	}()
+
+	walArchivingService.dropUnusedSlots(map[uuid.UUID]bool{archivingDatabaseID: true})
+
+	for _, databaseID := range []uuid.UUID{archivingDatabaseID, removedDatabaseID} {
+		slot, err := replicationSlotRepository.FindByDatabaseID(databaseID)
+		assert.NoError(t, err)
+		assert.NotNil(t, slot)
+	}
+}
+
+func createTestDatabase(
+	t *testing.T,
+) (*databases.Database, *storages.Storage, func()) {
+	t.Helper()
+
+	user := users_testing.CreateTestUser(users_enums.UserRoleAdmin)
+	router := backups.CreateTestRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", user, router)
+	storage := storages.CreateTestStorage(workspace.ID)
+	notifier := notifiers.CreateTestNotifier(workspace.ID)
+	database := databases.CreateTestDatabase(workspace.ID, storage, notifier)
+
+	return database, storage, func() {
+		databases.RemoveTestDatabase(database)
+		time.Sleep(50 * time.Millisecond) // Wait for cascading deletes
+		notifiers.RemoveTestNotifier(notifier)
+		storages.RemoveTestStorage(storage.ID)
+		workspaces_testing.RemoveTestWorkspace(workspace, router)
+	}
+}
+
+func createTestSegment(
+	databaseID uuid.UUID,
+	storageID uuid.UUID,
+	fileName string,
+	createdAt time.Time,
+) *WalSegment {
+	segment := &WalSegment{
+		DatabaseID: databaseID,
+		StorageID:  storageID,
+		FileName:   fileName,
+		SizeBytes:  16 * 1024 * 1024,
+		Encryption: backups_config.BackupEncryptionNone,
+		CreatedAt:  createdAt,
+	}
+
+	if err := walSegmentRepository.Save(segment); err != nil {
+		panic(err)
+	}
+
+	return segment
+}
@@ -97,6 +97,187 @@ func (m *MariadbDatabase) TestConnection(
 	return nil
 }
 
+// RunVerificationQuery runs the query and returns the first column of the
+// first row as text. Used to check restored backups
+func (m *MariadbDatabase) RunVerificationQuery(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	query string,
+) (*string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if m.Database == nil || *m.Database == "" {
+		return nil, errors.New("database name is required")
+	}
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	db, err := sql.Open("mysql", m.buildDSN(password, *m.Database))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MariaDB database '%s': %w", *m.Database, err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close MariaDB connection", "error", closeErr)
+		}
+	}()
+
+	var value sql.NullString
+	if err := db.QueryRowContext(ctx, query).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("query returned no rows")
+		}
+
+		return nil, err
+	}
+
+	if !value.Valid {
+		return nil, nil
+	}
+
+	return &value.String, nil
+}
+
+// CreateDatabase creates an empty database on the same server
+func (m *MariadbDatabase) CreateDatabase(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	name string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	db, err := sql.Open("mysql", m.buildDSN(password, ""))
+	if err != nil {
+		return fmt.Errorf("failed to connect to MariaDB: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close MariaDB connection", "error", closeErr)
+		}
+	}()
+
+	var count int
+	err = db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?",
+		name,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check database existence: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("database '%s' already exists", name)
+	}
+
+	quotedName := "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE "+quotedName); err != nil {
+		return fmt.Errorf("failed to create database '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// ListBinaryLogs returns binary logs present on the server, oldest first.
+// The last one is being written to
+func (m *MariadbDatabase) ListBinaryLogs(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	db, err := sql.Open("mysql", m.buildDSN(password, ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MariaDB: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close MariaDB connection", "error", closeErr)
+		}
+	}()
+
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list binary logs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	// number of columns differs between versions, only the name is needed
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var logNames []string
+	for rows.Next() {
+		values := make([]any, len(columns))
+		var logName string
+		values[0] = &logName
+		for i := 1; i < len(values); i++ {
+			values[i] = new(sql.RawBytes)
+		}
+
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+
+		logNames = append(logNames, logName)
+	}
+
+	return logNames, rows.Err()
+}
+
+// FlushBinaryLogs closes the binlog being written and opens the next one.
+// Requires RELOAD privilege
+func (m *MariadbDatabase) FlushBinaryLogs(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	db, err := sql.Open("mysql", m.buildDSN(password, ""))
+	if err != nil {
+		return fmt.Errorf("failed to connect to MariaDB: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("Failed to close MariaDB connection", "error", closeErr)
+		}
+	}()
+
+	if _, err := db.ExecContext(ctx, "FLUSH BINARY LOGS"); err != nil {
+		return fmt.Errorf("failed to flush binary logs: %w", err)
+	}
+
+	return nil
+}
+
 func (m *MariadbDatabase) HideSensitiveData() {
 	if m == nil {
 		return
@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"postgresus-backend/internal/util/encryption"
@@ -30,6 +35,19 @@ type MongodbDatabase struct {
 	Database     string `json:"database"     gorm:"type:text;not null"`
 	AuthDatabase string `json:"authDatabase" gorm:"type:text;not null;default:'admin'"`
 	IsHttps      bool   `json:"isHttps"      gorm:"type:boolean;default:false"`
+
+	// ConnectionString replaces host and port, e.g. to list replica set
+	// members or set read preference:
+	// mongodb://host1:27017,host2:27017/?replicaSet=rs0&readPreference=secondary
+	// Credentials are taken from username and password fields
+	ConnectionString *string `json:"connectionString" gorm:"type:text"`
+
+	// backup settings
+	IsAllDatabases bool `json:"isAllDatabases" gorm:"type:boolean;not null;default:false"`
+	// IsOplogEnabled dumps oplog entries written during the dump, so the
+	// snapshot is consistent and can be restored to a point in time of the
+	// dump. Requires replica set and all databases backup
+	IsOplogEnabled bool `json:"isOplogEnabled" gorm:"type:boolean;not null;default:false"`
 }
 
 func (m *MongodbDatabase) TableName() string {
@@ -37,11 +55,17 @@ func (m *MongodbDatabase) TableName() string {
 }
 
 func (m *MongodbDatabase) Validate() error {
-	if m.Host == "" {
-		return errors.New("host is required")
-	}
-	if m.Port == 0 {
-		return errors.New("port is required")
+	if m.ConnectionString != nil && *m.ConnectionString != "" {
+		if err := validateConnectionString(*m.ConnectionString); err != nil {
+			return err
+		}
+	} else {
+		if m.Host == "" {
+			return errors.New("host is required")
+		}
+		if m.Port == 0 {
+			return errors.New("port is required")
+		}
 	}
 	if m.Username == "" {
 		return errors.New("username is required")
@@ -49,9 +73,12 @@ func (m *MongodbDatabase) Validate() error {
 	if m.Password == "" {
 		return errors.New("password is required")
 	}
-	if m.Database == "" {
+	if m.Database == "" && !m.IsAllDatabases {
 		return errors.New("database is required")
 	}
+	if m.IsOplogEnabled && !m.IsAllDatabases {
+		return errors.New("oplog can be dumped only when all databases are backed up")
+	}
 	return nil
 }
 
@@ -94,6 +121,93 @@ func (m *MongodbDatabase) TestConnection(
 	return nil
 }
 
+// RunVerificationQuery returns the count of documents in the collection. MongoDB
+// has no SQL, so the query is the collection name. When all databases are
+// backed up, the query is "<database>.<collection>"
+func (m *MongodbDatabase) RunVerificationQuery(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	query string,
+) (*string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(m.buildConnectionURI(password)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer func() {
+		if disconnectErr := client.Disconnect(ctx); disconnectErr != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", disconnectErr)
+		}
+	}()
+
+	database := m.Database
+	collection := strings.TrimSpace(query)
+	if m.IsAllDatabases {
+		var isFound bool
+		database, collection, isFound = strings.Cut(collection, ".")
+		if !isFound {
+			return nil, errors.New("query must look like <database>.<collection>")
+		}
+	}
+
+	count, err := client.
+		Database(database).
+		Collection(collection).
+		CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	value := strconv.FormatInt(count, 10)
+	return &value, nil
+}
+
+// CreateDatabase only checks the name is free: MongoDB creates databases on
+// the first write, so mongorestore creates it
+func (m *MongodbDatabase) CreateDatabase(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	name string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	password, err := decryptPasswordIfNeeded(m.Password, encryptor, databaseID)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(m.buildConnectionURI(password)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer func() {
+		if disconnectErr := client.Disconnect(ctx); disconnectErr != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", disconnectErr)
+		}
+	}()
+
+	databaseNames, err := client.ListDatabaseNames(ctx, bson.D{})
+	if err != nil {
+		return fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	if slices.Contains(databaseNames, name) {
+		return fmt.Errorf("database '%s' already exists", name)
+	}
+
+	return nil
+}
+
 func (m *MongodbDatabase) HideSensitiveData() {
 	if m == nil {
 		return
@@ -109,6 +223,9 @@ func (m *MongodbDatabase) Update(incoming *MongodbDatabase) {
 	m.Database = incoming.Database
 	m.AuthDatabase = incoming.AuthDatabase
 	m.IsHttps = incoming.IsHttps
+	m.ConnectionString = incoming.ConnectionString
+	m.IsAllDatabases = incoming.IsAllDatabases
+	m.IsOplogEnabled = incoming.IsOplogEnabled
 
 	if incoming.Password != "" {
 		m.Password = incoming.Password
@@ -287,6 +404,17 @@ func (m *MongodbDatabase) CreateReadOnlyUser(
 		newUsername := fmt.Sprintf("postgresus-%s", uuid.New().String()[:8])
 		newPassword := uuid.New().String()
 
+		readRole := bson.D{
+			{Key: "role", Value: "read"},
+			{Key: "db", Value: m.Database},
+		}
+		if m.IsAllDatabases {
+			readRole = bson.D{
+				{Key: "role", Value: "readAnyDatabase"},
+				{Key: "db", Value: "admin"},
+			}
+		}
+
 		adminDB := client.Database(authDB)
 		err = adminDB.RunCommand(ctx, bson.D{
 			{Key: "createUser", Value: newUsername},
@@ -296,10 +424,7 @@ func (m *MongodbDatabase) CreateReadOnlyUser(
 					{Key: "role", Value: "backup"},
 					{Key: "db", Value: "admin"},
 				},
-				bson.D{
-					{Key: "role", Value: "read"},
-					{Key: "db", Value: m.Database},
-				},
+				readRole,
 			}},
 		}).Err()
 
@@ -322,49 +447,54 @@ func (m *MongodbDatabase) CreateReadOnlyUser(
 
 // buildConnectionURI builds a MongoDB connection URI
 func (m *MongodbDatabase) buildConnectionURI(password string) string {
+	return m.buildURI(password, m.Database)
+}
+
+// BuildMongodumpURI builds a URI suitable for mongodump (without database in path)
+func (m *MongodbDatabase) BuildMongodumpURI(password string) string {
+	return m.buildURI(password, "")
+}
+
+// buildURI puts credentials and common options into the connection string,
+// options given by the user in the connection string are kept
+func (m *MongodbDatabase) buildURI(password string, database string) string {
 	authDB := m.AuthDatabase
 	if authDB == "" {
 		authDB = "admin"
 	}
 
-	tlsOption := "false"
-	if m.IsHttps {
-		tlsOption = "true"
+	uri := &url.URL{
+		Scheme: "mongodb",
+		Host:   net.JoinHostPort(m.Host, strconv.Itoa(m.Port)),
 	}
 
-	return fmt.Sprintf(
-		"mongodb://%s:%s@%s:%d/%s?authSource=%s&tls=%s&connectTimeoutMS=15000",
-		m.Username,
-		password,
-		m.Host,
-		m.Port,
-		m.Database,
-		authDB,
-		tlsOption,
-	)
-}
+	if m.ConnectionString != nil && *m.ConnectionString != "" {
+		// validated on save, so error is not expected here
+		if parsedURI, err := url.Parse(*m.ConnectionString); err == nil {
+			uri = parsedURI
+		}
+	}
 
-// BuildMongodumpURI builds a URI suitable for mongodump (without database in path)
-func (m *MongodbDatabase) BuildMongodumpURI(password string) string {
-	authDB := m.AuthDatabase
-	if authDB == "" {
-		authDB = "admin"
+	uri.User = url.UserPassword(m.Username, password)
+	uri.Path = "/" + database
+
+	query := uri.Query()
+	if !query.Has("authSource") {
+		query.Set("authSource", authDB)
 	}
 
-	tlsOption := "false"
-	if m.IsHttps {
-		tlsOption = "true"
+	// mongodb+srv enables TLS by default, so it is only forced on
+	if !query.Has("tls") && !query.Has("ssl") && (m.IsHttps || uri.Scheme == "mongodb") {
+		query.Set("tls", strconv.FormatBool(m.IsHttps))
 	}
 
-	return fmt.Sprintf(
-		"mongodb://%s:%s@%s:%d/?authSource=%s&tls=%s&connectTimeoutMS=15000",
-		m.Username,
-		password,
-		m.Host,
-		m.Port,
-		authDB,
-		tlsOption,
-	)
+	if !query.Has("connectTimeoutMS") {
+		query.Set("connectTimeoutMS", "15000")
+	}
+
+	uri.RawQuery = query.Encode()
+
+	return uri.String()
 }
 
 // detectMongodbVersion gets MongoDB server version from buildInfo command
@@ -418,3 +548,24 @@ func decryptPasswordIfNeeded(
 	}
 	return encryptor.Decrypt(databaseID, password)
 }
+
+func validateConnectionString(connectionString string) error {
+	uri, err := url.Parse(connectionString)
+	if err != nil {
+		return fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	if uri.Scheme != "mongodb" && uri.Scheme != "mongodb+srv" {
+		return errors.New("connection string must start with mongodb:// or mongodb+srv://")
+	}
+
+	if uri.Host == "" {
+		return errors.New("connection string must contain hosts")
+	}
+
+	if uri.User != nil {
+		return errors.New("connection string must not contain credentials, use username and password")
+	}
+
+	return nil
+}
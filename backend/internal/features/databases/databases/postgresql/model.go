@@ -94,6 +94,116 @@ func (p *PostgresqlDatabase) TestConnection(
 	return testSingleDatabaseConnection(logger, ctx, p, encryptor, databaseID)
 }
 
+// RunVerificationQuery runs the query and returns the first column of the
+// first row as text. Used to check restored backups
+func (p *PostgresqlDatabase) RunVerificationQuery(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	query string,
+) (*string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if p.Database == nil || *p.Database == "" {
+		return nil, errors.New("database name is required")
+	}
+
+	password, err := decryptPasswordIfNeeded(p.Password, encryptor, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	conn, err := pgx.Connect(ctx, buildConnectionStringForDB(p, *p.Database, password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database '%s': %w", *p.Database, err)
+	}
+	defer func() {
+		if closeErr := conn.Close(ctx); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("query returned no rows")
+	}
+
+	values, err := rows.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 || values[0] == nil {
+		return nil, nil
+	}
+
+	value := fmt.Sprint(values[0])
+	return &value, nil
+}
+
+// CreateDatabase creates an empty database on the same server. The
+// connection goes to the configured database, so the user needs CREATEDB
+// privilege there
+func (p *PostgresqlDatabase) CreateDatabase(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	databaseID uuid.UUID,
+	name string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	connectToDatabase := "postgres"
+	if p.Database != nil && *p.Database != "" {
+		connectToDatabase = *p.Database
+	}
+
+	password, err := decryptPasswordIfNeeded(p.Password, encryptor, databaseID)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	conn, err := pgx.Connect(ctx, buildConnectionStringForDB(p, connectToDatabase, password))
+	if err != nil {
+		return fmt.Errorf("failed to connect to database '%s': %w", connectToDatabase, err)
+	}
+	defer func() {
+		if closeErr := conn.Close(ctx); closeErr != nil {
+			logger.Error("Failed to close connection", "error", closeErr)
+		}
+	}()
+
+	var isExists bool
+	err = conn.QueryRow(
+		ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)",
+		name,
+	).Scan(&isExists)
+	if err != nil {
+		return fmt.Errorf("failed to check database existence: %w", err)
+	}
+
+	if isExists {
+		return fmt.Errorf("database '%s' already exists", name)
+	}
+
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to create database '%s': %w", name, err)
+	}
+
+	return nil
+}
+
 func (p *PostgresqlDatabase) HideSensitiveData() {
 	if p == nil {
 		return
@@ -19,7 +19,7 @@ var databaseService = &DatabaseService{
 	[]DatabaseCreationListener{},
 	[]DatabaseRemoveListener{},
 	[]DatabaseCopyListener{},
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 	audit_logs.GetAuditLogService(),
 	encryption.GetFieldEncryptor(),
 }
@@ -8,10 +8,13 @@ import (
 	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/util/encryption"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Database struct {
@@ -23,6 +26,10 @@ type Database struct {
 	Name        string       `json:"name"        gorm:"column:name;type:text;not null"`
 	Type        DatabaseType `json:"type"        gorm:"column:type;type:text;not null"`
 
+	// Tags group databases for notification rules, e.g. "production"
+	Tags       []string `json:"tags" gorm:"-"`
+	TagsString string   `json:"-"    gorm:"column:tags;type:text;not null"`
+
 	// ServerID links this database to a server (optional for backward compatibility)
 	ServerID   *uuid.UUID `json:"serverId,omitempty"   gorm:"column:server_id;type:uuid"`
 	ServerName string     `json:"serverName,omitempty" gorm:"column:server_name;->"` // Populated from join, read-only
@@ -42,11 +49,32 @@ type Database struct {
 	HealthStatus *HealthStatus `json:"healthStatus" gorm:"column:health_status;type:text;not null"`
 }
 
+func (d *Database) BeforeSave(_ *gorm.DB) error {
+	d.TagsString = strings.Join(d.Tags, ",")
+	return nil
+}
+
+func (d *Database) AfterFind(_ *gorm.DB) error {
+	if d.TagsString != "" {
+		d.Tags = strings.Split(d.TagsString, ",")
+	} else {
+		d.Tags = []string{}
+	}
+
+	return nil
+}
+
 func (d *Database) Validate() error {
 	if d.Name == "" {
 		return errors.New("name is required")
 	}
 
+	for _, tag := range d.Tags {
+		if strings.TrimSpace(tag) == "" || strings.Contains(tag, ",") {
+			return errors.New("tags must be non-empty and must not contain commas")
+		}
+	}
+
 	switch d.Type {
 	case DatabaseTypePostgres:
 		if d.Postgresql == nil {
@@ -127,9 +155,75 @@ func (d *Database) PopulateVersionIfEmpty(
 	return nil
 }
 
+// RunVerificationQuery runs sanity query of restore verification and returns
+// the resulting value, nil means NULL
+func (d *Database) RunVerificationQuery(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	query string,
+) (*string, error) {
+	switch {
+	case d.Postgresql != nil:
+		return d.Postgresql.RunVerificationQuery(logger, encryptor, d.ID, query)
+	case d.Mysql != nil:
+		return d.Mysql.RunVerificationQuery(logger, encryptor, d.ID, query)
+	case d.Mariadb != nil:
+		return d.Mariadb.RunVerificationQuery(logger, encryptor, d.ID, query)
+	case d.Mongodb != nil:
+		return d.Mongodb.RunVerificationQuery(logger, encryptor, d.ID, query)
+	default:
+		return nil, errors.New("database connection is not configured")
+	}
+}
+
+// CreateDatabase creates an empty database with the given name on the server
+// of this connection
+func (d *Database) CreateDatabase(
+	logger *slog.Logger,
+	encryptor encryption.FieldEncryptor,
+	name string,
+) error {
+	switch {
+	case d.Postgresql != nil:
+		return d.Postgresql.CreateDatabase(logger, encryptor, d.ID, name)
+	case d.Mysql != nil:
+		return d.Mysql.CreateDatabase(logger, encryptor, d.ID, name)
+	case d.Mariadb != nil:
+		return d.Mariadb.CreateDatabase(logger, encryptor, d.ID, name)
+	case d.Mongodb != nil:
+		return d.Mongodb.CreateDatabase(logger, encryptor, d.ID, name)
+	default:
+		return errors.New("database connection is not configured")
+	}
+}
+
+// SetDatabaseName points the connection to another database on the same server
+func (d *Database) SetDatabaseName(name string) {
+	switch {
+	case d.Postgresql != nil:
+		d.Postgresql.Database = &name
+	case d.Mysql != nil:
+		d.Mysql.Database = &name
+	case d.Mariadb != nil:
+		d.Mariadb.Database = &name
+	case d.Mongodb != nil:
+		d.Mongodb.Database = name
+	}
+}
+
+func (d *Database) ToNotificationEventDatabase() *notifiers_events.EventDatabase {
+	return &notifiers_events.EventDatabase{
+		ID:   d.ID,
+		Name: d.Name,
+		Type: string(d.Type),
+		Tags: d.Tags,
+	}
+}
+
 func (d *Database) Update(incoming *Database) {
 	d.Name = incoming.Name
 	d.Type = incoming.Type
+	d.Tags = incoming.Tags
 	d.Notifiers = incoming.Notifiers
 	d.ServerID = incoming.ServerID
 
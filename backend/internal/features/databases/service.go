@@ -8,11 +8,13 @@ import (
 	"time"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	"postgresus-backend/internal/features/databases/databases/mariadb"
 	"postgresus-backend/internal/features/databases/databases/mongodb"
 	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
 	"postgresus-backend/internal/features/notifiers"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/encryption"
@@ -29,9 +31,9 @@ type DatabaseService struct {
 	dbRemoveListener   []DatabaseRemoveListener
 	dbCopyListener     []DatabaseCopyListener
 
-	workspaceService *workspaces_services.WorkspaceService
-	auditLogService  *audit_logs.AuditLogService
-	fieldEncryptor   encryption.FieldEncryptor
+	authorizationService *workspaces_services.AuthorizationService
+	auditLogService      *audit_logs.AuditLogService
+	fieldEncryptor       encryption.FieldEncryptor
 }
 
 func (s *DatabaseService) AddDbCreationListener(
@@ -57,7 +59,11 @@ func (s *DatabaseService) CreateDatabase(
 	workspaceID uuid.UUID,
 	database *Database,
 ) (*Database, error) {
-	canManage, err := s.workspaceService.CanUserManageDBs(workspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -88,11 +94,14 @@ func (s *DatabaseService) CreateDatabase(
 		listener.OnDatabaseCreated(database.ID)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Database created: %s", database.Name),
-		&user.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCreate,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &database.ID,
+		Message:      fmt.Sprintf("Database created: %s", database.Name),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
 
 	return database, nil
 }
@@ -114,7 +123,12 @@ func (s *DatabaseService) UpdateDatabase(
 		return errors.New("cannot update database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*existingDatabase.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*existingDatabase.WorkspaceID,
+		existingDatabase.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return err
 	}
@@ -126,6 +140,7 @@ func (s *DatabaseService) UpdateDatabase(
 		return err
 	}
 
+	databaseBefore := audit_logs.Snapshot(existingDatabase)
 	existingDatabase.Update(database)
 
 	if err := existingDatabase.Validate(); err != nil {
@@ -145,11 +160,16 @@ func (s *DatabaseService) UpdateDatabase(
 		return err
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Database updated: %s", existingDatabase.Name),
-		&user.ID,
-		existingDatabase.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &existingDatabase.ID,
+		Message:      fmt.Sprintf("Database updated: %s", existingDatabase.Name),
+		User:         user,
+		WorkspaceID:  existingDatabase.WorkspaceID,
+		Before:       databaseBefore,
+		After:        existingDatabase,
+	})
 
 	return nil
 }
@@ -167,7 +187,12 @@ func (s *DatabaseService) DeleteDatabase(
 		return errors.New("cannot delete database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*existingDatabase.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*existingDatabase.WorkspaceID,
+		existingDatabase.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return err
 	}
@@ -181,11 +206,14 @@ func (s *DatabaseService) DeleteDatabase(
 		}
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Database deleted: %s", existingDatabase.Name),
-		&user.ID,
-		existingDatabase.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &existingDatabase.ID,
+		Message:      fmt.Sprintf("Database deleted: %s", existingDatabase.Name),
+		User:         user,
+		WorkspaceID:  existingDatabase.WorkspaceID,
+	})
 
 	return s.dbRepository.Delete(id)
 }
@@ -203,7 +231,12 @@ func (s *DatabaseService) GetDatabase(
 		return nil, errors.New("cannot access database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +252,11 @@ func (s *DatabaseService) GetDatabasesByWorkspace(
 	user *users_models.User,
 	workspaceID uuid.UUID,
 ) ([]*Database, error) {
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(workspaceID, user)
+	canAccess, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -232,11 +269,27 @@ func (s *DatabaseService) GetDatabasesByWorkspace(
 		return nil, err
 	}
 
+	// custom roles may hide some databases of the workspace from the member
+	visibleDatabases := make([]*Database, 0, len(databases))
 	for _, database := range databases {
+		canView, err := s.authorizationService.HasDatabasePermission(
+			workspaceID,
+			database.ID,
+			user,
+			users_enums.WorkspacePermissionView,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !canView {
+			continue
+		}
+
 		database.HideSensitiveData()
+		visibleDatabases = append(visibleDatabases, database)
 	}
 
-	return databases, nil
+	return visibleDatabases, nil
 }
 
 func (s *DatabaseService) IsNotifierUsing(
@@ -264,7 +317,12 @@ func (s *DatabaseService) TestDatabaseConnection(
 		return errors.New("cannot test connection for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return err
 	}
@@ -373,7 +431,12 @@ func (s *DatabaseService) CopyDatabase(
 		return nil, errors.New("cannot copy database without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*existingDatabase.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*existingDatabase.WorkspaceID,
+		existingDatabase.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -386,6 +449,7 @@ func (s *DatabaseService) CopyDatabase(
 		WorkspaceID:            existingDatabase.WorkspaceID,
 		Name:                   existingDatabase.Name + " (Copy)",
 		Type:                   existingDatabase.Type,
+		Tags:                   existingDatabase.Tags,
 		Notifiers:              existingDatabase.Notifiers,
 		LastBackupTime:         nil,
 		LastBackupErrorMessage: nil,
@@ -448,6 +512,10 @@ func (s *DatabaseService) CopyDatabase(
 				Database:     existingDatabase.Mongodb.Database,
 				AuthDatabase: existingDatabase.Mongodb.AuthDatabase,
 				IsHttps:      existingDatabase.Mongodb.IsHttps,
+
+				ConnectionString: existingDatabase.Mongodb.ConnectionString,
+				IsAllDatabases:   existingDatabase.Mongodb.IsAllDatabases,
+				IsOplogEnabled:   existingDatabase.Mongodb.IsOplogEnabled,
 			}
 		}
 	}
@@ -469,11 +537,18 @@ func (s *DatabaseService) CopyDatabase(
 		listener.OnDatabaseCopied(databaseID, copiedDatabase.ID)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Database copied: %s to %s", existingDatabase.Name, copiedDatabase.Name),
-		&user.ID,
-		existingDatabase.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCopy,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &copiedDatabase.ID,
+		Message: fmt.Sprintf(
+			"Database copied: %s to %s",
+			existingDatabase.Name,
+			copiedDatabase.Name,
+		),
+		User:        user,
+		WorkspaceID: existingDatabase.WorkspaceID,
+	})
 
 	return copiedDatabase, nil
 }
@@ -528,9 +603,11 @@ func (s *DatabaseService) IsUserReadOnly(
 			return false, errors.New("cannot check user for database without workspace")
 		}
 
-		canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(
+		canAccess, err := s.authorizationService.HasDatabasePermission(
 			*existingDatabase.WorkspaceID,
+			existingDatabase.ID,
 			user,
+			users_enums.WorkspacePermissionView,
 		)
 		if err != nil {
 			return false, err
@@ -552,7 +629,12 @@ func (s *DatabaseService) IsUserReadOnly(
 		usingDatabase = existingDatabase
 	} else {
 		if database.WorkspaceID != nil {
-			canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, user)
+			canAccess, err := s.authorizationService.HasDatabasePermission(
+				*database.WorkspaceID,
+				database.ID,
+				user,
+				users_enums.WorkspacePermissionView,
+			)
 			if err != nil {
 				return false, err
 			}
@@ -617,7 +699,12 @@ func (s *DatabaseService) CreateReadOnlyUser(
 			return "", "", errors.New("cannot create user for database without workspace")
 		}
 
-		canManage, err := s.workspaceService.CanUserManageDBs(*existingDatabase.WorkspaceID, user)
+		canManage, err := s.authorizationService.HasDatabasePermission(
+			*existingDatabase.WorkspaceID,
+			existingDatabase.ID,
+			user,
+			users_enums.WorkspacePermissionManageDatabases,
+		)
 		if err != nil {
 			return "", "", err
 		}
@@ -638,7 +725,12 @@ func (s *DatabaseService) CreateReadOnlyUser(
 		usingDatabase = existingDatabase
 	} else {
 		if database.WorkspaceID != nil {
-			canManage, err := s.workspaceService.CanUserManageDBs(*database.WorkspaceID, user)
+			canManage, err := s.authorizationService.HasDatabasePermission(
+				*database.WorkspaceID,
+				database.ID,
+				user,
+				users_enums.WorkspacePermissionManageDatabases,
+			)
 			if err != nil {
 				return "", "", err
 			}
@@ -682,15 +774,18 @@ func (s *DatabaseService) CreateReadOnlyUser(
 	}
 
 	if usingDatabase.WorkspaceID != nil {
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf(
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionCreate,
+			ResourceType: audit_logs_events.AuditResourceDatabase,
+			ResourceID:   &usingDatabase.ID,
+			Message: fmt.Sprintf(
 				"Read-only user created for database: %s (username: %s)",
 				usingDatabase.Name,
 				username,
 			),
-			&user.ID,
-			usingDatabase.WorkspaceID,
-		)
+			User:        user,
+			WorkspaceID: usingDatabase.WorkspaceID,
+		})
 	}
 
 	return username, password, nil
@@ -0,0 +1,62 @@
+package rotation
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const (
+	keyReloadInterval      = time.Minute
+	keyMaintenanceInterval = time.Hour
+	// retired keys are kept at least for this period, the other process
+	// may encrypt data by the key until it reloads the keyring
+	retiredKeyGracePeriod = 24 * time.Hour
+	reencryptBackupsLimit = 10
+)
+
+type MasterKeyRotationBackgroundService struct {
+	rotationService *MasterKeyRotationService
+	logger          *slog.Logger
+
+	lastMaintenanceAt time.Time
+}
+
+func (s *MasterKeyRotationBackgroundService) Run() {
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.IsShouldShutdown() {
+			break
+		}
+
+		// the key is rotated by CLI command in another process
+		if err := s.rotationService.secretKeyService.ReloadKeys(); err != nil {
+			s.logger.Error("failed to reload master keys", "error", err)
+			continue
+		}
+
+		if time.Since(s.lastMaintenanceAt) < keyMaintenanceInterval {
+			continue
+		}
+		s.lastMaintenanceAt = time.Now().UTC()
+
+		// values written by processes which had not reloaded the keyring yet
+		if err := s.rotationService.ReencryptFields(); err != nil {
+			s.logger.Error("failed to re-encrypt fields", "error", err)
+		}
+
+		if config.GetEnv().IsReencryptBackupsOnKeyRotation {
+			if err := s.rotationService.ReencryptBackups(reencryptBackupsLimit); err != nil {
+				s.logger.Error("failed to re-encrypt backups", "error", err)
+			}
+		}
+
+		retiredBefore := time.Now().UTC().Add(-retiredKeyGracePeriod)
+		if err := s.rotationService.RemoveUnusedKeys(retiredBefore); err != nil {
+			s.logger.Error("failed to remove unused master keys", "error", err)
+		}
+	}
+}
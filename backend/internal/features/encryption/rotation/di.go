@@ -0,0 +1,28 @@
+package rotation
+
+import (
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+)
+
+var masterKeyRotationService = &MasterKeyRotationService{
+	secrets.GetSecretKeyService(),
+	encryption.GetFieldEncryptor(),
+	backups.GetBackupService(),
+	logger.GetLogger(),
+}
+
+var masterKeyRotationBackgroundService = &MasterKeyRotationBackgroundService{
+	rotationService: masterKeyRotationService,
+	logger:          logger.GetLogger(),
+}
+
+func GetMasterKeyRotationService() *MasterKeyRotationService {
+	return masterKeyRotationService
+}
+
+func GetMasterKeyRotationBackgroundService() *MasterKeyRotationBackgroundService {
+	return masterKeyRotationBackgroundService
+}
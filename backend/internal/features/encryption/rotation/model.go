@@ -0,0 +1,50 @@
+package rotation
+
+import "github.com/google/uuid"
+
+// encryptedField is a column encrypted by the field encryptor. IDColumn
+// keeps the ID the value was encrypted with, it is a part of the nonce
+type encryptedField struct {
+	Table    string
+	IDColumn string
+	Column   string
+}
+
+// encryptedFields must list every column encrypted by the field
+// encryptor, otherwise the column cannot be decrypted after retired keys
+// are removed
+var encryptedFields = []encryptedField{
+	{"ftp_storages", "storage_id", "password"},
+	{"google_drive_storages", "storage_id", "client_secret"},
+	{"google_drive_storages", "storage_id", "token_json"},
+	{"azure_blob_storages", "storage_id", "connection_string"},
+	{"azure_blob_storages", "storage_id", "account_key"},
+	{"nas_storages", "storage_id", "password"},
+	{"rclone_storages", "storage_id", "config_content"},
+	{"sftp_storages", "storage_id", "password"},
+	{"sftp_storages", "storage_id", "private_key"},
+	{"s3_storages", "storage_id", "s3_access_key"},
+	{"s3_storages", "storage_id", "s3_secret_key"},
+	{"webhook_notifiers", "notifier_id", "webhook_url"},
+	{"teams_notifiers", "notifier_id", "power_automate_url"},
+	{"discord_notifiers", "notifier_id", "channel_webhook_url"},
+	{"telegram_notifiers", "notifier_id", "bot_token"},
+	{"slack_notifiers", "notifier_id", "bot_token"},
+	{"email_notifiers", "notifier_id", "smtp_password"},
+	{"incident_notifiers", "notifier_id", "routing_key"},
+	{"postgresql_databases", "database_id", "password"},
+	{"mysql_databases", "database_id", "password"},
+	{"mariadb_databases", "database_id", "password"},
+	{"mongodb_databases", "database_id", "password"},
+	{"servers", "id", "password"},
+	{"approval_requests", "id", "payload"},
+	{"users", "id", "totp_secret"},
+}
+
+// tables of files encrypted by the master key with recorded key version
+var encryptedFileTables = []string{"backups", "wal_segments", "binlog_files"}
+
+type encryptedValue struct {
+	ID    uuid.UUID `gorm:"column:id"`
+	Value string    `gorm:"column:value"`
+}
@@ -0,0 +1,208 @@
+package rotation
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/encryption/secrets"
+	"postgresus-backend/internal/storage"
+	"postgresus-backend/internal/util/encryption"
+
+	"gorm.io/gorm"
+)
+
+type MasterKeyRotationService struct {
+	secretKeyService *secrets.SecretKeyService
+	fieldEncryptor   encryption.FieldEncryptor
+	backupService    *backups.BackupService
+	logger           *slog.Logger
+}
+
+// RotateMasterKey makes new master key current and re-encrypts stored
+// credentials by it. The keyring is saved first: if re-encryption fails,
+// the values are still decrypted by the retired key and the command can
+// be repeated
+func (s *MasterKeyRotationService) RotateMasterKey() (int, error) {
+	keyVersion, err := s.secretKeyService.RotateKey()
+	if err != nil {
+		return 0, fmt.Errorf("failed to rotate master key: %w", err)
+	}
+
+	if err := s.ReencryptFields(); err != nil {
+		return keyVersion, err
+	}
+
+	return keyVersion, nil
+}
+
+// ReencryptFields re-encrypts all fields encrypted by retired keys in one
+// transaction, so credentials are never left partially re-encrypted
+func (s *MasterKeyRotationService) ReencryptFields() error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		for _, field := range encryptedFields {
+			if err := s.reencryptField(tx, field); err != nil {
+				return fmt.Errorf(
+					"failed to re-encrypt %s.%s: %w",
+					field.Table,
+					field.Column,
+					err,
+				)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ReencryptBackups re-encrypts a batch of backups made with retired keys.
+// Backups are processed one by one, because each file is downloaded and
+// uploaded again
+func (s *MasterKeyRotationService) ReencryptBackups(limit int) error {
+	backupsToReencrypt, err := s.backupService.GetBackupsWithRetiredKey(limit)
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range backupsToReencrypt {
+		if config.IsShouldShutdown() {
+			return nil
+		}
+
+		if err := s.backupService.ReencryptBackup(backup); err != nil {
+			s.logger.Error(
+				"Failed to re-encrypt backup",
+				"backupId",
+				backup.ID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		s.logger.Info("Backup is re-encrypted by the current master key", "backupId", backup.ID)
+	}
+
+	return nil
+}
+
+// RemoveUnusedKeys removes retired keys which do not encrypt any field or
+// file. Keys retired recently are kept: another process may still use the
+// cached keyring to encrypt new data
+func (s *MasterKeyRotationService) RemoveUnusedKeys(retiredBefore time.Time) error {
+	retiredVersions, err := s.secretKeyService.GetRetiredKeyVersions(retiredBefore)
+	if err != nil {
+		return err
+	}
+
+	if len(retiredVersions) == 0 {
+		return nil
+	}
+
+	usedVersions, err := s.getUsedKeyVersions()
+	if err != nil {
+		return err
+	}
+
+	unusedVersions := slices.DeleteFunc(retiredVersions, func(version int) bool {
+		return slices.Contains(usedVersions, version)
+	})
+
+	if len(unusedVersions) == 0 {
+		return nil
+	}
+
+	if err := s.secretKeyService.RemoveKeys(unusedVersions); err != nil {
+		return err
+	}
+
+	s.logger.Info("Removed unused retired master keys", "versions", unusedVersions)
+	return nil
+}
+
+func (s *MasterKeyRotationService) reencryptField(tx *gorm.DB, field encryptedField) error {
+	values, err := findEncryptedValues(tx, field)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		reencryptedValue, err := s.fieldEncryptor.Reencrypt(value.ID, value.Value)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt value of %s: %w", value.ID, err)
+		}
+
+		if reencryptedValue == value.Value {
+			continue
+		}
+
+		if err := tx.
+			Table(field.Table).
+			Where(field.IDColumn+" = ?", value.ID).
+			Update(field.Column, reencryptedValue).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MasterKeyRotationService) getUsedKeyVersions() ([]int, error) {
+	var usedVersions []int
+
+	for _, field := range encryptedFields {
+		values, err := findEncryptedValues(storage.GetDb(), field)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values {
+			version, ok := encryption.GetEncryptedValueKeyVersion(value.Value)
+			if ok && !slices.Contains(usedVersions, version) {
+				usedVersions = append(usedVersions, version)
+			}
+		}
+	}
+
+	for _, table := range encryptedFileTables {
+		var versions []int
+
+		if err := storage.GetDb().Raw(
+			fmt.Sprintf(
+				"SELECT DISTINCT COALESCE(encryption_key_version, ?) FROM %s WHERE encryption = ?",
+				table,
+			),
+			secrets.LegacyKeyVersion,
+			backups_config.BackupEncryptionEncrypted,
+		).Scan(&versions).Error; err != nil {
+			return nil, fmt.Errorf("failed to get key versions of %s: %w", table, err)
+		}
+
+		for _, version := range versions {
+			if !slices.Contains(usedVersions, version) {
+				usedVersions = append(usedVersions, version)
+			}
+		}
+	}
+
+	return usedVersions, nil
+}
+
+func findEncryptedValues(db *gorm.DB, field encryptedField) ([]*encryptedValue, error) {
+	var values []*encryptedValue
+
+	if err := db.
+		Table(field.Table).
+		Select(fmt.Sprintf("%s AS id, %s AS value", field.IDColumn, field.Column)).
+		Where(field.IDColumn+" IS NOT NULL").
+		Where(field.Column+" LIKE ?", "enc:%").
+		Scan(&values).Error; err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,43 @@
+package rotation
+
+import (
+	"testing"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the registry keeps table names as strings, so renamed columns are found
+// here and not after retired keys are removed
+func Test_EncryptedFields_AllColumnsExist(t *testing.T) {
+	for _, field := range encryptedFields {
+		var count int64
+
+		err := storage.GetDb().Raw(
+			`SELECT COUNT(*) FROM information_schema.columns
+			 WHERE table_schema = current_schema()
+			   AND table_name = ?
+			   AND column_name IN (?, ?)`,
+			field.Table,
+			field.IDColumn,
+			field.Column,
+		).Scan(&count).Error
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count, "%s.%s", field.Table, field.Column)
+	}
+
+	for _, table := range encryptedFileTables {
+		var count int64
+
+		err := storage.GetDb().Raw(
+			`SELECT COUNT(*) FROM information_schema.columns
+			 WHERE table_schema = current_schema()
+			   AND table_name = ?
+			   AND column_name IN ('encryption', 'encryption_key_version')`,
+			table,
+		).Scan(&count).Error
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count, table)
+	}
+}
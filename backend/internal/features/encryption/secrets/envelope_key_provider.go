@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"postgresus-backend/internal/util/kms"
+)
+
+// EnvelopeKeyProvider keeps the key on disk encrypted by the key management
+// system (Vault Transit, KMIP, cloud KMS), so the disk with backups does not
+// contain the key. The key is decrypted once and cached in memory
+type EnvelopeKeyProvider struct {
+	keyWrapper kms.KeyWrapper
+	// path of the encrypted key
+	wrappedKeyPath string
+	// path of the plain text key of file provider, it is encrypted and
+	// removed on the first start, so existing backups stay readable
+	plainKeyPath string
+	logger       *slog.Logger
+}
+
+func NewEnvelopeKeyProvider(
+	keyWrapper kms.KeyWrapper,
+	wrappedKeyPath string,
+	plainKeyPath string,
+	logger *slog.Logger,
+) *EnvelopeKeyProvider {
+	return &EnvelopeKeyProvider{keyWrapper, wrappedKeyPath, plainKeyPath, logger}
+}
+
+func (p *EnvelopeKeyProvider) LoadKey() (string, error) {
+	data, err := os.ReadFile(p.wrappedKeyPath)
+	if err == nil {
+		key, err := p.keyWrapper.Unwrap(strings.TrimSpace(string(data)))
+		if err != nil {
+			return "", err
+		}
+
+		return string(key), nil
+	}
+
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read encrypted secret key file: %w", err)
+	}
+
+	plainKey, err := os.ReadFile(p.plainKeyPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read secret key file: %w", err)
+	}
+
+	if len(plainKey) > 0 {
+		if err := p.StoreKey(string(plainKey)); err != nil {
+			return "", err
+		}
+
+		if err := os.Remove(p.plainKeyPath); err != nil {
+			return "", fmt.Errorf("failed to remove plain text secret key: %w", err)
+		}
+
+		p.logger.Info("Secret key is moved from file to key management system")
+
+		return string(plainKey), nil
+	}
+
+	newKey := generateNewSecretKey()
+	if err := p.StoreKey(newKey); err != nil {
+		return "", err
+	}
+
+	return newKey, nil
+}
+
+// StoreKey encrypts the key and checks it can be decrypted back before
+// writing, so the key is not lost because of misconfigured permissions
+func (p *EnvelopeKeyProvider) StoreKey(key string) error {
+	wrappedKey, err := p.keyWrapper.Wrap([]byte(key))
+	if err != nil {
+		return err
+	}
+
+	unwrappedKey, err := p.keyWrapper.Unwrap(wrappedKey)
+	if err != nil {
+		return err
+	}
+
+	if string(unwrappedKey) != key {
+		return errors.New("key management system returned different key after decryption")
+	}
+
+	if err := os.WriteFile(p.wrappedKeyPath, []byte(wrappedKey), 0600); err != nil {
+		return fmt.Errorf("failed to write encrypted secret key to file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KeyProviderFile  = "file"
+	KeyProviderEnv   = "env"
+	KeyProviderVault = "vault"
+	KeyProviderHTTP  = "http"
+
+	// AES-256 key is taken from the beginning of the master key
+	minSecretKeyLength = 32
+)
+
+// KeyProvider keeps the master key. The key is created by the provider on
+// the first start, when the provider is able to store it
+type KeyProvider interface {
+	LoadKey() (string, error)
+	// StoreKey saves existing key, used to move the key between providers
+	StoreKey(key string) error
+}
+
+// FileKeyProvider keeps the key in plain text file next to the data
+type FileKeyProvider struct {
+	keyPath string
+}
+
+func NewFileKeyProvider(keyPath string) *FileKeyProvider {
+	return &FileKeyProvider{keyPath}
+}
+
+func (p *FileKeyProvider) LoadKey() (string, error) {
+	data, err := os.ReadFile(p.keyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			newKey := generateNewSecretKey()
+			if err := p.StoreKey(newKey); err != nil {
+				return "", err
+			}
+
+			return newKey, nil
+		}
+
+		return "", fmt.Errorf("failed to read secret key file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func (p *FileKeyProvider) StoreKey(key string) error {
+	if err := os.WriteFile(p.keyPath, []byte(key), 0600); err != nil {
+		return fmt.Errorf("failed to write secret key to file: %w", err)
+	}
+
+	return nil
+}
+
+// EnvKeyProvider takes the key from environment variable, so it is managed
+// by orchestrator secrets (Kubernetes, Docker Swarm, etc.)
+type EnvKeyProvider struct {
+	key string
+}
+
+func NewEnvKeyProvider(key string) *EnvKeyProvider {
+	return &EnvKeyProvider{key}
+}
+
+func (p *EnvKeyProvider) LoadKey() (string, error) {
+	if p.key == "" {
+		return "", errors.New("SECRET_KEY is not set")
+	}
+
+	if len(p.key) < minSecretKeyLength {
+		return "", fmt.Errorf("SECRET_KEY must be at least %d characters", minSecretKeyLength)
+	}
+
+	return p.key, nil
+}
+
+func (p *EnvKeyProvider) StoreKey(key string) error {
+	if key == p.key {
+		return nil
+	}
+
+	return errors.New(
+		"key cannot be saved to environment variable, set SECRET_KEY to the existing key",
+	)
+}
+
+func generateNewSecretKey() string {
+	return uuid.New().String() + uuid.New().String()
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileKeyProvider_WhenFileDoesNotExist_CreatesAndReusesKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "secret.key")
+	provider := NewFileKeyProvider(keyPath)
+
+	key, err := provider.LoadKey()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(key), minSecretKeyLength)
+
+	reloadedKey, err := NewFileKeyProvider(keyPath).LoadKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key, reloadedKey)
+}
+
+func Test_EnvKeyProvider_WithShortOrEmptyKey_ReturnsError(t *testing.T) {
+	_, err := NewEnvKeyProvider("").LoadKey()
+	assert.EqualError(t, err, "SECRET_KEY is not set")
+
+	_, err = NewEnvKeyProvider("short").LoadKey()
+	assert.EqualError(t, err, "SECRET_KEY must be at least 32 characters")
+
+	key := strings.Repeat("k", minSecretKeyLength)
+	loadedKey, err := NewEnvKeyProvider(key).LoadKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key, loadedKey)
+}
+
+func Test_EnvelopeKeyProvider_WithPlainKeyFile_EncryptsKeyAndRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	plainKeyPath := filepath.Join(dir, "secret.key")
+	wrappedKeyPath := filepath.Join(dir, "secret.key.wrapped")
+	existingKey := generateNewSecretKey()
+	assert.NoError(t, os.WriteFile(plainKeyPath, []byte(existingKey), 0600))
+
+	provider := NewEnvelopeKeyProvider(
+		&testKeyWrapper{},
+		wrappedKeyPath,
+		plainKeyPath,
+		logger.GetLogger(),
+	)
+
+	key, err := provider.LoadKey()
+	assert.NoError(t, err)
+	assert.Equal(t, existingKey, key)
+
+	_, err = os.Stat(plainKeyPath)
+	assert.True(t, os.IsNotExist(err))
+
+	wrappedKey, err := os.ReadFile(wrappedKeyPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(wrappedKey), existingKey)
+
+	reloadedKey, err := provider.LoadKey()
+	assert.NoError(t, err)
+	assert.Equal(t, existingKey, reloadedKey)
+}
+
+func Test_EnvelopeKeyProvider_WhenKeyManagementIsUnavailable_KeepsPlainKey(t *testing.T) {
+	dir := t.TempDir()
+	plainKeyPath := filepath.Join(dir, "secret.key")
+	wrappedKeyPath := filepath.Join(dir, "secret.key.wrapped")
+	assert.NoError(t, os.WriteFile(plainKeyPath, []byte(generateNewSecretKey()), 0600))
+
+	provider := NewEnvelopeKeyProvider(
+		&testKeyWrapper{isUnavailable: true},
+		wrappedKeyPath,
+		plainKeyPath,
+		logger.GetLogger(),
+	)
+
+	_, err := provider.LoadKey()
+	assert.Error(t, err)
+
+	_, err = os.Stat(plainKeyPath)
+	assert.NoError(t, err)
+
+	_, err = os.Stat(wrappedKeyPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+type testKeyWrapper struct {
+	isUnavailable bool
+}
+
+func (w *testKeyWrapper) Wrap(plaintext []byte) (string, error) {
+	if w.isUnavailable {
+		return "", errors.New("key management is unavailable")
+	}
+
+	return "test:" + base64.StdEncoding.EncodeToString(plaintext), nil
+}
+
+func (w *testKeyWrapper) Unwrap(ciphertext string) ([]byte, error) {
+	if w.isUnavailable {
+		return nil, errors.New("key management is unavailable")
+	}
+
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "test:"))
+}
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LegacyKeyVersion is the version of the key created before rotation was
+// introduced. Data without recorded version is encrypted by this key
+const LegacyKeyVersion = 1
+
+type MasterKey struct {
+	Version   int       `json:"version"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+	// RetiredAt is set when the next key becomes current
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+}
+
+// Keyring keeps the current key and retired keys. Retired keys are used
+// only to decrypt data encrypted before the rotation and are removed when
+// the data is re-encrypted or expired
+type Keyring struct {
+	CurrentVersion int          `json:"currentVersion"`
+	Keys           []*MasterKey `json:"keys"`
+}
+
+// parseKeyring reads the keyring stored by key provider. Before rotation
+// the provider stored the single key as is, it becomes the legacy version
+func parseKeyring(data string) (*Keyring, error) {
+	if !strings.HasPrefix(strings.TrimSpace(data), "{") {
+		if len(data) < minSecretKeyLength {
+			return nil, errors.New("secret key is too short")
+		}
+
+		return &Keyring{
+			CurrentVersion: LegacyKeyVersion,
+			Keys:           []*MasterKey{{Version: LegacyKeyVersion, Key: data}},
+		}, nil
+	}
+
+	var keyring Keyring
+	if err := json.Unmarshal([]byte(data), &keyring); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring: %w", err)
+	}
+
+	if keyring.GetKey(keyring.CurrentVersion) == nil {
+		return nil, errors.New("keyring does not contain the current key")
+	}
+
+	for _, key := range keyring.Keys {
+		if len(key.Key) < minSecretKeyLength {
+			return nil, fmt.Errorf("key of version %d is too short", key.Version)
+		}
+	}
+
+	return &keyring, nil
+}
+
+func (k *Keyring) GetKey(version int) *MasterKey {
+	for _, key := range k.Keys {
+		if key.Version == version {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (k *Keyring) GetCurrentKey() *MasterKey {
+	return k.GetKey(k.CurrentVersion)
+}
+
+// GetRetiredVersions returns versions of keys retired before the date
+func (k *Keyring) GetRetiredVersions(retiredBefore time.Time) []int {
+	versions := make([]int, 0, len(k.Keys))
+	for _, key := range k.Keys {
+		if key.Version != k.CurrentVersion &&
+			key.RetiredAt != nil &&
+			key.RetiredAt.Before(retiredBefore) {
+			versions = append(versions, key.Version)
+		}
+	}
+
+	return versions
+}
+
+// addKey makes the new key current, previous keys become retired
+func (k *Keyring) addKey(key string) *MasterKey {
+	version := 0
+	for _, existingKey := range k.Keys {
+		version = max(version, existingKey.Version)
+	}
+
+	now := time.Now().UTC()
+	if currentKey := k.GetCurrentKey(); currentKey != nil {
+		currentKey.RetiredAt = &now
+	}
+
+	masterKey := &MasterKey{
+		Version:   version + 1,
+		Key:       key,
+		CreatedAt: now,
+	}
+
+	k.Keys = append(k.Keys, masterKey)
+	k.CurrentVersion = masterKey.Version
+
+	return masterKey
+}
+
+func (k *Keyring) removeKeys(versions []int) {
+	k.Keys = slices.DeleteFunc(k.Keys, func(key *MasterKey) bool {
+		return key.Version != k.CurrentVersion && slices.Contains(versions, key.Version)
+	})
+}
+
+func (k *Keyring) serialize() (string, error) {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize keyring: %w", err)
+	}
+
+	return string(data), nil
+}
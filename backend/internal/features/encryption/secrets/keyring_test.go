@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseKeyring_WithPlainKey_ReturnsLegacyKey(t *testing.T) {
+	key := generateNewSecretKey()
+
+	keyring, err := parseKeyring(key)
+	assert.NoError(t, err)
+	assert.Equal(t, LegacyKeyVersion, keyring.CurrentVersion)
+	assert.Equal(t, key, keyring.GetCurrentKey().Key)
+
+	_, err = parseKeyring("short")
+	assert.Error(t, err)
+}
+
+func Test_SecretKeyService_RotateKey_KeepsRetiredKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "secret.key")
+	service := NewSecretKeyService(NewFileKeyProvider(keyPath))
+
+	legacyKey, err := service.GetSecretKey()
+	assert.NoError(t, err)
+
+	keyVersion, err := service.RotateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, LegacyKeyVersion+1, keyVersion)
+
+	currentVersion, currentKey, err := service.GetCurrentKey()
+	assert.NoError(t, err)
+	assert.Equal(t, keyVersion, currentVersion)
+	assert.NotEqual(t, legacyKey, currentKey)
+
+	retiredKey, err := service.GetKeyByVersion(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyKey, retiredKey)
+
+	// other process reads the rotated keyring from the same file
+	reloadedService := NewSecretKeyService(NewFileKeyProvider(keyPath))
+
+	reloadedKey, err := reloadedService.GetSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, currentKey, reloadedKey)
+
+	reloadedRetiredKey, err := reloadedService.GetKeyByVersion(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyKey, reloadedRetiredKey)
+}
+
+func Test_SecretKeyService_GetKeyByVersion_WhenRotatedByOtherProcess_ReloadsKeyring(
+	t *testing.T,
+) {
+	keyPath := filepath.Join(t.TempDir(), "secret.key")
+	service := NewSecretKeyService(NewFileKeyProvider(keyPath))
+	_, err := service.GetSecretKey()
+	assert.NoError(t, err)
+
+	keyVersion, err := NewSecretKeyService(NewFileKeyProvider(keyPath)).RotateKey()
+	assert.NoError(t, err)
+
+	key, err := service.GetKeyByVersion(&keyVersion)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(key), minSecretKeyLength)
+
+	missingVersion := keyVersion + 1
+	_, err = service.GetKeyByVersion(&missingVersion)
+	assert.Error(t, err)
+}
+
+func Test_SecretKeyService_RemoveKeys_RemovesOnlyRetiredKeys(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "secret.key")
+	service := NewSecretKeyService(NewFileKeyProvider(keyPath))
+
+	keyVersion, err := service.RotateKey()
+	assert.NoError(t, err)
+
+	retiredVersions, err := service.GetRetiredKeyVersions(time.Now().UTC().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, retiredVersions)
+
+	retiredVersions, err = service.GetRetiredKeyVersions(time.Now().UTC().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{LegacyKeyVersion}, retiredVersions)
+
+	err = service.RemoveKeys([]int{LegacyKeyVersion, keyVersion})
+	assert.NoError(t, err)
+
+	_, err = service.GetKeyByVersion(nil)
+	assert.Error(t, err)
+
+	_, err = service.GetKeyByVersion(&keyVersion)
+	assert.NoError(t, err)
+}
+
+func Test_SecretKeyService_RotateKey_WithEnvProvider_ReturnsError(t *testing.T) {
+	service := NewSecretKeyService(NewEnvKeyProvider(strings.Repeat("k", minSecretKeyLength)))
+
+	_, err := service.RotateKey()
+	assert.Error(t, err)
+
+	keyVersion, _, err := service.GetCurrentKey()
+	assert.NoError(t, err)
+	assert.Equal(t, LegacyKeyVersion, keyVersion)
+}
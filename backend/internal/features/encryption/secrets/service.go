@@ -3,21 +3,30 @@ package secrets
 import (
 	"errors"
 	"fmt"
-	"os"
+	"sync"
+	"time"
 
 	"postgresus-backend/internal/config"
 	user_models "postgresus-backend/internal/features/users/models"
 	"postgresus-backend/internal/storage"
+	"postgresus-backend/internal/util/kms"
+	"postgresus-backend/internal/util/logger"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type SecretKeyService struct {
-	cachedKey *string
+	cachedKeyring *Keyring
+
+	mu          sync.Mutex
+	keyProvider KeyProvider
+}
+
+func NewSecretKeyService(keyProvider KeyProvider) *SecretKeyService {
+	return &SecretKeyService{keyProvider: keyProvider}
 }
 
-func (s *SecretKeyService) MigrateKeyFromDbToFileIfExist() error {
+func (s *SecretKeyService) MigrateKeyFromDbIfExist() error {
 	var secretKey user_models.SecretKey
 
 	err := storage.GetDb().First(&secretKey).Error
@@ -32,9 +41,13 @@ func (s *SecretKeyService) MigrateKeyFromDbToFileIfExist() error {
 		return nil
 	}
 
-	secretKeyPath := config.GetEnv().SecretKeyPath
-	if err := os.WriteFile(secretKeyPath, []byte(secretKey.Secret), 0600); err != nil {
-		return fmt.Errorf("failed to write secret key to file: %w", err)
+	keyProvider, err := s.getKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := keyProvider.StoreKey(secretKey.Secret); err != nil {
+		return err
 	}
 
 	if err := storage.GetDb().Exec("DELETE FROM secret_keys").Error; err != nil {
@@ -44,30 +57,223 @@ func (s *SecretKeyService) MigrateKeyFromDbToFileIfExist() error {
 	return nil
 }
 
+// GetSecretKey returns the current master key, it signs tokens and
+// encrypts new data
 func (s *SecretKeyService) GetSecretKey() (string, error) {
-	if s.cachedKey != nil {
-		return *s.cachedKey, nil
+	_, key, err := s.GetCurrentKey()
+	return key, err
+}
+
+func (s *SecretKeyService) GetCurrentKey() (int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.getKeyring()
+	if err != nil {
+		return 0, "", err
+	}
+
+	currentKey := keyring.GetCurrentKey()
+	return currentKey.Version, currentKey.Key, nil
+}
+
+// GetKeyByVersion returns the key which encrypted the data. Data encrypted
+// before versioning has no version and is encrypted by the legacy key
+func (s *SecretKeyService) GetKeyByVersion(version *int) (string, error) {
+	keyVersion := LegacyKeyVersion
+	if version != nil {
+		keyVersion = *version
 	}
 
-	secretKeyPath := config.GetEnv().SecretKeyPath
-	data, err := os.ReadFile(secretKeyPath)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.getKeyring()
 	if err != nil {
-		if os.IsNotExist(err) {
-			newKey := s.generateNewSecretKey()
-			if err := os.WriteFile(secretKeyPath, []byte(newKey), 0600); err != nil {
-				return "", fmt.Errorf("failed to write new secret key: %w", err)
-			}
-			s.cachedKey = &newKey
-			return newKey, nil
-		}
-		return "", fmt.Errorf("failed to read secret key file: %w", err)
+		return "", err
+	}
+
+	if key := keyring.GetKey(keyVersion); key != nil {
+		return key.Key, nil
+	}
+
+	// the key may be rotated by another process (CLI) after the keyring
+	// was cached
+	keyring, err = s.loadKeyring()
+	if err != nil {
+		return "", err
+	}
+	s.cachedKeyring = keyring
+
+	if key := keyring.GetKey(keyVersion); key != nil {
+		return key.Key, nil
+	}
+
+	return "", fmt.Errorf("master key of version %d is not found", keyVersion)
+}
+
+func (s *SecretKeyService) ReloadKeys() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.loadKeyring()
+	if err != nil {
+		return err
+	}
+
+	s.cachedKeyring = keyring
+	return nil
+}
+
+// RotateKey generates new current key. Previous keys are kept as retired
+// to decrypt existing data until it is re-encrypted or removed
+func (s *SecretKeyService) RotateKey() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.loadKeyring()
+	if err != nil {
+		return 0, err
+	}
+
+	newKey := keyring.addKey(generateNewSecretKey())
+	if err := s.storeKeyring(keyring); err != nil {
+		return 0, err
+	}
+
+	s.cachedKeyring = keyring
+	return newKey.Version, nil
+}
+
+func (s *SecretKeyService) GetRetiredKeyVersions(retiredBefore time.Time) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.getKeyring()
+	if err != nil {
+		return nil, err
 	}
 
-	key := string(data)
-	s.cachedKey = &key
-	return key, nil
+	return keyring.GetRetiredVersions(retiredBefore), nil
 }
 
-func (s *SecretKeyService) generateNewSecretKey() string {
-	return uuid.New().String() + uuid.New().String()
+// RemoveKeys removes retired keys, the data encrypted by them can not be
+// decrypted anymore. The current key is never removed
+func (s *SecretKeyService) RemoveKeys(versions []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyring, err := s.loadKeyring()
+	if err != nil {
+		return err
+	}
+
+	keyring.removeKeys(versions)
+	if err := s.storeKeyring(keyring); err != nil {
+		return err
+	}
+
+	s.cachedKeyring = keyring
+	return nil
+}
+
+func (s *SecretKeyService) getKeyring() (*Keyring, error) {
+	if s.cachedKeyring != nil {
+		return s.cachedKeyring, nil
+	}
+
+	keyring, err := s.loadKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	s.cachedKeyring = keyring
+	return keyring, nil
+}
+
+func (s *SecretKeyService) loadKeyring() (*Keyring, error) {
+	keyProvider, err := s.getKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := keyProvider.LoadKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secret key: %w", err)
+	}
+
+	return parseKeyring(data)
+}
+
+func (s *SecretKeyService) storeKeyring(keyring *Keyring) error {
+	keyProvider, err := s.getKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	data, err := keyring.serialize()
+	if err != nil {
+		return err
+	}
+
+	if err := keyProvider.StoreKey(data); err != nil {
+		return fmt.Errorf("failed to store keyring: %w", err)
+	}
+
+	return nil
+}
+
+// getKeyProvider creates the provider on the first use, because the
+// provider is configured by environment variables
+func (s *SecretKeyService) getKeyProvider() (KeyProvider, error) {
+	if s.keyProvider != nil {
+		return s.keyProvider, nil
+	}
+
+	env := config.GetEnv()
+
+	switch env.SecretKeyProvider {
+	case "", KeyProviderFile:
+		s.keyProvider = NewFileKeyProvider(env.SecretKeyPath)
+	case KeyProviderEnv:
+		s.keyProvider = NewEnvKeyProvider(env.SecretKey)
+	case KeyProviderVault:
+		vaultClient, err := kms.NewVaultTransitClient(
+			env.VaultAddress,
+			env.VaultToken,
+			env.VaultNamespace,
+			env.VaultTransitMount,
+			env.VaultTransitKeyName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.keyProvider = NewEnvelopeKeyProvider(
+			vaultClient,
+			env.WrappedSecretKeyPath,
+			env.SecretKeyPath,
+			logger.GetLogger(),
+		)
+	case KeyProviderHTTP:
+		httpClient, err := kms.NewHTTPClient(
+			env.KeyManagementURL,
+			env.KeyManagementToken,
+			env.KeyManagementKeyID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.keyProvider = NewEnvelopeKeyProvider(
+			httpClient,
+			env.WrappedSecretKeyPath,
+			env.SecretKeyPath,
+			logger.GetLogger(),
+		)
+	default:
+		return nil, fmt.Errorf("unknown secret key provider: %s", env.SecretKeyProvider)
+	}
+
+	return s.keyProvider, nil
 }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"postgresus-backend/internal/features/databases"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/util/logger"
 	"time"
 
@@ -233,23 +234,24 @@ func (uc *CheckDatabaseHealthUseCase) sendDbStatusNotification(
 		return
 	}
 
-	messageTitle := ""
-	messageBody := ""
-
+	var event *notifiers_events.NotificationEvent
 	if newHealthStatus == databases.HealthStatusAvailable {
-		messageTitle = fmt.Sprintf("✅ [%s] DB is online", database.Name)
-		messageBody = fmt.Sprintf("✅ [%s] DB is back online", database.Name)
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventDatabaseAvailable,
+			fmt.Sprintf("✅ [%s] DB is online", database.Name),
+			fmt.Sprintf("✅ [%s] DB is back online", database.Name),
+		)
 	} else {
-		messageTitle = fmt.Sprintf("❌ [%s] DB is unavailable", database.Name)
-		messageBody = fmt.Sprintf("❌ [%s] DB is currently unavailable", database.Name)
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventDatabaseUnavailable,
+			fmt.Sprintf("❌ [%s] DB is unavailable", database.Name),
+			fmt.Sprintf("❌ [%s] DB is currently unavailable", database.Name),
+		)
 	}
+	event.Database = database.ToNotificationEventDatabase()
 
 	for _, notifier := range database.Notifiers {
-		uc.healthcheckAttemptSender.SendNotification(
-			&notifier,
-			messageTitle,
-			messageBody,
-		)
+		uc.healthcheckAttemptSender.SendNotification(&notifier, event)
 	}
 
 }
@@ -9,6 +9,7 @@ import (
 	"postgresus-backend/internal/features/databases"
 	healthcheck_config "postgresus-backend/internal/features/healthcheck/config"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/features/storages"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_testing "postgresus-backend/internal/features/users/testing"
@@ -42,7 +43,7 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 
 		// Setup mock notifier sender
 		mockSender := &MockHealthcheckAttemptSender{}
-		mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
+		mockSender.On("SendNotification", mock.Anything, mock.Anything).Return()
 
 		// Setup mock database service
 		mockDatabaseService := &MockDatabaseService{}
@@ -97,8 +98,10 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 			t,
 			"SendNotification",
 			mock.Anything,
-			fmt.Sprintf("❌ [%s] DB is unavailable", database.Name),
-			fmt.Sprintf("❌ [%s] DB is currently unavailable", database.Name),
+			mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+				return event.Type == notifiers_events.NotificationEventDatabaseUnavailable &&
+					event.Title == fmt.Sprintf("❌ [%s] DB is unavailable", database.Name)
+			}),
 		)
 	})
 
@@ -162,8 +165,10 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 				t,
 				"SendNotification",
 				mock.Anything,
-				fmt.Sprintf("❌ [%s] DB is unavailable", database.Name),
-				fmt.Sprintf("❌ [%s] DB is currently unavailable", database.Name),
+				mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+					return event.Type == notifiers_events.NotificationEventDatabaseUnavailable &&
+						event.Title == fmt.Sprintf("❌ [%s] DB is unavailable", database.Name)
+				}),
 			)
 		},
 	)
@@ -183,7 +188,7 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 
 			// Setup mock notifier sender
 			mockSender := &MockHealthcheckAttemptSender{}
-			mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
+			mockSender.On("SendNotification", mock.Anything, mock.Anything).Return()
 
 			// Setup mock database service
 			mockDatabaseService := &MockDatabaseService{}
@@ -241,8 +246,10 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 				t,
 				"SendNotification",
 				mock.Anything,
-				fmt.Sprintf("❌ [%s] DB is unavailable", database.Name),
-				fmt.Sprintf("❌ [%s] DB is currently unavailable", database.Name),
+				mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+					return event.Type == notifiers_events.NotificationEventDatabaseUnavailable &&
+						event.Title == fmt.Sprintf("❌ [%s] DB is unavailable", database.Name)
+				}),
 			)
 		},
 	)
@@ -260,7 +267,7 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 
 		// Setup mock notifier sender
 		mockSender := &MockHealthcheckAttemptSender{}
-		mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
+		mockSender.On("SendNotification", mock.Anything, mock.Anything).Return()
 
 		// Setup mock database service - connection succeeds
 		mockDatabaseService := &MockDatabaseService{}
@@ -314,8 +321,10 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 			t,
 			"SendNotification",
 			mock.Anything,
-			fmt.Sprintf("✅ [%s] DB is online", database.Name),
-			fmt.Sprintf("✅ [%s] DB is back online", database.Name),
+			mock.MatchedBy(func(event *notifiers_events.NotificationEvent) bool {
+				return event.Type == notifiers_events.NotificationEventDatabaseAvailable &&
+					event.Title == fmt.Sprintf("✅ [%s] DB is online", database.Name)
+			}),
 		)
 	})
 
@@ -327,7 +336,7 @@ func Test_CheckDatabaseHealthUseCase(t *testing.T) {
 
 			// Setup mock notifier sender
 			mockSender := &MockHealthcheckAttemptSender{}
-			mockSender.On("SendNotification", mock.Anything, mock.Anything, mock.Anything).Return()
+			mockSender.On("SendNotification", mock.Anything, mock.Anything).Return()
 
 			// Setup mock database service - connection succeeds
 			mockDatabaseService := &MockDatabaseService{}
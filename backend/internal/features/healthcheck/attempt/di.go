@@ -12,7 +12,7 @@ var healthcheckAttemptRepository = &HealthcheckAttemptRepository{}
 var healthcheckAttemptService = &HealthcheckAttemptService{
 	healthcheckAttemptRepository,
 	databases.GetDatabaseService(),
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 }
 
 var checkDatabaseHealthUseCase = &CheckDatabaseHealthUseCase{
@@ -3,6 +3,7 @@ package healthcheck_attempt
 import (
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -14,10 +15,9 @@ type MockHealthcheckAttemptSender struct {
 
 func (m *MockHealthcheckAttemptSender) SendNotification(
 	notifier *notifiers.Notifier,
-	title string,
-	message string,
+	event *notifiers_events.NotificationEvent,
 ) {
-	m.Called(notifier, title, message)
+	m.Called(notifier, event)
 }
 
 type MockDatabaseService struct {
@@ -1,6 +1,7 @@
 package healthcheck_attempt
 
 import (
+	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/storage"
 	"time"
 
@@ -43,6 +44,33 @@ func (r *HealthcheckAttemptRepository) FindLastByDatabaseID(
 	return &attempt, nil
 }
 
+func (r *HealthcheckAttemptRepository) CountByDatabaseIdCreatedBetweenGroupByStatus(
+	databaseID uuid.UUID,
+	from, to time.Time,
+) (map[databases.HealthStatus]int64, error) {
+	var rows []struct {
+		Status databases.HealthStatus
+		Count  int64
+	}
+
+	if err := storage.
+		GetDb().
+		Model(&HealthcheckAttempt{}).
+		Select("status, COUNT(*) AS count").
+		Where("database_id = ? AND created_at >= ? AND created_at < ?", databaseID, from, to).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	countByStatus := make(map[databases.HealthStatus]int64, len(rows))
+	for _, row := range rows {
+		countByStatus[row.Status] = row.Count
+	}
+
+	return countByStatus, nil
+}
+
 func (r *HealthcheckAttemptRepository) DeleteOlderThan(
 	databaseID uuid.UUID,
 	olderThan time.Time,
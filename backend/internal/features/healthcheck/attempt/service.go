@@ -3,6 +3,7 @@ package healthcheck_attempt
 import (
 	"errors"
 	"postgresus-backend/internal/features/databases"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"time"
@@ -13,7 +14,7 @@ import (
 type HealthcheckAttemptService struct {
 	healthcheckAttemptRepository *HealthcheckAttemptRepository
 	databaseService              *databases.DatabaseService
-	workspaceService             *workspaces_services.WorkspaceService
+	authorizationService         *workspaces_services.AuthorizationService
 }
 
 func (s *HealthcheckAttemptService) GetAttemptsByDatabase(
@@ -30,7 +31,12 @@ func (s *HealthcheckAttemptService) GetAttemptsByDatabase(
 		return nil, errors.New("cannot access healthcheck attempts for databases without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, &user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		&user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -43,3 +49,30 @@ func (s *HealthcheckAttemptService) GetAttemptsByDatabase(
 		afterDate,
 	)
 }
+
+// GetUptimePercent returns the share of successful healthchecks of the
+// database in the period, nil when there were no healthchecks. Attempts
+// older than the retention of the healthcheck config are already removed
+func (s *HealthcheckAttemptService) GetUptimePercent(
+	databaseID uuid.UUID,
+	from, to time.Time,
+) (*float64, error) {
+	countByStatus, err := s.healthcheckAttemptRepository.
+		CountByDatabaseIdCreatedBetweenGroupByStatus(databaseID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range countByStatus {
+		total += count
+	}
+
+	if total == 0 {
+		return nil, nil
+	}
+
+	uptimePercent := float64(countByStatus[databases.HealthStatusAvailable]) * 100 / float64(total)
+
+	return &uptimePercent, nil
+}
@@ -11,7 +11,7 @@ var healthcheckConfigRepository = &HealthcheckConfigRepository{}
 var healthcheckConfigService = &HealthcheckConfigService{
 	databases.GetDatabaseService(),
 	healthcheckConfigRepository,
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 	audit_logs.GetAuditLogService(),
 	logger.GetLogger(),
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	"postgresus-backend/internal/features/databases"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 
@@ -15,7 +17,7 @@ import (
 type HealthcheckConfigService struct {
 	databaseService             *databases.DatabaseService
 	healthcheckConfigRepository *HealthcheckConfigRepository
-	workspaceService            *workspaces_services.WorkspaceService
+	authorizationService        *workspaces_services.AuthorizationService
 	auditLogService             *audit_logs.AuditLogService
 	logger                      *slog.Logger
 }
@@ -42,7 +44,12 @@ func (s *HealthcheckConfigService) Save(
 		return errors.New("cannot modify healthcheck config for databases without workspace")
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(*database.WorkspaceID, &user)
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		&user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
 	if err != nil {
 		return err
 	}
@@ -55,6 +62,12 @@ func (s *HealthcheckConfigService) Save(
 
 	healthcheckConfig.DatabaseID = database.ID
 
+	existingConfig, err := s.healthcheckConfigRepository.GetByDatabaseID(database.ID)
+	if err != nil {
+		return err
+	}
+	configBefore := audit_logs.Snapshot(existingConfig)
+
 	err = s.healthcheckConfigRepository.Save(healthcheckConfig)
 	if err != nil {
 		return err
@@ -73,11 +86,16 @@ func (s *HealthcheckConfigService) Save(
 		}
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Healthcheck config updated for database '%s'", database.Name),
-		&user.ID,
-		database.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceHealthcheckConfig,
+		ResourceID:   &database.ID,
+		Message:      fmt.Sprintf("Healthcheck config updated for database '%s'", database.Name),
+		User:         &user,
+		WorkspaceID:  database.WorkspaceID,
+		Before:       configBefore,
+		After:        healthcheckConfig,
+	})
 
 	return nil
 }
@@ -95,7 +113,12 @@ func (s *HealthcheckConfigService) GetByDatabaseID(
 		return nil, errors.New("cannot access healthcheck config for databases without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(*database.WorkspaceID, &user)
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		&user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,25 @@
+package notifiers
+
+import (
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/google/uuid"
+)
+
+// NotifierAlertState is the failure of the database sent by the notifier.
+// It exists until the recovery, failures of the same class are suppressed
+// while it exists
+type NotifierAlertState struct {
+	NotifierID uuid.UUID                   `gorm:"column:notifier_id;type:uuid;primaryKey"`
+	DatabaseID uuid.UUID                   `gorm:"column:database_id;type:uuid;primaryKey"`
+	Class      notifiers_events.EventClass `gorm:"column:class;type:text;primaryKey"`
+
+	SuppressedCount int       `gorm:"column:suppressed_count;not null;default:0"`
+	FailedAt        time.Time `gorm:"column:failed_at;not null"`
+}
+
+func (NotifierAlertState) TableName() string {
+	return "notifier_alert_states"
+}
@@ -0,0 +1,47 @@
+package notifiers
+
+import (
+	"errors"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotifierAlertStateRepository struct{}
+
+func (r *NotifierAlertStateRepository) Save(state *NotifierAlertState) error {
+	return storage.GetDb().Save(state).Error
+}
+
+func (r *NotifierAlertStateRepository) Find(
+	notifierID uuid.UUID,
+	databaseID uuid.UUID,
+	class notifiers_events.EventClass,
+) (*NotifierAlertState, error) {
+	var state NotifierAlertState
+
+	if err := storage.GetDb().
+		Where("notifier_id = ? AND database_id = ? AND class = ?", notifierID, databaseID, class).
+		First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *NotifierAlertStateRepository) Delete(
+	notifierID uuid.UUID,
+	databaseID uuid.UUID,
+	class notifiers_events.EventClass,
+) error {
+	return storage.GetDb().
+		Where("notifier_id = ? AND database_id = ? AND class = ?", notifierID, databaseID, class).
+		Delete(&NotifierAlertState{}).Error
+}
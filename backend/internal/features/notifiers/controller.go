@@ -2,6 +2,7 @@ package notifiers
 
 import (
 	"net/http"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_middleware "postgresus-backend/internal/features/users/middleware"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 
@@ -10,8 +11,8 @@ import (
 )
 
 type NotifierController struct {
-	notifierService  *NotifierService
-	workspaceService *workspaces_services.WorkspaceService
+	notifierService      *NotifierService
+	authorizationService *workspaces_services.AuthorizationService
 }
 
 func (c *NotifierController) RegisterRoutes(router *gin.RouterGroup) {
@@ -21,6 +22,9 @@ func (c *NotifierController) RegisterRoutes(router *gin.RouterGroup) {
 	router.DELETE("/notifiers/:id", c.DeleteNotifier)
 	router.POST("/notifiers/:id/test", c.SendTestNotification)
 	router.POST("/notifiers/direct-test", c.SendTestNotificationDirect)
+	router.GET("/notifiers/:id/deliveries", c.GetNotificationDeliveries)
+	router.POST("/notifiers/deliveries/:deliveryId/resend", c.ResendNotification)
+	router.GET("/notifiers/default-message-templates", c.GetDefaultMessageTemplates)
 }
 
 // SaveNotifier
@@ -253,7 +257,11 @@ func (c *NotifierController) SendTestNotificationDirect(ctx *gin.Context) {
 		return
 	}
 
-	canView, _, err := c.workspaceService.CanUserAccessWorkspace(request.WorkspaceID, user)
+	canView, err := c.authorizationService.HasPermission(
+		request.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageNotifiers,
+	)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -273,3 +281,105 @@ func (c *NotifierController) SendTestNotificationDirect(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "test notification sent successfully"})
 }
+
+// GetNotificationDeliveries
+// @Summary Get notification delivery log
+// @Description Get notifications sent by the notifier with their delivery status, newest first
+// @Tags notifiers
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param id path string true "Notifier ID"
+// @Param limit query int false "Number of deliveries to return"
+// @Param offset query int false "Number of deliveries to skip"
+// @Success 200 {object} GetNotificationDeliveriesResponse
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /notifiers/{id}/deliveries [get]
+func (c *NotifierController) GetNotificationDeliveries(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid notifier ID"})
+		return
+	}
+
+	request := &GetNotificationDeliveriesRequest{}
+	if err := ctx.ShouldBindQuery(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	response, err := c.notifierService.GetNotificationDeliveries(user, id, request)
+	if err != nil {
+		if err.Error() == "insufficient permissions to view notifier in this workspace" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ResendNotification
+// @Summary Resend notification
+// @Description Queue the notification of the delivery again, e.g. after the notifier is fixed
+// @Tags notifiers
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Param deliveryId path string true "Notification delivery ID"
+// @Success 200 {object} NotificationDelivery
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /notifiers/deliveries/{deliveryId}/resend [post]
+func (c *NotifierController) ResendNotification(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	deliveryID, err := uuid.Parse(ctx.Param("deliveryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+
+	delivery, err := c.notifierService.ResendNotification(user, deliveryID)
+	if err != nil {
+		if err.Error() == "insufficient permissions to manage notifier in this workspace" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetDefaultMessageTemplates
+// @Summary Get default message templates
+// @Description Get templates used by notifier types when the notifier has no own template
+// @Tags notifiers
+// @Produce json
+// @Param Authorization header string true "JWT token"
+// @Success 200 {object} map[string]string
+// @Failure 401
+// @Router /notifiers/default-message-templates [get]
+func (c *NotifierController) GetDefaultMessageTemplates(ctx *gin.Context) {
+	if _, ok := users_middleware.GetUserFromContext(ctx); !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, GetDefaultMessageTemplates())
+}
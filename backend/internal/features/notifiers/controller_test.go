@@ -9,6 +9,7 @@ import (
 	audit_logs "postgresus-backend/internal/features/audit_logs"
 	discord_notifier "postgresus-backend/internal/features/notifiers/models/discord"
 	email_notifier "postgresus-backend/internal/features/notifiers/models/email_notifier"
+	incident_notifier "postgresus-backend/internal/features/notifiers/models/incident"
 	slack_notifier "postgresus-backend/internal/features/notifiers/models/slack"
 	teams_notifier "postgresus-backend/internal/features/notifiers/models/teams"
 	telegram_notifier "postgresus-backend/internal/features/notifiers/models/telegram"
@@ -667,6 +668,45 @@ func Test_NotifierSensitiveDataLifecycle_AllTypes(t *testing.T) {
 				assert.Equal(t, "", notifier.TeamsNotifier.WebhookURL)
 			},
 		},
+		{
+			name:         "Incident Notifier",
+			notifierType: NotifierTypeIncident,
+			createNotifier: func(workspaceID uuid.UUID) *Notifier {
+				return &Notifier{
+					WorkspaceID:  workspaceID,
+					Name:         "Test Incident Notifier",
+					NotifierType: NotifierTypeIncident,
+					IncidentNotifier: &incident_notifier.IncidentNotifier{
+						RoutingKey: "original-routing-key",
+						Severity:   incident_notifier.IncidentSeverityCritical,
+					},
+				}
+			},
+			updateNotifier: func(workspaceID uuid.UUID, notifierID uuid.UUID) *Notifier {
+				return &Notifier{
+					ID:           notifierID,
+					WorkspaceID:  workspaceID,
+					Name:         "Updated Incident Notifier",
+					NotifierType: NotifierTypeIncident,
+					IncidentNotifier: &incident_notifier.IncidentNotifier{
+						RoutingKey: "",
+						Severity:   incident_notifier.IncidentSeverityError,
+					},
+				}
+			},
+			verifySensitiveData: func(t *testing.T, notifier *Notifier) {
+				assert.True(
+					t,
+					isEncrypted(notifier.IncidentNotifier.RoutingKey),
+					"RoutingKey should be encrypted in DB",
+				)
+				decrypted := decryptField(t, notifier.ID, notifier.IncidentNotifier.RoutingKey)
+				assert.Equal(t, "original-routing-key", decrypted)
+			},
+			verifyHiddenData: func(t *testing.T, notifier *Notifier) {
+				assert.Equal(t, "", notifier.IncidentNotifier.RoutingKey)
+			},
+		},
 		{
 			name:         "Webhook Notifier",
 			notifierType: NotifierTypeWebhook,
@@ -907,6 +947,29 @@ func Test_CreateNotifier_AllSensitiveFieldsEncryptedInDB(t *testing.T) {
 				assert.Equal(t, "https://outlook.office.com/webhook/test123", decrypted)
 			},
 		},
+		{
+			name: "Incident Notifier - RoutingKey encrypted",
+			createNotifier: func(workspaceID uuid.UUID) *Notifier {
+				return &Notifier{
+					WorkspaceID:  workspaceID,
+					Name:         "Test Incident",
+					NotifierType: NotifierTypeIncident,
+					IncidentNotifier: &incident_notifier.IncidentNotifier{
+						RoutingKey: "test-routing-key",
+						Severity:   incident_notifier.IncidentSeverityCritical,
+					},
+				}
+			},
+			verifySensitiveEncryption: func(t *testing.T, notifier *Notifier) {
+				assert.True(
+					t,
+					isEncrypted(notifier.IncidentNotifier.RoutingKey),
+					"RoutingKey should be encrypted",
+				)
+				decrypted := decryptField(t, notifier.ID, notifier.IncidentNotifier.RoutingKey)
+				assert.Equal(t, "test-routing-key", decrypted)
+			},
+		},
 		{
 			name: "Webhook Notifier - WebhookURL encrypted",
 			createNotifier: func(workspaceID uuid.UUID) *Notifier {
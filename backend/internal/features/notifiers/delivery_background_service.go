@@ -0,0 +1,54 @@
+package notifiers
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const (
+	deliveryCheckInterval   = 5 * time.Second
+	deliveryCleanupInterval = time.Hour
+	digestCheckInterval     = time.Minute
+)
+
+type NotificationDeliveryBackgroundService struct {
+	notifierService *NotifierService
+	logger          *slog.Logger
+
+	lastCleanupAt     time.Time
+	lastDigestCheckAt time.Time
+}
+
+func (s *NotificationDeliveryBackgroundService) Run() {
+	ticker := time.NewTicker(deliveryCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.IsShouldShutdown() {
+			break
+		}
+
+		if err := s.notifierService.SendDueNotifications(); err != nil {
+			s.logger.Error("failed to send notifications", "error", err)
+		}
+
+		if time.Since(s.lastDigestCheckAt) >= digestCheckInterval {
+			s.lastDigestCheckAt = time.Now().UTC()
+
+			if err := s.notifierService.SendQuietHoursDigests(); err != nil {
+				s.logger.Error("failed to send quiet hours digests", "error", err)
+			}
+		}
+
+		if time.Since(s.lastCleanupAt) < deliveryCleanupInterval {
+			continue
+		}
+		s.lastCleanupAt = time.Now().UTC()
+
+		if err := s.notifierService.DeleteOldDeliveries(); err != nil {
+			s.logger.Error("failed to delete old notification deliveries", "error", err)
+		}
+	}
+}
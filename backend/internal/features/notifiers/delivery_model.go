@@ -0,0 +1,92 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationDelivery is stored before the notification is sent, so the
+// notification is not lost when the messenger is unavailable. The worker
+// sends pending deliveries and keeps them as the delivery log
+type NotificationDelivery struct {
+	ID         uuid.UUID `json:"id"         gorm:"column:id;type:uuid;primaryKey"`
+	NotifierID uuid.UUID `json:"notifierId" gorm:"column:notifier_id;type:uuid;not null"`
+
+	Title   string `json:"title"   gorm:"column:title;type:text;not null"`
+	Message string `json:"message" gorm:"column:message;type:text;not null"`
+
+	// Event is rendered on each attempt, so a template fixed after the
+	// failure is used by the retry
+	EventType notifiers_events.NotificationEventType `json:"eventType" gorm:"column:event_type"`
+	Event     *notifiers_events.NotificationEvent    `json:"event"     gorm:"-"`
+	EventJSON string                                 `json:"-"         gorm:"column:event"`
+
+	Status   NotificationDeliveryStatus `json:"status"   gorm:"column:status;type:text;not null"`
+	Attempts int                        `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	// ResponseCode is the HTTP status of the last unsuccessful attempt,
+	// empty for network errors and messengers without HTTP API
+	ResponseCode *int    `json:"responseCode" gorm:"column:response_code"`
+	LastError    *string `json:"lastError"    gorm:"column:last_error;type:text"`
+
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"column:next_attempt_at;not null"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt" gorm:"column:last_attempt_at"`
+	DeliveredAt   *time.Time `json:"deliveredAt"   gorm:"column:delivered_at"`
+	CreatedAt     time.Time  `json:"createdAt"     gorm:"column:created_at"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+func (d *NotificationDelivery) BeforeSave(_ *gorm.DB) error {
+	if d.Event == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+
+	d.EventJSON = string(data)
+
+	return nil
+}
+
+func (d *NotificationDelivery) AfterFind(_ *gorm.DB) error {
+	if d.EventJSON == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(d.EventJSON), &d.Event)
+}
+
+// GetEvent returns the stored event. Deliveries queued before events were
+// stored have only title and message
+func (d *NotificationDelivery) GetEvent() *notifiers_events.NotificationEvent {
+	if d.Event != nil {
+		return d.Event
+	}
+
+	event := notifiers_events.NewNotificationEvent(d.EventType, d.Title, d.Message)
+	event.OccurredAt = d.CreatedAt
+
+	return event
+}
+
+type GetNotificationDeliveriesRequest struct {
+	Limit  int `form:"limit"  json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+type GetNotificationDeliveriesResponse struct {
+	Deliveries []*NotificationDelivery `json:"deliveries"`
+	Total      int64                   `json:"total"`
+	Limit      int                     `json:"limit"`
+	Offset     int                     `json:"offset"`
+}
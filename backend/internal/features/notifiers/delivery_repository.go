@@ -0,0 +1,155 @@
+package notifiers
+
+import (
+	"errors"
+	"time"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationDeliveryRepository struct{}
+
+func (r *NotificationDeliveryRepository) Save(delivery *NotificationDelivery) error {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+
+	return storage.GetDb().Save(delivery).Error
+}
+
+func (r *NotificationDeliveryRepository) FindByID(id uuid.UUID) (*NotificationDelivery, error) {
+	var delivery NotificationDelivery
+
+	if err := storage.GetDb().Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *NotificationDeliveryRepository) FindByNotifierID(
+	notifierID uuid.UUID,
+	limit int,
+	offset int,
+) ([]*NotificationDelivery, error) {
+	var deliveries []*NotificationDelivery
+
+	if err := storage.GetDb().
+		Where("notifier_id = ?", notifierID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *NotificationDeliveryRepository) CountByNotifierID(notifierID uuid.UUID) (int64, error) {
+	var count int64
+
+	if err := storage.GetDb().
+		Model(&NotificationDelivery{}).
+		Where("notifier_id = ?", notifierID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// FindDue returns pending deliveries which next attempt time has come, in
+// the order they were created. Deliveries of the notifier wait while an
+// older one waits for retry, otherwise the recovery could be sent before
+// the failure and the incident opened by the failure would never resolve
+func (r *NotificationDeliveryRepository) FindDue(
+	now time.Time,
+	limit int,
+) ([]*NotificationDelivery, error) {
+	var deliveries []*NotificationDelivery
+
+	if err := storage.GetDb().
+		Where("status = ? AND next_attempt_at <= ?", NotificationDeliveryStatusPending, now).
+		Where(
+			`NOT EXISTS (
+				SELECT 1 FROM notification_deliveries AS older
+				WHERE older.notifier_id = notification_deliveries.notifier_id
+				AND older.status = ?
+				AND older.next_attempt_at > ?
+				AND older.created_at < notification_deliveries.created_at
+			)`,
+			NotificationDeliveryStatusPending,
+			now,
+		).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FindNotifierIDsWithHeld returns notifiers which have events held
+// during quiet hours
+func (r *NotificationDeliveryRepository) FindNotifierIDsWithHeld() ([]uuid.UUID, error) {
+	var notifierIDs []uuid.UUID
+
+	if err := storage.GetDb().
+		Model(&NotificationDelivery{}).
+		Where("status = ?", NotificationDeliveryStatusHeld).
+		Distinct().
+		Pluck("notifier_id", &notifierIDs).Error; err != nil {
+		return nil, err
+	}
+
+	return notifierIDs, nil
+}
+
+func (r *NotificationDeliveryRepository) FindHeldByNotifierID(
+	notifierID uuid.UUID,
+) ([]*NotificationDelivery, error) {
+	var deliveries []*NotificationDelivery
+
+	if err := storage.GetDb().
+		Where("notifier_id = ? AND status = ?", notifierID, NotificationDeliveryStatusHeld).
+		Order("created_at ASC").
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *NotificationDeliveryRepository) UpdateStatusByIDs(
+	ids []uuid.UUID,
+	status NotificationDeliveryStatus,
+) error {
+	return storage.GetDb().
+		Model(&NotificationDelivery{}).
+		Where("id IN ?", ids).
+		Update("status", status).Error
+}
+
+// DeleteFinishedBefore removes finished deliveries from the log, pending
+// and held ones are kept regardless of age
+func (r *NotificationDeliveryRepository) DeleteFinishedBefore(date time.Time) error {
+	return storage.GetDb().
+		Where(
+			"status NOT IN ? AND created_at < ?",
+			[]NotificationDeliveryStatus{
+				NotificationDeliveryStatusPending,
+				NotificationDeliveryStatusHeld,
+			},
+			date,
+		).
+		Delete(&NotificationDelivery{}).Error
+}
@@ -0,0 +1,350 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	webhook_notifier "postgresus-backend/internal/features/notifiers/models/webhook"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_testing "postgresus-backend/internal/features/users/testing"
+	workspaces_testing "postgresus-backend/internal/features/workspaces/testing"
+	test_utils "postgresus-backend/internal/util/testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SendNotification_WhenWebhookFails_RetriesAndLogsDelivery(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	var requestsCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestsCount.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := createWebhookNotifier(t, workspace.ID, server.URL)
+
+	GetNotifierService().SendNotification(notifier, newBackupFailedEvent())
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 1)
+	delivery := deliveries.Deliveries[0]
+	assert.Equal(t, NotificationDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, "Backup failed", delivery.Title)
+
+	GetNotifierService().deliver(delivery)
+
+	delivery, err := notificationDeliveryRepository.FindByID(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, NotificationDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.ResponseCode)
+	assert.Equal(t, http.StatusServiceUnavailable, *delivery.ResponseCode)
+	assert.True(t, delivery.NextAttemptAt.After(time.Now().UTC()))
+
+	GetNotifierService().deliver(delivery)
+
+	delivery, err = notificationDeliveryRepository.FindByID(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, NotificationDeliveryStatusDelivered, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Nil(t, delivery.LastError)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	var resentDelivery NotificationDelivery
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/notifiers/deliveries/%s/resend", delivery.ID),
+		"Bearer "+owner.Token,
+		nil,
+		http.StatusOK,
+		&resentDelivery,
+	)
+	assert.NotEqual(t, delivery.ID, resentDelivery.ID)
+	assert.Equal(t, NotificationDeliveryStatusPending, resentDelivery.Status)
+	assert.Equal(t, delivery.Message, resentDelivery.Message)
+
+	deliveries = getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	assert.Equal(t, int64(2), deliveries.Total)
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+func Test_SendNotification_WebhookReceivesEventPayload(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := createWebhookNotifier(t, workspace.ID, server.URL)
+
+	event := newBackupFailedEvent()
+	errorMessage := "connection refused"
+	event.Error = &errorMessage
+	GetNotifierService().SendNotification(notifier, event)
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 1)
+	assert.Equal(
+		t,
+		notifiers_events.NotificationEventBackupFailed,
+		deliveries.Deliveries[0].EventType,
+	)
+
+	delivery, err := notificationDeliveryRepository.FindByID(deliveries.Deliveries[0].ID)
+	require.NoError(t, err)
+	GetNotifierService().deliver(delivery)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(receivedBody, &payload))
+	assert.Equal(t, float64(1), payload["schemaVersion"])
+	assert.Equal(t, "BACKUP_FAILED", payload["type"])
+	assert.Equal(t, "Backup failed", payload["heading"])
+	assert.Equal(t, "Backup of db failed", payload["message"])
+	assert.Equal(t, errorMessage, payload["error"])
+	assert.Nil(t, payload["database"])
+
+	payloadWorkspace, ok := payload["workspace"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, workspace.ID.String(), payloadWorkspace["id"])
+	assert.Equal(t, workspace.Name, payloadWorkspace["name"])
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+func Test_SendNotification_WhenAllAttemptsFailed_DeliveryFailed(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := createWebhookNotifier(t, workspace.ID, server.URL)
+
+	GetNotifierService().SendNotification(notifier, newBackupFailedEvent())
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 1)
+	delivery := deliveries.Deliveries[0]
+	delivery.Attempts = maxDeliveryAttempts - 1
+
+	GetNotifierService().deliver(delivery)
+
+	delivery, err := notificationDeliveryRepository.FindByID(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, NotificationDeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, maxDeliveryAttempts, delivery.Attempts)
+	assert.NotNil(t, delivery.LastError)
+
+	updatedNotifier, err := notifierRepository.FindByID(notifier.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, updatedNotifier.LastSendError)
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+func Test_NotificationDeliveries_UserNotInWorkspace_CannotAccess(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	outsider := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	notifier := createWebhookNotifier(t, workspace.ID, "https://webhook.site/"+uuid.New().String())
+	GetNotifierService().SendNotification(notifier, newBackupFailedEvent())
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 1)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/notifiers/%s/deliveries", notifier.ID),
+		"Bearer "+outsider.Token,
+		http.StatusForbidden,
+	)
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/notifiers/deliveries/%s/resend", deliveries.Deliveries[0].ID),
+		"Bearer "+outsider.Token,
+		nil,
+		http.StatusForbidden,
+	)
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+func Test_GetDeliveryRetryDelay_DoublesUpToMaxDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, getDeliveryRetryDelay(1))
+	assert.Equal(t, time.Minute, getDeliveryRetryDelay(2))
+	assert.Equal(t, 8*time.Minute, getDeliveryRetryDelay(5))
+	assert.Equal(t, time.Hour, getDeliveryRetryDelay(maxDeliveryAttempts))
+	assert.Equal(t, time.Hour, getDeliveryRetryDelay(100))
+}
+
+func newBackupFailedEvent() *notifiers_events.NotificationEvent {
+	return notifiers_events.NewNotificationEvent(
+		notifiers_events.NotificationEventBackupFailed,
+		"Backup failed",
+		"Backup of db failed",
+	)
+}
+
+func createWebhookNotifier(t *testing.T, workspaceID uuid.UUID, webhookURL string) *Notifier {
+	notifier, err := notifierRepository.Save(&Notifier{
+		WorkspaceID:  workspaceID,
+		Name:         "Test Webhook Notifier " + uuid.New().String(),
+		NotifierType: NotifierTypeWebhook,
+		WebhookNotifier: &webhook_notifier.WebhookNotifier{
+			WebhookURL:    webhookURL,
+			WebhookMethod: webhook_notifier.WebhookMethodPOST,
+		},
+	})
+	require.NoError(t, err)
+
+	return notifier
+}
+
+func getNotificationDeliveries(
+	t *testing.T,
+	router *gin.Engine,
+	notifierID uuid.UUID,
+	token string,
+) *GetNotificationDeliveriesResponse {
+	var response GetNotificationDeliveriesResponse
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/notifiers/%s/deliveries", notifierID),
+		"Bearer "+token,
+		http.StatusOK,
+		&response,
+	)
+
+	return &response
+}
+
+func Test_SendNotification_WhenFailureRepeated_SuppressedUntilRecovery(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	notifier := createWebhookNotifier(t, workspace.ID, "https://webhook.site/"+uuid.New().String())
+	notifier.Rules = &NotificationRules{IsRepeatedFailuresSuppressed: true}
+	notifier, err := notifierRepository.Save(notifier)
+	require.NoError(t, err)
+
+	databaseID := uuid.New()
+	for _, eventType := range []notifiers_events.NotificationEventType{
+		notifiers_events.NotificationEventBackupFailed,
+		notifiers_events.NotificationEventBackupFailed,
+		notifiers_events.NotificationEventBackupFailed,
+		notifiers_events.NotificationEventBackupCompleted,
+		notifiers_events.NotificationEventBackupFailed,
+	} {
+		GetNotifierService().SendNotification(notifier, newDatabaseEvent(eventType, databaseID))
+	}
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 5)
+
+	statuses := make([]NotificationDeliveryStatus, 0, len(deliveries.Deliveries))
+	for i := len(deliveries.Deliveries) - 1; i >= 0; i-- {
+		statuses = append(statuses, deliveries.Deliveries[i].Status)
+	}
+
+	assert.Equal(t, []NotificationDeliveryStatus{
+		NotificationDeliveryStatusPending,
+		NotificationDeliveryStatusSuppressed,
+		NotificationDeliveryStatusSuppressed,
+		NotificationDeliveryStatusPending,
+		NotificationDeliveryStatusPending,
+	}, statuses, "failure after the recovery is sent again")
+
+	recovery := deliveries.Deliveries[1]
+	assert.Equal(t, notifiers_events.NotificationEventBackupCompleted, recovery.EventType)
+	assert.Contains(t, recovery.Message, "2 repeated failures")
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+func Test_SendNotification_DuringQuietHours_HeldAndSentAsDigest(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	now := time.Now().UTC()
+	notifier := createWebhookNotifier(t, workspace.ID, "https://webhook.site/"+uuid.New().String())
+	notifier.Rules = &NotificationRules{
+		IsQuietHoursEnabled: true,
+		QuietHoursStart:     now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:       now.Add(time.Hour).Format("15:04"),
+	}
+	notifier, err := notifierRepository.Save(notifier)
+	require.NoError(t, err)
+
+	GetNotifierService().SendNotification(notifier, newBackupFailedEvent())
+	GetNotifierService().SendNotification(notifier, newBackupFailedEvent())
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 2)
+	for _, delivery := range deliveries.Deliveries {
+		assert.Equal(t, NotificationDeliveryStatusHeld, delivery.Status)
+	}
+
+	require.NoError(t, GetNotifierService().SendQuietHoursDigests())
+	deliveries = getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	assert.Len(t, deliveries.Deliveries, 2, "digest is not sent during quiet hours")
+
+	notifier.Rules.IsQuietHoursEnabled = false
+	_, err = notifierRepository.Save(notifier)
+	require.NoError(t, err)
+
+	require.NoError(t, GetNotifierService().SendQuietHoursDigests())
+
+	deliveries = getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 3)
+
+	digest := deliveries.Deliveries[0]
+	assert.Equal(t, notifiers_events.NotificationEventQuietHoursDigest, digest.EventType)
+	assert.Equal(t, NotificationDeliveryStatusPending, digest.Status)
+	assert.Contains(t, digest.Title, "2 notifications during quiet hours")
+	assert.Equal(t, NotificationDeliveryStatusDigested, deliveries.Deliveries[1].Status)
+	assert.Equal(t, NotificationDeliveryStatusDigested, deliveries.Deliveries[2].Status)
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
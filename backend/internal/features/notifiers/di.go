@@ -1,6 +1,8 @@
 package notifiers
 
 import (
+	"time"
+
 	audit_logs "postgresus-backend/internal/features/audit_logs"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/encryption"
@@ -8,16 +10,28 @@ import (
 )
 
 var notifierRepository = &NotifierRepository{}
+var notificationDeliveryRepository = &NotificationDeliveryRepository{}
+var notifierAlertStateRepository = &NotifierAlertStateRepository{}
 var notifierService = &NotifierService{
 	notifierRepository,
+	notificationDeliveryRepository,
+	notifierAlertStateRepository,
 	logger.GetLogger(),
+	workspaces_services.GetAuthorizationService(),
 	workspaces_services.GetWorkspaceService(),
 	audit_logs.GetAuditLogService(),
 	encryption.GetFieldEncryptor(),
 }
 var notifierController = &NotifierController{
 	notifierService,
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
+}
+
+var notificationDeliveryBackgroundService = &NotificationDeliveryBackgroundService{
+	notifierService,
+	logger.GetLogger(),
+	time.Time{},
+	time.Time{},
 }
 
 func GetNotifierController() *NotifierController {
@@ -31,6 +45,11 @@ func GetNotifierService() *NotifierService {
 func GetNotifierRepository() *NotifierRepository {
 	return notifierRepository
 }
+
+func GetNotificationDeliveryBackgroundService() *NotificationDeliveryBackgroundService {
+	return notificationDeliveryBackgroundService
+}
+
 func SetupDependencies() {
 	workspaces_services.GetWorkspaceService().AddWorkspaceDeletionListener(notifierService)
 }
@@ -9,4 +9,22 @@ const (
 	NotifierTypeSlack    NotifierType = "SLACK"
 	NotifierTypeDiscord  NotifierType = "DISCORD"
 	NotifierTypeTeams    NotifierType = "TEAMS"
+	NotifierTypeIncident NotifierType = "INCIDENT"
+)
+
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryStatusPending   NotificationDeliveryStatus = "PENDING"
+	NotificationDeliveryStatusDelivered NotificationDeliveryStatus = "DELIVERED"
+	// FAILED is final: all attempts are used, the notification is sent
+	// again only by resend
+	NotificationDeliveryStatusFailed NotificationDeliveryStatus = "FAILED"
+	// HELD is the event of quiet hours, it is sent in the digest when
+	// quiet hours end and becomes DIGESTED
+	NotificationDeliveryStatusHeld     NotificationDeliveryStatus = "HELD"
+	NotificationDeliveryStatusDigested NotificationDeliveryStatus = "DIGESTED"
+	// SUPPRESSED is the failure repeated before the recovery, it is kept
+	// in the log only
+	NotificationDeliveryStatusSuppressed NotificationDeliveryStatus = "SUPPRESSED"
 )
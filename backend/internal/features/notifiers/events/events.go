@@ -0,0 +1,192 @@
+package notifiers_events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationEventType string
+
+const (
+	NotificationEventBackupCompleted          NotificationEventType = "BACKUP_COMPLETED"
+	NotificationEventBackupFailed             NotificationEventType = "BACKUP_FAILED"
+	NotificationEventBackupCorrupted          NotificationEventType = "BACKUP_CORRUPTED"
+	NotificationEventBackupVerificationFailed NotificationEventType = "BACKUP_VERIFICATION_FAILED"
+	NotificationEventRestoreCompleted         NotificationEventType = "RESTORE_COMPLETED"
+	NotificationEventRestoreFailed            NotificationEventType = "RESTORE_FAILED"
+	NotificationEventDatabaseUnavailable      NotificationEventType = "DATABASE_UNAVAILABLE"
+	NotificationEventDatabaseAvailable        NotificationEventType = "DATABASE_AVAILABLE"
+	NotificationEventApprovalRequested        NotificationEventType = "APPROVAL_REQUESTED"
+	NotificationEventApprovalDecided          NotificationEventType = "APPROVAL_DECIDED"
+	NotificationEventQuietHoursDigest         NotificationEventType = "QUIET_HOURS_DIGEST"
+	NotificationEventWorkspaceReport          NotificationEventType = "WORKSPACE_REPORT"
+	NotificationEventTest                     NotificationEventType = "TEST"
+)
+
+// NotificationEvent is passed to notifiers and rendered by message
+// templates. Title and Message are human readable summary of the event,
+// they are used by default templates and by webhooks created before the
+// event was introduced. Only fields related to the event type are set
+type NotificationEvent struct {
+	Type    NotificationEventType `json:"type"`
+	Title   string                `json:"title"`
+	Message string                `json:"message"`
+
+	Workspace *EventWorkspace `json:"workspace,omitempty"`
+	Database  *EventDatabase  `json:"database,omitempty"`
+	Backup    *EventBackup    `json:"backup,omitempty"`
+	Restore   *EventRestore   `json:"restore,omitempty"`
+	Storage   *EventStorage   `json:"storage,omitempty"`
+	Error     *string         `json:"error,omitempty"`
+	Report    *EventReport    `json:"report,omitempty"`
+
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type EventWorkspace struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type EventDatabase struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Type string    `json:"type"`
+	Tags []string  `json:"tags,omitempty"`
+}
+
+type EventBackup struct {
+	ID         uuid.UUID `json:"id"`
+	SizeMb     float64   `json:"sizeMb"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type EventRestore struct {
+	ID         uuid.UUID `json:"id"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+type EventStorage struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Type string    `json:"type"`
+}
+
+// EventReport is the summary of the workspace for the period, it is sent
+// by scheduled workspace reports
+type EventReport struct {
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+
+	Databases []*EventReportDatabase `json:"databases"`
+	Storages  []*EventReportStorage  `json:"storages"`
+}
+
+type EventReportDatabase struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Type string    `json:"type"`
+
+	// LastBackupAt is the time of the last successful backup, it may be
+	// before the period
+	LastBackupAt          *time.Time `json:"lastBackupAt"`
+	CompletedBackupsCount int64      `json:"completedBackupsCount"`
+	FailedBackupsCount    int64      `json:"failedBackupsCount"`
+	LastBackupSizeMb      float64    `json:"lastBackupSizeMb"`
+
+	// SizeGrowthPercent compares the last backup of the period with the
+	// last backup of the previous period, nil when one of them is missing
+	SizeGrowthPercent *float64 `json:"sizeGrowthPercent"`
+
+	// IsOverdue is set when backups are enabled and there is no successful
+	// backup within the backup interval
+	IsOverdue bool `json:"isOverdue"`
+
+	// UptimePercent is the share of successful healthchecks in the period,
+	// nil when healthchecks were not made
+	UptimePercent *float64 `json:"uptimePercent"`
+}
+
+type EventReportStorage struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Type   string    `json:"type"`
+	UsedMb float64   `json:"usedMb"`
+}
+
+func NewNotificationEvent(
+	eventType NotificationEventType,
+	title string,
+	message string,
+) *NotificationEvent {
+	return &NotificationEvent{
+		Type:       eventType,
+		Title:      title,
+		Message:    message,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// NewSampleEvent returns the event with all fields set. It is used to
+// validate templates, so a template is checked against every field
+func NewSampleEvent() *NotificationEvent {
+	errorMessage := "pg_dump: error: connection to server failed"
+	now := time.Now().UTC()
+	sizeGrowthPercent := 12.5
+	uptimePercent := 99.9
+
+	return &NotificationEvent{
+		Type:      NotificationEventBackupFailed,
+		Title:     "❌ Backup failed for database \"Sample\" (workspace \"Sample\")",
+		Message:   errorMessage,
+		Workspace: &EventWorkspace{ID: uuid.New(), Name: "Sample"},
+		Database: &EventDatabase{
+			ID:   uuid.New(),
+			Name: "Sample",
+			Type: "POSTGRES",
+			Tags: []string{"production"},
+		},
+		Backup:  &EventBackup{ID: uuid.New(), SizeMb: 512, DurationMs: 61000, CreatedAt: now},
+		Restore: &EventRestore{ID: uuid.New(), DurationMs: 61000},
+		Storage: &EventStorage{ID: uuid.New(), Name: "Sample", Type: "LOCAL"},
+		Error:   &errorMessage,
+		Report: &EventReport{
+			Period:      "WEEKLY",
+			PeriodStart: now.AddDate(0, 0, -7),
+			PeriodEnd:   now,
+			Databases: []*EventReportDatabase{
+				{
+					ID:                    uuid.New(),
+					Name:                  "Sample",
+					Type:                  "POSTGRES",
+					LastBackupAt:          &now,
+					CompletedBackupsCount: 7,
+					FailedBackupsCount:    1,
+					LastBackupSizeMb:      512,
+					SizeGrowthPercent:     &sizeGrowthPercent,
+					UptimePercent:         &uptimePercent,
+				},
+			},
+			Storages: []*EventReportStorage{
+				{ID: uuid.New(), Name: "Sample", Type: "LOCAL", UsedMb: 3584},
+			},
+		},
+		OccurredAt: now,
+	}
+}
+
+// NewTestEvent is sent by the test of the notifier. It has sample fields,
+// so the template of the notifier can be checked by the test message
+func NewTestEvent() *NotificationEvent {
+	event := NewSampleEvent()
+	event.Type = NotificationEventTest
+	event.Title = "Test message"
+	event.Message = "This is a test message"
+	event.Error = nil
+	event.Report = nil
+
+	return event
+}
@@ -0,0 +1,89 @@
+package notifiers_events
+
+type EventSeverity string
+
+const (
+	EventSeverityInfo     EventSeverity = "INFO"
+	EventSeverityWarning  EventSeverity = "WARNING"
+	EventSeverityCritical EventSeverity = "CRITICAL"
+)
+
+// EventClass groups the failure with the event of recovery, so the
+// recovery closes the failure of the same database
+type EventClass string
+
+const (
+	EventClassBackup          EventClass = "backup"
+	EventClassAvailability    EventClass = "availability"
+	EventClassBackupIntegrity EventClass = "backup_integrity"
+)
+
+type EventState struct {
+	Class     EventClass
+	IsFailure bool
+}
+
+var eventSeverities = map[NotificationEventType]EventSeverity{
+	NotificationEventBackupFailed:             EventSeverityCritical,
+	NotificationEventBackupCorrupted:          EventSeverityCritical,
+	NotificationEventBackupVerificationFailed: EventSeverityCritical,
+	NotificationEventDatabaseUnavailable:      EventSeverityCritical,
+	NotificationEventRestoreFailed:            EventSeverityWarning,
+	NotificationEventApprovalRequested:        EventSeverityWarning,
+}
+
+var eventStates = map[NotificationEventType]EventState{
+	NotificationEventBackupFailed:             {EventClassBackup, true},
+	NotificationEventBackupCompleted:          {EventClassBackup, false},
+	NotificationEventDatabaseUnavailable:      {EventClassAvailability, true},
+	NotificationEventDatabaseAvailable:        {EventClassAvailability, false},
+	NotificationEventBackupCorrupted:          {EventClassBackupIntegrity, true},
+	NotificationEventBackupVerificationFailed: {EventClassBackupIntegrity, true},
+}
+
+// GetSeverity returns INFO for events which do not need attention
+func (e *NotificationEvent) GetSeverity() EventSeverity {
+	if severity, ok := eventSeverities[e.Type]; ok {
+		return severity
+	}
+
+	return EventSeverityInfo
+}
+
+// GetState tells whether the event is the failure or the recovery of the
+// database, other events do not change the state
+func (e *NotificationEvent) GetState() (EventState, bool) {
+	state, ok := eventStates[e.Type]
+	return state, ok
+}
+
+// IsRecoverable tells whether the class has the event of recovery. Failures
+// of other classes stay open until they are resolved by hand
+func (c EventClass) IsRecoverable() bool {
+	return c == EventClassBackup || c == EventClassAvailability
+}
+
+func (s EventSeverity) IsValid() bool {
+	switch s {
+	case EventSeverityInfo, EventSeverityWarning, EventSeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAtLeast compares severities, INFO is the lowest
+func (s EventSeverity) IsAtLeast(minSeverity EventSeverity) bool {
+	return s.getLevel() >= minSeverity.getLevel()
+}
+
+func (s EventSeverity) getLevel() int {
+	switch s {
+	case EventSeverityCritical:
+		return 2
+	case EventSeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,171 @@
+package notifiers_events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	html_template "html/template"
+	"log/slog"
+	text_template "text/template"
+	"time"
+)
+
+type TemplateFormat string
+
+const (
+	TemplateFormatText TemplateFormat = "TEXT"
+	TemplateFormatHTML TemplateFormat = "HTML"
+	TemplateFormatJSON TemplateFormat = "JSON"
+)
+
+const maxTemplateLength = 20000
+
+var templateFuncs = map[string]any{
+	"json":           toJSON,
+	"escapeJSON":     EscapeJSON,
+	"formatSize":     FormatSize,
+	"formatDuration": FormatDuration,
+	"formatTime":     FormatTime,
+	"formatPercent":  FormatPercent,
+	"truncate":       truncate,
+}
+
+// RenderTemplate renders the custom template of the notifier. When it is
+// empty or fails on the event, the default template of the channel is
+// used, so a mistake in the template does not lose the notification
+func RenderTemplate(
+	logger *slog.Logger,
+	format TemplateFormat,
+	customTemplate string,
+	defaultTemplate string,
+	event *NotificationEvent,
+) (string, error) {
+	if customTemplate != "" {
+		result, err := render(format, customTemplate, event)
+		if err == nil {
+			return result, nil
+		}
+
+		logger.Warn(
+			"Failed to render message template, default template is used",
+			"eventType",
+			event.Type,
+			"error",
+			err,
+		)
+	}
+
+	return render(format, defaultTemplate, event)
+}
+
+// ValidateTemplate renders the template with the sample event, so unknown
+// fields are reported on save instead of on the first notification
+func ValidateTemplate(format TemplateFormat, templateText string) error {
+	if len(templateText) > maxTemplateLength {
+		return fmt.Errorf("message template is longer than %d characters", maxTemplateLength)
+	}
+
+	if _, err := render(format, templateText, NewSampleEvent()); err != nil {
+		return fmt.Errorf("invalid message template: %w", err)
+	}
+
+	return nil
+}
+
+func render(
+	format TemplateFormat,
+	templateText string,
+	event *NotificationEvent,
+) (string, error) {
+	var buffer bytes.Buffer
+
+	// html/template escapes values, text/template keeps them as is for
+	// messengers with their own markup
+	if format == TemplateFormatHTML {
+		tmpl, err := html_template.New("message").Funcs(templateFuncs).Parse(templateText)
+		if err != nil {
+			return "", err
+		}
+
+		if err := tmpl.Execute(&buffer, event); err != nil {
+			return "", err
+		}
+	} else {
+		tmpl, err := text_template.New("message").Funcs(templateFuncs).Parse(templateText)
+		if err != nil {
+			return "", err
+		}
+
+		if err := tmpl.Execute(&buffer, event); err != nil {
+			return "", err
+		}
+	}
+
+	if format == TemplateFormatJSON && !json.Valid(buffer.Bytes()) {
+		return "", errors.New("template result is not valid JSON")
+	}
+
+	return buffer.String(), nil
+}
+
+func toJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// EscapeJSON escapes the value to be placed inside JSON string quotes
+func EscapeJSON(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data[1 : len(data)-1])
+}
+
+func FormatSize(sizeMb float64) string {
+	if sizeMb < 1024 {
+		return fmt.Sprintf("%.2f MB", sizeMb)
+	}
+
+	return fmt.Sprintf("%.2f GB", sizeMb/1024)
+}
+
+// FormatDuration formats milliseconds as "0m 0s"
+func FormatDuration(durationMs int64) string {
+	minutes := durationMs / (1000 * 60)
+	seconds := (durationMs % (1000 * 60)) / 1000
+
+	return fmt.Sprintf("%dm %ds", minutes, seconds)
+}
+
+func FormatTime(value time.Time) string {
+	return value.Format("2006-01-02 15:04 MST")
+}
+
+// FormatPercent takes the pointer as report percents are missing when
+// there is nothing to compare with, such value is shown as "n/a"
+func FormatPercent(percent *float64) string {
+	if percent == nil {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("%.1f%%", *percent)
+}
+
+// Truncate cuts the text to the length in characters, messengers reject
+// messages above their limits
+func Truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length])
+}
+
+// truncate has arguments in the pipeline order: {{.Message | truncate 100}}
+func truncate(length int, text string) string {
+	return Truncate(text, length)
+}
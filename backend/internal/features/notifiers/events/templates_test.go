@@ -0,0 +1,71 @@
+package notifiers_events
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderTemplate_WhenCustomTemplateFails_DefaultTemplateUsed(t *testing.T) {
+	event := NewNotificationEvent(NotificationEventBackupCompleted, "Backup completed", "")
+
+	result, err := RenderTemplate(
+		slog.Default(),
+		TemplateFormatText,
+		"{{.Backup.SizeMb}}",
+		"{{.Title}}",
+		event,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "Backup completed", result)
+}
+
+func Test_RenderTemplate_TemplateFuncsApplied(t *testing.T) {
+	event := NewSampleEvent()
+	event.Title = `Backup "db" failed`
+
+	result, err := RenderTemplate(
+		slog.Default(),
+		TemplateFormatJSON,
+		`{"title": {{json .Title}}, "size": {{json (formatSize .Backup.SizeMb)}}, `+
+			`"duration": {{json (formatDuration .Backup.DurationMs)}}, `+
+			`"short": "{{.Title | truncate 6 | escapeJSON}}"}`,
+		"",
+		event,
+	)
+	assert.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{"title": "Backup \"db\" failed", "size": "512.00 MB", `+
+			`"duration": "1m 1s", "short": "Backup"}`,
+		result,
+	)
+}
+
+func Test_ValidateTemplate_WhenJSONExpected_RejectsInvalidJSON(t *testing.T) {
+	assert.NoError(t, ValidateTemplate(TemplateFormatJSON, `{"title": {{json .Title}}}`))
+	assert.Error(t, ValidateTemplate(TemplateFormatJSON, `{"title": {{.Title}}}`))
+	assert.NoError(t, ValidateTemplate(TemplateFormatText, `{"title": {{.Title}}}`))
+}
+
+func Test_RenderTemplate_WhenHTMLFormat_ValuesEscaped(t *testing.T) {
+	event := NewNotificationEvent(NotificationEventBackupFailed, "<b>db</b>", "")
+
+	result, err := RenderTemplate(
+		slog.Default(),
+		TemplateFormatHTML,
+		"",
+		"<p>{{.Title}}</p>",
+		event,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;db&lt;/b&gt;</p>", result)
+}
+
+func Test_FormatPercent_MissingValueShownAsNotAvailable(t *testing.T) {
+	growthPercent := -12.34
+
+	assert.Equal(t, "-12.3%", FormatPercent(&growthPercent))
+	assert.Equal(t, "n/a", FormatPercent(nil))
+}
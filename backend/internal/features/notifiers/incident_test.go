@@ -0,0 +1,242 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	incident_notifier "postgresus-backend/internal/features/notifiers/models/incident"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_testing "postgresus-backend/internal/features/users/testing"
+	workspaces_testing "postgresus-backend/internal/features/workspaces/testing"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SendIncidentNotification_BackupSucceededAfterFailure_IncidentResolved(t *testing.T) {
+	server, getRequests := createIncidentStandIn(t)
+
+	notifier := createIncidentNotifier(server.URL)
+	databaseID := uuid.New()
+
+	encryptor := encryption.GetFieldEncryptor()
+
+	for _, eventType := range []notifiers_events.NotificationEventType{
+		notifiers_events.NotificationEventBackupFailed,
+		notifiers_events.NotificationEventBackupCompleted,
+		notifiers_events.NotificationEventRestoreFailed,
+	} {
+		event := newDatabaseEvent(eventType, databaseID)
+		require.NoError(t, notifier.Send(encryptor, logger.GetLogger(), event))
+	}
+
+	requests := getRequests()
+	require.Len(t, requests, 2, "restore failure should not open incident")
+
+	trigger := requests[0]
+	assert.Equal(t, "trigger", trigger["event_action"])
+	assert.Equal(t, "test-routing-key", trigger["routing_key"])
+	assert.Equal(t, "postgresus-"+databaseID.String()+"-backup", trigger["dedup_key"])
+
+	payload := trigger["payload"].(map[string]any)
+	assert.Equal(t, "Backup failed", payload["summary"])
+	assert.Equal(t, "critical", payload["severity"])
+	assert.Equal(t, "app", payload["source"])
+	assert.Equal(t, "backup", payload["class"])
+
+	resolve := requests[1]
+	assert.Equal(t, "resolve", resolve["event_action"])
+	assert.Equal(t, trigger["dedup_key"], resolve["dedup_key"])
+	assert.Nil(t, resolve["payload"])
+}
+
+func Test_SendIncidentNotification_DatabaseAvailableAgain_IncidentResolved(t *testing.T) {
+	server, getRequests := createIncidentStandIn(t)
+
+	notifier := createIncidentNotifier(server.URL)
+	databaseID := uuid.New()
+
+	encryptor := encryption.GetFieldEncryptor()
+
+	for _, eventType := range []notifiers_events.NotificationEventType{
+		notifiers_events.NotificationEventDatabaseUnavailable,
+		notifiers_events.NotificationEventDatabaseAvailable,
+	} {
+		event := newDatabaseEvent(eventType, databaseID)
+		require.NoError(t, notifier.Send(encryptor, logger.GetLogger(), event))
+	}
+
+	requests := getRequests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "trigger", requests[0]["event_action"])
+	assert.Equal(t, "resolve", requests[1]["event_action"])
+	assert.Equal(
+		t,
+		"postgresus-"+databaseID.String()+"-availability",
+		requests[0]["dedup_key"],
+	)
+	assert.Equal(t, requests[0]["dedup_key"], requests[1]["dedup_key"])
+}
+
+func Test_SendIncidentNotification_WhenEventsAPIRejects_ErrorReturned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"invalid event"}`))
+	}))
+	defer server.Close()
+
+	notifier := createIncidentNotifier(server.URL)
+	event := newDatabaseEvent(notifiers_events.NotificationEventBackupFailed, uuid.New())
+
+	err := notifier.Send(encryption.GetFieldEncryptor(), logger.GetLogger(), event)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid event")
+	require.NotNil(t, notifier.LastSendError)
+}
+
+func Test_SendDueNotifications_WhenTriggerFailed_ResolveSentAfterTriggerRetry(t *testing.T) {
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	router := createRouter()
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	var mu sync.Mutex
+	var actions []string
+	var requestsCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestsCount.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var request map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		mu.Lock()
+		actions = append(actions, request["event_action"].(string))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier, err := notifierRepository.Save(&Notifier{
+		WorkspaceID:  workspace.ID,
+		Name:         "Test Incident Notifier " + uuid.New().String(),
+		NotifierType: NotifierTypeIncident,
+		IncidentNotifier: &incident_notifier.IncidentNotifier{
+			EventsURL:  server.URL,
+			RoutingKey: "test-routing-key",
+			Severity:   incident_notifier.IncidentSeverityCritical,
+		},
+	})
+	require.NoError(t, err)
+
+	databaseID := uuid.New()
+	service := GetNotifierService()
+
+	service.SendNotification(
+		notifier,
+		newDatabaseEvent(notifiers_events.NotificationEventBackupFailed, databaseID),
+	)
+	require.NoError(t, service.SendDueNotifications())
+
+	service.SendNotification(
+		notifier,
+		newDatabaseEvent(notifiers_events.NotificationEventBackupCompleted, databaseID),
+	)
+	require.NoError(t, service.SendDueNotifications())
+
+	// resolve waits for the trigger retry
+	assert.Equal(t, int32(1), requestsCount.Load())
+
+	deliveries := getNotificationDeliveries(t, router, notifier.ID, owner.Token)
+	require.Len(t, deliveries.Deliveries, 2)
+
+	for _, delivery := range deliveries.Deliveries {
+		assert.Equal(t, NotificationDeliveryStatusPending, delivery.Status)
+
+		if delivery.Attempts > 0 {
+			delivery.NextAttemptAt = time.Now().UTC()
+			require.NoError(t, notificationDeliveryRepository.Save(delivery))
+		}
+	}
+
+	require.NoError(t, service.SendDueNotifications())
+
+	mu.Lock()
+	assert.Equal(t, []string{"trigger", "resolve"}, actions)
+	mu.Unlock()
+
+	deleteNotifier(t, router, notifier.ID, workspace.ID, owner.Token)
+	workspaces_testing.RemoveTestWorkspace(workspace, router)
+}
+
+// createIncidentStandIn replaces Events API, it accepts events as the real
+// API does and records them for assertions
+func createIncidentStandIn(t *testing.T) (*httptest.Server, func() []map[string]any) {
+	var mu sync.Mutex
+	var requests []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"success","message":"Event processed"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]map[string]any{}, requests...)
+	}
+}
+
+func createIncidentNotifier(eventsURL string) *Notifier {
+	notifierID := uuid.New()
+
+	return &Notifier{
+		ID:           notifierID,
+		Name:         "On-call",
+		NotifierType: NotifierTypeIncident,
+		IncidentNotifier: &incident_notifier.IncidentNotifier{
+			NotifierID: notifierID,
+			EventsURL:  eventsURL,
+			RoutingKey: "test-routing-key",
+			Severity:   incident_notifier.IncidentSeverityCritical,
+		},
+	}
+}
+
+func newDatabaseEvent(
+	eventType notifiers_events.NotificationEventType,
+	databaseID uuid.UUID,
+) *notifiers_events.NotificationEvent {
+	event := notifiers_events.NewNotificationEvent(eventType, "Backup failed", "connection refused")
+	event.Database = &notifiers_events.EventDatabase{
+		ID:   databaseID,
+		Name: "app",
+		Type: "POSTGRES",
+	}
+
+	return event
+}
@@ -2,6 +2,7 @@ package notifiers
 
 import (
 	"log/slog"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/util/encryption"
 )
 
@@ -9,8 +10,8 @@ type NotificationSender interface {
 	Send(
 		encryptor encryption.FieldEncryptor,
 		logger *slog.Logger,
-		heading string,
-		message string,
+		event *notifiers_events.NotificationEvent,
+		messageTemplate string,
 	) error
 
 	Validate(encryptor encryption.FieldEncryptor) error
@@ -1,10 +1,13 @@
 package notifiers
 
 import (
+	"encoding/json"
 	"errors"
 	"log/slog"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	discord_notifier "postgresus-backend/internal/features/notifiers/models/discord"
 	"postgresus-backend/internal/features/notifiers/models/email_notifier"
+	incident_notifier "postgresus-backend/internal/features/notifiers/models/incident"
 	slack_notifier "postgresus-backend/internal/features/notifiers/models/slack"
 	teams_notifier "postgresus-backend/internal/features/notifiers/models/teams"
 	telegram_notifier "postgresus-backend/internal/features/notifiers/models/telegram"
@@ -12,6 +15,7 @@ import (
 	"postgresus-backend/internal/util/encryption"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Notifier struct {
@@ -21,6 +25,14 @@ type Notifier struct {
 	NotifierType  NotifierType `json:"notifierType"  gorm:"column:notifier_type;not null;type:varchar(50)"`
 	LastSendError *string      `json:"lastSendError" gorm:"column:last_send_error;type:text"`
 
+	// MessageTemplate is Go template rendered with the notification event,
+	// the default template of the channel is used when it is empty
+	MessageTemplate *string `json:"messageTemplate" gorm:"column:message_template;type:text"`
+
+	// Rules are empty for notifiers which send all events at once
+	Rules     *NotificationRules `json:"rules" gorm:"-"`
+	RulesJSON *string            `json:"-"     gorm:"column:rules;type:text"`
+
 	// specific notifier
 	TelegramNotifier *telegram_notifier.TelegramNotifier `json:"telegramNotifier"        gorm:"foreignKey:NotifierID"`
 	EmailNotifier    *email_notifier.EmailNotifier       `json:"emailNotifier"           gorm:"foreignKey:NotifierID"`
@@ -28,27 +40,70 @@ type Notifier struct {
 	SlackNotifier    *slack_notifier.SlackNotifier       `json:"slackNotifier"           gorm:"foreignKey:NotifierID"`
 	DiscordNotifier  *discord_notifier.DiscordNotifier   `json:"discordNotifier"         gorm:"foreignKey:NotifierID"`
 	TeamsNotifier    *teams_notifier.TeamsNotifier       `json:"teamsNotifier,omitempty" gorm:"foreignKey:NotifierID;constraint:OnDelete:CASCADE"`
+	IncidentNotifier *incident_notifier.IncidentNotifier `json:"incidentNotifier"        gorm:"foreignKey:NotifierID"`
 }
 
 func (n *Notifier) TableName() string {
 	return "notifiers"
 }
 
+func (n *Notifier) BeforeSave(_ *gorm.DB) error {
+	if n.Rules == nil {
+		n.RulesJSON = nil
+		return nil
+	}
+
+	data, err := json.Marshal(n.Rules)
+	if err != nil {
+		return err
+	}
+
+	rulesJSON := string(data)
+	n.RulesJSON = &rulesJSON
+
+	return nil
+}
+
+func (n *Notifier) AfterFind(_ *gorm.DB) error {
+	if n.RulesJSON == nil || *n.RulesJSON == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(*n.RulesJSON), &n.Rules)
+}
+
 func (n *Notifier) Validate(encryptor encryption.FieldEncryptor) error {
 	if n.Name == "" {
 		return errors.New("name is required")
 	}
 
+	if n.Rules != nil {
+		if err := n.Rules.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if n.MessageTemplate != nil && *n.MessageTemplate != "" {
+		err := notifiers_events.ValidateTemplate(n.getTemplateFormat(), *n.MessageTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
 	return n.getSpecificNotifier().Validate(encryptor)
 }
 
 func (n *Notifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading string,
-	message string,
+	event *notifiers_events.NotificationEvent,
 ) error {
-	err := n.getSpecificNotifier().Send(encryptor, logger, heading, message)
+	messageTemplate := ""
+	if n.MessageTemplate != nil {
+		messageTemplate = *n.MessageTemplate
+	}
+
+	err := n.getSpecificNotifier().Send(encryptor, logger, event, messageTemplate)
 
 	if err != nil {
 		lastSendError := err.Error()
@@ -71,6 +126,8 @@ func (n *Notifier) EncryptSensitiveData(encryptor encryption.FieldEncryptor) err
 func (n *Notifier) Update(incoming *Notifier) {
 	n.Name = incoming.Name
 	n.NotifierType = incoming.NotifierType
+	n.MessageTemplate = incoming.MessageTemplate
+	n.Rules = incoming.Rules
 
 	switch n.NotifierType {
 	case NotifierTypeTelegram:
@@ -97,6 +154,10 @@ func (n *Notifier) Update(incoming *Notifier) {
 		if n.TeamsNotifier != nil && incoming.TeamsNotifier != nil {
 			n.TeamsNotifier.Update(incoming.TeamsNotifier)
 		}
+	case NotifierTypeIncident:
+		if n.IncidentNotifier != nil && incoming.IncidentNotifier != nil {
+			n.IncidentNotifier.Update(incoming.IncidentNotifier)
+		}
 	}
 }
 
@@ -114,7 +175,36 @@ func (n *Notifier) getSpecificNotifier() NotificationSender {
 		return n.DiscordNotifier
 	case NotifierTypeTeams:
 		return n.TeamsNotifier
+	case NotifierTypeIncident:
+		return n.IncidentNotifier
 	default:
 		panic("unknown notifier type: " + string(n.NotifierType))
 	}
 }
+
+// getTemplateFormat returns the format the channel expects from the
+// template, JSON is checked on save as the channel rejects invalid one
+func (n *Notifier) getTemplateFormat() notifiers_events.TemplateFormat {
+	switch n.NotifierType {
+	case NotifierTypeEmail:
+		return notifiers_events.TemplateFormatHTML
+	case NotifierTypeSlack, NotifierTypeTeams:
+		return notifiers_events.TemplateFormatJSON
+	default:
+		return notifiers_events.TemplateFormatText
+	}
+}
+
+// GetDefaultMessageTemplates returns templates used by channels when the
+// notifier has no own template, they are the starting point for editing
+func GetDefaultMessageTemplates() map[NotifierType]string {
+	return map[NotifierType]string{
+		NotifierTypeTelegram: telegram_notifier.DefaultMessageTemplate,
+		NotifierTypeEmail:    email_notifier.DefaultMessageTemplate,
+		NotifierTypeWebhook:  webhook_notifier.DefaultMessageTemplate,
+		NotifierTypeSlack:    slack_notifier.DefaultMessageTemplate,
+		NotifierTypeDiscord:  discord_notifier.DefaultMessageTemplate,
+		NotifierTypeTeams:    teams_notifier.DefaultMessageTemplate,
+		NotifierTypeIncident: incident_notifier.DefaultMessageTemplate,
+	}
+}
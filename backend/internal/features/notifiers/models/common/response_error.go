@@ -0,0 +1,18 @@
+package common
+
+import "fmt"
+
+// ResponseError is returned when the messenger responds with unsuccessful
+// status, so the status is recorded in the delivery log
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func NewResponseError(statusCode int, format string, args ...any) *ResponseError {
+	return &ResponseError{statusCode, fmt.Sprintf(format, args...)}
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
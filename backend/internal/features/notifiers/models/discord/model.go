@@ -8,11 +8,22 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
 	"postgresus-backend/internal/util/encryption"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// DefaultMessageTemplate is rendered to the message with Discord markdown
+	DefaultMessageTemplate = `**{{.Title}}**{{if .Message}}
+
+{{.Message}}{{end}}`
+
+	maxMessageLength = 2000
+)
+
 type DiscordNotifier struct {
 	NotifierID        uuid.UUID `json:"notifierId"        gorm:"primaryKey;column:notifier_id"`
 	ChannelWebhookURL string    `json:"channelWebhookUrl" gorm:"not null;column:channel_webhook_url"`
@@ -33,21 +44,27 @@ func (d *DiscordNotifier) Validate(encryptor encryption.FieldEncryptor) error {
 func (d *DiscordNotifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading string,
-	message string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	webhookURL, err := encryptor.Decrypt(d.NotifierID, d.ChannelWebhookURL)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt webhook URL: %w", err)
 	}
 
-	fullMessage := heading
-	if message != "" {
-		fullMessage = fmt.Sprintf("%s\n\n%s", heading, message)
+	fullMessage, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatText,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
 	}
 
 	payload := map[string]interface{}{
-		"content": fullMessage,
+		"content": notifiers_events.Truncate(fullMessage, maxMessageLength),
 	}
 
 	jsonPayload, err := json.Marshal(payload)
@@ -73,7 +90,8 @@ func (d *DiscordNotifier) Send(
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf(
+		return common.NewResponseError(
+			resp.StatusCode,
 			"discord API returned non-OK status: %s. Error: %s",
 			resp.Status,
 			string(bodyBytes),
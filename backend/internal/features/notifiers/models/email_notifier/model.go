@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net"
 	"net/smtp"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/util/encryption"
 	"time"
 
@@ -21,6 +22,86 @@ const (
 	MIMECharsetUTF8  = "UTF-8"
 )
 
+// DefaultMessageTemplate is rendered to the HTML body of the email, the
+// subject is the title of the event
+const DefaultMessageTemplate = `
+<div style="font-family: Arial, sans-serif; font-size: 14px; color: #1f2937;">
+  <h2 style="font-size: 18px;">{{.Title}}</h2>
+  {{- if .Message}}
+  <p style="white-space: pre-line;">{{.Message}}</p>
+  {{- end}}
+  <table style="border-collapse: collapse;">
+    {{- with .Workspace}}
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Workspace</td>
+      <td>{{.Name}}</td>
+    </tr>
+    {{- end}}
+    {{- with .Database}}
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Database</td>
+      <td>{{.Name}}</td>
+    </tr>
+    {{- end}}
+    {{- with .Backup}}{{if .SizeMb}}
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Backup size</td>
+      <td>{{formatSize .SizeMb}}</td>
+    </tr>
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Duration</td>
+      <td>{{formatDuration .DurationMs}}</td>
+    </tr>
+    {{- end}}{{end}}
+    {{- with .Storage}}
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Storage</td>
+      <td>{{.Name}}</td>
+    </tr>
+    {{- end}}
+    <tr>
+      <td style="padding-right: 16px; color: #6b7280;">Time</td>
+      <td>{{formatTime .OccurredAt}}</td>
+    </tr>
+  </table>
+  {{- with .Report}}
+  <h3 style="font-size: 16px;">Databases</h3>
+  <table style="border-collapse: collapse;">
+    <tr style="color: #6b7280; text-align: left;">
+      <th style="padding-right: 16px;">Database</th>
+      <th style="padding-right: 16px;">Last backup</th>
+      <th style="padding-right: 16px;">Completed</th>
+      <th style="padding-right: 16px;">Failed</th>
+      <th style="padding-right: 16px;">Size</th>
+      <th style="padding-right: 16px;">Growth</th>
+      <th>Uptime</th>
+    </tr>
+    {{- range .Databases}}
+    <tr{{if .IsOverdue}} style="color: #dc2626;"{{end}}>
+      <td style="padding-right: 16px;">{{.Name}}</td>
+      <td style="padding-right: 16px;">
+        {{- with .LastBackupAt}}{{formatTime .}}{{else}}never{{end}}
+        {{- if .IsOverdue}} (overdue){{end}}</td>
+      <td style="padding-right: 16px;">{{.CompletedBackupsCount}}</td>
+      <td style="padding-right: 16px;">{{.FailedBackupsCount}}</td>
+      <td style="padding-right: 16px;">{{formatSize .LastBackupSizeMb}}</td>
+      <td style="padding-right: 16px;">{{formatPercent .SizeGrowthPercent}}</td>
+      <td>{{formatPercent .UptimePercent}}</td>
+    </tr>
+    {{- end}}
+  </table>
+  <h3 style="font-size: 16px;">Storages</h3>
+  <table style="border-collapse: collapse;">
+    {{- range .Storages}}
+    <tr>
+      <td style="padding-right: 16px;">{{.Name}}</td>
+      <td>{{formatSize .UsedMb}}</td>
+    </tr>
+    {{- end}}
+  </table>
+  {{- end}}
+</div>`
+
 type EmailNotifier struct {
 	NotifierID   uuid.UUID `json:"notifierId"   gorm:"primaryKey;type:uuid;column:notifier_id"`
 	TargetEmail  string    `json:"targetEmail"  gorm:"not null;type:varchar(255);column:target_email"`
@@ -58,9 +139,9 @@ func (e *EmailNotifier) Validate(encryptor encryption.FieldEncryptor) error {
 
 func (e *EmailNotifier) Send(
 	encryptor encryption.FieldEncryptor,
-	_ *slog.Logger,
-	heading string,
-	message string,
+	logger *slog.Logger,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	var smtpPassword string
 	if e.SMTPPassword != "" {
@@ -79,7 +160,18 @@ func (e *EmailNotifier) Send(
 		}
 	}
 
-	emailContent := e.buildEmailContent(heading, message, from)
+	body, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatHTML,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+
+	emailContent := e.buildEmailContent(event.Title, body, from)
 	isAuthRequired := e.SMTPUser != "" && smtpPassword != ""
 
 	if e.SMTPPort == ImplicitTLSPort {
@@ -0,0 +1,17 @@
+package incident_notifier
+
+type IncidentSeverity string
+
+const (
+	IncidentSeverityCritical IncidentSeverity = "critical"
+	IncidentSeverityError    IncidentSeverity = "error"
+	IncidentSeverityWarning  IncidentSeverity = "warning"
+	IncidentSeverityInfo     IncidentSeverity = "info"
+)
+
+type incidentAction string
+
+const (
+	incidentActionTrigger incidentAction = "trigger"
+	incidentActionResolve incidentAction = "resolve"
+)
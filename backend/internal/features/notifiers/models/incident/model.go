@@ -0,0 +1,274 @@
+package incident_notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
+	"postgresus-backend/internal/util/encryption"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	// DefaultMessageTemplate is rendered to the summary of the incident
+	DefaultMessageTemplate = `{{.Title}}`
+
+	maxSummaryLength = 1024
+	requestTimeout   = 30 * time.Second
+)
+
+// IncidentNotifier opens incidents via Events API v2. It is the API of
+// PagerDuty, Opsgenie and Grafana OnCall support it by PagerDuty
+// compatible integrations
+type IncidentNotifier struct {
+	NotifierID uuid.UUID        `json:"notifierId" gorm:"primaryKey;column:notifier_id"`
+	EventsURL  string           `json:"eventsUrl"  gorm:"not null;column:events_url"`
+	RoutingKey string           `json:"routingKey" gorm:"not null;column:routing_key"`
+	Severity   IncidentSeverity `json:"severity"   gorm:"not null;column:severity"`
+}
+
+type eventRequest struct {
+	RoutingKey  string         `json:"routing_key"`
+	EventAction incidentAction `json:"event_action"`
+	DedupKey    string         `json:"dedup_key"`
+	Payload     *eventPayload  `json:"payload,omitempty"`
+	Client      string         `json:"client,omitempty"`
+}
+
+type eventPayload struct {
+	Summary       string                              `json:"summary"`
+	Source        string                              `json:"source"`
+	Severity      IncidentSeverity                    `json:"severity"`
+	Timestamp     string                              `json:"timestamp"`
+	Component     string                              `json:"component,omitempty"`
+	Group         string                              `json:"group,omitempty"`
+	Class         string                              `json:"class"`
+	CustomDetails *notifiers_events.NotificationEvent `json:"custom_details"`
+}
+
+func (n *IncidentNotifier) TableName() string {
+	return "incident_notifiers"
+}
+
+func (n *IncidentNotifier) Validate(encryptor encryption.FieldEncryptor) error {
+	if n.RoutingKey == "" {
+		return errors.New("routing key is required")
+	}
+
+	if n.EventsURL != "" {
+		eventsURL, err := url.Parse(n.EventsURL)
+		if err != nil || (eventsURL.Scheme != "http" && eventsURL.Scheme != "https") ||
+			eventsURL.Host == "" {
+			return errors.New("events URL must be a valid HTTP or HTTPS URL")
+		}
+	}
+
+	switch n.Severity {
+	case IncidentSeverityCritical,
+		IncidentSeverityError,
+		IncidentSeverityWarning,
+		IncidentSeverityInfo:
+	default:
+		return errors.New("severity must be one of critical, error, warning or info")
+	}
+
+	return nil
+}
+
+func (n *IncidentNotifier) Send(
+	encryptor encryption.FieldEncryptor,
+	logger *slog.Logger,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
+) error {
+	routingKey, err := encryptor.Decrypt(n.NotifierID, n.RoutingKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt routing key: %w", err)
+	}
+
+	// test opens the incident and resolves it at once, so it checks the
+	// routing key without leaving the incident for on-call
+	if event.Type == notifiers_events.NotificationEventTest {
+		dedupKey := fmt.Sprintf("postgresus-test-%s", n.NotifierID)
+
+		request, err := n.buildTriggerRequest(
+			logger,
+			routingKey,
+			dedupKey,
+			"test",
+			event,
+			messageTemplate,
+		)
+		if err != nil {
+			return err
+		}
+		request.Payload.Severity = IncidentSeverityInfo
+
+		if err := n.sendEvent(logger, request); err != nil {
+			return err
+		}
+
+		return n.sendEvent(logger, &eventRequest{
+			RoutingKey:  routingKey,
+			EventAction: incidentActionResolve,
+			DedupKey:    dedupKey,
+		})
+	}
+
+	// only failures and recoveries are incidents, other events are skipped.
+	// Events of the same class share the dedup key, so the recovery
+	// resolves the incident opened by the failure
+	state, ok := event.GetState()
+	if !ok || event.Database == nil {
+		return nil
+	}
+
+	dedupKey := fmt.Sprintf("postgresus-%s-%s", event.Database.ID, state.Class)
+
+	if !state.IsFailure {
+		return n.sendEvent(logger, &eventRequest{
+			RoutingKey:  routingKey,
+			EventAction: incidentActionResolve,
+			DedupKey:    dedupKey,
+		})
+	}
+
+	request, err := n.buildTriggerRequest(
+		logger,
+		routingKey,
+		dedupKey,
+		string(state.Class),
+		event,
+		messageTemplate,
+	)
+	if err != nil {
+		return err
+	}
+
+	return n.sendEvent(logger, request)
+}
+
+func (n *IncidentNotifier) HideSensitiveData() {
+	n.RoutingKey = ""
+}
+
+func (n *IncidentNotifier) Update(incoming *IncidentNotifier) {
+	n.EventsURL = incoming.EventsURL
+	n.Severity = incoming.Severity
+
+	if incoming.RoutingKey != "" {
+		n.RoutingKey = incoming.RoutingKey
+	}
+}
+
+func (n *IncidentNotifier) EncryptSensitiveData(encryptor encryption.FieldEncryptor) error {
+	if n.RoutingKey != "" {
+		encrypted, err := encryptor.Encrypt(n.NotifierID, n.RoutingKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt routing key: %w", err)
+		}
+		n.RoutingKey = encrypted
+	}
+	return nil
+}
+
+func (n *IncidentNotifier) buildTriggerRequest(
+	logger *slog.Logger,
+	routingKey string,
+	dedupKey string,
+	class string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
+) (*eventRequest, error) {
+	summary, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatText,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render message: %w", err)
+	}
+
+	payload := &eventPayload{
+		Summary:       notifiers_events.Truncate(summary, maxSummaryLength),
+		Source:        "postgresus",
+		Severity:      n.Severity,
+		Timestamp:     event.OccurredAt.Format(time.RFC3339),
+		Class:         class,
+		CustomDetails: event,
+	}
+
+	if event.Database != nil {
+		payload.Source = event.Database.Name
+		payload.Component = event.Database.Type
+	}
+
+	if event.Workspace != nil {
+		payload.Group = event.Workspace.Name
+	}
+
+	return &eventRequest{
+		RoutingKey:  routingKey,
+		EventAction: incidentActionTrigger,
+		DedupKey:    dedupKey,
+		Payload:     payload,
+		Client:      "Postgresus",
+	}, nil
+}
+
+func (n *IncidentNotifier) sendEvent(logger *slog.Logger, request *eventRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.getEventsURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send incident event: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Error("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return common.NewResponseError(
+			resp.StatusCode,
+			"events API returned status: %s, body: %s",
+			resp.Status,
+			string(respBody),
+		)
+	}
+
+	return nil
+}
+
+func (n *IncidentNotifier) getEventsURL() string {
+	if n.EventsURL == "" {
+		return DefaultEventsURL
+	}
+
+	return n.EventsURL
+}
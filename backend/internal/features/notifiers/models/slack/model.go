@@ -8,6 +8,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
 	"postgresus-backend/internal/util/encryption"
 	"strconv"
 	"strings"
@@ -16,6 +18,32 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultMessageTemplate is rendered to the array of Block Kit blocks
+const DefaultMessageTemplate = `[
+  {
+    "type": "header",
+    "text": {"type": "plain_text", "text": {{json (truncate 150 .Title)}}}
+  },
+  {{- if .Message}}
+  {
+    "type": "section",
+    "text": {"type": "mrkdwn", "text": {{json (truncate 3000 .Message)}}}
+  },
+  {{- end}}
+  {
+    "type": "context",
+    "elements": [
+      {{- with .Workspace}}
+      {"type": "mrkdwn", "text": {{json (printf "Workspace: *%s*" .Name)}}},
+      {{- end}}
+      {{- with .Database}}
+      {"type": "mrkdwn", "text": {{json (printf "Database: *%s*" .Name)}}},
+      {{- end}}
+      {"type": "mrkdwn", "text": {{json (formatTime .OccurredAt)}}}
+    ]
+  }
+]`
+
 type SlackNotifier struct {
 	NotifierID   uuid.UUID `json:"notifierId"   gorm:"primaryKey;column:notifier_id"`
 	BotToken     string    `json:"botToken"     gorm:"not null;column:bot_token"`
@@ -47,23 +75,30 @@ func (s *SlackNotifier) Validate(encryptor encryption.FieldEncryptor) error {
 func (s *SlackNotifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading, message string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	botToken, err := encryptor.Decrypt(s.NotifierID, s.BotToken)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt bot token: %w", err)
 	}
 
-	full := fmt.Sprintf("*%s*", heading)
-
-	if message != "" {
-		full = fmt.Sprintf("%s\n\n%s", full, message)
+	blocks, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatJSON,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
 	}
 
+	// text is shown in push notifications, blocks are shown in the channel
 	payload, _ := json.Marshal(map[string]any{
 		"channel": s.TargetChatID,
-		"text":    full,
-		"mrkdwn":  true,
+		"text":    event.Title,
+		"blocks":  json.RawMessage(blocks),
 	})
 
 	const (
@@ -117,7 +152,11 @@ func (s *SlackNotifier) Send(
 			}
 
 			if attempts >= maxAttempts {
-				return fmt.Errorf("rate-limited after %d attempts, giving up", attempts)
+				return common.NewResponseError(
+					resp.StatusCode,
+					"rate-limited after %d attempts, giving up",
+					attempts,
+				)
 			}
 
 			logger.Warn("Slack rate-limited, retrying", "after", retryAfter, "attempt", attempts)
@@ -8,11 +8,50 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
 	"postgresus-backend/internal/util/encryption"
 
 	"github.com/google/uuid"
 )
 
+// DefaultMessageTemplate is rendered to the content of the adaptive card
+const DefaultMessageTemplate = `{
+  "type": "AdaptiveCard",
+  "version": "1.4",
+  "body": [
+    {
+      "type": "TextBlock",
+      "size": "Medium",
+      "weight": "Bolder",
+      "wrap": true,
+      "text": {{json .Title}}
+    },
+    {{- if .Message}}
+    {"type": "TextBlock", "wrap": true, "text": {{json .Message}}},
+    {{- end}}
+    {
+      "type": "FactSet",
+      "facts": [
+        {{- with .Workspace}}
+        {"title": "Workspace", "value": {{json .Name}}},
+        {{- end}}
+        {{- with .Database}}
+        {"title": "Database", "value": {{json .Name}}},
+        {{- end}}
+        {{- with .Backup}}{{if .SizeMb}}
+        {"title": "Backup size", "value": {{json (formatSize .SizeMb)}}},
+        {"title": "Duration", "value": {{json (formatDuration .DurationMs)}}},
+        {{- end}}{{end}}
+        {{- with .Storage}}
+        {"title": "Storage", "value": {{json .Name}}},
+        {{- end}}
+        {"title": "Time", "value": {{json (formatTime .OccurredAt)}}}
+      ]
+    }
+  ]
+}`
+
 type TeamsNotifier struct {
 	NotifierID uuid.UUID `gorm:"type:uuid;primaryKey;column:notifier_id"      json:"notifierId"`
 	WebhookURL string    `gorm:"type:text;not null;column:power_automate_url" json:"powerAutomateUrl"`
@@ -53,7 +92,8 @@ type payload struct {
 func (n *TeamsNotifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading, message string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	if err := n.Validate(encryptor); err != nil {
 		return err
@@ -64,25 +104,25 @@ func (n *TeamsNotifier) Send(
 		return fmt.Errorf("failed to decrypt webhook URL: %w", err)
 	}
 
-	card := map[string]any{
-		"type":    "AdaptiveCard",
-		"version": "1.4",
-		"body": []any{
-			map[string]any{
-				"type":   "TextBlock",
-				"size":   "Medium",
-				"weight": "Bolder",
-				"text":   heading,
-			},
-			map[string]any{"type": "TextBlock", "wrap": true, "text": message},
-		},
+	card, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatJSON,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
 	}
 
 	p := payload{
-		Title: heading,
-		Text:  message,
+		Title: event.Title,
+		Text:  event.Message,
 		Attachments: []cardAttachment{
-			{ContentType: "application/vnd.microsoft.card.adaptive", Content: card},
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content:     json.RawMessage(card),
+			},
 		},
 	}
 
@@ -105,7 +145,11 @@ func (n *TeamsNotifier) Send(
 	}()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("teams webhook returned status %d", resp.StatusCode)
+		return common.NewResponseError(
+			resp.StatusCode,
+			"teams webhook returned status %d",
+			resp.StatusCode,
+		)
 	}
 
 	return nil
@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
 	"postgresus-backend/internal/util/encryption"
 	"strconv"
 	"strings"
@@ -14,6 +16,15 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// DefaultMessageTemplate is rendered to the message with HTML parse mode
+	DefaultMessageTemplate = `<b>{{html .Title}}</b>{{if .Message}}
+
+{{html .Message}}{{end}}`
+
+	maxMessageLength = 4096
+)
+
 type TelegramNotifier struct {
 	NotifierID   uuid.UUID `json:"notifierId"   gorm:"primaryKey;column:notifier_id"`
 	BotToken     string    `json:"botToken"     gorm:"not null;column:bot_token"`
@@ -40,24 +51,30 @@ func (t *TelegramNotifier) Validate(encryptor encryption.FieldEncryptor) error {
 func (t *TelegramNotifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading string,
-	message string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	botToken, err := encryptor.Decrypt(t.NotifierID, t.BotToken)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt bot token: %w", err)
 	}
 
-	fullMessage := heading
-	if message != "" {
-		fullMessage = fmt.Sprintf("%s\n\n%s", heading, message)
+	fullMessage, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatText,
+		messageTemplate,
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
 	}
 
 	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
 
 	data := url.Values{}
 	data.Set("chat_id", t.TargetChatID)
-	data.Set("text", fullMessage)
+	data.Set("text", notifiers_events.Truncate(fullMessage, maxMessageLength))
 	data.Set("parse_mode", "HTML")
 
 	if t.ThreadID != nil && *t.ThreadID != 0 {
@@ -82,7 +99,8 @@ func (t *TelegramNotifier) Send(
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf(
+		return common.NewResponseError(
+			resp.StatusCode,
 			"telegram API returned non-OK status: %s. Error: %s",
 			resp.Status,
 			string(bodyBytes),
@@ -1,7 +1,6 @@
 package webhook_notifier
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +8,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
 	"postgresus-backend/internal/util/encryption"
 	"strings"
 
@@ -16,6 +17,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultMessageTemplate is rendered to the request body. The payload is
+// documented in docs/notifications.md, fields are only added to it, and
+// breaking changes increase schemaVersion. heading is the same as title
+// and kept for webhooks created before the payload had the schema
+const DefaultMessageTemplate = `{
+  "schemaVersion": 1,
+  "type": {{json .Type}},
+  "heading": {{json .Title}},
+  "title": {{json .Title}},
+  "message": {{json .Message}},
+  "workspace": {{json .Workspace}},
+  "database": {{json .Database}},
+  "backup": {{json .Backup}},
+  "restore": {{json .Restore}},
+  "storage": {{json .Storage}},
+  "error": {{json .Error}},
+  "report": {{json .Report}},
+  "occurredAt": {{json .OccurredAt}}
+}`
+
 type WebhookHeader struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -70,25 +91,43 @@ func (t *WebhookNotifier) Validate(encryptor encryption.FieldEncryptor) error {
 		return errors.New("webhook method is required")
 	}
 
+	if t.BodyTemplate != nil && *t.BodyTemplate != "" {
+		return notifiers_events.ValidateTemplate(
+			notifiers_events.TemplateFormatText,
+			convertLegacyPlaceholders(*t.BodyTemplate),
+		)
+	}
+
 	return nil
 }
 
 func (t *WebhookNotifier) Send(
 	encryptor encryption.FieldEncryptor,
 	logger *slog.Logger,
-	heading string,
-	message string,
+	event *notifiers_events.NotificationEvent,
+	messageTemplate string,
 ) error {
 	webhookURL, err := encryptor.Decrypt(t.NotifierID, t.WebhookURL)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt webhook URL: %w", err)
 	}
 
+	body, err := notifiers_events.RenderTemplate(
+		logger,
+		notifiers_events.TemplateFormatText,
+		t.getBodyTemplate(messageTemplate),
+		DefaultMessageTemplate,
+		event,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+
 	switch t.WebhookMethod {
 	case WebhookMethodGET:
-		return t.sendGET(webhookURL, heading, message, logger)
+		return t.sendGET(webhookURL, event, body, logger)
 	case WebhookMethodPOST:
-		return t.sendPOST(webhookURL, heading, message, logger)
+		return t.sendPOST(webhookURL, body, logger)
 	default:
 		return fmt.Errorf("unsupported webhook method: %s", t.WebhookMethod)
 	}
@@ -118,11 +157,20 @@ func (t *WebhookNotifier) EncryptSensitiveData(encryptor encryption.FieldEncrypt
 	return nil
 }
 
-func (t *WebhookNotifier) sendGET(webhookURL, heading, message string, logger *slog.Logger) error {
-	reqURL := fmt.Sprintf("%s?heading=%s&message=%s",
+// sendGET passes the rendered body in payload param. heading and message
+// are kept for webhooks created before the payload had the schema
+func (t *WebhookNotifier) sendGET(
+	webhookURL string,
+	event *notifiers_events.NotificationEvent,
+	body string,
+	logger *slog.Logger,
+) error {
+	reqURL := fmt.Sprintf("%s?heading=%s&message=%s&type=%s&payload=%s",
 		webhookURL,
-		url.QueryEscape(heading),
-		url.QueryEscape(message),
+		url.QueryEscape(event.Title),
+		url.QueryEscape(event.Message),
+		url.QueryEscape(string(event.Type)),
+		url.QueryEscape(body),
 	)
 
 	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
@@ -146,7 +194,8 @@ func (t *WebhookNotifier) sendGET(webhookURL, heading, message string, logger *s
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf(
+		return common.NewResponseError(
+			resp.StatusCode,
 			"webhook GET returned status: %s, body: %s",
 			resp.Status,
 			string(body),
@@ -156,10 +205,8 @@ func (t *WebhookNotifier) sendGET(webhookURL, heading, message string, logger *s
 	return nil
 }
 
-func (t *WebhookNotifier) sendPOST(webhookURL, heading, message string, logger *slog.Logger) error {
-	body := t.buildRequestBody(heading, message)
-
-	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+func (t *WebhookNotifier) sendPOST(webhookURL, body string, logger *slog.Logger) error {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, strings.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create POST request: %w", err)
 	}
@@ -193,7 +240,8 @@ func (t *WebhookNotifier) sendPOST(webhookURL, heading, message string, logger *
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf(
+		return common.NewResponseError(
+			resp.StatusCode,
 			"webhook POST returned status: %s, body: %s",
 			resp.Status,
 			string(respBody),
@@ -203,21 +251,18 @@ func (t *WebhookNotifier) sendPOST(webhookURL, heading, message string, logger *
 	return nil
 }
 
-func (t *WebhookNotifier) buildRequestBody(heading, message string) []byte {
-	if t.BodyTemplate != nil && *t.BodyTemplate != "" {
-		result := *t.BodyTemplate
-		result = strings.ReplaceAll(result, "{{heading}}", escapeJSONString(heading))
-		result = strings.ReplaceAll(result, "{{message}}", escapeJSONString(message))
-		return []byte(result)
+// getBodyTemplate prefers the message template of the notifier. Body
+// template is set by webhooks created before message templates
+func (t *WebhookNotifier) getBodyTemplate(messageTemplate string) string {
+	if messageTemplate != "" {
+		return messageTemplate
 	}
 
-	payload := map[string]string{
-		"heading": heading,
-		"message": message,
+	if t.BodyTemplate != nil && *t.BodyTemplate != "" {
+		return convertLegacyPlaceholders(*t.BodyTemplate)
 	}
-	body, _ := json.Marshal(payload)
 
-	return body
+	return ""
 }
 
 func (t *WebhookNotifier) applyHeaders(req *http.Request) {
@@ -228,16 +273,9 @@ func (t *WebhookNotifier) applyHeaders(req *http.Request) {
 	}
 }
 
-func escapeJSONString(s string) string {
-	b, err := json.Marshal(s)
-	if err != nil || len(b) < 2 {
-		escaped := strings.ReplaceAll(s, `\`, `\\`)
-		escaped = strings.ReplaceAll(escaped, `"`, `\"`)
-		escaped = strings.ReplaceAll(escaped, "\n", `\n`)
-		escaped = strings.ReplaceAll(escaped, "\r", `\r`)
-		escaped = strings.ReplaceAll(escaped, "\t", `\t`)
-		return escaped
-	}
-
-	return string(b[1 : len(b)-1])
+// convertLegacyPlaceholders replaces {{heading}} and {{message}} of body
+// templates created before Go templates by the same escaped values
+func convertLegacyPlaceholders(bodyTemplate string) string {
+	result := strings.ReplaceAll(bodyTemplate, "{{heading}}", "{{escapeJSON .Title}}")
+	return strings.ReplaceAll(result, "{{message}}", "{{escapeJSON .Message}}")
 }
@@ -39,6 +39,10 @@ func (r *NotifierRepository) Save(notifier *Notifier) (*Notifier, error) {
 			if notifier.TeamsNotifier != nil {
 				notifier.TeamsNotifier.NotifierID = notifier.ID
 			}
+		case NotifierTypeIncident:
+			if notifier.IncidentNotifier != nil {
+				notifier.IncidentNotifier.NotifierID = notifier.ID
+			}
 		}
 
 		if notifier.ID == uuid.Nil {
@@ -50,6 +54,7 @@ func (r *NotifierRepository) Save(notifier *Notifier) (*Notifier, error) {
 					"SlackNotifier",
 					"DiscordNotifier",
 					"TeamsNotifier",
+					"IncidentNotifier",
 				).
 				Create(notifier).Error; err != nil {
 				return err
@@ -63,6 +68,7 @@ func (r *NotifierRepository) Save(notifier *Notifier) (*Notifier, error) {
 					"SlackNotifier",
 					"DiscordNotifier",
 					"TeamsNotifier",
+					"IncidentNotifier",
 				).
 				Save(notifier).Error; err != nil {
 				return err
@@ -112,6 +118,13 @@ func (r *NotifierRepository) Save(notifier *Notifier) (*Notifier, error) {
 					return err
 				}
 			}
+		case NotifierTypeIncident:
+			if notifier.IncidentNotifier != nil {
+				notifier.IncidentNotifier.NotifierID = notifier.ID
+				if err := tx.Save(notifier.IncidentNotifier).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
@@ -135,6 +148,7 @@ func (r *NotifierRepository) FindByID(id uuid.UUID) (*Notifier, error) {
 		Preload("SlackNotifier").
 		Preload("DiscordNotifier").
 		Preload("TeamsNotifier").
+		Preload("IncidentNotifier").
 		Where("id = ?", id).
 		First(&notifier).Error; err != nil {
 		return nil, err
@@ -154,6 +168,7 @@ func (r *NotifierRepository) FindByWorkspaceID(workspaceID uuid.UUID) ([]*Notifi
 		Preload("SlackNotifier").
 		Preload("DiscordNotifier").
 		Preload("TeamsNotifier").
+		Preload("IncidentNotifier").
 		Where("workspace_id = ?", workspaceID).
 		Order("name ASC").
 		Find(&notifiers).Error; err != nil {
@@ -202,6 +217,12 @@ func (r *NotifierRepository) Delete(notifier *Notifier) error {
 					return err
 				}
 			}
+		case NotifierTypeIncident:
+			if notifier.IncidentNotifier != nil {
+				if err := tx.Delete(notifier.IncidentNotifier).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		return tx.Delete(notifier).Error
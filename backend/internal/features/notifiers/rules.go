@@ -0,0 +1,137 @@
+package notifiers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/google/uuid"
+)
+
+const quietHoursTimeLayout = "15:04"
+
+// NotificationRules decide which events the notifier sends and when. Empty
+// filters match all events, so the notifier without rules sends everything
+type NotificationRules struct {
+	EventTypes   []notifiers_events.NotificationEventType `json:"eventTypes"`
+	MinSeverity  notifiers_events.EventSeverity           `json:"minSeverity"`
+	DatabaseIDs  []uuid.UUID                              `json:"databaseIds"`
+	DatabaseTags []string                                 `json:"databaseTags"`
+
+	// events of quiet hours are held and sent as one digest when quiet
+	// hours end. Start after end means quiet hours pass midnight
+	IsQuietHoursEnabled bool   `json:"isQuietHoursEnabled"`
+	QuietHoursStart     string `json:"quietHoursStart"`
+	QuietHoursEnd       string `json:"quietHoursEnd"`
+	// QuietHoursTimezone is IANA name, UTC is used when it is empty
+	QuietHoursTimezone string `json:"quietHoursTimezone"`
+
+	// failure repeated before the recovery is not sent, the recovery tells
+	// how many failures were suppressed
+	IsRepeatedFailuresSuppressed bool `json:"isRepeatedFailuresSuppressed"`
+}
+
+func (r *NotificationRules) Validate() error {
+	if r.MinSeverity != "" && !r.MinSeverity.IsValid() {
+		return errors.New("min severity must be one of INFO, WARNING or CRITICAL")
+	}
+
+	if !r.IsQuietHoursEnabled {
+		return nil
+	}
+
+	start, err := time.Parse(quietHoursTimeLayout, r.QuietHoursStart)
+	if err != nil {
+		return errors.New("quiet hours start must be in HH:MM format")
+	}
+
+	end, err := time.Parse(quietHoursTimeLayout, r.QuietHoursEnd)
+	if err != nil {
+		return errors.New("quiet hours end must be in HH:MM format")
+	}
+
+	if start.Equal(end) {
+		return errors.New("quiet hours start and end must differ")
+	}
+
+	if _, err := time.LoadLocation(r.QuietHoursTimezone); err != nil {
+		return fmt.Errorf("invalid quiet hours timezone: %w", err)
+	}
+
+	return nil
+}
+
+// IsMatched checks filters of the rules. Test, digest and report are always
+// sent, they are requested by the user or contain already matched events
+func (r *NotificationRules) IsMatched(event *notifiers_events.NotificationEvent) bool {
+	if r == nil ||
+		event.Type == notifiers_events.NotificationEventTest ||
+		event.Type == notifiers_events.NotificationEventQuietHoursDigest ||
+		event.Type == notifiers_events.NotificationEventWorkspaceReport {
+		return true
+	}
+
+	if len(r.EventTypes) > 0 && !slices.Contains(r.EventTypes, event.Type) {
+		return false
+	}
+
+	if r.MinSeverity != "" && !event.GetSeverity().IsAtLeast(r.MinSeverity) {
+		return false
+	}
+
+	if len(r.DatabaseIDs) == 0 && len(r.DatabaseTags) == 0 {
+		return true
+	}
+
+	// events without database are not related to the filtered databases
+	if event.Database == nil {
+		return false
+	}
+
+	if slices.Contains(r.DatabaseIDs, event.Database.ID) {
+		return true
+	}
+
+	for _, tag := range event.Database.Tags {
+		if slices.Contains(r.DatabaseTags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *NotificationRules) IsQuietTime(now time.Time) bool {
+	if r == nil || !r.IsQuietHoursEnabled {
+		return false
+	}
+
+	location, err := time.LoadLocation(r.QuietHoursTimezone)
+	if err != nil {
+		return false
+	}
+
+	start, err := time.Parse(quietHoursTimeLayout, r.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+
+	end, err := time.Parse(quietHoursTimeLayout, r.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	localNow := now.In(location)
+	minutes := localNow.Hour()*60 + localNow.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	if startMinutes < endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes
+	}
+
+	return minutes >= startMinutes || minutes < endMinutes
+}
@@ -0,0 +1,95 @@
+package notifiers
+
+import (
+	"testing"
+	"time"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsMatched_WhenRulesFilterEvents_OnlyMatchedEventsPass(t *testing.T) {
+	productionDatabaseID := uuid.New()
+	taggedEvent := newDatabaseEvent(notifiers_events.NotificationEventBackupFailed, uuid.New())
+	taggedEvent.Database.Tags = []string{"production"}
+
+	rules := &NotificationRules{
+		EventTypes: []notifiers_events.NotificationEventType{
+			notifiers_events.NotificationEventBackupFailed,
+			notifiers_events.NotificationEventBackupCompleted,
+		},
+		MinSeverity:  notifiers_events.EventSeverityCritical,
+		DatabaseIDs:  []uuid.UUID{productionDatabaseID},
+		DatabaseTags: []string{"production"},
+	}
+
+	assert.True(t, rules.IsMatched(
+		newDatabaseEvent(notifiers_events.NotificationEventBackupFailed, productionDatabaseID),
+	))
+	assert.True(t, rules.IsMatched(taggedEvent))
+	assert.True(t, rules.IsMatched(notifiers_events.NewTestEvent()))
+
+	assert.False(t, rules.IsMatched(
+		newDatabaseEvent(notifiers_events.NotificationEventBackupFailed, uuid.New()),
+	), "database is neither listed nor tagged")
+	assert.False(t, rules.IsMatched(
+		newDatabaseEvent(notifiers_events.NotificationEventBackupCompleted, productionDatabaseID),
+	), "severity is below critical")
+	assert.False(t, rules.IsMatched(
+		newDatabaseEvent(notifiers_events.NotificationEventBackupCorrupted, productionDatabaseID),
+	), "event type is not listed")
+
+	var emptyRules *NotificationRules
+	assert.True(t, emptyRules.IsMatched(
+		newDatabaseEvent(notifiers_events.NotificationEventRestoreCompleted, uuid.New()),
+	))
+}
+
+func Test_IsQuietTime_WhenQuietHoursPassMidnight_NightIsQuiet(t *testing.T) {
+	rules := &NotificationRules{
+		IsQuietHoursEnabled: true,
+		QuietHoursStart:     "22:00",
+		QuietHoursEnd:       "07:30",
+		QuietHoursTimezone:  "Europe/Berlin",
+	}
+
+	location, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	assert.True(t, rules.IsQuietTime(time.Date(2025, 1, 31, 23, 0, 0, 0, location)))
+	assert.True(t, rules.IsQuietTime(time.Date(2025, 1, 31, 7, 29, 0, 0, location)))
+	assert.False(t, rules.IsQuietTime(time.Date(2025, 1, 31, 7, 30, 0, 0, location)))
+	assert.False(t, rules.IsQuietTime(time.Date(2025, 1, 31, 12, 0, 0, 0, location)))
+
+	// 22:30 UTC is 23:30 in Berlin
+	assert.True(t, rules.IsQuietTime(time.Date(2025, 1, 31, 22, 30, 0, 0, time.UTC)))
+
+	rules.IsQuietHoursEnabled = false
+	assert.False(t, rules.IsQuietTime(time.Date(2025, 1, 31, 23, 0, 0, 0, location)))
+}
+
+func Test_ValidateRules_WhenQuietHoursInvalid_ReturnsError(t *testing.T) {
+	invalidRules := []*NotificationRules{
+		{IsQuietHoursEnabled: true, QuietHoursStart: "25:00", QuietHoursEnd: "07:00"},
+		{IsQuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "22:00"},
+		{
+			IsQuietHoursEnabled: true,
+			QuietHoursStart:     "22:00",
+			QuietHoursEnd:       "07:00",
+			QuietHoursTimezone:  "Mars/Olympus",
+		},
+		{MinSeverity: "URGENT"},
+	}
+
+	for _, rules := range invalidRules {
+		assert.Error(t, rules.Validate())
+	}
+
+	assert.NoError(t, (&NotificationRules{
+		IsQuietHoursEnabled: true,
+		QuietHoursStart:     "22:00",
+		QuietHoursEnd:       "07:00",
+	}).Validate())
+}
@@ -4,8 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/notifiers/models/common"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/encryption"
@@ -13,12 +19,31 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	maxDeliveryAttempts        = 8
+	deliveryRetryBaseDelay     = 30 * time.Second
+	deliveryRetryMaxDelay      = time.Hour
+	dueDeliveriesBatchSize     = 50
+	defaultDeliveriesPageSize  = 50
+	maxDeliveriesPageSize      = 200
+	finishedDeliveriesLifetime = 30 * 24 * time.Hour
+	// maxEventTextLength limits stored text, channels cut the rendered
+	// message to their own limits
+	maxEventTextLength = 10000
+	// maxDigestEventsCount limits lines of the quiet hours digest, the rest
+	// are counted only
+	maxDigestEventsCount = 50
+)
+
 type NotifierService struct {
-	notifierRepository *NotifierRepository
-	logger             *slog.Logger
-	workspaceService   *workspaces_services.WorkspaceService
-	auditLogService    *audit_logs.AuditLogService
-	fieldEncryptor     encryption.FieldEncryptor
+	notifierRepository   *NotifierRepository
+	deliveryRepository   *NotificationDeliveryRepository
+	alertStateRepository *NotifierAlertStateRepository
+	logger               *slog.Logger
+	authorizationService *workspaces_services.AuthorizationService
+	workspaceService     *workspaces_services.WorkspaceService
+	auditLogService      *audit_logs.AuditLogService
+	fieldEncryptor       encryption.FieldEncryptor
 }
 
 func (s *NotifierService) SaveNotifier(
@@ -26,7 +51,11 @@ func (s *NotifierService) SaveNotifier(
 	workspaceID uuid.UUID,
 	notifier *Notifier,
 ) error {
-	canManage, err := s.workspaceService.CanUserManageDBs(workspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageNotifiers,
+	)
 	if err != nil {
 		return err
 	}
@@ -46,6 +75,7 @@ func (s *NotifierService) SaveNotifier(
 			return errors.New("notifier does not belong to this workspace")
 		}
 
+		notifierBefore := audit_logs.Snapshot(existingNotifier)
 		existingNotifier.Update(notifier)
 
 		if err := existingNotifier.EncryptSensitiveData(s.fieldEncryptor); err != nil {
@@ -61,11 +91,16 @@ func (s *NotifierService) SaveNotifier(
 			return err
 		}
 
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf("Notifier updated: %s", existingNotifier.Name),
-			&user.ID,
-			&workspaceID,
-		)
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionUpdate,
+			ResourceType: audit_logs_events.AuditResourceNotifier,
+			ResourceID:   &existingNotifier.ID,
+			Message:      fmt.Sprintf("Notifier updated: %s", existingNotifier.Name),
+			User:         user,
+			WorkspaceID:  &workspaceID,
+			Before:       notifierBefore,
+			After:        existingNotifier,
+		})
 	} else {
 		notifier.WorkspaceID = workspaceID
 
@@ -82,11 +117,14 @@ func (s *NotifierService) SaveNotifier(
 			return err
 		}
 
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf("Notifier created: %s", notifier.Name),
-			&user.ID,
-			&workspaceID,
-		)
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionCreate,
+			ResourceType: audit_logs_events.AuditResourceNotifier,
+			ResourceID:   &notifier.ID,
+			Message:      fmt.Sprintf("Notifier created: %s", notifier.Name),
+			User:         user,
+			WorkspaceID:  &workspaceID,
+		})
 	}
 
 	return nil
@@ -101,7 +139,11 @@ func (s *NotifierService) DeleteNotifier(
 		return err
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(notifier.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		notifier.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageNotifiers,
+	)
 	if err != nil {
 		return err
 	}
@@ -114,11 +156,14 @@ func (s *NotifierService) DeleteNotifier(
 		return err
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Notifier deleted: %s", notifier.Name),
-		&user.ID,
-		&notifier.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceNotifier,
+		ResourceID:   &notifier.ID,
+		Message:      fmt.Sprintf("Notifier deleted: %s", notifier.Name),
+		User:         user,
+		WorkspaceID:  &notifier.WorkspaceID,
+	})
 
 	return nil
 }
@@ -132,7 +177,11 @@ func (s *NotifierService) GetNotifier(
 		return nil, err
 	}
 
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(notifier.WorkspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		notifier.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +197,11 @@ func (s *NotifierService) GetNotifiers(
 	user *users_models.User,
 	workspaceID uuid.UUID,
 ) ([]*Notifier, error) {
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(workspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +230,11 @@ func (s *NotifierService) SendTestNotification(
 		return err
 	}
 
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(notifier.WorkspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		notifier.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return err
 	}
@@ -185,7 +242,7 @@ func (s *NotifierService) SendTestNotification(
 		return errors.New("insufficient permissions to test notifier in this workspace")
 	}
 
-	err = notifier.Send(s.fieldEncryptor, s.logger, "Test message", "This is a test message")
+	err = notifier.Send(s.fieldEncryptor, s.logger, notifiers_events.NewTestEvent())
 	if err != nil {
 		return err
 	}
@@ -232,41 +289,476 @@ func (s *NotifierService) SendTestNotificationToNotifier(
 		usingNotifier = notifier
 	}
 
-	return usingNotifier.Send(s.fieldEncryptor, s.logger, "Test message", "This is a test message")
+	return usingNotifier.Send(s.fieldEncryptor, s.logger, notifiers_events.NewTestEvent())
 }
 
+// SendNotification queues the notification, it is sent by the delivery
+// worker with retries, so a short outage of the messenger does not lose it.
+// Rules of the notifier filter the event, hold it during quiet hours and
+// suppress repeated failures
 func (s *NotifierService) SendNotification(
 	notifier *Notifier,
-	title string,
-	message string,
+	event *notifiers_events.NotificationEvent,
 ) {
-	// Truncate message to 2000 characters if it's too long
-	messageRunes := []rune(message)
-	if len(messageRunes) > 2000 {
-		message = string(messageRunes[:2000])
+	if !notifier.Rules.IsMatched(event) {
+		// the recovery filtered out by the rules still ends the failure
+		s.ResetFailureState(notifier, event)
+		return
 	}
 
-	notifiedFromDb, err := s.notifierRepository.FindByID(notifier.ID)
-	if err != nil {
+	if event.Workspace == nil {
+		event.Workspace = s.getEventWorkspace(notifier.WorkspaceID)
+	}
+
+	event.Message = notifiers_events.Truncate(event.Message, maxEventTextLength)
+	if event.Error != nil {
+		errorMessage := notifiers_events.Truncate(*event.Error, maxEventTextLength)
+		event.Error = &errorMessage
+	}
+
+	now := time.Now().UTC()
+	isRepeatedFailure, event := s.updateFailureState(notifier, event, now)
+
+	status := NotificationDeliveryStatusPending
+	if isRepeatedFailure {
+		status = NotificationDeliveryStatusSuppressed
+	} else if notifier.Rules.IsQuietTime(now) {
+		status = NotificationDeliveryStatusHeld
+	}
+
+	delivery := &NotificationDelivery{
+		NotifierID:    notifier.ID,
+		Title:         event.Title,
+		Message:       event.Message,
+		EventType:     event.Type,
+		Event:         event,
+		Status:        status,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	if err := s.deliveryRepository.Save(delivery); err != nil {
+		s.logger.Error("Failed to queue notification", "notifierId", notifier.ID, "error", err)
+	}
+}
+
+// ResetFailureState ends the failure of the database on the recovery. It
+// is called for notifiers which do not send the recovery, so the next
+// failure is not suppressed as repeated
+func (s *NotifierService) ResetFailureState(
+	notifier *Notifier,
+	event *notifiers_events.NotificationEvent,
+) {
+	state, ok := event.GetState()
+	if !ok || state.IsFailure || event.Database == nil {
 		return
 	}
 
-	err = notifiedFromDb.Send(s.fieldEncryptor, s.logger, title, message)
+	err := s.alertStateRepository.Delete(notifier.ID, event.Database.ID, state.Class)
+	if err != nil {
+		s.logger.Error("Failed to reset failure state", "notifierId", notifier.ID, "error", err)
+	}
+}
+
+func (s *NotifierService) GetNotificationDeliveries(
+	user *users_models.User,
+	notifierID uuid.UUID,
+	request *GetNotificationDeliveriesRequest,
+) (*GetNotificationDeliveriesResponse, error) {
+	notifier, err := s.notifierRepository.FindByID(notifierID)
+	if err != nil {
+		return nil, err
+	}
+
+	canView, err := s.authorizationService.HasPermission(
+		notifier.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, errors.New("insufficient permissions to view notifier in this workspace")
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultDeliveriesPageSize
+	}
+	limit = min(limit, maxDeliveriesPageSize)
+	offset := max(request.Offset, 0)
+
+	deliveries, err := s.deliveryRepository.FindByNotifierID(notifierID, limit, offset)
 	if err != nil {
-		errMsg := err.Error()
-		notifiedFromDb.LastSendError = &errMsg
+		return nil, err
+	}
+
+	total, err := s.deliveryRepository.CountByNotifierID(notifierID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetNotificationDeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	}, nil
+}
+
+// ResendNotification queues the notification of the delivery again. The
+// new delivery is created, so the log keeps attempts of the original one
+func (s *NotifierService) ResendNotification(
+	user *users_models.User,
+	deliveryID uuid.UUID,
+) (*NotificationDelivery, error) {
+	delivery, err := s.deliveryRepository.FindByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, errors.New("notification delivery not found")
+	}
+
+	notifier, err := s.notifierRepository.FindByID(delivery.NotifierID)
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = s.notifierRepository.Save(notifiedFromDb)
+	canManage, err := s.authorizationService.HasPermission(
+		notifier.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageNotifiers,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage {
+		return nil, errors.New("insufficient permissions to manage notifier in this workspace")
+	}
+
+	now := time.Now().UTC()
+	resentDelivery := &NotificationDelivery{
+		NotifierID:    delivery.NotifierID,
+		Title:         delivery.Title,
+		Message:       delivery.Message,
+		EventType:     delivery.EventType,
+		Event:         delivery.Event,
+		Status:        NotificationDeliveryStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	if err := s.deliveryRepository.Save(resentDelivery); err != nil {
+		return nil, err
+	}
+
+	return resentDelivery, nil
+}
+
+func (s *NotifierService) SendDueNotifications() error {
+	deliveries, err := s.deliveryRepository.FindDue(time.Now().UTC(), dueDeliveriesBatchSize)
+	if err != nil {
+		return err
+	}
+
+	// after a failed attempt the rest of the notifier deliveries wait for
+	// the retry, so the notifier receives events in order
+	failedNotifierIDs := make(map[uuid.UUID]bool)
+
+	for _, delivery := range deliveries {
+		if failedNotifierIDs[delivery.NotifierID] {
+			continue
+		}
+
+		if !s.deliver(delivery) {
+			failedNotifierIDs[delivery.NotifierID] = true
+		}
+	}
+
+	return nil
+}
+
+// SendQuietHoursDigests sends events held during quiet hours when quiet
+// hours of the notifier end
+func (s *NotifierService) SendQuietHoursDigests() error {
+	notifierIDs, err := s.deliveryRepository.FindNotifierIDsWithHeld()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, notifierID := range notifierIDs {
+		notifier, err := s.notifierRepository.FindByID(notifierID)
 		if err != nil {
-			s.logger.Error("Failed to save notifier", "error", err)
+			s.logger.Error(
+				"Failed to get notifier for digest",
+				"notifierId",
+				notifierID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		if notifier.Rules.IsQuietTime(now) {
+			continue
+		}
+
+		if err := s.sendQuietHoursDigest(notifier, now); err != nil {
+			s.logger.Error(
+				"Failed to send quiet hours digest",
+				"notifierId",
+				notifierID,
+				"error",
+				err,
+			)
 		}
 	}
 
-	notifiedFromDb.LastSendError = nil
-	_, err = s.notifierRepository.Save(notifiedFromDb)
+	return nil
+}
+
+func (s *NotifierService) DeleteOldDeliveries() error {
+	return s.deliveryRepository.DeleteFinishedBefore(
+		time.Now().UTC().Add(-finishedDeliveriesLifetime),
+	)
+}
+
+// deliver returns false when the notification is not sent
+func (s *NotifierService) deliver(delivery *NotificationDelivery) bool {
+	notifier, err := s.notifierRepository.FindByID(delivery.NotifierID)
 	if err != nil {
+		s.logger.Error(
+			"Failed to get notifier for delivery",
+			"deliveryId",
+			delivery.ID,
+			"error",
+			err,
+		)
+		return false
+	}
+
+	sendErr := notifier.Send(s.fieldEncryptor, s.logger, delivery.GetEvent())
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseCode = nil
+
+	if sendErr == nil {
+		delivery.Status = NotificationDeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+	} else {
+		errMsg := sendErr.Error()
+		delivery.LastError = &errMsg
+
+		var responseErr *common.ResponseError
+		if errors.As(sendErr, &responseErr) {
+			responseCode := responseErr.StatusCode
+			delivery.ResponseCode = &responseCode
+		}
+
+		if delivery.Attempts >= maxDeliveryAttempts {
+			delivery.Status = NotificationDeliveryStatusFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(getDeliveryRetryDelay(delivery.Attempts))
+		}
+
+		s.logger.Warn(
+			"Failed to send notification",
+			"deliveryId",
+			delivery.ID,
+			"attempts",
+			delivery.Attempts,
+			"error",
+			sendErr,
+		)
+	}
+
+	if err := s.deliveryRepository.Save(delivery); err != nil {
+		s.logger.Error("Failed to save notification delivery", "error", err)
+	}
+
+	// notifier keeps the result of the last attempt
+	if _, err := s.notifierRepository.Save(notifier); err != nil {
 		s.logger.Error("Failed to save notifier", "error", err)
 	}
+
+	return sendErr == nil
+}
+
+// updateFailureState tells whether the failure repeats the failure sent
+// before the recovery. The recovery after suppressed failures tells how
+// many of them were suppressed
+func (s *NotifierService) updateFailureState(
+	notifier *Notifier,
+	event *notifiers_events.NotificationEvent,
+	now time.Time,
+) (bool, *notifiers_events.NotificationEvent) {
+	state, ok := event.GetState()
+	if !ok || event.Database == nil {
+		return false, event
+	}
+
+	if !state.IsFailure {
+		alertState, err := s.alertStateRepository.Find(notifier.ID, event.Database.ID, state.Class)
+		if err != nil || alertState == nil {
+			return false, event
+		}
+
+		s.ResetFailureState(notifier, event)
+
+		if alertState.SuppressedCount == 0 {
+			return false, event
+		}
+
+		// the event is shared by notifiers of the database, so it is copied
+		recoveryEvent := *event
+		recoveryEvent.Message = fmt.Sprintf(
+			"%s\n%d repeated failures since %s were suppressed",
+			event.Message,
+			alertState.SuppressedCount,
+			notifiers_events.FormatTime(alertState.FailedAt),
+		)
+
+		return false, &recoveryEvent
+	}
+
+	// failures without recovery would be suppressed forever
+	if notifier.Rules == nil ||
+		!notifier.Rules.IsRepeatedFailuresSuppressed ||
+		!state.Class.IsRecoverable() {
+		return false, event
+	}
+
+	alertState, err := s.alertStateRepository.Find(notifier.ID, event.Database.ID, state.Class)
+	if err != nil {
+		s.logger.Error("Failed to get failure state", "notifierId", notifier.ID, "error", err)
+		return false, event
+	}
+
+	if alertState == nil {
+		alertState = &NotifierAlertState{
+			NotifierID: notifier.ID,
+			DatabaseID: event.Database.ID,
+			Class:      state.Class,
+			FailedAt:   now,
+		}
+	} else {
+		alertState.SuppressedCount++
+	}
+
+	if err := s.alertStateRepository.Save(alertState); err != nil {
+		s.logger.Error("Failed to save failure state", "notifierId", notifier.ID, "error", err)
+		return false, event
+	}
+
+	return alertState.SuppressedCount > 0, event
+}
+
+// sendQuietHoursDigest queues held events as one notification. The single
+// event is sent as is, the digest of one event tells nothing more
+func (s *NotifierService) sendQuietHoursDigest(notifier *Notifier, now time.Time) error {
+	heldDeliveries, err := s.deliveryRepository.FindHeldByNotifierID(notifier.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(heldDeliveries) == 0 {
+		return nil
+	}
+
+	if len(heldDeliveries) == 1 {
+		delivery := heldDeliveries[0]
+		delivery.Status = NotificationDeliveryStatusPending
+		delivery.NextAttemptAt = now
+
+		return s.deliveryRepository.Save(delivery)
+	}
+
+	event := newQuietHoursDigestEvent(heldDeliveries)
+	event.Workspace = s.getEventWorkspace(notifier.WorkspaceID)
+
+	digestDelivery := &NotificationDelivery{
+		NotifierID:    notifier.ID,
+		Title:         event.Title,
+		Message:       event.Message,
+		EventType:     event.Type,
+		Event:         event,
+		Status:        NotificationDeliveryStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	if err := s.deliveryRepository.Save(digestDelivery); err != nil {
+		return err
+	}
+
+	heldDeliveryIDs := make([]uuid.UUID, 0, len(heldDeliveries))
+	for _, delivery := range heldDeliveries {
+		heldDeliveryIDs = append(heldDeliveryIDs, delivery.ID)
+	}
+
+	return s.deliveryRepository.UpdateStatusByIDs(
+		heldDeliveryIDs,
+		NotificationDeliveryStatusDigested,
+	)
+}
+
+func newQuietHoursDigestEvent(
+	heldDeliveries []*NotificationDelivery,
+) *notifiers_events.NotificationEvent {
+	var message strings.Builder
+	for i, delivery := range heldDeliveries {
+		if i == maxDigestEventsCount {
+			fmt.Fprintf(&message, "...and %d more\n", len(heldDeliveries)-i)
+			break
+		}
+
+		fmt.Fprintf(
+			&message,
+			"%s: %s\n",
+			notifiers_events.FormatTime(delivery.CreatedAt),
+			delivery.Title,
+		)
+	}
+
+	return notifiers_events.NewNotificationEvent(
+		notifiers_events.NotificationEventQuietHoursDigest,
+		fmt.Sprintf("🔕 %d notifications during quiet hours", len(heldDeliveries)),
+		strings.TrimSuffix(message.String(), "\n"),
+	)
+}
+
+// getDeliveryRetryDelay doubles the delay after each failed attempt
+func getDeliveryRetryDelay(attempts int) time.Duration {
+	delay := deliveryRetryBaseDelay
+	for i := 1; i < attempts && delay < deliveryRetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, deliveryRetryMaxDelay)
+}
+
+func (s *NotifierService) GetNotifiersByWorkspaceID(workspaceID uuid.UUID) ([]*Notifier, error) {
+	return s.notifierRepository.FindByWorkspaceID(workspaceID)
+}
+
+// getEventWorkspace is used when the sender of the event does not know the
+// workspace, the notifier belongs to the workspace of the event
+func (s *NotifierService) getEventWorkspace(
+	workspaceID uuid.UUID,
+) *notifiers_events.EventWorkspace {
+	workspace, err := s.workspaceService.GetWorkspaceByID(workspaceID)
+	if err != nil {
+		s.logger.Warn("Failed to get workspace for notification", "error", err)
+		return &notifiers_events.EventWorkspace{ID: workspaceID}
+	}
+
+	return &notifiers_events.EventWorkspace{ID: workspace.ID, Name: workspace.Name}
 }
 
 func (s *NotifierService) OnBeforeWorkspaceDeletion(workspaceID uuid.UUID) error {
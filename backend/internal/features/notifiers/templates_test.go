@@ -0,0 +1,55 @@
+package notifiers
+
+import (
+	"testing"
+
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DefaultMessageTemplates_RenderAllEvents(t *testing.T) {
+	events := []*notifiers_events.NotificationEvent{
+		notifiers_events.NewSampleEvent(),
+		notifiers_events.NewTestEvent(),
+		notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventDatabaseUnavailable,
+			"❌ [db] DB is unavailable",
+			"",
+		),
+	}
+
+	for notifierType, defaultTemplate := range GetDefaultMessageTemplates() {
+		notifier := &Notifier{NotifierType: notifierType}
+
+		for _, event := range events {
+			_, err := notifiers_events.RenderTemplate(
+				nil,
+				notifier.getTemplateFormat(),
+				"",
+				defaultTemplate,
+				event,
+			)
+			assert.NoError(t, err, "notifier type %s, event %s", notifierType, event.Type)
+		}
+	}
+}
+
+func Test_ValidateNotifier_WhenTemplateInvalid_ReturnsError(t *testing.T) {
+	invalidTemplates := map[NotifierType]string{
+		NotifierTypeTelegram: "{{.UnknownField}}",
+		NotifierTypeSlack:    `[{"type": "section", "text": {{.Title}}}]`,
+		NotifierTypeEmail:    "<p>{{.Title}</p>",
+	}
+
+	for notifierType, messageTemplate := range invalidTemplates {
+		notifier := &Notifier{
+			Name:            "Test notifier",
+			NotifierType:    notifierType,
+			MessageTemplate: &messageTemplate,
+		}
+
+		err := notifier.Validate(nil)
+		assert.ErrorContains(t, err, "invalid message template", "notifier type %s", notifierType)
+	}
+}
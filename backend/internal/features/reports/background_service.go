@@ -0,0 +1,30 @@
+package reports
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const reportCheckInterval = time.Minute
+
+type ReportBackgroundService struct {
+	reportService *ReportService
+	logger        *slog.Logger
+}
+
+func (s *ReportBackgroundService) Run() {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if config.IsShouldShutdown() {
+			break
+		}
+
+		if err := s.reportService.SendDueReports(); err != nil {
+			s.logger.Error("failed to send workspace reports", "error", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package reports
+
+import (
+	"net/http"
+	"strings"
+
+	users_middleware "postgresus-backend/internal/features/users/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReportController struct {
+	reportService *ReportService
+}
+
+func (c *ReportController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/report-config", c.SaveReportConfig)
+	router.GET("/report-config/:workspaceId", c.GetReportConfig)
+	router.GET("/report-config/:workspaceId/preview", c.GetReportPreview)
+	router.POST("/report-config/:workspaceId/send", c.SendReport)
+}
+
+// SaveReportConfig
+// @Summary Save workspace report configuration
+// @Description Create or update the scheduled summary of the workspace sent through notifiers
+// @Tags report-config
+// @Accept json
+// @Produce json
+// @Param request body ReportConfig true "Report configuration data"
+// @Success 200 {object} ReportConfig
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /report-config [post]
+func (c *ReportController) SaveReportConfig(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request ReportConfig
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	savedConfig, err := c.reportService.SaveReportConfigWithAuth(user, &request)
+	if err != nil {
+		respondWithReportError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, savedConfig)
+}
+
+// GetReportConfig
+// @Summary Get workspace report configuration
+// @Description Get the scheduled summary configuration of the workspace
+// @Tags report-config
+// @Produce json
+// @Param workspaceId path string true "Workspace ID"
+// @Success 200 {object} ReportConfig
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /report-config/{workspaceId} [get]
+func (c *ReportController) GetReportConfig(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("workspaceId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	reportConfig, err := c.reportService.GetReportConfigWithAuth(user, workspaceID)
+	if err != nil {
+		respondWithReportError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reportConfig)
+}
+
+// GetReportPreview
+// @Summary Preview workspace report
+// @Description Build the report of the workspace as it would be sent now, without sending it
+// @Tags report-config
+// @Produce json
+// @Param workspaceId path string true "Workspace ID"
+// @Success 200 {object} notifiers_events.NotificationEvent
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /report-config/{workspaceId}/preview [get]
+func (c *ReportController) GetReportPreview(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("workspaceId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	event, err := c.reportService.GetReportPreviewWithAuth(user, workspaceID)
+	if err != nil {
+		respondWithReportError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, event)
+}
+
+// SendReport
+// @Summary Send workspace report
+// @Description Send the report to notifiers of the configuration now, the schedule does not change
+// @Tags report-config
+// @Produce json
+// @Param workspaceId path string true "Workspace ID"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Router /report-config/{workspaceId}/send [post]
+func (c *ReportController) SendReport(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("workspaceId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	if err := c.reportService.SendReportWithAuth(user, workspaceID); err != nil {
+		respondWithReportError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "report sent successfully"})
+}
+
+func respondWithReportError(ctx *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "insufficient permissions") {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,50 @@
+package reports
+
+import (
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	healthcheck_attempt "postgresus-backend/internal/features/healthcheck/attempt"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/storages"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/logger"
+)
+
+var reportConfigRepository = &ReportConfigRepository{}
+var reportService = &ReportService{
+	reportConfigRepository,
+	backups.GetBackupService(),
+	backups_config.GetBackupConfigService(),
+	databases.GetDatabaseService(),
+	storages.GetStorageService(),
+	healthcheck_attempt.GetHealthcheckAttemptService(),
+	notifiers.GetNotifierService(),
+	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
+	audit_logs.GetAuditLogService(),
+	users_services.GetUserService(),
+	logger.GetLogger(),
+}
+var reportController = &ReportController{
+	reportService,
+}
+
+var reportBackgroundService = &ReportBackgroundService{
+	reportService,
+	logger.GetLogger(),
+}
+
+func GetReportService() *ReportService {
+	return reportService
+}
+
+func GetReportController() *ReportController {
+	return reportController
+}
+
+func GetReportBackgroundService() *ReportBackgroundService {
+	return reportBackgroundService
+}
@@ -0,0 +1,114 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/intervals"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+)
+
+func newReportEvent(
+	report *notifiers_events.EventReport,
+	workspaceName string,
+) *notifiers_events.NotificationEvent {
+	var periodName string
+	switch intervals.IntervalType(report.Period) {
+	case intervals.IntervalDaily:
+		periodName = "Daily"
+	case intervals.IntervalMonthly:
+		periodName = "Monthly"
+	default:
+		periodName = "Weekly"
+	}
+
+	event := notifiers_events.NewNotificationEvent(
+		notifiers_events.NotificationEventWorkspaceReport,
+		fmt.Sprintf("📊 %s report for workspace \"%s\"", periodName, workspaceName),
+		buildReportMessage(report),
+	)
+	event.Report = report
+
+	return event
+}
+
+// buildReportMessage is the text summary for messengers, email renders
+// the report as a table
+func buildReportMessage(report *notifiers_events.EventReport) string {
+	var message strings.Builder
+
+	var overdueCount, failedBackupsCount int64
+	for _, database := range report.Databases {
+		if database.IsOverdue {
+			overdueCount++
+		}
+
+		failedBackupsCount += database.FailedBackupsCount
+	}
+
+	fmt.Fprintf(
+		&message,
+		"%s - %s\nDatabases: %d, overdue: %d, failed backups: %d\n",
+		notifiers_events.FormatTime(report.PeriodStart),
+		notifiers_events.FormatTime(report.PeriodEnd),
+		len(report.Databases),
+		overdueCount,
+		failedBackupsCount,
+	)
+
+	for _, database := range report.Databases {
+		status := "✅"
+		if database.IsOverdue {
+			status = "⚠️"
+		} else if database.FailedBackupsCount > 0 {
+			status = "❌"
+		}
+
+		lastBackup := "never"
+		if database.LastBackupAt != nil {
+			lastBackup = notifiers_events.FormatTime(*database.LastBackupAt)
+		}
+
+		fmt.Fprintf(
+			&message,
+			"\n%s %s: last backup %s, completed %d, failed %d, size %s, growth %s, uptime %s",
+			status,
+			database.Name,
+			lastBackup,
+			database.CompletedBackupsCount,
+			database.FailedBackupsCount,
+			notifiers_events.FormatSize(database.LastBackupSizeMb),
+			notifiers_events.FormatPercent(database.SizeGrowthPercent),
+			notifiers_events.FormatPercent(database.UptimePercent),
+		)
+	}
+
+	if len(report.Storages) > 0 {
+		message.WriteString("\n\nStorages:")
+
+		for _, storage := range report.Storages {
+			fmt.Fprintf(
+				&message,
+				"\n%s: %s",
+				storage.Name,
+				notifiers_events.FormatSize(storage.UsedMb),
+			)
+		}
+	}
+
+	return message.String()
+}
+
+// getSizeGrowthPercent compares the last backup of the period with the last
+// backup of the previous period
+func getSizeGrowthPercent(lastBackup, previousBackup *backups.Backup) *float64 {
+	if lastBackup == nil || previousBackup == nil || previousBackup.BackupSizeMb == 0 {
+		return nil
+	}
+
+	growthPercent := (lastBackup.BackupSizeMb - previousBackup.BackupSizeMb) /
+		previousBackup.BackupSizeMb * 100
+
+	return &growthPercent
+}
@@ -0,0 +1,71 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"postgresus-backend/internal/features/backups/backups"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetSizeGrowthPercent_ComparedWithPreviousPeriod(t *testing.T) {
+	lastBackup := &backups.Backup{BackupSizeMb: 150}
+	previousBackup := &backups.Backup{BackupSizeMb: 100}
+
+	growthPercent := getSizeGrowthPercent(lastBackup, previousBackup)
+	assert.NotNil(t, growthPercent)
+	assert.InDelta(t, 50, *growthPercent, 0.001)
+
+	decreasePercent := getSizeGrowthPercent(previousBackup, lastBackup)
+	assert.NotNil(t, decreasePercent)
+	assert.InDelta(t, -33.333, *decreasePercent, 0.001)
+
+	assert.Nil(t, getSizeGrowthPercent(nil, previousBackup))
+	assert.Nil(t, getSizeGrowthPercent(lastBackup, nil))
+	assert.Nil(t, getSizeGrowthPercent(lastBackup, &backups.Backup{}))
+}
+
+func Test_NewReportEvent_MessageSummarizesDatabasesAndStorages(t *testing.T) {
+	periodEnd := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	lastBackupAt := periodEnd.Add(-time.Hour)
+	uptimePercent := 99.5
+
+	report := &notifiers_events.EventReport{
+		Period:      "WEEKLY",
+		PeriodStart: periodEnd.AddDate(0, 0, -7),
+		PeriodEnd:   periodEnd,
+		Databases: []*notifiers_events.EventReportDatabase{
+			{
+				ID:                    uuid.New(),
+				Name:                  "app",
+				LastBackupAt:          &lastBackupAt,
+				CompletedBackupsCount: 6,
+				FailedBackupsCount:    1,
+				LastBackupSizeMb:      512,
+				UptimePercent:         &uptimePercent,
+			},
+			{ID: uuid.New(), Name: "billing", IsOverdue: true},
+		},
+		Storages: []*notifiers_events.EventReportStorage{
+			{ID: uuid.New(), Name: "S3", UsedMb: 2048},
+		},
+	}
+
+	event := newReportEvent(report, "prod")
+
+	assert.Equal(t, notifiers_events.NotificationEventWorkspaceReport, event.Type)
+	assert.Equal(t, "📊 Weekly report for workspace \"prod\"", event.Title)
+	assert.Same(t, report, event.Report)
+	assert.Contains(t, event.Message, "Databases: 2, overdue: 1, failed backups: 1")
+	assert.Contains(
+		t,
+		event.Message,
+		"❌ app: last backup 2025-01-13 08:00 UTC, completed 6, failed 1, "+
+			"size 512.00 MB, growth n/a, uptime 99.5%",
+	)
+	assert.Contains(t, event.Message, "⚠️ billing: last backup never")
+	assert.Contains(t, event.Message, "S3: 2.00 GB")
+}
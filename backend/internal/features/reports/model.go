@@ -0,0 +1,130 @@
+package reports
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/features/intervals"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReportConfig describes the scheduled summary of the workspace sent
+// through the chosen notifiers
+type ReportConfig struct {
+	WorkspaceID uuid.UUID `json:"workspaceId" gorm:"column:workspace_id;type:uuid;primaryKey"`
+
+	IsEnabled bool `json:"isEnabled" gorm:"column:is_enabled;type:boolean;not null;default:false"`
+
+	// ReportInterval is daily, weekly or monthly. The report covers the
+	// same period before it is sent
+	ReportIntervalID *uuid.UUID          `json:"reportIntervalId"         gorm:"column:report_interval_id;type:uuid"`
+	ReportInterval   *intervals.Interval `json:"reportInterval,omitempty" gorm:"foreignKey:ReportIntervalID"`
+
+	NotifierIDs       []uuid.UUID `json:"notifierIds" gorm:"-"`
+	NotifierIDsString string      `json:"-"           gorm:"column:notifier_ids;type:text;not null;default:''"`
+
+	LastSentAt *time.Time `json:"lastSentAt" gorm:"column:last_sent_at"`
+
+	// UpdatedByUserID is the user who saved the config last. Scheduled
+	// reports contain only databases this user can view, so members with
+	// per-database roles do not send other databases to the notifiers
+	UpdatedByUserID *uuid.UUID `json:"updatedByUserId" gorm:"column:updated_by_user_id;type:uuid"`
+}
+
+func (c *ReportConfig) TableName() string {
+	return "report_configs"
+}
+
+func (c *ReportConfig) BeforeSave(_ *gorm.DB) error {
+	notifierIDs := make([]string, 0, len(c.NotifierIDs))
+	for _, notifierID := range c.NotifierIDs {
+		notifierIDs = append(notifierIDs, notifierID.String())
+	}
+
+	c.NotifierIDsString = strings.Join(notifierIDs, ",")
+
+	return nil
+}
+
+func (c *ReportConfig) AfterFind(_ *gorm.DB) error {
+	c.NotifierIDs = []uuid.UUID{}
+
+	if c.NotifierIDsString == "" {
+		return nil
+	}
+
+	for _, notifierID := range strings.Split(c.NotifierIDsString, ",") {
+		parsedID, err := uuid.Parse(notifierID)
+		if err != nil {
+			return err
+		}
+
+		c.NotifierIDs = append(c.NotifierIDs, parsedID)
+	}
+
+	return nil
+}
+
+func (c *ReportConfig) Validate() error {
+	if c.ReportInterval != nil {
+		switch c.ReportInterval.Interval {
+		case intervals.IntervalDaily, intervals.IntervalWeekly, intervals.IntervalMonthly:
+		default:
+			return errors.New("report interval must be daily, weekly or monthly")
+		}
+
+		if err := c.ReportInterval.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.IsEnabled {
+		if c.ReportIntervalID == nil && c.ReportInterval == nil {
+			return errors.New("report interval is required")
+		}
+
+		if len(c.NotifierIDs) == 0 {
+			return errors.New("at least one notifier is required")
+		}
+	}
+
+	return nil
+}
+
+// IsDue returns true when the scheduled time of the report passed since
+// the last report
+func (c *ReportConfig) IsDue(now time.Time) bool {
+	if !c.IsEnabled || c.ReportInterval == nil {
+		return false
+	}
+
+	return c.ReportInterval.ShouldTriggerBackup(now, c.LastSentAt)
+}
+
+// getPeriodStart returns the start of the period covered by the report
+// ending at the given time. Weekly report is used when there is no config
+func (c *ReportConfig) getPeriodStart(periodEnd time.Time) time.Time {
+	if c.ReportInterval == nil {
+		return periodEnd.AddDate(0, 0, -7)
+	}
+
+	switch c.ReportInterval.Interval {
+	case intervals.IntervalDaily:
+		return periodEnd.AddDate(0, 0, -1)
+	case intervals.IntervalMonthly:
+		return periodEnd.AddDate(0, -1, 0)
+	default:
+		return periodEnd.AddDate(0, 0, -7)
+	}
+}
+
+func (c *ReportConfig) getPeriod() intervals.IntervalType {
+	if c.ReportInterval == nil {
+		return intervals.IntervalWeekly
+	}
+
+	return c.ReportInterval.Interval
+}
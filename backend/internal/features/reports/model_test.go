@@ -0,0 +1,71 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"postgresus-backend/internal/features/intervals"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReportConfig_Validate_EnabledConfigRequiresIntervalAndNotifiers(t *testing.T) {
+	reportConfig := &ReportConfig{WorkspaceID: uuid.New(), IsEnabled: true}
+	assert.EqualError(t, reportConfig.Validate(), "report interval is required")
+
+	reportConfig.ReportInterval = &intervals.Interval{Interval: intervals.IntervalHourly}
+	assert.EqualError(t, reportConfig.Validate(), "report interval must be daily, weekly or monthly")
+
+	timeOfDay := "09:00"
+	reportConfig.ReportInterval = &intervals.Interval{
+		Interval:  intervals.IntervalDaily,
+		TimeOfDay: &timeOfDay,
+	}
+	assert.EqualError(t, reportConfig.Validate(), "at least one notifier is required")
+
+	reportConfig.NotifierIDs = []uuid.UUID{uuid.New()}
+	assert.NoError(t, reportConfig.Validate())
+}
+
+func Test_ReportConfig_IsDue_SentOnceAfterScheduledTime(t *testing.T) {
+	timeOfDay := "09:00"
+	monday := int(time.Monday)
+	lastSentAt := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+
+	reportConfig := &ReportConfig{
+		IsEnabled: true,
+		ReportInterval: &intervals.Interval{
+			Interval:  intervals.IntervalWeekly,
+			TimeOfDay: &timeOfDay,
+			Weekday:   &monday,
+		},
+		LastSentAt: &lastSentAt,
+	}
+
+	assert.False(t, reportConfig.IsDue(time.Date(2025, 1, 6, 8, 59, 0, 0, time.UTC)))
+	assert.True(t, reportConfig.IsDue(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)))
+
+	sentAt := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	reportConfig.LastSentAt = &sentAt
+	assert.False(t, reportConfig.IsDue(time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, reportConfig.IsDue(time.Date(2025, 1, 13, 9, 1, 0, 0, time.UTC)))
+
+	reportConfig.IsEnabled = false
+	assert.False(t, reportConfig.IsDue(time.Date(2025, 1, 13, 9, 1, 0, 0, time.UTC)))
+}
+
+func Test_ReportConfig_NotifierIDs_StoredAsText(t *testing.T) {
+	notifierIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	reportConfig := &ReportConfig{NotifierIDs: notifierIDs}
+	assert.NoError(t, reportConfig.BeforeSave(nil))
+
+	foundConfig := &ReportConfig{NotifierIDsString: reportConfig.NotifierIDsString}
+	assert.NoError(t, foundConfig.AfterFind(nil))
+	assert.Equal(t, notifierIDs, foundConfig.NotifierIDs)
+
+	emptyConfig := &ReportConfig{}
+	assert.NoError(t, emptyConfig.AfterFind(nil))
+	assert.Empty(t, emptyConfig.NotifierIDs)
+}
@@ -0,0 +1,73 @@
+package reports
+
+import (
+	"errors"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReportConfigRepository struct{}
+
+func (r *ReportConfigRepository) Save(reportConfig *ReportConfig) (*ReportConfig, error) {
+	db := storage.GetDb()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if reportConfig.ReportInterval != nil {
+			if reportConfig.ReportInterval.ID == uuid.Nil {
+				if err := tx.Create(reportConfig.ReportInterval).Error; err != nil {
+					return err
+				}
+			} else {
+				if err := tx.Save(reportConfig.ReportInterval).Error; err != nil {
+					return err
+				}
+			}
+
+			reportConfig.ReportIntervalID = &reportConfig.ReportInterval.ID
+		}
+
+		return tx.Omit("ReportInterval").
+			Save(reportConfig).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reportConfig, nil
+}
+
+func (r *ReportConfigRepository) FindByWorkspaceID(workspaceID uuid.UUID) (*ReportConfig, error) {
+	var reportConfig ReportConfig
+
+	if err := storage.
+		GetDb().
+		Preload("ReportInterval").
+		Where("workspace_id = ?", workspaceID).
+		First(&reportConfig).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &reportConfig, nil
+}
+
+func (r *ReportConfigRepository) FindEnabled() ([]*ReportConfig, error) {
+	var reportConfigs []*ReportConfig
+
+	if err := storage.
+		GetDb().
+		Preload("ReportInterval").
+		Where("is_enabled = ?", true).
+		Find(&reportConfigs).Error; err != nil {
+		return nil, err
+	}
+
+	return reportConfigs, nil
+}
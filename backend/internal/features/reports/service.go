@@ -0,0 +1,438 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	healthcheck_attempt "postgresus-backend/internal/features/healthcheck/attempt"
+	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/storages"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+
+	"github.com/google/uuid"
+)
+
+// overdueGracePeriod is given to the scheduled backup to complete before
+// the database is reported as overdue
+const overdueGracePeriod = time.Hour
+
+type ReportService struct {
+	reportConfigRepository    *ReportConfigRepository
+	backupService             *backups.BackupService
+	backupConfigService       *backups_config.BackupConfigService
+	databaseService           *databases.DatabaseService
+	storageService            *storages.StorageService
+	healthcheckAttemptService *healthcheck_attempt.HealthcheckAttemptService
+	notifierService           *notifiers.NotifierService
+	workspaceService          *workspaces_services.WorkspaceService
+	authorizationService      *workspaces_services.AuthorizationService
+	auditLogService           *audit_logs.AuditLogService
+	userService               *users_services.UserService
+	logger                    *slog.Logger
+}
+
+func (s *ReportService) GetReportConfigWithAuth(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) (*ReportConfig, error) {
+	if err := s.checkPermission(
+		user,
+		workspaceID,
+		users_enums.WorkspacePermissionView,
+	); err != nil {
+		return nil, err
+	}
+
+	reportConfig, err := s.reportConfigRepository.FindByWorkspaceID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reportConfig == nil {
+		return &ReportConfig{
+			WorkspaceID: workspaceID,
+			IsEnabled:   false,
+			NotifierIDs: []uuid.UUID{},
+		}, nil
+	}
+
+	return reportConfig, nil
+}
+
+func (s *ReportService) SaveReportConfigWithAuth(
+	user *users_models.User,
+	reportConfig *ReportConfig,
+) (*ReportConfig, error) {
+	if err := reportConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(
+		user,
+		reportConfig.WorkspaceID,
+		users_enums.WorkspacePermissionManageNotifiers,
+	); err != nil {
+		return nil, err
+	}
+
+	workspaceNotifiers, err := s.notifierService.GetNotifiersByWorkspaceID(
+		reportConfig.WorkspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, notifierID := range reportConfig.NotifierIDs {
+		isWorkspaceNotifier := slices.ContainsFunc(
+			workspaceNotifiers,
+			func(notifier *notifiers.Notifier) bool { return notifier.ID == notifierID },
+		)
+		if !isWorkspaceNotifier {
+			return nil, errors.New("notifier does not belong to this workspace")
+		}
+	}
+
+	existingConfig, err := s.reportConfigRepository.FindByWorkspaceID(reportConfig.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	configBefore := audit_logs.Snapshot(existingConfig)
+
+	// the first report is sent at the next scheduled time, not at once
+	if existingConfig != nil && existingConfig.LastSentAt != nil {
+		reportConfig.LastSentAt = existingConfig.LastSentAt
+	} else {
+		now := time.Now().UTC()
+		reportConfig.LastSentAt = &now
+	}
+
+	reportConfig.UpdatedByUserID = &user.ID
+
+	savedConfig, err := s.reportConfigRepository.Save(reportConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceReportConfig,
+		ResourceID:   &savedConfig.WorkspaceID,
+		Message:      "Workspace report config updated",
+		User:         user,
+		WorkspaceID:  &savedConfig.WorkspaceID,
+		Before:       configBefore,
+		After:        savedConfig,
+	})
+
+	return savedConfig, nil
+}
+
+// GetReportPreviewWithAuth builds the report as it would be sent now. It
+// contains only databases visible to the user
+func (s *ReportService) GetReportPreviewWithAuth(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) (*notifiers_events.NotificationEvent, error) {
+	reportConfig, err := s.GetReportConfigWithAuth(user, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceDatabases, err := s.databaseService.GetDatabasesByWorkspace(user, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceStorages, err := s.storageService.GetStorages(user, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildReportEvent(
+		reportConfig,
+		workspaceDatabases,
+		workspaceStorages,
+		time.Now().UTC(),
+	)
+}
+
+// SendReportWithAuth sends the report to notifiers of the config at once.
+// The schedule of the report does not change
+func (s *ReportService) SendReportWithAuth(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+) error {
+	if err := s.checkPermission(
+		user,
+		workspaceID,
+		users_enums.WorkspacePermissionManageNotifiers,
+	); err != nil {
+		return err
+	}
+
+	reportConfig, err := s.reportConfigRepository.FindByWorkspaceID(workspaceID)
+	if err != nil {
+		return err
+	}
+	if reportConfig == nil || len(reportConfig.NotifierIDs) == 0 {
+		return errors.New("report notifiers are not configured")
+	}
+
+	return s.sendReport(reportConfig, time.Now().UTC())
+}
+
+func (s *ReportService) SendDueReports() error {
+	reportConfigs, err := s.reportConfigRepository.FindEnabled()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for _, reportConfig := range reportConfigs {
+		if !reportConfig.IsDue(now) {
+			continue
+		}
+
+		// the time is saved before sending, so a failing report is not
+		// rebuilt every minute until the next scheduled time
+		reportConfig.LastSentAt = &now
+		if _, err := s.reportConfigRepository.Save(reportConfig); err != nil {
+			s.logger.Error(
+				"Failed to save report config",
+				"workspaceId",
+				reportConfig.WorkspaceID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		if err := s.sendReport(reportConfig, now); err != nil {
+			s.logger.Error(
+				"Failed to send workspace report",
+				"workspaceId",
+				reportConfig.WorkspaceID,
+				"error",
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// sendReport sends the report built on behalf of the user who saved the
+// config, the same way as the preview of this user
+func (s *ReportService) sendReport(reportConfig *ReportConfig, now time.Time) error {
+	if reportConfig.UpdatedByUserID == nil {
+		return errors.New("report config has no owner, save it again to send reports")
+	}
+
+	updatedBy, err := s.userService.GetUserByID(*reportConfig.UpdatedByUserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user who configured reports: %w", err)
+	}
+
+	if !updatedBy.IsActiveUser() {
+		return errors.New("user who configured reports is not active anymore")
+	}
+
+	workspaceDatabases, err := s.databaseService.GetDatabasesByWorkspace(
+		updatedBy,
+		reportConfig.WorkspaceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	workspaceStorages, err := s.storageService.GetStorages(updatedBy, reportConfig.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.buildReportEvent(reportConfig, workspaceDatabases, workspaceStorages, now)
+	if err != nil {
+		return err
+	}
+
+	workspaceNotifiers, err := s.notifierService.GetNotifiersByWorkspaceID(
+		reportConfig.WorkspaceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	// notifiers removed after the config was saved are skipped
+	for _, notifier := range workspaceNotifiers {
+		if slices.Contains(reportConfig.NotifierIDs, notifier.ID) {
+			s.notifierService.SendNotification(notifier, event)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReportService) buildReportEvent(
+	reportConfig *ReportConfig,
+	workspaceDatabases []*databases.Database,
+	workspaceStorages []*storages.Storage,
+	periodEnd time.Time,
+) (*notifiers_events.NotificationEvent, error) {
+	workspace, err := s.workspaceService.GetWorkspaceByID(reportConfig.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &notifiers_events.EventReport{
+		Period:      string(reportConfig.getPeriod()),
+		PeriodStart: reportConfig.getPeriodStart(periodEnd),
+		PeriodEnd:   periodEnd,
+		Databases:   make([]*notifiers_events.EventReportDatabase, 0, len(workspaceDatabases)),
+		Storages:    make([]*notifiers_events.EventReportStorage, 0, len(workspaceStorages)),
+	}
+
+	for _, database := range workspaceDatabases {
+		reportDatabase, err := s.buildReportDatabase(database, report.PeriodStart, periodEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build report of database %s: %w", database.ID, err)
+		}
+
+		report.Databases = append(report.Databases, reportDatabase)
+	}
+
+	storageIDs := make([]uuid.UUID, 0, len(workspaceStorages))
+	for _, storage := range workspaceStorages {
+		storageIDs = append(storageIDs, storage.ID)
+	}
+
+	usedMbByStorageID, err := s.backupService.GetStoragesUsedMb(storageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, storage := range workspaceStorages {
+		report.Storages = append(report.Storages, &notifiers_events.EventReportStorage{
+			ID:     storage.ID,
+			Name:   storage.Name,
+			Type:   string(storage.Type),
+			UsedMb: usedMbByStorageID[storage.ID],
+		})
+	}
+
+	event := newReportEvent(report, workspace.Name)
+	event.Workspace = &notifiers_events.EventWorkspace{ID: workspace.ID, Name: workspace.Name}
+
+	return event, nil
+}
+
+func (s *ReportService) buildReportDatabase(
+	database *databases.Database,
+	periodStart, periodEnd time.Time,
+) (*notifiers_events.EventReportDatabase, error) {
+	reportDatabase := &notifiers_events.EventReportDatabase{
+		ID:   database.ID,
+		Name: database.Name,
+		Type: string(database.Type),
+	}
+
+	periodBackups, err := s.backupService.GetBackupsCreatedBetween(
+		database.ID,
+		periodStart,
+		periodEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastPeriodBackup *backups.Backup
+	for _, backup := range periodBackups {
+		switch backup.Status {
+		case backups.BackupStatusCompleted:
+			reportDatabase.CompletedBackupsCount++
+
+			// backups are sorted newest first
+			if lastPeriodBackup == nil {
+				lastPeriodBackup = backup
+			}
+		case backups.BackupStatusFailed:
+			reportDatabase.FailedBackupsCount++
+		}
+	}
+
+	lastBackup, err := s.backupService.GetLastCompletedBackupBefore(database.ID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastBackup != nil {
+		reportDatabase.LastBackupAt = &lastBackup.CreatedAt
+		reportDatabase.LastBackupSizeMb = lastBackup.BackupSizeMb
+	}
+
+	previousPeriodBackup, err := s.backupService.GetLastCompletedBackupBefore(
+		database.ID,
+		periodStart,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reportDatabase.SizeGrowthPercent = getSizeGrowthPercent(lastPeriodBackup, previousPeriodBackup)
+
+	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(database.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if backupConfig.IsBackupsEnabled && backupConfig.BackupInterval != nil {
+		reportDatabase.IsOverdue = backupConfig.BackupInterval.ShouldTriggerBackup(
+			periodEnd.Add(-overdueGracePeriod),
+			reportDatabase.LastBackupAt,
+		)
+	}
+
+	reportDatabase.UptimePercent, err = s.healthcheckAttemptService.GetUptimePercent(
+		database.ID,
+		periodStart,
+		periodEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reportDatabase, nil
+}
+
+func (s *ReportService) checkPermission(
+	user *users_models.User,
+	workspaceID uuid.UUID,
+	permission users_enums.WorkspacePermission,
+) error {
+	hasPermission, err := s.authorizationService.HasPermission(workspaceID, user, permission)
+	if err != nil {
+		return err
+	}
+
+	if !hasPermission {
+		if permission == users_enums.WorkspacePermissionView {
+			return errors.New("insufficient permissions to view reports of this workspace")
+		}
+
+		return errors.New("insufficient permissions to manage reports of this workspace")
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@ type RestoreController struct {
 func (c *RestoreController) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/restores/:backupId", c.GetRestores)
 	router.POST("/restores/:backupId/restore", c.RestoreBackup)
+	router.GET("/restores/:backupId/toc", c.GetBackupToc)
 }
 
 // GetRestores
@@ -51,10 +52,11 @@ func (c *RestoreController) GetRestores(ctx *gin.Context) {
 
 // RestoreBackup
 // @Summary Restore a backup
-// @Description Start a restore process for a specific backup
+// @Description Start a restore process for a specific backup. When restores of the database require approval, the approval request is created instead
 // @Tags restores
 // @Param backupId path string true "Backup ID"
 // @Success 200 {object} map[string]string
+// @Success 202 {object} map[string]string
 // @Failure 400
 // @Failure 401
 // @Router /restores/{backupId}/restore [post]
@@ -77,10 +79,51 @@ func (c *RestoreController) RestoreBackup(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.restoreService.RestoreBackupWithAuth(user, backupID, requestDTO); err != nil {
+	approvalRequest, err := c.restoreService.RestoreBackupWithAuth(user, backupID, requestDTO)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if approvalRequest != nil {
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"message":           "restore is waiting for approval",
+			"approvalRequestId": approvalRequest.ID,
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "restore started successfully"})
 }
+
+// GetBackupToc
+// @Summary List objects of a backup
+// @Description List table of contents of PostgreSQL logical backup (pg_restore --list) to pick objects for selective restore
+// @Tags restores
+// @Produce json
+// @Param backupId path string true "Backup ID"
+// @Success 200 {array} models.TocEntry
+// @Failure 400
+// @Failure 401
+// @Router /restores/{backupId}/toc [get]
+func (c *RestoreController) GetBackupToc(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	backupID, err := uuid.Parse(ctx.Param("backupId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid backup ID"})
+		return
+	}
+
+	tocEntries, err := c.restoreService.GetBackupTocWithAuth(user, backupID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tocEntries)
+}
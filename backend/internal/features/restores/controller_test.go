@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
+	"postgresus-backend/internal/features/approvals"
 	audit_logs "postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -28,6 +29,7 @@ import (
 	users_services "postgresus-backend/internal/features/users/services"
 	users_testing "postgresus-backend/internal/features/users/testing"
 	workspaces_controllers "postgresus-backend/internal/features/workspaces/controllers"
+	workspaces_dto "postgresus-backend/internal/features/workspaces/dto"
 	workspaces_models "postgresus-backend/internal/features/workspaces/models"
 	workspaces_testing "postgresus-backend/internal/features/workspaces/testing"
 	util_encryption "postgresus-backend/internal/util/encryption"
@@ -39,10 +41,12 @@ func createTestRouter() *gin.Engine {
 	router := workspaces_testing.CreateTestRouter(
 		workspaces_controllers.GetWorkspaceController(),
 		workspaces_controllers.GetMembershipController(),
+		workspaces_controllers.GetCustomRoleController(),
 		databases.GetDatabaseController(),
 		backups_config.GetBackupConfigController(),
 		backups.GetBackupController(),
 		GetRestoreController(),
+		approvals.GetApprovalController(),
 	)
 	return router
 }
@@ -250,6 +254,265 @@ func Test_RestoreBackup_AuditLogWritten(t *testing.T) {
 	assert.True(t, found, "Audit log for restore not found")
 }
 
+func Test_RestoreBackup_WithInvalidNewDatabaseName_ReturnsBadRequest(t *testing.T) {
+	router := createTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	_, backup := createTestDatabaseWithBackupForRestore(workspace, owner, router)
+
+	restoreUsername := "postgres"
+	restorePassword := "postgres"
+	newDatabaseName := "restored; DROP DATABASE app"
+
+	request := RestoreBackupRequest{
+		RestoreUsername: &restoreUsername,
+		RestorePassword: &restorePassword,
+		NewDatabaseName: &newDatabaseName,
+	}
+
+	testResp := test_utils.MakePostRequest(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s/restore", backup.ID.String()),
+		"Bearer "+owner.Token,
+		request,
+		http.StatusBadRequest,
+	)
+
+	assert.Contains(t, string(testResp.Body), "new database name must start with")
+}
+
+func Test_RestoreBackup_WhenCustomRoleCannotRestoreToOtherTarget_ReturnsForbidden(t *testing.T) {
+	router := createTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	_, backup := createTestDatabaseWithBackupForRestore(workspace, owner, router)
+
+	member := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspaces_testing.AddMemberToWorkspaceViaOwner(
+		workspace,
+		member,
+		users_enums.WorkspaceRoleMember,
+		router,
+	)
+
+	var role workspaces_dto.CustomRoleResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles",
+		"Bearer "+owner.Token,
+		workspaces_dto.SaveCustomRoleRequestDTO{
+			Name: "In place restore",
+			Permissions: []users_enums.WorkspacePermission{
+				users_enums.WorkspacePermissionView,
+				users_enums.WorkspacePermissionRestore,
+			},
+		},
+		http.StatusOK,
+		&role,
+	)
+
+	test_utils.MakePutRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+
+			"/members/"+member.UserID.String()+"/custom-role",
+		"Bearer "+owner.Token,
+		workspaces_dto.SetMemberCustomRoleRequestDTO{CustomRoleID: &role.ID},
+		http.StatusOK,
+	)
+
+	otherDbName := "other_db"
+	request := RestoreBackupRequest{
+		PostgresqlDatabase: &postgresql.PostgresqlDatabase{
+			Version:  tools.PostgresqlVersion16,
+			Host:     "localhost",
+			Port:     5432,
+			Username: "postgres",
+			Password: "postgres",
+			Database: &otherDbName,
+		},
+	}
+
+	testResp := test_utils.MakePostRequest(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s/restore", backup.ID.String()),
+		"Bearer "+member.Token,
+		request,
+		http.StatusBadRequest,
+	)
+
+	assert.Contains(t, string(testResp.Body), "to another database")
+}
+
+func Test_RestoreBackup_WhenApprovalRequired_RestoreStartedAfterAnotherAdminApproves(t *testing.T) {
+	router := createTestRouter()
+	approvals.GetApprovalService().SetActionExecutor(approvals.ApprovalActionRestore, restoreService)
+
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	database, backup := createTestDatabaseWithBackupForRestore(workspace, owner, router)
+
+	configService := backups_config.GetBackupConfigService()
+	config, err := configService.GetBackupConfigByDbId(database.ID)
+	assert.NoError(t, err)
+	config.IsRestoreApprovalRequired = true
+	_, err = configService.SaveBackupConfig(config)
+	assert.NoError(t, err)
+
+	admin := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspaces_testing.AddMemberToWorkspaceViaOwner(
+		workspace,
+		admin,
+		users_enums.WorkspaceRoleAdmin,
+		router,
+	)
+
+	request := RestoreBackupRequest{
+		PostgresqlDatabase: &postgresql.PostgresqlDatabase{
+			Version:  tools.PostgresqlVersion16,
+			Host:     "localhost",
+			Port:     5432,
+			Username: "postgres",
+			Password: "postgres",
+		},
+	}
+
+	var restoreResponse struct {
+		ApprovalRequestID uuid.UUID `json:"approvalRequestId"`
+	}
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s/restore", backup.ID.String()),
+		"Bearer "+owner.Token,
+		request,
+		http.StatusAccepted,
+		&restoreResponse,
+	)
+	assert.NotEqual(t, uuid.Nil, restoreResponse.ApprovalRequestID)
+
+	var restores []*models.Restore
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s", backup.ID.String()),
+		"Bearer "+owner.Token,
+		http.StatusOK,
+		&restores,
+	)
+	assert.Equal(t, 0, len(restores))
+
+	approveURL := fmt.Sprintf(
+		"/api/v1/approvals/%s/approve",
+		restoreResponse.ApprovalRequestID.String(),
+	)
+
+	testResp := test_utils.MakePostRequest(
+		t,
+		router,
+		approveURL,
+		"Bearer "+owner.Token,
+		nil,
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(testResp.Body), "another admin")
+
+	var approvalRequest approvals.ApprovalRequest
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		approveURL,
+		"Bearer "+admin.Token,
+		nil,
+		http.StatusOK,
+		&approvalRequest,
+	)
+	assert.Equal(t, approvals.ApprovalStatusApproved, approvalRequest.Status)
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		approveURL,
+		"Bearer "+admin.Token,
+		nil,
+		http.StatusBadRequest,
+	)
+
+	time.Sleep(100 * time.Millisecond)
+
+	auditLogs, err := audit_logs.GetAuditLogService().GetWorkspaceAuditLogs(
+		workspace.ID,
+		&audit_logs.GetAuditLogsRequest{Limit: 100, Offset: 0},
+	)
+	assert.NoError(t, err)
+
+	messages := make([]string, 0, len(auditLogs.AuditLogs))
+	for _, log := range auditLogs.AuditLogs {
+		messages = append(messages, log.Message)
+	}
+	joinedMessages := strings.Join(messages, "\n")
+
+	assert.Contains(t, joinedMessages, "Approval requested for restore")
+	assert.Contains(t, joinedMessages, "Approval request approved for restore")
+	assert.Contains(t, joinedMessages, "Database restored from backup")
+}
+
+func Test_RestoreBackup_WhenTargetDatabaseRequiresApproval_ApprovalRequested(t *testing.T) {
+	router := createTestRouter()
+
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace := workspaces_testing.CreateTestWorkspace("Test Workspace", owner, router)
+
+	_, backup := createTestDatabaseWithBackupForRestore(workspace, owner, router)
+	targetDatabase, _ := createTestDatabaseWithBackupForRestore(workspace, owner, router)
+
+	configService := backups_config.GetBackupConfigService()
+	targetConfig, err := configService.GetBackupConfigByDbId(targetDatabase.ID)
+	assert.NoError(t, err)
+	targetConfig.IsRestoreApprovalRequired = true
+	_, err = configService.SaveBackupConfig(targetConfig)
+	assert.NoError(t, err)
+
+	restoreUsername := "postgres"
+	restorePassword := "postgres"
+	request := RestoreBackupRequest{
+		TargetDatabaseId: &targetDatabase.ID,
+		RestoreUsername:  &restoreUsername,
+		RestorePassword:  &restorePassword,
+	}
+
+	var restoreResponse struct {
+		ApprovalRequestID uuid.UUID `json:"approvalRequestId"`
+	}
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s/restore", backup.ID.String()),
+		"Bearer "+owner.Token,
+		request,
+		http.StatusAccepted,
+		&restoreResponse,
+	)
+	assert.NotEqual(t, uuid.Nil, restoreResponse.ApprovalRequestID)
+
+	var restores []*models.Restore
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		fmt.Sprintf("/api/v1/restores/%s", backup.ID.String()),
+		"Bearer "+owner.Token,
+		http.StatusOK,
+		&restores,
+	)
+	assert.Equal(t, 0, len(restores))
+}
+
 func createTestDatabaseWithBackupForRestore(
 	workspace *workspaces_models.Workspace,
 	owner *users_dto.SignInResponseDTO,
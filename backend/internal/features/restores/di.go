@@ -1,6 +1,7 @@
 package restores
 
 import (
+	"postgresus-backend/internal/features/approvals"
 	audit_logs "postgresus-backend/internal/features/audit_logs"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
@@ -22,10 +23,11 @@ var restoreService = &RestoreService{
 	usecases.GetRestoreBackupUsecase(),
 	databases.GetDatabaseService(),
 	logger.GetLogger(),
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 	audit_logs.GetAuditLogService(),
 	encryption.GetFieldEncryptor(),
 	notifiers.GetNotifierService(),
+	approvals.GetApprovalService(),
 }
 var restoreController = &RestoreController{
 	restoreService,
@@ -46,4 +48,8 @@ func GetRestoreBackgroundService() *RestoreBackgroundService {
 
 func SetupDependencies() {
 	backups.GetBackupService().AddBackupRemoveListener(restoreService)
+	approvals.GetApprovalService().SetActionExecutor(
+		approvals.ApprovalActionRestore,
+		restoreService,
+	)
 }
@@ -1,10 +1,13 @@
 package restores
 
 import (
+	"time"
+
 	"postgresus-backend/internal/features/databases/databases/mariadb"
 	"postgresus-backend/internal/features/databases/databases/mongodb"
 	"postgresus-backend/internal/features/databases/databases/mysql"
 	"postgresus-backend/internal/features/databases/databases/postgresql"
+	"postgresus-backend/internal/features/restores/models"
 
 	"github.com/google/uuid"
 )
@@ -23,4 +26,28 @@ type RestoreBackupRequest struct {
 	MysqlDatabase      *mysql.MysqlDatabase           `json:"mysqlDatabase"`
 	MariadbDatabase    *mariadb.MariadbDatabase       `json:"mariadbDatabase"`
 	MongodbDatabase    *mongodb.MongodbDatabase       `json:"mongodbDatabase"`
+
+	// Point-in-time recovery target. Time and LSN apply to physical PostgreSQL
+	// backups: they are restored into a new data directory instead of a
+	// running database, so connection fields are not needed. Time and binlog
+	// position apply to MySQL and MariaDB dumps with archived binlogs: the
+	// dump is restored and binlogs are replayed up to the target. Time also
+	// applies to MongoDB dumps with oplog, it limits the oplog replay
+	RecoveryTargetTime           *time.Time `json:"recoveryTargetTime"`
+	RecoveryTargetLsn            *string    `json:"recoveryTargetLsn"`
+	RecoveryTargetBinlogFile     *string    `json:"recoveryTargetBinlogFile"`
+	RecoveryTargetBinlogPosition *int64     `json:"recoveryTargetBinlogPosition"`
+
+	// Filter restores only selected objects of PostgreSQL logical backup.
+	// Objects can be picked from the list returned by the TOC endpoint
+	Filter *models.RestoreFilter `json:"filter"`
+
+	// NewDatabaseName restores into a new database created on the same
+	// server. When neither target database nor manual connection is given,
+	// the server of the backed up database is used with restore credentials
+	NewDatabaseName *string `json:"newDatabaseName"`
+
+	// IsRegisterNewDatabase adds the created database to the workspace after
+	// successful restore
+	IsRegisterNewDatabase bool `json:"isRegisterNewDatabase"`
 }
@@ -1,11 +1,13 @@
 package models
 
 import (
+	"encoding/json"
 	"postgresus-backend/internal/features/backups/backups"
 	"postgresus-backend/internal/features/restores/enums"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Restore struct {
@@ -17,6 +19,59 @@ type Restore struct {
 
 	FailMessage *string `json:"failMessage" gorm:"column:fail_message"`
 
+	// point-in-time recovery target of physical backups, when both are empty
+	// all archived WAL is replayed
+	RecoveryTargetTime *time.Time `json:"recoveryTargetTime" gorm:"column:recovery_target_time"`
+	RecoveryTargetLsn  *string    `json:"recoveryTargetLsn"  gorm:"column:recovery_target_lsn"`
+
+	// binlog replay stops before the event at this position of MySQL and
+	// MariaDB binlog. Replay to time uses RecoveryTargetTime
+	RecoveryTargetBinlogFile     *string `json:"recoveryTargetBinlogFile"     gorm:"column:recovery_target_binlog_file"`
+	RecoveryTargetBinlogPosition *int64  `json:"recoveryTargetBinlogPosition" gorm:"column:recovery_target_binlog_position"`
+
+	// RecoveredDataDirectory is the PostgreSQL data directory produced by
+	// physical restore. Start PostgreSQL on it to finish the recovery
+	RecoveredDataDirectory *string `json:"recoveredDataDirectory" gorm:"column:recovered_data_directory"`
+
+	// Filter limits restore of PostgreSQL logical backups to selected
+	// objects. Nil means the whole backup is restored
+	Filter       *RestoreFilter `json:"filter" gorm:"-"`
+	FilterString *string        `json:"-"      gorm:"column:filter"`
+
+	// NewDatabaseName is set when the backup is restored into a newly
+	// created database, CreatedDatabaseID when it was added to the workspace
+	NewDatabaseName   *string    `json:"newDatabaseName"   gorm:"column:new_database_name"`
+	CreatedDatabaseID *uuid.UUID `json:"createdDatabaseId" gorm:"column:created_database_id;type:uuid"`
+
 	RestoreDurationMs int64     `json:"restoreDurationMs" gorm:"column:restore_duration_ms;default:0"`
 	CreatedAt         time.Time `json:"createdAt"         gorm:"column:created_at;default:now()"`
 }
+
+func (r *Restore) BeforeSave(_ *gorm.DB) error {
+	if r.Filter == nil {
+		r.FilterString = nil
+		return nil
+	}
+
+	filter, err := json.Marshal(r.Filter)
+	if err != nil {
+		return err
+	}
+
+	filterString := string(filter)
+	r.FilterString = &filterString
+
+	return nil
+}
+
+func (r *Restore) AfterFind(_ *gorm.DB) error {
+	r.Filter = nil
+
+	if r.FilterString == nil || *r.FilterString == "" {
+		return nil
+	}
+
+	r.Filter = &RestoreFilter{}
+
+	return json.Unmarshal([]byte(*r.FilterString), r.Filter)
+}
@@ -0,0 +1,55 @@
+package models
+
+import "errors"
+
+// RestoreFilter selects objects of PostgreSQL archive to restore. Tables are
+// written as "schema.table", table without schema matches in any schema.
+// Object types are named as pg_restore lists them, e.g. "TABLE DATA" or
+// "INDEX"
+type RestoreFilter struct {
+	IncludeSchemas []string `json:"includeSchemas"`
+	ExcludeSchemas []string `json:"excludeSchemas"`
+
+	IncludeTables []string `json:"includeTables"`
+	ExcludeTables []string `json:"excludeTables"`
+
+	IncludeObjectTypes []string `json:"includeObjectTypes"`
+	ExcludeObjectTypes []string `json:"excludeObjectTypes"`
+
+	IsDataOnly   bool `json:"isDataOnly"`
+	IsSchemaOnly bool `json:"isSchemaOnly"`
+}
+
+func (f *RestoreFilter) Validate() error {
+	if f.IsDataOnly && f.IsSchemaOnly {
+		return errors.New("only one of data only or schema only can be set")
+	}
+
+	return nil
+}
+
+// HasObjectFilters tells whether the archive TOC should be filtered. Data
+// only and schema only are passed to pg_restore as is
+func (f *RestoreFilter) HasObjectFilters() bool {
+	return len(f.IncludeSchemas) > 0 ||
+		len(f.ExcludeSchemas) > 0 ||
+		len(f.IncludeTables) > 0 ||
+		len(f.ExcludeTables) > 0 ||
+		len(f.IncludeObjectTypes) > 0 ||
+		len(f.ExcludeObjectTypes) > 0
+}
+
+// TocEntry is an object of PostgreSQL custom format archive as listed by
+// pg_restore --list
+type TocEntry struct {
+	ID           int    `json:"id"`
+	Type         string `json:"type"`
+	Schema       string `json:"schema"`
+	Name         string `json:"name"`
+	Owner        string `json:"owner"`
+	Dependencies []int  `json:"dependencies"`
+
+	// Line is the original line of the list, it is written back to the
+	// list passed to pg_restore --use-list
+	Line string `json:"-"`
+}
@@ -1,27 +1,44 @@
 package restores
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/approvals"
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	"postgresus-backend/internal/features/backups/backups"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
 	"postgresus-backend/internal/features/restores/enums"
 	"postgresus-backend/internal/features/restores/models"
 	"postgresus-backend/internal/features/restores/usecases"
 	"postgresus-backend/internal/features/storages"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/encryption"
 	"postgresus-backend/internal/util/tools"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var recoveryTargetLsnRegexp = regexp.MustCompile(`^[0-9A-Fa-f]{1,8}/[0-9A-Fa-f]{1,8}$`)
+
+var recoveryTargetBinlogFileRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+\.[0-9]+$`)
+
+// newDatabaseNameRegexp is stricter than any supported engine requires, so
+// the name is valid everywhere and needs no escaping in tools arguments
+var newDatabaseNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]{0,62}$`)
+
 type RestoreService struct {
 	backupService        *backups.BackupService
 	restoreRepository    *RestoreRepository
@@ -30,10 +47,11 @@ type RestoreService struct {
 	restoreBackupUsecase *usecases.RestoreBackupUsecase
 	databaseService      *databases.DatabaseService
 	logger               *slog.Logger
-	workspaceService     *workspaces_services.WorkspaceService
+	authorizationService *workspaces_services.AuthorizationService
 	auditLogService      *audit_logs.AuditLogService
 	fieldEncryptor       encryption.FieldEncryptor
 	notifierService      *notifiers.NotifierService
+	approvalService      *approvals.ApprovalService
 }
 
 func (s *RestoreService) OnBeforeBackupRemove(backup *backups.Backup) error {
@@ -75,9 +93,11 @@ func (s *RestoreService) GetRestores(
 		return nil, errors.New("cannot get restores for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(
+	canAccess, err := s.authorizationService.HasDatabasePermission(
 		*database.WorkspaceID,
+		database.ID,
 		user,
+		users_enums.WorkspacePermissionView,
 	)
 	if err != nil {
 		return nil, err
@@ -89,59 +109,221 @@ func (s *RestoreService) GetRestores(
 	return s.restoreRepository.FindByBackupID(backupID)
 }
 
+// GetBackupTocWithAuth lists objects of PostgreSQL logical backup, so the
+// user can pick schemas and tables for selective restore
+func (s *RestoreService) GetBackupTocWithAuth(
+	user *users_models.User,
+	backupID uuid.UUID,
+) ([]*models.TocEntry, error) {
+	backup, err := s.backupService.GetBackup(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if database.WorkspaceID == nil {
+		return nil, errors.New("cannot list backup objects for database without workspace")
+	}
+
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccess {
+		return nil, errors.New("insufficient permissions to access this backup")
+	}
+
+	if backup.Status != backups.BackupStatusCompleted {
+		return nil, errors.New("backup is not completed")
+	}
+
+	storage, err := s.backupService.GetAvailableBackupStorage(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.restoreBackupUsecase.ListTocEntries(database, backup, storage)
+}
+
+// RestoreBackupWithAuth returns the approval request instead of starting the
+// restore when restores of the database require approval
 func (s *RestoreService) RestoreBackupWithAuth(
 	user *users_models.User,
 	backupID uuid.UUID,
 	requestDTO RestoreBackupRequest,
+) (*approvals.ApprovalRequest, error) {
+	return s.restoreBackupWithAuth(user, backupID, requestDTO, false)
+}
+
+// ExecuteApprovedAction starts the approved restore on behalf of the
+// requester
+func (s *RestoreService) ExecuteApprovedAction(
+	request *approvals.ApprovalRequest,
+	requestedBy *users_models.User,
+	payload string,
 ) error {
+	var requestDTO RestoreBackupRequest
+	if err := json.Unmarshal([]byte(payload), &requestDTO); err != nil {
+		return fmt.Errorf("failed to parse restore request: %w", err)
+	}
+
+	_, err := s.restoreBackupWithAuth(requestedBy, request.BackupID, requestDTO, true)
+	return err
+}
+
+func (s *RestoreService) restoreBackupWithAuth(
+	user *users_models.User,
+	backupID uuid.UUID,
+	requestDTO RestoreBackupRequest,
+	isApproved bool,
+) (*approvals.ApprovalRequest, error) {
 	backup, err := s.backupService.GetBackup(backupID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if database.WorkspaceID == nil {
-		return errors.New("cannot restore backup for database without workspace")
+		return nil, errors.New("cannot restore backup for database without workspace")
 	}
 
-	canAccess, _, err := s.workspaceService.CanUserAccessWorkspace(
+	canAccess, err := s.authorizationService.HasDatabasePermission(
 		*database.WorkspaceID,
+		database.ID,
 		user,
+		users_enums.WorkspacePermissionRestore,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !canAccess {
-		return errors.New("insufficient permissions to restore this backup")
+		return nil, errors.New("insufficient permissions to restore this backup")
 	}
 
 	backupDatabase, err := s.databaseService.GetDatabase(user, backup.DatabaseID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := validateRecoveryTarget(backup, requestDTO); err != nil {
+		return nil, err
+	}
+
+	if err := validateRestoreFilter(backup, database, requestDTO); err != nil {
+		return nil, err
+	}
+
+	if err := validateNewDatabase(backup, database, requestDTO); err != nil {
+		return nil, err
+	}
+
+	if isRestoreToOtherTarget(database, requestDTO) {
+		canRestoreToOtherTarget, err := s.authorizationService.HasDatabasePermission(
+			*database.WorkspaceID,
+			database.ID,
+			user,
+			users_enums.WorkspacePermissionRestoreToOtherTarget,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !canRestoreToOtherTarget {
+			return nil, errors.New("insufficient permissions to restore this backup to another database")
+		}
+	}
+
+	if requestDTO.IsRegisterNewDatabase {
+		canManage, err := s.authorizationService.HasPermission(
+			*database.WorkspaceID,
+			user,
+			users_enums.WorkspacePermissionManageDatabases,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !canManage {
+			return nil, errors.New("insufficient permissions to add restored database to the workspace")
+		}
+	}
+
+	// New database is created on the server of the backed up database, unless
+	// another connection is given
+	targetDatabaseID := requestDTO.TargetDatabaseId
+	if requestDTO.NewDatabaseName != nil && targetDatabaseID == nil &&
+		!hasManualConnection(requestDTO) {
+		targetDatabaseID = &database.ID
+	}
+
+	if !isApproved {
+		isApprovalRequired, err := s.isRestoreApprovalRequired(database.ID, targetDatabaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		if isApprovalRequired {
+			return s.approvalService.CreateRequest(
+				user,
+				database,
+				backup.ID,
+				approvals.ApprovalActionRestore,
+				describeRestore(backup, database, requestDTO),
+				requestDTO,
+			)
+		}
+	}
+
+	if backup.BackupType == backups_config.BackupTypePhysical {
+		return nil, s.startRestore(user, backup, database, requestDTO)
 	}
 
 	// If TargetDatabaseId is provided, populate requestDTO with target database config + owner credentials
-	if requestDTO.TargetDatabaseId != nil {
+	if targetDatabaseID != nil {
 		// Validate that restore credentials are provided
 		if requestDTO.RestoreUsername == nil || *requestDTO.RestoreUsername == "" {
-			return errors.New("restore username is required for restoring to a different database")
+			return nil, errors.New("restore username is required for restoring to a different database")
 		}
 		if requestDTO.RestorePassword == nil || *requestDTO.RestorePassword == "" {
-			return errors.New("restore password is required for restoring to a different database")
+			return nil, errors.New("restore password is required for restoring to a different database")
 		}
 
-		targetDatabase, err := s.databaseService.GetDatabaseByID(*requestDTO.TargetDatabaseId)
+		targetDatabase, err := s.databaseService.GetDatabaseByID(*targetDatabaseID)
 		if err != nil {
-			return fmt.Errorf("failed to get target database: %w", err)
+			return nil, fmt.Errorf("failed to get target database: %w", err)
+		}
+
+		if targetDatabase.WorkspaceID == nil {
+			return nil, errors.New("cannot restore backup to database without workspace")
+		}
+
+		canRestoreTarget, err := s.authorizationService.HasDatabasePermission(
+			*targetDatabase.WorkspaceID,
+			targetDatabase.ID,
+			user,
+			users_enums.WorkspacePermissionRestore,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !canRestoreTarget {
+			return nil, errors.New("insufficient permissions to restore into the target database")
 		}
 
 		// Verify same type
 		if targetDatabase.Type != database.Type {
-			return errors.New("target database type must match backup database type")
+			return nil, errors.New("target database type must match backup database type")
 		}
 
 		// Use target database connection info but with owner credentials (not read-only)
@@ -178,29 +360,39 @@ func (s *RestoreService) RestoreBackupWithAuth(
 	}
 
 	if err := s.validateVersionCompatibility(backupDatabase, requestDTO); err != nil {
-		return err
+		return nil, err
 	}
 
-	go func() {
-		if err := s.RestoreBackup(backup, requestDTO); err != nil {
-			s.logger.Error("Failed to restore backup", "error", err)
+	return nil, s.startRestore(user, backup, database, requestDTO)
+}
+
+// isRestoreApprovalRequired checks both the backed up database and the
+// target, because restore overwrites data of the target database
+func (s *RestoreService) isRestoreApprovalRequired(
+	databaseID uuid.UUID,
+	targetDatabaseID *uuid.UUID,
+) (bool, error) {
+	databaseIDs := []uuid.UUID{databaseID}
+	if targetDatabaseID != nil && *targetDatabaseID != databaseID {
+		databaseIDs = append(databaseIDs, *targetDatabaseID)
+	}
+
+	for _, id := range databaseIDs {
+		backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(id)
+		if err != nil {
+			return false, err
 		}
-	}()
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf(
-			"Database restored from backup %s for database: %s",
-			backupID.String(),
-			database.Name,
-		),
-		&user.ID,
-		database.WorkspaceID,
-	)
+		if backupConfig.IsRestoreApprovalRequired {
+			return true, nil
+		}
+	}
 
-	return nil
+	return false, nil
 }
 
 func (s *RestoreService) RestoreBackup(
+	user *users_models.User,
 	backup *backups.Backup,
 	requestDTO RestoreBackupRequest,
 ) error {
@@ -216,9 +408,11 @@ func (s *RestoreService) RestoreBackup(
 	// NOTE: TargetDatabaseId is handled in RestoreBackupWithAuth with proper password decryption
 	// requestDTO.PostgresqlDatabase etc. are already populated with decrypted credentials
 
+	isPhysical := backup.BackupType == backups_config.BackupTypePhysical
+
 	switch database.Type {
 	case databases.DatabaseTypePostgres:
-		if requestDTO.PostgresqlDatabase == nil {
+		if requestDTO.PostgresqlDatabase == nil && !isPhysical {
 			return errors.New("postgresql database is required")
 		}
 	case databases.DatabaseTypeMysql:
@@ -246,6 +440,24 @@ func (s *RestoreService) RestoreBackup(
 		RestoreDurationMs: 0,
 
 		FailMessage: nil,
+
+		RecoveryTargetTime:           requestDTO.RecoveryTargetTime,
+		RecoveryTargetLsn:            requestDTO.RecoveryTargetLsn,
+		RecoveryTargetBinlogFile:     requestDTO.RecoveryTargetBinlogFile,
+		RecoveryTargetBinlogPosition: requestDTO.RecoveryTargetBinlogPosition,
+
+		Filter: requestDTO.Filter,
+
+		NewDatabaseName: requestDTO.NewDatabaseName,
+	}
+
+	if isPhysical {
+		recoveredDataDirectory := filepath.Join(
+			config.GetEnv().DataFolder,
+			"recovered",
+			restore.ID.String(),
+		)
+		restore.RecoveredDataDirectory = &recoveredDataDirectory
 	}
 
 	// Save the restore first
@@ -258,8 +470,17 @@ func (s *RestoreService) RestoreBackup(
 		return err
 	}
 
-	storage, err := s.storageService.GetStorageByID(backup.StorageID)
+	// falls back to secondary copies when the primary storage is unavailable
+	storage, err := s.backupService.GetAvailableBackupStorage(backup)
 	if err != nil {
+		errMsg := err.Error()
+		restore.FailMessage = &errMsg
+		restore.Status = enums.RestoreStatusFailed
+
+		if saveErr := s.restoreRepository.Save(&restore); saveErr != nil {
+			return saveErr
+		}
+
 		return err
 	}
 
@@ -289,20 +510,28 @@ func (s *RestoreService) RestoreBackup(
 		isExcludeExtensions = requestDTO.PostgresqlDatabase.IsExcludeExtensions
 	}
 
-	err = s.restoreBackupUsecase.Execute(
-		backupConfig,
-		restore,
-		database,
-		restoringToDB,
-		backup,
-		storage,
-		isExcludeExtensions,
-	)
+	if requestDTO.NewDatabaseName != nil {
+		err = restoringToDB.CreateDatabase(s.logger, s.fieldEncryptor, *requestDTO.NewDatabaseName)
+		restoringToDB.SetDatabaseName(*requestDTO.NewDatabaseName)
+	}
+
+	if err == nil {
+		err = s.restoreBackupUsecase.Execute(
+			backupConfig,
+			restore,
+			database,
+			restoringToDB,
+			backup,
+			storage,
+			isExcludeExtensions,
+		)
+	}
 	if err != nil {
 		errMsg := err.Error()
 		restore.FailMessage = &errMsg
 		restore.Status = enums.RestoreStatusFailed
 		restore.RestoreDurationMs = time.Since(start).Milliseconds()
+		restore.RecoveredDataDirectory = nil
 
 		if saveErr := s.restoreRepository.Save(&restore); saveErr != nil {
 			return saveErr
@@ -317,6 +546,26 @@ func (s *RestoreService) RestoreBackup(
 	restore.Status = enums.RestoreStatusCompleted
 	restore.RestoreDurationMs = time.Since(start).Milliseconds()
 
+	// restore itself succeeded, so failed registration is only logged and the
+	// database can be added manually
+	if requestDTO.IsRegisterNewDatabase {
+		createdDatabase, err := s.registerRestoredDatabase(
+			user,
+			database,
+			restoringToDB,
+			*requestDTO.NewDatabaseName,
+		)
+		if err != nil {
+			s.logger.Error(
+				"Failed to add restored database to the workspace",
+				"restoreId", restore.ID,
+				"error", err,
+			)
+		} else {
+			restore.CreatedDatabaseID = &createdDatabase.ID
+		}
+	}
+
 	if err := s.restoreRepository.Save(&restore); err != nil {
 		return err
 	}
@@ -327,6 +576,85 @@ func (s *RestoreService) RestoreBackup(
 	return nil
 }
 
+func (s *RestoreService) startRestore(
+	user *users_models.User,
+	backup *backups.Backup,
+	database *databases.Database,
+	requestDTO RestoreBackupRequest,
+) error {
+	go func() {
+		if err := s.RestoreBackup(user, backup, requestDTO); err != nil {
+			s.logger.Error("Failed to restore backup", "error", err)
+		}
+	}()
+
+	message := fmt.Sprintf(
+		"Database restored from backup %s for database: %s",
+		backup.ID.String(),
+		database.Name,
+	)
+	if requestDTO.NewDatabaseName != nil {
+		message += fmt.Sprintf(" into new database: %s", *requestDTO.NewDatabaseName)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionStartRestore,
+		ResourceType: audit_logs_events.AuditResourceBackup,
+		ResourceID:   &backup.ID,
+		Message:      message,
+		User:         user,
+		WorkspaceID:  database.WorkspaceID,
+	})
+
+	return nil
+}
+
+// registerRestoredDatabase adds the database created by restore to the
+// workspace of the backed up database. Restore credentials are stored,
+// because they are the only ones known to have access to the new database
+func (s *RestoreService) registerRestoredDatabase(
+	user *users_models.User,
+	database *databases.Database,
+	restoringToDB *databases.Database,
+	name string,
+) (*databases.Database, error) {
+	healthStatus := databases.HealthStatusAvailable
+
+	newDatabase := &databases.Database{
+		Name:         name,
+		Type:         database.Type,
+		Notifiers:    database.Notifiers,
+		HealthStatus: &healthStatus,
+	}
+
+	switch {
+	case restoringToDB.Postgresql != nil:
+		pgCopy := *restoringToDB.Postgresql
+		pgCopy.ID = uuid.Nil
+		pgCopy.DatabaseID = nil
+		newDatabase.Postgresql = &pgCopy
+	case restoringToDB.Mysql != nil:
+		mysqlCopy := *restoringToDB.Mysql
+		mysqlCopy.ID = uuid.Nil
+		mysqlCopy.DatabaseID = nil
+		newDatabase.Mysql = &mysqlCopy
+	case restoringToDB.Mariadb != nil:
+		mariadbCopy := *restoringToDB.Mariadb
+		mariadbCopy.ID = uuid.Nil
+		mariadbCopy.DatabaseID = nil
+		newDatabase.Mariadb = &mariadbCopy
+	case restoringToDB.Mongodb != nil:
+		mongodbCopy := *restoringToDB.Mongodb
+		mongodbCopy.ID = uuid.Nil
+		mongodbCopy.DatabaseID = nil
+		newDatabase.Mongodb = &mongodbCopy
+	default:
+		return nil, errors.New("database connection is not configured")
+	}
+
+	return s.databaseService.CreateDatabase(user, *database.WorkspaceID, newDatabase)
+}
+
 func (s *RestoreService) validateVersionCompatibility(
 	backupDatabase *databases.Database,
 	requestDTO RestoreBackupRequest,
@@ -443,22 +771,248 @@ func (s *RestoreService) sendRestoreNotification(
 		return
 	}
 
-	var title, message string
+	var event *notifiers_events.NotificationEvent
 	if isSuccess {
-		title = fmt.Sprintf("✅ Restore completed: %s", database.Name)
-		// Format duration
-		totalMs := restore.RestoreDurationMs
-		minutes := totalMs / (1000 * 60)
-		seconds := (totalMs % (1000 * 60)) / 1000
-		durationStr := fmt.Sprintf("%dm %ds", minutes, seconds)
-		message = fmt.Sprintf("Database restore completed successfully in %s.", durationStr)
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventRestoreCompleted,
+			fmt.Sprintf("✅ Restore completed: %s", database.Name),
+			fmt.Sprintf(
+				"Database restore completed successfully in %s.",
+				notifiers_events.FormatDuration(restore.RestoreDurationMs),
+			),
+		)
 	} else {
-		title = fmt.Sprintf("❌ Restore failed: %s", database.Name)
-		message = fmt.Sprintf("Restore failed: %s", errorMessage)
+		event = notifiers_events.NewNotificationEvent(
+			notifiers_events.NotificationEventRestoreFailed,
+			fmt.Sprintf("❌ Restore failed: %s", database.Name),
+			fmt.Sprintf("Restore failed: %s", errorMessage),
+		)
+		event.Error = &errorMessage
+	}
+
+	event.Database = database.ToNotificationEventDatabase()
+	event.Restore = &notifiers_events.EventRestore{
+		ID:         restore.ID,
+		DurationMs: restore.RestoreDurationMs,
 	}
 
 	// Send notification to all notifiers
 	for _, notifier := range dbNotifiers {
-		s.notifierService.SendNotification(&notifier, title, message)
+		s.notifierService.SendNotification(&notifier, event)
+	}
+}
+
+func validateRecoveryTarget(backup *backups.Backup, requestDTO RestoreBackupRequest) error {
+	isBinlogTarget := requestDTO.RecoveryTargetBinlogFile != nil ||
+		requestDTO.RecoveryTargetBinlogPosition != nil
+
+	if requestDTO.RecoveryTargetTime == nil && requestDTO.RecoveryTargetLsn == nil &&
+		!isBinlogTarget {
+		return nil
+	}
+
+	isPhysical := backup.BackupType == backups_config.BackupTypePhysical
+	hasBinlogPosition := backup.BinlogFile != nil && backup.BinlogPosition != nil
+
+	if !isPhysical && !hasBinlogPosition && !backup.IsOplogIncluded {
+		return errors.New(
+			"recovery target is supported only for physical backups, backups with archived binlogs and MongoDB backups with oplog",
+		)
+	}
+
+	targetsCount := 0
+	for _, isSet := range []bool{
+		requestDTO.RecoveryTargetTime != nil,
+		requestDTO.RecoveryTargetLsn != nil,
+		isBinlogTarget,
+	} {
+		if isSet {
+			targetsCount++
+		}
+	}
+
+	if targetsCount > 1 {
+		return errors.New("only one recovery target can be set")
+	}
+
+	if requestDTO.RecoveryTargetTime != nil &&
+		requestDTO.RecoveryTargetTime.Before(backup.CreatedAt) {
+		return errors.New("recovery target time cannot be earlier than the backup")
+	}
+
+	if requestDTO.RecoveryTargetLsn != nil {
+		if !isPhysical {
+			return errors.New("recovery target LSN is supported only for physical backups")
+		}
+
+		if !recoveryTargetLsnRegexp.MatchString(*requestDTO.RecoveryTargetLsn) {
+			return errors.New("recovery target LSN must look like 0/16B3740")
+		}
+	}
+
+	if isBinlogTarget {
+		if !hasBinlogPosition {
+			return errors.New("recovery target binlog position is supported only for backups with archived binlogs")
+		}
+
+		if requestDTO.RecoveryTargetBinlogFile == nil ||
+			requestDTO.RecoveryTargetBinlogPosition == nil {
+			return errors.New("recovery target binlog file and position must be set together")
+		}
+
+		if *requestDTO.RecoveryTargetBinlogPosition <= 0 {
+			return errors.New("recovery target binlog position must be positive")
+		}
+
+		if !recoveryTargetBinlogFileRegexp.MatchString(*requestDTO.RecoveryTargetBinlogFile) {
+			return errors.New("recovery target binlog file must look like mysql-bin.000042")
+		}
+
+		if *requestDTO.RecoveryTargetBinlogFile == *backup.BinlogFile &&
+			*requestDTO.RecoveryTargetBinlogPosition < *backup.BinlogPosition {
+			return errors.New("recovery target binlog position cannot be earlier than the backup")
+		}
+	}
+
+	return nil
+}
+
+func validateRestoreFilter(
+	backup *backups.Backup,
+	database *databases.Database,
+	requestDTO RestoreBackupRequest,
+) error {
+	if requestDTO.Filter == nil {
+		return nil
+	}
+
+	if database.Type != databases.DatabaseTypePostgres ||
+		backup.BackupType == backups_config.BackupTypePhysical {
+		return errors.New("selective restore is supported only for PostgreSQL logical backups")
+	}
+
+	return requestDTO.Filter.Validate()
+}
+
+func validateNewDatabase(
+	backup *backups.Backup,
+	database *databases.Database,
+	requestDTO RestoreBackupRequest,
+) error {
+	if requestDTO.NewDatabaseName == nil {
+		if requestDTO.IsRegisterNewDatabase {
+			return errors.New("new database name is required to add restored database to the workspace")
+		}
+
+		return nil
+	}
+
+	if backup.BackupType == backups_config.BackupTypePhysical {
+		return errors.New("physical backups cannot be restored into a new database")
 	}
+
+	if backup.IsOplogIncluded || (database.Mongodb != nil && database.Mongodb.IsAllDatabases) {
+		return errors.New("backups of all databases cannot be restored into a new database")
+	}
+
+	if !newDatabaseNameRegexp.MatchString(*requestDTO.NewDatabaseName) {
+		return errors.New(
+			"new database name must start with a letter or underscore and contain " +
+				"only letters, digits, underscores and hyphens (up to 63 characters)",
+		)
+	}
+
+	return nil
+}
+
+func hasManualConnection(requestDTO RestoreBackupRequest) bool {
+	return requestDTO.PostgresqlDatabase != nil ||
+		requestDTO.MysqlDatabase != nil ||
+		requestDTO.MariadbDatabase != nil ||
+		requestDTO.MongodbDatabase != nil
+}
+
+// isRestoreToOtherTarget returns false when the restore overwrites the
+// backed up database itself. Manual connection is compared by address,
+// because UI prefills it with the connection of the backed up database
+func isRestoreToOtherTarget(database *databases.Database, requestDTO RestoreBackupRequest) bool {
+	if requestDTO.NewDatabaseName != nil {
+		return true
+	}
+
+	if requestDTO.TargetDatabaseId != nil {
+		return *requestDTO.TargetDatabaseId != database.ID
+	}
+
+	switch {
+	case requestDTO.PostgresqlDatabase != nil && database.Postgresql != nil:
+		target, source := requestDTO.PostgresqlDatabase, database.Postgresql
+		return !isSameAddress(target.Host, target.Port, target.Database,
+			source.Host, source.Port, source.Database)
+	case requestDTO.MysqlDatabase != nil && database.Mysql != nil:
+		target, source := requestDTO.MysqlDatabase, database.Mysql
+		return !isSameAddress(target.Host, target.Port, target.Database,
+			source.Host, source.Port, source.Database)
+	case requestDTO.MariadbDatabase != nil && database.Mariadb != nil:
+		target, source := requestDTO.MariadbDatabase, database.Mariadb
+		return !isSameAddress(target.Host, target.Port, target.Database,
+			source.Host, source.Port, source.Database)
+	case requestDTO.MongodbDatabase != nil && database.Mongodb != nil:
+		target, source := requestDTO.MongodbDatabase, database.Mongodb
+		return !isSameAddress(target.Host, target.Port, &target.Database,
+			source.Host, source.Port, &source.Database)
+	}
+
+	return hasManualConnection(requestDTO)
+}
+
+// describeRestore is shown to approvers, so it must not contain credentials
+func describeRestore(
+	backup *backups.Backup,
+	database *databases.Database,
+	requestDTO RestoreBackupRequest,
+) string {
+	description := fmt.Sprintf(
+		"restore backup from %s",
+		backup.CreatedAt.Format("2006-01-02 15:04:05 MST"),
+	)
+
+	switch {
+	case requestDTO.NewDatabaseName != nil:
+		description += fmt.Sprintf(" into new database %s", *requestDTO.NewDatabaseName)
+	case requestDTO.TargetDatabaseId != nil && *requestDTO.TargetDatabaseId != database.ID:
+		description += fmt.Sprintf(" to database with ID %s", *requestDTO.TargetDatabaseId)
+	case isRestoreToOtherTarget(database, requestDTO):
+		description += " to another server"
+	default:
+		description += fmt.Sprintf(" to database %s", database.Name)
+	}
+
+	if requestDTO.RecoveryTargetTime != nil {
+		description += fmt.Sprintf(
+			", recovery target %s",
+			requestDTO.RecoveryTargetTime.Format(time.RFC3339),
+		)
+	}
+
+	return description
+}
+
+func isSameAddress(
+	host string,
+	port int,
+	name *string,
+	otherHost string,
+	otherPort int,
+	otherName *string,
+) bool {
+	if !strings.EqualFold(host, otherHost) || port != otherPort {
+		return false
+	}
+
+	if name == nil || otherName == nil {
+		return name == nil && otherName == nil
+	}
+
+	return *name == *otherName
 }
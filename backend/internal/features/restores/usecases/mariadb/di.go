@@ -1,6 +1,7 @@
 package usecases_mariadb
 
 import (
+	backups_binlog "postgresus-backend/internal/features/backups/binlog"
 	"postgresus-backend/internal/features/encryption/secrets"
 	"postgresus-backend/internal/util/logger"
 )
@@ -8,6 +9,7 @@ import (
 var restoreMariadbBackupUsecase = &RestoreMariadbBackupUsecase{
 	logger.GetLogger(),
 	secrets.GetSecretKeyService(),
+	backups_binlog.GetBinlogArchivingService(),
 }
 
 func GetRestoreMariadbBackupUsecase() *RestoreMariadbBackupUsecase {
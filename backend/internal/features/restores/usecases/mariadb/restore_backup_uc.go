@@ -1,6 +1,7 @@
 package usecases_mariadb
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
@@ -15,11 +16,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/klauspost/compress/zstd"
 
 	"postgresus-backend/internal/config"
 	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	"postgresus-backend/internal/features/backups/backups/encryption"
+	backups_binlog "postgresus-backend/internal/features/backups/binlog"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
 	mariadbtypes "postgresus-backend/internal/features/databases/databases/mariadb"
@@ -32,8 +34,9 @@ import (
 )
 
 type RestoreMariadbBackupUsecase struct {
-	logger           *slog.Logger
-	secretKeyService *encryption_secrets.SecretKeyService
+	logger                 *slog.Logger
+	secretKeyService       *encryption_secrets.SecretKeyService
+	binlogArchivingService *backups_binlog.BinlogArchivingService
 }
 
 func (uc *RestoreMariadbBackupUsecase) Execute(
@@ -88,6 +91,7 @@ func (uc *RestoreMariadbBackupUsecase) Execute(
 		),
 		args,
 		mdb.Password,
+		restore,
 		backup,
 		storage,
 		mdb,
@@ -99,6 +103,7 @@ func (uc *RestoreMariadbBackupUsecase) restoreFromStorage(
 	mariadbBin string,
 	args []string,
 	password string,
+	restore models.Restore,
 	backup *backups.Backup,
 	storage *storages.Storage,
 	mdbConfig *mariadbtypes.MariadbDatabase,
@@ -140,7 +145,7 @@ func (uc *RestoreMariadbBackupUsecase) restoreFromStorage(
 	}
 	defer cleanupFunc()
 
-	return uc.executeMariadbRestore(
+	err = uc.executeMariadbRestore(
 		ctx,
 		database,
 		mariadbBin,
@@ -149,6 +154,24 @@ func (uc *RestoreMariadbBackupUsecase) restoreFromStorage(
 		tempBackupFile,
 		backup,
 	)
+	if err != nil {
+		return err
+	}
+
+	if restore.RecoveryTargetTime == nil && restore.RecoveryTargetBinlogFile == nil {
+		return nil
+	}
+
+	return uc.replayBinlogs(
+		ctx,
+		database,
+		mariadbBin,
+		args,
+		myCnfFile,
+		restore,
+		backup,
+		mdbConfig,
+	)
 }
 
 func (uc *RestoreMariadbBackupUsecase) executeMariadbRestore(
@@ -181,13 +204,13 @@ func (uc *RestoreMariadbBackupUsecase) executeMariadbRestore(
 		inputReader = decryptReader
 	}
 
-	zstdReader, err := zstd.NewReader(inputReader)
+	decompressionReader, err := compression.NewDecompressionReader(inputReader, backup.Compression)
 	if err != nil {
-		return fmt.Errorf("failed to create zstd reader: %w", err)
+		return fmt.Errorf("failed to create decompression reader: %w", err)
 	}
-	defer zstdReader.Close()
+	defer func() { _ = decompressionReader.Close() }()
 
-	cmd.Stdin = zstdReader
+	cmd.Stdin = decompressionReader
 
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env,
@@ -225,6 +248,113 @@ func (uc *RestoreMariadbBackupUsecase) executeMariadbRestore(
 	return nil
 }
 
+// replayBinlogs applies archived binlogs on top of the restored dump up to the
+// recovery target. Only events of the backed up database are applied, the
+// same way the dump contains only this database
+func (uc *RestoreMariadbBackupUsecase) replayBinlogs(
+	ctx context.Context,
+	database *databases.Database,
+	mariadbBin string,
+	args []string,
+	myCnfFile string,
+	restore models.Restore,
+	backup *backups.Backup,
+	mdbConfig *mariadbtypes.MariadbDatabase,
+) error {
+	if database.Mariadb == nil || database.Mariadb.Database == nil || backup.BinlogPosition == nil {
+		return errors.New("backup has no binlog position to replay binlogs from")
+	}
+
+	tempDir, err := os.MkdirTemp(config.GetEnv().TempFolder, "binlog_"+uuid.New().String())
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	binlogPaths, err := uc.binlogArchivingService.DownloadBinlogsForRestore(
+		backup,
+		restore.RecoveryTargetBinlogFile,
+		restore.RecoveryTargetTime,
+		tempDir,
+	)
+	if err != nil {
+		return err
+	}
+
+	binlogBin := tools.GetMariadbExecutable(
+		tools.MariadbExecutableMariadbBinlog,
+		database.Mariadb.Version,
+		config.GetEnv().EnvMode,
+		config.GetEnv().MariadbInstallDir,
+	)
+
+	binlogArgs := backups_binlog.BuildReplayArgs(
+		backups_binlog.ReplayOptions{
+			StartPosition:  *backup.BinlogPosition,
+			StopTime:       restore.RecoveryTargetTime,
+			StopPosition:   restore.RecoveryTargetBinlogPosition,
+			SourceDatabase: *database.Mariadb.Database,
+			TargetDatabase: *mdbConfig.Database,
+		},
+		binlogPaths,
+	)
+
+	// binlog events may contain binary data, which the client rejects in
+	// the default mode
+	clientArgs := append([]string{"--defaults-file=" + myCnfFile, "--binary-mode"}, args...)
+
+	binlogCmd := exec.CommandContext(ctx, binlogBin, binlogArgs...)
+	binlogCmd.Env = append(os.Environ(), "TZ=UTC", "LC_ALL=C.UTF-8", "LANG=C.UTF-8")
+
+	var binlogStderr bytes.Buffer
+	binlogCmd.Stderr = &binlogStderr
+
+	binlogStdout, err := binlogCmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	clientCmd := exec.CommandContext(ctx, mariadbBin, clientArgs...)
+	clientCmd.Env = append(os.Environ(), "MYSQL_PWD=", "LC_ALL=C.UTF-8", "LANG=C.UTF-8")
+	clientCmd.Stdin = binlogStdout
+
+	var clientStderr bytes.Buffer
+	clientCmd.Stderr = &clientStderr
+
+	uc.logger.Info(
+		"Replaying binlogs",
+		"restoreId", restore.ID,
+		"binlogsCount", len(binlogPaths),
+		"command", binlogCmd.String(),
+	)
+
+	if err := binlogCmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", filepath.Base(binlogBin), err)
+	}
+
+	clientErr := clientCmd.Run()
+	binlogErr := binlogCmd.Wait()
+
+	if config.IsShouldShutdown() {
+		return fmt.Errorf("restore cancelled due to shutdown")
+	}
+
+	if clientErr != nil {
+		return uc.handleMariadbRestoreError(database, clientErr, clientStderr.Bytes(), mariadbBin)
+	}
+
+	if binlogErr != nil {
+		return fmt.Errorf(
+			"%s failed: %v – stderr: %s",
+			filepath.Base(binlogBin),
+			binlogErr,
+			binlogStderr.String(),
+		)
+	}
+
+	return nil
+}
+
 func (uc *RestoreMariadbBackupUsecase) downloadBackupToTempFile(
 	ctx context.Context,
 	backup *backups.Backup,
@@ -256,7 +386,7 @@ func (uc *RestoreMariadbBackupUsecase) downloadBackupToTempFile(
 	)
 
 	fieldEncryptor := util_encryption.GetFieldEncryptor()
-	rawReader, err := storage.GetFile(fieldEncryptor, backup.ID)
+	rawReader, err := storage.GetFile(fieldEncryptor, backup.GetFileID())
 	if err != nil {
 		cleanupFunc()
 		return "", nil, fmt.Errorf("failed to get backup file from storage: %w", err)
@@ -296,7 +426,7 @@ func (uc *RestoreMariadbBackupUsecase) setupDecryption(
 		return nil, fmt.Errorf("backup is encrypted but missing encryption metadata")
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	masterKey, err := uc.secretKeyService.GetKeyByVersion(backup.EncryptionKeyVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get master key for decryption: %w", err)
 	}
@@ -17,6 +17,7 @@ import (
 
 	"postgresus-backend/internal/config"
 	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/backups/backups/compression"
 	"postgresus-backend/internal/features/backups/backups/encryption"
 	backups_config "postgresus-backend/internal/features/backups/config"
 	"postgresus-backend/internal/features/databases"
@@ -61,7 +62,12 @@ func (uc *RestoreMongodbBackupUsecase) Execute(
 		return fmt.Errorf("mongodb configuration is required for restore")
 	}
 
-	if mdb.Database == "" {
+	// backups of all databases are restored as a whole, so the oplog can be
+	// replayed on top of them
+	isAllDatabasesBackup := backup.IsOplogIncluded ||
+		(originalDB.Mongodb != nil && originalDB.Mongodb.IsAllDatabases)
+
+	if mdb.Database == "" && !isAllDatabasesBackup {
 		return fmt.Errorf("target database name is required for mongorestore")
 	}
 
@@ -76,7 +82,10 @@ func (uc *RestoreMongodbBackupUsecase) Execute(
 		sourceDatabase = originalDB.Mongodb.Database
 	}
 
-	args := uc.buildMongorestoreArgs(mdb, decryptedPassword, sourceDatabase)
+	args := uc.buildMongorestoreArgs(mdb, decryptedPassword, sourceDatabase, backup)
+	if isAllDatabasesBackup {
+		args = uc.buildAllDatabasesRestoreArgs(mdb, decryptedPassword, restore, backup)
+	}
 
 	return uc.restoreFromStorage(
 		tools.GetMongodbExecutable(
@@ -94,16 +103,20 @@ func (uc *RestoreMongodbBackupUsecase) buildMongorestoreArgs(
 	mdb *mongodbtypes.MongodbDatabase,
 	password string,
 	sourceDatabase string,
+	backup *backups.Backup,
 ) []string {
 	uri := mdb.BuildMongodumpURI(password)
 
 	args := []string{
 		"--uri=" + uri,
 		"--archive",
-		"--gzip",
 		"--drop",
 	}
 
+	if backup.Compression == backups_config.BackupCompressionGzip {
+		args = append(args, "--gzip")
+	}
+
 	if sourceDatabase != "" && sourceDatabase != mdb.Database {
 		args = append(args, "--nsFrom="+sourceDatabase+".*")
 		args = append(args, "--nsTo="+mdb.Database+".*")
@@ -114,6 +127,41 @@ func (uc *RestoreMongodbBackupUsecase) buildMongorestoreArgs(
 	return args
 }
 
+// buildAllDatabasesRestoreArgs restores every database of the archive and
+// replays the dumped oplog. Oplog entries at or after the recovery target
+// time are not applied
+func (uc *RestoreMongodbBackupUsecase) buildAllDatabasesRestoreArgs(
+	mdb *mongodbtypes.MongodbDatabase,
+	password string,
+	restore models.Restore,
+	backup *backups.Backup,
+) []string {
+	args := []string{
+		"--uri=" + mdb.BuildMongodumpURI(password),
+		"--archive",
+		"--drop",
+	}
+
+	if backup.Compression == backups_config.BackupCompressionGzip {
+		args = append(args, "--gzip")
+	}
+
+	if !backup.IsOplogIncluded {
+		return args
+	}
+
+	args = append(args, "--oplogReplay")
+
+	if restore.RecoveryTargetTime != nil {
+		args = append(
+			args,
+			fmt.Sprintf("--oplogLimit=%d", restore.RecoveryTargetTime.UTC().Unix()),
+		)
+	}
+
+	return args
+}
+
 func (uc *RestoreMongodbBackupUsecase) restoreFromStorage(
 	mongorestoreBin string,
 	args []string,
@@ -189,6 +237,20 @@ func (uc *RestoreMongodbBackupUsecase) executeMongoRestore(
 		inputReader = decryptReader
 	}
 
+	// gzip archives are decompressed by mongorestore itself
+	if backup.Compression != backups_config.BackupCompressionGzip {
+		decompressionReader, err := compression.NewDecompressionReader(
+			inputReader,
+			backup.Compression,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create decompression reader: %w", err)
+		}
+		defer func() { _ = decompressionReader.Close() }()
+
+		inputReader = decompressionReader
+	}
+
 	cmd.Stdin = inputReader
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, "LC_ALL=C.UTF-8", "LANG=C.UTF-8")
@@ -253,7 +315,7 @@ func (uc *RestoreMongodbBackupUsecase) downloadBackupToTempFile(
 	)
 
 	fieldEncryptor := util_encryption.GetFieldEncryptor()
-	rawReader, err := storage.GetFile(fieldEncryptor, backup.ID)
+	rawReader, err := storage.GetFile(fieldEncryptor, backup.GetFileID())
 	if err != nil {
 		cleanupFunc()
 		return "", nil, fmt.Errorf("failed to get backup file from storage: %w", err)
@@ -303,7 +365,7 @@ func (uc *RestoreMongodbBackupUsecase) setupDecryption(
 		return nil, fmt.Errorf("failed to decode encryption IV: %w", err)
 	}
 
-	masterKey, err := uc.secretKeyService.GetSecretKey()
+	masterKey, err := uc.secretKeyService.GetKeyByVersion(backup.EncryptionKeyVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret key: %w", err)
 	}
@@ -1,6 +1,7 @@
 package usecases_mysql
 
 import (
+	backups_binlog "postgresus-backend/internal/features/backups/binlog"
 	"postgresus-backend/internal/features/encryption/secrets"
 	"postgresus-backend/internal/util/logger"
 )
@@ -8,6 +9,7 @@ import (
 var restoreMysqlBackupUsecase = &RestoreMysqlBackupUsecase{
 	logger.GetLogger(),
 	secrets.GetSecretKeyService(),
+	backups_binlog.GetBinlogArchivingService(),
 }
 
 func GetRestoreMysqlBackupUsecase() *RestoreMysqlBackupUsecase {
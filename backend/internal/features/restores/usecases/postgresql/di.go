@@ -1,6 +1,7 @@
 package usecases_postgresql
 
 import (
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/encryption/secrets"
 	"postgresus-backend/internal/util/logger"
 )
@@ -8,6 +9,7 @@ import (
 var restorePostgresqlBackupUsecase = &RestorePostgresqlBackupUsecase{
 	logger.GetLogger(),
 	secrets.GetSecretKeyService(),
+	backups_wal.GetWalArchivingService(),
 }
 
 func GetRestorePostgresqlBackupUsecase() *RestorePostgresqlBackupUsecase {
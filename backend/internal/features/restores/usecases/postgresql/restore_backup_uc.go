@@ -18,6 +18,7 @@ import (
 	"postgresus-backend/internal/features/backups/backups"
 	"postgresus-backend/internal/features/backups/backups/encryption"
 	backups_config "postgresus-backend/internal/features/backups/config"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
 	"postgresus-backend/internal/features/databases"
 	pgtypes "postgresus-backend/internal/features/databases/databases/postgresql"
 	encryption_secrets "postgresus-backend/internal/features/encryption/secrets"
@@ -31,8 +32,9 @@ import (
 )
 
 type RestorePostgresqlBackupUsecase struct {
-	logger           *slog.Logger
-	secretKeyService *encryption_secrets.SecretKeyService
+	logger              *slog.Logger
+	secretKeyService    *encryption_secrets.SecretKeyService
+	walArchivingService *backups_wal.WalArchivingService
 }
 
 func (uc *RestorePostgresqlBackupUsecase) Execute(
@@ -48,6 +50,10 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 		return errors.New("database type not supported")
 	}
 
+	if backup.BackupType == backups_config.BackupTypePhysical {
+		return uc.restorePhysicalBackup(restore, backup, storage)
+	}
+
 	uc.logger.Info(
 		"Restoring PostgreSQL backup via pg_restore",
 		"restoreId",
@@ -77,11 +83,23 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 		"-p", strconv.Itoa(pg.Port),
 		"-U", pg.Username,
 		"-d", *pg.Database,
-		"--verbose",   // Add verbose output to help with debugging
-		"--clean",     // Clean (drop) database objects before recreating them
-		"--if-exists", // Use IF EXISTS when dropping objects
-		"--no-owner",  // Skip restoring ownership
-		"--no-acl",    // Skip restoring access privileges (GRANT/REVOKE commands)
+		"--verbose",  // Add verbose output to help with debugging
+		"--no-owner", // Skip restoring ownership
+		"--no-acl",   // Skip restoring access privileges (GRANT/REVOKE commands)
+	}
+
+	switch {
+	case restore.Filter != nil && restore.Filter.IsDataOnly:
+		// pg_restore does not allow --clean with --data-only, rows are
+		// loaded into existing tables
+		args = append(args, "--data-only")
+	case restore.Filter != nil && restore.Filter.IsSchemaOnly:
+		args = append(args, "--schema-only", "--clean", "--if-exists")
+	default:
+		args = append(args,
+			"--clean",     // Clean (drop) database objects before recreating them
+			"--if-exists", // Use IF EXISTS when dropping objects
+		)
 	}
 
 	return uc.restoreFromStorage(
@@ -98,9 +116,50 @@ func (uc *RestorePostgresqlBackupUsecase) Execute(
 		storage,
 		pg,
 		isExcludeExtensions,
+		restore.Filter,
 	)
 }
 
+// ListTocEntries lists objects of the logical backup, so they can be picked
+// for selective restore. The archive is read locally, no connection to the
+// database is made
+func (uc *RestorePostgresqlBackupUsecase) ListTocEntries(
+	originalDB *databases.Database,
+	backup *backups.Backup,
+	storage *storages.Storage,
+) ([]*models.TocEntry, error) {
+	if originalDB.Type != databases.DatabaseTypePostgres || originalDB.Postgresql == nil {
+		return nil, errors.New("database type not supported")
+	}
+
+	if backup.BackupType == backups_config.BackupTypePhysical {
+		return nil, errors.New("objects can be listed only for logical backups")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	tempBackupFile, cleanupFunc, err := uc.downloadBackupToTempFile(ctx, backup, storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download backup to temporary file: %w", err)
+	}
+	defer cleanupFunc()
+
+	pgBin := tools.GetPostgresqlExecutable(
+		originalDB.Postgresql.Version,
+		"pg_restore",
+		config.GetEnv().EnvMode,
+		config.GetEnv().PostgresesInstallDir,
+	)
+
+	tocOutput, err := uc.listToc(ctx, pgBin, tempBackupFile, "", originalDB.Postgresql)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTocList(string(tocOutput)), nil
+}
+
 // restoreFromStorage restores backup data from storage using pg_restore
 func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 	database *databases.Database,
@@ -111,6 +170,7 @@ func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 	storage *storages.Storage,
 	pgConfig *pgtypes.PostgresqlDatabase,
 	isExcludeExtensions bool,
+	filter *models.RestoreFilter,
 ) error {
 	uc.logger.Info(
 		"Restoring PostgreSQL backup from storage via temporary file",
@@ -120,6 +180,8 @@ func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 		args,
 		"isExcludeExtensions",
 		isExcludeExtensions,
+		"filter",
+		filter,
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
@@ -176,14 +238,17 @@ func (uc *RestorePostgresqlBackupUsecase) restoreFromStorage(
 	}
 	defer cleanupFunc()
 
-	// If excluding extensions, generate filtered TOC list and use it
-	if isExcludeExtensions {
+	// If excluding extensions or restoring selected objects, generate
+	// filtered TOC list and use it
+	if isExcludeExtensions || (filter != nil && filter.HasObjectFilters()) {
 		tocListFile, err := uc.generateFilteredTocList(
 			ctx,
 			pgBin,
 			tempBackupFile,
 			pgpassFile,
 			pgConfig,
+			isExcludeExtensions,
+			filter,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to generate filtered TOC list: %w", err)
@@ -238,7 +303,7 @@ func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 		backup.Encryption == backups_config.BackupEncryptionEncrypted,
 	)
 	fieldEncryptor := util_encryption.GetFieldEncryptor()
-	rawReader, err := storage.GetFile(fieldEncryptor, backup.ID)
+	rawReader, err := storage.GetFile(fieldEncryptor, backup.GetFileID())
 	if err != nil {
 		cleanupFunc()
 		return "", nil, fmt.Errorf("failed to get backup file from storage: %w", err)
@@ -259,7 +324,7 @@ func (uc *RestorePostgresqlBackupUsecase) downloadBackupToTempFile(
 		}
 
 		// Get master key
-		masterKey, err := uc.secretKeyService.GetSecretKey()
+		masterKey, err := uc.secretKeyService.GetKeyByVersion(backup.EncryptionKeyVersion)
 		if err != nil {
 			cleanupFunc()
 			return "", nil, fmt.Errorf("failed to get master key for decryption: %w", err)
@@ -579,45 +644,42 @@ func containsIgnoreCase(str, substr string) bool {
 	return strings.Contains(strings.ToLower(str), strings.ToLower(substr))
 }
 
-// generateFilteredTocList generates a pg_restore TOC list file with extensions filtered out.
-// This is used when isExcludeExtensions is true to skip CREATE EXTENSION statements.
+// generateFilteredTocList generates a pg_restore TOC list file with only
+// selected objects. Extensions are filtered out when isExcludeExtensions is
+// true to skip CREATE EXTENSION statements
 func (uc *RestorePostgresqlBackupUsecase) generateFilteredTocList(
 	ctx context.Context,
 	pgBin string,
 	backupFile string,
 	pgpassFile string,
 	pgConfig *pgtypes.PostgresqlDatabase,
+	isExcludeExtensions bool,
+	filter *models.RestoreFilter,
 ) (string, error) {
-	uc.logger.Info("Generating filtered TOC list to exclude extensions", "backupFile", backupFile)
-
-	// Run pg_restore -l to get the TOC list
-	listCmd := exec.CommandContext(ctx, pgBin, "-l", backupFile)
-	uc.setupPgRestoreEnvironment(listCmd, pgpassFile, pgConfig)
+	uc.logger.Info(
+		"Generating filtered TOC list",
+		"backupFile",
+		backupFile,
+		"isExcludeExtensions",
+		isExcludeExtensions,
+	)
 
-	tocOutput, err := listCmd.Output()
+	tocOutput, err := uc.listToc(ctx, pgBin, backupFile, pgpassFile, pgConfig)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate TOC list: %w", err)
+		return "", err
 	}
 
-	// Filter out EXTENSION-related lines (both CREATE EXTENSION and COMMENT ON EXTENSION)
-	var filteredLines []string
-	for line := range strings.SplitSeq(string(tocOutput), "\n") {
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine == "" {
-			continue
-		}
-
-		upperLine := strings.ToUpper(trimmedLine)
+	tocEntries := parseTocList(string(tocOutput))
+	selectedEntries := selectTocEntries(tocEntries, isExcludeExtensions, filter)
 
-		// Skip lines that contain " EXTENSION " - this catches both:
-		// - CREATE EXTENSION entries: "3420; 0 0 EXTENSION - uuid-ossp"
-		// - COMMENT ON EXTENSION entries: "3462; 0 0 COMMENT - EXTENSION "uuid-ossp""
-		if strings.Contains(upperLine, " EXTENSION ") {
-			uc.logger.Info("Excluding extension-related entry from restore", "tocLine", trimmedLine)
-			continue
-		}
+	if filter != nil && filter.HasObjectFilters() &&
+		len(selectedEntries) <= countTocSettingEntries(tocEntries) {
+		return "", errors.New("no objects in the backup match the restore filter")
+	}
 
-		filteredLines = append(filteredLines, line)
+	filteredLines := make([]string, 0, len(selectedEntries))
+	for _, entry := range selectedEntries {
+		filteredLines = append(filteredLines, entry.Line)
 	}
 
 	// Write filtered TOC to temporary file
@@ -641,13 +703,32 @@ func (uc *RestorePostgresqlBackupUsecase) generateFilteredTocList(
 
 	uc.logger.Info("Generated filtered TOC list file",
 		"tocFile", tocFilePath,
-		"originalLines", len(strings.Split(string(tocOutput), "\n")),
-		"filteredLines", len(filteredLines),
+		"originalEntries", len(tocEntries),
+		"filteredEntries", len(selectedEntries),
 	)
 
 	return tocFilePath, nil
 }
 
+// listToc runs pg_restore --list. Verbose mode adds dependencies of entries
+func (uc *RestorePostgresqlBackupUsecase) listToc(
+	ctx context.Context,
+	pgBin string,
+	backupFile string,
+	pgpassFile string,
+	pgConfig *pgtypes.PostgresqlDatabase,
+) ([]byte, error) {
+	listCmd := exec.CommandContext(ctx, pgBin, "--list", "--verbose", backupFile)
+	uc.setupPgRestoreEnvironment(listCmd, pgpassFile, pgConfig)
+
+	tocOutput, err := listCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOC list: %w", err)
+	}
+
+	return tocOutput, nil
+}
+
 // createTempPgpassFile creates a temporary .pgpass file with the given password
 func (uc *RestorePostgresqlBackupUsecase) createTempPgpassFile(
 	pgConfig *pgtypes.PostgresqlDatabase,
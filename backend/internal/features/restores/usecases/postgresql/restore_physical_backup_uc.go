@@ -0,0 +1,313 @@
+package usecases_postgresql
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/backups/backups/compression"
+	backups_wal "postgresus-backend/internal/features/backups/wal"
+	"postgresus-backend/internal/features/restores/models"
+	"postgresus-backend/internal/features/storages"
+)
+
+// restorePhysicalBackup unpacks pg_basebackup archive into a new data
+// directory and configures it to replay archived WAL up to the recovery
+// target. The server is not started: PostgreSQL of the same major version
+// should be started on the directory to finish the recovery and promote
+func (uc *RestorePostgresqlBackupUsecase) restorePhysicalBackup(
+	restore models.Restore,
+	backup *backups.Backup,
+	storage *storages.Storage,
+) error {
+	if restore.RecoveredDataDirectory == nil {
+		return errors.New("recovered data directory is required for physical restore")
+	}
+
+	dataDirectory := *restore.RecoveredDataDirectory
+	walArchiveDirectory := dataDirectory + "_wal_archive"
+
+	uc.logger.Info(
+		"Restoring PostgreSQL physical backup",
+		"restoreId",
+		restore.ID,
+		"backupId",
+		backup.ID,
+		"dataDirectory",
+		dataDirectory,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	isCompleted := false
+	defer func() {
+		if !isCompleted {
+			_ = os.RemoveAll(dataDirectory)
+			_ = os.RemoveAll(walArchiveDirectory)
+		}
+	}()
+
+	// data directory of PostgreSQL must not be accessible by other users
+	if err := os.MkdirAll(dataDirectory, 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	if err := os.MkdirAll(walArchiveDirectory, 0700); err != nil {
+		return fmt.Errorf("failed to create WAL archive directory: %w", err)
+	}
+
+	tempBackupFile, cleanupFunc, err := uc.downloadBackupToTempFile(ctx, backup, storage)
+	if err != nil {
+		return fmt.Errorf("failed to download backup to temporary file: %w", err)
+	}
+	defer cleanupFunc()
+
+	if err := uc.extractBaseBackup(backup, tempBackupFile, dataDirectory); err != nil {
+		return fmt.Errorf("failed to extract base backup: %w", err)
+	}
+
+	if err := uc.downloadWalSegments(ctx, backup, walArchiveDirectory); err != nil {
+		return fmt.Errorf("failed to download WAL segments: %w", err)
+	}
+
+	if err := writeRecoveryConfig(restore, dataDirectory, walArchiveDirectory); err != nil {
+		return fmt.Errorf("failed to write recovery configuration: %w", err)
+	}
+
+	isCompleted = true
+
+	uc.logger.Info(
+		"PostgreSQL physical backup restored",
+		"restoreId",
+		restore.ID,
+		"dataDirectory",
+		dataDirectory,
+	)
+
+	return nil
+}
+
+// extractBaseBackup unpacks tar archive written by pg_basebackup. The archive
+// is compressed on client side with the algorithm recorded on the backup
+func (uc *RestorePostgresqlBackupUsecase) extractBaseBackup(
+	backup *backups.Backup,
+	backupFile string,
+	dataDirectory string,
+) error {
+	file, err := os.Open(backupFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	archiveReader, err := compression.NewDecompressionReader(file, backup.Compression)
+	if err != nil {
+		return fmt.Errorf("failed to create decompression reader: %w", err)
+	}
+	defer func() {
+		_ = archiveReader.Close()
+	}()
+
+	return extractTar(archiveReader, dataDirectory)
+}
+
+// downloadWalSegments downloads archived WAL made after the backup. Each
+// segment is downloaded once, other storages are tried only when the copy
+// cannot be read
+func (uc *RestorePostgresqlBackupUsecase) downloadWalSegments(
+	ctx context.Context,
+	backup *backups.Backup,
+	walArchiveDirectory string,
+) error {
+	segments, err := uc.walArchivingService.GetSegmentsForRestore(backup)
+	if err != nil {
+		return err
+	}
+
+	downloadedFileNames := make(map[string]bool)
+	lastErrors := make(map[string]error)
+
+	for _, segment := range segments {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if downloadedFileNames[segment.FileName] {
+			continue
+		}
+
+		if err := uc.downloadWalSegment(segment, walArchiveDirectory); err != nil {
+			lastErrors[segment.FileName] = err
+			continue
+		}
+
+		downloadedFileNames[segment.FileName] = true
+		delete(lastErrors, segment.FileName)
+	}
+
+	for fileName, err := range lastErrors {
+		return fmt.Errorf("failed to download WAL segment %s: %w", fileName, err)
+	}
+
+	uc.logger.Info(
+		"WAL segments downloaded",
+		"backupId",
+		backup.ID,
+		"segmentsCount",
+		len(downloadedFileNames),
+	)
+
+	return nil
+}
+
+func (uc *RestorePostgresqlBackupUsecase) downloadWalSegment(
+	segment *backups_wal.WalSegment,
+	walArchiveDirectory string,
+) error {
+	filePath := filepath.Join(walArchiveDirectory, filepath.Base(segment.FileName))
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	downloadErr := uc.walArchivingService.DownloadSegment(segment, file)
+	closeErr := file.Close()
+
+	if downloadErr != nil {
+		_ = os.Remove(filePath)
+		return downloadErr
+	}
+
+	return closeErr
+}
+
+// writeRecoveryConfig makes PostgreSQL start in targeted recovery mode
+// (PostgreSQL 12+) and promote once the target is reached
+func writeRecoveryConfig(
+	restore models.Restore,
+	dataDirectory string,
+	walArchiveDirectory string,
+) error {
+	settings := []string{
+		"",
+		"# added by Postgresus point-in-time restore",
+		fmt.Sprintf(
+			"restore_command = 'cp \"%s/%%f\" \"%%p\"'",
+			escapeConfigValue(walArchiveDirectory),
+		),
+		"recovery_target_action = 'promote'",
+	}
+
+	if restore.RecoveryTargetTime != nil {
+		settings = append(
+			settings,
+			fmt.Sprintf(
+				"recovery_target_time = '%s'",
+				restore.RecoveryTargetTime.UTC().Format("2006-01-02 15:04:05.999999+00"),
+			),
+		)
+	}
+
+	if restore.RecoveryTargetLsn != nil {
+		settings = append(
+			settings,
+			fmt.Sprintf("recovery_target_lsn = '%s'", escapeConfigValue(*restore.RecoveryTargetLsn)),
+		)
+	}
+
+	autoConfFile, err := os.OpenFile(
+		filepath.Join(dataDirectory, "postgresql.auto.conf"),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+		0600,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, writeErr := autoConfFile.WriteString(strings.Join(settings, "\n") + "\n")
+	closeErr := autoConfFile.Close()
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if closeErr != nil {
+		return closeErr
+	}
+
+	return os.WriteFile(filepath.Join(dataDirectory, "recovery.signal"), []byte{}, 0600)
+}
+
+func extractTar(reader io.Reader, destination string) error {
+	tarReader := tar.NewReader(reader)
+	cleanDestination := filepath.Clean(destination)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		targetPath := filepath.Join(cleanDestination, header.Name)
+
+		// protect from archive entries like "../../etc/passwd"
+		if targetPath != cleanDestination &&
+			!strings.HasPrefix(targetPath, cleanDestination+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0700); err != nil {
+				return err
+			}
+
+			file, err := os.OpenFile(
+				targetPath,
+				os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
+				os.FileMode(header.Mode)&0700,
+			)
+			if err != nil {
+				return err
+			}
+
+			_, copyErr := io.Copy(file, tarReader)
+			closeErr := file.Close()
+
+			if copyErr != nil {
+				return copyErr
+			}
+
+			if closeErr != nil {
+				return closeErr
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, targetPath); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func escapeConfigValue(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
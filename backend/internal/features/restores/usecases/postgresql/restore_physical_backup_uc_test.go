@@ -0,0 +1,92 @@
+package usecases_postgresql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"postgresus-backend/internal/features/restores/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteRecoveryConfig_TargetTimeAndLsnWritten(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	existingConfig := "# Do not edit this file manually!\nwal_level = 'replica'\n"
+	err := os.WriteFile(
+		filepath.Join(dataDirectory, "postgresql.auto.conf"),
+		[]byte(existingConfig),
+		0600,
+	)
+	assert.NoError(t, err)
+
+	targetTime := time.Date(2026, 3, 14, 15, 9, 26, 535000000, time.FixedZone("UTC+3", 3*3600))
+	targetLsn := "0/3000060"
+
+	err = writeRecoveryConfig(
+		models.Restore{
+			RecoveryTargetTime: &targetTime,
+			RecoveryTargetLsn:  &targetLsn,
+		},
+		dataDirectory,
+		"/var/lib/postgresus/wal",
+	)
+	assert.NoError(t, err)
+
+	autoConf, err := os.ReadFile(filepath.Join(dataDirectory, "postgresql.auto.conf"))
+	assert.NoError(t, err)
+
+	// settings of the base backup are kept, recovery settings are appended
+	assert.Equal(
+		t,
+		existingConfig+
+			"\n# added by Postgresus point-in-time restore\n"+
+			"restore_command = 'cp \"/var/lib/postgresus/wal/%f\" \"%p\"'\n"+
+			"recovery_target_action = 'promote'\n"+
+			"recovery_target_time = '2026-03-14 12:09:26.535+00'\n"+
+			"recovery_target_lsn = '0/3000060'\n",
+		string(autoConf),
+	)
+
+	assert.FileExists(t, filepath.Join(dataDirectory, "recovery.signal"))
+}
+
+func Test_WriteRecoveryConfig_WithoutTarget_AllWalReplayed(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	err := writeRecoveryConfig(models.Restore{}, dataDirectory, "/tmp/wal")
+	assert.NoError(t, err)
+
+	autoConf, err := os.ReadFile(filepath.Join(dataDirectory, "postgresql.auto.conf"))
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(autoConf), "restore_command = 'cp \"/tmp/wal/%f\" \"%p\"'")
+	assert.Contains(t, string(autoConf), "recovery_target_action = 'promote'")
+	assert.NotContains(t, string(autoConf), "recovery_target_time")
+	assert.NotContains(t, string(autoConf), "recovery_target_lsn")
+	assert.FileExists(t, filepath.Join(dataDirectory, "recovery.signal"))
+}
+
+func Test_WriteRecoveryConfig_QuotesInValuesEscaped(t *testing.T) {
+	dataDirectory := t.TempDir()
+	targetLsn := "0/1' recovery_target_action = 'shutdown"
+
+	err := writeRecoveryConfig(
+		models.Restore{RecoveryTargetLsn: &targetLsn},
+		dataDirectory,
+		"/tmp/o'wal",
+	)
+	assert.NoError(t, err)
+
+	autoConf, err := os.ReadFile(filepath.Join(dataDirectory, "postgresql.auto.conf"))
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(autoConf), "restore_command = 'cp \"/tmp/o''wal/%f\" \"%p\"'")
+	assert.Contains(
+		t,
+		string(autoConf),
+		"recovery_target_lsn = '0/1'' recovery_target_action = ''shutdown'",
+	)
+}
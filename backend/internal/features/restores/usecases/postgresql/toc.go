@@ -0,0 +1,350 @@
+package usecases_postgresql
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"postgresus-backend/internal/features/restores/models"
+)
+
+// tocEntryTypes are object descriptions written by pg_dump. Some of them
+// contain spaces, so the type cannot be taken as the first word of the line
+var tocEntryTypes = sortByLengthDesc([]string{
+	"ACCESS METHOD", "ACL", "AGGREGATE", "BLOB", "BLOB DATA", "BLOBS", "CAST",
+	"CHECK CONSTRAINT", "COLLATION", "COMMENT", "CONSTRAINT", "CONVERSION",
+	"DATABASE", "DATABASE PROPERTIES", "DEFAULT", "DEFAULT ACL", "DOMAIN",
+	"ENCODING", "EVENT TRIGGER", "EXTENSION", "FK CONSTRAINT",
+	"FOREIGN DATA WRAPPER", "FOREIGN SERVER", "FOREIGN TABLE", "FUNCTION",
+	"INDEX", "INDEX ATTACH", "LARGE OBJECT", "MATERIALIZED VIEW",
+	"MATERIALIZED VIEW DATA", "OPERATOR", "OPERATOR CLASS", "OPERATOR FAMILY",
+	"POLICY", "PROCEDURAL LANGUAGE", "PROCEDURE", "PUBLICATION",
+	"PUBLICATION TABLE", "PUBLICATION TABLES IN SCHEMA", "ROW SECURITY", "RULE",
+	"SCHEMA", "SEARCH_PATH", "SECURITY LABEL", "SEQUENCE", "SEQUENCE OWNED BY",
+	"SEQUENCE SET", "SERVER", "SHELL TYPE", "STATISTICS", "STDSTRINGS",
+	"SUBSCRIPTION", "SUBSCRIPTION TABLE", "TABLE", "TABLE ATTACH", "TABLE DATA",
+	"TEXT SEARCH CONFIGURATION", "TEXT SEARCH DICTIONARY", "TEXT SEARCH PARSER",
+	"TEXT SEARCH TEMPLATE", "TRANSFORM", "TRIGGER", "TYPE", "USER MAPPING",
+	"VIEW",
+})
+
+// tocTableObjectTypes are objects which belong to the table they depend on
+var tocTableObjectTypes = []string{
+	"INDEX", "INDEX ATTACH", "STATISTICS", "COMMENT", "ACL", "SECURITY LABEL",
+	"SEQUENCE", "SEQUENCE SET", "SEQUENCE OWNED BY", "TABLE ATTACH",
+}
+
+// tocSettingTypes are always processed by pg_restore, whatever the list says
+var tocSettingTypes = []string{"ENCODING", "STDSTRINGS", "SEARCH_PATH"}
+
+type tocTable struct {
+	schema string
+	name   string
+}
+
+// parseTocList parses output of pg_restore --list --verbose. Verbose mode
+// adds "; depends on: ..." comment after entries, the dependencies are used
+// to find the table of indexes, comments and other objects
+func parseTocList(output string) []*models.TocEntry {
+	var entries []*models.TocEntry
+	var lastEntry *models.TocEntry
+
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmedLine, ";") {
+			comment := strings.TrimSpace(strings.TrimPrefix(trimmedLine, ";"))
+
+			dependencies, isDependencies := strings.CutPrefix(comment, "depends on:")
+			if isDependencies && lastEntry != nil {
+				for dependency := range strings.FieldsSeq(dependencies) {
+					if id, err := strconv.Atoi(dependency); err == nil {
+						lastEntry.Dependencies = append(lastEntry.Dependencies, id)
+					}
+				}
+			}
+
+			continue
+		}
+
+		entry := parseTocLine(line)
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+
+		lastEntry = entry
+	}
+
+	return entries
+}
+
+// parseTocLine parses line like "215; 1259 16387 TABLE public users postgres"
+func parseTocLine(line string) *models.TocEntry {
+	idPart, rest, isFound := strings.Cut(line, ";")
+	if !isFound {
+		return nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(idPart))
+	if err != nil {
+		return nil
+	}
+
+	// skip table OID and object OID
+	fields := strings.SplitN(strings.TrimLeft(rest, " "), " ", 3)
+	if len(fields) < 3 {
+		return nil
+	}
+
+	description := fields[2]
+
+	entryType := ""
+	for _, knownType := range tocEntryTypes {
+		if strings.HasPrefix(description, knownType+" ") {
+			entryType = knownType
+			break
+		}
+	}
+
+	if entryType == "" {
+		entryType, _, _ = strings.Cut(description, " ")
+	}
+
+	// the rest is "<schema> <name> <owner>", schema is "-" for objects
+	// outside of schemas and owner may be empty
+	description = strings.TrimPrefix(description, entryType+" ")
+
+	schema, nameAndOwner, _ := strings.Cut(description, " ")
+	if schema == "-" {
+		schema = ""
+	}
+
+	name := nameAndOwner
+	owner := ""
+	if index := strings.LastIndex(nameAndOwner, " "); index >= 0 {
+		name = nameAndOwner[:index]
+		owner = nameAndOwner[index+1:]
+	}
+
+	return &models.TocEntry{
+		ID:           id,
+		Type:         entryType,
+		Schema:       schema,
+		Name:         name,
+		Owner:        owner,
+		Dependencies: []int{},
+		Line:         line,
+	}
+}
+
+// selectTocEntries returns entries to be restored. Entries excluded by any
+// rule are skipped
+func selectTocEntries(
+	entries []*models.TocEntry,
+	isExcludeExtensions bool,
+	filter *models.RestoreFilter,
+) []*models.TocEntry {
+	tables := resolveTocTables(entries)
+
+	var selectedEntries []*models.TocEntry
+
+	for _, entry := range entries {
+		if slices.Contains(tocSettingTypes, entry.Type) {
+			selectedEntries = append(selectedEntries, entry)
+			continue
+		}
+
+		if isExcludeExtensions && isExtensionTocEntry(entry) {
+			continue
+		}
+
+		if filter != nil {
+			table, hasTable := tables[entry.ID]
+			if !isTocEntryMatchFilter(entry, table, hasTable, filter) {
+				continue
+			}
+		}
+
+		selectedEntries = append(selectedEntries, entry)
+	}
+
+	return selectedEntries
+}
+
+func isTocEntryMatchFilter(
+	entry *models.TocEntry,
+	table tocTable,
+	hasTable bool,
+	filter *models.RestoreFilter,
+) bool {
+	if len(filter.IncludeObjectTypes) > 0 &&
+		!containsIgnoreCaseItem(filter.IncludeObjectTypes, entry.Type) {
+		return false
+	}
+
+	if containsIgnoreCaseItem(filter.ExcludeObjectTypes, entry.Type) {
+		return false
+	}
+
+	schema := entry.Schema
+	if entry.Type == "SCHEMA" {
+		schema = entry.Name
+	}
+
+	if len(filter.IncludeSchemas) > 0 && !slices.Contains(filter.IncludeSchemas, schema) {
+		return false
+	}
+
+	if schema != "" && slices.Contains(filter.ExcludeSchemas, schema) {
+		return false
+	}
+
+	// schemas are never restored when tables are selected: with --clean the
+	// whole schema would be dropped
+	if len(filter.IncludeTables) > 0 && (!hasTable || !isTableMatch(table, filter.IncludeTables)) {
+		return false
+	}
+
+	if hasTable && isTableMatch(table, filter.ExcludeTables) {
+		return false
+	}
+
+	return true
+}
+
+// resolveTocTables finds the table (or other relation) each entry belongs
+// to: table data, columns defaults, constraints, indexes, triggers, comments,
+// grants and sequences used by table columns
+func resolveTocTables(entries []*models.TocEntry) map[int]tocTable {
+	entriesByID := make(map[int]*models.TocEntry, len(entries))
+	for _, entry := range entries {
+		entriesByID[entry.ID] = entry
+	}
+
+	tables := make(map[int]tocTable)
+
+	// name of these objects contains the table name
+	for _, entry := range entries {
+		switch entry.Type {
+		case "TABLE", "VIEW", "MATERIALIZED VIEW", "FOREIGN TABLE",
+			"TABLE DATA", "MATERIALIZED VIEW DATA", "ROW SECURITY":
+			tables[entry.ID] = tocTable{entry.Schema, entry.Name}
+		case "DEFAULT", "CONSTRAINT", "FK CONSTRAINT", "CHECK CONSTRAINT",
+			"TRIGGER", "RULE", "POLICY":
+			tableName, _, _ := strings.Cut(entry.Name, " ")
+			tables[entry.ID] = tocTable{entry.Schema, tableName}
+		}
+	}
+
+	// serial columns default to nextval() of the sequence, so the sequence
+	// is restored with the table
+	for _, entry := range entries {
+		if entry.Type != "DEFAULT" {
+			continue
+		}
+
+		for _, dependency := range entry.Dependencies {
+			if dependencyEntry, isFound := entriesByID[dependency]; isFound &&
+				dependencyEntry.Type == "SEQUENCE" {
+				tables[dependency] = tables[entry.ID]
+			}
+		}
+	}
+
+	resolveTocTablesByDependencies(entries, tables)
+
+	// sequences not used by any table are selected by their own name
+	for _, entry := range entries {
+		if _, isResolved := tables[entry.ID]; !isResolved && entry.Type == "SEQUENCE" {
+			tables[entry.ID] = tocTable{entry.Schema, entry.Name}
+		}
+	}
+
+	resolveTocTablesByDependencies(entries, tables)
+
+	return tables
+}
+
+// resolveTocTablesByDependencies assigns objects to the table they depend
+// on. Objects may depend on other objects of the table, e.g. comment on
+// index, so dependencies are resolved until nothing changes
+func resolveTocTablesByDependencies(entries []*models.TocEntry, tables map[int]tocTable) {
+	for isChanged := true; isChanged; {
+		isChanged = false
+
+		for _, entry := range entries {
+			if _, isResolved := tables[entry.ID]; isResolved ||
+				!slices.Contains(tocTableObjectTypes, entry.Type) {
+				continue
+			}
+
+			for _, dependency := range entry.Dependencies {
+				if table, isFound := tables[dependency]; isFound {
+					tables[entry.ID] = table
+					isChanged = true
+					break
+				}
+			}
+		}
+	}
+}
+
+func countTocSettingEntries(entries []*models.TocEntry) int {
+	count := 0
+	for _, entry := range entries {
+		if slices.Contains(tocSettingTypes, entry.Type) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func isTableMatch(table tocTable, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, ".") {
+			if pattern == table.schema+"."+table.name {
+				return true
+			}
+
+			continue
+		}
+
+		if pattern == table.name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExtensionTocEntry matches both CREATE EXTENSION entries
+// ("3420; 0 0 EXTENSION - uuid-ossp") and comments on extensions
+// ("3462; 0 0 COMMENT - EXTENSION "uuid-ossp"")
+func isExtensionTocEntry(entry *models.TocEntry) bool {
+	return strings.Contains(strings.ToUpper(entry.Line), " EXTENSION ")
+}
+
+func containsIgnoreCaseItem(items []string, value string) bool {
+	for _, item := range items {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortByLengthDesc(values []string) []string {
+	sort.SliceStable(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	return values
+}
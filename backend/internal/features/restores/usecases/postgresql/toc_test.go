@@ -0,0 +1,156 @@
+package usecases_postgresql
+
+import (
+	"testing"
+
+	"postgresus-backend/internal/features/restores/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTocList = `;
+; Archive created at 2026-01-10 10:00:00 UTC
+;     dbname: app
+;
+; Selected TOC Entries:
+;
+3400; 0 0 ENCODING - ENCODING
+3401; 0 0 STDSTRINGS - STDSTRINGS
+3402; 0 0 SEARCH_PATH - SEARCH_PATH
+5; 2615 2200 SCHEMA - public postgres
+6; 2615 16384 SCHEMA - audit postgres
+2; 3079 16385 EXTENSION - uuid-ossp
+3420; 0 0 COMMENT - EXTENSION "uuid-ossp"
+;	depends on: 2
+215; 1259 16390 TABLE public users postgres
+;	depends on: 5
+216; 1259 16389 SEQUENCE public users_id_seq postgres
+;	depends on: 5
+217; 0 0 SEQUENCE OWNED BY public users_id_seq postgres
+;	depends on: 216
+218; 1259 16400 TABLE public orders postgres
+;	depends on: 5
+219; 1259 16410 TABLE audit events postgres
+;	depends on: 6
+3250; 2604 16391 DEFAULT public users id postgres
+;	depends on: 216 215
+3360; 0 16390 TABLE DATA public users postgres
+;	depends on: 215
+3361; 0 16400 TABLE DATA public orders postgres
+;	depends on: 218
+3362; 0 16410 TABLE DATA audit events postgres
+;	depends on: 219
+3430; 0 0 SEQUENCE SET public users_id_seq postgres
+;	depends on: 216
+3210; 2606 16395 CONSTRAINT public users users_pkey postgres
+;	depends on: 215
+3211; 1259 16396 INDEX public idx_users_email postgres
+;	depends on: 215
+3212; 2606 16397 FK CONSTRAINT public orders orders_user_id_fkey postgres
+;	depends on: 218 3210
+3450; 0 0 COMMENT public TABLE users postgres
+;	depends on: 215
+`
+
+func Test_ParseTocList_EntriesWithSpacesInTypeAndDependencies_Parsed(t *testing.T) {
+	entries := parseTocList(testTocList)
+
+	assert.Len(t, entries, 21)
+
+	encoding := entries[0]
+	assert.Equal(t, 3400, encoding.ID)
+	assert.Equal(t, "ENCODING", encoding.Type)
+	assert.Equal(t, "", encoding.Schema)
+	assert.Equal(t, "ENCODING", encoding.Name)
+	assert.Equal(t, "", encoding.Owner)
+
+	tableData := findTocEntry(entries, 3360)
+	assert.Equal(t, "TABLE DATA", tableData.Type)
+	assert.Equal(t, "public", tableData.Schema)
+	assert.Equal(t, "users", tableData.Name)
+	assert.Equal(t, "postgres", tableData.Owner)
+	assert.Equal(t, []int{215}, tableData.Dependencies)
+
+	fkConstraint := findTocEntry(entries, 3212)
+	assert.Equal(t, "FK CONSTRAINT", fkConstraint.Type)
+	assert.Equal(t, "orders orders_user_id_fkey", fkConstraint.Name)
+	assert.Equal(t, []int{218, 3210}, fkConstraint.Dependencies)
+
+	comment := findTocEntry(entries, 3450)
+	assert.Equal(t, "COMMENT", comment.Type)
+	assert.Equal(t, "TABLE users", comment.Name)
+}
+
+func Test_SelectTocEntries_WhenTableIncluded_OnlyTableObjectsSelected(t *testing.T) {
+	entries := parseTocList(testTocList)
+
+	selectedEntries := selectTocEntries(
+		entries,
+		false,
+		&models.RestoreFilter{IncludeTables: []string{"public.users"}},
+	)
+
+	assert.ElementsMatch(
+		t,
+		[]int{3400, 3401, 3402, 215, 216, 217, 3250, 3360, 3430, 3210, 3211, 3450},
+		getTocEntryIDs(selectedEntries),
+	)
+}
+
+func Test_SelectTocEntries_WhenSchemaExcluded_SchemaObjectsSkipped(t *testing.T) {
+	entries := parseTocList(testTocList)
+
+	selectedEntries := selectTocEntries(
+		entries,
+		true,
+		&models.RestoreFilter{ExcludeSchemas: []string{"audit"}},
+	)
+
+	selectedIDs := getTocEntryIDs(selectedEntries)
+
+	assert.NotContains(t, selectedIDs, 6)
+	assert.NotContains(t, selectedIDs, 219)
+	assert.NotContains(t, selectedIDs, 3362)
+	assert.NotContains(t, selectedIDs, 2)
+	assert.NotContains(t, selectedIDs, 3420)
+	assert.Contains(t, selectedIDs, 218)
+	assert.Contains(t, selectedIDs, 3361)
+}
+
+func Test_SelectTocEntries_WhenObjectTypesFiltered_OnlyMatchingTypesSelected(t *testing.T) {
+	entries := parseTocList(testTocList)
+
+	selectedEntries := selectTocEntries(
+		entries,
+		false,
+		&models.RestoreFilter{
+			IncludeObjectTypes: []string{"table data", "SEQUENCE SET"},
+			ExcludeTables:      []string{"events"},
+		},
+	)
+
+	assert.ElementsMatch(
+		t,
+		[]int{3400, 3401, 3402, 3360, 3361, 3430},
+		getTocEntryIDs(selectedEntries),
+	)
+}
+
+func findTocEntry(entries []*models.TocEntry, id int) *models.TocEntry {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func getTocEntryIDs(entries []*models.TocEntry) []int {
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+
+	return ids
+}
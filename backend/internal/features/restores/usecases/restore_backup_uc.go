@@ -72,3 +72,17 @@ func (uc *RestoreBackupUsecase) Execute(
 		return errors.New("database type not supported")
 	}
 }
+
+// ListTocEntries lists objects of the backup for selective restore. Only
+// PostgreSQL archives have the table of contents
+func (uc *RestoreBackupUsecase) ListTocEntries(
+	originalDB *databases.Database,
+	backup *backups.Backup,
+	storage *storages.Storage,
+) ([]*models.TocEntry, error) {
+	if originalDB.Type != databases.DatabaseTypePostgres {
+		return nil, errors.New("objects can be listed only for PostgreSQL backups")
+	}
+
+	return uc.restorePostgresqlBackupUsecase.ListTocEntries(originalDB, backup, storage)
+}
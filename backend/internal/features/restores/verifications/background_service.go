@@ -0,0 +1,113 @@
+package restores_verifications
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+	"postgresus-backend/internal/features/backups/backups"
+)
+
+type VerificationBackgroundService struct {
+	verificationService *VerificationService
+	backupService       *backups.BackupService
+	logger              *slog.Logger
+}
+
+func (s *VerificationBackgroundService) Run() {
+	if err := s.failVerificationsInProgress(); err != nil {
+		s.logger.Error("Failed to fail verifications in progress", "error", err)
+	}
+
+	for {
+		if config.IsShouldShutdown() {
+			return
+		}
+
+		if err := s.runPendingVerifications(); err != nil {
+			s.logger.Error("Failed to run pending verifications", "error", err)
+		}
+
+		time.Sleep(1 * time.Minute)
+	}
+}
+
+func (s *VerificationBackgroundService) failVerificationsInProgress() error {
+	resultsInProgress, err := s.backupService.GetVerificationResultsByStatus(
+		backups.VerificationStatusInProgress,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range resultsInProgress {
+		failMessage := "Verification failed due to application restart"
+		result.FailMessage = &failMessage
+		result.Status = backups.VerificationStatusFailed
+
+		if err := s.backupService.SaveVerificationResult(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *VerificationBackgroundService) runPendingVerifications() error {
+	verificationConfigs, err := s.verificationService.GetConfigsWithEnabledVerification()
+	if err != nil {
+		return err
+	}
+
+	for _, verificationConfig := range verificationConfigs {
+		if verificationConfig.VerificationInterval == nil {
+			continue
+		}
+
+		lastResult, err := s.backupService.GetLastVerificationResult(
+			verificationConfig.DatabaseID,
+		)
+		if err != nil {
+			s.logger.Error(
+				"Failed to get last verification result",
+				"databaseId",
+				verificationConfig.DatabaseID,
+				"error",
+				err,
+			)
+			continue
+		}
+
+		var lastVerificationTime *time.Time
+		if lastResult != nil {
+			lastVerificationTime = &lastResult.CreatedAt
+		}
+
+		if !verificationConfig.VerificationInterval.ShouldTriggerBackup(
+			time.Now().UTC(),
+			lastVerificationTime,
+		) {
+			continue
+		}
+
+		s.logger.Info(
+			"Triggering scheduled backup verification",
+			"databaseId",
+			verificationConfig.DatabaseID,
+		)
+
+		go func(verificationConfig *VerificationConfig) {
+			if err := s.verificationService.VerifyLastBackup(verificationConfig); err != nil {
+				s.logger.Error(
+					"Failed to verify backup",
+					"databaseId",
+					verificationConfig.DatabaseID,
+					"error",
+					err,
+				)
+			}
+		}(verificationConfig)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package restores_verifications
+
+import (
+	"net/http"
+
+	users_middleware "postgresus-backend/internal/features/users/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VerificationController struct {
+	verificationService *VerificationService
+}
+
+func (c *VerificationController) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/verification-config", c.SaveVerificationConfig)
+	router.GET("/verification-config/:databaseId", c.GetVerificationConfig)
+	router.POST("/verification-config/:databaseId/verify", c.VerifyLastBackup)
+}
+
+// SaveVerificationConfig
+// @Summary Save backup verification configuration
+// @Description Create or update scheduled restore verification of database backups
+// @Tags verification-config
+// @Accept json
+// @Produce json
+// @Param request body VerificationConfig true "Verification configuration data"
+// @Success 200 {object} VerificationConfig
+// @Failure 400
+// @Failure 401
+// @Router /verification-config [post]
+func (c *VerificationController) SaveVerificationConfig(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request VerificationConfig
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	savedConfig, err := c.verificationService.SaveVerificationConfigWithAuth(user, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, savedConfig)
+}
+
+// GetVerificationConfig
+// @Summary Get backup verification configuration
+// @Description Get scheduled restore verification configuration of the database
+// @Tags verification-config
+// @Produce json
+// @Param databaseId path string true "Database ID"
+// @Success 200 {object} VerificationConfig
+// @Failure 400
+// @Failure 401
+// @Router /verification-config/{databaseId} [get]
+func (c *VerificationController) GetVerificationConfig(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	databaseID, err := uuid.Parse(ctx.Param("databaseId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid database ID"})
+		return
+	}
+
+	verificationConfig, err := c.verificationService.GetVerificationConfigWithAuth(user, databaseID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verificationConfig)
+}
+
+// VerifyLastBackup
+// @Summary Verify the latest backup
+// @Description Restore the latest completed backup into the target database and run the checks
+// @Tags verification-config
+// @Param databaseId path string true "Database ID"
+// @Success 200 {object} map[string]string
+// @Failure 400
+// @Failure 401
+// @Router /verification-config/{databaseId}/verify [post]
+func (c *VerificationController) VerifyLastBackup(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	databaseID, err := uuid.Parse(ctx.Param("databaseId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid database ID"})
+		return
+	}
+
+	if err := c.verificationService.VerifyLastBackupWithAuth(user, databaseID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "backup verification started successfully"})
+}
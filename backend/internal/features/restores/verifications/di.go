@@ -0,0 +1,56 @@
+package restores_verifications
+
+import (
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	"postgresus-backend/internal/features/restores/usecases"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
+)
+
+var verificationConfigRepository = &VerificationConfigRepository{}
+
+var verificationService = &VerificationService{
+	verificationConfigRepository,
+	backups.GetBackupService(),
+	backups_config.GetBackupConfigService(),
+	databases.GetDatabaseService(),
+	usecases.GetRestoreBackupUsecase(),
+	notifiers.GetNotifierService(),
+	workspaces_services.GetAuthorizationService(),
+	users_services.GetUserService(),
+	audit_logs.GetAuditLogService(),
+	encryption.GetFieldEncryptor(),
+	logger.GetLogger(),
+}
+
+var verificationBackgroundService = &VerificationBackgroundService{
+	verificationService,
+	backups.GetBackupService(),
+	logger.GetLogger(),
+}
+
+var verificationController = &VerificationController{
+	verificationService,
+}
+
+func SetupDependencies() {
+	databases.GetDatabaseService().AddDbRemoveListener(verificationService)
+}
+
+func GetVerificationService() *VerificationService {
+	return verificationService
+}
+
+func GetVerificationBackgroundService() *VerificationBackgroundService {
+	return verificationBackgroundService
+}
+
+func GetVerificationController() *VerificationController {
+	return verificationController
+}
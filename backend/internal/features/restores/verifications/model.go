@@ -0,0 +1,131 @@
+package restores_verifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"postgresus-backend/internal/features/backups/backups"
+	"postgresus-backend/internal/features/intervals"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VerificationConfig describes the scheduled restore drill of the database:
+// the latest backup is restored into the target database and the checks are
+// run against the restored data
+type VerificationConfig struct {
+	DatabaseID uuid.UUID `json:"databaseId" gorm:"column:database_id;type:uuid;primaryKey"`
+
+	IsVerificationEnabled bool `json:"isVerificationEnabled" gorm:"column:is_verification_enabled;type:boolean;not null;default:false"`
+
+	VerificationIntervalID *uuid.UUID          `json:"verificationIntervalId"         gorm:"column:verification_interval_id;type:uuid"`
+	VerificationInterval   *intervals.Interval `json:"verificationInterval,omitempty" gorm:"foreignKey:VerificationIntervalID"`
+
+	// TargetDatabaseID is a scratch database of the same type. It is fully
+	// overwritten by each verification, so it must not contain useful data
+	TargetDatabaseID *uuid.UUID `json:"targetDatabaseId" gorm:"column:target_database_id;type:uuid"`
+
+	// UpdatedByUserID is the user who saved the config last. Scheduled
+	// verifications overwrite the target on behalf of this user, so the
+	// permissions are checked again before each run
+	UpdatedByUserID *uuid.UUID `json:"updatedByUserId" gorm:"column:updated_by_user_id;type:uuid"`
+
+	Checks       []*VerificationCheck `json:"checks" gorm:"-"`
+	ChecksString string               `json:"-"      gorm:"column:checks;type:text;not null;default:'[]'"`
+}
+
+// VerificationCheck is a query returning a single value. For MongoDB the
+// query is a collection name and the value is its documents count. When
+// expected value is empty, the check passes if the query succeeds
+type VerificationCheck struct {
+	Name          string  `json:"name"`
+	Query         string  `json:"query"`
+	ExpectedValue *string `json:"expectedValue"`
+}
+
+func (c *VerificationConfig) TableName() string {
+	return "verification_configs"
+}
+
+func (c *VerificationConfig) BeforeSave(_ *gorm.DB) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.Checks == nil {
+		c.Checks = []*VerificationCheck{}
+	}
+
+	checks, err := json.Marshal(c.Checks)
+	if err != nil {
+		return err
+	}
+
+	c.ChecksString = string(checks)
+
+	return nil
+}
+
+func (c *VerificationConfig) AfterFind(_ *gorm.DB) error {
+	c.Checks = []*VerificationCheck{}
+
+	if c.ChecksString == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(c.ChecksString), &c.Checks)
+}
+
+func (c *VerificationConfig) Validate() error {
+	if c.IsVerificationEnabled {
+		if c.VerificationIntervalID == nil && c.VerificationInterval == nil {
+			return errors.New("verification interval is required")
+		}
+
+		if c.TargetDatabaseID == nil {
+			return errors.New("target database is required")
+		}
+	}
+
+	if c.TargetDatabaseID != nil && *c.TargetDatabaseID == c.DatabaseID {
+		return errors.New("backup cannot be verified by restoring into the same database")
+	}
+
+	for i, check := range c.Checks {
+		if check == nil || check.Name == "" {
+			return fmt.Errorf("name is required for check #%d", i+1)
+		}
+
+		if check.Query == "" {
+			return fmt.Errorf("query is required for check \"%s\"", check.Name)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate converts query outcome into the check result
+func (c *VerificationCheck) Evaluate(value *string, queryErr error) *backups.VerificationCheckResult {
+	result := &backups.VerificationCheckResult{
+		Name:          c.Name,
+		Value:         value,
+		ExpectedValue: c.ExpectedValue,
+	}
+
+	if queryErr != nil {
+		errMsg := queryErr.Error()
+		result.Error = &errMsg
+		return result
+	}
+
+	if c.ExpectedValue == nil {
+		result.IsPassed = true
+		return result
+	}
+
+	result.IsPassed = value != nil && *value == *c.ExpectedValue
+
+	return result
+}
@@ -0,0 +1,61 @@
+package restores_verifications
+
+import (
+	"errors"
+	"testing"
+
+	"postgresus-backend/internal/features/intervals"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VerificationConfig_Validate_EnabledConfigRequiresIntervalAndTarget(t *testing.T) {
+	databaseID := uuid.New()
+	targetDatabaseID := uuid.New()
+
+	verificationConfig := &VerificationConfig{
+		DatabaseID:            databaseID,
+		IsVerificationEnabled: true,
+	}
+	assert.EqualError(t, verificationConfig.Validate(), "verification interval is required")
+
+	verificationConfig.VerificationInterval = &intervals.Interval{Interval: intervals.IntervalHourly}
+	assert.EqualError(t, verificationConfig.Validate(), "target database is required")
+
+	verificationConfig.TargetDatabaseID = &databaseID
+	assert.Error(t, verificationConfig.Validate())
+
+	verificationConfig.TargetDatabaseID = &targetDatabaseID
+	assert.NoError(t, verificationConfig.Validate())
+
+	verificationConfig.Checks = []*VerificationCheck{{Name: "users count"}}
+	assert.EqualError(t, verificationConfig.Validate(), "query is required for check \"users count\"")
+}
+
+func Test_VerificationCheck_Evaluate_ComparesWithExpectedValue(t *testing.T) {
+	expectedValue := "42"
+	actualValue := "42"
+	otherValue := "41"
+
+	check := &VerificationCheck{
+		Name:          "users count",
+		Query:         "SELECT count(*) FROM users",
+		ExpectedValue: &expectedValue,
+	}
+
+	assert.True(t, check.Evaluate(&actualValue, nil).IsPassed)
+	assert.False(t, check.Evaluate(&otherValue, nil).IsPassed)
+	assert.False(t, check.Evaluate(nil, nil).IsPassed)
+
+	failedResult := check.Evaluate(nil, errors.New("relation \"users\" does not exist"))
+	assert.False(t, failedResult.IsPassed)
+	assert.NotNil(t, failedResult.Error)
+
+	t.Run("Without expected value: Passed when query succeeds", func(t *testing.T) {
+		check.ExpectedValue = nil
+
+		assert.True(t, check.Evaluate(&otherValue, nil).IsPassed)
+		assert.False(t, check.Evaluate(nil, errors.New("syntax error")).IsPassed)
+	})
+}
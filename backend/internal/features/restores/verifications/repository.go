@@ -0,0 +1,97 @@
+package restores_verifications
+
+import (
+	"errors"
+
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VerificationConfigRepository struct{}
+
+func (r *VerificationConfigRepository) Save(
+	verificationConfig *VerificationConfig,
+) (*VerificationConfig, error) {
+	db := storage.GetDb()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if verificationConfig.VerificationInterval != nil {
+			if verificationConfig.VerificationInterval.ID == uuid.Nil {
+				if err := tx.Create(verificationConfig.VerificationInterval).Error; err != nil {
+					return err
+				}
+			} else {
+				if err := tx.Save(verificationConfig.VerificationInterval).Error; err != nil {
+					return err
+				}
+			}
+
+			verificationConfig.VerificationIntervalID = &verificationConfig.VerificationInterval.ID
+		}
+
+		return tx.Omit("VerificationInterval").
+			Save(verificationConfig).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return verificationConfig, nil
+}
+
+func (r *VerificationConfigRepository) FindByDatabaseID(
+	databaseID uuid.UUID,
+) (*VerificationConfig, error) {
+	var verificationConfig VerificationConfig
+
+	if err := storage.
+		GetDb().
+		Preload("VerificationInterval").
+		Where("database_id = ?", databaseID).
+		First(&verificationConfig).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &verificationConfig, nil
+}
+
+func (r *VerificationConfigRepository) FindWithEnabledVerification() (
+	[]*VerificationConfig, error,
+) {
+	var verificationConfigs []*VerificationConfig
+
+	if err := storage.
+		GetDb().
+		Preload("VerificationInterval").
+		Where("is_verification_enabled = ?", true).
+		Find(&verificationConfigs).Error; err != nil {
+		return nil, err
+	}
+
+	return verificationConfigs, nil
+}
+
+// DeleteByDatabaseID removes the config of the database and disables configs
+// which use the database as the verification target
+func (r *VerificationConfigRepository) DeleteByDatabaseID(databaseID uuid.UUID) error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&VerificationConfig{}).
+			Where("target_database_id = ?", databaseID).
+			UpdateColumns(map[string]any{
+				"target_database_id":      nil,
+				"is_verification_enabled": false,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&VerificationConfig{}, "database_id = ?", databaseID).Error
+	})
+}
@@ -0,0 +1,520 @@
+package restores_verifications
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	"postgresus-backend/internal/features/backups/backups"
+	backups_config "postgresus-backend/internal/features/backups/config"
+	"postgresus-backend/internal/features/databases"
+	"postgresus-backend/internal/features/notifiers"
+	notifiers_events "postgresus-backend/internal/features/notifiers/events"
+	"postgresus-backend/internal/features/restores/enums"
+	"postgresus-backend/internal/features/restores/models"
+	"postgresus-backend/internal/features/restores/usecases"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+	"postgresus-backend/internal/util/encryption"
+
+	"github.com/google/uuid"
+)
+
+type VerificationService struct {
+	verificationConfigRepository *VerificationConfigRepository
+	backupService                *backups.BackupService
+	backupConfigService          *backups_config.BackupConfigService
+	databaseService              *databases.DatabaseService
+	restoreBackupUsecase         *usecases.RestoreBackupUsecase
+	notifierService              *notifiers.NotifierService
+	authorizationService         *workspaces_services.AuthorizationService
+	userService                  *users_services.UserService
+	auditLogService              *audit_logs.AuditLogService
+	fieldEncryptor               encryption.FieldEncryptor
+	logger                       *slog.Logger
+}
+
+func (s *VerificationService) OnBeforeDatabaseRemove(databaseID uuid.UUID) error {
+	return s.verificationConfigRepository.DeleteByDatabaseID(databaseID)
+}
+
+func (s *VerificationService) SaveVerificationConfigWithAuth(
+	user *users_models.User,
+	verificationConfig *VerificationConfig,
+) (*VerificationConfig, error) {
+	if err := verificationConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	database, err := s.getManagedDatabase(user, verificationConfig.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if verificationConfig.TargetDatabaseID != nil {
+		targetDatabase, err := s.databaseService.GetDatabaseByID(*verificationConfig.TargetDatabaseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get target database: %w", err)
+		}
+
+		if err := s.validateTargetDatabase(user, database, targetDatabase); err != nil {
+			return nil, err
+		}
+	}
+
+	verificationConfig.UpdatedByUserID = &user.ID
+
+	existingConfig, err := s.verificationConfigRepository.FindByDatabaseID(database.ID)
+	if err != nil {
+		return nil, err
+	}
+	configBefore := audit_logs.Snapshot(existingConfig)
+
+	savedConfig, err := s.verificationConfigRepository.Save(verificationConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceVerificationConfig,
+		ResourceID:   &database.ID,
+		Message: fmt.Sprintf(
+			"Backup verification config updated for database '%s'",
+			database.Name,
+		),
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+		Before:      configBefore,
+		After:       savedConfig,
+	})
+
+	return savedConfig, nil
+}
+
+func (s *VerificationService) GetVerificationConfigWithAuth(
+	user *users_models.User,
+	databaseID uuid.UUID,
+) (*VerificationConfig, error) {
+	database, err := s.databaseService.GetDatabaseByID(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if database.WorkspaceID == nil {
+		return nil, errors.New("cannot access verification config for database without workspace")
+	}
+
+	canAccess, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccess {
+		return nil, errors.New("insufficient permissions to view verification config")
+	}
+
+	verificationConfig, err := s.verificationConfigRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if verificationConfig == nil {
+		return &VerificationConfig{
+			DatabaseID:            databaseID,
+			IsVerificationEnabled: false,
+			Checks:                []*VerificationCheck{},
+		}, nil
+	}
+
+	return verificationConfig, nil
+}
+
+func (s *VerificationService) VerifyLastBackupWithAuth(
+	user *users_models.User,
+	databaseID uuid.UUID,
+) error {
+	database, err := s.getManagedDatabase(user, databaseID)
+	if err != nil {
+		return err
+	}
+
+	verificationConfig, err := s.verificationConfigRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if verificationConfig == nil || verificationConfig.TargetDatabaseID == nil {
+		return errors.New("target database for verification is not configured")
+	}
+
+	targetDatabase, err := s.databaseService.GetDatabaseByID(*verificationConfig.TargetDatabaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get target database: %w", err)
+	}
+
+	if err := s.validateTargetDatabase(user, database, targetDatabase); err != nil {
+		return err
+	}
+
+	lastResult, err := s.backupService.GetLastVerificationResult(databaseID)
+	if err != nil {
+		return err
+	}
+
+	if lastResult != nil && lastResult.Status == backups.VerificationStatusInProgress {
+		return errors.New("verification is already in progress")
+	}
+
+	go func() {
+		if err := s.VerifyLastBackup(verificationConfig); err != nil {
+			s.logger.Error("Failed to verify backup", "databaseId", databaseID, "error", err)
+		}
+	}()
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionStartVerification,
+		ResourceType: audit_logs_events.AuditResourceDatabase,
+		ResourceID:   &databaseID,
+		Message: fmt.Sprintf(
+			"Backup verification manually initiated for database: %s",
+			database.Name,
+		),
+		User:        user,
+		WorkspaceID: database.WorkspaceID,
+	})
+
+	return nil
+}
+
+// VerifyLastBackup restores the latest completed backup into the target
+// database and runs the checks. The result is saved even when the
+// verification fails, so it is shown next to the backup
+func (s *VerificationService) VerifyLastBackup(verificationConfig *VerificationConfig) error {
+	backup, err := s.backupService.GetLastVerifiableBackup(verificationConfig.DatabaseID)
+	if err != nil {
+		return err
+	}
+
+	if backup == nil {
+		s.logger.Info(
+			"No completed backups to verify",
+			"databaseId",
+			verificationConfig.DatabaseID,
+		)
+		return nil
+	}
+
+	result := &backups.VerificationResult{
+		BackupID:     backup.ID,
+		DatabaseID:   backup.DatabaseID,
+		Status:       backups.VerificationStatusInProgress,
+		CheckResults: []*backups.VerificationCheckResult{},
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	if err := s.backupService.SaveVerificationResult(result); err != nil {
+		return err
+	}
+
+	start := time.Now().UTC()
+
+	checkResults, verifyErr := s.restoreAndCheck(verificationConfig, backup)
+
+	result.CheckResults = checkResults
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Status = backups.VerificationStatusSuccess
+
+	if verifyErr == nil {
+		for _, checkResult := range checkResults {
+			if !checkResult.IsPassed {
+				verifyErr = fmt.Errorf("check \"%s\" failed", checkResult.Name)
+				break
+			}
+		}
+	}
+
+	if verifyErr != nil {
+		failMessage := verifyErr.Error()
+		result.Status = backups.VerificationStatusFailed
+		result.FailMessage = &failMessage
+	}
+
+	if err := s.backupService.SaveVerificationResult(result); err != nil {
+		return err
+	}
+
+	if verifyErr != nil {
+		s.sendVerificationFailedNotification(backup, verifyErr)
+	}
+
+	return nil
+}
+
+func (s *VerificationService) GetConfigsWithEnabledVerification() ([]*VerificationConfig, error) {
+	return s.verificationConfigRepository.FindWithEnabledVerification()
+}
+
+func (s *VerificationService) restoreAndCheck(
+	verificationConfig *VerificationConfig,
+	backup *backups.Backup,
+) ([]*backups.VerificationCheckResult, error) {
+	if verificationConfig.TargetDatabaseID == nil {
+		return nil, errors.New("target database for verification is not configured")
+	}
+
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetDatabase, err := s.databaseService.GetDatabaseByID(*verificationConfig.TargetDatabaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target database: %w", err)
+	}
+
+	// permissions or approval settings may change after the config is saved
+	if verificationConfig.UpdatedByUserID == nil {
+		return nil, errors.New("verification config has no owner, save it again to verify backups")
+	}
+
+	updatedBy, err := s.userService.GetUserByID(*verificationConfig.UpdatedByUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user who configured verification: %w", err)
+	}
+
+	if !updatedBy.IsActiveUser() {
+		return nil, errors.New("user who configured verification is not active anymore")
+	}
+
+	if err := s.validateTargetDatabase(updatedBy, database, targetDatabase); err != nil {
+		return nil, err
+	}
+
+	restoringToDB, err := s.buildRestoringToDB(targetDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	backupConfig, err := s.backupConfigService.GetBackupConfigByDbId(database.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := s.backupService.GetAvailableBackupStorage(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	// restore record is not saved: drills are not shown in restores history
+	restore := models.Restore{
+		ID:        uuid.New(),
+		Status:    enums.RestoreStatusInProgress,
+		BackupID:  backup.ID,
+		Backup:    backup,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = s.restoreBackupUsecase.Execute(
+		backupConfig,
+		restore,
+		database,
+		restoringToDB,
+		backup,
+		storage,
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	checkResults := make([]*backups.VerificationCheckResult, 0, len(verificationConfig.Checks))
+	for _, check := range verificationConfig.Checks {
+		value, queryErr := targetDatabase.RunVerificationQuery(s.logger, s.fieldEncryptor, check.Query)
+		checkResults = append(checkResults, check.Evaluate(value, queryErr))
+	}
+
+	return checkResults, nil
+}
+
+// buildRestoringToDB copies connection of the target database with decrypted
+// password, because restore usecases expect plain credentials
+func (s *VerificationService) buildRestoringToDB(
+	targetDatabase *databases.Database,
+) (*databases.Database, error) {
+	restoringToDB := &databases.Database{
+		ID:   targetDatabase.ID,
+		Type: targetDatabase.Type,
+	}
+
+	switch {
+	case targetDatabase.Postgresql != nil:
+		password, err := s.fieldEncryptor.Decrypt(targetDatabase.ID, targetDatabase.Postgresql.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt target database password: %w", err)
+		}
+
+		pgCopy := *targetDatabase.Postgresql
+		pgCopy.Password = password
+		restoringToDB.Postgresql = &pgCopy
+	case targetDatabase.Mysql != nil:
+		password, err := s.fieldEncryptor.Decrypt(targetDatabase.ID, targetDatabase.Mysql.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt target database password: %w", err)
+		}
+
+		mysqlCopy := *targetDatabase.Mysql
+		mysqlCopy.Password = password
+		restoringToDB.Mysql = &mysqlCopy
+	case targetDatabase.Mariadb != nil:
+		password, err := s.fieldEncryptor.Decrypt(targetDatabase.ID, targetDatabase.Mariadb.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt target database password: %w", err)
+		}
+
+		mariadbCopy := *targetDatabase.Mariadb
+		mariadbCopy.Password = password
+		restoringToDB.Mariadb = &mariadbCopy
+	case targetDatabase.Mongodb != nil:
+		password, err := s.fieldEncryptor.Decrypt(targetDatabase.ID, targetDatabase.Mongodb.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt target database password: %w", err)
+		}
+
+		mongodbCopy := *targetDatabase.Mongodb
+		mongodbCopy.Password = password
+		restoringToDB.Mongodb = &mongodbCopy
+	default:
+		return nil, errors.New("target database connection is not configured")
+	}
+
+	return restoringToDB, nil
+}
+
+func (s *VerificationService) getManagedDatabase(
+	user *users_models.User,
+	databaseID uuid.UUID,
+) (*databases.Database, error) {
+	database, err := s.databaseService.GetDatabaseByID(databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if database.WorkspaceID == nil {
+		return nil, errors.New("cannot verify backups of database without workspace")
+	}
+
+	canManage, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionManageDatabases,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage {
+		return nil, errors.New("insufficient permissions to manage backup verification")
+	}
+
+	return database, nil
+}
+
+// validateTargetDatabase checks that the user may overwrite the target
+// database. Each verification restores into the target, so it requires the
+// same permissions as a manual restore to another database, and targets
+// protected by restore approvals are not allowed
+func (s *VerificationService) validateTargetDatabase(
+	user *users_models.User,
+	database *databases.Database,
+	targetDatabase *databases.Database,
+) error {
+	if targetDatabase.WorkspaceID == nil ||
+		*targetDatabase.WorkspaceID != *database.WorkspaceID {
+		return errors.New("target database must belong to the same workspace")
+	}
+
+	if targetDatabase.Type != database.Type {
+		return errors.New("target database type must match database type")
+	}
+
+	canRestoreToOtherTarget, err := s.authorizationService.HasDatabasePermission(
+		*database.WorkspaceID,
+		database.ID,
+		user,
+		users_enums.WorkspacePermissionRestoreToOtherTarget,
+	)
+	if err != nil {
+		return err
+	}
+	if !canRestoreToOtherTarget {
+		return errors.New("insufficient permissions to restore backups to another database")
+	}
+
+	for _, permission := range []users_enums.WorkspacePermission{
+		users_enums.WorkspacePermissionRestore,
+		users_enums.WorkspacePermissionRestoreToOtherTarget,
+	} {
+		canRestoreTarget, err := s.authorizationService.HasDatabasePermission(
+			*targetDatabase.WorkspaceID,
+			targetDatabase.ID,
+			user,
+			permission,
+		)
+		if err != nil {
+			return err
+		}
+		if !canRestoreTarget {
+			return errors.New("insufficient permissions to restore into the target database")
+		}
+	}
+
+	targetBackupConfig, err := s.backupConfigService.GetBackupConfigByDbId(targetDatabase.ID)
+	if err != nil {
+		return err
+	}
+
+	if targetBackupConfig.IsRestoreApprovalRequired {
+		return errors.New("target database requires restore approval, choose another target")
+	}
+
+	return nil
+}
+
+func (s *VerificationService) sendVerificationFailedNotification(
+	backup *backups.Backup,
+	verifyErr error,
+) {
+	database, err := s.databaseService.GetDatabaseByID(backup.DatabaseID)
+	if err != nil {
+		s.logger.Error("Failed to get database for notification", "error", err)
+		return
+	}
+
+	event := notifiers_events.NewNotificationEvent(
+		notifiers_events.NotificationEventBackupVerificationFailed,
+		fmt.Sprintf("❌ Backup verification failed: %s", database.Name),
+		fmt.Sprintf(
+			"Backup from %s could not be verified: %s",
+			notifiers_events.FormatTime(backup.CreatedAt),
+			verifyErr.Error(),
+		),
+	)
+	errorMessage := verifyErr.Error()
+	event.Error = &errorMessage
+	event.Database = database.ToNotificationEventDatabase()
+	event.Backup = backup.ToNotificationEventBackup()
+
+	for _, notifier := range database.Notifiers {
+		s.notifierService.SendNotification(&notifier, event)
+	}
+}
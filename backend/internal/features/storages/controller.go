@@ -2,6 +2,7 @@ package storages
 
 import (
 	"net/http"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_middleware "postgresus-backend/internal/features/users/middleware"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 
@@ -10,8 +11,8 @@ import (
 )
 
 type StorageController struct {
-	storageService   *StorageService
-	workspaceService *workspaces_services.WorkspaceService
+	storageService       *StorageService
+	authorizationService *workspaces_services.AuthorizationService
 }
 
 func (c *StorageController) RegisterRoutes(router *gin.RouterGroup) {
@@ -253,7 +254,11 @@ func (c *StorageController) TestStorageConnectionDirect(ctx *gin.Context) {
 		return
 	}
 
-	canView, _, err := c.workspaceService.CanUserAccessWorkspace(request.WorkspaceID, user)
+	canView, err := c.authorizationService.HasPermission(
+		request.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageStorages,
+	)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -9,13 +9,13 @@ import (
 var storageRepository = &StorageRepository{}
 var storageService = &StorageService{
 	storageRepository,
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 	audit_logs.GetAuditLogService(),
 	encryption.GetFieldEncryptor(),
 }
 var storageController = &StorageController{
 	storageService,
-	workspaces_services.GetWorkspaceService(),
+	workspaces_services.GetAuthorizationService(),
 }
 
 func GetStorageService() *StorageService {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	workspaces_services "postgresus-backend/internal/features/workspaces/services"
 	"postgresus-backend/internal/util/encryption"
@@ -13,10 +15,10 @@ import (
 )
 
 type StorageService struct {
-	storageRepository *StorageRepository
-	workspaceService  *workspaces_services.WorkspaceService
-	auditLogService   *audit_logs.AuditLogService
-	fieldEncryptor    encryption.FieldEncryptor
+	storageRepository    *StorageRepository
+	authorizationService *workspaces_services.AuthorizationService
+	auditLogService      *audit_logs.AuditLogService
+	fieldEncryptor       encryption.FieldEncryptor
 }
 
 func (s *StorageService) SaveStorage(
@@ -24,7 +26,11 @@ func (s *StorageService) SaveStorage(
 	workspaceID uuid.UUID,
 	storage *Storage,
 ) error {
-	canManage, err := s.workspaceService.CanUserManageDBs(workspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageStorages,
+	)
 	if err != nil {
 		return err
 	}
@@ -44,6 +50,7 @@ func (s *StorageService) SaveStorage(
 			return errors.New("storage does not belong to this workspace")
 		}
 
+		storageBefore := audit_logs.Snapshot(existingStorage)
 		existingStorage.Update(storage)
 
 		if err := existingStorage.EncryptSensitiveData(s.fieldEncryptor); err != nil {
@@ -59,11 +66,16 @@ func (s *StorageService) SaveStorage(
 			return err
 		}
 
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf("Storage updated: %s", existingStorage.Name),
-			&user.ID,
-			&workspaceID,
-		)
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionUpdate,
+			ResourceType: audit_logs_events.AuditResourceStorage,
+			ResourceID:   &existingStorage.ID,
+			Message:      fmt.Sprintf("Storage updated: %s", existingStorage.Name),
+			User:         user,
+			WorkspaceID:  &workspaceID,
+			Before:       storageBefore,
+			After:        existingStorage,
+		})
 	} else {
 		storage.WorkspaceID = workspaceID
 
@@ -80,11 +92,14 @@ func (s *StorageService) SaveStorage(
 			return err
 		}
 
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf("Storage created: %s", storage.Name),
-			&user.ID,
-			&workspaceID,
-		)
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionCreate,
+			ResourceType: audit_logs_events.AuditResourceStorage,
+			ResourceID:   &storage.ID,
+			Message:      fmt.Sprintf("Storage created: %s", storage.Name),
+			User:         user,
+			WorkspaceID:  &workspaceID,
+		})
 	}
 
 	return nil
@@ -99,7 +114,11 @@ func (s *StorageService) DeleteStorage(
 		return err
 	}
 
-	canManage, err := s.workspaceService.CanUserManageDBs(storage.WorkspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		storage.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionManageStorages,
+	)
 	if err != nil {
 		return err
 	}
@@ -112,11 +131,14 @@ func (s *StorageService) DeleteStorage(
 		return err
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Storage deleted: %s", storage.Name),
-		&user.ID,
-		&storage.WorkspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceStorage,
+		ResourceID:   &storage.ID,
+		Message:      fmt.Sprintf("Storage deleted: %s", storage.Name),
+		User:         user,
+		WorkspaceID:  &storage.WorkspaceID,
+	})
 
 	return nil
 }
@@ -130,7 +152,11 @@ func (s *StorageService) GetStorage(
 		return nil, err
 	}
 
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(storage.WorkspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		storage.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +173,11 @@ func (s *StorageService) GetStorages(
 	user *users_models.User,
 	workspaceID uuid.UUID,
 ) ([]*Storage, error) {
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(workspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -176,7 +206,11 @@ func (s *StorageService) TestStorageConnection(
 		return err
 	}
 
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(storage.WorkspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		storage.WorkspaceID,
+		user,
+		users_enums.WorkspacePermissionView,
+	)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"testing"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	users_dto "postgresus-backend/internal/features/users/dto"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_middleware "postgresus-backend/internal/features/users/middleware"
@@ -254,10 +255,6 @@ func createE2ETestRouter() *gin.Engine {
 
 type AuditLogWriterStub struct{}
 
-func (a *AuditLogWriterStub) WriteAuditLog(
-	message string,
-	userID *uuid.UUID,
-	workspaceID *uuid.UUID,
-) {
+func (a *AuditLogWriterStub) WriteAuditEvent(event *audit_logs_events.AuditEvent) {
 	// do nothing
 }
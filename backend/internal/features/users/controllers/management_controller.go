@@ -35,6 +35,16 @@ func (c *ManagementController) RegisterRoutes(router *gin.RouterGroup) {
 		user_middleware.RequireRole(user_enums.UserRoleAdmin),
 		c.ChangeUserRole,
 	)
+	router.POST(
+		"/users/:id/force-logout",
+		user_middleware.RequireRole(user_enums.UserRoleAdmin),
+		c.ForceLogoutUser,
+	)
+	router.POST(
+		"/users/:id/2fa/reset",
+		user_middleware.RequireRole(user_enums.UserRoleAdmin),
+		c.ResetUserTwoFactor,
+	)
 }
 
 // ListUsers
@@ -96,6 +106,8 @@ func (c *ManagementController) GetUsers(ctx *gin.Context) {
 			Role:      u.Role,
 			IsActive:  u.IsActiveUser(),
 			CreatedAt: u.CreatedAt,
+
+			IsTwoFactorEnabled: u.IsTwoFactorEnabled,
 		}
 	}
 
@@ -146,6 +158,8 @@ func (c *ManagementController) GetUserProfile(ctx *gin.Context) {
 		Role:      user.Role,
 		IsActive:  user.IsActiveUser(),
 		CreatedAt: user.CreatedAt,
+
+		IsTwoFactorEnabled: user.IsTwoFactorEnabled,
 	}
 
 	ctx.JSON(http.StatusOK, profile)
@@ -258,3 +272,69 @@ func (c *ManagementController) ChangeUserRole(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "User role changed successfully"})
 }
+
+// ForceLogoutUser
+// @Summary Force logout user
+// @Description Revoke all sessions of the user (admin only)
+// @Tags user-management
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Router /users/{id}/force-logout [post]
+func (c *ManagementController) ForceLogoutUser(ctx *gin.Context) {
+	currentUser, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr := ctx.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.managementService.ForceLogoutUser(userID, currentUser); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User logged out successfully"})
+}
+
+// ResetUserTwoFactor
+// @Summary Reset user two-factor authentication
+// @Description Disable 2FA of the user who lost the authenticator app (admin only)
+// @Tags user-management
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Router /users/{id}/2fa/reset [post]
+func (c *ManagementController) ResetUserTwoFactor(ctx *gin.Context) {
+	currentUser, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userIDStr := ctx.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.managementService.ResetUserTwoFactor(userID, currentUser); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset successfully"})
+}
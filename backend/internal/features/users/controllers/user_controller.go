@@ -9,6 +9,7 @@ import (
 	users_services "postgresus-backend/internal/features/users/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/time/rate"
 )
 
@@ -20,6 +21,9 @@ type UserController struct {
 func (c *UserController) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/users/signup", c.SignUp)
 	router.POST("/users/signin", c.SignIn)
+	router.POST("/users/refresh", c.RefreshToken)
+	router.POST("/users/signin/2fa", c.SignInWithTwoFactor)
+	router.POST("/users/signin/2fa/setup", c.SetupTwoFactorOnSignIn)
 
 	// Admin password setup (no auth required)
 	router.GET("/users/admin/has-password", c.IsAdminHasPassword)
@@ -28,6 +32,11 @@ func (c *UserController) RegisterRoutes(router *gin.RouterGroup) {
 	// OAuth callbacks
 	router.POST("/auth/github/callback", c.HandleGitHubOAuth)
 	router.POST("/auth/google/callback", c.HandleGoogleOAuth)
+
+	// OpenID Connect
+	router.GET("/auth/oidc/config", c.GetOIDCConfig)
+	router.POST("/auth/oidc/authorize", c.StartOIDCSignIn)
+	router.POST("/auth/oidc/callback", c.HandleOIDCOAuth)
 }
 
 func (c *UserController) RegisterProtectedRoutes(router *gin.RouterGroup) {
@@ -35,6 +44,19 @@ func (c *UserController) RegisterProtectedRoutes(router *gin.RouterGroup) {
 	router.PUT("/users/me", c.UpdateUserInfo)
 	router.PUT("/users/change-password", c.ChangePassword)
 	router.POST("/users/invite", c.InviteUser)
+	router.POST("/users/signout", c.SignOut)
+
+	// Sessions
+	router.GET("/users/me/sessions", c.GetSessions)
+	router.DELETE("/users/me/sessions", c.RevokeAllSessions)
+	router.DELETE("/users/me/sessions/:id", c.RevokeSession)
+
+	// Two-factor authentication
+	router.GET("/users/me/2fa", c.GetTwoFactorStatus)
+	router.POST("/users/me/2fa/setup", c.SetupTwoFactor)
+	router.POST("/users/me/2fa/enable", c.EnableTwoFactor)
+	router.POST("/users/me/2fa/disable", c.DisableTwoFactor)
+	router.POST("/users/me/2fa/recovery-codes", c.RegenerateRecoveryCodes)
 }
 
 func (c *UserController) SetSignInLimiter(limiter *rate.Limiter) {
@@ -58,7 +80,7 @@ func (c *UserController) SignUp(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.SignUp(&request)
+	err := c.userService.SignUp(&request, getClientInfo(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -94,15 +116,371 @@ func (c *UserController) SignIn(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.userService.SignIn(&request)
+	response, err := c.userService.SignIn(&request, getClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SignInWithTwoFactor
+// @Summary Complete sign in with two-factor code
+// @Description Second step of sign in when 2FA is enabled or required. Accepts TOTP code or recovery code. If 2FA is set up during sign in, recovery codes are returned once
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body users_dto.TwoFactorSignInRequestDTO true "Two-factor sign in data"
+// @Success 200 {object} users_dto.SignInResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string "Rate limit exceeded"
+// @Router /users/signin/2fa [post]
+func (c *UserController) SignInWithTwoFactor(ctx *gin.Context) {
+	// Codes are short, so the same limiter as for passwords is used
+	if !c.signinLimiter.Allow() {
+		ctx.JSON(
+			http.StatusTooManyRequests,
+			gin.H{"error": "Rate limit exceeded. Please try again later."},
+		)
+		return
+	}
+
+	var request user_dto.TwoFactorSignInRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.SignInWithTwoFactor(&request, getClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactorOnSignIn
+// @Summary Set up two-factor authentication during sign in
+// @Description Generate TOTP secret when 2FA is required, but not set up yet. The setup is confirmed by /users/signin/2fa
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body users_dto.TwoFactorSetupOnSignInRequestDTO true "Two-factor token"
+// @Success 200 {object} users_dto.TwoFactorSetupResponseDTO
+// @Failure 400 {object} map[string]string
+// @Router /users/signin/2fa/setup [post]
+func (c *UserController) SetupTwoFactorOnSignIn(ctx *gin.Context) {
+	var request user_dto.TwoFactorSetupOnSignInRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.SetupTwoFactorOnSignIn(request.TwoFactorToken)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTwoFactorStatus
+// @Summary Get two-factor authentication status
+// @Description Get whether 2FA is enabled and required for the current user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} users_dto.TwoFactorStatusResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/2fa [get]
+func (c *UserController) GetTwoFactorStatus(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := c.userService.GetTwoFactorStatus(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor
+// @Summary Set up two-factor authentication
+// @Description Generate TOTP secret and otpauth URI for authenticator app. 2FA is enabled after the first code is confirmed
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} users_dto.TwoFactorSetupResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/2fa/setup [post]
+func (c *UserController) SetupTwoFactor(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	response, err := c.userService.SetupTwoFactor(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// EnableTwoFactor
+// @Summary Enable two-factor authentication
+// @Description Confirm 2FA setup with TOTP code. Recovery codes are returned once
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body users_dto.TwoFactorCodeRequestDTO true "TOTP code"
+// @Success 200 {object} users_dto.TwoFactorRecoveryCodesResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/2fa/enable [post]
+func (c *UserController) EnableTwoFactor(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request user_dto.TwoFactorCodeRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	recoveryCodes, err := c.userService.EnableTwoFactor(user, request.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		user_dto.TwoFactorRecoveryCodesResponseDTO{RecoveryCodes: recoveryCodes},
+	)
+}
+
+// DisableTwoFactor
+// @Summary Disable two-factor authentication
+// @Description Disable 2FA with TOTP code or recovery code. Not allowed when 2FA is required by settings
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body users_dto.TwoFactorCodeRequestDTO true "TOTP or recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/2fa/disable [post]
+func (c *UserController) DisableTwoFactor(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request user_dto.TwoFactorCodeRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.userService.DisableTwoFactor(user, request.Code); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes
+// @Summary Regenerate recovery codes
+// @Description Replace recovery codes with a new set, requires TOTP code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body users_dto.TwoFactorCodeRequestDTO true "TOTP code"
+// @Success 200 {object} users_dto.TwoFactorRecoveryCodesResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/2fa/recovery-codes [post]
+func (c *UserController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request user_dto.TwoFactorCodeRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	recoveryCodes, err := c.userService.RegenerateRecoveryCodes(user, request.Code)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	ctx.JSON(
+		http.StatusOK,
+		user_dto.TwoFactorRecoveryCodesResponseDTO{RecoveryCodes: recoveryCodes},
+	)
+}
+
+// RefreshToken
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated on every use
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body users_dto.RefreshTokenRequestDTO true "Refresh token"
+// @Success 200 {object} users_dto.SignInResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/refresh [post]
+func (c *UserController) RefreshToken(ctx *gin.Context) {
+	var request user_dto.RefreshTokenRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.RefreshAccessToken(request.RefreshToken, getClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, response)
 }
 
+// SignOut
+// @Summary Sign out
+// @Description Revoke the session of the current access token
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/signout [post]
+func (c *UserController) SignOut(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := c.userService.SignOut(user); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Signed out successfully"})
+}
+
+// GetSessions
+// @Summary Get active sessions
+// @Description Get active sessions of the current user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} users_dto.UserSessionResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/sessions [get]
+func (c *UserController) GetSessions(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := c.userService.GetUserSessions(user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession
+// @Summary Revoke session
+// @Description Revoke one of the sessions of the current user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/sessions/{id} [delete]
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := c.userService.RevokeSession(user, sessionID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions
+// @Summary Revoke all sessions
+// @Description Sign out the current user from all devices
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /users/me/sessions [delete]
+func (c *UserController) RevokeAllSessions(ctx *gin.Context) {
+	user, ok := user_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := c.userService.RevokeAllSessions(user); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "All sessions revoked successfully"})
+}
+
 // Admin password endpoints
 func (c *UserController) IsAdminHasPassword(ctx *gin.Context) {
 	hasPassword, err := c.userService.IsRootAdminHasPassword()
@@ -300,7 +678,11 @@ func (c *UserController) HandleGitHubOAuth(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.userService.HandleGitHubOAuth(request.Code, request.RedirectUri)
+	response, err := c.userService.HandleGitHubOAuth(
+		request.Code,
+		request.RedirectUri,
+		getClientInfo(ctx),
+	)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -333,7 +715,54 @@ func (c *UserController) HandleGoogleOAuth(ctx *gin.Context) {
 		return
 	}
 
-	response, err := c.userService.HandleGoogleOAuth(request.Code, request.RedirectUri)
+	response, err := c.userService.HandleGoogleOAuth(
+		request.Code,
+		request.RedirectUri,
+		getClientInfo(ctx),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetOIDCConfig
+// @Summary Get OpenID Connect configuration
+// @Description Get whether single sign-on via OpenID Connect is configured
+// @Tags auth
+// @Produce json
+// @Success 200 {object} users_dto.OIDCConfigResponseDTO
+// @Router /auth/oidc/config [get]
+func (c *UserController) GetOIDCConfig(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.userService.GetOIDCConfig())
+}
+
+// StartOIDCSignIn
+// @Summary Start OpenID Connect sign in
+// @Description Get authorization URL of the OpenID provider. State and flow token must be sent back with the callback
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body users_dto.OIDCAuthorizeRequestDTO true "Redirect URI"
+// @Success 200 {object} users_dto.OIDCAuthorizeResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /auth/oidc/authorize [post]
+func (c *UserController) StartOIDCSignIn(ctx *gin.Context) {
+	if !c.userService.GetOIDCConfig().IsEnabled {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"error": "OpenID Connect is not configured"})
+		return
+	}
+
+	var request user_dto.OIDCAuthorizeRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.StartOIDCSignIn(request.RedirectUri)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -341,3 +770,42 @@ func (c *UserController) HandleGoogleOAuth(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// HandleOIDCOAuth
+// @Summary Handle OpenID Connect callback
+// @Description Exchange authorization code for JWT token. Role and workspace memberships are synced from groups claim when mapping is configured
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body users_dto.OIDCCallbackRequestDTO true "OIDC callback data"
+// @Success 200 {object} users_dto.OAuthCallbackResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /auth/oidc/callback [post]
+func (c *UserController) HandleOIDCOAuth(ctx *gin.Context) {
+	if !c.userService.GetOIDCConfig().IsEnabled {
+		ctx.JSON(http.StatusNotImplemented, gin.H{"error": "OpenID Connect is not configured"})
+		return
+	}
+
+	var request user_dto.OIDCCallbackRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.userService.HandleOIDCOAuth(&request, getClientInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func getClientInfo(ctx *gin.Context) *user_dto.ClientInfoDTO {
+	return &user_dto.ClientInfoDTO{
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	}
+}
@@ -1,19 +1,31 @@
 package users_controllers
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"postgresus-backend/internal/features/encryption/secrets"
 	users_dto "postgresus-backend/internal/features/users/dto"
 	users_enums "postgresus-backend/internal/features/users/enums"
+	users_repositories "postgresus-backend/internal/features/users/repositories"
 	users_services "postgresus-backend/internal/features/users/services"
 	users_testing "postgresus-backend/internal/features/users/testing"
+	"postgresus-backend/internal/util/oidc"
 	test_utils "postgresus-backend/internal/util/testing"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2"
 )
@@ -721,6 +733,559 @@ func Test_UpdateUserInfo_WhenAdminTriesToChangeEmail_ReturnsBadRequest(t *testin
 	assert.Contains(t, string(resp.Body), "admin email cannot be changed")
 }
 
+func Test_RefreshToken_WithValidToken_TokenRotated(t *testing.T) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	var response users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/refresh",
+		"",
+		users_dto.RefreshTokenRequestDTO{RefreshToken: testUser.RefreshToken},
+		http.StatusOK,
+		&response,
+	)
+
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.NotEqual(t, testUser.RefreshToken, response.RefreshToken)
+	assert.Equal(t, testUser.UserID, response.UserID)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+response.Token,
+		http.StatusOK,
+	)
+}
+
+func Test_RefreshToken_WhenRotatedTokenReused_SessionRevoked(t *testing.T) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	var response users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/refresh",
+		"",
+		users_dto.RefreshTokenRequestDTO{RefreshToken: testUser.RefreshToken},
+		http.StatusOK,
+		&response,
+	)
+
+	// move the session out of the grace period of concurrent refreshes
+	sessionRepository := users_repositories.GetUserSessionRepository()
+	sessions, err := sessionRepository.GetActiveSessionsByUserID(testUser.UserID, time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	sessions[0].LastSeenAt = time.Now().UTC().Add(-time.Minute)
+	assert.NoError(t, sessionRepository.Save(sessions[0]))
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/refresh",
+		"",
+		users_dto.RefreshTokenRequestDTO{RefreshToken: testUser.RefreshToken},
+		http.StatusUnauthorized,
+	)
+
+	// the whole session is revoked, including the newest tokens
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/refresh",
+		"",
+		users_dto.RefreshTokenRequestDTO{RefreshToken: response.RefreshToken},
+		http.StatusUnauthorized,
+	)
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+response.Token,
+		http.StatusUnauthorized,
+	)
+}
+
+func Test_SignOut_WithValidSession_AccessTokenRejected(t *testing.T) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signout",
+		"Bearer "+testUser.Token,
+		nil,
+		http.StatusOK,
+	)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+testUser.Token,
+		http.StatusUnauthorized,
+	)
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/refresh",
+		"",
+		users_dto.RefreshTokenRequestDTO{RefreshToken: testUser.RefreshToken},
+		http.StatusUnauthorized,
+	)
+}
+
+func Test_GetMe_WithTokenIssuedBeforeSessions_UserReturned(t *testing.T) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	legacyToken := createTokenWithoutSession(t, testUser.UserID, time.Now().UTC())
+
+	test_utils.MakeGetRequest(t, router, "/api/v1/users/me", "Bearer "+legacyToken, http.StatusOK)
+}
+
+func Test_GetMe_WithTokenWithoutSessionAfterAllSessionsRevoked_ReturnsUnauthorized(
+	t *testing.T,
+) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	legacyToken := createTokenWithoutSession(
+		t,
+		testUser.UserID,
+		time.Now().UTC().Add(-1*time.Minute),
+	)
+
+	test_utils.MakeGetRequest(t, router, "/api/v1/users/me", "Bearer "+legacyToken, http.StatusOK)
+
+	test_utils.MakeDeleteRequest(
+		t,
+		router,
+		"/api/v1/users/me/sessions",
+		"Bearer "+testUser.Token,
+		http.StatusOK,
+	)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+legacyToken,
+		http.StatusUnauthorized,
+	)
+}
+
+// createTokenWithoutSession signs claims of access tokens issued before
+// sessions were introduced
+func createTokenWithoutSession(t *testing.T, userID uuid.UUID, issuedAt time.Time) string {
+	t.Helper()
+
+	user, err := users_repositories.GetUserRepository().GetUserByID(userID)
+	assert.NoError(t, err)
+
+	secretKey, err := secrets.GetSecretKeyService().GetSecretKey()
+	assert.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":                  user.ID.String(),
+		"exp":                  issuedAt.Add(24 * time.Hour).Unix(),
+		"iat":                  issuedAt.Unix(),
+		"role":                 string(user.Role),
+		"passwordCreationTime": user.PasswordCreationTime.Unix(),
+	}).SignedString([]byte(secretKey))
+	assert.NoError(t, err)
+
+	return token
+}
+
+func Test_GetMe_WithTwoFactorToken_ReturnsUnauthorized(t *testing.T) {
+	defer users_testing.ResetSettingsToDefaults()
+
+	router := createUserTestRouter()
+	email := "2fa-token-" + uuid.New().String() + "@example.com"
+	password := "testpassword123"
+
+	signupRequest := users_dto.SignUpRequestDTO{
+		Email:    email,
+		Password: password,
+		Name:     "Test User",
+	}
+	test_utils.MakePostRequest(t, router, "/api/v1/users/signup", "", signupRequest, http.StatusOK)
+
+	users_testing.SetTwoFactorRequirement(users_enums.TwoFactorRequirementAllUsers)
+
+	var challengeResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		users_dto.SignInRequestDTO{Email: email, Password: password},
+		http.StatusOK,
+		&challengeResponse,
+	)
+	assert.NotEmpty(t, challengeResponse.TwoFactorToken)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+challengeResponse.TwoFactorToken,
+		http.StatusUnauthorized,
+	)
+}
+
+func Test_RevokeSession_WithOtherSession_OnlyOtherSessionRevoked(t *testing.T) {
+	router := createUserTestRouter()
+	email := "sessions" + uuid.New().String() + "@example.com"
+	password := "testpassword123"
+
+	signupRequest := users_dto.SignUpRequestDTO{
+		Email:    email,
+		Password: password,
+		Name:     "Test User",
+	}
+	test_utils.MakePostRequest(t, router, "/api/v1/users/signup", "", signupRequest, http.StatusOK)
+
+	signinRequest := users_dto.SignInRequestDTO{Email: email, Password: password}
+
+	var firstSession users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&firstSession,
+	)
+
+	var secondSession users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&secondSession,
+	)
+
+	var sessions []users_dto.UserSessionResponseDTO
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/me/sessions",
+		"Bearer "+firstSession.Token,
+		http.StatusOK,
+		&sessions,
+	)
+	assert.Len(t, sessions, 2)
+
+	var otherSessionID uuid.UUID
+	for _, session := range sessions {
+		if !session.IsCurrent {
+			otherSessionID = session.ID
+		}
+	}
+	assert.NotEqual(t, uuid.Nil, otherSessionID)
+
+	test_utils.MakeDeleteRequest(
+		t,
+		router,
+		"/api/v1/users/me/sessions/"+otherSessionID.String(),
+		"Bearer "+firstSession.Token,
+		http.StatusOK,
+	)
+
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+secondSession.Token,
+		http.StatusUnauthorized,
+	)
+	test_utils.MakeGetRequest(
+		t,
+		router,
+		"/api/v1/users/me",
+		"Bearer "+firstSession.Token,
+		http.StatusOK,
+	)
+}
+
+func Test_SignInUser_WhenTwoFactorEnabled_CodeRequired(t *testing.T) {
+	router := createUserTestRouter()
+	email := "totp" + uuid.New().String() + "@example.com"
+	password := "testpassword123"
+
+	signupRequest := users_dto.SignUpRequestDTO{
+		Email:    email,
+		Password: password,
+		Name:     "Test User",
+	}
+	test_utils.MakePostRequest(t, router, "/api/v1/users/signup", "", signupRequest, http.StatusOK)
+
+	signinRequest := users_dto.SignInRequestDTO{Email: email, Password: password}
+
+	var signinResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&signinResponse,
+	)
+
+	var setupResponse users_dto.TwoFactorSetupResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/me/2fa/setup",
+		"Bearer "+signinResponse.Token,
+		nil,
+		http.StatusOK,
+		&setupResponse,
+	)
+	assert.Contains(t, setupResponse.OTPAuthURI, "otpauth://totp/")
+
+	code, err := totp.GenerateCode(setupResponse.Secret, time.Now().UTC())
+	assert.NoError(t, err)
+
+	var recoveryCodesResponse users_dto.TwoFactorRecoveryCodesResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/me/2fa/enable",
+		"Bearer "+signinResponse.Token,
+		users_dto.TwoFactorCodeRequestDTO{Code: code},
+		http.StatusOK,
+		&recoveryCodesResponse,
+	)
+	assert.Len(t, recoveryCodesResponse.RecoveryCodes, 10)
+
+	var challengeResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&challengeResponse,
+	)
+	assert.True(t, challengeResponse.IsTwoFactorRequired)
+	assert.False(t, challengeResponse.IsTwoFactorSetupRequired)
+	assert.Empty(t, challengeResponse.Token)
+	assert.NotEmpty(t, challengeResponse.TwoFactorToken)
+
+	// code used to enable 2FA can not be used again
+	resp := test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		users_dto.TwoFactorSignInRequestDTO{
+			TwoFactorToken: challengeResponse.TwoFactorToken,
+			Code:           code,
+		},
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(resp.Body), "already used")
+
+	nextCode, err := totp.GenerateCode(setupResponse.Secret, time.Now().UTC().Add(30*time.Second))
+	assert.NoError(t, err)
+
+	var twoFactorResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		users_dto.TwoFactorSignInRequestDTO{
+			TwoFactorToken: challengeResponse.TwoFactorToken,
+			Code:           nextCode,
+		},
+		http.StatusOK,
+		&twoFactorResponse,
+	)
+	assert.NotEmpty(t, twoFactorResponse.Token)
+
+	// recovery code is accepted only once
+	recoveryCodeRequest := users_dto.TwoFactorSignInRequestDTO{
+		TwoFactorToken: challengeResponse.TwoFactorToken,
+		Code:           recoveryCodesResponse.RecoveryCodes[0],
+	}
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		recoveryCodeRequest,
+		http.StatusOK,
+	)
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		recoveryCodeRequest,
+		http.StatusBadRequest,
+	)
+
+	var status users_dto.TwoFactorStatusResponseDTO
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/me/2fa",
+		"Bearer "+twoFactorResponse.Token,
+		http.StatusOK,
+		&status,
+	)
+	assert.True(t, status.IsEnabled)
+	assert.Equal(t, int64(9), status.RecoveryCodesLeft)
+}
+
+func Test_SignInWithTwoFactor_AfterTooManyWrongCodes_SignInLocked(t *testing.T) {
+	router := createUserTestRouter()
+	email := "totp-lock" + uuid.New().String() + "@example.com"
+	password := "testpassword123"
+
+	signupRequest := users_dto.SignUpRequestDTO{
+		Email:    email,
+		Password: password,
+		Name:     "Test User",
+	}
+	test_utils.MakePostRequest(t, router, "/api/v1/users/signup", "", signupRequest, http.StatusOK)
+
+	signinRequest := users_dto.SignInRequestDTO{Email: email, Password: password}
+
+	var signinResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&signinResponse,
+	)
+
+	var setupResponse users_dto.TwoFactorSetupResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/me/2fa/setup",
+		"Bearer "+signinResponse.Token,
+		nil,
+		http.StatusOK,
+		&setupResponse,
+	)
+
+	code, err := totp.GenerateCode(setupResponse.Secret, time.Now().UTC())
+	assert.NoError(t, err)
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/me/2fa/enable",
+		"Bearer "+signinResponse.Token,
+		users_dto.TwoFactorCodeRequestDTO{Code: code},
+		http.StatusOK,
+	)
+
+	var challengeResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&challengeResponse,
+	)
+
+	wrongCodeRequest := users_dto.TwoFactorSignInRequestDTO{
+		TwoFactorToken: challengeResponse.TwoFactorToken,
+		Code:           "wrong-recovery-code",
+	}
+
+	for range 4 {
+		resp := test_utils.MakePostRequest(
+			t,
+			router,
+			"/api/v1/users/signin/2fa",
+			"",
+			wrongCodeRequest,
+			http.StatusBadRequest,
+		)
+		assert.Contains(t, string(resp.Body), "invalid two-factor code")
+	}
+
+	resp := test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		wrongCodeRequest,
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(resp.Body), "too many failed two-factor attempts")
+
+	// valid code is rejected as well, both for the old and for a new token
+	nextCode, err := totp.GenerateCode(setupResponse.Secret, time.Now().UTC().Add(30*time.Second))
+	assert.NoError(t, err)
+
+	var newChallengeResponse users_dto.SignInResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/users/signin",
+		"",
+		signinRequest,
+		http.StatusOK,
+		&newChallengeResponse,
+	)
+
+	for _, twoFactorToken := range []string{
+		challengeResponse.TwoFactorToken,
+		newChallengeResponse.TwoFactorToken,
+	} {
+		resp := test_utils.MakePostRequest(
+			t,
+			router,
+			"/api/v1/users/signin/2fa",
+			"",
+			users_dto.TwoFactorSignInRequestDTO{TwoFactorToken: twoFactorToken, Code: nextCode},
+			http.StatusBadRequest,
+		)
+		assert.Contains(t, string(resp.Body), "too many failed two-factor attempts")
+	}
+}
+
+func Test_SignInWithTwoFactor_WithAccessToken_ReturnsBadRequest(t *testing.T) {
+	router := createUserTestRouter()
+	testUser := users_testing.CreateTestUser(users_enums.UserRoleMember)
+
+	test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/users/signin/2fa",
+		"",
+		users_dto.TwoFactorSignInRequestDTO{TwoFactorToken: testUser.Token, Code: "123456"},
+		http.StatusBadRequest,
+	)
+}
+
 func Test_GitHubOAuth_WithValidCode_ReturnsToken(t *testing.T) {
 	testID := uuid.New().String()[:8]
 	testEmail := "github-user-" + testID + "@example.com"
@@ -1146,3 +1711,325 @@ func Test_GoogleOAuth_WithInvitedUser_ActivatesUser(t *testing.T) {
 	assert.Equal(t, email, response.Email)
 	assert.False(t, response.IsNewUser)
 }
+
+func Test_OIDCOAuth_WithAdminGroup_UserCreatedAsAdmin(t *testing.T) {
+	testID := uuid.New().String()[:8]
+	testEmail := "oidc-user-" + testID + "@example.com"
+
+	mockProvider := createMockOIDCProvider(t, map[string]any{
+		"sub":            "oidc-" + testID,
+		"email":          testEmail,
+		"email_verified": true,
+		"name":           "OIDC Test User",
+		"groups":         []string{"developers", "postgresus-admins"},
+	})
+
+	settings := &users_services.OIDCSettings{
+		IssuerURL:          mockProvider.server.URL,
+		ClientID:           "postgresus",
+		ClientSecret:       "secret",
+		Scopes:             []string{"openid", "email", "profile"},
+		GroupsClaim:        "groups",
+		AdminGroups:        []string{"postgresus-admins"},
+		IsAutoProvisioning: true,
+	}
+
+	userService := users_services.GetUserService()
+
+	authorizeResponse, err := userService.StartOIDCSignInWithMockProvider(
+		"http://localhost:3000/auth/callback",
+		mockProvider.provider,
+		settings,
+	)
+	assert.NoError(t, err)
+
+	authorizationURL, err := url.Parse(authorizeResponse.AuthorizationURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "S256", authorizationURL.Query().Get("code_challenge_method"))
+	mockProvider.authorize(authorizationURL.Query())
+
+	response, err := userService.HandleOIDCOAuthWithMockProvider(
+		&users_dto.OIDCCallbackRequestDTO{
+			Code:      "test-code",
+			State:     authorizeResponse.State,
+			FlowToken: authorizeResponse.FlowToken,
+		},
+		mockProvider.provider,
+		settings,
+	)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, testEmail, response.Email)
+	assert.True(t, response.IsNewUser)
+
+	user, err := users_repositories.GetUserRepository().GetUserByID(response.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, users_enums.UserRoleAdmin, user.Role)
+}
+
+func Test_OIDCOAuth_WithWrongState_ReturnsError(t *testing.T) {
+	mockProvider := createMockOIDCProvider(t, map[string]any{
+		"sub":   "oidc-" + uuid.New().String(),
+		"email": "oidc-state@example.com",
+	})
+
+	settings := &users_services.OIDCSettings{
+		IssuerURL:    mockProvider.server.URL,
+		ClientID:     "postgresus",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+		GroupsClaim:  "groups",
+	}
+
+	userService := users_services.GetUserService()
+
+	authorizeResponse, err := userService.StartOIDCSignInWithMockProvider(
+		"http://localhost:3000/auth/callback",
+		mockProvider.provider,
+		settings,
+	)
+	assert.NoError(t, err)
+
+	_, err = userService.HandleOIDCOAuthWithMockProvider(
+		&users_dto.OIDCCallbackRequestDTO{
+			Code:      "test-code",
+			State:     "forged-state",
+			FlowToken: authorizeResponse.FlowToken,
+		},
+		mockProvider.provider,
+		settings,
+	)
+
+	assert.EqualError(t, err, "invalid OAuth state")
+}
+
+func Test_OIDCOAuth_WithVerifiedEmail_LinksExistingAccount(t *testing.T) {
+	testID := uuid.New().String()[:8]
+	email := "oidc-existing-" + testID + "@example.com"
+	signUpTestUser(t, email)
+
+	mockProvider := createMockOIDCProvider(t, map[string]any{
+		"sub":            "oidc-" + testID,
+		"email":          email,
+		"email_verified": true,
+	})
+
+	response, err := signInWithMockOIDCProvider(t, mockProvider)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.Equal(t, email, response.Email)
+	assert.False(t, response.IsNewUser)
+
+	linkedUser, err := users_repositories.GetUserRepository().GetUserByOIDCSubject("oidc-" + testID)
+	assert.NoError(t, err)
+	assert.NotNil(t, linkedUser)
+}
+
+func Test_OIDCOAuth_WithUnverifiedEmail_ExistingAccountNotLinked(t *testing.T) {
+	testCases := []struct {
+		name   string
+		claims func(email string) map[string]any
+	}{
+		{
+			name: "email without email_verified claim",
+			claims: func(email string) map[string]any {
+				return map[string]any{"email": email}
+			},
+		},
+		{
+			// Azure AD lets users change preferred_username, so it must not
+			// identify an existing account
+			name: "preferred_username instead of email",
+			claims: func(email string) map[string]any {
+				return map[string]any{"preferred_username": email}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testID := uuid.New().String()[:8]
+			email := "oidc-victim-" + testID + "@example.com"
+			signUpTestUser(t, email)
+
+			claims := testCase.claims(email)
+			claims["sub"] = "oidc-attacker-" + testID
+			mockProvider := createMockOIDCProvider(t, claims)
+
+			_, err := signInWithMockOIDCProvider(t, mockProvider)
+			assert.EqualError(
+				t,
+				err,
+				"account with this email already exists, but the provider did not verify the email",
+			)
+
+			linkedUser, err := users_repositories.GetUserRepository().GetUserByOIDCSubject(
+				"oidc-attacker-" + testID,
+			)
+			assert.NoError(t, err)
+			assert.Nil(t, linkedUser)
+		})
+	}
+}
+
+func Test_OIDCOAuth_WhenTwoFactorRequired_AccessTokenNotIssued(t *testing.T) {
+	defer users_testing.ResetSettingsToDefaults()
+	users_testing.SetTwoFactorRequirement(users_enums.TwoFactorRequirementAllUsers)
+
+	testID := uuid.New().String()[:8]
+	mockProvider := createMockOIDCProvider(t, map[string]any{
+		"sub":            "oidc-" + testID,
+		"email":          "oidc-2fa-" + testID + "@example.com",
+		"email_verified": true,
+	})
+
+	response, err := signInWithMockOIDCProvider(t, mockProvider)
+
+	assert.NoError(t, err)
+	assert.Empty(t, response.Token)
+	assert.Empty(t, response.RefreshToken)
+	assert.True(t, response.IsTwoFactorRequired)
+	assert.True(t, response.IsTwoFactorSetupRequired)
+	assert.NotEmpty(t, response.TwoFactorToken)
+}
+
+func signUpTestUser(t *testing.T, email string) {
+	router := createUserTestRouter()
+	signupRequest := users_dto.SignUpRequestDTO{
+		Email:    email,
+		Password: "testpassword123",
+		Name:     "Existing User",
+	}
+	test_utils.MakePostRequest(t, router, "/api/v1/users/signup", "", signupRequest, http.StatusOK)
+}
+
+func signInWithMockOIDCProvider(
+	t *testing.T,
+	mockProvider *mockOIDCProvider,
+) (*users_dto.OAuthCallbackResponseDTO, error) {
+	settings := &users_services.OIDCSettings{
+		IssuerURL:          mockProvider.server.URL,
+		ClientID:           "postgresus",
+		ClientSecret:       "secret",
+		Scopes:             []string{"openid", "email", "profile"},
+		GroupsClaim:        "groups",
+		IsAutoProvisioning: true,
+	}
+
+	userService := users_services.GetUserService()
+
+	authorizeResponse, err := userService.StartOIDCSignInWithMockProvider(
+		"http://localhost:3000/auth/callback",
+		mockProvider.provider,
+		settings,
+	)
+	assert.NoError(t, err)
+
+	authorizationURL, err := url.Parse(authorizeResponse.AuthorizationURL)
+	assert.NoError(t, err)
+	mockProvider.authorize(authorizationURL.Query())
+
+	return userService.HandleOIDCOAuthWithMockProvider(
+		&users_dto.OIDCCallbackRequestDTO{
+			Code:      "test-code",
+			State:     authorizeResponse.State,
+			FlowToken: authorizeResponse.FlowToken,
+		},
+		mockProvider.provider,
+		settings,
+	)
+}
+
+type mockOIDCProvider struct {
+	server   *httptest.Server
+	provider *oidc.Provider
+
+	nonce         string
+	codeChallenge string
+}
+
+// authorize remembers parameters of the authorization request, as the real
+// provider does before redirecting back with the code
+func (p *mockOIDCProvider) authorize(query url.Values) {
+	p.nonce = query.Get("nonce")
+	p.codeChallenge = query.Get("code_challenge")
+}
+
+func createMockOIDCProvider(t *testing.T, claims map[string]any) *mockOIDCProvider {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	mockProvider := &mockOIDCProvider{}
+
+	mux := http.NewServeMux()
+	mockProvider.server = httptest.NewServer(mux)
+	t.Cleanup(mockProvider.server.Close)
+
+	issuer := mockProvider.server.URL
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": issuer + "/authorize",
+			"token_endpoint":         issuer + "/token",
+			"jwks_uri":               issuer + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "key-1",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+					"e": base64.RawURLEncoding.EncodeToString(
+						big.NewInt(int64(privateKey.E)).Bytes(),
+					),
+				},
+			},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		verifierHash := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(verifierHash[:]) != mockProvider.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		idTokenClaims := jwt.MapClaims{
+			"iss":   issuer,
+			"aud":   "postgresus",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": mockProvider.nonce,
+		}
+		for key, value := range claims {
+			idTokenClaims[key] = value
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, idTokenClaims)
+		idToken.Header["kid"] = "key-1"
+		signedIDToken, err := idToken.SignedString(privateKey)
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "mock-access-token",
+			"token_type":   "bearer",
+			"id_token":     signedIDToken,
+		})
+	})
+
+	mockProvider.provider = oidc.NewProvider(issuer, "postgresus")
+
+	return mockProvider
+}
@@ -19,10 +19,72 @@ type SignInRequestDTO struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// SignInResponseDTO contains short-lived access token and refresh token of
+// the session, the refresh token is exchanged for a new pair before expiration.
+// When 2FA is required tokens are empty and the sign in is continued with
+// TwoFactorToken
 type SignInResponseDTO struct {
-	UserID uuid.UUID `json:"userId"`
-	Email  string    `json:"email"`
-	Token  string    `json:"token"`
+	UserID       uuid.UUID `json:"userId"`
+	Email        string    `json:"email"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+
+	IsTwoFactorRequired      bool   `json:"isTwoFactorRequired"`
+	IsTwoFactorSetupRequired bool   `json:"isTwoFactorSetupRequired"`
+	TwoFactorToken           string `json:"twoFactorToken,omitempty"`
+	// returned once when 2FA is set up during sign in
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// TwoFactorSignInRequestDTO accepts either TOTP code or one of recovery codes
+type TwoFactorSignInRequestDTO struct {
+	TwoFactorToken string `json:"twoFactorToken" binding:"required"`
+	Code           string `json:"code"           binding:"required"`
+}
+
+type TwoFactorSetupOnSignInRequestDTO struct {
+	TwoFactorToken string `json:"twoFactorToken" binding:"required"`
+}
+
+type TwoFactorSetupResponseDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpAuthUri"`
+}
+
+type TwoFactorCodeRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorRecoveryCodesResponseDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorStatusResponseDTO struct {
+	IsEnabled         bool  `json:"isEnabled"`
+	IsRequired        bool  `json:"isRequired"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// ClientInfoDTO describes the device signing in, it is shown in the list
+// of sessions
+type ClientInfoDTO struct {
+	UserAgent string
+	IPAddress string
+}
+
+type UserSessionResponseDTO struct {
+	ID         uuid.UUID `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	IsCurrent  bool      `json:"isCurrent"`
 }
 
 type SetAdminPasswordRequestDTO struct {
@@ -63,6 +125,8 @@ type UserProfileResponseDTO struct {
 	Role      users_enums.UserRole `json:"role"`
 	IsActive  bool                 `json:"isActive"`
 	CreatedAt time.Time            `json:"createdAt"`
+
+	IsTwoFactorEnabled bool `json:"isTwoFactorEnabled"`
 }
 
 type ListUsersResponseDTO struct {
@@ -86,9 +150,39 @@ type OAuthCallbackRequestDTO struct {
 	RedirectUri string `json:"redirectUri" binding:"required"`
 }
 
+type OIDCConfigResponseDTO struct {
+	IsEnabled    bool   `json:"isEnabled"`
+	ProviderName string `json:"providerName"`
+}
+
+type OIDCAuthorizeRequestDTO struct {
+	RedirectUri string `json:"redirectUri" binding:"required"`
+}
+
+// OIDCAuthorizeResponseDTO contains URL to redirect the user to. Frontend
+// keeps state and flow token until the callback
+type OIDCAuthorizeResponseDTO struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+	State            string `json:"state"`
+	FlowToken        string `json:"flowToken"`
+}
+
+type OIDCCallbackRequestDTO struct {
+	Code      string `json:"code"      binding:"required"`
+	State     string `json:"state"     binding:"required"`
+	FlowToken string `json:"flowToken" binding:"required"`
+}
+
 type OAuthCallbackResponseDTO struct {
-	UserID    uuid.UUID `json:"userId"`
-	Email     string    `json:"email"`
-	Token     string    `json:"token"`
-	IsNewUser bool      `json:"isNewUser"`
+	UserID       uuid.UUID `json:"userId"`
+	Email        string    `json:"email"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	IsNewUser    bool      `json:"isNewUser"`
+
+	// same 2FA challenge as in SignInResponseDTO, finished via 2FA sign in
+	IsTwoFactorRequired      bool   `json:"isTwoFactorRequired"`
+	IsTwoFactorSetupRequired bool   `json:"isTwoFactorSetupRequired"`
+	TwoFactorToken           string `json:"twoFactorToken,omitempty"`
 }
@@ -0,0 +1,21 @@
+package users_enums
+
+type TwoFactorRequirement string
+
+const (
+	TwoFactorRequirementNone     TwoFactorRequirement = "NONE"
+	TwoFactorRequirementAllUsers TwoFactorRequirement = "ALL_USERS"
+	// owners and admins of any workspace and system admins
+	TwoFactorRequirementWorkspaceManagers TwoFactorRequirement = "WORKSPACE_MANAGERS"
+)
+
+func (r TwoFactorRequirement) IsValid() bool {
+	switch r {
+	case TwoFactorRequirementNone,
+		TwoFactorRequirementAllUsers,
+		TwoFactorRequirementWorkspaceManagers:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package users_enums
+
+import "slices"
+
+type WorkspacePermission string
+
+// Permissions of databases and other resources of the workspace, they can
+// be combined into custom roles
+const (
+	WorkspacePermissionView                 WorkspacePermission = "VIEW"
+	WorkspacePermissionBackup               WorkspacePermission = "BACKUP"
+	WorkspacePermissionDownload             WorkspacePermission = "DOWNLOAD"
+	WorkspacePermissionRestore              WorkspacePermission = "RESTORE"
+	WorkspacePermissionRestoreToOtherTarget WorkspacePermission = "RESTORE_TO_OTHER_TARGET"
+	WorkspacePermissionManageDatabases      WorkspacePermission = "MANAGE_DATABASES"
+	WorkspacePermissionManageStorages       WorkspacePermission = "MANAGE_STORAGES"
+	WorkspacePermissionManageNotifiers      WorkspacePermission = "MANAGE_NOTIFIERS"
+)
+
+// Permissions of the workspace itself, they are given only by built-in roles
+const (
+	WorkspacePermissionAccess          WorkspacePermission = "ACCESS"
+	WorkspacePermissionManageWorkspace WorkspacePermission = "MANAGE_WORKSPACE"
+	WorkspacePermissionManageMembers   WorkspacePermission = "MANAGE_MEMBERS"
+	WorkspacePermissionManageAdmins    WorkspacePermission = "MANAGE_ADMINS"
+)
+
+var resourcePermissions = []WorkspacePermission{
+	WorkspacePermissionView,
+	WorkspacePermissionBackup,
+	WorkspacePermissionDownload,
+	WorkspacePermissionRestore,
+	WorkspacePermissionRestoreToOtherTarget,
+	WorkspacePermissionManageDatabases,
+	WorkspacePermissionManageStorages,
+	WorkspacePermissionManageNotifiers,
+}
+
+// IsAssignable returns true for permissions which custom roles can contain
+func (p WorkspacePermission) IsAssignable() bool {
+	return slices.Contains(resourcePermissions, p)
+}
+
+func GetAssignablePermissions() []WorkspacePermission {
+	return append([]WorkspacePermission{}, resourcePermissions...)
+}
+
+// GetWorkspaceRolePermissions returns permissions of built-in role. Viewer
+// keeps backup, download and restore, as it was allowed before custom roles
+func GetWorkspaceRolePermissions(role WorkspaceRole) []WorkspacePermission {
+	switch role {
+	case WorkspaceRoleOwner:
+		return append(
+			GetAssignablePermissions(),
+			WorkspacePermissionAccess,
+			WorkspacePermissionManageWorkspace,
+			WorkspacePermissionManageMembers,
+			WorkspacePermissionManageAdmins,
+		)
+	case WorkspaceRoleAdmin:
+		return append(
+			GetAssignablePermissions(),
+			WorkspacePermissionAccess,
+			WorkspacePermissionManageWorkspace,
+			WorkspacePermissionManageMembers,
+		)
+	case WorkspaceRoleMember:
+		return append(GetAssignablePermissions(), WorkspacePermissionAccess)
+	case WorkspaceRoleViewer:
+		return []WorkspacePermission{
+			WorkspacePermissionAccess,
+			WorkspacePermissionView,
+			WorkspacePermissionBackup,
+			WorkspacePermissionDownload,
+			WorkspacePermissionRestore,
+			WorkspacePermissionRestoreToOtherTarget,
+		}
+	default:
+		return []WorkspacePermission{}
+	}
+}
@@ -1,9 +1,32 @@
 package users_interfaces
 
 import (
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+
 	"github.com/google/uuid"
 )
 
 type AuditLogWriter interface {
-	WriteAuditLog(message string, userID *uuid.UUID, workspaceID *uuid.UUID)
+	WriteAuditEvent(event *audit_logs_events.AuditEvent)
+}
+
+// WorkspaceMembershipSyncer applies workspace roles given by identity
+// provider, nil role removes the membership
+type WorkspaceMembershipSyncer interface {
+	SyncUserWorkspaceRoles(
+		userID uuid.UUID,
+		workspaceRoles map[uuid.UUID]*users_enums.WorkspaceRole,
+	) error
+}
+
+type APIKeyAuthenticator interface {
+	IsAPIKey(token string) bool
+	AuthenticateAPIKey(
+		token string,
+		method string,
+		route string,
+	) (user *users_models.User, isRouteAllowed bool, err error)
+	WriteAPIKeyRequestAuditLog(user *users_models.User, method string, path string, statusCode int)
 }
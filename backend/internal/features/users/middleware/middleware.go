@@ -3,6 +3,7 @@ package users_middleware
 import (
 	"net/http"
 	users_enums "postgresus-backend/internal/features/users/enums"
+	users_interfaces "postgresus-backend/internal/features/users/interfaces"
 	users_models "postgresus-backend/internal/features/users/models"
 	users_services "postgresus-backend/internal/features/users/services"
 
@@ -24,6 +25,12 @@ func AuthMiddleware(userService *users_services.UserService) gin.HandlerFunc {
 			token = token[7:]
 		}
 
+		apiKeyAuthenticator := userService.GetAPIKeyAuthenticator()
+		if apiKeyAuthenticator != nil && apiKeyAuthenticator.IsAPIKey(token) {
+			authenticateAPIKey(ctx, apiKeyAuthenticator, token)
+			return
+		}
+
 		user, err := userService.GetUserFromToken(token)
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -31,11 +38,55 @@ func AuthMiddleware(userService *users_services.UserService) gin.HandlerFunc {
 			return
 		}
 
+		setRequestClient(ctx, user)
+
 		ctx.Set("user", user)
 		ctx.Next()
 	}
 }
 
+// authenticateAPIKey allows the request only when scopes of the key grant
+// the route. Every request made by the key is written to audit logs
+func authenticateAPIKey(
+	ctx *gin.Context,
+	apiKeyAuthenticator users_interfaces.APIKeyAuthenticator,
+	token string,
+) {
+	method := ctx.Request.Method
+	path := ctx.Request.URL.Path
+
+	user, isRouteAllowed, err := apiKeyAuthenticator.AuthenticateAPIKey(
+		token,
+		method,
+		ctx.FullPath(),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		ctx.Abort()
+		return
+	}
+
+	setRequestClient(ctx, user)
+
+	if !isRouteAllowed {
+		apiKeyAuthenticator.WriteAPIKeyRequestAuditLog(user, method, path, http.StatusForbidden)
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "API key scopes do not allow this request"})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set("user", user)
+	ctx.Next()
+
+	apiKeyAuthenticator.WriteAPIKeyRequestAuditLog(user, method, path, ctx.Writer.Status())
+}
+
+func setRequestClient(ctx *gin.Context, user *users_models.User) {
+	user.RequestIPAddress = ctx.ClientIP()
+	user.RequestUserAgent = ctx.Request.UserAgent()
+}
+
 func RequireRole(requiredRole users_enums.UserRole) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userInterface, exists := ctx.Get("user")
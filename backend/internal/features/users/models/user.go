@@ -17,7 +17,35 @@ type User struct {
 	Status               users_enums.UserStatus `json:"status"`
 	GitHubOAuthID        *string                `json:"-"         gorm:"column:github_oauth_id"`
 	GoogleOAuthID        *string                `json:"-"         gorm:"column:google_oauth_id"`
+	OIDCSubject          *string                `json:"-"         gorm:"column:oidc_subject"`
+	LDAPDN               *string                `json:"-"         gorm:"column:ldap_dn"`
 	CreatedAt            time.Time              `json:"createdAt"`
+
+	// access tokens issued before this time are rejected even without
+	// session, it is moved forward when all sessions of the user are revoked
+	TokensValidAfter *time.Time `json:"-" gorm:"column:tokens_valid_after"`
+
+	// TOTP secret is encrypted and set on 2FA setup, 2FA is enabled only
+	// after the first code from authenticator app is confirmed
+	TOTPSecret         *string `json:"-" gorm:"column:totp_secret"`
+	IsTwoFactorEnabled bool    `json:"-" gorm:"column:is_two_factor_enabled"`
+	// time step of the last accepted TOTP code, codes can not be reused
+	TOTPLastUsedStep int64 `json:"-" gorm:"column:totp_last_used_step"`
+	// wrong codes entered on sign in, too many of them lock 2FA sign in
+	TwoFactorFailedAttempts int        `json:"-" gorm:"column:two_factor_failed_attempts"`
+	TwoFactorLockedUntil    *time.Time `json:"-" gorm:"column:two_factor_locked_until"`
+
+	// session of the access token (not saved to DB), empty for tokens issued
+	// before sessions were introduced
+	SessionID *uuid.UUID `json:"-" gorm:"-"`
+
+	// set when the request is authenticated by workspace API key (not saved to DB)
+	APIKeyID          *uuid.UUID `json:"-" gorm:"-"`
+	APIKeyWorkspaceID *uuid.UUID `json:"-" gorm:"-"`
+
+	// client of the current request, written to audit logs (not saved to DB)
+	RequestIPAddress string `json:"-" gorm:"-"`
+	RequestUserAgent string `json:"-" gorm:"-"`
 }
 
 func (User) TableName() string {
@@ -48,6 +76,12 @@ func (u *User) CanCreateWorkspaces(settings *UsersSettings) bool {
 	return u.Role == users_enums.UserRoleMember && settings.IsMemberAllowedToCreateWorkspaces
 }
 
+// IsRestrictedByAPIKey returns true when the user acts by API key issued
+// for another workspace
+func (u *User) IsRestrictedByAPIKey(workspaceID uuid.UUID) bool {
+	return u.APIKeyWorkspaceID != nil && *u.APIKeyWorkspaceID != workspaceID
+}
+
 func (u *User) IsActiveUser() bool {
 	return u.Status == users_enums.UserStatusActive
 }
@@ -0,0 +1,21 @@
+package users_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRecoveryCode is one-time code to pass the second step of sign in
+// when the authenticator app is lost
+type UserRecoveryCode struct {
+	ID         uuid.UUID  `gorm:"column:id;primaryKey;type:uuid"`
+	UserID     uuid.UUID  `gorm:"column:user_id;type:uuid;not null"`
+	HashedCode string     `gorm:"column:hashed_code;type:text;not null"`
+	UsedAt     *time.Time `gorm:"column:used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,35 @@
+package users_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSession is created on sign in. Access tokens are short-lived and are
+// issued for the session, so revoking the session signs the device out
+type UserSession struct {
+	ID     uuid.UUID `json:"id"     gorm:"column:id;primaryKey;type:uuid"`
+	UserID uuid.UUID `json:"userId" gorm:"column:user_id;type:uuid;not null"`
+
+	// refresh token is rotated on each use, the previous one is kept to
+	// detect reuse of a stolen token
+	HashedRefreshToken         string  `json:"-" gorm:"column:hashed_refresh_token;type:text;not null"`
+	PreviousHashedRefreshToken *string `json:"-" gorm:"column:previous_hashed_refresh_token;type:text"`
+
+	Device    string `json:"device"    gorm:"column:device;type:text;not null"`
+	IPAddress string `json:"ipAddress" gorm:"column:ip_address;type:text;not null"`
+
+	CreatedAt  time.Time  `json:"createdAt"  gorm:"column:created_at;not null"`
+	LastSeenAt time.Time  `json:"lastSeenAt" gorm:"column:last_seen_at;not null"`
+	ExpiresAt  time.Time  `json:"expiresAt"  gorm:"column:expires_at;not null"`
+	RevokedAt  *time.Time `json:"revokedAt"  gorm:"column:revoked_at"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(now)
+}
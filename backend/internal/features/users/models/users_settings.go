@@ -1,6 +1,10 @@
 package users_models
 
-import "github.com/google/uuid"
+import (
+	users_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/google/uuid"
+)
 
 type UsersSettings struct {
 	ID uuid.UUID `json:"id"                                gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -10,6 +14,9 @@ type UsersSettings struct {
 	IsAllowMemberInvitations bool `json:"isAllowMemberInvitations"          gorm:"column:is_allow_member_invitations"`
 	// means that any user with role MEMBER can create their own workspaces
 	IsMemberAllowedToCreateWorkspaces bool `json:"isMemberAllowedToCreateWorkspaces" gorm:"column:is_member_allowed_to_create_workspaces"`
+	// which users must pass 2FA on sign in with password, LDAP, GitHub, Google
+	// or OpenID, users without 2FA set it up on the next sign in
+	TwoFactorRequirement users_enums.TwoFactorRequirement `json:"twoFactorRequirement" gorm:"column:two_factor_requirement"`
 }
 
 func (UsersSettings) TableName() string {
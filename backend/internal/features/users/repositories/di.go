@@ -2,6 +2,8 @@ package users_repositories
 
 var userRepository = &UserRepository{}
 var usersSettingsRepository = &UsersSettingsRepository{}
+var userSessionRepository = &UserSessionRepository{}
+var userRecoveryCodeRepository = &UserRecoveryCodeRepository{}
 
 func GetUserRepository() *UserRepository {
 	return userRepository
@@ -10,3 +12,11 @@ func GetUserRepository() *UserRepository {
 func GetUsersSettingsRepository() *UsersSettingsRepository {
 	return usersSettingsRepository
 }
+
+func GetUserSessionRepository() *UserSessionRepository {
+	return userSessionRepository
+}
+
+func GetUserRecoveryCodeRepository() *UserRecoveryCodeRepository {
+	return userRecoveryCodeRepository
+}
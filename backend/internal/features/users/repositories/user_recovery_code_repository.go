@@ -0,0 +1,65 @@
+package users_repositories
+
+import (
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserRecoveryCodeRepository struct{}
+
+// ReplaceUserRecoveryCodes removes previous codes of the user, so only the
+// latest generated set is valid
+func (r *UserRecoveryCodeRepository) ReplaceUserRecoveryCodes(
+	userID uuid.UUID,
+	codes []*users_models.UserRecoveryCode,
+) error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ?", userID).
+			Delete(&users_models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(codes).Error
+	})
+}
+
+// UseRecoveryCode marks the code as used and returns false if there is no
+// such unused code
+func (r *UserRecoveryCodeRepository) UseRecoveryCode(
+	userID uuid.UUID,
+	hashedCode string,
+	usedAt time.Time,
+) (bool, error) {
+	result := storage.GetDb().Model(&users_models.UserRecoveryCode{}).
+		Where("user_id = ? AND hashed_code = ? AND used_at IS NULL", userID, hashedCode).
+		Update("used_at", usedAt)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *UserRecoveryCodeRepository) CountUnusedRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+
+	if err := storage.GetDb().Model(&users_models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *UserRecoveryCodeRepository) DeleteUserRecoveryCodes(userID uuid.UUID) error {
+	return storage.GetDb().
+		Where("user_id = ?", userID).
+		Delete(&users_models.UserRecoveryCode{}).Error
+}
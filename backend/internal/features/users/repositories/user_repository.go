@@ -132,6 +132,12 @@ func (r *UserRepository) UpdateUserStatus(userID uuid.UUID, status users_enums.U
 		}).Error
 }
 
+func (r *UserRepository) UpdateTokensValidAfter(userID uuid.UUID, validAfter time.Time) error {
+	return storage.GetDb().Model(&users_models.User{}).
+		Where("id = ?", userID).
+		Update("tokens_valid_after", validAfter).Error
+}
+
 func (r *UserRepository) UpdateUserRole(userID uuid.UUID, role users_enums.UserRole) error {
 	return storage.GetDb().Model(&users_models.User{}).
 		Where("id = ?", userID).
@@ -199,9 +205,115 @@ func (r *UserRepository) GetUserByGoogleOAuthID(googleID string) (*users_models.
 	return &user, nil
 }
 
+func (r *UserRepository) GetUserByOIDCSubject(subject string) (*users_models.User, error) {
+	var user users_models.User
+	err := storage.GetDb().Where("oidc_subject = ?", subject).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) GetUserByLDAPDN(dn string) (*users_models.User, error) {
+	var user users_models.User
+	err := storage.GetDb().Where("ldap_dn = ?", dn).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetActiveLDAPUsers returns users signed in via LDAP at least once, they
+// are checked against the directory by the sync
+func (r *UserRepository) GetActiveLDAPUsers() ([]*users_models.User, error) {
+	var users []*users_models.User
+
+	if err := storage.GetDb().
+		Where("ldap_dn IS NOT NULL AND status = ?", users_enums.UserStatusActive).
+		Order("created_at ASC").
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
 func (r *UserRepository) LinkOAuthID(userID uuid.UUID, oauthColumn, oauthID string) error {
 	updates := map[string]any{oauthColumn: oauthID}
 	return storage.GetDb().Model(&users_models.User{}).
 		Where("id = ?", userID).
 		Updates(updates).Error
 }
+
+func (r *UserRepository) UpdateUserTwoFactor(
+	userID uuid.UUID,
+	totpSecret *string,
+	isTwoFactorEnabled bool,
+) error {
+	return storage.GetDb().Model(&users_models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{
+			"totp_secret":           totpSecret,
+			"is_two_factor_enabled": isTwoFactorEnabled,
+		}).Error
+}
+
+// UpdateTOTPLastUsedStep returns false when the step or a later one is
+// already used, so the same code is accepted only once
+func (r *UserRepository) UpdateTOTPLastUsedStep(userID uuid.UUID, step int64) (bool, error) {
+	result := storage.GetDb().Model(&users_models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", userID, step).
+		Update("totp_last_used_step", step)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// IncrementTwoFactorFailedAttempts returns the number of failed attempts
+// including the current one
+func (r *UserRepository) IncrementTwoFactorFailedAttempts(userID uuid.UUID) (int, error) {
+	var attempts int
+
+	if err := storage.GetDb().Raw(
+		`UPDATE users SET two_factor_failed_attempts = two_factor_failed_attempts + 1
+		WHERE id = ? RETURNING two_factor_failed_attempts`,
+		userID,
+	).Scan(&attempts).Error; err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+func (r *UserRepository) UpdateTwoFactorLock(userID uuid.UUID, lockedUntil *time.Time) error {
+	return storage.GetDb().Model(&users_models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{
+			"two_factor_failed_attempts": 0,
+			"two_factor_locked_until":    lockedUntil,
+		}).Error
+}
+
+func (r *UserRepository) HasWorkspaceRole(
+	userID uuid.UUID,
+	roles []users_enums.WorkspaceRole,
+) (bool, error) {
+	var count int64
+
+	if err := storage.GetDb().Table("workspace_memberships").
+		Where("user_id = ? AND role IN ?", userID, roles).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,117 @@
+package users_repositories
+
+import (
+	users_models "postgresus-backend/internal/features/users/models"
+	"postgresus-backend/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserSessionRepository struct{}
+
+func (r *UserSessionRepository) Save(session *users_models.UserSession) error {
+	return storage.GetDb().Save(session).Error
+}
+
+func (r *UserSessionRepository) GetSessionByID(
+	sessionID uuid.UUID,
+) (*users_models.UserSession, error) {
+	var session users_models.UserSession
+
+	if err := storage.GetDb().Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *UserSessionRepository) GetSessionByRefreshToken(
+	hashedRefreshToken string,
+) (*users_models.UserSession, error) {
+	var session users_models.UserSession
+
+	if err := storage.GetDb().
+		Where("hashed_refresh_token = ?", hashedRefreshToken).
+		First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *UserSessionRepository) GetSessionByPreviousRefreshToken(
+	hashedRefreshToken string,
+) (*users_models.UserSession, error) {
+	var session users_models.UserSession
+
+	if err := storage.GetDb().
+		Where("previous_hashed_refresh_token = ?", hashedRefreshToken).
+		First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *UserSessionRepository) GetActiveSessionsByUserID(
+	userID uuid.UUID,
+	now time.Time,
+) ([]*users_models.UserSession, error) {
+	var sessions []*users_models.UserSession
+
+	if err := storage.GetDb().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *UserSessionRepository) RevokeSession(sessionID uuid.UUID, revokedAt time.Time) error {
+	return storage.GetDb().
+		Model(&users_models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeUserSessions also rejects access tokens issued before revocation,
+// so tokens without session stop working as well
+func (r *UserSessionRepository) RevokeUserSessions(userID uuid.UUID, revokedAt time.Time) error {
+	return storage.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&users_models.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", revokedAt).Error; err != nil {
+			return err
+		}
+
+		return tx.
+			Model(&users_models.User{}).
+			Where("id = ?", userID).
+			Update("tokens_valid_after", revokedAt).Error
+	})
+}
+
+// DeleteInactiveSessions removes sessions of the user which were revoked
+// or expired before the given time
+func (r *UserSessionRepository) DeleteInactiveSessions(userID uuid.UUID, before time.Time) error {
+	return storage.GetDb().
+		Where("user_id = ? AND (revoked_at < ? OR expires_at < ?)", userID, before, before).
+		Delete(&users_models.UserSession{}).Error
+}
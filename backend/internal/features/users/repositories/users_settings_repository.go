@@ -1,6 +1,7 @@
 package users_repositories
 
 import (
+	user_enums "postgresus-backend/internal/features/users/enums"
 	user_models "postgresus-backend/internal/features/users/models"
 	"postgresus-backend/internal/storage"
 
@@ -21,6 +22,7 @@ func (r *UsersSettingsRepository) GetSettings() (*user_models.UsersSettings, err
 				IsAllowExternalRegistrations:      true,
 				IsAllowMemberInvitations:          true,
 				IsMemberAllowedToCreateWorkspaces: true,
+				TwoFactorRequirement:              user_enums.TwoFactorRequirementNone,
 			}
 
 			if createErr := storage.GetDb().Create(defaultSettings).Error; createErr != nil {
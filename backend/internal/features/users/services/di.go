@@ -3,13 +3,20 @@ package users_services
 import (
 	"postgresus-backend/internal/features/encryption/secrets"
 	users_repositories "postgresus-backend/internal/features/users/repositories"
+	"postgresus-backend/internal/util/encryption"
+	"postgresus-backend/internal/util/logger"
 )
 
 var userService = &UserService{
 	users_repositories.GetUserRepository(),
+	users_repositories.GetUserSessionRepository(),
+	users_repositories.GetUserRecoveryCodeRepository(),
 	secrets.GetSecretKeyService(),
+	encryption.GetFieldEncryptor(),
 	settingsService,
 	nil,
+	nil,
+	nil,
 }
 var settingsService = &SettingsService{
 	users_repositories.GetUsersSettingsRepository(),
@@ -17,8 +24,14 @@ var settingsService = &SettingsService{
 }
 var managementService = &UserManagementService{
 	users_repositories.GetUserRepository(),
+	users_repositories.GetUserSessionRepository(),
+	users_repositories.GetUserRecoveryCodeRepository(),
 	nil,
 }
+var ldapSyncBackgroundService = &LDAPSyncBackgroundService{
+	userService,
+	logger.GetLogger(),
+}
 
 func GetUserService() *UserService {
 	return userService
@@ -31,3 +44,7 @@ func GetSettingsService() *SettingsService {
 func GetManagementService() *UserManagementService {
 	return managementService
 }
+
+func GetLDAPSyncBackgroundService() *LDAPSyncBackgroundService {
+	return ldapSyncBackgroundService
+}
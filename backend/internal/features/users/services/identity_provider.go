@@ -0,0 +1,150 @@
+package users_services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+)
+
+// WorkspaceGroupMapping gives members of the identity provider group the
+// role in the workspace
+type WorkspaceGroupMapping struct {
+	Group       string
+	WorkspaceID uuid.UUID
+	Role        users_enums.WorkspaceRole
+}
+
+// ParseWorkspaceGroupMappings parses "group=workspaceID:ROLE" entries
+// separated by semicolon
+func ParseWorkspaceGroupMappings(value string) ([]WorkspaceGroupMapping, error) {
+	mappings := []WorkspaceGroupMapping{}
+
+	for _, entry := range splitConfigList(value, ";") {
+		// group DNs contain "=", so the last one separates the target
+		separatorIndex := strings.LastIndex(entry, "=")
+		if separatorIndex < 0 {
+			return nil, fmt.Errorf("invalid workspace group mapping: %s", entry)
+		}
+		group, target := entry[:separatorIndex], entry[separatorIndex+1:]
+
+		workspaceIDStr, roleStr, ok := strings.Cut(target, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid workspace group mapping: %s", entry)
+		}
+
+		workspaceID, err := uuid.Parse(strings.TrimSpace(workspaceIDStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace ID in group mapping: %s", entry)
+		}
+
+		role := users_enums.WorkspaceRole(strings.TrimSpace(roleStr))
+		if !role.IsValid() || role == users_enums.WorkspaceRoleOwner {
+			return nil, fmt.Errorf("invalid workspace role in group mapping: %s", entry)
+		}
+
+		mappings = append(mappings, WorkspaceGroupMapping{
+			Group:       strings.TrimSpace(group),
+			WorkspaceID: workspaceID,
+			Role:        role,
+		})
+	}
+
+	return mappings, nil
+}
+
+// syncIdentityProviderRoles applies groups of the identity provider, so
+// changes in the provider are reflected without manual work
+func (s *UserService) syncIdentityProviderRoles(
+	userID uuid.UUID,
+	groups []string,
+	adminGroups []string,
+	workspaceGroups []WorkspaceGroupMapping,
+	providerName string,
+) error {
+	user, err := s.userRepository.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if len(adminGroups) > 0 && user.Email != "admin" {
+		role := users_enums.UserRoleMember
+		if slices.ContainsFunc(groups, func(group string) bool {
+			return slices.Contains(adminGroups, group)
+		}) {
+			role = users_enums.UserRoleAdmin
+		}
+
+		if role != user.Role {
+			if err := s.userRepository.UpdateUserRole(user.ID, role); err != nil {
+				return fmt.Errorf("failed to update user role: %w", err)
+			}
+
+			s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionChangeRole,
+				ResourceType: audit_logs_events.AuditResourceUser,
+				ResourceID:   &user.ID,
+				Message: fmt.Sprintf(
+					"User role changed by %s groups: %s from %s to %s",
+					providerName,
+					user.Email,
+					user.Role,
+					role,
+				),
+				UserID: &user.ID,
+			})
+		}
+	}
+
+	if len(workspaceGroups) == 0 || s.workspaceMembershipSyncer == nil {
+		return nil
+	}
+
+	workspaceRoles := make(map[uuid.UUID]*users_enums.WorkspaceRole)
+	for _, workspaceGroup := range workspaceGroups {
+		if _, ok := workspaceRoles[workspaceGroup.WorkspaceID]; !ok {
+			workspaceRoles[workspaceGroup.WorkspaceID] = nil
+		}
+
+		if !slices.Contains(groups, workspaceGroup.Group) {
+			continue
+		}
+
+		currentRole := workspaceRoles[workspaceGroup.WorkspaceID]
+		if currentRole == nil ||
+			getWorkspaceRolePriority(workspaceGroup.Role) > getWorkspaceRolePriority(*currentRole) {
+			role := workspaceGroup.Role
+			workspaceRoles[workspaceGroup.WorkspaceID] = &role
+		}
+	}
+
+	return s.workspaceMembershipSyncer.SyncUserWorkspaceRoles(user.ID, workspaceRoles)
+}
+
+func getWorkspaceRolePriority(role users_enums.WorkspaceRole) int {
+	switch role {
+	case users_enums.WorkspaceRoleOwner:
+		return 4
+	case users_enums.WorkspaceRoleAdmin:
+		return 3
+	case users_enums.WorkspaceRoleMember:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func splitConfigList(value string, separator string) []string {
+	items := []string{}
+	for item := range strings.SplitSeq(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,345 @@
+package users_services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"postgresus-backend/internal/config"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	ldap_utils "postgresus-backend/internal/util/ldap"
+)
+
+// LDAPSettings is parsed from LDAP_* env variables
+type LDAPSettings struct {
+	Connection            *ldap_utils.Config
+	AdminGroups           []string
+	WorkspaceGroups       []WorkspaceGroupMapping
+	IsAutoProvisioning    bool
+	IsLinkExistingByEmail bool
+}
+
+// GetLDAPSettings returns error when LDAP is not configured or the groups
+// mapping is invalid
+func GetLDAPSettings() (*LDAPSettings, error) {
+	if !isLDAPConfigured() {
+		return nil, errors.New("LDAP is not configured")
+	}
+
+	env := config.GetEnv()
+
+	settings := &LDAPSettings{
+		Connection: &ldap_utils.Config{
+			URL:             env.LDAPURL,
+			BindDN:          env.LDAPBindDN,
+			BindPassword:    env.LDAPBindPassword,
+			SearchBase:      env.LDAPSearchBase,
+			UserFilter:      env.LDAPUserFilter,
+			EmailAttribute:  env.LDAPEmailAttribute,
+			NameAttribute:   env.LDAPNameAttribute,
+			GroupAttribute:  env.LDAPGroupAttribute,
+			IsStartTLS:      env.LDAPIsStartTLS,
+			IsSkipTLSVerify: env.LDAPSkipTLSVerify,
+		},
+		AdminGroups:           splitConfigList(env.LDAPAdminGroups, ","),
+		IsAutoProvisioning:    env.LDAPIsAutoProvisioning,
+		IsLinkExistingByEmail: env.LDAPIsLinkExistingByEmail,
+	}
+
+	if settings.Connection.UserFilter == "" {
+		settings.Connection.UserFilter = ldap_utils.DefaultUserFilter
+	}
+
+	if settings.Connection.EmailAttribute == "" {
+		settings.Connection.EmailAttribute = ldap_utils.DefaultEmailAttribute
+	}
+
+	if settings.Connection.NameAttribute == "" {
+		settings.Connection.NameAttribute = ldap_utils.DefaultNameAttribute
+	}
+
+	if settings.Connection.GroupAttribute == "" {
+		settings.Connection.GroupAttribute = ldap_utils.DefaultGroupAttribute
+	}
+
+	workspaceGroups, err := ParseWorkspaceGroupMappings(env.LDAPWorkspaceGroups)
+	if err != nil {
+		return nil, err
+	}
+	settings.WorkspaceGroups = workspaceGroups
+
+	return settings, nil
+}
+
+// SyncLDAPUsers checks users signed in via LDAP against the directory:
+// removed and disabled users are deactivated, groups of others are applied
+// to roles and workspace memberships
+func (s *UserService) SyncLDAPUsers() error {
+	settings, err := GetLDAPSettings()
+	if err != nil {
+		return err
+	}
+
+	users, err := s.userRepository.GetActiveLDAPUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get LDAP users: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	connection, err := ldap_utils.Dial(settings.Connection)
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	entries := make(map[uuid.UUID]*ldap_utils.Entry, len(users))
+	for _, user := range users {
+		entry, err := connection.GetEntry(*user.LDAPDN)
+		if err != nil {
+			return err
+		}
+
+		entries[user.ID] = entry
+	}
+
+	// service account without read access sees no users at all, it should
+	// not lead to deactivation of everyone
+	if len(users) > 1 && !hasAnyLDAPEntry(entries) {
+		return errors.New("none of LDAP users is found in the directory, check LDAP_BIND_DN access")
+	}
+
+	for _, user := range users {
+		entry := entries[user.ID]
+
+		if entry == nil || entry.IsDisabled {
+			if err := s.deactivateLDAPUser(user); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := s.syncIdentityProviderRoles(
+			user.ID,
+			entry.Groups,
+			settings.AdminGroups,
+			settings.WorkspaceGroups,
+			"LDAP",
+		); err != nil {
+			return fmt.Errorf("failed to sync groups of %s: %w", user.Email, err)
+		}
+	}
+
+	return nil
+}
+
+// signInWithLDAP returns the user authenticated by the directory, creating
+// or linking it on the first sign in
+func (s *UserService) signInWithLDAP(
+	username string,
+	password string,
+) (*users_models.User, error) {
+	settings, err := GetLDAPSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	connection, err := ldap_utils.Dial(settings.Connection)
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+
+	entry, err := connection.Authenticate(username, password)
+	if err != nil {
+		if errors.Is(err, ldap_utils.ErrInvalidCredentials) {
+			return nil, errors.New("email or password is incorrect")
+		}
+
+		return nil, err
+	}
+
+	if entry.IsDisabled {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	user, err := s.getOrCreateLDAPUser(entry, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status != users_enums.UserStatusActive {
+		return user, nil
+	}
+
+	if err := s.syncIdentityProviderRoles(
+		user.ID,
+		entry.Groups,
+		settings.AdminGroups,
+		settings.WorkspaceGroups,
+		"LDAP",
+	); err != nil {
+		return nil, err
+	}
+
+	return s.userRepository.GetUserByID(user.ID)
+}
+
+func (s *UserService) getOrCreateLDAPUser(
+	entry *ldap_utils.Entry,
+	settings *LDAPSettings,
+) (*users_models.User, error) {
+	existingUser, err := s.userRepository.GetUserByLDAPDN(entry.DN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check LDAP DN: %w", err)
+	}
+
+	if existingUser != nil {
+		return existingUser, nil
+	}
+
+	if entry.Email == "" {
+		return nil, errors.New("LDAP user has no email")
+	}
+
+	name := entry.Name
+	if name == "" {
+		name = entry.Email
+	}
+
+	userByEmail, err := s.userRepository.GetUserByEmail(entry.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+
+	if userByEmail != nil {
+		// invited user has no credentials yet, other accounts are linked
+		// only when the directory is trusted to own the emails
+		if userByEmail.Status != users_enums.UserStatusInvited &&
+			!settings.IsLinkExistingByEmail {
+			return nil, errors.New(
+				"account with this email already exists and LDAP linking by email is disabled",
+			)
+		}
+
+		if userByEmail.Status == users_enums.UserStatusInvited {
+			if err := s.userRepository.UpdateUserStatus(
+				userByEmail.ID,
+				users_enums.UserStatusActive,
+			); err != nil {
+				return nil, fmt.Errorf("failed to activate user: %w", err)
+			}
+
+			if err := s.userRepository.UpdateUserInfo(userByEmail.ID, &name, nil); err != nil {
+				return nil, fmt.Errorf("failed to update name: %w", err)
+			}
+		}
+
+		if err := s.userRepository.LinkOAuthID(userByEmail.ID, "ldap_dn", entry.DN); err != nil {
+			return nil, fmt.Errorf("failed to link LDAP DN: %w", err)
+		}
+
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionLinkAccount,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &userByEmail.ID,
+			Message:      fmt.Sprintf("LDAP account linked to existing account: %s", entry.DN),
+			UserID:       &userByEmail.ID,
+		})
+
+		return s.userRepository.GetUserByID(userByEmail.ID)
+	}
+
+	usersSettings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	if !usersSettings.IsAllowExternalRegistrations && !settings.IsAutoProvisioning {
+		return nil, errors.New("external registration is disabled")
+	}
+
+	ldapDN := entry.DN
+	newUser := &users_models.User{
+		ID:                   uuid.New(),
+		Email:                entry.Email,
+		Name:                 name,
+		HashedPassword:       nil,
+		PasswordCreationTime: time.Now().UTC(),
+		Role:                 users_enums.UserRoleMember,
+		Status:               users_enums.UserStatusActive,
+		LDAPDN:               &ldapDN,
+		CreatedAt:            time.Now().UTC(),
+	}
+
+	if err := s.userRepository.CreateUser(newUser); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionSignUp,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &newUser.ID,
+		Message:      fmt.Sprintf("User registered via LDAP: %s", newUser.Email),
+		UserID:       &newUser.ID,
+	})
+
+	return newUser, nil
+}
+
+func (s *UserService) deactivateLDAPUser(user *users_models.User) error {
+	if err := s.userRepository.UpdateUserStatus(
+		user.ID,
+		users_enums.UserStatusInactive,
+	); err != nil {
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+
+	if err := s.userSessionRepository.RevokeUserSessions(user.ID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDeactivate,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message: fmt.Sprintf(
+			"User deactivated as removed or disabled in LDAP: %s",
+			user.Email,
+		),
+	})
+
+	return nil
+}
+
+// shouldSignInWithLDAP keeps password sign in for local users, so the
+// initial admin is not locked out when the directory is unavailable
+func shouldSignInWithLDAP(user *users_models.User) bool {
+	if !isLDAPConfigured() {
+		return false
+	}
+
+	return user == nil || user.HashedPassword == nil || user.LDAPDN != nil
+}
+
+func isLDAPConfigured() bool {
+	env := config.GetEnv()
+	return env.LDAPURL != "" && env.LDAPSearchBase != ""
+}
+
+func hasAnyLDAPEntry(entries map[uuid.UUID]*ldap_utils.Entry) bool {
+	for _, entry := range entries {
+		if entry != nil {
+			return true
+		}
+	}
+
+	return false
+}
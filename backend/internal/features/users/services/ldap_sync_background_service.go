@@ -0,0 +1,44 @@
+package users_services
+
+import (
+	"log/slog"
+	"time"
+
+	"postgresus-backend/internal/config"
+)
+
+const defaultLDAPSyncInterval = time.Hour
+
+type LDAPSyncBackgroundService struct {
+	userService *UserService
+	logger      *slog.Logger
+}
+
+func (s *LDAPSyncBackgroundService) Run() {
+	if !isLDAPConfigured() {
+		return
+	}
+
+	syncInterval := defaultLDAPSyncInterval
+	if minutes := config.GetEnv().LDAPSyncIntervalMinutes; minutes > 0 {
+		syncInterval = time.Duration(minutes) * time.Minute
+	}
+
+	s.syncUsers()
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if config.IsShouldShutdown() {
+			break
+		}
+
+		s.syncUsers()
+	}
+}
+
+func (s *LDAPSyncBackgroundService) syncUsers() {
+	if err := s.userService.SyncLDAPUsers(); err != nil {
+		s.logger.Error("failed to sync LDAP users", "error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package users_services
+
+import (
+	"testing"
+	"time"
+
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_repositories "postgresus-backend/internal/features/users/repositories"
+	ldap_utils "postgresus-backend/internal/util/ldap"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetOrCreateLDAPUser_WhenPasswordAccountHasSameEmail_LinkedOnlyWhenEnabled(
+	t *testing.T,
+) {
+	tests := []struct {
+		name                  string
+		isLinkExistingByEmail bool
+		isLinked              bool
+	}{
+		{name: "linking disabled", isLinkExistingByEmail: false, isLinked: false},
+		{name: "linking enabled", isLinkExistingByEmail: true, isLinked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepository := users_repositories.GetUserRepository()
+			service := &UserService{
+				userRepository: userRepository,
+				auditLogWriter: &auditLogWriterStub{},
+			}
+
+			hashedPassword := "$2a$10$test"
+			existingUser := &users_models.User{
+				ID:                   uuid.New(),
+				Email:                "ldap-" + uuid.New().String()[:8] + "@test.com",
+				Name:                 "Password User",
+				HashedPassword:       &hashedPassword,
+				PasswordCreationTime: time.Now().UTC(),
+				CreatedAt:            time.Now().UTC(),
+				Role:                 users_enums.UserRoleMember,
+				Status:               users_enums.UserStatusActive,
+			}
+			assert.NoError(t, userRepository.CreateUser(existingUser))
+
+			entry := &ldap_utils.Entry{
+				DN:    "uid=" + existingUser.ID.String() + ",ou=people,dc=example,dc=com",
+				Email: existingUser.Email,
+				Name:  "LDAP User",
+			}
+
+			user, err := service.getOrCreateLDAPUser(
+				entry,
+				&LDAPSettings{IsLinkExistingByEmail: tt.isLinkExistingByEmail},
+			)
+
+			savedUser, getErr := userRepository.GetUserByID(existingUser.ID)
+			assert.NoError(t, getErr)
+
+			if tt.isLinked {
+				assert.NoError(t, err)
+				assert.Equal(t, existingUser.ID, user.ID)
+				assert.NotNil(t, savedUser.LDAPDN)
+			} else {
+				assert.ErrorContains(t, err, "account with this email already exists")
+				assert.Nil(t, savedUser.LDAPDN)
+			}
+		})
+	}
+}
+
+type auditLogWriterStub struct{}
+
+func (a *auditLogWriterStub) WriteAuditEvent(event *audit_logs_events.AuditEvent) {}
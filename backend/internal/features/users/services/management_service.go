@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	user_enums "postgresus-backend/internal/features/users/enums"
 	user_interfaces "postgresus-backend/internal/features/users/interfaces"
 	user_models "postgresus-backend/internal/features/users/models"
@@ -14,8 +15,10 @@ import (
 )
 
 type UserManagementService struct {
-	userRepository *user_repositories.UserRepository
-	auditLogWriter user_interfaces.AuditLogWriter
+	userRepository             *user_repositories.UserRepository
+	userSessionRepository      *user_repositories.UserSessionRepository
+	userRecoveryCodeRepository *user_repositories.UserRecoveryCodeRepository
+	auditLogWriter             user_interfaces.AuditLogWriter
 }
 
 func (s *UserManagementService) SetAuditLogWriter(writer user_interfaces.AuditLogWriter) {
@@ -74,14 +77,103 @@ func (s *UserManagementService) DeactivateUser(
 		return fmt.Errorf("failed to deactivate user: %w", err)
 	}
 
+	if err := s.userSessionRepository.RevokeUserSessions(userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
 	if s.auditLogWriter != nil {
-		s.auditLogWriter.WriteAuditLog(
-			fmt.Sprintf("User deactivated: %s", user.Email),
-			&deactivatedBy.ID,
-			nil,
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionDeactivate,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &user.ID,
+			Message:      fmt.Sprintf("User deactivated: %s", user.Email),
+			User:         deactivatedBy,
+		})
+	}
+
+	return nil
+}
+
+// ForceLogoutUser revokes all sessions of the user, access tokens stop
+// working immediately and the user has to sign in again
+func (s *UserManagementService) ForceLogoutUser(
+	userID uuid.UUID,
+	loggedOutBy *user_models.User,
+) error {
+	if !loggedOutBy.CanManageUsers() {
+		return errors.New("insufficient permissions to log out users")
+	}
+
+	user, err := s.userRepository.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Only user with email "admin" can log out ADMIN users
+	if user.Role == user_enums.UserRoleAdmin &&
+		user.ID != loggedOutBy.ID &&
+		loggedOutBy.Email != "admin" {
+		return errors.New("only the root admin user can log out admin accounts")
+	}
+
+	if err := s.userSessionRepository.RevokeUserSessions(userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if s.auditLogWriter != nil {
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionRevokeSession,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &user.ID,
+			Message:      fmt.Sprintf("User logged out from all sessions: %s", user.Email),
+			User:         loggedOutBy,
+		})
+	}
+
+	return nil
+}
+
+// ResetUserTwoFactor disables 2FA of the user who lost the authenticator
+// app and recovery codes. If 2FA is required, the user sets it up again on
+// the next sign in
+func (s *UserManagementService) ResetUserTwoFactor(
+	userID uuid.UUID,
+	resetBy *user_models.User,
+) error {
+	if !resetBy.CanManageUsers() {
+		return errors.New("insufficient permissions to reset two-factor authentication")
+	}
+
+	user, err := s.userRepository.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Only user with email "admin" can reset 2FA of ADMIN users
+	if user.Role == user_enums.UserRoleAdmin && resetBy.Email != "admin" {
+		return errors.New(
+			"only the root admin user can reset two-factor authentication of admin accounts",
 		)
 	}
 
+	if err := s.userRepository.UpdateUserTwoFactor(userID, nil, false); err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+
+	if err := s.userRecoveryCodeRepository.DeleteUserRecoveryCodes(userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if s.auditLogWriter != nil {
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionDisableTwoFactor,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &user.ID,
+			Message:      fmt.Sprintf("User two-factor authentication reset: %s", user.Email),
+			User:         resetBy,
+		})
+	}
+
 	return nil
 }
 
@@ -108,11 +200,13 @@ func (s *UserManagementService) ActivateUser(
 	}
 
 	if s.auditLogWriter != nil {
-		s.auditLogWriter.WriteAuditLog(
-			fmt.Sprintf("User activated: %s", user.Email),
-			&activatedBy.ID,
-			nil,
-		)
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionActivate,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &user.ID,
+			Message:      fmt.Sprintf("User activated: %s", user.Email),
+			User:         activatedBy,
+		})
 	}
 
 	return nil
@@ -155,11 +249,18 @@ func (s *UserManagementService) ChangeUserRole(
 	}
 
 	if s.auditLogWriter != nil {
-		s.auditLogWriter.WriteAuditLog(
-			fmt.Sprintf("User role changed: %s from %s to %s", user.Email, user.Role, newRole),
-			&changedBy.ID,
-			nil,
-		)
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionChangeRole,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &user.ID,
+			Message: fmt.Sprintf(
+				"User role changed: %s from %s to %s",
+				user.Email,
+				user.Role,
+				newRole,
+			),
+			User: changedBy,
+		})
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package users_services
 
 import (
 	users_dto "postgresus-backend/internal/features/users/dto"
+	"postgresus-backend/internal/util/oidc"
 
 	"golang.org/x/oauth2"
 )
@@ -11,7 +12,7 @@ func (s *UserService) HandleGitHubOAuthWithMockEndpoint(
 	endpoint oauth2.Endpoint,
 	userAPIURL string,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
-	return s.handleGitHubOAuthWithEndpoint(code, redirectUri, endpoint, userAPIURL)
+	return s.handleGitHubOAuthWithEndpoint(code, redirectUri, endpoint, userAPIURL, nil)
 }
 
 func (s *UserService) HandleGoogleOAuthWithMockEndpoint(
@@ -19,5 +20,21 @@ func (s *UserService) HandleGoogleOAuthWithMockEndpoint(
 	endpoint oauth2.Endpoint,
 	userAPIURL string,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
-	return s.handleGoogleOAuthWithEndpoint(code, redirectUri, endpoint, userAPIURL)
+	return s.handleGoogleOAuthWithEndpoint(code, redirectUri, endpoint, userAPIURL, nil)
+}
+
+func (s *UserService) StartOIDCSignInWithMockProvider(
+	redirectUri string,
+	provider *oidc.Provider,
+	settings *OIDCSettings,
+) (*users_dto.OIDCAuthorizeResponseDTO, error) {
+	return s.startOIDCSignInWithProvider(redirectUri, provider, settings)
+}
+
+func (s *UserService) HandleOIDCOAuthWithMockProvider(
+	request *users_dto.OIDCCallbackRequestDTO,
+	provider *oidc.Provider,
+	settings *OIDCSettings,
+) (*users_dto.OAuthCallbackResponseDTO, error) {
+	return s.handleOIDCOAuthWithProvider(request, provider, settings, nil)
 }
@@ -0,0 +1,372 @@
+package users_services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+
+	"postgresus-backend/internal/config"
+	users_dto "postgresus-backend/internal/features/users/dto"
+	"postgresus-backend/internal/util/oidc"
+)
+
+const (
+	oidcFlowTokenLifetime = 10 * time.Minute
+	oidcFlowTokenPurpose  = "oidc_flow"
+	oidcDefaultScopes     = "openid email profile"
+	oidcDefaultGroupClaim = "groups"
+	oidcDefaultName       = "SSO"
+)
+
+var (
+	oidcProvider      *oidc.Provider
+	oidcProviderMutex sync.Mutex
+)
+
+// OIDCSettings is parsed from OIDC_* env variables
+type OIDCSettings struct {
+	IssuerURL          string
+	ClientID           string
+	ClientSecret       string
+	Scopes             []string
+	GroupsClaim        string
+	AdminGroups        []string
+	WorkspaceGroups    []WorkspaceGroupMapping
+	IsAutoProvisioning bool
+}
+
+func (s *UserService) GetOIDCConfig() *users_dto.OIDCConfigResponseDTO {
+	env := config.GetEnv()
+
+	providerName := env.OIDCProviderName
+	if providerName == "" {
+		providerName = oidcDefaultName
+	}
+
+	return &users_dto.OIDCConfigResponseDTO{
+		IsEnabled:    isOIDCConfigured(),
+		ProviderName: providerName,
+	}
+}
+
+// StartOIDCSignIn returns URL of the provider to redirect the user to.
+// State, nonce and PKCE verifier are kept in the signed flow token, the
+// frontend returns it with the callback
+func (s *UserService) StartOIDCSignIn(
+	redirectUri string,
+) (*users_dto.OIDCAuthorizeResponseDTO, error) {
+	settings, err := GetOIDCSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startOIDCSignInWithProvider(redirectUri, getOIDCProvider(settings), settings)
+}
+
+func (s *UserService) HandleOIDCOAuth(
+	request *users_dto.OIDCCallbackRequestDTO,
+	client *users_dto.ClientInfoDTO,
+) (*users_dto.OAuthCallbackResponseDTO, error) {
+	settings, err := GetOIDCSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.handleOIDCOAuthWithProvider(request, getOIDCProvider(settings), settings, client)
+}
+
+func (s *UserService) startOIDCSignInWithProvider(
+	redirectUri string,
+	provider *oidc.Provider,
+	settings *OIDCSettings,
+) (*users_dto.OIDCAuthorizeResponseDTO, error) {
+	discovery, err := provider.GetDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := generateOIDCRandomValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	nonce, err := generateOIDCRandomValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	codeVerifier := oauth2.GenerateVerifier()
+
+	secretKey, err := s.secretKeyService.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	flowToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose":      oidcFlowTokenPurpose,
+		"state":        state,
+		"nonce":        nonce,
+		"codeVerifier": codeVerifier,
+		"redirectUri":  redirectUri,
+		"exp":          time.Now().UTC().Add(oidcFlowTokenLifetime).Unix(),
+	}).SignedString([]byte(secretKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate flow token: %w", err)
+	}
+
+	oauthConfig := getOIDCOAuthConfig(discovery, settings, redirectUri)
+
+	authorizationURL := oauthConfig.AuthCodeURL(
+		state,
+		oauth2.S256ChallengeOption(codeVerifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	)
+
+	return &users_dto.OIDCAuthorizeResponseDTO{
+		AuthorizationURL: authorizationURL,
+		State:            state,
+		FlowToken:        flowToken,
+	}, nil
+}
+
+func (s *UserService) handleOIDCOAuthWithProvider(
+	request *users_dto.OIDCCallbackRequestDTO,
+	provider *oidc.Provider,
+	settings *OIDCSettings,
+	client *users_dto.ClientInfoDTO,
+) (*users_dto.OAuthCallbackResponseDTO, error) {
+	flowClaims, err := s.parseOIDCFlowToken(request.FlowToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if flowClaims["state"] != request.State {
+		return nil, errors.New("invalid OAuth state")
+	}
+
+	discovery, err := provider.GetDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	redirectUri, _ := flowClaims["redirectUri"].(string)
+	codeVerifier, _ := flowClaims["codeVerifier"].(string)
+	nonce, _ := flowClaims["nonce"].(string)
+
+	oauthConfig := getOIDCOAuthConfig(discovery, settings, redirectUri)
+
+	token, err := oauthConfig.Exchange(
+		context.Background(),
+		request.Code,
+		oauth2.VerifierOption(codeVerifier),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("OpenID provider did not return ID token")
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+
+	// unverified email can not be trusted to link existing account
+	if isEmailVerified, ok := claims["email_verified"].(bool); ok && !isEmailVerified {
+		return nil, errors.New("email is not verified by OpenID provider")
+	}
+
+	email, isEmailVerified := getOIDCEmail(claims)
+	if email == "" {
+		return nil, errors.New("OpenID provider did not return email")
+	}
+
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = email
+	}
+
+	user, isNewUser, err := s.getOrCreateUserFromOAuth(
+		subject,
+		email,
+		name,
+		"oidc",
+		client,
+		settings.IsAutoProvisioning,
+		isEmailVerified,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.syncIdentityProviderRoles(
+		user.ID,
+		getOIDCGroups(claims, settings.GroupsClaim),
+		settings.AdminGroups,
+		settings.WorkspaceGroups,
+		"OpenID",
+	); err != nil {
+		return nil, err
+	}
+
+	// roles from groups decide whether 2FA is required
+	user, err = s.userRepository.GetUserByID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated user: %w", err)
+	}
+
+	return s.signInOAuthUser(user, "oidc", client, isNewUser)
+}
+
+func (s *UserService) parseOIDCFlowToken(flowToken string) (jwt.MapClaims, error) {
+	secretKey, err := s.secretKeyService.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	parsedToken, err := jwt.Parse(flowToken, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil || !parsedToken.Valid {
+		return nil, errors.New("sign in expired, please try again")
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != oidcFlowTokenPurpose {
+		return nil, errors.New("invalid token claims")
+	}
+
+	return claims, nil
+}
+
+// GetOIDCSettings returns error when OIDC is not configured or the groups
+// mapping is invalid
+func GetOIDCSettings() (*OIDCSettings, error) {
+	if !isOIDCConfigured() {
+		return nil, errors.New("OpenID Connect is not configured")
+	}
+
+	env := config.GetEnv()
+
+	settings := &OIDCSettings{
+		IssuerURL:          env.OIDCIssuerURL,
+		ClientID:           env.OIDCClientID,
+		ClientSecret:       env.OIDCClientSecret,
+		Scopes:             strings.Fields(env.OIDCScopes),
+		GroupsClaim:        env.OIDCGroupsClaim,
+		AdminGroups:        splitConfigList(env.OIDCAdminGroups, ","),
+		IsAutoProvisioning: env.OIDCIsAutoProvisioning,
+	}
+
+	if len(settings.Scopes) == 0 {
+		settings.Scopes = strings.Fields(oidcDefaultScopes)
+	}
+
+	if settings.GroupsClaim == "" {
+		settings.GroupsClaim = oidcDefaultGroupClaim
+	}
+
+	workspaceGroups, err := ParseWorkspaceGroupMappings(env.OIDCWorkspaceGroups)
+	if err != nil {
+		return nil, err
+	}
+	settings.WorkspaceGroups = workspaceGroups
+
+	return settings, nil
+}
+
+func isOIDCConfigured() bool {
+	env := config.GetEnv()
+	return env.OIDCIssuerURL != "" && env.OIDCClientID != "" && env.OIDCClientSecret != ""
+}
+
+func getOIDCProvider(settings *OIDCSettings) *oidc.Provider {
+	oidcProviderMutex.Lock()
+	defer oidcProviderMutex.Unlock()
+
+	if oidcProvider == nil {
+		oidcProvider = oidc.NewProvider(settings.IssuerURL, settings.ClientID)
+	}
+
+	return oidcProvider
+}
+
+func getOIDCOAuthConfig(
+	discovery *oidc.Discovery,
+	settings *OIDCSettings,
+	redirectUri string,
+) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		RedirectURL:  redirectUri,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		Scopes: settings.Scopes,
+	}
+}
+
+// getOIDCEmail falls back to preferred_username, Azure AD does not always
+// return email claim. Email is verified only when the provider confirms the
+// email claim, preferred_username is never verified and in Azure AD may be
+// changed by the user
+func getOIDCEmail(claims jwt.MapClaims) (string, bool) {
+	if email, _ := claims["email"].(string); email != "" {
+		isEmailVerified, _ := claims["email_verified"].(bool)
+		return email, isEmailVerified
+	}
+
+	if username, _ := claims["preferred_username"].(string); strings.Contains(username, "@") {
+		return username, false
+	}
+
+	return "", false
+}
+
+// getOIDCGroups supports both list and single string claims
+func getOIDCGroups(claims jwt.MapClaims, groupsClaim string) []string {
+	switch value := claims[groupsClaim].(type) {
+	case []any:
+		groups := make([]string, 0, len(value))
+		for _, group := range value {
+			if groupStr, ok := group.(string); ok {
+				groups = append(groups, groupStr)
+			}
+		}
+		return groups
+	case string:
+		return []string{value}
+	default:
+		return []string{}
+	}
+}
+
+func generateOIDCRandomValue() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
@@ -3,6 +3,7 @@ package users_services
 import (
 	"fmt"
 
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	users_interfaces "postgresus-backend/internal/features/users/interfaces"
 	users_models "postgresus-backend/internal/features/users/models"
 	users_repositories "postgresus-backend/internal/features/users/repositories"
@@ -34,46 +35,35 @@ func (s *SettingsService) UpdateSettings(
 		return nil, fmt.Errorf("failed to get current settings: %w", err)
 	}
 
-	auditLogMessages := []string{}
+	settingsBefore := *existingSettings
 
-	if request.IsAllowExternalRegistrations != existingSettings.IsAllowExternalRegistrations {
-		existingSettings.IsAllowExternalRegistrations = request.IsAllowExternalRegistrations
-		auditLogMessages = append(
-			auditLogMessages,
-			fmt.Sprintf(
-				"isAllowExternalRegistrations: %t -> %t",
-				existingSettings.IsAllowExternalRegistrations,
-				request.IsAllowExternalRegistrations,
-			),
-		)
-	}
+	existingSettings.IsAllowExternalRegistrations = request.IsAllowExternalRegistrations
+	existingSettings.IsAllowMemberInvitations = request.IsAllowMemberInvitations
+	existingSettings.IsMemberAllowedToCreateWorkspaces = request.IsMemberAllowedToCreateWorkspaces
 
-	if request.IsAllowMemberInvitations != existingSettings.IsAllowMemberInvitations {
-		existingSettings.IsAllowMemberInvitations = request.IsAllowMemberInvitations
-		auditLogMessages = append(
-			auditLogMessages,
-			fmt.Sprintf(
-				"isAllowMemberInvitations: %t -> %t",
-				existingSettings.IsAllowMemberInvitations,
-				request.IsAllowMemberInvitations,
-			),
-		)
-	}
+	// older clients do not send the requirement, keep the current one
+	if request.TwoFactorRequirement != "" {
+		if !request.TwoFactorRequirement.IsValid() {
+			return nil, fmt.Errorf("invalid two-factor requirement")
+		}
 
-	if request.IsMemberAllowedToCreateWorkspaces != existingSettings.IsMemberAllowedToCreateWorkspaces {
-		existingSettings.IsMemberAllowedToCreateWorkspaces = request.IsMemberAllowedToCreateWorkspaces
+		existingSettings.TwoFactorRequirement = request.TwoFactorRequirement
 	}
 
 	if err := s.userSettingsRepository.UpdateSettings(existingSettings); err != nil {
 		return nil, fmt.Errorf("failed to update settings: %w", err)
 	}
 
-	for _, message := range auditLogMessages {
-		s.auditLogWriter.WriteAuditLog(
-			message,
-			&updatedBy.ID,
-			nil,
-		)
+	if settingsBefore != *existingSettings {
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionUpdate,
+			ResourceType: audit_logs_events.AuditResourceUsersSettings,
+			ResourceID:   &existingSettings.ID,
+			Message:      "Users settings updated",
+			User:         updatedBy,
+			Before:       settingsBefore,
+			After:        existingSettings,
+		})
 	}
 
 	return existingSettings, nil
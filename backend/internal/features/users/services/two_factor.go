@@ -0,0 +1,593 @@
+package users_services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_dto "postgresus-backend/internal/features/users/dto"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+)
+
+const (
+	totpIssuer = "Postgresus"
+	totpPeriod = 30
+	// codes of the previous and the next step are accepted to tolerate
+	// clock drift of the phone
+	totpSkewSteps = 1
+
+	twoFactorTokenLifetime = 5 * time.Minute
+	twoFactorTokenPurpose  = "two_factor"
+
+	recoveryCodesCount = 10
+	recoveryCodeBytes  = 8
+
+	// lockout is longer than 2FA token lifetime, so tokens issued before the
+	// lockout expire and the password has to be entered again
+	maxTwoFactorFailedAttempts = 5
+	twoFactorLockoutDuration   = 15 * time.Minute
+)
+
+var errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// GetTwoFactorStatus returns whether 2FA is enabled for the user and
+// whether it is required by users settings
+func (s *UserService) GetTwoFactorStatus(
+	user *users_models.User,
+) (*users_dto.TwoFactorStatusResponseDTO, error) {
+	isRequired, err := s.isTwoFactorRequired(user)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodesLeft, err := s.userRecoveryCodeRepository.CountUnusedRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return &users_dto.TwoFactorStatusResponseDTO{
+		IsEnabled:         user.IsTwoFactorEnabled,
+		IsRequired:        isRequired,
+		RecoveryCodesLeft: recoveryCodesLeft,
+	}, nil
+}
+
+// SetupTwoFactor generates new TOTP secret for authenticator app. 2FA is
+// not enabled until the first code is confirmed by EnableTwoFactor
+func (s *UserService) SetupTwoFactor(
+	user *users_models.User,
+) (*users_dto.TwoFactorSetupResponseDTO, error) {
+	if user.IsTwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	encryptedSecret, err := s.fieldEncryptor.Encrypt(user.ID, key.Secret())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if err := s.userRepository.UpdateUserTwoFactor(user.ID, &encryptedSecret, false); err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	return &users_dto.TwoFactorSetupResponseDTO{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+	}, nil
+}
+
+// EnableTwoFactor confirms the setup by the first code from authenticator
+// app and returns recovery codes, they are shown to the user only once
+func (s *UserService) EnableTwoFactor(user *users_models.User, code string) ([]string, error) {
+	if user.IsTwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, errors.New("two-factor authentication is not set up")
+	}
+
+	if err := s.verifyTOTPCode(user, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepository.UpdateUserTwoFactor(user.ID, user.TOTPSecret, true); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	user.IsTwoFactorEnabled = true
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionEnableTwoFactor,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      "Two-factor authentication enabled",
+		User:         user,
+	})
+
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor accepts TOTP code or recovery code, 2FA can not be
+// disabled when it is required by users settings
+func (s *UserService) DisableTwoFactor(user *users_models.User, code string) error {
+	if !user.IsTwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	isRequired, err := s.isTwoFactorRequired(user)
+	if err != nil {
+		return err
+	}
+
+	if isRequired {
+		return errors.New("two-factor authentication is required by administrator")
+	}
+
+	if _, err := s.verifyTwoFactorCode(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepository.UpdateUserTwoFactor(user.ID, nil, false); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if err := s.userRecoveryCodeRepository.DeleteUserRecoveryCodes(user.ID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDisableTwoFactor,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      "Two-factor authentication disabled",
+		User:         user,
+	})
+
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates previous recovery codes, it requires
+// TOTP code so lost recovery codes can not be used to get new ones
+func (s *UserService) RegenerateRecoveryCodes(
+	user *users_models.User,
+	code string,
+) ([]string, error) {
+	if !user.IsTwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.verifyTOTPCode(user, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRegenerateCodes,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      "Two-factor recovery codes regenerated",
+		User:         user,
+	})
+
+	return recoveryCodes, nil
+}
+
+// SetupTwoFactorOnSignIn is used when 2FA is required by users settings,
+// but the user has not set it up yet
+func (s *UserService) SetupTwoFactorOnSignIn(
+	twoFactorToken string,
+) (*users_dto.TwoFactorSetupResponseDTO, error) {
+	user, err := s.getUserFromTwoFactorToken(twoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SetupTwoFactor(user)
+}
+
+// SignInWithTwoFactor is the second step of sign in with password. If 2FA
+// is set up during sign in, the code enables it and recovery codes are
+// returned with tokens
+func (s *UserService) SignInWithTwoFactor(
+	request *users_dto.TwoFactorSignInRequestDTO,
+	client *users_dto.ClientInfoDTO,
+) (*users_dto.SignInResponseDTO, error) {
+	user, err := s.getUserFromTwoFactorToken(request.TwoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorLockedUntil != nil && time.Now().UTC().Before(*user.TwoFactorLockedUntil) {
+		return nil, errors.New("too many failed two-factor attempts, please try again later")
+	}
+
+	if !user.IsTwoFactorEnabled {
+		recoveryCodes, err := s.EnableTwoFactor(user, request.Code)
+		if err != nil {
+			return nil, s.registerFailedTwoFactorAttempt(user, client, err)
+		}
+
+		if err := s.resetTwoFactorFailedAttempts(user); err != nil {
+			return nil, err
+		}
+
+		response, err := s.GenerateAccessToken(user, client)
+		if err != nil {
+			return nil, err
+		}
+
+		response.RecoveryCodes = recoveryCodes
+
+		s.writeSignInAuditLog(
+			user,
+			client,
+			fmt.Sprintf("User signed in with email: %s", user.Email),
+		)
+
+		return response, nil
+	}
+
+	isRecoveryCode, err := s.verifyTwoFactorCode(user, request.Code)
+	if err != nil {
+		return nil, s.registerFailedTwoFactorAttempt(user, client, err)
+	}
+
+	if err := s.resetTwoFactorFailedAttempts(user); err != nil {
+		return nil, err
+	}
+
+	response, err := s.GenerateAccessToken(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if isRecoveryCode {
+		s.writeSignInAuditLog(
+			user,
+			client,
+			fmt.Sprintf("User signed in with email and recovery code: %s", user.Email),
+		)
+	} else {
+		s.writeSignInAuditLog(
+			user,
+			client,
+			fmt.Sprintf("User signed in with email: %s", user.Email),
+		)
+	}
+
+	return response, nil
+}
+
+// registerFailedTwoFactorAttempt counts wrong codes of the user, not of the
+// token, because the password step can be repeated to get new tokens
+func (s *UserService) registerFailedTwoFactorAttempt(
+	user *users_models.User,
+	client *users_dto.ClientInfoDTO,
+	codeErr error,
+) error {
+	if !errors.Is(codeErr, errInvalidTwoFactorCode) {
+		return codeErr
+	}
+
+	attempts, err := s.userRepository.IncrementTwoFactorFailedAttempts(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count two-factor attempts: %w", err)
+	}
+
+	if attempts < maxTwoFactorFailedAttempts {
+		return codeErr
+	}
+
+	lockedUntil := time.Now().UTC().Add(twoFactorLockoutDuration)
+	if err := s.userRepository.UpdateTwoFactorLock(user.ID, &lockedUntil); err != nil {
+		return fmt.Errorf("failed to lock two-factor sign in: %w", err)
+	}
+
+	s.writeSignInAuditLog(
+		user,
+		client,
+		fmt.Sprintf("Two-factor sign in locked after %d failed attempts: %s", attempts, user.Email),
+	)
+
+	return errors.New("too many failed two-factor attempts, please try again later")
+}
+
+func (s *UserService) resetTwoFactorFailedAttempts(user *users_models.User) error {
+	if user.TwoFactorFailedAttempts == 0 && user.TwoFactorLockedUntil == nil {
+		return nil
+	}
+
+	if err := s.userRepository.UpdateTwoFactorLock(user.ID, nil); err != nil {
+		return fmt.Errorf("failed to reset two-factor attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UserService) isTwoFactorRequired(user *users_models.User) (bool, error) {
+	settings, err := s.settingsService.GetSettings()
+	if err != nil {
+		return false, fmt.Errorf("failed to get users settings: %w", err)
+	}
+
+	switch settings.TwoFactorRequirement {
+	case users_enums.TwoFactorRequirementAllUsers:
+		return true, nil
+	case users_enums.TwoFactorRequirementWorkspaceManagers:
+		if user.Role == users_enums.UserRoleAdmin {
+			return true, nil
+		}
+
+		isManager, err := s.userRepository.HasWorkspaceRole(
+			user.ID,
+			[]users_enums.WorkspaceRole{
+				users_enums.WorkspaceRoleOwner,
+				users_enums.WorkspaceRoleAdmin,
+			},
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to get workspace roles: %w", err)
+		}
+
+		return isManager, nil
+	default:
+		return false, nil
+	}
+}
+
+// startTwoFactorSignIn returns short-lived token instead of access token,
+// the token only proves that the password is already checked
+func (s *UserService) startTwoFactorSignIn(
+	user *users_models.User,
+) (*users_dto.SignInResponseDTO, error) {
+	secretKey, err := s.secretKeyService.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":                  user.ID.String(),
+		"purpose":              twoFactorTokenPurpose,
+		"exp":                  time.Now().UTC().Add(twoFactorTokenLifetime).Unix(),
+		"iat":                  time.Now().UTC().Unix(),
+		"passwordCreationTime": user.PasswordCreationTime.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(secretKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &users_dto.SignInResponseDTO{
+		UserID:                   user.ID,
+		Email:                    user.Email,
+		IsTwoFactorRequired:      true,
+		IsTwoFactorSetupRequired: !user.IsTwoFactorEnabled,
+		TwoFactorToken:           tokenString,
+	}, nil
+}
+
+func (s *UserService) getUserFromTwoFactorToken(token string) (*users_models.User, error) {
+	secretKey, err := s.secretKeyService.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil || !parsedToken.Valid {
+		return nil, errors.New("two-factor sign in expired, please sign in again")
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != twoFactorTokenPurpose {
+		return nil, errors.New("invalid token claims")
+	}
+
+	userIDStr, ok := claims["sub"].(string)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, errors.New("invalid token claims")
+	}
+
+	user, err := s.userRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActiveUser() {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	passwordCreationTimeUnix, ok := claims["passwordCreationTime"].(float64)
+	if !ok || int64(passwordCreationTimeUnix) != user.PasswordCreationTime.Unix() {
+		return nil, errors.New("password has been changed, please sign in again")
+	}
+
+	return user, nil
+}
+
+// verifyTwoFactorCode accepts TOTP code or recovery code and returns true
+// if recovery code is used
+func (s *UserService) verifyTwoFactorCode(user *users_models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == int(otp.DigitsSix) {
+		return false, s.verifyTOTPCode(user, code)
+	}
+
+	isUsed, err := s.userRecoveryCodeRepository.UseRecoveryCode(
+		user.ID,
+		hashRecoveryCode(code),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	if !isUsed {
+		return false, errInvalidTwoFactorCode
+	}
+
+	return true, nil
+}
+
+func (s *UserService) verifyTOTPCode(user *users_models.User, code string) error {
+	if user.TOTPSecret == nil {
+		return errors.New("two-factor authentication is not set up")
+	}
+
+	secret, err := s.fieldEncryptor.Decrypt(user.ID, *user.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	step, isValid, err := validateTOTPCode(secret, strings.TrimSpace(code), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if !isValid {
+		return errInvalidTwoFactorCode
+	}
+
+	isStepUpdated, err := s.userRepository.UpdateTOTPLastUsedStep(user.ID, step)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP step: %w", err)
+	}
+
+	if !isStepUpdated {
+		return errors.New("two-factor code is already used, wait for the next one")
+	}
+
+	return nil
+}
+
+func (s *UserService) generateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	recoveryCodes := make([]*users_models.UserRecoveryCode, 0, recoveryCodesCount)
+	now := time.Now().UTC()
+
+	for range recoveryCodesCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, &users_models.UserRecoveryCode{
+			ID:         uuid.New(),
+			UserID:     userID,
+			HashedCode: hashRecoveryCode(code),
+			CreatedAt:  now,
+		})
+	}
+
+	if err := s.userRecoveryCodeRepository.ReplaceUserRecoveryCodes(
+		userID,
+		recoveryCodes,
+	); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// validateTOTPCode returns the time step the code is issued for, so the
+// caller can reject reuse of the code
+func validateTOTPCode(secret string, code string, now time.Time) (int64, bool, error) {
+	currentStep := now.Unix() / totpPeriod
+
+	for step := currentStep - totpSkewSteps; step <= currentStep+totpSkewSteps; step++ {
+		expectedCode, err := totp.GenerateCodeCustom(
+			secret,
+			time.Unix(step*totpPeriod, 0).UTC(),
+			totp.ValidateOpts{
+				Period:    totpPeriod,
+				Digits:    otp.DigitsSix,
+				Algorithm: otp.AlgorithmSHA1,
+			},
+		)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to generate TOTP code: %w", err)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expectedCode), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// generateRecoveryCode returns code like "abcde-fghij-klm"
+func generateRecoveryCode() (string, error) {
+	randomBytes := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(
+		base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes),
+	)
+
+	parts := []string{}
+	for len(encoded) > 5 {
+		parts = append(parts, encoded[:5])
+		encoded = encoded[5:]
+	}
+	parts = append(parts, encoded)
+
+	return strings.Join(parts, "-"), nil
+}
+
+// hashRecoveryCode ignores case and dashes, so the code can be typed
+// in any form
+func hashRecoveryCode(code string) string {
+	normalizedCode := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+
+	hash := sha256.Sum256([]byte(normalizedCode))
+	return hex.EncodeToString(hash[:])
+}
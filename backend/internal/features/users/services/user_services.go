@@ -2,6 +2,10 @@ package users_services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,26 +21,68 @@ import (
 	"golang.org/x/oauth2/google"
 
 	"postgresus-backend/internal/config"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	"postgresus-backend/internal/features/encryption/secrets"
 	users_dto "postgresus-backend/internal/features/users/dto"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_interfaces "postgresus-backend/internal/features/users/interfaces"
 	users_models "postgresus-backend/internal/features/users/models"
 	users_repositories "postgresus-backend/internal/features/users/repositories"
+	"postgresus-backend/internal/util/encryption"
 )
 
+const (
+	accessTokenLifetime  = 15 * time.Minute
+	refreshTokenLifetime = 30 * 24 * time.Hour
+	refreshTokenBytes    = 32
+
+	// rotated refresh token used again within this period is treated as
+	// concurrent refresh from another tab, not as reuse of a stolen token
+	refreshTokenReuseGracePeriod = 30 * time.Second
+
+	legacyTokenGracePeriod = 14 * 24 * time.Hour
+)
+
+// access tokens without session were issued before sessions were introduced
+// and lived for ten years. They are accepted only for a short period after
+// the update, so the update does not sign out everyone at once
+var legacyTokensIssuedBefore = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
 type UserService struct {
-	userRepository   *users_repositories.UserRepository
-	secretKeyService *secrets.SecretKeyService
-	settingsService  *SettingsService
-	auditLogWriter   users_interfaces.AuditLogWriter
+	userRepository             *users_repositories.UserRepository
+	userSessionRepository      *users_repositories.UserSessionRepository
+	userRecoveryCodeRepository *users_repositories.UserRecoveryCodeRepository
+	secretKeyService           *secrets.SecretKeyService
+	fieldEncryptor             encryption.FieldEncryptor
+	settingsService            *SettingsService
+	auditLogWriter             users_interfaces.AuditLogWriter
+	apiKeyAuthenticator        users_interfaces.APIKeyAuthenticator
+	workspaceMembershipSyncer  users_interfaces.WorkspaceMembershipSyncer
 }
 
 func (s *UserService) SetAuditLogWriter(writer users_interfaces.AuditLogWriter) {
 	s.auditLogWriter = writer
 }
 
-func (s *UserService) SignUp(request *users_dto.SignUpRequestDTO) error {
+func (s *UserService) SetWorkspaceMembershipSyncer(
+	syncer users_interfaces.WorkspaceMembershipSyncer,
+) {
+	s.workspaceMembershipSyncer = syncer
+}
+
+func (s *UserService) SetAPIKeyAuthenticator(authenticator users_interfaces.APIKeyAuthenticator) {
+	s.apiKeyAuthenticator = authenticator
+}
+
+// GetAPIKeyAuthenticator returns nil when API keys are not set up
+func (s *UserService) GetAPIKeyAuthenticator() users_interfaces.APIKeyAuthenticator {
+	return s.apiKeyAuthenticator
+}
+
+func (s *UserService) SignUp(
+	request *users_dto.SignUpRequestDTO,
+	client *users_dto.ClientInfoDTO,
+) error {
 	existingUser, err := s.userRepository.GetUserByEmail(request.Email)
 	if err != nil {
 		return fmt.Errorf("failed to check existing user: %w", err)
@@ -68,11 +114,16 @@ func (s *UserService) SignUp(request *users_dto.SignUpRequestDTO) error {
 			return fmt.Errorf("failed to update name: %w", err)
 		}
 
-		s.auditLogWriter.WriteAuditLog(
-			fmt.Sprintf("Invited user completed registration: %s", existingUser.Email),
-			&existingUser.ID,
-			nil,
-		)
+		s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionSignUp,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &existingUser.ID,
+			Message: fmt.Sprintf(
+				"Invited user completed registration: %s",
+				existingUser.Email,
+			),
+			UserID: &existingUser.ID,
+		}, client))
 
 		return nil
 	}
@@ -103,23 +154,34 @@ func (s *UserService) SignUp(request *users_dto.SignUpRequestDTO) error {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
-	s.auditLogWriter.WriteAuditLog(
-		fmt.Sprintf("User registered with email: %s", user.Email),
-		&user.ID,
-		nil,
-	)
+	s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionSignUp,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      fmt.Sprintf("User registered with email: %s", user.Email),
+		UserID:       &user.ID,
+	}, client))
 
 	return nil
 }
 
 func (s *UserService) SignIn(
 	request *users_dto.SignInRequestDTO,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.SignInResponseDTO, error) {
 	user, err := s.userRepository.GetUserByEmail(request.Email)
 	if err != nil {
 		return nil, errors.New("user with this email does not exist")
 	}
 
+	isLDAPSignIn := shouldSignInWithLDAP(user)
+	if isLDAPSignIn {
+		user, err = s.signInWithLDAP(request.Email, request.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if user == nil {
 		usersCount, err := s.userRepository.GetUsersCount()
 		if err != nil {
@@ -143,21 +205,36 @@ func (s *UserService) SignIn(
 		return nil, errors.New("user account is deactivated")
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(*user.HashedPassword), []byte(request.Password))
+	if !isLDAPSignIn {
+		err = bcrypt.CompareHashAndPassword([]byte(*user.HashedPassword), []byte(request.Password))
+		if err != nil {
+			s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionSignInFailed,
+				ResourceType: audit_logs_events.AuditResourceUser,
+				ResourceID:   &user.ID,
+				Message:      fmt.Sprintf("Sign in with incorrect password: %s", user.Email),
+				UserID:       &user.ID,
+			}, client))
+
+			return nil, errors.New("password is incorrect")
+		}
+	}
+
+	isTwoFactorRequired, err := s.isTwoFactorRequired(user)
 	if err != nil {
-		return nil, errors.New("password is incorrect")
+		return nil, err
+	}
+
+	if user.IsTwoFactorEnabled || isTwoFactorRequired {
+		return s.startTwoFactorSignIn(user)
 	}
 
-	response, err := s.GenerateAccessToken(user)
+	response, err := s.GenerateAccessToken(user, client)
 	if err != nil {
 		return nil, err
 	}
 
-	s.auditLogWriter.WriteAuditLog(
-		fmt.Sprintf("User signed in with email: %s", user.Email),
-		&user.ID,
-		nil,
-	)
+	s.writeSignInAuditLog(user, client, fmt.Sprintf("User signed in with email: %s", user.Email))
 
 	return response, nil
 }
@@ -180,6 +257,12 @@ func (s *UserService) GetUserFromToken(token string) (*users_models.User, error)
 	}
 
 	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
+		// 2FA and OpenID flow tokens are signed with the same key, but they
+		// do not grant access
+		if _, ok := claims["purpose"]; ok {
+			return nil, errors.New("invalid token claims")
+		}
+
 		userIDStr, ok := claims["sub"].(string)
 		if !ok {
 			return nil, errors.New("invalid token claims")
@@ -213,39 +296,213 @@ func (s *UserService) GetUserFromToken(token string) (*users_models.User, error)
 			return nil, errors.New("invalid token claims: missing password creation time")
 		}
 
+		issuedAtUnix, _ := claims["iat"].(float64)
+		issuedAt := time.Unix(int64(issuedAtUnix), 0).UTC()
+
+		// iat has seconds precision, token issued within the same second
+		// as revocation is issued after it
+		if user.TokensValidAfter != nil &&
+			issuedAt.Before(user.TokensValidAfter.Truncate(time.Second)) {
+			return nil, errors.New("session has been revoked, please sign in again")
+		}
+
+		sessionID, err := s.getActiveSessionID(claims, user, issuedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		user.SessionID = sessionID
+
 		return user, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
 
+// GenerateAccessToken starts a new session of the user and returns
+// short-lived access token with refresh token of the session
 func (s *UserService) GenerateAccessToken(
 	user *users_models.User,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.SignInResponseDTO, error) {
-	secretKey, err := s.secretKeyService.GetSecretKey()
+	refreshToken, err := generateRefreshToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret key: %w", err)
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	tenYearsExpiration := time.Now().UTC().Add(time.Hour * 24 * 365 * 10)
+	now := time.Now().UTC()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":                  user.ID.String(),
-		"exp":                  tenYearsExpiration.Unix(),
-		"iat":                  time.Now().UTC().Unix(),
-		"role":                 string(user.Role),
-		"passwordCreationTime": user.PasswordCreationTime.Unix(),
-	})
+	// sessions stopped working long ago are not needed in the list anymore
+	if err := s.userSessionRepository.DeleteInactiveSessions(
+		user.ID,
+		now.Add(-refreshTokenLifetime),
+	); err != nil {
+		return nil, fmt.Errorf("failed to delete inactive sessions: %w", err)
+	}
 
-	tokenString, err := token.SignedString([]byte(secretKey))
+	session := &users_models.UserSession{
+		ID:                 uuid.New(),
+		UserID:             user.ID,
+		HashedRefreshToken: hashRefreshToken(refreshToken),
+		CreatedAt:          now,
+		LastSeenAt:         now,
+		ExpiresAt:          now.Add(refreshTokenLifetime),
+	}
+	setSessionClient(session, client)
+
+	if err := s.userSessionRepository.Save(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueAccessToken(user, session, refreshToken)
+}
+
+// RefreshAccessToken rotates refresh token of the session and issues new
+// access token. Reuse of already rotated refresh token revokes the session
+func (s *UserService) RefreshAccessToken(
+	refreshToken string,
+	client *users_dto.ClientInfoDTO,
+) (*users_dto.SignInResponseDTO, error) {
+	hashedRefreshToken := hashRefreshToken(refreshToken)
+	now := time.Now().UTC()
+
+	session, err := s.userSessionRepository.GetSessionByRefreshToken(hashedRefreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	return &users_dto.SignInResponseDTO{
-		UserID: user.ID,
-		Token:  tokenString,
-	}, nil
+	if session == nil {
+		if err := s.revokeSessionOnRefreshTokenReuse(hashedRefreshToken, now); err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if !session.IsActive(now) {
+		return nil, errors.New("session expired, please sign in again")
+	}
+
+	user, err := s.userRepository.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsActiveUser() {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	previousHashedRefreshToken := session.HashedRefreshToken
+	session.PreviousHashedRefreshToken = &previousHashedRefreshToken
+	session.HashedRefreshToken = hashRefreshToken(newRefreshToken)
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(refreshTokenLifetime)
+	setSessionClient(session, client)
+
+	if err := s.userSessionRepository.Save(session); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return s.issueAccessToken(user, session, newRefreshToken)
+}
+
+func (s *UserService) GetUserSessions(
+	user *users_models.User,
+) ([]*users_dto.UserSessionResponseDTO, error) {
+	sessions, err := s.userSessionRepository.GetActiveSessionsByUserID(
+		user.ID,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	response := make([]*users_dto.UserSessionResponseDTO, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, &users_dto.UserSessionResponseDTO{
+			ID:         session.ID,
+			Device:     session.Device,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			IsCurrent:  user.SessionID != nil && *user.SessionID == session.ID,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *UserService) RevokeSession(user *users_models.User, sessionID uuid.UUID) error {
+	session, err := s.userSessionRepository.GetSessionByID(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session == nil || session.UserID != user.ID {
+		return errors.New("session not found")
+	}
+
+	if err := s.userSessionRepository.RevokeSession(sessionID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRevokeSession,
+		ResourceType: audit_logs_events.AuditResourceSession,
+		ResourceID:   &session.ID,
+		Message:      fmt.Sprintf("Session revoked: %s (%s)", session.Device, session.IPAddress),
+		User:         user,
+	})
+
+	return nil
+}
+
+func (s *UserService) RevokeAllSessions(user *users_models.User) error {
+	if err := s.userSessionRepository.RevokeUserSessions(user.ID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRevokeSession,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      "All sessions revoked",
+		User:         user,
+	})
+
+	return nil
+}
+
+func (s *UserService) SignOut(user *users_models.User) error {
+	// token without session can not be revoked alone, so every token issued
+	// before sign out is rejected instead
+	now := time.Now().UTC()
+
+	if user.SessionID == nil {
+		if err := s.userRepository.UpdateTokensValidAfter(user.ID, now); err != nil {
+			return fmt.Errorf("failed to revoke tokens: %w", err)
+		}
+	} else {
+		if err := s.userSessionRepository.RevokeSession(*user.SessionID, now); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionSignOut,
+		ResourceType: audit_logs_events.AuditResourceSession,
+		ResourceID:   user.SessionID,
+		Message:      "User signed out",
+		User:         user,
+	})
+
+	return nil
 }
 
 func (s *UserService) CreateInitialAdmin() error {
@@ -290,11 +547,13 @@ func (s *UserService) SetRootAdminPassword(password string) error {
 	}
 
 	if s.auditLogWriter != nil {
-		s.auditLogWriter.WriteAuditLog(
-			"Admin password set",
-			&admin.ID,
-			nil,
-		)
+		s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionChangePassword,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &admin.ID,
+			Message:      "Admin password set",
+			UserID:       &admin.ID,
+		})
 	}
 
 	return nil
@@ -319,11 +578,17 @@ func (s *UserService) ChangeUserPassword(userID uuid.UUID, newPassword string) e
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
-	s.auditLogWriter.WriteAuditLog(
-		"Password changed",
-		&userID,
-		nil,
-	)
+	if err := s.userSessionRepository.RevokeUserSessions(userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionChangePassword,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &userID,
+		Message:      "Password changed",
+		UserID:       &userID,
+	})
 
 	return nil
 }
@@ -371,11 +636,14 @@ func (s *UserService) InviteUser(
 	if request.IntendedWorkspaceID != nil {
 		message += fmt.Sprintf(" for workspace %s", request.IntendedWorkspaceID.String())
 	}
-	s.auditLogWriter.WriteAuditLog(
-		message,
-		&invitedBy.ID,
-		request.IntendedWorkspaceID,
-	)
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionInvite,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      message,
+		User:         invitedBy,
+		WorkspaceID:  request.IntendedWorkspaceID,
+	})
 
 	return &users_dto.InviteUserResponseDTO{
 		ID:                    user.ID,
@@ -404,6 +672,8 @@ func (s *UserService) GetCurrentUserProfile(
 		Role:      user.Role,
 		IsActive:  user.IsActiveUser(),
 		CreatedAt: user.CreatedAt,
+
+		IsTwoFactorEnabled: user.IsTwoFactorEnabled,
 	}
 }
 
@@ -434,18 +704,26 @@ func (s *UserService) UpdateUserInfo(
 		return fmt.Errorf("failed to update user info: %w", err)
 	}
 
-	s.auditLogWriter.WriteAuditLog("User info updated", &userID, nil)
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &userID,
+		Message:      "User info updated",
+		UserID:       &userID,
+	})
 	return nil
 }
 
 func (s *UserService) HandleGitHubOAuth(
 	code, redirectUri string,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
 	return s.handleGitHubOAuthWithEndpoint(
 		code,
 		redirectUri,
 		github.Endpoint,
 		"https://api.github.com/user",
+		client,
 	)
 }
 
@@ -453,6 +731,7 @@ func (s *UserService) handleGitHubOAuthWithEndpoint(
 	code, redirectUri string,
 	endpoint oauth2.Endpoint,
 	userAPIURL string,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
 	env := config.GetEnv()
 
@@ -469,8 +748,8 @@ func (s *UserService) handleGitHubOAuthWithEndpoint(
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	client := oauthConfig.Client(context.Background(), token)
-	resp, err := client.Get(userAPIURL)
+	httpClient := oauthConfig.Client(context.Background(), token)
+	resp, err := httpClient.Get(userAPIURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -500,7 +779,7 @@ func (s *UserService) handleGitHubOAuthWithEndpoint(
 
 	email := githubUser.Email
 	if email == "" {
-		email, err = s.fetchGitHubPrimaryEmail(client, userAPIURL)
+		email, err = s.fetchGitHubPrimaryEmail(httpClient, userAPIURL)
 		if err != nil {
 			return nil, err
 		}
@@ -512,17 +791,32 @@ func (s *UserService) handleGitHubOAuthWithEndpoint(
 	}
 
 	oauthID := fmt.Sprintf("%d", githubUser.ID)
-	return s.getOrCreateUserFromOAuth(oauthID, email, name, "github")
+	user, isNewUser, err := s.getOrCreateUserFromOAuth(
+		oauthID,
+		email,
+		name,
+		"github",
+		client,
+		false,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signInOAuthUser(user, "github", client, isNewUser)
 }
 
 func (s *UserService) HandleGoogleOAuth(
 	code, redirectUri string,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
 	return s.handleGoogleOAuthWithEndpoint(
 		code,
 		redirectUri,
 		google.Endpoint,
 		"https://www.googleapis.com/oauth2/v2/userinfo",
+		client,
 	)
 }
 
@@ -530,6 +824,7 @@ func (s *UserService) handleGoogleOAuthWithEndpoint(
 	code, redirectUri string,
 	endpoint oauth2.Endpoint,
 	userAPIURL string,
+	client *users_dto.ClientInfoDTO,
 ) (*users_dto.OAuthCallbackResponseDTO, error) {
 	env := config.GetEnv()
 
@@ -549,8 +844,8 @@ func (s *UserService) handleGoogleOAuthWithEndpoint(
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	client := oauthConfig.Client(context.Background(), token)
-	resp, err := client.Get(userAPIURL)
+	httpClient := oauthConfig.Client(context.Background(), token)
+	resp, err := httpClient.Get(userAPIURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -586,113 +881,124 @@ func (s *UserService) handleGoogleOAuthWithEndpoint(
 		name = "User"
 	}
 
-	return s.getOrCreateUserFromOAuth(googleUser.ID, googleUser.Email, name, "google")
+	user, isNewUser, err := s.getOrCreateUserFromOAuth(
+		googleUser.ID,
+		googleUser.Email,
+		name,
+		"google",
+		client,
+		false,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signInOAuthUser(user, "google", client, isNewUser)
 }
 
+// getOrCreateUserFromOAuth creates new users only when external
+// registrations are allowed, unless provisioning is allowed by the provider
+// configuration. Existing account is linked by email only when the provider
+// verified the email, otherwise anyone able to set such email in the provider
+// would take over the account
 func (s *UserService) getOrCreateUserFromOAuth(
 	oauthID, email, name, provider string,
-) (*users_dto.OAuthCallbackResponseDTO, error) {
+	client *users_dto.ClientInfoDTO,
+	isProvisioningAllowed bool,
+	isEmailVerified bool,
+) (*users_models.User, bool, error) {
 	var existingUser *users_models.User
 	var err error
 
-	if provider == "github" {
+	switch provider {
+	case "github":
 		existingUser, err = s.userRepository.GetUserByGitHubOAuthID(oauthID)
-	} else {
+	case "google":
 		existingUser, err = s.userRepository.GetUserByGoogleOAuthID(oauthID)
+	default:
+		existingUser, err = s.userRepository.GetUserByOIDCSubject(oauthID)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to check OAuth ID: %w", err)
+		return nil, false, fmt.Errorf("failed to check OAuth ID: %w", err)
 	}
 
 	if existingUser != nil {
-		tokenResponse, err := s.GenerateAccessToken(existingUser)
-		if err != nil {
-			return nil, err
-		}
-
-		if s.auditLogWriter != nil {
-			s.auditLogWriter.WriteAuditLog(
-				fmt.Sprintf("User signed in via %s", provider),
-				&existingUser.ID,
-				nil,
-			)
-		}
-
-		return &users_dto.OAuthCallbackResponseDTO{
-			UserID:    tokenResponse.UserID,
-			Email:     existingUser.Email,
-			Token:     tokenResponse.Token,
-			IsNewUser: false,
-		}, nil
+		return existingUser, false, nil
 	}
 
 	userByEmail, err := s.userRepository.GetUserByEmail(email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check email: %w", err)
+		return nil, false, fmt.Errorf("failed to check email: %w", err)
 	}
 
 	if userByEmail != nil {
+		if !isEmailVerified {
+			return nil, false, errors.New(
+				"account with this email already exists, but the provider did not verify the email",
+			)
+		}
+
 		if userByEmail.Status == users_enums.UserStatusInvited {
 			if err := s.userRepository.UpdateUserStatus(userByEmail.ID, users_enums.UserStatusActive); err != nil {
-				return nil, fmt.Errorf("failed to activate user: %w", err)
+				return nil, false, fmt.Errorf("failed to activate user: %w", err)
 			}
 
 			if err := s.userRepository.UpdateUserInfo(userByEmail.ID, &name, nil); err != nil {
-				return nil, fmt.Errorf("failed to update name: %w", err)
+				return nil, false, fmt.Errorf("failed to update name: %w", err)
 			}
 		}
 
 		oauthColumn := "github_oauth_id"
-		if provider == "google" {
+		switch provider {
+		case "google":
 			oauthColumn = "google_oauth_id"
+		case "oidc":
+			oauthColumn = "oidc_subject"
 		}
 
 		if err := s.userRepository.LinkOAuthID(userByEmail.ID, oauthColumn, oauthID); err != nil {
-			return nil, fmt.Errorf("failed to link OAuth ID: %w", err)
+			return nil, false, fmt.Errorf("failed to link OAuth ID: %w", err)
 		}
 
 		user, err := s.userRepository.GetUserByID(userByEmail.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get updated user: %w", err)
-		}
-
-		tokenResponse, err := s.GenerateAccessToken(user)
-		if err != nil {
-			return nil, err
+			return nil, false, fmt.Errorf("failed to get updated user: %w", err)
 		}
 
 		if s.auditLogWriter != nil {
-			s.auditLogWriter.WriteAuditLog(
-				fmt.Sprintf("%s OAuth linked to existing account", provider),
-				&user.ID,
-				nil,
-			)
+			s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionLinkAccount,
+				ResourceType: audit_logs_events.AuditResourceUser,
+				ResourceID:   &user.ID,
+				Message:      fmt.Sprintf("%s OAuth linked to existing account", provider),
+				UserID:       &user.ID,
+			}, client))
 		}
 
-		return &users_dto.OAuthCallbackResponseDTO{
-			UserID:    tokenResponse.UserID,
-			Email:     user.Email,
-			Token:     tokenResponse.Token,
-			IsNewUser: false,
-		}, nil
+		return user, false, nil
 	}
 
 	settings, err := s.settingsService.GetSettings()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get settings: %w", err)
+		return nil, false, fmt.Errorf("failed to get settings: %w", err)
 	}
 
-	if !settings.IsAllowExternalRegistrations {
-		return nil, errors.New("external registration is disabled")
+	if !settings.IsAllowExternalRegistrations && !isProvisioningAllowed {
+		return nil, false, errors.New("external registration is disabled")
 	}
 
 	var githubOAuthID *string
 	var googleOAuthID *string
-	if provider == "github" {
+	var oidcSubject *string
+	switch provider {
+	case "github":
 		githubOAuthID = &oauthID
-	} else {
+	case "google":
 		googleOAuthID = &oauthID
+	default:
+		oidcSubject = &oauthID
 	}
 
 	newUser := &users_models.User{
@@ -705,31 +1011,72 @@ func (s *UserService) getOrCreateUserFromOAuth(
 		Status:               users_enums.UserStatusActive,
 		GitHubOAuthID:        githubOAuthID,
 		GoogleOAuthID:        googleOAuthID,
+		OIDCSubject:          oidcSubject,
 		CreatedAt:            time.Now().UTC(),
 	}
 
 	if err := s.userRepository.CreateUser(newUser); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, false, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if s.auditLogWriter != nil {
+		s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionSignUp,
+			ResourceType: audit_logs_events.AuditResourceUser,
+			ResourceID:   &newUser.ID,
+			Message:      fmt.Sprintf("User registered via %s OAuth: %s", provider, email),
+			UserID:       &newUser.ID,
+		}, client))
 	}
 
-	tokenResponse, err := s.GenerateAccessToken(newUser)
+	return newUser, true, nil
+}
+
+// signInOAuthUser applies the same 2FA requirement as sign in with
+// password, so the provider does not replace the second factor
+func (s *UserService) signInOAuthUser(
+	user *users_models.User,
+	provider string,
+	client *users_dto.ClientInfoDTO,
+	isNewUser bool,
+) (*users_dto.OAuthCallbackResponseDTO, error) {
+	isTwoFactorRequired, err := s.isTwoFactorRequired(user)
 	if err != nil {
 		return nil, err
 	}
 
-	if s.auditLogWriter != nil {
-		s.auditLogWriter.WriteAuditLog(
-			fmt.Sprintf("User registered via %s OAuth: %s", provider, email),
-			&newUser.ID,
-			nil,
-		)
+	if user.IsTwoFactorEnabled || isTwoFactorRequired {
+		twoFactorResponse, err := s.startTwoFactorSignIn(user)
+		if err != nil {
+			return nil, err
+		}
+
+		return &users_dto.OAuthCallbackResponseDTO{
+			UserID:                   user.ID,
+			Email:                    user.Email,
+			IsNewUser:                isNewUser,
+			IsTwoFactorRequired:      true,
+			IsTwoFactorSetupRequired: twoFactorResponse.IsTwoFactorSetupRequired,
+			TwoFactorToken:           twoFactorResponse.TwoFactorToken,
+		}, nil
+	}
+
+	tokenResponse, err := s.GenerateAccessToken(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditLogWriter != nil && !isNewUser {
+		s.writeSignInAuditLog(user, client, fmt.Sprintf("User signed in via %s", provider))
 	}
 
 	return &users_dto.OAuthCallbackResponseDTO{
-		UserID:    tokenResponse.UserID,
-		Email:     newUser.Email,
-		Token:     tokenResponse.Token,
-		IsNewUser: true,
+		UserID:       tokenResponse.UserID,
+		Email:        user.Email,
+		Token:        tokenResponse.Token,
+		RefreshToken: tokenResponse.RefreshToken,
+		ExpiresAt:    tokenResponse.ExpiresAt,
+		IsNewUser:    isNewUser,
 	}, nil
 }
 
@@ -788,3 +1135,167 @@ func (s *UserService) fetchGitHubPrimaryEmail(
 
 	return "", errors.New("github account has no accessible email")
 }
+
+func (s *UserService) issueAccessToken(
+	user *users_models.User,
+	session *users_models.UserSession,
+	refreshToken string,
+) (*users_dto.SignInResponseDTO, error) {
+	secretKey, err := s.secretKeyService.GetSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
+
+	expiresAt := time.Now().UTC().Add(accessTokenLifetime)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":                  user.ID.String(),
+		"sid":                  session.ID.String(),
+		"exp":                  expiresAt.Unix(),
+		"iat":                  time.Now().UTC().Unix(),
+		"role":                 string(user.Role),
+		"passwordCreationTime": user.PasswordCreationTime.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(secretKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &users_dto.SignInResponseDTO{
+		UserID:       user.ID,
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// getActiveSessionID checks the session of access token on each request, so
+// revoked sessions stop working without waiting for the token expiration.
+// Tokens issued before sessions were introduced have no session, they are
+// accepted only within the access token lifetime or the legacy grace period
+func (s *UserService) getActiveSessionID(
+	claims jwt.MapClaims,
+	user *users_models.User,
+	issuedAt time.Time,
+) (*uuid.UUID, error) {
+	sessionIDStr, ok := claims["sid"].(string)
+	if !ok {
+		if !isSessionlessTokenAccepted(issuedAt, time.Now().UTC()) {
+			return nil, errors.New("session has expired, please sign in again")
+		}
+
+		return nil, nil
+	}
+
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return nil, errors.New("invalid token claims")
+	}
+
+	session, err := s.userSessionRepository.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session == nil || session.UserID != user.ID || session.RevokedAt != nil {
+		return nil, errors.New("session has been revoked, please sign in again")
+	}
+
+	return &session.ID, nil
+}
+
+func isSessionlessTokenAccepted(issuedAt time.Time, now time.Time) bool {
+	if now.Before(issuedAt.Add(accessTokenLifetime)) {
+		return true
+	}
+
+	return issuedAt.Before(legacyTokensIssuedBefore) &&
+		now.Before(legacyTokensIssuedBefore.Add(legacyTokenGracePeriod))
+}
+
+func (s *UserService) revokeSessionOnRefreshTokenReuse(
+	hashedRefreshToken string,
+	now time.Time,
+) error {
+	session, err := s.userSessionRepository.GetSessionByPreviousRefreshToken(hashedRefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session == nil || session.RevokedAt != nil {
+		return nil
+	}
+
+	if now.Sub(session.LastSeenAt) < refreshTokenReuseGracePeriod {
+		return nil
+	}
+
+	if err := s.userSessionRepository.RevokeSession(session.ID, now); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.auditLogWriter.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRevokeSession,
+		ResourceType: audit_logs_events.AuditResourceSession,
+		ResourceID:   &session.ID,
+		Message: fmt.Sprintf(
+			"Session revoked due to reuse of refresh token: %s (%s)",
+			session.Device,
+			session.IPAddress,
+		),
+		UserID: &session.UserID,
+	})
+
+	return nil
+}
+
+func (s *UserService) writeSignInAuditLog(
+	user *users_models.User,
+	client *users_dto.ClientInfoDTO,
+	message string,
+) {
+	s.auditLogWriter.WriteAuditEvent(withAuditClient(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionSignIn,
+		ResourceType: audit_logs_events.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Message:      message,
+		UserID:       &user.ID,
+	}, client))
+}
+
+// withAuditClient sets source of the request made before authentication
+func withAuditClient(
+	event *audit_logs_events.AuditEvent,
+	client *users_dto.ClientInfoDTO,
+) *audit_logs_events.AuditEvent {
+	if client != nil {
+		event.SourceIP = client.IPAddress
+		event.UserAgent = client.UserAgent
+	}
+
+	return event
+}
+
+func setSessionClient(session *users_models.UserSession, client *users_dto.ClientInfoDTO) {
+	if client == nil {
+		return
+	}
+
+	session.Device = client.UserAgent
+	session.IPAddress = client.IPAddress
+}
+
+func generateRefreshToken() (string, error) {
+	randomBytes := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(hash[:])
+}
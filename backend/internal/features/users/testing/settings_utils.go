@@ -1,6 +1,7 @@
 package users_testing
 
 import (
+	users_enums "postgresus-backend/internal/features/users/enums"
 	users_repositories "postgresus-backend/internal/features/users/repositories"
 )
 
@@ -28,6 +29,21 @@ func DisableMemberWorkspaceCreation() {
 	updateUsersSetting("is_member_allowed_to_create_workspaces", false)
 }
 
+func SetTwoFactorRequirement(requirement users_enums.TwoFactorRequirement) {
+	repository := &users_repositories.UsersSettingsRepository{}
+	settings, err := repository.GetSettings()
+	if err != nil {
+		panic(err)
+	}
+
+	settings.TwoFactorRequirement = requirement
+
+	err = repository.UpdateSettings(settings)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func ResetSettingsToDefaults() {
 	repository := &users_repositories.UsersSettingsRepository{}
 	settings, err := repository.GetSettings()
@@ -38,6 +54,7 @@ func ResetSettingsToDefaults() {
 	settings.IsAllowExternalRegistrations = true
 	settings.IsAllowMemberInvitations = true
 	settings.IsMemberAllowedToCreateWorkspaces = true
+	settings.TwoFactorRequirement = users_enums.TwoFactorRequirementNone
 
 	err = repository.UpdateSettings(settings)
 	if err != nil {
@@ -36,7 +36,7 @@ func CreateTestUser(role users_enums.UserRole) *users_dto.SignInResponseDTO {
 		panic(err)
 	}
 
-	response, err := users_services.GetUserService().GenerateAccessToken(user)
+	response, err := users_services.GetUserService().GenerateAccessToken(user, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -55,7 +55,7 @@ func ReacreateInitAdminAndGetAccess() *users_dto.SignInResponseDTO {
 		panic(err)
 	}
 
-	response, err := users_services.GetUserService().GenerateAccessToken(user)
+	response, err := users_services.GetUserService().GenerateAccessToken(user, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,457 @@
+package workspaces_controllers
+
+import (
+	"net/http"
+	"strings"
+
+	users_middleware "postgresus-backend/internal/features/users/middleware"
+	workspaces_dto "postgresus-backend/internal/features/workspaces/dto"
+	workspaces_services "postgresus-backend/internal/features/workspaces/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CustomRoleController struct {
+	customRoleService *workspaces_services.CustomRoleService
+}
+
+func (c *CustomRoleController) RegisterRoutes(router *gin.RouterGroup) {
+	workspaceRoutes := router.Group("/workspaces/memberships/:id")
+
+	workspaceRoutes.GET("/roles", c.ListRoles)
+	workspaceRoutes.POST("/roles", c.CreateRole)
+	workspaceRoutes.PUT("/roles/:roleId", c.UpdateRole)
+	workspaceRoutes.DELETE("/roles/:roleId", c.DeleteRole)
+	workspaceRoutes.PUT("/members/:userId/custom-role", c.SetMemberCustomRole)
+	workspaceRoutes.GET("/databases/:databaseId/roles", c.ListDatabaseRoles)
+	workspaceRoutes.PUT("/databases/:databaseId/roles/:userId", c.AssignDatabaseRole)
+	workspaceRoutes.DELETE("/databases/:databaseId/roles/:userId", c.RemoveDatabaseRole)
+	workspaceRoutes.GET("/permissions", c.GetPermissions)
+}
+
+// ListRoles
+// @Summary List custom roles
+// @Description Get custom roles of the workspace and permissions they can contain
+// @Tags workspace-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Success 200 {object} workspaces_dto.ListCustomRolesResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/roles [get]
+func (c *CustomRoleController) ListRoles(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	response, err := c.customRoleService.GetRoles(workspaceID, user)
+	if err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CreateRole
+// @Summary Create custom role
+// @Description Create a named set of permissions which can be assigned to members and viewers
+// @Tags workspace-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param request body workspaces_dto.SaveCustomRoleRequestDTO true "Role data"
+// @Success 200 {object} workspaces_dto.CustomRoleResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/roles [post]
+func (c *CustomRoleController) CreateRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var request workspaces_dto.SaveCustomRoleRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.customRoleService.CreateRole(workspaceID, &request, user)
+	if err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpdateRole
+// @Summary Update custom role
+// @Description Change name and permissions of the custom role, members with the role get new permissions immediately
+// @Tags workspace-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param roleId path string true "Role ID"
+// @Param request body workspaces_dto.SaveCustomRoleRequestDTO true "Role data"
+// @Success 200 {object} workspaces_dto.CustomRoleResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/roles/{roleId} [put]
+func (c *CustomRoleController) UpdateRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("roleId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	var request workspaces_dto.SaveCustomRoleRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := c.customRoleService.UpdateRole(workspaceID, roleID, &request, user)
+	if err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// DeleteRole
+// @Summary Delete custom role
+// @Description Delete the custom role which is not assigned to anyone
+// @Tags workspace-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param roleId path string true "Role ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/roles/{roleId} [delete]
+func (c *CustomRoleController) DeleteRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("roleId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	if err := c.customRoleService.DeleteRole(workspaceID, roleID, user); err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// SetMemberCustomRole
+// @Summary Set custom role of member
+// @Description Replace permissions of the member or viewer in the whole workspace with the custom role. Empty role ID restores permissions of the built-in role
+// @Tags workspace-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param userId path string true "User ID"
+// @Param request body workspaces_dto.SetMemberCustomRoleRequestDTO true "Custom role data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/members/{userId}/custom-role [put]
+func (c *CustomRoleController) SetMemberCustomRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	memberUserID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request workspaces_dto.SetMemberCustomRoleRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.customRoleService.SetMemberCustomRole(
+		workspaceID,
+		memberUserID,
+		&request,
+		user,
+	); err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully"})
+}
+
+// ListDatabaseRoles
+// @Summary List database roles
+// @Description Get members which have custom role for the database
+// @Tags workspace-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param databaseId path string true "Database ID"
+// @Success 200 {object} workspaces_dto.ListDatabaseRoleAssignmentsResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/databases/{databaseId}/roles [get]
+func (c *CustomRoleController) ListDatabaseRoles(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	databaseID, err := uuid.Parse(ctx.Param("databaseId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid database ID"})
+		return
+	}
+
+	response, err := c.customRoleService.GetDatabaseRoles(workspaceID, databaseID, user)
+	if err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// AssignDatabaseRole
+// @Summary Assign database role
+// @Description Give the member or viewer permissions of the custom role for the single database instead of permissions in the workspace
+// @Tags workspace-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param databaseId path string true "Database ID"
+// @Param userId path string true "User ID"
+// @Param request body workspaces_dto.AssignDatabaseRoleRequestDTO true "Custom role data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/databases/{databaseId}/roles/{userId} [put]
+func (c *CustomRoleController) AssignDatabaseRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	databaseID, err := uuid.Parse(ctx.Param("databaseId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid database ID"})
+		return
+	}
+
+	memberUserID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request workspaces_dto.AssignDatabaseRoleRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.customRoleService.AssignDatabaseRole(
+		workspaceID,
+		databaseID,
+		memberUserID,
+		&request,
+		user,
+	); err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Database role assigned successfully"})
+}
+
+// RemoveDatabaseRole
+// @Summary Remove database role
+// @Description Remove custom role of the member for the database, so permissions in the workspace apply
+// @Tags workspace-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param databaseId path string true "Database ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/memberships/{id}/databases/{databaseId}/roles/{userId} [delete]
+func (c *CustomRoleController) RemoveDatabaseRole(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	databaseID, err := uuid.Parse(ctx.Param("databaseId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid database ID"})
+		return
+	}
+
+	memberUserID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.customRoleService.RemoveDatabaseRole(
+		workspaceID,
+		databaseID,
+		memberUserID,
+		user,
+	); err != nil {
+		respondWithRoleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Database role removed successfully"})
+}
+
+// GetPermissions
+// @Summary Get my permissions
+// @Description Get permissions of the current user in the workspace or for the single database of the workspace
+// @Tags workspace-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param databaseId query string false "Database ID"
+// @Success 200 {object} workspaces_dto.PermissionsResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /workspaces/memberships/{id}/permissions [get]
+func (c *CustomRoleController) GetPermissions(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var databaseID *uuid.UUID
+	if databaseIDStr := ctx.Query("databaseId"); databaseIDStr != "" {
+		id, err := uuid.Parse(databaseIDStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid database ID"})
+			return
+		}
+
+		databaseID = &id
+	}
+
+	response, err := c.customRoleService.GetPermissions(workspaceID, databaseID, user)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func respondWithRoleError(ctx *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "insufficient permissions") {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,230 @@
+package workspaces_controllers
+
+import (
+	"net/http"
+	"testing"
+
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_testing "postgresus-backend/internal/features/users/testing"
+	workspaces_dto "postgresus-backend/internal/features/workspaces/dto"
+	workspaces_testing "postgresus-backend/internal/features/workspaces/testing"
+	test_utils "postgresus-backend/internal/util/testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateCustomRole_PermissionsEnforced(t *testing.T) {
+	tests := []struct {
+		name               string
+		workspaceRole      users_enums.WorkspaceRole
+		expectedStatusCode int
+	}{
+		{
+			name:               "workspace admin can create roles",
+			workspaceRole:      users_enums.WorkspaceRoleAdmin,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "workspace member cannot create roles",
+			workspaceRole:      users_enums.WorkspaceRoleMember,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "workspace viewer cannot create roles",
+			workspaceRole:      users_enums.WorkspaceRoleViewer,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := createCustomRoleTestRouter()
+			owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+			workspace, _ := workspaces_testing.CreateTestWorkspaceViaAPI(
+				"Test Workspace",
+				owner,
+				router,
+			)
+
+			member := users_testing.CreateTestUser(users_enums.UserRoleMember)
+			workspaces_testing.AddMemberToWorkspaceViaOwner(
+				workspace,
+				member,
+				tt.workspaceRole,
+				router,
+			)
+
+			request := workspaces_dto.SaveCustomRoleRequestDTO{
+				Name: "Contractor",
+				Permissions: []users_enums.WorkspacePermission{
+					users_enums.WorkspacePermissionView,
+				},
+			}
+
+			test_utils.MakePostRequest(
+				t,
+				router,
+				"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles",
+				"Bearer "+member.Token,
+				request,
+				tt.expectedStatusCode,
+			)
+		})
+	}
+}
+
+func Test_CreateCustomRole_WithRoleOnlyPermission_ReturnsBadRequest(t *testing.T) {
+	router := createCustomRoleTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace, _ := workspaces_testing.CreateTestWorkspaceViaAPI("Test Workspace", owner, router)
+
+	request := workspaces_dto.SaveCustomRoleRequestDTO{
+		Name: "Almost admin",
+		Permissions: []users_enums.WorkspacePermission{
+			users_enums.WorkspacePermissionManageMembers,
+		},
+	}
+
+	resp := test_utils.MakePostRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles",
+		"Bearer "+owner.Token,
+		request,
+		http.StatusBadRequest,
+	)
+
+	assert.Contains(t, string(resp.Body), "unknown permission")
+}
+
+func Test_SetMemberCustomRole_PermissionsOfMemberReplacedByRole(t *testing.T) {
+	router := createCustomRoleTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace, _ := workspaces_testing.CreateTestWorkspaceViaAPI("Test Workspace", owner, router)
+
+	member := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspaces_testing.AddMemberToWorkspaceViaOwner(
+		workspace,
+		member,
+		users_enums.WorkspaceRoleMember,
+		router,
+	)
+
+	var role workspaces_dto.CustomRoleResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles",
+		"Bearer "+owner.Token,
+		workspaces_dto.SaveCustomRoleRequestDTO{
+			Name: "Downloader",
+			Permissions: []users_enums.WorkspacePermission{
+				users_enums.WorkspacePermissionView,
+				users_enums.WorkspacePermissionDownload,
+			},
+		},
+		http.StatusOK,
+		&role,
+	)
+
+	test_utils.MakePutRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+
+			"/members/"+member.UserID.String()+"/custom-role",
+		"Bearer "+owner.Token,
+		workspaces_dto.SetMemberCustomRoleRequestDTO{CustomRoleID: &role.ID},
+		http.StatusOK,
+	)
+
+	var permissions workspaces_dto.PermissionsResponseDTO
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/permissions",
+		"Bearer "+member.Token,
+		http.StatusOK,
+		&permissions,
+	)
+
+	assert.ElementsMatch(t, []users_enums.WorkspacePermission{
+		users_enums.WorkspacePermissionAccess,
+		users_enums.WorkspacePermissionView,
+		users_enums.WorkspacePermissionDownload,
+	}, permissions.Permissions)
+
+	resp := test_utils.MakeDeleteRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles/"+role.ID.String(),
+		"Bearer "+owner.Token,
+		http.StatusBadRequest,
+	)
+	assert.Contains(t, string(resp.Body), "role is assigned to members")
+
+	test_utils.MakePutRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+
+			"/members/"+member.UserID.String()+"/custom-role",
+		"Bearer "+owner.Token,
+		workspaces_dto.SetMemberCustomRoleRequestDTO{CustomRoleID: nil},
+		http.StatusOK,
+	)
+
+	test_utils.MakeGetRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/permissions",
+		"Bearer "+member.Token,
+		http.StatusOK,
+		&permissions,
+	)
+
+	assert.Contains(t, permissions.Permissions, users_enums.WorkspacePermissionBackup)
+	assert.Contains(t, permissions.Permissions, users_enums.WorkspacePermissionManageDatabases)
+}
+
+func Test_SetMemberCustomRole_WhenMemberIsAdmin_ReturnsBadRequest(t *testing.T) {
+	router := createCustomRoleTestRouter()
+	owner := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspace, _ := workspaces_testing.CreateTestWorkspaceViaAPI("Test Workspace", owner, router)
+
+	admin := users_testing.CreateTestUser(users_enums.UserRoleMember)
+	workspaces_testing.AddMemberToWorkspaceViaOwner(
+		workspace,
+		admin,
+		users_enums.WorkspaceRoleAdmin,
+		router,
+	)
+
+	var role workspaces_dto.CustomRoleResponseDTO
+	test_utils.MakePostRequestAndUnmarshal(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+"/roles",
+		"Bearer "+owner.Token,
+		workspaces_dto.SaveCustomRoleRequestDTO{Name: "Viewer only"},
+		http.StatusOK,
+		&role,
+	)
+
+	test_utils.MakePutRequest(
+		t,
+		router,
+		"/api/v1/workspaces/memberships/"+workspace.ID.String()+
+			"/members/"+admin.UserID.String()+"/custom-role",
+		"Bearer "+owner.Token,
+		workspaces_dto.SetMemberCustomRoleRequestDTO{CustomRoleID: &role.ID},
+		http.StatusBadRequest,
+	)
+}
+
+func createCustomRoleTestRouter() *gin.Engine {
+	return workspaces_testing.CreateTestRouter(
+		GetWorkspaceController(),
+		GetMembershipController(),
+		GetCustomRoleController(),
+	)
+}
@@ -12,6 +12,10 @@ var membershipController = &MembershipController{
 	workspaces_services.GetMembershipService(),
 }
 
+var customRoleController = &CustomRoleController{
+	workspaces_services.GetCustomRoleService(),
+}
+
 func GetWorkspaceController() *WorkspaceController {
 	return workspaceController
 }
@@ -19,3 +23,7 @@ func GetWorkspaceController() *WorkspaceController {
 func GetMembershipController() *MembershipController {
 	return membershipController
 }
+
+func GetCustomRoleController() *CustomRoleController {
+	return customRoleController
+}
@@ -26,6 +26,7 @@ func (c *WorkspaceController) RegisterRoutes(router *gin.RouterGroup) {
 	workspaceRoutes.PUT("/:id", c.UpdateWorkspace)
 	workspaceRoutes.DELETE("/:id", c.DeleteWorkspace)
 	workspaceRoutes.GET("/:id/audit-logs", c.GetWorkspaceAuditLogs)
+	workspaceRoutes.GET("/:id/audit-logs/export", c.ExportWorkspaceAuditLogs)
 }
 
 // CreateWorkspace
@@ -227,6 +228,9 @@ func (c *WorkspaceController) DeleteWorkspace(ctx *gin.Context) {
 // @Param limit query int false "Limit number of results" default(100)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Param beforeDate query string false "Filter logs created before this date (RFC3339 format)" format(date-time)
+// @Param action query string false "Filter by action, e.g. UPDATE"
+// @Param resourceType query string false "Filter by resource type, e.g. DATABASE"
+// @Param resourceId query string false "Filter by resource ID"
 // @Success 200 {object} audit_logs.GetAuditLogsResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -264,3 +268,60 @@ func (c *WorkspaceController) GetWorkspaceAuditLogs(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// ExportWorkspaceAuditLogs
+// @Summary Export workspace audit logs
+// @Description Download audit logs of the workspace as CSV or JSON, filters are the same as for listing
+// @Tags workspaces
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workspace ID"
+// @Param format query string false "csv (default) or json"
+// @Param limit query int false "Limit number of results" default(100000)
+// @Param beforeDate query string false "Filter logs created before this date (RFC3339 format)" format(date-time)
+// @Param action query string false "Filter by action, e.g. UPDATE"
+// @Param resourceType query string false "Filter by resource type, e.g. DATABASE"
+// @Param resourceId query string false "Filter by resource ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /workspaces/{id}/audit-logs/export [get]
+func (c *WorkspaceController) ExportWorkspaceAuditLogs(ctx *gin.Context) {
+	user, ok := users_middleware.GetUserFromContext(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	workspaceID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	format, err := audit_logs.ParseAuditLogExportFormat(ctx.Query("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := &audit_logs.GetAuditLogsRequest{}
+	if err := ctx.ShouldBindQuery(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	auditLogs, err := c.workspaceService.ExportWorkspaceAuditLogs(workspaceID, user, request)
+	if err != nil {
+		if err.Error() == "insufficient permissions to view workspace audit logs" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit_logs.WriteAuditLogsExportResponse(ctx, "workspace-audit-logs", format, auditLogs)
+}
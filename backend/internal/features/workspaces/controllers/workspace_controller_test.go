@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	users_dto "postgresus-backend/internal/features/users/dto"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
@@ -698,9 +699,5 @@ func getUserFromSignInResponse(response *users_dto.SignInResponseDTO) *users_mod
 
 type AuditLogWriterStub struct{}
 
-func (a *AuditLogWriterStub) WriteAuditLog(
-	message string,
-	userID *uuid.UUID,
-	workspaceID *uuid.UUID,
-) {
+func (a *AuditLogWriterStub) WriteAuditEvent(event *audit_logs_events.AuditEvent) {
 }
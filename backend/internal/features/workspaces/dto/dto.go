@@ -58,8 +58,53 @@ type WorkspaceMemberResponseDTO struct {
 	Name      string                    `json:"name"`  // Populated from user join
 	Role      users_enums.WorkspaceRole `json:"role"`
 	CreatedAt time.Time                 `json:"createdAt"`
+
+	CustomRoleID *uuid.UUID `json:"customRoleId"`
 }
 
 type GetMembersResponseDTO struct {
 	Members []WorkspaceMemberResponseDTO `json:"members"`
 }
+
+// Custom role DTOs
+type SaveCustomRoleRequestDTO struct {
+	Name        string                            `json:"name"        binding:"required,min=1,max=255"`
+	Permissions []users_enums.WorkspacePermission `json:"permissions"`
+}
+
+type CustomRoleResponseDTO struct {
+	ID          uuid.UUID                         `json:"id"`
+	Name        string                            `json:"name"`
+	Permissions []users_enums.WorkspacePermission `json:"permissions"`
+	CreatedAt   time.Time                         `json:"createdAt"`
+}
+
+type ListCustomRolesResponseDTO struct {
+	Roles []CustomRoleResponseDTO `json:"roles"`
+
+	// AssignablePermissions lists permissions which roles can contain
+	AssignablePermissions []users_enums.WorkspacePermission `json:"assignablePermissions"`
+}
+
+// SetMemberCustomRoleRequestDTO removes the custom role when ID is empty
+type SetMemberCustomRoleRequestDTO struct {
+	CustomRoleID *uuid.UUID `json:"customRoleId"`
+}
+
+type AssignDatabaseRoleRequestDTO struct {
+	CustomRoleID uuid.UUID `json:"customRoleId" binding:"required"`
+}
+
+type DatabaseRoleAssignmentResponseDTO struct {
+	UserID       uuid.UUID `json:"userId"`
+	CustomRoleID uuid.UUID `json:"customRoleId"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type ListDatabaseRoleAssignmentsResponseDTO struct {
+	Assignments []DatabaseRoleAssignmentResponseDTO `json:"assignments"`
+}
+
+type PermissionsResponseDTO struct {
+	Permissions []users_enums.WorkspacePermission `json:"permissions"`
+}
@@ -0,0 +1,22 @@
+package workspaces_models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DatabaseRoleAssignment replaces permissions of the workspace member for a
+// single database
+type DatabaseRoleAssignment struct {
+	ID           uuid.UUID `json:"id"           gorm:"column:id;primaryKey;type:uuid"`
+	WorkspaceID  uuid.UUID `json:"workspaceId"  gorm:"column:workspace_id;type:uuid;not null"`
+	DatabaseID   uuid.UUID `json:"databaseId"   gorm:"column:database_id;type:uuid;not null"`
+	UserID       uuid.UUID `json:"userId"       gorm:"column:user_id;type:uuid;not null"`
+	CustomRoleID uuid.UUID `json:"customRoleId" gorm:"column:custom_role_id;type:uuid;not null"`
+	CreatedAt    time.Time `json:"createdAt"    gorm:"column:created_at;not null"`
+}
+
+func (DatabaseRoleAssignment) TableName() string {
+	return "database_role_assignments"
+}
@@ -0,0 +1,76 @@
+package workspaces_models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	users_enums "postgresus-backend/internal/features/users/enums"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkspaceCustomRole is a named set of permissions, it is assigned to the
+// member for the whole workspace or for a single database
+type WorkspaceCustomRole struct {
+	ID          uuid.UUID `json:"id"          gorm:"column:id;primaryKey;type:uuid"`
+	WorkspaceID uuid.UUID `json:"workspaceId" gorm:"column:workspace_id;type:uuid;not null"`
+	Name        string    `json:"name"        gorm:"column:name;type:text;not null"`
+
+	Permissions       []users_enums.WorkspacePermission `json:"permissions" gorm:"-"`
+	PermissionsString string                            `json:"-"           gorm:"column:permissions;type:text;not null"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at;not null"`
+}
+
+func (WorkspaceCustomRole) TableName() string {
+	return "workspace_custom_roles"
+}
+
+func (r *WorkspaceCustomRole) BeforeSave(tx *gorm.DB) error {
+	permissions := make([]string, len(r.Permissions))
+	for i, permission := range r.Permissions {
+		permissions[i] = string(permission)
+	}
+
+	r.PermissionsString = strings.Join(permissions, ",")
+
+	return nil
+}
+
+func (r *WorkspaceCustomRole) AfterFind(tx *gorm.DB) error {
+	r.Permissions = []users_enums.WorkspacePermission{}
+
+	if r.PermissionsString == "" {
+		return nil
+	}
+
+	for permission := range strings.SplitSeq(r.PermissionsString, ",") {
+		r.Permissions = append(r.Permissions, users_enums.WorkspacePermission(permission))
+	}
+
+	return nil
+}
+
+// Validate allows empty permissions, such role gives access to the
+// workspace only and is combined with roles for single databases
+func (r *WorkspaceCustomRole) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	for _, permission := range r.Permissions {
+		if !permission.IsAssignable() {
+			return fmt.Errorf("unknown permission: %s", permission)
+		}
+	}
+
+	if len(slices.Compact(slices.Sorted(slices.Values(r.Permissions)))) != len(r.Permissions) {
+		return errors.New("permissions must not repeat")
+	}
+
+	return nil
+}
@@ -14,6 +14,9 @@ type WorkspaceMembership struct {
 	WorkspaceID uuid.UUID                 `json:"workspaceId" gorm:"column:workspace_id"`
 	Role        users_enums.WorkspaceRole `json:"role"        gorm:"column:role"`
 	CreatedAt   time.Time                 `json:"createdAt"   gorm:"column:created_at"`
+
+	// custom role replaces permissions of member and viewer roles
+	CustomRoleID *uuid.UUID `json:"customRoleId" gorm:"column:custom_role_id"`
 }
 
 func (WorkspaceMembership) TableName() string {
@@ -0,0 +1,84 @@
+package workspaces_repositories
+
+import (
+	"errors"
+	"time"
+
+	workspaces_models "postgresus-backend/internal/features/workspaces/models"
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CustomRoleRepository struct{}
+
+func (r *CustomRoleRepository) Save(role *workspaces_models.WorkspaceCustomRole) error {
+	if role.ID == uuid.Nil {
+		role.ID = uuid.New()
+	}
+
+	if role.CreatedAt.IsZero() {
+		role.CreatedAt = time.Now().UTC()
+	}
+
+	return storage.GetDb().Save(role).Error
+}
+
+func (r *CustomRoleRepository) FindByID(
+	roleID uuid.UUID,
+) (*workspaces_models.WorkspaceCustomRole, error) {
+	var role workspaces_models.WorkspaceCustomRole
+
+	if err := storage.GetDb().Where("id = ?", roleID).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *CustomRoleRepository) FindByWorkspaceID(
+	workspaceID uuid.UUID,
+) ([]*workspaces_models.WorkspaceCustomRole, error) {
+	var roles []*workspaces_models.WorkspaceCustomRole
+
+	if err := storage.GetDb().
+		Where("workspace_id = ?", workspaceID).
+		Order("name ASC").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// IsRoleInUse checks both workspace memberships and database assignments
+func (r *CustomRoleRepository) IsRoleInUse(roleID uuid.UUID) (bool, error) {
+	var membershipsCount int64
+	if err := storage.GetDb().
+		Model(&workspaces_models.WorkspaceMembership{}).
+		Where("custom_role_id = ?", roleID).
+		Count(&membershipsCount).Error; err != nil {
+		return false, err
+	}
+
+	var assignmentsCount int64
+	if err := storage.GetDb().
+		Model(&workspaces_models.DatabaseRoleAssignment{}).
+		Where("custom_role_id = ?", roleID).
+		Count(&assignmentsCount).Error; err != nil {
+		return false, err
+	}
+
+	return membershipsCount+assignmentsCount > 0, nil
+}
+
+func (r *CustomRoleRepository) Delete(roleID uuid.UUID) error {
+	return storage.GetDb().
+		Where("id = ?", roleID).
+		Delete(&workspaces_models.WorkspaceCustomRole{}).Error
+}
@@ -0,0 +1,98 @@
+package workspaces_repositories
+
+import (
+	"errors"
+	"time"
+
+	workspaces_models "postgresus-backend/internal/features/workspaces/models"
+	"postgresus-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DatabaseRoleAssignmentRepository struct{}
+
+func (r *DatabaseRoleAssignmentRepository) Save(
+	assignment *workspaces_models.DatabaseRoleAssignment,
+) error {
+	if assignment.ID == uuid.Nil {
+		assignment.ID = uuid.New()
+	}
+
+	if assignment.CreatedAt.IsZero() {
+		assignment.CreatedAt = time.Now().UTC()
+	}
+
+	return storage.GetDb().Save(assignment).Error
+}
+
+// FindByDatabaseAndUser returns nil when the user has no role for the
+// database
+func (r *DatabaseRoleAssignmentRepository) FindByDatabaseAndUser(
+	databaseID uuid.UUID,
+	userID uuid.UUID,
+) (*workspaces_models.DatabaseRoleAssignment, error) {
+	var assignment workspaces_models.DatabaseRoleAssignment
+
+	if err := storage.GetDb().
+		Where("database_id = ? AND user_id = ?", databaseID, userID).
+		First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+func (r *DatabaseRoleAssignmentRepository) FindByDatabaseID(
+	databaseID uuid.UUID,
+) ([]*workspaces_models.DatabaseRoleAssignment, error) {
+	var assignments []*workspaces_models.DatabaseRoleAssignment
+
+	if err := storage.GetDb().
+		Where("database_id = ?", databaseID).
+		Order("created_at ASC").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+func (r *DatabaseRoleAssignmentRepository) Delete(databaseID uuid.UUID, userID uuid.UUID) error {
+	return storage.GetDb().
+		Where("database_id = ? AND user_id = ?", databaseID, userID).
+		Delete(&workspaces_models.DatabaseRoleAssignment{}).Error
+}
+
+// DeleteByUserAndWorkspace is called when the user leaves the workspace
+func (r *DatabaseRoleAssignmentRepository) DeleteByUserAndWorkspace(
+	userID uuid.UUID,
+	workspaceID uuid.UUID,
+) error {
+	return storage.GetDb().
+		Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Delete(&workspaces_models.DatabaseRoleAssignment{}).Error
+}
+
+// IsDatabaseInWorkspace reads the databases table directly, because the
+// databases feature depends on workspaces and cannot be imported here
+func (r *DatabaseRoleAssignmentRepository) IsDatabaseInWorkspace(
+	databaseID uuid.UUID,
+	workspaceID uuid.UUID,
+) (bool, error) {
+	var count int64
+
+	if err := storage.GetDb().
+		Table("databases").
+		Where("id = ? AND workspace_id = ?", databaseID, workspaceID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
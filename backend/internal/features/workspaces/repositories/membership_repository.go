@@ -50,7 +50,7 @@ func (r *MembershipRepository) GetWorkspaceMembers(
 
 	err := storage.GetDb().
 		Table("workspace_memberships wm").
-		Select("wm.id, wm.user_id, u.email, u.name, wm.role, wm.created_at").
+		Select("wm.id, wm.user_id, u.email, u.name, wm.role, wm.custom_role_id, wm.created_at").
 		Joins("JOIN users u ON wm.user_id = u.id").
 		Where("wm.workspace_id = ?", workspaceID).
 		Order("wm.created_at ASC").
@@ -94,6 +94,36 @@ func (r *MembershipRepository) GetUserWorkspaceRole(
 	return &membership.Role, nil
 }
 
+// GetUserMembership returns nil when the user is not a member
+func (r *MembershipRepository) GetUserMembership(
+	workspaceID, userID uuid.UUID,
+) (*workspaces_models.WorkspaceMembership, error) {
+	var membership workspaces_models.WorkspaceMembership
+	err := storage.GetDb().
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&membership).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &membership, nil
+}
+
+func (r *MembershipRepository) UpdateMemberCustomRole(
+	userID, workspaceID uuid.UUID,
+	customRoleID *uuid.UUID,
+) error {
+	return storage.GetDb().
+		Model(&workspaces_models.WorkspaceMembership{}).
+		Where("user_id = ? AND workspace_id = ?", userID, workspaceID).
+		Update("custom_role_id", customRoleID).Error
+}
+
 func (r *MembershipRepository) GetWorkspaceOwner(
 	workspaceID uuid.UUID,
 ) (*workspaces_models.WorkspaceMembership, error) {
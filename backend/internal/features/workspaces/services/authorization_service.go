@@ -0,0 +1,158 @@
+package workspaces_services
+
+import (
+	"fmt"
+	"slices"
+
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	workspaces_models "postgresus-backend/internal/features/workspaces/models"
+	workspaces_repositories "postgresus-backend/internal/features/workspaces/repositories"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizationService is the single place which decides what the user can
+// do in the workspace. Permissions come from the built-in role of the
+// member, from the custom role assigned for the workspace or from the
+// custom role assigned for the single database
+type AuthorizationService struct {
+	membershipRepository             *workspaces_repositories.MembershipRepository
+	customRoleRepository             *workspaces_repositories.CustomRoleRepository
+	databaseRoleAssignmentRepository *workspaces_repositories.DatabaseRoleAssignmentRepository
+}
+
+func (s *AuthorizationService) HasPermission(
+	workspaceID uuid.UUID,
+	user *users_models.User,
+	permission users_enums.WorkspacePermission,
+) (bool, error) {
+	permissions, err := s.GetWorkspacePermissions(workspaceID, user)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
+
+func (s *AuthorizationService) HasDatabasePermission(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+	user *users_models.User,
+	permission users_enums.WorkspacePermission,
+) (bool, error) {
+	permissions, err := s.GetDatabasePermissions(workspaceID, databaseID, user)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
+
+func (s *AuthorizationService) GetWorkspacePermissions(
+	workspaceID uuid.UUID,
+	user *users_models.User,
+) ([]users_enums.WorkspacePermission, error) {
+	permissions, _, err := s.getWorkspacePermissions(workspaceID, user)
+	return permissions, err
+}
+
+// GetDatabasePermissions applies the role assigned for the database over
+// permissions in the workspace, so it can both extend and narrow them
+func (s *AuthorizationService) GetDatabasePermissions(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+	user *users_models.User,
+) ([]users_enums.WorkspacePermission, error) {
+	permissions, membership, err := s.getWorkspacePermissions(workspaceID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if membership == nil || !isCustomRoleApplicable(membership.Role) {
+		return permissions, nil
+	}
+
+	assignment, err := s.databaseRoleAssignmentRepository.FindByDatabaseAndUser(
+		databaseID,
+		user.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database role: %w", err)
+	}
+
+	if assignment == nil || assignment.WorkspaceID != workspaceID {
+		return permissions, nil
+	}
+
+	return s.replaceWithCustomRole(permissions, assignment.CustomRoleID)
+}
+
+// getWorkspacePermissions also returns membership of the user, it is nil
+// for system admins and requests restricted by API key
+func (s *AuthorizationService) getWorkspacePermissions(
+	workspaceID uuid.UUID,
+	user *users_models.User,
+) ([]users_enums.WorkspacePermission, *workspaces_models.WorkspaceMembership, error) {
+	if user.IsRestrictedByAPIKey(workspaceID) {
+		return []users_enums.WorkspacePermission{}, nil, nil
+	}
+
+	if user.Role == users_enums.UserRoleAdmin {
+		return users_enums.GetWorkspaceRolePermissions(users_enums.WorkspaceRoleOwner), nil, nil
+	}
+
+	membership, err := s.membershipRepository.GetUserMembership(workspaceID, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	if membership == nil {
+		return []users_enums.WorkspacePermission{}, nil, nil
+	}
+
+	rolePermissions := users_enums.GetWorkspaceRolePermissions(membership.Role)
+	if membership.CustomRoleID == nil || !isCustomRoleApplicable(membership.Role) {
+		return rolePermissions, membership, nil
+	}
+
+	permissions, err := s.replaceWithCustomRole(rolePermissions, *membership.CustomRoleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return permissions, membership, nil
+}
+
+// replaceWithCustomRole keeps permissions of the workspace itself and
+// takes permissions of resources from the custom role
+func (s *AuthorizationService) replaceWithCustomRole(
+	permissions []users_enums.WorkspacePermission,
+	customRoleID uuid.UUID,
+) ([]users_enums.WorkspacePermission, error) {
+	customRole, err := s.customRoleRepository.FindByID(customRoleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom role: %w", err)
+	}
+
+	result := []users_enums.WorkspacePermission{}
+	for _, permission := range permissions {
+		if !permission.IsAssignable() {
+			result = append(result, permission)
+		}
+	}
+
+	// deleted role gives no permissions rather than falling back to wider
+	// permissions of the built-in role
+	if customRole == nil {
+		return result, nil
+	}
+
+	return append(result, customRole.Permissions...), nil
+}
+
+// isCustomRoleApplicable returns false for owners and admins, they always
+// have all permissions
+func isCustomRoleApplicable(role users_enums.WorkspaceRole) bool {
+	return role == users_enums.WorkspaceRoleMember || role == users_enums.WorkspaceRoleViewer
+}
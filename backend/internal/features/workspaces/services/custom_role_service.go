@@ -0,0 +1,525 @@
+package workspaces_services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
+	users_enums "postgresus-backend/internal/features/users/enums"
+	users_models "postgresus-backend/internal/features/users/models"
+	users_services "postgresus-backend/internal/features/users/services"
+	workspaces_dto "postgresus-backend/internal/features/workspaces/dto"
+	workspaces_models "postgresus-backend/internal/features/workspaces/models"
+	workspaces_repositories "postgresus-backend/internal/features/workspaces/repositories"
+
+	"github.com/google/uuid"
+)
+
+type CustomRoleService struct {
+	customRoleRepository             *workspaces_repositories.CustomRoleRepository
+	databaseRoleAssignmentRepository *workspaces_repositories.DatabaseRoleAssignmentRepository
+	membershipRepository             *workspaces_repositories.MembershipRepository
+	userService                      *users_services.UserService
+	auditLogService                  *audit_logs.AuditLogService
+	authorizationService             *AuthorizationService
+}
+
+func (s *CustomRoleService) GetRoles(
+	workspaceID uuid.UUID,
+	user *users_models.User,
+) (*workspaces_dto.ListCustomRolesResponseDTO, error) {
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, errors.New("insufficient permissions to view workspace roles")
+	}
+
+	roles, err := s.customRoleRepository.FindByWorkspaceID(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	response := &workspaces_dto.ListCustomRolesResponseDTO{
+		Roles:                 make([]workspaces_dto.CustomRoleResponseDTO, len(roles)),
+		AssignablePermissions: users_enums.GetAssignablePermissions(),
+	}
+	for i, role := range roles {
+		response.Roles[i] = toCustomRoleResponseDTO(role)
+	}
+
+	return response, nil
+}
+
+func (s *CustomRoleService) CreateRole(
+	workspaceID uuid.UUID,
+	request *workspaces_dto.SaveCustomRoleRequestDTO,
+	user *users_models.User,
+) (*workspaces_dto.CustomRoleResponseDTO, error) {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return nil, err
+	}
+
+	role := &workspaces_models.WorkspaceCustomRole{
+		WorkspaceID: workspaceID,
+		Name:        strings.TrimSpace(request.Name),
+		Permissions: request.Permissions,
+	}
+
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.customRoleRepository.Save(role); err != nil {
+		return nil, fmt.Errorf("failed to save role: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCreate,
+		ResourceType: audit_logs_events.AuditResourceCustomRole,
+		ResourceID:   &role.ID,
+		Message: fmt.Sprintf(
+			"Custom role created: %s (%s)",
+			role.Name,
+			formatPermissions(role.Permissions),
+		),
+		User:        user,
+		WorkspaceID: &workspaceID,
+	})
+
+	response := toCustomRoleResponseDTO(role)
+	return &response, nil
+}
+
+func (s *CustomRoleService) UpdateRole(
+	workspaceID uuid.UUID,
+	roleID uuid.UUID,
+	request *workspaces_dto.SaveCustomRoleRequestDTO,
+	user *users_models.User,
+) (*workspaces_dto.CustomRoleResponseDTO, error) {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return nil, err
+	}
+
+	role, err := s.getWorkspaceRole(workspaceID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleBefore := *role
+	role.Name = strings.TrimSpace(request.Name)
+	role.Permissions = request.Permissions
+
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.customRoleRepository.Save(role); err != nil {
+		return nil, fmt.Errorf("failed to save role: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceCustomRole,
+		ResourceID:   &role.ID,
+		Message: fmt.Sprintf(
+			"Custom role updated: %s (%s)",
+			role.Name,
+			formatPermissions(role.Permissions),
+		),
+		User:        user,
+		WorkspaceID: &workspaceID,
+		Before:      roleBefore,
+		After:       role,
+	})
+
+	response := toCustomRoleResponseDTO(role)
+	return &response, nil
+}
+
+func (s *CustomRoleService) DeleteRole(
+	workspaceID uuid.UUID,
+	roleID uuid.UUID,
+	user *users_models.User,
+) error {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return err
+	}
+
+	role, err := s.getWorkspaceRole(workspaceID, roleID)
+	if err != nil {
+		return err
+	}
+
+	isInUse, err := s.customRoleRepository.IsRoleInUse(role.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check role usage: %w", err)
+	}
+	if isInUse {
+		return errors.New("role is assigned to members, unassign it first")
+	}
+
+	if err := s.customRoleRepository.Delete(role.ID); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceCustomRole,
+		ResourceID:   &role.ID,
+		Message:      fmt.Sprintf("Custom role deleted: %s", role.Name),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
+
+	return nil
+}
+
+// SetMemberCustomRole replaces permissions of the built-in role of the member
+// in the whole workspace. Owners and admins cannot be limited by custom roles
+func (s *CustomRoleService) SetMemberCustomRole(
+	workspaceID uuid.UUID,
+	memberUserID uuid.UUID,
+	request *workspaces_dto.SetMemberCustomRoleRequestDTO,
+	user *users_models.User,
+) error {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return err
+	}
+
+	if memberUserID == user.ID {
+		return errors.New("cannot change your own role")
+	}
+
+	membership, targetUser, err := s.getCustomizableMember(workspaceID, memberUserID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Custom role removed from member: %s", targetUser.Email)
+	if request.CustomRoleID != nil {
+		role, err := s.getWorkspaceRole(workspaceID, *request.CustomRoleID)
+		if err != nil {
+			return err
+		}
+
+		message = fmt.Sprintf("Custom role of member %s set to %s", targetUser.Email, role.Name)
+	}
+
+	if err := s.membershipRepository.UpdateMemberCustomRole(
+		membership.UserID,
+		workspaceID,
+		request.CustomRoleID,
+	); err != nil {
+		return fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionChangeRole,
+		ResourceType: audit_logs_events.AuditResourceMembership,
+		ResourceID:   &membership.UserID,
+		Message:      message,
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
+
+	return nil
+}
+
+func (s *CustomRoleService) GetDatabaseRoles(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+	user *users_models.User,
+) (*workspaces_dto.ListDatabaseRoleAssignmentsResponseDTO, error) {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateDatabaseInWorkspace(workspaceID, databaseID); err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.databaseRoleAssignmentRepository.FindByDatabaseID(databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database roles: %w", err)
+	}
+
+	response := &workspaces_dto.ListDatabaseRoleAssignmentsResponseDTO{
+		Assignments: make([]workspaces_dto.DatabaseRoleAssignmentResponseDTO, len(assignments)),
+	}
+	for i, assignment := range assignments {
+		response.Assignments[i] = workspaces_dto.DatabaseRoleAssignmentResponseDTO{
+			UserID:       assignment.UserID,
+			CustomRoleID: assignment.CustomRoleID,
+			CreatedAt:    assignment.CreatedAt,
+		}
+	}
+
+	return response, nil
+}
+
+// AssignDatabaseRole gives the member permissions of the role for the single
+// database instead of permissions in the workspace
+func (s *CustomRoleService) AssignDatabaseRole(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+	memberUserID uuid.UUID,
+	request *workspaces_dto.AssignDatabaseRoleRequestDTO,
+	user *users_models.User,
+) error {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return err
+	}
+
+	if memberUserID == user.ID {
+		return errors.New("cannot change your own role")
+	}
+
+	if err := s.validateDatabaseInWorkspace(workspaceID, databaseID); err != nil {
+		return err
+	}
+
+	_, targetUser, err := s.getCustomizableMember(workspaceID, memberUserID)
+	if err != nil {
+		return err
+	}
+
+	role, err := s.getWorkspaceRole(workspaceID, request.CustomRoleID)
+	if err != nil {
+		return err
+	}
+
+	assignment, err := s.databaseRoleAssignmentRepository.FindByDatabaseAndUser(
+		databaseID,
+		memberUserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get database role: %w", err)
+	}
+
+	if assignment == nil {
+		assignment = &workspaces_models.DatabaseRoleAssignment{
+			WorkspaceID: workspaceID,
+			DatabaseID:  databaseID,
+			UserID:      memberUserID,
+		}
+	}
+	assignment.CustomRoleID = role.ID
+
+	if err := s.databaseRoleAssignmentRepository.Save(assignment); err != nil {
+		return fmt.Errorf("failed to save database role: %w", err)
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceDatabaseRole,
+		ResourceID:   &databaseID,
+		Message: fmt.Sprintf(
+			"Database role of member %s set to %s for database %s",
+			targetUser.Email,
+			role.Name,
+			databaseID,
+		),
+		User:        user,
+		WorkspaceID: &workspaceID,
+	})
+
+	return nil
+}
+
+func (s *CustomRoleService) RemoveDatabaseRole(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+	memberUserID uuid.UUID,
+	user *users_models.User,
+) error {
+	if err := s.validateCanManageRoles(workspaceID, user); err != nil {
+		return err
+	}
+
+	if err := s.validateDatabaseInWorkspace(workspaceID, databaseID); err != nil {
+		return err
+	}
+
+	assignment, err := s.databaseRoleAssignmentRepository.FindByDatabaseAndUser(
+		databaseID,
+		memberUserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get database role: %w", err)
+	}
+
+	if assignment == nil {
+		return errors.New("member has no role for this database")
+	}
+
+	if err := s.databaseRoleAssignmentRepository.Delete(databaseID, memberUserID); err != nil {
+		return fmt.Errorf("failed to remove database role: %w", err)
+	}
+
+	targetUser, err := s.userService.GetUserByID(memberUserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceDatabaseRole,
+		ResourceID:   &databaseID,
+		Message: fmt.Sprintf(
+			"Database role of member %s removed for database %s",
+			targetUser.Email,
+			databaseID,
+		),
+		User:        user,
+		WorkspaceID: &workspaceID,
+	})
+
+	return nil
+}
+
+// GetPermissions returns permissions of the user, so UI can hide actions
+// which are not allowed
+func (s *CustomRoleService) GetPermissions(
+	workspaceID uuid.UUID,
+	databaseID *uuid.UUID,
+	user *users_models.User,
+) (*workspaces_dto.PermissionsResponseDTO, error) {
+	var permissions []users_enums.WorkspacePermission
+	var err error
+
+	if databaseID != nil {
+		if err := s.validateDatabaseInWorkspace(workspaceID, *databaseID); err != nil {
+			return nil, err
+		}
+
+		permissions, err = s.authorizationService.GetDatabasePermissions(
+			workspaceID,
+			*databaseID,
+			user,
+		)
+	} else {
+		permissions, err = s.authorizationService.GetWorkspacePermissions(workspaceID, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &workspaces_dto.PermissionsResponseDTO{Permissions: permissions}, nil
+}
+
+func (s *CustomRoleService) validateCanManageRoles(
+	workspaceID uuid.UUID,
+	user *users_models.User,
+) error {
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageMembers,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !canManage {
+		return errors.New("insufficient permissions to manage roles")
+	}
+
+	return nil
+}
+
+func (s *CustomRoleService) getWorkspaceRole(
+	workspaceID uuid.UUID,
+	roleID uuid.UUID,
+) (*workspaces_models.WorkspaceCustomRole, error) {
+	role, err := s.customRoleRepository.FindByID(roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	if role == nil || role.WorkspaceID != workspaceID {
+		return nil, errors.New("role not found")
+	}
+
+	return role, nil
+}
+
+func (s *CustomRoleService) getCustomizableMember(
+	workspaceID uuid.UUID,
+	memberUserID uuid.UUID,
+) (*workspaces_models.WorkspaceMembership, *users_models.User, error) {
+	membership, err := s.membershipRepository.GetUserMembership(workspaceID, memberUserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	if membership == nil {
+		return nil, nil, errors.New("user is not a member of this workspace")
+	}
+
+	if !isCustomRoleApplicable(membership.Role) {
+		return nil, nil, errors.New("custom roles can be assigned only to members and viewers")
+	}
+
+	targetUser, err := s.userService.GetUserByID(memberUserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+
+	return membership, targetUser, nil
+}
+
+func (s *CustomRoleService) validateDatabaseInWorkspace(
+	workspaceID uuid.UUID,
+	databaseID uuid.UUID,
+) error {
+	isInWorkspace, err := s.databaseRoleAssignmentRepository.IsDatabaseInWorkspace(
+		databaseID,
+		workspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get database: %w", err)
+	}
+
+	if !isInWorkspace {
+		return errors.New("database not found in this workspace")
+	}
+
+	return nil
+}
+
+func toCustomRoleResponseDTO(
+	role *workspaces_models.WorkspaceCustomRole,
+) workspaces_dto.CustomRoleResponseDTO {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []users_enums.WorkspacePermission{}
+	}
+
+	return workspaces_dto.CustomRoleResponseDTO{
+		ID:          role.ID,
+		Name:        role.Name,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+func formatPermissions(permissions []users_enums.WorkspacePermission) string {
+	if len(permissions) == 0 {
+		return "no permissions"
+	}
+
+	items := make([]string, len(permissions))
+	for i, permission := range permissions {
+		items[i] = string(permission)
+	}
+
+	return strings.Join(items, ", ")
+}
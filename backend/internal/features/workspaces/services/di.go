@@ -9,6 +9,14 @@ import (
 
 var workspaceRepository = &workspaces_repositories.WorkspaceRepository{}
 var membershipRepository = &workspaces_repositories.MembershipRepository{}
+var customRoleRepository = &workspaces_repositories.CustomRoleRepository{}
+var databaseRoleAssignmentRepository = &workspaces_repositories.DatabaseRoleAssignmentRepository{}
+
+var authorizationService = &AuthorizationService{
+	membershipRepository,
+	customRoleRepository,
+	databaseRoleAssignmentRepository,
+}
 
 var workspaceService = &WorkspaceService{
 	workspaceRepository,
@@ -16,18 +24,29 @@ var workspaceService = &WorkspaceService{
 	users_services.GetUserService(),
 	audit_logs.GetAuditLogService(),
 	users_services.GetSettingsService(),
+	authorizationService,
 	[]workspaces_interfaces.WorkspaceDeletionListener{},
 }
 
 var membershipService = &MembershipService{
 	membershipRepository,
 	workspaceRepository,
+	databaseRoleAssignmentRepository,
 	users_services.GetUserService(),
 	audit_logs.GetAuditLogService(),
-	workspaceService,
+	authorizationService,
 	users_services.GetSettingsService(),
 }
 
+var customRoleService = &CustomRoleService{
+	customRoleRepository,
+	databaseRoleAssignmentRepository,
+	membershipRepository,
+	users_services.GetUserService(),
+	audit_logs.GetAuditLogService(),
+	authorizationService,
+}
+
 func GetWorkspaceService() *WorkspaceService {
 	return workspaceService
 }
@@ -35,3 +54,15 @@ func GetWorkspaceService() *WorkspaceService {
 func GetMembershipService() *MembershipService {
 	return membershipService
 }
+
+func GetAuthorizationService() *AuthorizationService {
+	return authorizationService
+}
+
+func GetCustomRoleService() *CustomRoleService {
+	return customRoleService
+}
+
+func SetupDependencies() {
+	users_services.GetUserService().SetWorkspaceMembershipSyncer(membershipService)
+}
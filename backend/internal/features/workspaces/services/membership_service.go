@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	users_dto "postgresus-backend/internal/features/users/dto"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
@@ -17,19 +18,24 @@ import (
 )
 
 type MembershipService struct {
-	membershipRepository *workspaces_repositories.MembershipRepository
-	workspaceRepository  *workspaces_repositories.WorkspaceRepository
-	userService          *users_services.UserService
-	auditLogService      *audit_logs.AuditLogService
-	workspaceService     *WorkspaceService
-	settingsService      *users_services.SettingsService
+	membershipRepository             *workspaces_repositories.MembershipRepository
+	workspaceRepository              *workspaces_repositories.WorkspaceRepository
+	databaseRoleAssignmentRepository *workspaces_repositories.DatabaseRoleAssignmentRepository
+	userService                      *users_services.UserService
+	auditLogService                  *audit_logs.AuditLogService
+	authorizationService             *AuthorizationService
+	settingsService                  *users_services.SettingsService
 }
 
 func (s *MembershipService) GetMembers(
 	workspaceID uuid.UUID,
 	user *users_models.User,
 ) (*workspaces_dto.GetMembersResponseDTO, error) {
-	canView, _, err := s.workspaceService.CanUserAccessWorkspace(workspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -98,15 +104,18 @@ func (s *MembershipService) AddMember(
 			return nil, fmt.Errorf("failed to add member: %w", err)
 		}
 
-		s.auditLogService.WriteAuditLog(
-			fmt.Sprintf(
+		s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+			Action:       audit_logs_events.AuditActionInvite,
+			ResourceType: audit_logs_events.AuditResourceMembership,
+			ResourceID:   &inviteResponse.ID,
+			Message: fmt.Sprintf(
 				"User invited to workspace: %s and added as %s",
 				request.Email,
 				request.Role,
 			),
-			&addedBy.ID,
-			&workspaceID,
-		)
+			User:        addedBy,
+			WorkspaceID: &workspaceID,
+		})
 
 		return &workspaces_dto.AddMemberResponseDTO{
 			Status: workspaces_dto.AddStatusInvited,
@@ -131,11 +140,18 @@ func (s *MembershipService) AddMember(
 		return nil, fmt.Errorf("failed to add member: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("User added to workspace: %s as %s", targetUser.Email, request.Role),
-		&addedBy.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionAddMember,
+		ResourceType: audit_logs_events.AuditResourceMembership,
+		ResourceID:   &targetUser.ID,
+		Message: fmt.Sprintf(
+			"User added to workspace: %s as %s",
+			targetUser.Email,
+			request.Role,
+		),
+		User:        addedBy,
+		WorkspaceID: &workspaceID,
+	})
 
 	return &workspaces_dto.AddMemberResponseDTO{
 		Status: workspaces_dto.AddStatusAdded,
@@ -177,16 +193,19 @@ func (s *MembershipService) ChangeMemberRole(
 		return fmt.Errorf("failed to update member role: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf(
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionChangeRole,
+		ResourceType: audit_logs_events.AuditResourceMembership,
+		ResourceID:   &targetUser.ID,
+		Message: fmt.Sprintf(
 			"Member role changed: %s from %s to %s",
 			targetUser.Email,
 			existingMembership.Role,
 			request.Role,
 		),
-		&changedBy.ID,
-		&workspaceID,
-	)
+		User:        changedBy,
+		WorkspaceID: &workspaceID,
+	})
 
 	return nil
 }
@@ -196,7 +215,11 @@ func (s *MembershipService) RemoveMember(
 	memberUserID uuid.UUID,
 	removedBy *users_models.User,
 ) error {
-	canManage, err := s.workspaceService.CanUserManageMembership(workspaceID, removedBy)
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		removedBy,
+		users_enums.WorkspacePermissionManageMembers,
+	)
 	if err != nil {
 		return err
 	}
@@ -218,7 +241,11 @@ func (s *MembershipService) RemoveMember(
 	}
 
 	if existingMembership.Role == users_enums.WorkspaceRoleAdmin {
-		canManageAdmins, err := s.workspaceService.CanUserManageAdmins(workspaceID, removedBy)
+		canManageAdmins, err := s.authorizationService.HasPermission(
+			workspaceID,
+			removedBy,
+			users_enums.WorkspacePermissionManageAdmins,
+		)
 		if err != nil {
 			return err
 		}
@@ -232,15 +259,18 @@ func (s *MembershipService) RemoveMember(
 		return errors.New("user not found")
 	}
 
-	if err := s.membershipRepository.RemoveMember(memberUserID, workspaceID); err != nil {
-		return fmt.Errorf("failed to remove member: %w", err)
+	if err := s.removeMembership(memberUserID, workspaceID); err != nil {
+		return err
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Member removed from workspace: %s", targetUser.Email),
-		&removedBy.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionRemoveMember,
+		ResourceType: audit_logs_events.AuditResourceMembership,
+		ResourceID:   &targetUser.ID,
+		Message:      fmt.Sprintf("Member removed from workspace: %s", targetUser.Email),
+		User:         removedBy,
+		WorkspaceID:  &workspaceID,
+	})
 
 	return nil
 }
@@ -291,11 +321,123 @@ func (s *MembershipService) TransferOwnership(
 		return fmt.Errorf("failed to update previous owner role: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Workspace ownership transferred to: %s", newOwner.Email),
-		&user.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionTransferOwnership,
+		ResourceType: audit_logs_events.AuditResourceWorkspace,
+		ResourceID:   &workspaceID,
+		Message:      fmt.Sprintf("Workspace ownership transferred to: %s", newOwner.Email),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
+
+	return nil
+}
+
+// SyncUserWorkspaceRoles applies workspace roles given by identity provider
+// on sign in. Owners are never changed or removed by the sync
+func (s *MembershipService) SyncUserWorkspaceRoles(
+	userID uuid.UUID,
+	workspaceRoles map[uuid.UUID]*users_enums.WorkspaceRole,
+) error {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	for workspaceID, role := range workspaceRoles {
+		currentRole, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get workspace role: %w", err)
+		}
+
+		if currentRole != nil && *currentRole == users_enums.WorkspaceRoleOwner {
+			continue
+		}
+
+		switch {
+		case role == nil && currentRole != nil:
+			if err := s.removeMembership(userID, workspaceID); err != nil {
+				return err
+			}
+
+			s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionRemoveMember,
+				ResourceType: audit_logs_events.AuditResourceMembership,
+				ResourceID:   &userID,
+				Message: fmt.Sprintf(
+					"Member removed from workspace by identity provider: %s",
+					user.Email,
+				),
+				UserID:      &userID,
+				WorkspaceID: &workspaceID,
+			})
+		case role != nil && currentRole == nil:
+			workspace, err := s.workspaceRepository.GetWorkspaceByID(workspaceID)
+			if err != nil || workspace == nil {
+				// mapping may point to deleted workspace, other mappings
+				// are still applied
+				continue
+			}
+
+			membership := &workspaces_models.WorkspaceMembership{
+				UserID:      userID,
+				WorkspaceID: workspaceID,
+				Role:        *role,
+			}
+
+			if err := s.membershipRepository.CreateMembership(membership); err != nil {
+				return fmt.Errorf("failed to add member: %w", err)
+			}
+
+			s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionAddMember,
+				ResourceType: audit_logs_events.AuditResourceMembership,
+				ResourceID:   &userID,
+				Message: fmt.Sprintf(
+					"User added to workspace by identity provider: %s as %s",
+					user.Email,
+					*role,
+				),
+				UserID:      &userID,
+				WorkspaceID: &workspaceID,
+			})
+		case role != nil && *currentRole != *role:
+			if err := s.membershipRepository.UpdateMemberRole(userID, workspaceID, *role); err != nil {
+				return fmt.Errorf("failed to update member role: %w", err)
+			}
+
+			s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+				Action:       audit_logs_events.AuditActionChangeRole,
+				ResourceType: audit_logs_events.AuditResourceMembership,
+				ResourceID:   &userID,
+				Message: fmt.Sprintf(
+					"Member role changed by identity provider: %s from %s to %s",
+					user.Email,
+					*currentRole,
+					*role,
+				),
+				UserID:      &userID,
+				WorkspaceID: &workspaceID,
+			})
+		}
+	}
+
+	return nil
+}
+
+// removeMembership also removes roles for databases, so they are not
+// restored if the user is added to the workspace again
+func (s *MembershipService) removeMembership(userID uuid.UUID, workspaceID uuid.UUID) error {
+	if err := s.databaseRoleAssignmentRepository.DeleteByUserAndWorkspace(
+		userID,
+		workspaceID,
+	); err != nil {
+		return fmt.Errorf("failed to remove database roles: %w", err)
+	}
+
+	if err := s.membershipRepository.RemoveMember(userID, workspaceID); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
 
 	return nil
 }
@@ -306,7 +448,11 @@ func (s *MembershipService) validateCanManageMembership(
 	changesRoleTo users_enums.WorkspaceRole,
 ) error {
 	if changesRoleTo == users_enums.WorkspaceRoleAdmin {
-		canManageAdmins, err := s.workspaceService.CanUserManageAdmins(workspaceID, user)
+		canManageAdmins, err := s.authorizationService.HasPermission(
+			workspaceID,
+			user,
+			users_enums.WorkspacePermissionManageAdmins,
+		)
 		if err != nil {
 			return err
 		}
@@ -316,7 +462,11 @@ func (s *MembershipService) validateCanManageMembership(
 		return nil
 	}
 
-	canManageMembership, err := s.workspaceService.CanUserManageMembership(workspaceID, user)
+	canManageMembership, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageMembers,
+	)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	audit_logs "postgresus-backend/internal/features/audit_logs"
+	audit_logs_events "postgresus-backend/internal/features/audit_logs/events"
 	users_enums "postgresus-backend/internal/features/users/enums"
 	users_models "postgresus-backend/internal/features/users/models"
 	users_services "postgresus-backend/internal/features/users/services"
@@ -23,6 +24,7 @@ type WorkspaceService struct {
 	userService                *users_services.UserService
 	auditLogService            *audit_logs.AuditLogService
 	settingsService            *users_services.SettingsService
+	authorizationService       *AuthorizationService
 	workspaceDeletionListeners []workspaces_interfaces.WorkspaceDeletionListener
 }
 
@@ -67,11 +69,14 @@ func (s *WorkspaceService) CreateWorkspace(
 		return nil, fmt.Errorf("failed to create workspace membership: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Workspace created: %s", workspace.Name),
-		&creator.ID,
-		&workspace.ID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionCreate,
+		ResourceType: audit_logs_events.AuditResourceWorkspace,
+		ResourceID:   &workspace.ID,
+		Message:      fmt.Sprintf("Workspace created: %s", workspace.Name),
+		User:         creator,
+		WorkspaceID:  &workspace.ID,
+	})
 
 	ownerRole := users_enums.WorkspaceRoleOwner
 	return &workspaces_dto.WorkspaceResponseDTO{
@@ -86,7 +91,11 @@ func (s *WorkspaceService) GetWorkspace(
 	workspaceID uuid.UUID,
 	user *users_models.User,
 ) (*workspaces_models.Workspace, error) {
-	canView, _, err := s.CanUserAccessWorkspace(workspaceID, user)
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +124,11 @@ func (s *WorkspaceService) UpdateWorkspace(
 	updateDTO *workspaces_models.Workspace,
 	user *users_models.User,
 ) (*workspaces_models.Workspace, error) {
-	canManage, err := s.CanUserManageWorkspace(workspaceID, user)
+	canManage, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionManageWorkspace,
+	)
 
 	if err != nil {
 		return nil, err
@@ -132,17 +145,23 @@ func (s *WorkspaceService) UpdateWorkspace(
 	updateDTO.ID = workspaceID
 	updateDTO.CreatedAt = existingWorkspace.CreatedAt
 
+	workspaceBefore := *existingWorkspace
 	existingWorkspace.UpdateFromDTO(updateDTO)
 
 	if err := s.workspaceRepository.UpdateWorkspace(existingWorkspace); err != nil {
 		return nil, fmt.Errorf("failed to update workspace: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Workspace updated: %s", updateDTO.Name),
-		&user.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionUpdate,
+		ResourceType: audit_logs_events.AuditResourceWorkspace,
+		ResourceID:   &workspaceID,
+		Message:      fmt.Sprintf("Workspace updated: %s", updateDTO.Name),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+		Before:       workspaceBefore,
+		After:        existingWorkspace,
+	})
 
 	return existingWorkspace, nil
 }
@@ -174,11 +193,14 @@ func (s *WorkspaceService) DeleteWorkspace(workspaceID uuid.UUID, user *users_mo
 		return fmt.Errorf("failed to delete workspace: %w", err)
 	}
 
-	s.auditLogService.WriteAuditLog(
-		fmt.Sprintf("Workspace deleted: %s", workspace.Name),
-		&user.ID,
-		&workspaceID,
-	)
+	s.auditLogService.WriteAuditEvent(&audit_logs_events.AuditEvent{
+		Action:       audit_logs_events.AuditActionDelete,
+		ResourceType: audit_logs_events.AuditResourceWorkspace,
+		ResourceID:   &workspaceID,
+		Message:      fmt.Sprintf("Workspace deleted: %s", workspace.Name),
+		User:         user,
+		WorkspaceID:  &workspaceID,
+	})
 
 	return nil
 }
@@ -190,111 +212,36 @@ func (s *WorkspaceService) GetUserWorkspaceRole(
 	return s.membershipRepository.GetUserWorkspaceRole(workspaceID, userID)
 }
 
-func (s *WorkspaceService) CanUserAccessWorkspace(
-	workspaceID uuid.UUID,
-	user *users_models.User,
-) (bool, *users_enums.WorkspaceRole, error) {
-	if user.Role == users_enums.UserRoleAdmin {
-		adminRole := users_enums.WorkspaceRoleOwner
-		return true, &adminRole, nil
-	}
-
-	role, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, user.ID)
-	if err != nil {
-		return false, nil, nil
-	}
-
-	return role != nil, role, nil
-}
-
-func (s *WorkspaceService) CanUserManageWorkspace(
-	workspaceID uuid.UUID,
-	user *users_models.User,
-) (bool, error) {
-	if user.Role == users_enums.UserRoleAdmin {
-		return true, nil
-	}
-
-	role, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, user.ID)
-	if err != nil {
-		return false, err
-	}
-
-	if role == nil {
-		return false, nil
-	}
-
-	return *role == users_enums.WorkspaceRoleOwner ||
-		*role == users_enums.WorkspaceRoleAdmin, nil
-}
-
-func (s *WorkspaceService) CanUserManageDBs(
-	workspaceID uuid.UUID,
-	user *users_models.User,
-) (bool, error) {
-	if user.Role == users_enums.UserRoleAdmin {
-		return true, nil
-	}
-
-	role, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, user.ID)
-	if err != nil {
-		return false, err
-	}
-
-	if role == nil {
-		return false, nil
-	}
-
-	return *role == users_enums.WorkspaceRoleOwner ||
-		*role == users_enums.WorkspaceRoleAdmin || *role == users_enums.WorkspaceRoleMember, nil
-}
-
-func (s *WorkspaceService) CanUserManageMembership(
-	workspaceID uuid.UUID,
-	user *users_models.User,
-) (bool, error) {
-	if user.Role == users_enums.UserRoleAdmin {
-		return true, nil
-	}
-
-	role, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, user.ID)
-	if err != nil {
-		return false, err
-	}
-
-	if role == nil {
-		return false, nil
-	}
-
-	return *role == users_enums.WorkspaceRoleOwner || *role == users_enums.WorkspaceRoleAdmin, nil
-}
-
-func (s *WorkspaceService) CanUserManageAdmins(
+func (s *WorkspaceService) GetWorkspaceAuditLogs(
 	workspaceID uuid.UUID,
 	user *users_models.User,
-) (bool, error) {
-	if user.Role == users_enums.UserRoleAdmin {
-		return true, nil
-	}
-
-	role, err := s.membershipRepository.GetUserWorkspaceRole(workspaceID, user.ID)
+	request *audit_logs.GetAuditLogsRequest,
+) (*audit_logs.GetAuditLogsResponse, error) {
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-
-	if role == nil {
-		return false, nil
+	if !canView {
+		return nil, errors.New("insufficient permissions to view workspace audit logs")
 	}
 
-	return *role == users_enums.WorkspaceRoleOwner, nil
+	return s.auditLogService.GetWorkspaceAuditLogs(workspaceID, request)
 }
 
-func (s *WorkspaceService) GetWorkspaceAuditLogs(
+func (s *WorkspaceService) ExportWorkspaceAuditLogs(
 	workspaceID uuid.UUID,
 	user *users_models.User,
 	request *audit_logs.GetAuditLogsRequest,
-) (*audit_logs.GetAuditLogsResponse, error) {
-	canView, _, err := s.CanUserAccessWorkspace(workspaceID, user)
+) ([]*audit_logs.AuditLogDTO, error) {
+	canView, err := s.authorizationService.HasPermission(
+		workspaceID,
+		user,
+		users_enums.WorkspacePermissionAccess,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +249,7 @@ func (s *WorkspaceService) GetWorkspaceAuditLogs(
 		return nil, errors.New("insufficient permissions to view workspace audit logs")
 	}
 
-	return s.auditLogService.GetWorkspaceAuditLogs(workspaceID, request)
+	return s.auditLogService.GetWorkspaceAuditLogsForExport(workspaceID, request)
 }
 
 func (s *WorkspaceService) GetAllWorkspaces() ([]*workspaces_models.Workspace, error) {
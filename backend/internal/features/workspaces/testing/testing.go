@@ -201,7 +201,7 @@ func AddMemberToWorkspaceViaOwner(
 				panic("Failed to get owner user: " + err.Error())
 			}
 
-			tokenResponse, err := userService.GenerateAccessToken(owner)
+			tokenResponse, err := userService.GenerateAccessToken(owner, nil)
 			if err != nil {
 				panic("Failed to generate owner token: " + err.Error())
 			}
@@ -388,7 +388,7 @@ func RemoveTestWorkspace(workspace *workspaces_models.Workspace, router *gin.Eng
 				panic("Failed to get owner user: " + err.Error())
 			}
 
-			tokenResponse, err := userService.GenerateAccessToken(owner)
+			tokenResponse, err := userService.GenerateAccessToken(owner, nil)
 			if err != nil {
 				panic("Failed to generate owner token: " + err.Error())
 			}
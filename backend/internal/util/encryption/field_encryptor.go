@@ -12,4 +12,8 @@ type FieldEncryptor interface {
 	// If the string is not encrypted, returns it as-is.
 	// Empty strings are returned unchanged.
 	Decrypt(itemID uuid.UUID, ciphertext string) (string, error)
+
+	// Reencrypt encrypts the value by the current master key if it was
+	// encrypted by a retired one. Not encrypted values are returned as-is.
+	Reencrypt(itemID uuid.UUID, ciphertext string) (string, error)
 }
@@ -9,12 +9,16 @@ import (
 	"errors"
 	"fmt"
 	"postgresus-backend/internal/features/encryption/secrets"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
 )
 
-const encryptedPrefix = "enc:"
+const (
+	encryptedPrefix = "enc:"
+	versionPrefix   = "v"
+)
 
 type SecretKeyFieldEncryptor struct {
 	secretKeyService *secrets.SecretKeyService
@@ -29,29 +33,12 @@ func (e *SecretKeyFieldEncryptor) Encrypt(itemID uuid.UUID, plaintext string) (s
 		return plaintext, nil
 	}
 
-	masterKey, err := e.secretKeyService.GetSecretKey()
+	keyVersion, masterKey, err := e.secretKeyService.GetCurrentKey()
 	if err != nil {
 		return "", fmt.Errorf("failed to get master key: %w", err)
 	}
 
-	block, err := aes.NewCipher([]byte(masterKey)[:32])
-	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
-	}
-
-	nonce := e.deriveNonce(itemID, masterKey, gcm.NonceSize())
-
-	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), nil)
-
-	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
-	ciphertextBase64 := base64.StdEncoding.EncodeToString(ciphertext)
-
-	return fmt.Sprintf("%s%s:%s", encryptedPrefix, nonceBase64, ciphertextBase64), nil
+	return e.encryptWithKey(itemID, plaintext, keyVersion, masterKey)
 }
 
 func (e *SecretKeyFieldEncryptor) Decrypt(itemID uuid.UUID, ciphertext string) (string, error) {
@@ -63,14 +50,11 @@ func (e *SecretKeyFieldEncryptor) Decrypt(itemID uuid.UUID, ciphertext string) (
 		return ciphertext, nil
 	}
 
-	parts := strings.SplitN(ciphertext, ":", 3)
-	if len(parts) != 3 {
-		return "", errors.New("invalid encrypted format")
+	keyVersion, nonceBase64, ciphertextBase64, err := parseEncryptedValue(ciphertext)
+	if err != nil {
+		return "", err
 	}
 
-	nonceBase64 := parts[1]
-	ciphertextBase64 := parts[2]
-
 	nonce, err := base64.StdEncoding.DecodeString(nonceBase64)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode nonce: %w", err)
@@ -81,19 +65,14 @@ func (e *SecretKeyFieldEncryptor) Decrypt(itemID uuid.UUID, ciphertext string) (
 		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
 	}
 
-	masterKey, err := e.secretKeyService.GetSecretKey()
+	masterKey, err := e.secretKeyService.GetKeyByVersion(&keyVersion)
 	if err != nil {
 		return "", fmt.Errorf("failed to get master key: %w", err)
 	}
 
-	block, err := aes.NewCipher([]byte(masterKey)[:32])
+	gcm, err := createGCM(masterKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", err
 	}
 
 	plaintext, err := gcm.Open(nil, nonce, encryptedData, nil)
@@ -104,6 +83,72 @@ func (e *SecretKeyFieldEncryptor) Decrypt(itemID uuid.UUID, ciphertext string) (
 	return string(plaintext), nil
 }
 
+func (e *SecretKeyFieldEncryptor) Reencrypt(itemID uuid.UUID, ciphertext string) (string, error) {
+	if !e.isEncrypted(ciphertext) {
+		return ciphertext, nil
+	}
+
+	keyVersion, masterKey, err := e.secretKeyService.GetCurrentKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	if version, ok := GetEncryptedValueKeyVersion(ciphertext); ok && version == keyVersion {
+		return ciphertext, nil
+	}
+
+	plaintext, err := e.Decrypt(itemID, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return e.encryptWithKey(itemID, plaintext, keyVersion, masterKey)
+}
+
+// GetEncryptedValueKeyVersion returns version of the master key which
+// encrypted the value. Values encrypted before versioning have no version
+// in the value and belong to the legacy key
+func GetEncryptedValueKeyVersion(value string) (int, bool) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return 0, false
+	}
+
+	keyVersion, _, _, err := parseEncryptedValue(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return keyVersion, true
+}
+
+func (e *SecretKeyFieldEncryptor) encryptWithKey(
+	itemID uuid.UUID,
+	plaintext string,
+	keyVersion int,
+	masterKey string,
+) (string, error) {
+	gcm, err := createGCM(masterKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := e.deriveNonce(itemID, masterKey, gcm.NonceSize())
+
+	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), nil)
+
+	nonceBase64 := base64.StdEncoding.EncodeToString(nonce)
+	ciphertextBase64 := base64.StdEncoding.EncodeToString(ciphertext)
+
+	return fmt.Sprintf(
+		"%s%s%d:%s:%s",
+		encryptedPrefix,
+		versionPrefix,
+		keyVersion,
+		nonceBase64,
+		ciphertextBase64,
+	), nil
+}
+
 func (e *SecretKeyFieldEncryptor) isEncrypted(value string) bool {
 	return strings.HasPrefix(value, encryptedPrefix)
 }
@@ -118,3 +163,41 @@ func (e *SecretKeyFieldEncryptor) deriveNonce(
 	hash := h.Sum(nil)
 	return hash[:nonceSize]
 }
+
+// parseEncryptedValue supports both "enc:v<version>:<nonce>:<ciphertext>"
+// and "enc:<nonce>:<ciphertext>" written before keys were versioned
+func parseEncryptedValue(value string) (int, string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(value, encryptedPrefix), ":")
+
+	switch len(parts) {
+	case 2:
+		return secrets.LegacyKeyVersion, parts[0], parts[1], nil
+	case 3:
+		if !strings.HasPrefix(parts[0], versionPrefix) {
+			return 0, "", "", errors.New("invalid encrypted format")
+		}
+
+		keyVersion, err := strconv.Atoi(strings.TrimPrefix(parts[0], versionPrefix))
+		if err != nil {
+			return 0, "", "", fmt.Errorf("invalid key version: %w", err)
+		}
+
+		return keyVersion, parts[1], parts[2], nil
+	default:
+		return 0, "", "", errors.New("invalid encrypted format")
+	}
+}
+
+func createGCM(masterKey string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher([]byte(masterKey)[:32])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
+}
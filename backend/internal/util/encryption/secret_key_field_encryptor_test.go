@@ -1,8 +1,13 @@
 package encryption
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"postgresus-backend/internal/features/encryption/secrets"
+
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -118,3 +123,84 @@ func Test_EncryptedFormat_ContainsPrefix(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, encrypted, "enc:")
 }
+
+func Test_Encrypt_ContainsCurrentKeyVersion(t *testing.T) {
+	encryptor := createTestFieldEncryptor(t)
+
+	encrypted, err := encryptor.Encrypt(uuid.New(), "my-password")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:"))
+
+	keyVersion, ok := GetEncryptedValueKeyVersion(encrypted)
+	assert.True(t, ok)
+	assert.Equal(t, secrets.LegacyKeyVersion, keyVersion)
+}
+
+func Test_Decrypt_AfterKeyRotation_DecryptsByRetiredKey(t *testing.T) {
+	encryptor := createTestFieldEncryptor(t)
+	itemID := uuid.New()
+	plaintext := "my-password"
+
+	encrypted, err := encryptor.Encrypt(itemID, plaintext)
+	assert.NoError(t, err)
+
+	// values encrypted before keys were versioned have no version
+	legacyEncrypted := "enc:" + strings.TrimPrefix(encrypted, "enc:v1:")
+
+	keyVersion, err := encryptor.secretKeyService.RotateKey()
+	assert.NoError(t, err)
+
+	for _, value := range []string{encrypted, legacyEncrypted} {
+		decrypted, err := encryptor.Decrypt(itemID, value)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	}
+
+	newEncrypted, err := encryptor.Encrypt(uuid.New(), plaintext)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newEncrypted, fmt.Sprintf("enc:v%d:", keyVersion)))
+}
+
+func Test_Reencrypt_WithRetiredKey_EncryptsByCurrentKey(t *testing.T) {
+	encryptor := createTestFieldEncryptor(t)
+	itemID := uuid.New()
+	plaintext := "my-password"
+
+	encrypted, err := encryptor.Encrypt(itemID, plaintext)
+	assert.NoError(t, err)
+
+	keyVersion, err := encryptor.secretKeyService.RotateKey()
+	assert.NoError(t, err)
+
+	reencrypted, err := encryptor.Reencrypt(itemID, encrypted)
+	assert.NoError(t, err)
+	assert.NotEqual(t, encrypted, reencrypted)
+
+	reencryptedVersion, ok := GetEncryptedValueKeyVersion(reencrypted)
+	assert.True(t, ok)
+	assert.Equal(t, keyVersion, reencryptedVersion)
+
+	err = encryptor.secretKeyService.RemoveKeys([]int{secrets.LegacyKeyVersion})
+	assert.NoError(t, err)
+
+	decrypted, err := encryptor.Decrypt(itemID, reencrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	unchanged, err := encryptor.Reencrypt(itemID, reencrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, reencrypted, unchanged)
+
+	_, err = encryptor.Decrypt(itemID, encrypted)
+	assert.Error(t, err)
+}
+
+// createTestFieldEncryptor uses own key file, so rotation does not change
+// the key of the development environment
+func createTestFieldEncryptor(t *testing.T) *SecretKeyFieldEncryptor {
+	keyPath := filepath.Join(t.TempDir(), "secret.key")
+
+	return &SecretKeyFieldEncryptor{
+		secrets.NewSecretKeyService(secrets.NewFileKeyProvider(keyPath)),
+	}
+}
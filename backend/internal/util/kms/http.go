@@ -0,0 +1,94 @@
+package kms
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPClient wraps keys by generic envelope encryption endpoint, e.g. KMIP
+// gateway or proxy in front of cloud KMS. The endpoint must implement:
+//
+//	POST {url}/wrap   {"keyId": "...", "plaintext": "<base64>"} -> {"ciphertext": "..."}
+//	POST {url}/unwrap {"keyId": "...", "ciphertext": "..."}     -> {"plaintext": "<base64>"}
+//
+// The token, when set, is sent as Bearer
+type HTTPClient struct {
+	url   string
+	token string
+	keyID string
+
+	httpClient *http.Client
+}
+
+type httpWrapRequest struct {
+	KeyID      string `json:"keyId"`
+	Plaintext  string `json:"plaintext,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+type httpWrapResponse struct {
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func NewHTTPClient(url string, token string, keyID string) (*HTTPClient, error) {
+	if url == "" {
+		return nil, errors.New("key management URL is not set")
+	}
+
+	return &HTTPClient{
+		url:        strings.TrimSuffix(url, "/"),
+		token:      token,
+		keyID:      keyID,
+		httpClient: &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+func (c *HTTPClient) Wrap(plaintext []byte) (string, error) {
+	var response httpWrapResponse
+
+	err := c.post("wrap", &httpWrapRequest{
+		KeyID:     c.keyID,
+		Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+	}, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap key: %w", err)
+	}
+
+	if response.Ciphertext == "" {
+		return "", errors.New("key management returned empty ciphertext")
+	}
+
+	return response.Ciphertext, nil
+}
+
+func (c *HTTPClient) Unwrap(ciphertext string) ([]byte, error) {
+	var response httpWrapResponse
+
+	err := c.post("unwrap", &httpWrapRequest{
+		KeyID:      c.keyID,
+		Ciphertext: ciphertext,
+	}, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %w", err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(response.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode unwrapped key: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func (c *HTTPClient) post(operation string, requestBody any, responseBody any) error {
+	headers := map[string]string{}
+	if c.token != "" {
+		headers["Authorization"] = "Bearer " + c.token
+	}
+
+	return postJSON(c.httpClient, c.url+"/"+operation, headers, requestBody, responseBody)
+}
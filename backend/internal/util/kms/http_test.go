@@ -0,0 +1,74 @@
+package kms
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTTPClient_WrapAndUnwrap_ReturnsOriginalKey(t *testing.T) {
+	server := createTestKeyManagementServer(t, "test-token")
+	defer server.Close()
+
+	client, err := NewHTTPClient(server.URL, "test-token", "master")
+	assert.NoError(t, err)
+
+	wrappedKey, err := client.Wrap([]byte("my-secret-key"))
+	assert.NoError(t, err)
+	assert.NotContains(t, wrappedKey, "my-secret-key")
+
+	key, err := client.Unwrap(wrappedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-secret-key", string(key))
+}
+
+func Test_HTTPClient_WithInvalidToken_ReturnsError(t *testing.T) {
+	server := createTestKeyManagementServer(t, "test-token")
+	defer server.Close()
+
+	client, err := NewHTTPClient(server.URL, "wrong-token", "master")
+	assert.NoError(t, err)
+
+	_, err = client.Wrap([]byte("my-secret-key"))
+	assert.ErrorContains(t, err, "returned status 401")
+}
+
+// createTestKeyManagementServer "encrypts" keys by reversing base64 string,
+// it is enough to check the protocol
+func createTestKeyManagementServer(t *testing.T, token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var request httpWrapRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "master", request.KeyID)
+
+		var response httpWrapResponse
+		switch r.URL.Path {
+		case "/wrap":
+			response.Ciphertext = "wrapped:" + reverse(request.Plaintext)
+		case "/unwrap":
+			response.Plaintext = reverse(request.Ciphertext[len("wrapped:"):])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(&response))
+	}))
+}
+
+func reverse(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
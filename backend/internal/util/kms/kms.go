@@ -0,0 +1,66 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	httpTimeout = 15 * time.Second
+	// error responses are read only partially, they are used in messages
+	maxErrorBodySize = 1024
+)
+
+// KeyWrapper encrypts and decrypts small keys by the key which never leaves
+// the key management system (envelope encryption)
+type KeyWrapper interface {
+	Wrap(plaintext []byte) (string, error)
+	Unwrap(ciphertext string) ([]byte, error)
+}
+
+func postJSON(
+	httpClient *http.Client,
+	url string,
+	headers map[string]string,
+	requestBody any,
+	responseBody any,
+) error {
+	payload, err := json.Marshal(requestBody)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		return fmt.Errorf(
+			"%s returned status %d: %s",
+			url,
+			response.StatusCode,
+			bytes.TrimSpace(body),
+		)
+	}
+
+	return json.NewDecoder(response.Body).Decode(responseBody)
+}
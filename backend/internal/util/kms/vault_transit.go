@@ -0,0 +1,122 @@
+package kms
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultVaultTransitMount = "transit"
+
+// VaultTransitClient wraps keys by the named key of HashiCorp Vault Transit
+// secrets engine. The token needs "update" capability on encrypt/<key> and
+// decrypt/<key> paths only
+type VaultTransitClient struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	keyName   string
+
+	httpClient *http.Client
+}
+
+type vaultTransitRequest struct {
+	Plaintext  string `json:"plaintext,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+type vaultTransitResponse struct {
+	Data struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"data"`
+}
+
+func NewVaultTransitClient(
+	address string,
+	token string,
+	namespace string,
+	mount string,
+	keyName string,
+) (*VaultTransitClient, error) {
+	if address == "" {
+		return nil, errors.New("vault address is not set")
+	}
+
+	if token == "" {
+		return nil, errors.New("vault token is not set")
+	}
+
+	if keyName == "" {
+		return nil, errors.New("vault transit key name is not set")
+	}
+
+	if mount == "" {
+		mount = defaultVaultTransitMount
+	}
+
+	return &VaultTransitClient{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		namespace:  namespace,
+		mount:      strings.Trim(mount, "/"),
+		keyName:    keyName,
+		httpClient: &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+func (c *VaultTransitClient) Wrap(plaintext []byte) (string, error) {
+	var response vaultTransitResponse
+
+	err := c.post("encrypt", &vaultTransitRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+	}, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt key by Vault: %w", err)
+	}
+
+	if response.Data.Ciphertext == "" {
+		return "", errors.New("vault returned empty ciphertext")
+	}
+
+	return response.Data.Ciphertext, nil
+}
+
+func (c *VaultTransitClient) Unwrap(ciphertext string) ([]byte, error) {
+	var response vaultTransitResponse
+
+	err := c.post("decrypt", &vaultTransitRequest{
+		Ciphertext: ciphertext,
+	}, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key by Vault: %w", err)
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(response.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key returned by Vault: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func (c *VaultTransitClient) post(operation string, requestBody any, responseBody any) error {
+	endpoint := fmt.Sprintf(
+		"%s/v1/%s/%s/%s",
+		c.address,
+		c.mount,
+		operation,
+		url.PathEscape(c.keyName),
+	)
+
+	headers := map[string]string{"X-Vault-Token": c.token}
+	if c.namespace != "" {
+		headers["X-Vault-Namespace"] = c.namespace
+	}
+
+	return postJSON(c.httpClient, endpoint, headers, requestBody, responseBody)
+}
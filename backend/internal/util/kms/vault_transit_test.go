@@ -0,0 +1,81 @@
+package kms
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"postgresus-backend/internal/config"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVaultToken = "testtoken"
+
+func Test_VaultTransitClient_WrapAndUnwrap_ReturnsOriginalKey(t *testing.T) {
+	vaultAddress := getTestVaultAddress(t)
+	keyName := "postgresus-test-" + uuid.New().String()
+	createTestVaultTransitKey(t, vaultAddress, keyName)
+
+	client, err := NewVaultTransitClient(vaultAddress, testVaultToken, "", "", keyName)
+	assert.NoError(t, err)
+
+	wrappedKey, err := client.Wrap([]byte("my-secret-key"))
+	assert.NoError(t, err)
+	assert.Contains(t, wrappedKey, "vault:v1:")
+
+	key, err := client.Unwrap(wrappedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-secret-key", string(key))
+}
+
+func Test_VaultTransitClient_WithInvalidToken_ReturnsError(t *testing.T) {
+	vaultAddress := getTestVaultAddress(t)
+	keyName := "postgresus-test-" + uuid.New().String()
+	createTestVaultTransitKey(t, vaultAddress, keyName)
+
+	client, err := NewVaultTransitClient(vaultAddress, "wrong-token", "", "", keyName)
+	assert.NoError(t, err)
+
+	_, err = client.Wrap([]byte("my-secret-key"))
+	assert.ErrorContains(t, err, "returned status 403")
+}
+
+func getTestVaultAddress(t *testing.T) string {
+	port := config.GetEnv().TestVaultPort
+	if port == "" {
+		t.Skip("TEST_VAULT_PORT is not set")
+	}
+
+	return fmt.Sprintf("http://127.0.0.1:%s", port)
+}
+
+// createTestVaultTransitKey enables Transit engine on dev server, the
+// engine may be already enabled by previous runs
+func createTestVaultTransitKey(t *testing.T, vaultAddress string, keyName string) {
+	httpClient := &http.Client{Timeout: httpTimeout}
+	headers := map[string]string{"X-Vault-Token": testVaultToken}
+
+	_ = postJSON(
+		httpClient,
+		vaultAddress+"/v1/sys/mounts/transit",
+		headers,
+		map[string]string{"type": "transit"},
+		&map[string]any{},
+	)
+
+	request, err := http.NewRequest(
+		http.MethodPost,
+		vaultAddress+"/v1/transit/keys/"+keyName,
+		nil,
+	)
+	require.NoError(t, err)
+	request.Header.Set("X-Vault-Token", testVaultToken)
+
+	response, err := httpClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	require.Less(t, response.StatusCode, 300)
+}
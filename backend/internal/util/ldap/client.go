@@ -0,0 +1,239 @@
+package ldap_utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	UsernamePlaceholder = "{username}"
+
+	DefaultUserFilter = "(&(objectClass=person)(|(uid={username})(mail={username})" +
+		"(sAMAccountName={username})(userPrincipalName={username})))"
+	DefaultEmailAttribute = "mail"
+	DefaultNameAttribute  = "displayName"
+	DefaultGroupAttribute = "memberOf"
+
+	connectionTimeout = 15 * time.Second
+
+	// ACCOUNTDISABLE flag of Active Directory userAccountControl attribute
+	adAccountDisabledFlag = 0x2
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+type Config struct {
+	URL          string
+	BindDN       string
+	BindPassword string
+	SearchBase   string
+	// filter with {username} placeholder
+	UserFilter      string
+	EmailAttribute  string
+	NameAttribute   string
+	GroupAttribute  string
+	IsStartTLS      bool
+	IsSkipTLSVerify bool
+}
+
+// Entry is the user found in the directory. Groups contain both DN and CN
+// of each group, so mappings can use any of them
+type Entry struct {
+	DN         string
+	Email      string
+	Name       string
+	Groups     []string
+	IsDisabled bool
+}
+
+// Connection is bound as the service account and used for searching users
+type Connection struct {
+	config *Config
+	conn   *ldap.Conn
+}
+
+func Dial(config *Config) (*Connection, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.IsSkipTLSVerify}
+
+	conn, err := ldap.DialURL(config.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	conn.SetTimeout(connectionTimeout)
+
+	if config.IsStartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	connection := &Connection{config: config, conn: conn}
+	if err := connection.bindServiceAccount(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (c *Connection) Close() {
+	_ = c.conn.Close()
+}
+
+// Authenticate finds the user by the filter and checks the password by
+// binding as the user. The connection stays bound as the service account
+func (c *Connection) Authenticate(username string, password string) (*Entry, error) {
+	// LDAP servers treat bind with empty password as anonymous bind, which
+	// succeeds for any DN
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	result, err := c.conn.Search(ldap.NewSearchRequest(
+		c.config.SearchBase,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		0,
+		false,
+		BuildUserFilter(c.config.UserFilter, username),
+		c.getAttributes(),
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search user: %w", err)
+	}
+
+	// several entries mean the filter is too wide, guessing the user is
+	// not safe
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry := c.toEntry(result.Entries[0])
+
+	if err := c.conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+
+		return nil, fmt.Errorf("failed to bind as user: %w", err)
+	}
+
+	if err := c.bindServiceAccount(); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetEntry returns nil when the user is removed from the directory
+func (c *Connection) GetEntry(dn string) (*Entry, error) {
+	result, err := c.conn.Search(ldap.NewSearchRequest(
+		dn,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		1,
+		0,
+		false,
+		"(objectClass=*)",
+		c.getAttributes(),
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get user %s: %w", dn, err)
+	}
+
+	if len(result.Entries) == 0 {
+		return nil, nil
+	}
+
+	return c.toEntry(result.Entries[0]), nil
+}
+
+func (c *Connection) bindServiceAccount() error {
+	if c.config.BindDN == "" {
+		return nil
+	}
+
+	if err := c.conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		return fmt.Errorf("failed to bind as service account: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Connection) getAttributes() []string {
+	return []string{
+		c.config.EmailAttribute,
+		c.config.NameAttribute,
+		c.config.GroupAttribute,
+		"userAccountControl",
+	}
+}
+
+func (c *Connection) toEntry(ldapEntry *ldap.Entry) *Entry {
+	return ToEntry(
+		ldapEntry,
+		c.config.EmailAttribute,
+		c.config.NameAttribute,
+		c.config.GroupAttribute,
+	)
+}
+
+// BuildUserFilter escapes the username, so it can not change the filter
+func BuildUserFilter(filter string, username string) string {
+	return strings.ReplaceAll(filter, UsernamePlaceholder, ldap.EscapeFilter(username))
+}
+
+func ToEntry(
+	ldapEntry *ldap.Entry,
+	emailAttribute string,
+	nameAttribute string,
+	groupAttribute string,
+) *Entry {
+	entry := &Entry{
+		DN:     ldapEntry.DN,
+		Email:  ldapEntry.GetEqualFoldAttributeValue(emailAttribute),
+		Name:   ldapEntry.GetEqualFoldAttributeValue(nameAttribute),
+		Groups: []string{},
+	}
+
+	for _, group := range ldapEntry.GetEqualFoldAttributeValues(groupAttribute) {
+		entry.Groups = append(entry.Groups, group)
+
+		// memberOf contains DNs, while groups without DN (e.g. posixGroup
+		// names) are used as is
+		groupDN, err := ldap.ParseDN(group)
+		if err != nil || len(groupDN.RDNs) == 0 || len(groupDN.RDNs[0].Attributes) == 0 {
+			continue
+		}
+
+		groupName := groupDN.RDNs[0].Attributes[0]
+		if strings.EqualFold(groupName.Type, "cn") && groupName.Value != group {
+			entry.Groups = append(entry.Groups, groupName.Value)
+		}
+	}
+
+	userAccountControl, err := strconv.ParseInt(
+		ldapEntry.GetEqualFoldAttributeValue("userAccountControl"),
+		10,
+		64,
+	)
+	if err == nil {
+		entry.IsDisabled = userAccountControl&adAccountDisabledFlag != 0
+	}
+
+	return entry
+}
@@ -0,0 +1,47 @@
+package ldap_utils
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildUserFilter_WithSpecialCharacters_UsernameEscaped(t *testing.T) {
+	filter := BuildUserFilter("(uid={username})", "john*)(uid=*")
+
+	assert.Equal(t, `(uid=john\2a\29\28uid=\2a)`, filter)
+}
+
+func Test_ToEntry_WithGroupDNs_GroupNamesAdded(t *testing.T) {
+	ldapEntry := ldap.NewEntry("cn=John,ou=users,dc=example,dc=com", map[string][]string{
+		"mail":        {"john@example.com"},
+		"displayName": {"John"},
+		"memberOf": {
+			"CN=DBA,OU=Groups,DC=example,DC=com",
+			"developers",
+		},
+	})
+
+	entry := ToEntry(ldapEntry, "mail", "displayName", "memberof")
+
+	assert.Equal(t, "john@example.com", entry.Email)
+	assert.Equal(t, "John", entry.Name)
+	assert.Equal(
+		t,
+		[]string{"CN=DBA,OU=Groups,DC=example,DC=com", "DBA", "developers"},
+		entry.Groups,
+	)
+	assert.False(t, entry.IsDisabled)
+}
+
+func Test_ToEntry_WithDisabledADAccount_EntryDisabled(t *testing.T) {
+	ldapEntry := ldap.NewEntry("cn=John,ou=users,dc=example,dc=com", map[string][]string{
+		"mail":               {"john@example.com"},
+		"userAccountControl": {"514"},
+	})
+
+	entry := ToEntry(ldapEntry, "mail", "displayName", "memberOf")
+
+	assert.True(t, entry.IsDisabled)
+}
@@ -0,0 +1,308 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	discoveryCacheLifetime = time.Hour
+	// unknown key id triggers JWKS refetch, but not more often than this
+	// to not be used for flooding the provider
+	jwksRefetchInterval = time.Minute
+	// tolerated difference between clocks of the provider and ours
+	clockSkew   = time.Minute
+	httpTimeout = 15 * time.Second
+)
+
+var supportedSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+type Discovery struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	UserinfoEndpoint              string   `json:"userinfo_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// Provider fetches discovery document and signing keys of OpenID provider
+// and verifies ID tokens issued for the client
+type Provider struct {
+	issuerURL  string
+	clientID   string
+	httpClient *http.Client
+
+	mu                 sync.Mutex
+	discovery          *Discovery
+	discoveryFetchedAt time.Time
+	keys               map[string]any
+	keysFetchedAt      time.Time
+}
+
+func NewProvider(issuerURL string, clientID string) *Provider {
+	return &Provider{
+		issuerURL:  strings.TrimSuffix(issuerURL, "/"),
+		clientID:   clientID,
+		httpClient: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (p *Provider) GetDiscovery() (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.getDiscovery()
+}
+
+// VerifyIDToken checks signature, issuer, audience, expiration and nonce
+// of the ID token and returns its claims
+func (p *Provider) VerifyIDToken(rawIDToken string, nonce string) (jwt.MapClaims, error) {
+	p.mu.Lock()
+	discovery, err := p.getDiscovery()
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.NewParser(
+		jwt.WithValidMethods(supportedSigningMethods),
+		jwt.WithoutClaimsValidation(),
+	).ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.getSigningKey(discovery, keyID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return nil, errors.New("invalid ID token: unexpected issuer")
+	}
+
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, errors.New("invalid ID token: unexpected audience")
+	}
+
+	// azp is required when the token is issued for several audiences
+	if audiences, ok := claims["aud"].([]any); ok && len(audiences) > 1 {
+		if authorizedParty, _ := claims["azp"].(string); authorizedParty != p.clientID {
+			return nil, errors.New("invalid ID token: unexpected authorized party")
+		}
+	}
+
+	if !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true) {
+		return nil, errors.New("invalid ID token: token is expired")
+	}
+
+	if !claims.VerifyIssuedAt(now.Add(clockSkew).Unix(), false) {
+		return nil, errors.New("invalid ID token: token is issued in the future")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("invalid ID token: unexpected nonce")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) getDiscovery() (*Discovery, error) {
+	if p.discovery != nil && time.Since(p.discoveryFetchedAt) < discoveryCacheLifetime {
+		return p.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := p.getJSON(p.issuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to get OpenID configuration: %w", err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuerURL {
+		return nil, fmt.Errorf(
+			"issuer of OpenID configuration %s does not match %s",
+			discovery.Issuer,
+			p.issuerURL,
+		)
+	}
+
+	if discovery.AuthorizationEndpoint == "" ||
+		discovery.TokenEndpoint == "" ||
+		discovery.JWKSURI == "" {
+		return nil, errors.New("OpenID configuration misses required endpoints")
+	}
+
+	p.discovery = &discovery
+	p.discoveryFetchedAt = time.Now().UTC()
+
+	return p.discovery, nil
+}
+
+func (p *Provider) getSigningKey(discovery *Discovery, keyID string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.findKey(keyID); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefetchInterval {
+		return nil, errors.New("signing key is not found")
+	}
+
+	keys, err := p.fetchKeys(discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now().UTC()
+
+	if key, ok := p.findKey(keyID); ok {
+		return key, nil
+	}
+
+	return nil, errors.New("signing key is not found")
+}
+
+// findKey returns the only key when the token has no key ID
+func (p *Provider) findKey(keyID string) (any, bool) {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[keyID]
+	return key, ok
+}
+
+func (p *Provider) fetchKeys(jwksURI string) (map[string]any, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := p.getJSON(jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+
+	keys := make(map[string]any)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.toPublicKey()
+		if err != nil {
+			// keys of unsupported types are skipped, other keys still can
+			// be used
+			continue
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+func (p *Provider) getJSON(url string, target any) error {
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k *jsonWebKey) toPublicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.KeyType)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,147 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientID = "postgresus"
+
+func Test_VerifyIDToken_WithValidToken_ClaimsReturned(t *testing.T) {
+	server, privateKey := createTestProviderServer(t)
+
+	provider := NewProvider(server.URL, testClientID)
+	idToken := signTestIDToken(t, privateKey, createTestIDTokenClaims(server.URL))
+
+	claims, err := provider.VerifyIDToken(idToken, "nonce")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims["sub"])
+}
+
+func Test_VerifyIDToken_WithInvalidClaims_ReturnsError(t *testing.T) {
+	server, privateKey := createTestProviderServer(t)
+	provider := NewProvider(server.URL, testClientID)
+
+	testCases := []struct {
+		name          string
+		modifyClaims  func(claims jwt.MapClaims)
+		expectedError string
+	}{
+		{
+			name:          "another audience",
+			modifyClaims:  func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			expectedError: "unexpected audience",
+		},
+		{
+			name:          "another issuer",
+			modifyClaims:  func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			expectedError: "unexpected issuer",
+		},
+		{
+			name: "expired",
+			modifyClaims: func(claims jwt.MapClaims) {
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			expectedError: "token is expired",
+		},
+		{
+			name:          "another nonce",
+			modifyClaims:  func(claims jwt.MapClaims) { claims["nonce"] = "replayed" },
+			expectedError: "unexpected nonce",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := createTestIDTokenClaims(server.URL)
+			tc.modifyClaims(claims)
+
+			_, err := provider.VerifyIDToken(signTestIDToken(t, privateKey, claims), "nonce")
+
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_VerifyIDToken_WithSymmetricSignature_ReturnsError(t *testing.T) {
+	server, _ := createTestProviderServer(t)
+	provider := NewProvider(server.URL, testClientID)
+
+	idToken, err := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		createTestIDTokenClaims(server.URL),
+	).SignedString([]byte(testClientID))
+	assert.NoError(t, err)
+
+	_, err = provider.VerifyIDToken(idToken, "nonce")
+
+	assert.ErrorContains(t, err, "invalid ID token")
+}
+
+func createTestProviderServer(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:                server.URL,
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			JWKSURI:               server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []jsonWebKey{
+				{
+					KeyType: "RSA",
+					KeyID:   "key-1",
+					Use:     "sig",
+					N:       base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+					E: base64.RawURLEncoding.EncodeToString(
+						big.NewInt(int64(privateKey.E)).Bytes(),
+					),
+				},
+			},
+		})
+	})
+
+	return server, privateKey
+}
+
+func createTestIDTokenClaims(issuer string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   issuer,
+		"sub":   "user-1",
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "nonce",
+	}
+}
+
+func signTestIDToken(t *testing.T, privateKey *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+
+	idToken, err := token.SignedString(privateKey)
+	assert.NoError(t, err)
+
+	return idToken
+}
@@ -23,8 +23,9 @@ const (
 type MysqlExecutable string
 
 const (
-	MysqlExecutableMysqldump MysqlExecutable = "mysqldump"
-	MysqlExecutableMysql     MysqlExecutable = "mysql"
+	MysqlExecutableMysqldump   MysqlExecutable = "mysqldump"
+	MysqlExecutableMysql       MysqlExecutable = "mysql"
+	MysqlExecutableMysqlbinlog MysqlExecutable = "mysqlbinlog"
 )
 
 // GetMysqlExecutable returns the full path to a specific MySQL executable
@@ -66,6 +67,7 @@ func VerifyMysqlInstallation(
 	requiredCommands := []MysqlExecutable{
 		MysqlExecutableMysqldump,
 		MysqlExecutableMysql,
+		MysqlExecutableMysqlbinlog,
 	}
 
 	for _, version := range versions {
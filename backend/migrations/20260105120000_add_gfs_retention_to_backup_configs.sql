@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN retention_policy_type     TEXT NOT NULL DEFAULT 'TIME_PERIOD',
+    ADD COLUMN gfs_hourly_backups_count  INT  NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_daily_backups_count   INT  NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_weekly_backups_count  INT  NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_monthly_backups_count INT  NOT NULL DEFAULT 0,
+    ADD COLUMN gfs_yearly_backups_count  INT  NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS gfs_yearly_backups_count,
+    DROP COLUMN IF EXISTS gfs_monthly_backups_count,
+    DROP COLUMN IF EXISTS gfs_weekly_backups_count,
+    DROP COLUMN IF EXISTS gfs_daily_backups_count,
+    DROP COLUMN IF EXISTS gfs_hourly_backups_count,
+    DROP COLUMN IF EXISTS retention_policy_type;
+
+-- +goose StatementEnd
@@ -0,0 +1,81 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE backup_config_secondary_storages (
+    backup_config_database_id UUID NOT NULL,
+    storage_id                UUID NOT NULL,
+    PRIMARY KEY (backup_config_database_id, storage_id)
+);
+
+ALTER TABLE backup_config_secondary_storages
+    ADD CONSTRAINT fk_backup_config_secondary_storages_database_id
+    FOREIGN KEY (backup_config_database_id)
+    REFERENCES backup_configs (database_id)
+    ON DELETE CASCADE;
+
+ALTER TABLE backup_config_secondary_storages
+    ADD CONSTRAINT fk_backup_config_secondary_storages_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE RESTRICT;
+
+CREATE INDEX idx_backup_config_secondary_storages_storage_id ON backup_config_secondary_storages (storage_id);
+
+CREATE TABLE backup_storage_copies (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    backup_id      UUID NOT NULL,
+    storage_id     UUID NOT NULL,
+    status         TEXT NOT NULL,
+    fail_message   TEXT,
+    backup_size_mb DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE backup_storage_copies
+    ADD CONSTRAINT fk_backup_storage_copies_backup_id
+    FOREIGN KEY (backup_id)
+    REFERENCES backups (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE backup_storage_copies
+    ADD CONSTRAINT fk_backup_storage_copies_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE RESTRICT;
+
+ALTER TABLE backup_storage_copies
+    ADD CONSTRAINT uk_backup_storage_copies_backup_id_storage_id
+    UNIQUE (backup_id, storage_id);
+
+CREATE INDEX idx_backup_storage_copies_storage_id ON backup_storage_copies (storage_id);
+
+-- Existing backups have exactly one copy in their storage
+INSERT INTO backup_storage_copies (
+    backup_id,
+    storage_id,
+    status,
+    fail_message,
+    backup_size_mb,
+    created_at
+)
+SELECT
+    id,
+    storage_id,
+    CASE WHEN status = 'CANCELED' THEN 'FAILED' ELSE status END,
+    fail_message,
+    backup_size_mb,
+    created_at
+FROM backups;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_backup_storage_copies_storage_id;
+DROP TABLE IF EXISTS backup_storage_copies;
+
+DROP INDEX IF EXISTS idx_backup_config_secondary_storages_storage_id;
+DROP TABLE IF EXISTS backup_config_secondary_storages;
+
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN backup_type              TEXT    NOT NULL DEFAULT 'LOGICAL',
+    ADD COLUMN is_wal_archiving_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE backups
+    ADD COLUMN backup_type TEXT NOT NULL DEFAULT 'LOGICAL';
+
+ALTER TABLE restores
+    ADD COLUMN recovery_target_time     TIMESTAMPTZ,
+    ADD COLUMN recovery_target_lsn      TEXT,
+    ADD COLUMN recovered_data_directory TEXT;
+
+CREATE TABLE wal_segments (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_id     UUID NOT NULL,
+    storage_id      UUID NOT NULL,
+    file_name       TEXT NOT NULL,
+    size_bytes      BIGINT NOT NULL DEFAULT 0,
+    encryption      TEXT NOT NULL DEFAULT 'NONE',
+    encryption_salt TEXT,
+    encryption_iv   TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT fk_wal_segments_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT fk_wal_segments_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE RESTRICT;
+
+ALTER TABLE wal_segments
+    ADD CONSTRAINT uk_wal_segments_database_id_storage_id_file_name
+    UNIQUE (database_id, storage_id, file_name);
+
+CREATE INDEX idx_wal_segments_database_id_created_at ON wal_segments (database_id, created_at);
+CREATE INDEX idx_wal_segments_storage_id ON wal_segments (storage_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_wal_segments_storage_id;
+DROP INDEX IF EXISTS idx_wal_segments_database_id_created_at;
+DROP TABLE IF EXISTS wal_segments;
+
+ALTER TABLE restores
+    DROP COLUMN IF EXISTS recovered_data_directory,
+    DROP COLUMN IF EXISTS recovery_target_lsn,
+    DROP COLUMN IF EXISTS recovery_target_time;
+
+ALTER TABLE backups
+    DROP COLUMN IF EXISTS backup_type;
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS is_wal_archiving_enabled,
+    DROP COLUMN IF EXISTS backup_type;
+
+-- +goose StatementEnd
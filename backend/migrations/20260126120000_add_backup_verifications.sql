@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE verification_configs (
+    database_id              UUID PRIMARY KEY,
+    is_verification_enabled  BOOLEAN NOT NULL DEFAULT FALSE,
+    verification_interval_id UUID,
+    target_database_id       UUID,
+    checks                   TEXT NOT NULL DEFAULT '[]'
+);
+
+ALTER TABLE verification_configs
+    ADD CONSTRAINT fk_verification_configs_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE verification_configs
+    ADD CONSTRAINT fk_verification_configs_verification_interval_id
+    FOREIGN KEY (verification_interval_id)
+    REFERENCES intervals (id)
+    ON DELETE SET NULL;
+
+ALTER TABLE verification_configs
+    ADD CONSTRAINT fk_verification_configs_target_database_id
+    FOREIGN KEY (target_database_id)
+    REFERENCES databases (id)
+    ON DELETE SET NULL;
+
+CREATE TABLE backup_verification_results (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    backup_id     UUID NOT NULL,
+    database_id   UUID NOT NULL,
+    status        TEXT NOT NULL,
+    fail_message  TEXT,
+    check_results TEXT NOT NULL DEFAULT '[]',
+    duration_ms   BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE backup_verification_results
+    ADD CONSTRAINT fk_backup_verification_results_backup_id
+    FOREIGN KEY (backup_id)
+    REFERENCES backups (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_backup_verification_results_backup_id ON backup_verification_results (backup_id);
+CREATE INDEX idx_backup_verification_results_database_id_created_at ON backup_verification_results (database_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_backup_verification_results_database_id_created_at;
+DROP INDEX IF EXISTS idx_backup_verification_results_backup_id;
+DROP TABLE IF EXISTS backup_verification_results;
+
+DROP TABLE IF EXISTS verification_configs;
+
+-- +goose StatementEnd
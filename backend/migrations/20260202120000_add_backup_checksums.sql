@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backups
+    ADD COLUMN sha256                 TEXT,
+    ADD COLUMN integrity_status       TEXT NOT NULL DEFAULT 'NOT_CHECKED',
+    ADD COLUMN integrity_fail_message TEXT,
+    ADD COLUMN integrity_checked_at   TIMESTAMPTZ;
+
+CREATE INDEX idx_backups_integrity_checked_at ON backups (integrity_checked_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_backups_integrity_checked_at;
+
+ALTER TABLE backups
+    DROP COLUMN IF EXISTS integrity_checked_at,
+    DROP COLUMN IF EXISTS integrity_fail_message,
+    DROP COLUMN IF EXISTS integrity_status,
+    DROP COLUMN IF EXISTS sha256;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    ADD COLUMN filter TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    DROP COLUMN IF EXISTS filter;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    ADD COLUMN new_database_name   TEXT,
+    ADD COLUMN created_database_id UUID REFERENCES databases (id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE restores
+    DROP COLUMN IF EXISTS created_database_id,
+    DROP COLUMN IF EXISTS new_database_name;
+
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN is_binlog_archiving_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE backups
+    ADD COLUMN binlog_file     TEXT,
+    ADD COLUMN binlog_position BIGINT;
+
+ALTER TABLE restores
+    ADD COLUMN recovery_target_binlog_file     TEXT,
+    ADD COLUMN recovery_target_binlog_position BIGINT;
+
+CREATE TABLE binlog_files (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_id     UUID NOT NULL,
+    storage_id      UUID NOT NULL,
+    file_name       TEXT NOT NULL,
+    size_bytes      BIGINT NOT NULL DEFAULT 0,
+    encryption      TEXT NOT NULL DEFAULT 'NONE',
+    encryption_salt TEXT,
+    encryption_iv   TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE binlog_files
+    ADD CONSTRAINT fk_binlog_files_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE binlog_files
+    ADD CONSTRAINT fk_binlog_files_storage_id
+    FOREIGN KEY (storage_id)
+    REFERENCES storages (id)
+    ON DELETE RESTRICT;
+
+ALTER TABLE binlog_files
+    ADD CONSTRAINT uk_binlog_files_database_id_storage_id_file_name
+    UNIQUE (database_id, storage_id, file_name);
+
+CREATE INDEX idx_binlog_files_database_id_file_name ON binlog_files (database_id, file_name);
+CREATE INDEX idx_binlog_files_storage_id ON binlog_files (storage_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_binlog_files_storage_id;
+DROP INDEX IF EXISTS idx_binlog_files_database_id_file_name;
+DROP TABLE IF EXISTS binlog_files;
+
+ALTER TABLE restores
+    DROP COLUMN IF EXISTS recovery_target_binlog_position,
+    DROP COLUMN IF EXISTS recovery_target_binlog_file;
+
+ALTER TABLE backups
+    DROP COLUMN IF EXISTS binlog_position,
+    DROP COLUMN IF EXISTS binlog_file;
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS is_binlog_archiving_enabled;
+
+-- +goose StatementEnd
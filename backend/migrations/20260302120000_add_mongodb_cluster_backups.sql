@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE mongodb_databases
+    ADD COLUMN connection_string TEXT,
+    ADD COLUMN is_all_databases  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN is_oplog_enabled  BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE backups
+    ADD COLUMN is_oplog_included BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backups
+    DROP COLUMN IF EXISTS is_oplog_included;
+
+ALTER TABLE mongodb_databases
+    DROP COLUMN IF EXISTS is_oplog_enabled,
+    DROP COLUMN IF EXISTS is_all_databases,
+    DROP COLUMN IF EXISTS connection_string;
+
+-- +goose StatementEnd
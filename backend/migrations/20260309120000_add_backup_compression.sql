@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    ADD COLUMN compression       TEXT NOT NULL DEFAULT 'ZSTD',
+    ADD COLUMN compression_level INT  NOT NULL DEFAULT 5;
+
+ALTER TABLE backups
+    ADD COLUMN compression TEXT NOT NULL DEFAULT 'ZSTD';
+
+UPDATE backups
+SET compression = 'GZIP'
+FROM databases
+WHERE backups.database_id = databases.id
+  AND databases.type = 'MONGODB';
+
+UPDATE backups
+SET compression = 'GZIP'
+FROM postgresql_databases
+WHERE backups.database_id = postgresql_databases.database_id
+  AND (
+      postgresql_databases.version IN ('12', '13', '14')
+      OR (postgresql_databases.version = '15' AND backups.backup_type = 'LOGICAL')
+  );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backups
+    DROP COLUMN IF EXISTS compression;
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS compression_level,
+    DROP COLUMN IF EXISTS compression;
+
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE user_sessions (
+    id                            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id                       UUID NOT NULL,
+    hashed_refresh_token          TEXT NOT NULL,
+    previous_hashed_refresh_token TEXT,
+    device                        TEXT NOT NULL,
+    ip_address                    TEXT NOT NULL,
+    created_at                    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at                    TIMESTAMPTZ NOT NULL,
+    revoked_at                    TIMESTAMPTZ
+);
+
+ALTER TABLE user_sessions
+    ADD CONSTRAINT fk_user_sessions_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE user_sessions
+    ADD CONSTRAINT uk_user_sessions_hashed_refresh_token
+    UNIQUE (hashed_refresh_token);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
+
+CREATE INDEX idx_user_sessions_previous_hashed_refresh_token
+    ON user_sessions (previous_hashed_refresh_token);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_user_sessions_previous_hashed_refresh_token;
+DROP INDEX IF EXISTS idx_user_sessions_user_id;
+
+ALTER TABLE user_sessions DROP CONSTRAINT IF EXISTS uk_user_sessions_hashed_refresh_token;
+ALTER TABLE user_sessions DROP CONSTRAINT IF EXISTS fk_user_sessions_user_id;
+
+DROP TABLE IF EXISTS user_sessions;
+
+-- +goose StatementEnd
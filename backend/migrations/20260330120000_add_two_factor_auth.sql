@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN totp_secret           TEXT,
+    ADD COLUMN is_two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_used_step   BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE users_settings
+    ADD COLUMN two_factor_requirement TEXT NOT NULL DEFAULT 'NONE';
+
+CREATE TABLE user_recovery_codes (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL,
+    hashed_code TEXT NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE user_recovery_codes
+    ADD CONSTRAINT fk_user_recovery_codes_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+
+ALTER TABLE user_recovery_codes DROP CONSTRAINT IF EXISTS fk_user_recovery_codes_user_id;
+
+DROP TABLE IF EXISTS user_recovery_codes;
+
+ALTER TABLE users_settings
+    DROP COLUMN IF EXISTS two_factor_requirement;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_used_step,
+    DROP COLUMN IF EXISTS is_two_factor_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
+
+-- +goose StatementEnd
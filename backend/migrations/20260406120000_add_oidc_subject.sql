@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN oidc_subject TEXT;
+
+CREATE UNIQUE INDEX idx_users_oidc_subject ON users (oidc_subject) WHERE oidc_subject IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_users_oidc_subject;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS oidc_subject;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN ldap_dn TEXT;
+
+CREATE UNIQUE INDEX idx_users_ldap_dn ON users (ldap_dn) WHERE ldap_dn IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_users_ldap_dn;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS ldap_dn;
+
+-- +goose StatementEnd
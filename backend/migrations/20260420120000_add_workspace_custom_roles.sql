@@ -0,0 +1,94 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE workspace_custom_roles (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL,
+    name         TEXT NOT NULL,
+    permissions  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE workspace_custom_roles
+    ADD CONSTRAINT fk_workspace_custom_roles_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_workspace_custom_roles_workspace_id ON workspace_custom_roles (workspace_id);
+
+ALTER TABLE workspace_memberships
+    ADD COLUMN custom_role_id UUID;
+
+ALTER TABLE workspace_memberships
+    ADD CONSTRAINT fk_workspace_memberships_custom_role_id
+    FOREIGN KEY (custom_role_id)
+    REFERENCES workspace_custom_roles (id)
+    ON DELETE SET NULL;
+
+CREATE TABLE database_role_assignments (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id   UUID NOT NULL,
+    database_id    UUID NOT NULL,
+    user_id        UUID NOT NULL,
+    custom_role_id UUID NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE database_role_assignments
+    ADD CONSTRAINT fk_database_role_assignments_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE database_role_assignments
+    ADD CONSTRAINT fk_database_role_assignments_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE database_role_assignments
+    ADD CONSTRAINT fk_database_role_assignments_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE database_role_assignments
+    ADD CONSTRAINT fk_database_role_assignments_custom_role_id
+    FOREIGN KEY (custom_role_id)
+    REFERENCES workspace_custom_roles (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE database_role_assignments
+    ADD CONSTRAINT uk_database_role_assignments_database_user
+    UNIQUE (database_id, user_id);
+
+CREATE INDEX idx_database_role_assignments_user_id ON database_role_assignments (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_database_role_assignments_user_id;
+
+ALTER TABLE database_role_assignments DROP CONSTRAINT IF EXISTS uk_database_role_assignments_database_user;
+ALTER TABLE database_role_assignments DROP CONSTRAINT IF EXISTS fk_database_role_assignments_custom_role_id;
+ALTER TABLE database_role_assignments DROP CONSTRAINT IF EXISTS fk_database_role_assignments_user_id;
+ALTER TABLE database_role_assignments DROP CONSTRAINT IF EXISTS fk_database_role_assignments_database_id;
+ALTER TABLE database_role_assignments DROP CONSTRAINT IF EXISTS fk_database_role_assignments_workspace_id;
+
+DROP TABLE IF EXISTS database_role_assignments;
+
+ALTER TABLE workspace_memberships DROP CONSTRAINT IF EXISTS fk_workspace_memberships_custom_role_id;
+
+ALTER TABLE workspace_memberships
+    DROP COLUMN IF EXISTS custom_role_id;
+
+DROP INDEX IF EXISTS idx_workspace_custom_roles_workspace_id;
+
+ALTER TABLE workspace_custom_roles DROP CONSTRAINT IF EXISTS fk_workspace_custom_roles_workspace_id;
+
+DROP TABLE IF EXISTS workspace_custom_roles;
+
+-- +goose StatementEnd
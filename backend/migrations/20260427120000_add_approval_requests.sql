@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE approval_requests (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id         UUID NOT NULL,
+    database_id          UUID NOT NULL,
+    backup_id            UUID NOT NULL,
+    action               TEXT NOT NULL,
+    status               TEXT NOT NULL,
+    summary              TEXT NOT NULL,
+    payload              TEXT NOT NULL,
+    requested_by_user_id UUID NOT NULL,
+    decided_by_user_id   UUID,
+    decision_comment     TEXT,
+    fail_message         TEXT,
+    expires_at           TIMESTAMPTZ NOT NULL,
+    decided_at           TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE approval_requests
+    ADD CONSTRAINT fk_approval_requests_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE approval_requests
+    ADD CONSTRAINT fk_approval_requests_database_id
+    FOREIGN KEY (database_id)
+    REFERENCES databases (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE approval_requests
+    ADD CONSTRAINT fk_approval_requests_requested_by_user_id
+    FOREIGN KEY (requested_by_user_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_approval_requests_workspace_id ON approval_requests (workspace_id, created_at DESC);
+CREATE INDEX idx_approval_requests_status ON approval_requests (status, expires_at);
+
+ALTER TABLE backup_configs
+    ADD COLUMN is_restore_approval_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE backup_configs
+    ADD COLUMN is_backup_deletion_approval_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS is_backup_deletion_approval_required;
+
+ALTER TABLE backup_configs
+    DROP COLUMN IF EXISTS is_restore_approval_required;
+
+DROP INDEX IF EXISTS idx_approval_requests_status;
+DROP INDEX IF EXISTS idx_approval_requests_workspace_id;
+
+ALTER TABLE approval_requests DROP CONSTRAINT IF EXISTS fk_approval_requests_requested_by_user_id;
+ALTER TABLE approval_requests DROP CONSTRAINT IF EXISTS fk_approval_requests_database_id;
+ALTER TABLE approval_requests DROP CONSTRAINT IF EXISTS fk_approval_requests_workspace_id;
+
+DROP TABLE IF EXISTS approval_requests;
+
+-- +goose StatementEnd
@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- logs are hashed, so they must not be changed by removal of the user
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS fk_audit_logs_user_id;
+
+ALTER TABLE audit_logs
+    ADD COLUMN action TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs
+    ADD COLUMN resource_type TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs
+    ADD COLUMN resource_id UUID;
+
+ALTER TABLE audit_logs
+    ADD COLUMN source_ip TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs
+    ADD COLUMN changes TEXT;
+
+ALTER TABLE audit_logs
+    ADD COLUMN sequence BIGINT;
+
+ALTER TABLE audit_logs
+    ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_logs
+    ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+-- existing logs keep empty hash, the chain starts from the first new log
+UPDATE audit_logs
+SET sequence = numbered.row_number
+FROM (
+    SELECT id, row_number() OVER (ORDER BY created_at, id) AS row_number
+    FROM audit_logs
+) AS numbered
+WHERE audit_logs.id = numbered.id;
+
+ALTER TABLE audit_logs
+    ALTER COLUMN sequence SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_audit_logs_sequence ON audit_logs (sequence);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_resource ON audit_logs (resource_type, resource_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_sequence;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS sequence;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS changes;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS source_ip;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS resource_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS resource_type;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS action;
+
+ALTER TABLE audit_logs
+    ADD CONSTRAINT fk_audit_logs_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL;
+
+-- +goose StatementEnd
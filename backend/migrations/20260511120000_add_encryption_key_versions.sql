@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- NULL means the file was encrypted by the legacy master key, created
+-- before keys were versioned
+ALTER TABLE backups
+    ADD COLUMN encryption_key_version INT;
+
+ALTER TABLE wal_segments
+    ADD COLUMN encryption_key_version INT;
+
+ALTER TABLE binlog_files
+    ADD COLUMN encryption_key_version INT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE binlog_files
+    DROP COLUMN encryption_key_version;
+
+ALTER TABLE wal_segments
+    DROP COLUMN encryption_key_version;
+
+ALTER TABLE backups
+    DROP COLUMN encryption_key_version;
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE notification_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    notifier_id     UUID NOT NULL,
+    title           TEXT NOT NULL,
+    message         TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    response_code   INT,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_attempt_at TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notification_deliveries
+    ADD CONSTRAINT fk_notification_deliveries_notifier_id
+    FOREIGN KEY (notifier_id)
+    REFERENCES notifiers (id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_notification_deliveries_notifier_id ON notification_deliveries (notifier_id, created_at DESC);
+CREATE INDEX idx_notification_deliveries_status ON notification_deliveries (status, next_attempt_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_notification_deliveries_status;
+DROP INDEX IF EXISTS idx_notification_deliveries_notifier_id;
+
+ALTER TABLE notification_deliveries DROP CONSTRAINT IF EXISTS fk_notification_deliveries_notifier_id;
+
+DROP TABLE IF EXISTS notification_deliveries;
+
+-- +goose StatementEnd
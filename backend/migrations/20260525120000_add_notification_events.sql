@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE notifiers
+    ADD COLUMN message_template TEXT;
+
+ALTER TABLE notification_deliveries
+    ADD COLUMN event_type TEXT;
+
+ALTER TABLE notification_deliveries
+    ADD COLUMN event TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE notification_deliveries
+    DROP COLUMN IF EXISTS event;
+
+ALTER TABLE notification_deliveries
+    DROP COLUMN IF EXISTS event_type;
+
+ALTER TABLE notifiers
+    DROP COLUMN IF EXISTS message_template;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE incident_notifiers (
+    notifier_id UUID PRIMARY KEY,
+    events_url  TEXT NOT NULL DEFAULT '',
+    routing_key TEXT NOT NULL,
+    severity    TEXT NOT NULL
+);
+
+ALTER TABLE incident_notifiers
+    ADD CONSTRAINT fk_incident_notifiers_notifier
+    FOREIGN KEY (notifier_id)
+    REFERENCES notifiers (id)
+    ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS incident_notifiers;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE notifiers
+    ADD COLUMN rules TEXT;
+
+ALTER TABLE databases
+    ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE notifier_alert_states (
+    notifier_id      UUID NOT NULL,
+    database_id      UUID NOT NULL,
+    class            TEXT NOT NULL,
+    suppressed_count INT NOT NULL DEFAULT 0,
+    failed_at        TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (notifier_id, database_id, class)
+);
+
+ALTER TABLE notifier_alert_states
+    ADD CONSTRAINT fk_notifier_alert_states_notifier_id
+    FOREIGN KEY (notifier_id)
+    REFERENCES notifiers (id)
+    ON DELETE CASCADE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE notifier_alert_states DROP CONSTRAINT IF EXISTS fk_notifier_alert_states_notifier_id;
+
+DROP TABLE IF EXISTS notifier_alert_states;
+
+ALTER TABLE databases
+    DROP COLUMN IF EXISTS tags;
+
+ALTER TABLE notifiers
+    DROP COLUMN IF EXISTS rules;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE report_configs (
+    workspace_id       UUID PRIMARY KEY,
+    is_enabled         BOOLEAN NOT NULL DEFAULT FALSE,
+    report_interval_id UUID,
+    notifier_ids       TEXT NOT NULL DEFAULT '',
+    last_sent_at       TIMESTAMPTZ
+);
+
+ALTER TABLE report_configs
+    ADD CONSTRAINT fk_report_configs_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id)
+    ON DELETE CASCADE;
+
+ALTER TABLE report_configs
+    ADD CONSTRAINT fk_report_configs_report_interval_id
+    FOREIGN KEY (report_interval_id)
+    REFERENCES intervals (id)
+    ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS report_configs;
+
+-- +goose StatementEnd
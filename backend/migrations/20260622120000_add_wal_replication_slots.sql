@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- no foreign key to databases, the slot must be dropped on the server even
+-- after the database is removed from Postgresus
+CREATE TABLE wal_replication_slots (
+    database_id UUID PRIMARY KEY,
+    version     TEXT        NOT NULL,
+    host        TEXT        NOT NULL,
+    port        INT         NOT NULL,
+    username    TEXT        NOT NULL,
+    password    TEXT        NOT NULL,
+    is_https    BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- slots could be created for every physical backup config before they were
+-- tracked, dropping a missing slot is a no-op
+INSERT INTO wal_replication_slots (database_id, version, host, port, username, password, is_https)
+SELECT postgresql_databases.database_id,
+       postgresql_databases.version,
+       postgresql_databases.host,
+       postgresql_databases.port,
+       postgresql_databases.username,
+       postgresql_databases.password,
+       postgresql_databases.is_https
+FROM backup_configs
+JOIN postgresql_databases ON postgresql_databases.database_id = backup_configs.database_id
+WHERE backup_configs.backup_type = 'PHYSICAL';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS wal_replication_slots;
+
+-- +goose StatementEnd
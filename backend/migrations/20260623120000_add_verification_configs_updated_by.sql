@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE verification_configs
+    ADD COLUMN updated_by_user_id UUID;
+
+ALTER TABLE verification_configs
+    ADD CONSTRAINT fk_verification_configs_updated_by_user_id
+    FOREIGN KEY (updated_by_user_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE verification_configs
+    DROP CONSTRAINT IF EXISTS fk_verification_configs_updated_by_user_id;
+
+ALTER TABLE verification_configs
+    DROP COLUMN IF EXISTS updated_by_user_id;
+
+-- +goose StatementEnd
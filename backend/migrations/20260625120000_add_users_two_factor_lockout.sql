@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN two_factor_failed_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN two_factor_locked_until    TIMESTAMPTZ;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS two_factor_locked_until,
+    DROP COLUMN IF EXISTS two_factor_failed_attempts;
+
+-- +goose StatementEnd
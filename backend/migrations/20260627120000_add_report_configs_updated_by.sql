@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE report_configs
+    ADD COLUMN updated_by_user_id UUID;
+
+ALTER TABLE report_configs
+    ADD CONSTRAINT fk_report_configs_updated_by_user_id
+    FOREIGN KEY (updated_by_user_id)
+    REFERENCES users (id)
+    ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE report_configs
+    DROP CONSTRAINT IF EXISTS fk_report_configs_updated_by_user_id;
+
+ALTER TABLE report_configs
+    DROP COLUMN IF EXISTS updated_by_user_id;
+
+-- +goose StatementEnd
@@ -145,6 +145,7 @@ for version in $mysql_versions; do
         # Copy client binaries
         cp "$EXTRACTED_DIR/bin/mysql" "$version_dir/bin/" 2>/dev/null || true
         cp "$EXTRACTED_DIR/bin/mysqldump" "$version_dir/bin/" 2>/dev/null || true
+        cp "$EXTRACTED_DIR/bin/mysqlbinlog" "$version_dir/bin/" 2>/dev/null || true
         chmod +x "$version_dir/bin/"*
         
         echo "  MySQL $version client tools installed successfully"
@@ -216,6 +217,7 @@ for version in $mariadb_versions; do
     if [ -d "$EXTRACTED_DIR" ] && [ -f "$EXTRACTED_DIR/bin/mariadb-dump" ]; then
         cp "$EXTRACTED_DIR/bin/mariadb" "$version_dir/bin/" 2>/dev/null || true
         cp "$EXTRACTED_DIR/bin/mariadb-dump" "$version_dir/bin/" 2>/dev/null || true
+        cp "$EXTRACTED_DIR/bin/mariadb-binlog" "$version_dir/bin/" 2>/dev/null || true
         chmod +x "$version_dir/bin/"*
         echo "  MariaDB $version client tools installed successfully"
     else
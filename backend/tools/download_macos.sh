@@ -185,6 +185,7 @@ install_mysql_client() {
         # Copy client binaries
         cp "$EXTRACTED_DIR/bin/mysql" "$version_dir/bin/" 2>/dev/null || true
         cp "$EXTRACTED_DIR/bin/mysqldump" "$version_dir/bin/" 2>/dev/null || true
+        cp "$EXTRACTED_DIR/bin/mysqlbinlog" "$version_dir/bin/" 2>/dev/null || true
         chmod +x "$version_dir/bin/"*
         
         echo "  MySQL $version client tools installed successfully"
@@ -273,6 +274,7 @@ for version in $mariadb_versions; do
         # The Homebrew version (latest) should handle both old and new servers
         ln -sf "$BREW_MARIADB/mariadb" "$version_dir/bin/mariadb"
         ln -sf "$BREW_MARIADB/mariadb-dump" "$version_dir/bin/mariadb-dump"
+        ln -sf "$BREW_MARIADB/mariadb-binlog" "$version_dir/bin/mariadb-binlog"
         echo "  MariaDB $version client tools linked from Homebrew"
         
         # Test the installation
@@ -177,6 +177,7 @@ for %%v in (%mysql_versions%) do (
                 mkdir "!install_dir!\bin" 2>nul
                 copy "%%d\bin\mysql.exe" "!install_dir!\bin\" >nul 2>&1
                 copy "%%d\bin\mysqldump.exe" "!install_dir!\bin\" >nul 2>&1
+                copy "%%d\bin\mysqlbinlog.exe" "!install_dir!\bin\" >nul 2>&1
             )
         )
         
@@ -269,6 +270,7 @@ for %%v in (%mariadb_versions%) do (
             if exist "%%d\bin\mariadb-dump.exe" (
                 copy "%%d\bin\mariadb.exe" "!mariadb_install_dir!\bin\" >nul 2>&1
                 copy "%%d\bin\mariadb-dump.exe" "!mariadb_install_dir!\bin\" >nul 2>&1
+                copy "%%d\bin\mariadb-binlog.exe" "!mariadb_install_dir!\bin\" >nul 2>&1
             )
         )
         
@@ -17,9 +17,15 @@ import type { UserProfile } from '../model/UserProfile';
 
 const listeners: (() => void)[] = [];
 
-const saveAuthorizedData = (accessToken: string, userId: string) => {
+const saveAuthorizedData = (accessToken: string, userId: string, refreshToken?: string) => {
   accessTokenHelper.saveAccessToken(accessToken);
   accessTokenHelper.saveUserId(userId);
+
+  if (refreshToken) {
+    accessTokenHelper.saveRefreshToken(refreshToken);
+  } else {
+    accessTokenHelper.cleanRefreshToken();
+  }
 };
 
 const notifyAuthListeners = () => {
@@ -43,7 +49,7 @@ export const userApi = {
       .fetchPostJson(`${getApplicationServer()}/api/v1/users/signin`, requestOptions)
       .then((response: unknown): SignInResponse => {
         const typedResponse = response as SignInResponse;
-        saveAuthorizedData(typedResponse.token, typedResponse.userId);
+        saveAuthorizedData(typedResponse.token, typedResponse.userId, typedResponse.refreshToken);
         notifyAuthListeners();
         return typedResponse;
       });
@@ -114,7 +120,7 @@ export const userApi = {
       .fetchPostJson(`${getApplicationServer()}/api/v1/auth/github/callback`, requestOptions)
       .then((response: unknown): OAuthCallbackResponse => {
         const typedResponse = response as OAuthCallbackResponse;
-        saveAuthorizedData(typedResponse.token, typedResponse.userId);
+        saveAuthorizedData(typedResponse.token, typedResponse.userId, typedResponse.refreshToken);
         notifyAuthListeners();
         return typedResponse;
       });
@@ -128,7 +134,7 @@ export const userApi = {
       .fetchPostJson(`${getApplicationServer()}/api/v1/auth/google/callback`, requestOptions)
       .then((response: unknown): OAuthCallbackResponse => {
         const typedResponse = response as OAuthCallbackResponse;
-        saveAuthorizedData(typedResponse.token, typedResponse.userId);
+        saveAuthorizedData(typedResponse.token, typedResponse.userId, typedResponse.refreshToken);
         notifyAuthListeners();
         return typedResponse;
       });
@@ -138,6 +144,7 @@ export const userApi = {
 
   logout: () => {
     accessTokenHelper.cleanAccessToken();
+    accessTokenHelper.cleanRefreshToken();
   },
 
   // listeners
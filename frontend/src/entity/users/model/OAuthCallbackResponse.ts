@@ -2,5 +2,7 @@ export interface OAuthCallbackResponse {
   userId: string;
   email: string;
   token: string;
+  refreshToken: string;
+  expiresAt: string;
   isNewUser: boolean;
 }
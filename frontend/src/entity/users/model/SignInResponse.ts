@@ -1,4 +1,6 @@
 export interface SignInResponse {
   userId: string;
   token: string;
+  refreshToken: string;
+  expiresAt: string;
 }
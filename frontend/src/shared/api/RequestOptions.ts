@@ -32,6 +32,15 @@ export default class RequestOptions {
     return this;
   }
 
+  setHeader(headerName: string, headerValue?: string): RequestOptions {
+    this.headers = this.headers.filter(([name]) => name !== headerName);
+    return this.addHeader(headerName, headerValue);
+  }
+
+  getHeader(headerName: string): string | undefined {
+    return this.headers.find(([name]) => name === headerName)?.[1];
+  }
+
   toRequestInit(): RequestInit {
     // Example:
     //
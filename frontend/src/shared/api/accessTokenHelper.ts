@@ -1,5 +1,6 @@
 const AUTHORIED_USER_TOKEN_KEY = 'postgresus_user_token';
 const AUTHORIED_USER_ID_KEY = 'postgresus_user_id';
+const AUTHORIED_USER_REFRESH_TOKEN_KEY = 'postgresus_user_refresh_token';
 
 export const accessTokenHelper = {
   saveAccessToken: (token: string) => {
@@ -26,6 +27,30 @@ export const accessTokenHelper = {
     localStorage.removeItem(AUTHORIED_USER_TOKEN_KEY);
   },
 
+  saveRefreshToken: (refreshToken: string) => {
+    if (typeof localStorage === 'undefined') {
+      return;
+    }
+
+    localStorage.setItem(AUTHORIED_USER_REFRESH_TOKEN_KEY, refreshToken);
+  },
+
+  getRefreshToken: (): string | undefined => {
+    if (typeof localStorage === 'undefined') {
+      return;
+    }
+
+    return localStorage.getItem(AUTHORIED_USER_REFRESH_TOKEN_KEY) || undefined;
+  },
+
+  cleanRefreshToken: () => {
+    if (typeof localStorage === 'undefined') {
+      return;
+    }
+
+    localStorage.removeItem(AUTHORIED_USER_REFRESH_TOKEN_KEY);
+  },
+
   saveUserId: (id: string) => {
     if (typeof localStorage === 'undefined') {
       return;
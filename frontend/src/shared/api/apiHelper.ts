@@ -1,9 +1,67 @@
 import { accessTokenHelper } from '.';
+import { getApplicationServer } from '../../constants';
 import RequestOptions from './RequestOptions';
 
 const REPEAT_TRIES_COUNT = 10;
 const REPEAT_INTERVAL_MS = 3_000;
 
+let refreshPromise: Promise<boolean> | undefined;
+
+const requestNewAccessToken = async (): Promise<boolean> => {
+  const refreshToken = accessTokenHelper.getRefreshToken();
+
+  // tokens issued before refresh tokens were introduced can not be refreshed
+  if (!refreshToken) {
+    return false;
+  }
+
+  const response = await fetch(`${getApplicationServer()}/api/v1/users/refresh`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', Accept: 'application/json' },
+    body: JSON.stringify({ refreshToken }),
+    cache: 'no-cache',
+  });
+
+  // unavailable server must not sign the user out, the request is retried
+  if (response.status >= 500) {
+    throw new Error('failed to refresh access token');
+  }
+
+  if (!response.ok) {
+    return false;
+  }
+
+  const json = (await response.json()) as { token: string; refreshToken: string };
+  accessTokenHelper.saveAccessToken(json.token);
+  accessTokenHelper.saveRefreshToken(json.refreshToken);
+
+  return true;
+};
+
+/**
+ * Returns true when the request rejected with the expired token can be repeated
+ * with the new one. Requests failed at the same time share a single refresh,
+ * because the refresh token is rotated on every use
+ */
+const refreshAccessToken = async (expiredToken?: string): Promise<boolean> => {
+  if (!expiredToken) {
+    return false;
+  }
+
+  const currentToken = accessTokenHelper.getAccessToken();
+  if (currentToken && currentToken !== expiredToken) {
+    return true;
+  }
+
+  if (!refreshPromise) {
+    refreshPromise = requestNewAccessToken().finally(() => {
+      refreshPromise = undefined;
+    });
+  }
+
+  return refreshPromise;
+};
+
 const handleOrThrowMessageIfResponseError = async (
   url: string,
   response: Response,
@@ -11,6 +69,7 @@ const handleOrThrowMessageIfResponseError = async (
 ) => {
   if (handleNotAuthorizedError && response.status === 401) {
     accessTokenHelper?.cleanAccessToken();
+    accessTokenHelper?.cleanRefreshToken();
     window.location.reload();
   }
 
@@ -42,7 +101,17 @@ const makeRequest = async (
   currentTry = 0,
 ): Promise<Response> => {
   try {
-    const response = await fetch(url, optionsWrapper.toRequestInit());
+    let response = await fetch(url, optionsWrapper.toRequestInit());
+
+    // access token lives for minutes, so it is refreshed and the request repeated
+    if (
+      response.status === 401 &&
+      (await refreshAccessToken(optionsWrapper.getHeader('Authorization')))
+    ) {
+      optionsWrapper.setHeader('Authorization', accessTokenHelper.getAccessToken());
+      response = await fetch(url, optionsWrapper.toRequestInit());
+    }
+
     await handleOrThrowMessageIfResponseError(url, response);
     return response;
   } catch (e) {